@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,6 +18,12 @@ import (
 
 	"github.com/LederWorks/siros/backend/internal/api"
 	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/ingest"
+	"github.com/LederWorks/siros/backend/internal/mcp"
+	"github.com/LederWorks/siros/backend/internal/providers"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+	"github.com/LederWorks/siros/backend/internal/services"
 	"github.com/LederWorks/siros/backend/internal/storage"
 )
 
@@ -32,18 +39,63 @@ var webAssets embed.FS
 
 // App holds the application dependencies
 type App struct {
-	logger *log.Logger
-	config *config.Config
-	db     *sql.DB
-	server *api.Server
+	logger    *log.Logger
+	config    *config.Config
+	db        *sql.DB
+	repos     *repositories.Repositories
+	providers *providers.Manager
+	server    *api.Server
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		if err := runMCP(os.Args[2:]); err != nil {
+			log.Fatalf("MCP server failed: %v", err)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
 		log.Fatalf("Application failed: %v", err)
 	}
 }
 
+// runMCP serves the Model Context Protocol over stdio for clients that
+// start Siros as a subprocess. Logs go to stderr, as stdout carries the
+// protocol.
+func runMCP(args []string) error {
+	flags := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	stdio := flags.Bool("stdio", false, "serve MCP over standard input and output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*stdio {
+		return fmt.Errorf("siros-server mcp requires --stdio; the HTTP transport is served at /api/v1/mcp")
+	}
+
+	logger := log.New(os.Stderr, "siros-mcp: ", log.LstdFlags)
+
+	cfg, err := config.Load("config.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	db, err := connectDB(&cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	svc := services.NewServices(repositories.NewRepositories(db, logger), nil, logger)
+	return mcp.NewServer(svc.MCP, logger).ServeStdio(ctx, os.Stdin, os.Stdout)
+}
+
 func run() error {
 	// Initialize logger
 	logger := log.New(os.Stdout, "siros: ", log.LstdFlags|log.Lshortfile)
@@ -74,21 +126,62 @@ func run() error {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	// Initialize API server
-	server := api.NewServer(cfg, storageInstance, webAssets, logger)
+	// Register the configured providers, shared by scans and ingestion
+	manager := providers.NewManager(&cfg.Providers)
+	if err := manager.RegisterConfigured(); err != nil {
+		return fmt.Errorf("failed to register providers: %w", err)
+	}
+	defer func() {
+		if err := manager.Close(); err != nil {
+			logger.Printf("Error closing providers: %v", err)
+		}
+	}()
+
+	// Initialize services and the API server
+	repos := repositories.NewRepositories(db, logger)
+	svc := services.NewServices(repos, manager, logger)
+	server := api.NewServer(cfg, storageInstance, svc, webAssets, logger)
 
 	// Create app instance
 	app := &App{
-		logger: logger,
-		config: cfg,
-		db:     db,
-		server: server,
+		logger:    logger,
+		config:    cfg,
+		db:        db,
+		repos:     repos,
+		providers: manager,
+		server:    server,
+	}
+
+	// Start audit event ingestion
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.Ingest.Enabled {
+		app.startIngest(ctx)
 	}
 
 	// Start server
 	return app.startServer()
 }
 
+// startIngest starts watching the ingest spool directory for audit events
+// until ctx is canceled
+func (app *App) startIngest(ctx context.Context) {
+	repos := app.repos
+	resolver := identity.NewResolver(repos.Identity)
+	ingester := ingest.NewIngester(app.providers, services.NewResourceSink(repos.Resource, resolver), resolver, repos.Resource, repos.Blockchain, app.logger)
+
+	interval := time.Duration(app.config.Ingest.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	go func() {
+		if err := ingester.Watch(ctx, app.config.Ingest.SpoolDir, interval); err != nil {
+			app.logger.Printf("Audit event ingestion stopped: %v", err)
+		}
+	}()
+	app.logger.Printf("Watching %s for audit events", app.config.Ingest.SpoolDir)
+}
+
 func connectDB(cfg *config.DatabaseConfig) (*sql.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.SSLMode)
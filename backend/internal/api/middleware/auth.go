@@ -117,6 +117,35 @@ func OptionalAuthMiddleware() Middleware {
 	}
 }
 
+// TrustedUserMiddleware authenticates requests as the user named by a header
+// that an authenticating proxy in front of Siros sets. The header is trusted
+// as is; requests without it are not authenticated.
+func TrustedUserMiddleware(header string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user := strings.TrimSpace(r.Header.Get(header)); user != "" {
+				r = r.WithContext(WithUser(r.Context(), user))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithUser returns a copy of ctx carrying the authenticated user, for
+// authentication middleware to set
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, contextKeyUser, user)
+}
+
+// GetUser returns the authenticated user of a request, or "" when the
+// request is not authenticated
+func GetUser(r *http.Request) string {
+	if user, ok := r.Context().Value(contextKeyUser).(string); ok {
+		return user
+	}
+	return ""
+}
+
 // writeAuthError writes a standardized authentication error response
 func writeAuthError(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedUserMiddleware(t *testing.T) {
+	var user string
+	handler := TrustedUserMiddleware("X-Forwarded-User")(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		user = GetUser(r)
+	}))
+
+	for _, tc := range []struct {
+		header, value, want string
+	}{
+		{"X-Forwarded-User", "alice", "alice"},
+		{"X-Forwarded-User", " ", ""},
+		{"X-User", "mallory", ""},
+	} {
+		req := httptest.NewRequest("POST", "/api/v1/proposals/p1/approve", http.NoBody)
+		req.Header.Set(tc.header, tc.value)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if user != tc.want {
+			t.Errorf("%s: %q authenticated %q, want %q", tc.header, tc.value, user, tc.want)
+		}
+	}
+}
@@ -19,6 +19,7 @@ func CORSMiddleware() Middleware {
 			"Authorization",
 			"X-Requested-With",
 			"X-Request-ID",
+			"X-User",
 		},
 		ExposedHeaders: []string{
 			"X-Request-ID",
@@ -54,6 +55,7 @@ func DefaultCORSConfig() *CORSConfig {
 			"Authorization",
 			"X-Requested-With",
 			"X-Request-ID",
+			"X-User",
 		},
 		ExposedHeaders: []string{
 			"X-Request-ID",
@@ -34,12 +34,17 @@ func LoggingMiddleware(logger *log.Logger) Middleware {
 	}
 }
 
-// RecoveryMiddleware recovers from panics and returns a 500 error
+// RecoveryMiddleware recovers from panics and returns a 500 error.
+// http.ErrAbortHandler is re-panicked so the server aborts the response, as
+// handlers that already streamed part of a body intend.
 func RecoveryMiddleware(logger *log.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
 					requestID, _ := r.Context().Value("requestID").(string)
 
 					logger.Printf("[%s] PANIC: %v\n%s", requestID, err, debug.Stack())
@@ -67,3 +72,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap returns the wrapped writer, so that http.ResponseController can
+// flush streamed responses
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
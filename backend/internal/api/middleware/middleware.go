@@ -12,7 +12,10 @@ type Config struct {
 	EnableCORS    bool
 	EnableLogging bool
 	EnableAuth    bool
-	Logger        *log.Logger
+	// UserHeader names the trusted header authenticating users; empty
+	// leaves requests unauthenticated
+	UserHeader string
+	Logger     *log.Logger
 }
 
 // DefaultConfig returns a default middleware configuration
@@ -43,6 +46,9 @@ func (c *Config) Apply(router *mux.Router) http.Handler {
 	}
 
 	// Authentication middleware
+	if c.UserHeader != "" {
+		handler = TrustedUserMiddleware(c.UserHeader)(handler)
+	}
 	if c.EnableAuth {
 		handler = AuthMiddleware()(handler)
 	}
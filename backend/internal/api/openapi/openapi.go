@@ -0,0 +1,98 @@
+// Package openapi describes an HTTP API as an OpenAPI 3.1 document, whose
+// schemas are generated from the Go types the API encodes and decodes.
+package openapi
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.1.0"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations of a path, by lowercase HTTP method
+type PathItem map[string]*Operation
+
+// Operation is a single API operation on a path
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter of an operation
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Schema      Schema `json:"schema"`
+}
+
+// RequestBody is the body an operation accepts
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation, or a reference to a response
+// among the components
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in one media type
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+// Components holds the schemas and responses operations refer to
+type Components struct {
+	Schemas   map[string]Schema    `json:"schemas,omitempty"`
+	Responses map[string]*Response `json:"responses,omitempty"`
+}
+
+// Schema is a JSON Schema, as OpenAPI 3.1 uses draft 2020-12
+type Schema map[string]interface{}
+
+// Ref returns a schema referring to the component schema of the name
+func Ref(name string) Schema {
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+// Nullable returns the schema also admitting null
+func Nullable(schema Schema) Schema {
+	if len(schema) == 0 {
+		return schema
+	}
+	if t, ok := schema["type"].(string); ok {
+		nullable := make(Schema, len(schema))
+		for keyword, value := range schema {
+			nullable[keyword] = value
+		}
+		nullable["type"] = []string{t, "null"}
+		return nullable
+	}
+	return Schema{"anyOf": []Schema{schema, {"type": "null"}}}
+}
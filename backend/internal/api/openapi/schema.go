@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Schemas generates the schemas of Go types as encoding/json encodes them.
+// Named struct types become component schemas that the generated schemas
+// refer to, so recursive types are described once.
+type Schemas struct {
+	components map[string]Schema
+	names      map[reflect.Type]string
+}
+
+// NewSchemas creates an empty set of component schemas
+func NewSchemas() *Schemas {
+	return &Schemas{
+		components: map[string]Schema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// Components returns the component schemas of the types generated so far
+func (s *Schemas) Components() map[string]Schema {
+	return s.components
+}
+
+// For returns the schema of values of type t. Fields that encode as null,
+// the nil pointers, slices and maps without omitempty, admit null; fields
+// without omitempty are required.
+func (s *Schemas) For(t reflect.Type) Schema {
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return Schema{}
+	case t.Kind() != reflect.Pointer && t.Implements(jsonMarshalerType):
+		return Schema{}
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalerType):
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Pointer:
+		return s.For(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": s.For(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.For(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return Ref(s.component(t))
+	}
+	// Interfaces hold any value
+	return Schema{}
+}
+
+// component returns the name of the component schema of a named struct,
+// generating it on first use
+func (s *Schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	s.names[t] = name
+	s.components[name] = Schema{}
+	s.components[name] = s.object(t)
+	return name
+}
+
+// object returns the schema of a struct's fields
+func (s *Schemas) object(t reflect.Type) Schema {
+	properties := map[string]Schema{}
+	var required []string
+	s.fields(t, properties, &required)
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields adds the properties of a struct's fields, flattening embedded
+// structs as encoding/json does
+func (s *Schemas) fields(t reflect.Type, properties map[string]Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				s.fields(fieldType, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		omitempty := hasOption(options, "omitempty")
+		schema := s.For(fieldType)
+		if hasOption(options, "string") {
+			schema = Schema{"type": "string"}
+		}
+		switch fieldType.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			if !omitempty {
+				schema = Nullable(schema)
+			}
+		}
+
+		properties[name] = schema
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// hasOption reports whether a json tag's options include option
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
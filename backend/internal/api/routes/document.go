@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/LederWorks/siros/backend/internal/api/openapi"
+	"github.com/LederWorks/siros/backend/internal/views"
+)
+
+// pathVariable matches the mux variables of a route path
+var pathVariable = regexp.MustCompile(`\{([^}:]+)\}`)
+
+// Document generates the OpenAPI document of routes
+func Document(routes []Route) *openapi.Document {
+	schemas := openapi.NewSchemas()
+	envelope := schemas.For(reflect.TypeFor[views.APIResponse]())
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "Siros API",
+			Description: "Multi-cloud resource platform",
+			Version:     "1.0.0",
+		},
+		Paths: map[string]openapi.PathItem{},
+		Components: openapi.Components{
+			Responses: map[string]*openapi.Response{
+				"Error": {
+					Description: "Error",
+					Content: map[string]openapi.MediaType{
+						"application/json": {Schema: openapi.Schema{"allOf": []openapi.Schema{envelope}, "required": []string{"error"}}},
+					},
+				},
+			},
+		},
+	}
+
+	tags := map[string]bool{}
+	for _, route := range routes {
+		path := APIPrefix + route.Path
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = openapi.PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation(route, schemas, envelope)
+
+		if !tags[route.Tag] {
+			tags[route.Tag] = true
+			doc.Tags = append(doc.Tags, openapi.Tag{Name: route.Tag})
+		}
+	}
+
+	doc.Components.Schemas = schemas.Components()
+	return doc
+}
+
+// operation describes a route
+func operation(route Route, schemas *openapi.Schemas, envelope openapi.Schema) *openapi.Operation {
+	op := &openapi.Operation{
+		OperationID: route.ID,
+		Summary:     route.Summary,
+		Tags:        []string{route.Tag},
+		Responses:   map[string]*openapi.Response{},
+	}
+
+	for _, match := range pathVariable.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   openapi.Schema{"type": "string"},
+		})
+	}
+	for _, param := range route.Query {
+		schema := openapi.Schema{"type": "string"}
+		if param.Type != "" {
+			schema["type"] = param.Type
+		}
+		if param.Format != "" {
+			schema["format"] = param.Format
+		}
+		if len(param.Enum) > 0 {
+			schema["enum"] = param.Enum
+		}
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Schema:      schema,
+		})
+	}
+
+	if route.Body != nil || route.BodyMedia != nil {
+		body := &openapi.RequestBody{Required: !route.BodyOptional, Content: map[string]openapi.MediaType{}}
+		if route.Body != nil {
+			body.Content["application/json"] = openapi.MediaType{Schema: schemas.For(route.Body)}
+		}
+		for media, schema := range route.BodyMedia {
+			body.Content[media] = openapi.MediaType{Schema: schema}
+		}
+		op.RequestBody = body
+	}
+
+	success := &openapi.Response{Description: http.StatusText(route.Status)}
+	switch {
+	case route.Media != nil:
+		success.Content = mediaTypes(route.Media)
+	case route.Data != nil:
+		success.Content = map[string]openapi.MediaType{
+			"application/json": {Schema: openapi.Schema{
+				"allOf":      []openapi.Schema{envelope},
+				"properties": map[string]openapi.Schema{"data": schemas.For(route.Data)},
+				"required":   []string{"data"},
+			}},
+		}
+	}
+	op.Responses[strconv.Itoa(route.Status)] = success
+	for status, description := range route.Extra {
+		op.Responses[strconv.Itoa(status)] = &openapi.Response{Description: description}
+	}
+
+	if route.Errors != nil {
+		op.Responses["default"] = &openapi.Response{Description: "Error", Content: mediaTypes(route.Errors)}
+	} else {
+		op.Responses["default"] = &openapi.Response{Ref: "#/components/responses/Error"}
+	}
+	return op
+}
+
+// mediaTypes returns the content of schemas by media type
+func mediaTypes(schemas map[string]openapi.Schema) map[string]openapi.MediaType {
+	content := make(map[string]openapi.MediaType, len(schemas))
+	for media, schema := range schemas {
+		content[media] = openapi.MediaType{Schema: schema}
+	}
+	return content
+}
+
+// serveDocument serves the OpenAPI document of the routes, generated when
+// first requested
+func serveDocument(routes *[]Route) http.HandlerFunc {
+	var once sync.Once
+	var body []byte
+	var err error
+	return func(w http.ResponseWriter, _ *http.Request) {
+		once.Do(func() {
+			body, err = json.Marshal(Document(*routes))
+		})
+		if err != nil {
+			views.WriteInternalError(w, "Failed to generate the OpenAPI document", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
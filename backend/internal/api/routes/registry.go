@@ -0,0 +1,235 @@
+package routes
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/LederWorks/siros/backend/internal/api/openapi"
+	"github.com/LederWorks/siros/backend/internal/controllers"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/terraform"
+)
+
+// Route is an API operation: the handler serving it and the contract the
+// OpenAPI document publishes for it. Unless Media says otherwise, responses
+// are views.APIResponse envelopes and errors are described by their
+// envelope.
+type Route struct {
+	Method  string
+	Path    string // below APIPrefix, with mux variables such as {id}
+	Handler http.HandlerFunc
+
+	ID      string // operationId
+	Summary string
+	Tag     string
+	Query   []Param
+
+	Body         reflect.Type              // JSON request body
+	BodyMedia    map[string]openapi.Schema // request body in other media types
+	BodyOptional bool
+
+	Status int                       // status of a successful response
+	Data   reflect.Type              // data of the success envelope; nil for no content
+	Media  map[string]openapi.Schema // success responses that are not envelopes
+	Errors map[string]openapi.Schema // error responses that are not envelopes
+	Extra  map[int]string            // further statuses, without content, by description
+}
+
+// Param is a query parameter
+type Param struct {
+	Name        string
+	Description string
+	Type        string // JSON type of the value; string when empty
+	Format      string
+	Enum        []string
+}
+
+var (
+	object = reflect.TypeFor[map[string]interface{}]()
+	text   = openapi.Schema{"type": "string"}
+)
+
+// pageParams are the query parameters of cursor-paginated listings
+var pageParams = []Param{
+	{Name: "cursor", Description: "Cursor of the page to return, from next_cursor or prev_cursor"},
+	{Name: "limit", Type: "integer", Description: "Maximum number of items on the page"},
+	{Name: "total", Enum: []string{models.TotalExact, models.TotalEstimate}, Description: "Count every matching item, exactly or by estimate"},
+}
+
+// searchParams are the query parameters of resource listings. Parameters
+// named filter_<field> further narrow the listing by field.
+var searchParams = append([]Param{
+	{Name: "q", Description: "Text to search for"},
+	{Name: "filter", Description: "Filter expression"},
+	{Name: "provider", Description: "Cloud provider of the resources"},
+	{Name: "type", Description: "Type of the resources"},
+	{Name: "sort_by", Description: "Field to sort by"},
+	{Name: "sort_order", Enum: []string{models.SortOrderAsc, models.SortOrderDesc}},
+	{Name: "offset", Type: "integer", Description: "Number of resources to skip"},
+}, pageParams...)
+
+// providerParam selects a schema by provider
+var providerParam = Param{Name: "provider", Description: "Provider of the schema, for schemas registered for several"}
+
+// Routes returns every API route. It is the single registry both the
+// router and the OpenAPI document are built from.
+func Routes(c *controllers.Controllers) []Route {
+	var routes []Route
+	routes = []Route{
+		// Health
+		{Method: "GET", Path: "/health", Handler: c.Health.Check, ID: "getHealth", Summary: "Check the service health", Tag: "health", Status: http.StatusOK, Data: object},
+		{Method: "GET", Path: "/version", Handler: c.Health.Version, ID: "getVersion", Summary: "Get the service version", Tag: "health", Status: http.StatusOK, Data: object},
+		{Method: "GET", Path: "/openapi.json", Handler: serveDocument(&routes), ID: "getOpenAPIDocument", Summary: "Get this OpenAPI document", Tag: "health",
+			Status: http.StatusOK, Media: map[string]openapi.Schema{"application/json": {"type": "object"}}},
+
+		// Resources
+		{Method: "GET", Path: "/resources", Handler: c.Resource.List, ID: "listResources", Summary: "List resources", Tag: "resources", Query: searchParams,
+			Status: http.StatusOK, Data: reflect.TypeFor[[]models.Resource]()},
+		{Method: "POST", Path: "/resources", Handler: c.Resource.Create, ID: "createResource", Summary: "Create a resource", Tag: "resources",
+			Body: reflect.TypeFor[models.CreateResourceRequest](), Status: http.StatusCreated, Data: reflect.TypeFor[models.Resource]()},
+		{Method: "GET", Path: "/resources/{id}", Handler: c.Resource.Get, ID: "getResource", Summary: "Get a resource", Tag: "resources",
+			Status: http.StatusOK, Data: reflect.TypeFor[models.Resource]()},
+		{Method: "PUT", Path: "/resources/{id}", Handler: c.Resource.Update, ID: "updateResource", Summary: "Update a resource", Tag: "resources",
+			Body: reflect.TypeFor[models.UpdateResourceRequest](), Status: http.StatusOK, Data: reflect.TypeFor[models.Resource]()},
+		{Method: "DELETE", Path: "/resources/{id}", Handler: c.Resource.Delete, ID: "deleteResource", Summary: "Delete a resource", Tag: "resources", Status: http.StatusNoContent},
+		{Method: "GET", Path: "/resources/{id}/relationships", Handler: c.Resource.GetRelationships, ID: "listResourceRelationships", Summary: "List the relationships of a resource", Tag: "resources",
+			Status: http.StatusOK, Data: object},
+		{Method: "GET", Path: "/resources/{id}/children", Handler: c.Resource.GetChildren, ID: "listResourceChildren", Summary: "List the children of a resource", Tag: "resources",
+			Status: http.StatusOK, Data: reflect.TypeFor[[]models.Resource]()},
+		{Method: "GET", Path: "/resources/{id}/parents", Handler: c.Resource.GetParents, ID: "listResourceParents", Summary: "List the parents of a resource", Tag: "resources",
+			Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/resources:bulk", Handler: c.Import.Bulk, ID: "importResources", Summary: "Import resources in bulk from JSON Lines or CSV", Tag: "resources",
+			Query:     []Param{{Name: "format", Enum: []string{"jsonl", "csv"}, Description: "Format of the body, when its Content-Type does not say"}},
+			BodyMedia: map[string]openapi.Schema{"application/x-ndjson": text, "application/jsonl": text, "text/csv": text},
+			Status:    http.StatusOK, Data: reflect.TypeFor[models.ImportReport]()},
+		{Method: "POST", Path: "/resources:deduplicate", Handler: c.Identity.Deduplicate, ID: "deduplicateResources", Summary: "Move resources to their derived IDs, merging duplicates", Tag: "resources",
+			Status: http.StatusOK, Data: reflect.TypeFor[models.DeduplicationReport]()},
+		{Method: "GET", Path: "/export", Handler: c.Export.Export, ID: "exportResources", Summary: "Export resources as JSON Lines, CSV or Parquet", Tag: "resources",
+			Query: append([]Param{
+				{Name: "format", Enum: []string{"jsonl", "csv", "parquet"}, Description: "Format of the export; jsonl when empty"},
+				{Name: "columns", Description: "Comma-separated columns of a CSV or Parquet export"},
+			}, searchParams...),
+			Status: http.StatusOK, Media: map[string]openapi.Schema{
+				"application/x-ndjson":           text,
+				"text/csv":                       text,
+				"application/vnd.apache.parquet": {"type": "string", "contentEncoding": "binary"},
+			}},
+
+		// Search and discovery
+		{Method: "POST", Path: "/search", Handler: c.Search.Semantic, ID: "search", Summary: "Search resources by meaning", Tag: "search",
+			Body: object, Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/search/semantic", Handler: c.Search.Semantic, ID: "semanticSearch", Summary: "Search resources by meaning", Tag: "search",
+			Body: object, Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/search/text", Handler: c.Search.Text, ID: "textSearch", Summary: "Search resources by text", Tag: "search",
+			Body: object, Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/search/similarity", Handler: c.Search.Similarity, ID: "similaritySearch", Summary: "Find resources similar to a resource", Tag: "search",
+			Body: object, Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/discovery/scan", Handler: c.Search.ScanProviders, ID: "scanProviders", Summary: "Scan cloud providers for resources", Tag: "search",
+			Body: object, BodyOptional: true, Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/discovery/relationships", Handler: c.Search.DiscoverRelationships, ID: "discoverRelationships", Summary: "Discover the relationships of resources", Tag: "search",
+			Body: object, Status: http.StatusAccepted, Data: object},
+
+		// Saved searches
+		{Method: "GET", Path: "/saved-searches", Handler: c.SavedSearch.List, ID: "listSavedSearches", Summary: "List saved searches", Tag: "saved-searches",
+			Status: http.StatusOK, Data: reflect.TypeFor[[]models.SavedSearch]()},
+		{Method: "POST", Path: "/saved-searches", Handler: c.SavedSearch.Create, ID: "createSavedSearch", Summary: "Save a search", Tag: "saved-searches",
+			Body: reflect.TypeFor[models.SavedSearch](), Status: http.StatusCreated, Data: reflect.TypeFor[models.SavedSearch]()},
+		{Method: "GET", Path: "/saved-searches/{id}", Handler: c.SavedSearch.Get, ID: "getSavedSearch", Summary: "Get a saved search", Tag: "saved-searches",
+			Status: http.StatusOK, Data: reflect.TypeFor[models.SavedSearch]()},
+		{Method: "PUT", Path: "/saved-searches/{id}", Handler: c.SavedSearch.Update, ID: "updateSavedSearch", Summary: "Update a saved search", Tag: "saved-searches",
+			Body: reflect.TypeFor[models.SavedSearch](), Status: http.StatusOK, Data: reflect.TypeFor[models.SavedSearch]()},
+		{Method: "DELETE", Path: "/saved-searches/{id}", Handler: c.SavedSearch.Delete, ID: "deleteSavedSearch", Summary: "Delete a saved search", Tag: "saved-searches", Status: http.StatusNoContent},
+		{Method: "GET", Path: "/saved-searches/{id}/resources", Handler: c.SavedSearch.Resources, ID: "runSavedSearch", Summary: "List the resources a saved search matches", Tag: "saved-searches",
+			Query: searchParams, Status: http.StatusOK, Data: reflect.TypeFor[[]models.Resource]()},
+
+		// Change proposals, where changes proposed over MCP are reviewed
+		{Method: "GET", Path: "/proposals", Handler: c.Proposal.List, ID: "listProposals", Summary: "List change proposals", Tag: "proposals",
+			Query: []Param{{Name: "status", Enum: []string{models.ProposalStatusPending, models.ProposalStatusApproved, models.ProposalStatusApplied,
+				models.ProposalStatusFailed, models.ProposalStatusRejected}}},
+			Status: http.StatusOK, Data: reflect.TypeFor[[]models.ChangeProposal]()},
+		{Method: "GET", Path: "/proposals/{id}", Handler: c.Proposal.Get, ID: "getProposal", Summary: "Get a change proposal", Tag: "proposals",
+			Status: http.StatusOK, Data: reflect.TypeFor[models.ChangeProposal]()},
+		{Method: "POST", Path: "/proposals/{id}/approve", Handler: c.Proposal.Approve, ID: "approveProposal", Summary: "Approve and apply a change proposal", Tag: "proposals",
+			Body: reflect.TypeFor[models.ProposalReview](), BodyOptional: true, Status: http.StatusOK, Data: reflect.TypeFor[models.ChangeProposal]()},
+		{Method: "POST", Path: "/proposals/{id}/reject", Handler: c.Proposal.Reject, ID: "rejectProposal", Summary: "Reject a change proposal", Tag: "proposals",
+			Body: reflect.TypeFor[models.ProposalReview](), BodyOptional: true, Status: http.StatusOK, Data: reflect.TypeFor[models.ChangeProposal]()},
+
+		// Schemas
+		{Method: "GET", Path: "/schemas", Handler: c.Schema.List, ID: "listSchemas", Summary: "List schemas", Tag: "schemas",
+			Query: append([]Param{
+				{Name: "provider", Description: "Provider of the schemas"},
+				{Name: "type", Description: "Resource type of the schemas"},
+				{Name: "custom", Type: "boolean", Description: "Only custom or only predefined schemas"},
+			}, pageParams...),
+			Status: http.StatusOK, Data: reflect.TypeFor[[]models.Schema]()},
+		{Method: "POST", Path: "/schemas", Handler: c.Schema.Create, ID: "createSchema", Summary: "Register a schema", Tag: "schemas",
+			Body: reflect.TypeFor[models.Schema](), Status: http.StatusCreated, Data: reflect.TypeFor[models.Schema]()},
+		{Method: "GET", Path: "/schemas/{name}", Handler: c.Schema.Get, ID: "getSchema", Summary: "Get a schema", Tag: "schemas",
+			Query: []Param{providerParam}, Status: http.StatusOK, Data: reflect.TypeFor[models.Schema]()},
+		{Method: "PUT", Path: "/schemas/{name}", Handler: c.Schema.Update, ID: "updateSchema", Summary: "Update a schema", Tag: "schemas",
+			Query: []Param{providerParam}, Body: reflect.TypeFor[models.Schema](), Status: http.StatusOK, Data: reflect.TypeFor[models.Schema]()},
+		{Method: "DELETE", Path: "/schemas/{name}", Handler: c.Schema.Delete, ID: "deleteSchema", Summary: "Delete a schema", Tag: "schemas",
+			Query: []Param{providerParam}, Status: http.StatusNoContent},
+		{Method: "POST", Path: "/schemas/{name}/validate", Handler: c.Schema.Validate, ID: "validateSchemaData", Summary: "Validate resource data against a schema", Tag: "schemas",
+			Query: []Param{providerParam}, Body: object, Status: http.StatusOK, Data: reflect.TypeFor[models.SchemaValidationResult]()},
+		{Method: "GET", Path: "/schemas/{name}/versions", Handler: c.Schema.Versions, ID: "listSchemaVersions", Summary: "List the versions of a schema", Tag: "schemas",
+			Query: []Param{providerParam}, Status: http.StatusOK, Data: reflect.TypeFor[[]models.Schema]()},
+		{Method: "GET", Path: "/schemas/{name}/compatibility", Handler: c.Schema.Compatibility, ID: "compareSchemaVersions", Summary: "Compare two versions of a schema", Tag: "schemas",
+			Query:  []Param{providerParam, {Name: "from", Description: "Version to compare from"}, {Name: "to", Description: "Version to compare to"}},
+			Status: http.StatusOK, Data: reflect.TypeFor[models.SchemaCompatibility]()},
+		{Method: "POST", Path: "/schemas/{name}/migrate", Handler: c.Schema.Migrate, ID: "migrateSchemaResources", Summary: "Migrate the resources of a schema to a newer version", Tag: "schemas",
+			Query: []Param{providerParam}, Body: reflect.TypeFor[models.SchemaMigrationRequest](), BodyOptional: true,
+			Status: http.StatusOK, Data: reflect.TypeFor[models.SchemaMigrationReport]()},
+		{Method: "POST", Path: "/schemas:terraform", Handler: c.Schema.ImportTerraform, ID: "importTerraformSchemas", Summary: "Register the schemas of Terraform providers", Tag: "schemas",
+			Query: []Param{{Name: "dry_run", Type: "boolean", Description: "Report what would change without registering anything"}},
+			Body:  reflect.TypeFor[terraform.ProviderSchemas](), Status: http.StatusOK, Data: reflect.TypeFor[models.SchemaCatalogReport]()},
+
+		// Terraform
+		{Method: "POST", Path: "/terraform/import", Handler: c.Terraform.ImportState, ID: "importTerraformState", Summary: "Import resources from Terraform state", Tag: "terraform",
+			Body: object, Status: http.StatusAccepted, Data: object},
+		{Method: "GET", Path: "/terraform/state", Handler: c.Terraform.GetState, ID: "getTerraformState", Summary: "Get the Terraform state", Tag: "terraform",
+			Status: http.StatusOK, Data: object},
+		{Method: "GET", Path: "/terraform/coverage", Handler: c.Terraform.AnalyzeCoverage, ID: "analyzeTerraformCoverage", Summary: "Analyze which resources Terraform manages", Tag: "terraform",
+			Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/terraform/plan", Handler: c.Terraform.Plan, ID: "planTerraform", Summary: "Plan a Terraform change", Tag: "terraform",
+			Body: object, Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/terraform/apply", Handler: c.Terraform.Apply, ID: "applyTerraform", Summary: "Apply a Terraform change", Tag: "terraform",
+			Body: object, Status: http.StatusOK, Data: object},
+		{Method: "POST", Path: "/terraform/siros_key", Handler: c.Terraform.CreateKey, ID: "createTerraformKey", Summary: "Create a siros_key", Tag: "terraform",
+			Body: object, Status: http.StatusCreated, Data: object},
+		{Method: "GET", Path: "/terraform/siros_key/{key}", Handler: c.Terraform.GetKey, ID: "getTerraformKey", Summary: "Get a siros_key", Tag: "terraform",
+			Status: http.StatusOK, Data: object},
+		{Method: "PUT", Path: "/terraform/siros_key/{key}", Handler: c.Terraform.UpdateKey, ID: "updateTerraformKey", Summary: "Update a siros_key", Tag: "terraform",
+			Body: object, Status: http.StatusOK, Data: object},
+		{Method: "DELETE", Path: "/terraform/siros_key/{key}", Handler: c.Terraform.DeleteKey, ID: "deleteTerraformKey", Summary: "Delete a siros_key", Tag: "terraform", Status: http.StatusNoContent},
+		{Method: "POST", Path: "/terraform/siros_key_path", Handler: c.Terraform.QueryByPath, ID: "queryTerraformKeys", Summary: "Query siros_keys by path", Tag: "terraform",
+			Body: object, Status: http.StatusOK, Data: object},
+
+		// Audit
+		{Method: "GET", Path: "/audit/changes", Handler: c.Audit.ListChanges, ID: "listChanges", Summary: "List recorded changes, newest first", Tag: "audit",
+			Query: append([]Param{
+				{Name: "resource_id", Description: "Resource the changes were made to"},
+				{Name: "actor", Description: "Who made the changes"},
+				{Name: "operation", Description: "Operation of the changes, such as CREATE"},
+				{Name: "since", Format: "date-time", Description: "RFC 3339 time of the oldest change"},
+			}, pageParams...),
+			Status: http.StatusOK, Data: reflect.TypeFor[[]models.ChangeRecord]()},
+		{Method: "GET", Path: "/audit/trail/{id}", Handler: c.Audit.GetAuditTrail, ID: "getAuditTrail", Summary: "Get the audit trail of a resource", Tag: "audit",
+			Status: http.StatusOK, Data: object},
+		{Method: "GET", Path: "/audit/verify/{id}", Handler: c.Audit.VerifyIntegrity, ID: "verifyIntegrity", Summary: "Verify the integrity of a resource's audit trail", Tag: "audit",
+			Status: http.StatusOK, Data: object},
+	}
+
+	// The MCP Streamable HTTP transport speaks JSON-RPC, not envelopes
+	jsonRPC := map[string]openapi.Schema{"application/json": {}}
+	routes = append(routes,
+		Route{Method: "POST", Path: "/mcp", Handler: c.MCP.Handle, ID: "postMCPMessage", Summary: "Send MCP JSON-RPC messages", Tag: "mcp",
+			BodyMedia: jsonRPC, Status: http.StatusOK, Errors: jsonRPC, Extra: map[int]string{http.StatusAccepted: "Notifications and responses were accepted"},
+			Media: map[string]openapi.Schema{"application/json": {}, "text/event-stream": text}},
+		Route{Method: "GET", Path: "/mcp", Handler: c.MCP.Handle, ID: "streamMCPMessages", Summary: "Stream MCP messages from the server", Tag: "mcp",
+			Status: http.StatusOK, Errors: jsonRPC, Media: map[string]openapi.Schema{"text/event-stream": text}},
+		Route{Method: "DELETE", Path: "/mcp", Handler: c.MCP.Handle, ID: "deleteMCPSession", Summary: "End an MCP session", Tag: "mcp",
+			Status: http.StatusNoContent, Errors: jsonRPC},
+	)
+	return routes
+}
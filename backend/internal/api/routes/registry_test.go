@@ -0,0 +1,480 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/LederWorks/siros/backend/internal/api/middleware"
+	"github.com/LederWorks/siros/backend/internal/controllers"
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/internal/terraform"
+)
+
+// testResource is what the fake services store
+func testResource() models.Resource {
+	parent := "vpc-1"
+	return models.Resource{
+		ID:       "r1",
+		Type:     "ec2",
+		Provider: "aws",
+		Name:     "web",
+		Data:     map[string]interface{}{"instance_type": "t3.micro"},
+		ParentID: &parent,
+		Metadata: models.ResourceMetadata{CreatedBy: "alice", Tags: map[string]string{"env": "prod"}},
+	}
+}
+
+type fakeResources struct{ services.ResourceService }
+
+func (fakeResources) CreateResource(_ context.Context, req *models.CreateResourceRequest) (*models.Resource, error) {
+	resource := testResource()
+	resource.Name = req.Name
+	return &resource, nil
+}
+
+func (fakeResources) GetResource(_ context.Context, id string) (*models.Resource, error) {
+	if id != "r1" {
+		return nil, fmt.Errorf("resource %w: %s", repositories.ErrNotFound, id)
+	}
+	resource := testResource()
+	return &resource, nil
+}
+
+func (fakeResources) UpdateResource(_ context.Context, _ string, _ models.UpdateResourceRequest, _ string) (*models.Resource, error) {
+	resource := testResource()
+	return &resource, nil
+}
+
+func (fakeResources) DeleteResource(context.Context, string, string) error {
+	return nil
+}
+
+// ListResources finds nothing, which must still be listed as an array
+func (fakeResources) ListResources(context.Context, *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	return nil, &models.PageInfo{}, nil
+}
+
+func (fakeResources) GetResourcesByParent(context.Context, string) ([]models.Resource, error) {
+	return []models.Resource{testResource()}, nil
+}
+
+type fakeSavedSearches struct{ services.SavedSearchService }
+
+func (fakeSavedSearches) CreateSearch(_ context.Context, search *models.SavedSearch, actor string) error {
+	search.ID, search.CreatedBy = "s1", actor
+	return nil
+}
+
+func (fakeSavedSearches) GetSearch(_ context.Context, id string) (*models.SavedSearch, error) {
+	return &models.SavedSearch{ID: id, Name: "prod", Filter: "tags.env = 'prod'"}, nil
+}
+
+func (fakeSavedSearches) UpdateSearch(context.Context, string, *models.SavedSearch, string) error {
+	return nil
+}
+
+func (fakeSavedSearches) DeleteSearch(context.Context, string) error {
+	return nil
+}
+
+func (fakeSavedSearches) ListSearches(context.Context) ([]models.SavedSearch, error) {
+	return nil, nil
+}
+
+func (fakeSavedSearches) RunSearch(context.Context, string, *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	return []models.Resource{testResource()}, &models.PageInfo{NextCursor: "next"}, nil
+}
+
+type fakeSchemas struct{ services.SchemaService }
+
+func (fakeSchemas) CreateSchema(context.Context, *models.Schema) error {
+	return nil
+}
+
+func (fakeSchemas) GetSchema(_ context.Context, name, provider string) (*models.Schema, error) {
+	return &models.Schema{Name: name, Provider: provider, Version: "1.0.0", Schema: map[string]interface{}{"type": "object"}}, nil
+}
+
+func (fakeSchemas) ListSchemas(context.Context, *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error) {
+	return []models.Schema{{Name: "ec2", Provider: "aws"}}, nil, nil
+}
+
+func (fakeSchemas) UpdateSchema(context.Context, string, string, *models.Schema) error {
+	return nil
+}
+
+func (fakeSchemas) DeleteSchema(context.Context, string, string) error {
+	return nil
+}
+
+func (fakeSchemas) ValidateData(context.Context, string, string, map[string]interface{}) (*models.SchemaValidationResult, error) {
+	return &models.SchemaValidationResult{}, nil
+}
+
+func (fakeSchemas) ListVersions(context.Context, string, string) ([]models.Schema, error) {
+	return []models.Schema{{Name: "ec2", Version: "1.0.0"}, {Name: "ec2", Version: "2.0.0"}}, nil
+}
+
+func (fakeSchemas) CompareVersions(context.Context, string, string, string, string) (*models.SchemaCompatibility, error) {
+	return &models.SchemaCompatibility{}, nil
+}
+
+func (fakeSchemas) MigrateResources(context.Context, string, string, *models.SchemaMigrationRequest, string) (*models.SchemaMigrationReport, error) {
+	return &models.SchemaMigrationReport{}, nil
+}
+
+func (fakeSchemas) ImportTerraformSchemas(context.Context, *terraform.ProviderSchemas, bool) (*models.SchemaCatalogReport, error) {
+	return &models.SchemaCatalogReport{}, nil
+}
+
+type fakeProposals struct{ services.ProposalService }
+
+func testProposal(id, status string) *models.ChangeProposal {
+	return &models.ChangeProposal{
+		ID:         id,
+		Operation:  "UPDATE",
+		ResourceID: "r1",
+		Diff:       map[string]interface{}{"name": map[string]interface{}{"old": "web", "new": "api"}},
+		Reason:     "Rename",
+		Status:     status,
+		ProposedBy: "mcp:assistant",
+	}
+}
+
+func (fakeProposals) ListProposals(context.Context, string) ([]models.ChangeProposal, error) {
+	return []models.ChangeProposal{*testProposal("p1", models.ProposalStatusPending)}, nil
+}
+
+func (fakeProposals) GetProposal(_ context.Context, id string) (*models.ChangeProposal, error) {
+	return testProposal(id, models.ProposalStatusPending), nil
+}
+
+func (fakeProposals) ApproveProposal(_ context.Context, id, _, _ string) (*models.ChangeProposal, error) {
+	return testProposal(id, models.ProposalStatusApplied), nil
+}
+
+func (fakeProposals) RejectProposal(_ context.Context, id, _, _ string) (*models.ChangeProposal, error) {
+	return testProposal(id, models.ProposalStatusRejected), nil
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) ListChanges(context.Context, *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error) {
+	return []models.ChangeRecord{{ID: "c1", ResourceID: "r1", Operation: "CREATE", Actor: "alice"}}, &models.PageInfo{}, nil
+}
+
+type fakeImport struct{}
+
+func (fakeImport) Import(_ context.Context, r io.Reader, _, _ string) (*models.ImportReport, error) {
+	_, err := io.ReadAll(r)
+	return &models.ImportReport{Total: 1, Created: 1}, err
+}
+
+type fakeIdentity struct{}
+
+func (fakeIdentity) Deduplicate(context.Context, string) (*models.DeduplicationReport, error) {
+	return &models.DeduplicationReport{}, nil
+}
+
+type fakeSearch struct{ services.SearchService }
+
+func (fakeSearch) ScanProviders(_ context.Context, names []string) (*services.ProviderScanResult, error) {
+	return &services.ProviderScanResult{"status": "completed", "providers": names}, nil
+}
+
+type fakeExport struct{}
+
+func (fakeExport) Export(_ context.Context, w io.Writer, _ *models.SearchQuery, _ string, _ []string) error {
+	_, err := io.WriteString(w, `{"id":"r1"}`+"\n")
+	return err
+}
+
+// newTestRouter routes the API to controllers over fake services; MCP is
+// left unconfigured
+func newTestRouter() (*mux.Router, []Route) {
+	c := controllers.NewControllers(&services.Services{
+		Resource:    fakeResources{},
+		Import:      fakeImport{},
+		Export:      fakeExport{},
+		Identity:    fakeIdentity{},
+		Audit:       fakeAudit{},
+		Search:      fakeSearch{},
+		SavedSearch: fakeSavedSearches{},
+		Proposal:    fakeProposals{},
+		Schema:      fakeSchemas{},
+	}, log.New(io.Discard, "", 0))
+
+	router := mux.NewRouter()
+	SetupAPIRoutes(router, c)
+	return router, Routes(c)
+}
+
+// specDocument returns the OpenAPI document of routes as decoded JSON
+func specDocument(t *testing.T, routes []Route) map[string]interface{} {
+	t.Helper()
+	body, err := json.Marshal(Document(routes))
+	if err != nil {
+		t.Fatalf("Failed to marshal the OpenAPI document: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("Failed to unmarshal the OpenAPI document: %v", err)
+	}
+	return doc
+}
+
+// pointerEscaper escapes a JSON pointer token
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// responseContent returns the content the document gives a response of an
+// operation and the JSON pointer of the response, or false when the status
+// is not documented
+func responseContent(doc map[string]interface{}, path, method string, status int) (map[string]interface{}, string, bool) {
+	operation := doc["paths"].(map[string]interface{})[path].(map[string]interface{})[method].(map[string]interface{})
+	responses := operation["responses"].(map[string]interface{})
+	pointer := "#/paths/" + pointerEscaper.Replace(path) + "/" + method + "/responses/"
+
+	response, ok := responses[strconv.Itoa(status)].(map[string]interface{})
+	if ok {
+		pointer += strconv.Itoa(status)
+	} else if status >= 400 {
+		response, ok = responses["default"].(map[string]interface{})
+		pointer += "default"
+	}
+	if !ok {
+		return nil, "", false
+	}
+	if ref, isRef := response["$ref"].(string); isRef {
+		name := strings.TrimPrefix(ref, "#/components/responses/")
+		response = doc["components"].(map[string]interface{})["responses"].(map[string]interface{})[name].(map[string]interface{})
+		pointer = ref
+	}
+	content, _ := response["content"].(map[string]interface{})
+	return content, pointer + "/content", true
+}
+
+// validateResponse checks a response against the operation the document
+// gives the request's route
+func validateResponse(t *testing.T, doc map[string]interface{}, router *mux.Router, req *http.Request, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var match mux.RouteMatch
+	if !router.Match(req, &match) || match.Route == nil {
+		t.Errorf("%s %s is not routed", req.Method, req.URL.Path)
+		return ""
+	}
+	path, _ := match.Route.GetPathTemplate()
+	method := strings.ToLower(req.Method)
+	operation := doc["paths"].(map[string]interface{})[path].(map[string]interface{})[method].(map[string]interface{})
+
+	content, pointer, ok := responseContent(doc, path, method, w.Code)
+	if !ok {
+		t.Errorf("%s %s: status %d is not documented: %s", req.Method, path, w.Code, w.Body.String())
+		return operation["operationId"].(string)
+	}
+	if content == nil {
+		if w.Body.Len() > 0 {
+			t.Errorf("%s %s: status %d has no documented content, got %s", req.Method, path, w.Code, w.Body.String())
+		}
+		return operation["operationId"].(string)
+	}
+
+	media, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || content[media] == nil {
+		t.Errorf("%s %s: Content-Type %q is not documented for status %d", req.Method, path, w.Header().Get("Content-Type"), w.Code)
+		return operation["operationId"].(string)
+	}
+	if media == "application/json" {
+		schemaDoc := make(map[string]interface{}, len(doc)+1)
+		for key, value := range doc {
+			schemaDoc[key] = value
+		}
+		schemaDoc["$ref"] = pointer + "/" + pointerEscaper.Replace(media) + "/schema"
+		schema, err := jsonschema.Compile(schemaDoc)
+		if err != nil {
+			t.Fatalf("%s %s: failed to compile the response schema: %v", req.Method, path, err)
+		}
+		var body interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s %s: response is not JSON: %v", req.Method, path, err)
+		} else if violations := schema.Validate(body); len(violations) > 0 {
+			t.Errorf("%s %s: status %d response breaks the documented schema: %v\n%s", req.Method, path, w.Code, violations, w.Body.String())
+		}
+	}
+	return operation["operationId"].(string)
+}
+
+func TestRoutes_Registered(t *testing.T) {
+	router, routes := newTestRouter()
+	doc := specDocument(t, routes)
+
+	routed := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routed[strings.ToLower(method)+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk the router: %v", err)
+	}
+
+	documented := map[string]bool{}
+	operationIDs := map[string]bool{}
+	for path, item := range doc["paths"].(map[string]interface{}) {
+		for method, operation := range item.(map[string]interface{}) {
+			documented[method+" "+path] = true
+			id := operation.(map[string]interface{})["operationId"].(string)
+			if operationIDs[id] {
+				t.Errorf("operationId %s is used twice", id)
+			}
+			operationIDs[id] = true
+		}
+	}
+
+	for route := range routed {
+		if !documented[route] {
+			t.Errorf("route %s is not in the OpenAPI document", route)
+		}
+	}
+	for operation := range documented {
+		if !routed[operation] {
+			t.Errorf("operation %s is not routed", operation)
+		}
+	}
+	if doc["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v, want 3.1.0", doc["openapi"])
+	}
+}
+
+func TestRoutes_Contract(t *testing.T) {
+	router, routes := newTestRouter()
+	doc := specDocument(t, routes)
+
+	exercised := map[string]bool{}
+	for _, tc := range []struct {
+		method, path, contentType, body string
+		status                          int
+	}{
+		{"GET", "/health", "", "", http.StatusOK},
+		{"GET", "/version", "", "", http.StatusOK},
+		{"GET", "/openapi.json", "", "", http.StatusOK},
+
+		{"GET", "/resources?provider=aws&limit=10", "", "", http.StatusOK},
+		{"GET", "/resources?limit=x&sort_order=sideways", "", "", http.StatusOK},
+		{"POST", "/resources", "application/json", `{"type": "ec2", "provider": "aws", "name": "web", "data": {}}`, http.StatusCreated},
+		{"POST", "/resources", "application/json", `{`, http.StatusBadRequest},
+		{"GET", "/resources/r1", "", "", http.StatusOK},
+		{"GET", "/resources/missing", "", "", http.StatusNotFound},
+		{"PUT", "/resources/r1", "application/json", `{"name": "api"}`, http.StatusOK},
+		{"DELETE", "/resources/r1", "", "", http.StatusNoContent},
+		{"GET", "/resources/r1/relationships", "", "", http.StatusOK},
+		{"GET", "/resources/r1/children", "", "", http.StatusOK},
+		{"GET", "/resources/r1/parents", "", "", http.StatusOK},
+		{"POST", "/resources:bulk", "application/x-ndjson", `{"type": "ec2", "provider": "aws", "name": "web"}` + "\n", http.StatusOK},
+		{"POST", "/resources:bulk", "text/plain", "", http.StatusUnsupportedMediaType},
+		{"POST", "/resources:deduplicate", "", "", http.StatusOK},
+		{"GET", "/export?format=jsonl", "", "", http.StatusOK},
+		{"GET", "/export?format=xml", "", "", http.StatusBadRequest},
+
+		{"POST", "/search", "application/json", `{"query": "web"}`, http.StatusOK},
+		{"POST", "/search/semantic", "application/json", `{"query": "web"}`, http.StatusOK},
+		{"POST", "/search/text", "application/json", `{"query": "web"}`, http.StatusOK},
+		{"POST", "/search/similarity", "application/json", `{"resource_id": "r1"}`, http.StatusOK},
+		{"POST", "/discovery/scan", "application/json", `{"providers": ["aws"]}`, http.StatusOK},
+		{"POST", "/discovery/relationships", "application/json", `{"resource_id": "r1"}`, http.StatusAccepted},
+
+		{"GET", "/saved-searches", "", "", http.StatusOK},
+		{"POST", "/saved-searches", "application/json", `{"name": "prod", "filter": "tags.env = 'prod'"}`, http.StatusCreated},
+		{"GET", "/saved-searches/s1", "", "", http.StatusOK},
+		{"PUT", "/saved-searches/s1", "application/json", `{"name": "prod"}`, http.StatusOK},
+		{"DELETE", "/saved-searches/s1", "", "", http.StatusNoContent},
+		{"GET", "/saved-searches/s1/resources", "", "", http.StatusOK},
+
+		{"GET", "/proposals?status=pending", "", "", http.StatusOK},
+		{"GET", "/proposals?status=done", "", "", http.StatusBadRequest},
+		{"GET", "/proposals/p1", "", "", http.StatusOK},
+		{"POST", "/proposals/p1/approve", "application/json", `{"note": "ok"}`, http.StatusOK},
+		{"POST", "/proposals/p1/reject", "", "", http.StatusOK},
+
+		{"GET", "/schemas?custom=true", "", "", http.StatusOK},
+		{"GET", "/schemas?custom=maybe", "", "", http.StatusBadRequest},
+		{"POST", "/schemas", "application/json", `{"name": "firewall", "provider": "custom", "schema": {"type": "object"}}`, http.StatusCreated},
+		{"GET", "/schemas/ec2?provider=aws", "", "", http.StatusOK},
+		{"PUT", "/schemas/firewall", "application/json", `{"name": "firewall", "provider": "custom"}`, http.StatusOK},
+		{"DELETE", "/schemas/firewall", "", "", http.StatusNoContent},
+		{"POST", "/schemas/ec2/validate", "application/json", `{"instance_type": "t3.micro"}`, http.StatusOK},
+		{"GET", "/schemas/ec2/versions", "", "", http.StatusOK},
+		{"GET", "/schemas/ec2/compatibility?from=1.0.0&to=2.0.0", "", "", http.StatusOK},
+		{"POST", "/schemas/ec2/migrate", "", "", http.StatusOK},
+		{"POST", "/schemas:terraform?dry_run=true", "application/json", `{"format_version": "1.0", "provider_schemas": {}}`, http.StatusOK},
+
+		{"POST", "/terraform/import", "application/json", `{"state_file": "terraform.tfstate"}`, http.StatusAccepted},
+		{"GET", "/terraform/state", "", "", http.StatusOK},
+		{"GET", "/terraform/coverage", "", "", http.StatusOK},
+		{"POST", "/terraform/plan", "application/json", `{}`, http.StatusOK},
+		{"POST", "/terraform/apply", "application/json", `{}`, http.StatusOK},
+		{"POST", "/terraform/siros_key", "application/json", `{"key": "k1", "path": "/aws"}`, http.StatusCreated},
+		{"GET", "/terraform/siros_key/k1", "", "", http.StatusOK},
+		{"PUT", "/terraform/siros_key/k1", "application/json", `{"path": "/aws"}`, http.StatusOK},
+		{"DELETE", "/terraform/siros_key/k1", "", "", http.StatusNoContent},
+		{"POST", "/terraform/siros_key_path", "application/json", `{"path": "/aws"}`, http.StatusOK},
+
+		{"GET", "/audit/changes?actor=alice", "", "", http.StatusOK},
+		{"GET", "/audit/changes?since=yesterday", "", "", http.StatusBadRequest},
+		{"GET", "/audit/trail/r1", "", "", http.StatusOK},
+		{"GET", "/audit/verify/r1", "", "", http.StatusOK},
+
+		{"POST", "/mcp", "application/json", `{"jsonrpc": "2.0", "id": 1, "method": "ping"}`, http.StatusServiceUnavailable},
+		{"GET", "/mcp", "", "", http.StatusServiceUnavailable},
+		{"DELETE", "/mcp", "", "", http.StatusServiceUnavailable},
+	} {
+		req := httptest.NewRequest(tc.method, APIPrefix+tc.path, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		req.Header.Set("X-User", "alice")
+		req = req.WithContext(middleware.WithUser(req.Context(), "alice"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s %s: status %d, want %d: %s", tc.method, tc.path, w.Code, tc.status, w.Body.String())
+		}
+		exercised[validateResponse(t, doc, router, req, w)] = true
+	}
+
+	// Every route needs a case, so that none escapes the contract
+	var untested []string
+	for _, route := range routes {
+		if !exercised[route.ID] {
+			untested = append(untested, route.ID)
+		}
+	}
+	sort.Strings(untested)
+	if len(untested) > 0 {
+		t.Errorf("operations without a contract test case: %v", untested)
+	}
+}
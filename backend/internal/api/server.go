@@ -27,21 +27,18 @@ type Server struct {
 	logger      *log.Logger
 }
 
-// NewServer creates a new API server.
-func NewServer(cfg *config.Config, storage *storage.Storage, webAssets embed.FS, logger *log.Logger) *Server {
+// NewServer creates a new API server serving svc. Controllers whose service
+// is nil answer 503 Service Unavailable.
+func NewServer(cfg *config.Config, storage *storage.Storage, svc *services.Services, webAssets embed.FS, logger *log.Logger) *Server {
 	s := &Server{
 		config:    cfg,
 		storage:   storage,
 		router:    mux.NewRouter(),
+		services:  svc,
 		webAssets: webAssets,
 		logger:    logger,
 	}
 
-	// Initialize services (simplified for now)
-	s.services = &services.Services{
-		// TODO: Initialize with proper repositories
-	}
-
 	// Initialize controllers
 	s.controllers = controllers.NewControllers(s.services, logger)
 
@@ -53,6 +50,7 @@ func NewServer(cfg *config.Config, storage *storage.Storage, webAssets embed.FS,
 func (s *Server) Router() http.Handler {
 	// Apply middleware
 	middlewareConfig := middleware.DefaultConfig(s.logger)
+	middlewareConfig.UserHeader = s.config.Server.ReviewerHeader
 	return middlewareConfig.Apply(s.router)
 }
 
@@ -105,6 +103,7 @@ func (s *Server) serveDevelopmentPage(w http.ResponseWriter, _ *http.Request) {
         <a href="/api/v1/health" class="api-link">Health Check</a>
         <a href="/api/v1/version" class="api-link">Version</a>
         <a href="/api/v1/resources" class="api-link">Resources</a>
+        <a href="/api/v1/openapi.json" class="api-link">OpenAPI</a>
         <h2>Features</h2>
         <ul>
             <li>✅ HTTP API for resource management</li>
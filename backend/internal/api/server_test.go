@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/services"
+)
+
+// fakeSchemaService lists a fixed schema; other methods are not called
+type fakeSchemaService struct {
+	services.SchemaService
+}
+
+func (fakeSchemaService) ListSchemas(context.Context, *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error) {
+	return []models.Schema{{Name: "aws_instance", Provider: "aws"}}, nil, nil
+}
+
+func TestNewServer_ServesServices(t *testing.T) {
+	svc := &services.Services{Schema: fakeSchemaService{}}
+	server := NewServer(&config.Config{}, nil, svc, embed.FS{}, log.New(io.Discard, "", 0))
+
+	w := httptest.NewRecorder()
+	server.Router().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/schemas", http.NoBody))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data []models.Schema `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Data) != 1 || response.Data[0].Name != "aws_instance" {
+		t.Errorf("Expected the schema from the service, got %+v", response.Data)
+	}
+
+	// Services left out answer unavailable rather than failing
+	w = httptest.NewRecorder()
+	server.Router().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/export", http.NoBody))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without an export service, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
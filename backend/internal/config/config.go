@@ -16,6 +16,7 @@ type Config struct {
 	Vector     VectorConfig     `yaml:"vector"`
 	Blockchain BlockchainConfig `yaml:"blockchain"`
 	Providers  ProvidersConfig  `yaml:"providers"`
+	Ingest     IngestConfig     `yaml:"ingest"`
 }
 
 // ServerConfig contains HTTP server settings
@@ -29,6 +30,11 @@ type ServerConfig struct {
 		CertFile string `yaml:"cert_file"`
 		KeyFile  string `yaml:"key_file"`
 	} `yaml:"tls"`
+
+	// ReviewerHeader names the request header that carries the user
+	// reviewing change proposals. It is trusted as is, so it must be set by
+	// an authenticating proxy in front of Siros; empty disables reviews.
+	ReviewerHeader string `yaml:"reviewer_header" env:"SIROS_REVIEWER_HEADER"`
 }
 
 // DatabaseConfig contains database connection settings
@@ -81,39 +87,163 @@ type ProvidersConfig struct {
 	AWS   AWSConfig   `yaml:"aws"`
 	Azure AzureConfig `yaml:"azure"`
 	GCP   GCPConfig   `yaml:"gcp"`
+	OCI   OCIConfig   `yaml:"oci"`
+
+	Kubernetes KubernetesConfig `yaml:"kubernetes"`
+	Plugins    PluginsConfig    `yaml:"plugins"`
 }
 
 // AWSConfig contains AWS-specific settings
 type AWSConfig struct {
+	Enabled         bool   `yaml:"enabled"`
 	Region          string `yaml:"region" env:"AWS_REGION"`
 	AccessKeyID     string `yaml:"access_key_id" env:"AWS_ACCESS_KEY_ID"`
 	SecretAccessKey string `yaml:"secret_access_key" env:"AWS_SECRET_ACCESS_KEY"`
 	SessionToken    string `yaml:"session_token" env:"AWS_SESSION_TOKEN"`
+
+	// Regions limits scanning to the listed regions. When empty, every region
+	// enabled for the account is scanned.
+	Regions []string `yaml:"regions"`
+
+	// Accounts lists the accounts to scan. When empty, only the account owning
+	// the base credentials is scanned.
+	Accounts []AWSAccountConfig `yaml:"accounts"`
+
+	// RoleName is the role assumed in each account that does not set its own
+	// role_arn, e.g. "OrganizationAccountAccessRole".
+	RoleName string `yaml:"role_name"`
+
+	// ExternalID is passed to STS AssumeRole unless overridden per account.
+	ExternalID string `yaml:"external_id" env:"AWS_EXTERNAL_ID"`
+
+	// MaxConcurrency bounds the number of account/region/service scans in flight.
+	MaxConcurrency int `yaml:"max_concurrency"`
+}
+
+// AWSAccountConfig describes a single AWS account reached via STS AssumeRole
+type AWSAccountConfig struct {
+	ID         string `yaml:"id"`
+	Name       string `yaml:"name"`
+	RoleARN    string `yaml:"role_arn"`
+	ExternalID string `yaml:"external_id"`
 }
 
 // AzureConfig contains Azure-specific settings
 type AzureConfig struct {
+	Enabled        bool   `yaml:"enabled"`
 	TenantID       string `yaml:"tenant_id" env:"AZURE_TENANT_ID"`
 	ClientID       string `yaml:"client_id" env:"AZURE_CLIENT_ID"`
 	ClientSecret   string `yaml:"client_secret" env:"AZURE_CLIENT_SECRET"`
 	SubscriptionID string `yaml:"subscription_id" env:"AZURE_SUBSCRIPTION_ID"`
+
+	// Subscriptions limits scanning to the listed subscription IDs. When
+	// empty, every subscription the service principal can read is scanned.
+	Subscriptions []string `yaml:"subscriptions"`
+
+	// AuthorityHost and ResourceManagerEndpoint select the Azure cloud. They
+	// default to the public cloud endpoints.
+	AuthorityHost           string `yaml:"authority_host"`
+	ResourceManagerEndpoint string `yaml:"resource_manager_endpoint"`
 }
 
 // GCPConfig contains GCP-specific settings
 type GCPConfig struct {
+	Enabled               bool   `yaml:"enabled"`
 	ProjectID             string `yaml:"project_id" env:"GCP_PROJECT_ID"`
 	ServiceAccountKeyFile string `yaml:"service_account_key_file"`
 	Region                string `yaml:"region"`
+
+	// FolderID and OrganizationID widen the scan scope. The broadest scope
+	// set wins: organization, then folder, then project.
+	FolderID       string `yaml:"folder_id" env:"GCP_FOLDER_ID"`
+	OrganizationID string `yaml:"organization_id" env:"GCP_ORGANIZATION_ID"`
+
+	// AssetTypes limits scanning to the listed Cloud Asset types, e.g.
+	// "compute.googleapis.com/Instance". When empty, every type is scanned.
+	AssetTypes []string `yaml:"asset_types"`
+
+	// AssetAPI selects "search" (searchAllResources, the default) or "list"
+	// (listAssets, which returns full resource data but needs broader access).
+	AssetAPI string `yaml:"asset_api"`
+
+	// AssetEndpoint overrides the Cloud Asset Inventory endpoint
+	AssetEndpoint string `yaml:"asset_endpoint"`
+}
+
+// OCIConfig contains Oracle Cloud Infrastructure settings. Requests are
+// signed with the API key identified by TenancyOCID, UserOCID and Fingerprint.
+type OCIConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	TenancyOCID string `yaml:"tenancy_ocid" env:"OCI_TENANCY_OCID"`
+	UserOCID    string `yaml:"user_ocid" env:"OCI_USER_OCID"`
+	Fingerprint string `yaml:"fingerprint" env:"OCI_FINGERPRINT"`
+	KeyFile     string `yaml:"key_file" env:"OCI_KEY_FILE"`
+	Region      string `yaml:"region" env:"OCI_REGION"`
+
+	// Regions lists the regions to search. When empty, only Region is searched.
+	Regions []string `yaml:"regions"`
+
+	// SearchEndpoint overrides the Search service endpoint. A "{region}"
+	// placeholder is replaced with each region being searched.
+	SearchEndpoint string `yaml:"search_endpoint"`
+}
+
+// KubernetesConfig contains Kubernetes cluster discovery settings
+type KubernetesConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Kubeconfig is the kubeconfig file to read. It defaults to $KUBECONFIG,
+	// then ~/.kube/config.
+	Kubeconfig string `yaml:"kubeconfig" env:"KUBECONFIG"`
+
+	// Contexts lists the kubeconfig contexts to scan. When empty, only the
+	// current context is scanned.
+	Contexts []KubernetesContextConfig `yaml:"contexts"`
+}
+
+// KubernetesContextConfig selects a kubeconfig context to scan
+type KubernetesContextConfig struct {
+	Name string `yaml:"name"`
+
+	// CloudResourceID links the cluster to its EKS, AKS or GKE resource. It is
+	// detected from EKS and GKE kubeconfig entries when not set.
+	CloudResourceID string `yaml:"cloud_resource_id"`
+}
+
+// PluginsConfig configures out-of-process provider plugins
+type PluginsConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Dir is searched for executables named siros-provider-<name>
+	Dir string `yaml:"dir"`
+
+	// Config holds the settings handed to each plugin during its handshake,
+	// keyed by the <name> part of the executable's file name.
+	Config map[string]map[string]interface{} `yaml:"config"`
+}
+
+// IngestConfig configures incremental ingestion of cloud audit events
+type IngestConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// SpoolDir is polled for CloudTrail, Azure Activity Log and GCP Audit Log
+	// exports. Ingested files are moved to its processed/ and failed/
+	// subdirectories.
+	SpoolDir string `yaml:"spool_dir"`
+
+	// PollInterval is the number of seconds between spool directory scans
+	PollInterval int `yaml:"poll_interval"`
 }
 
 // Load loads configuration from file with environment variable overrides
 func Load(path string) (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Host:         "0.0.0.0",
-			Port:         8080,
-			ReadTimeout:  30,
-			WriteTimeout: 30,
+			Host:           "0.0.0.0",
+			Port:           8080,
+			ReadTimeout:    30,
+			WriteTimeout:   30,
+			ReviewerHeader: "X-User",
 		},
 		Database: DatabaseConfig{
 			Driver:   "postgres",
@@ -127,6 +257,10 @@ func Load(path string) (*Config, error) {
 		Vector: VectorConfig{
 			Provider: "pgvector",
 		},
+		Ingest: IngestConfig{
+			SpoolDir:     "ingest",
+			PollInterval: 30,
+		},
 	}
 
 	// Load from file if it exists
@@ -215,6 +349,9 @@ func loadEnvVars(cfg *Config) {
 	if val := os.Getenv("SIROS_HOST"); val != "" {
 		cfg.Server.Host = val
 	}
+	if val, ok := os.LookupEnv("SIROS_REVIEWER_HEADER"); ok {
+		cfg.Server.ReviewerHeader = val
+	}
 	if val := os.Getenv("SIROS_DB_HOST"); val != "" {
 		cfg.Database.Host = val
 	}
@@ -25,6 +25,10 @@ func TestLoad(t *testing.T) {
 	if cfg.Database.Driver != "postgres" {
 		t.Errorf("Expected default driver 'postgres', got: %s", cfg.Database.Driver)
 	}
+
+	if cfg.Server.ReviewerHeader != "X-User" {
+		t.Errorf("Expected default reviewer header 'X-User', got: %s", cfg.Server.ReviewerHeader)
+	}
 }
 
 func TestEnvironmentVariableOverrides(t *testing.T) {
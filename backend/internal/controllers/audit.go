@@ -3,23 +3,27 @@ package controllers
 import (
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/services"
 	"github.com/LederWorks/siros/backend/internal/views"
 )
 
 // AuditController handles blockchain audit trail related HTTP requests
 type AuditController struct {
-	logger *log.Logger
-	// TODO: Add audit service dependencies
+	auditService services.AuditService
+	logger       *log.Logger
 }
 
 // NewAuditController creates a new audit controller
-func NewAuditController(logger *log.Logger) *AuditController {
+func NewAuditController(auditService services.AuditService, logger *log.Logger) *AuditController {
 	return &AuditController{
-		logger: logger,
+		auditService: auditService,
+		logger:       logger,
 	}
 }
 
@@ -82,47 +86,49 @@ func (c *AuditController) GetAuditTrail(w http.ResponseWriter, r *http.Request)
 	views.WriteJSONResponse(w, http.StatusOK, response)
 }
 
-// ListChanges handles GET /api/v1/audit/changes
-func (c *AuditController) ListChanges(w http.ResponseWriter, _ *http.Request) {
-	c.logger.Printf("Listing recent changes")
+// ListChanges handles GET /api/v1/audit/changes. Changes are returned
+// newest first and can be filtered by resource_id, actor, operation and an
+// RFC 3339 since time.
+func (c *AuditController) ListChanges(w http.ResponseWriter, r *http.Request) {
+	if c.auditService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Change listing is not available", nil)
+		return
+	}
 
-	// Parse query parameters
-	// limit := r.URL.Query().Get("limit")
-	// offset := r.URL.Query().Get("offset")
-	// since := r.URL.Query().Get("since")
+	params := r.URL.Query()
+	query := models.ChangeQuery{
+		ResourceID: params.Get("resource_id"),
+		Actor:      params.Get("actor"),
+		Operation:  params.Get("operation"),
+		Cursor:     params.Get("cursor"),
+		Total:      params.Get("total"),
+	}
+	if limit, err := strconv.Atoi(params.Get("limit")); err == nil {
+		query.Limit = limit
+	}
+	if since := params.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			views.WriteBadRequest(w, "since must be an RFC 3339 time", err)
+			return
+		}
+		query.Since = &t
+	}
 
-	// TODO: Implement change listing with filtering
+	records, page, err := c.auditService.ListChanges(r.Context(), &query)
+	if err != nil {
+		c.logger.Printf("Failed to list changes: %v", err)
 
-	// Placeholder response
-	changes := []map[string]interface{}{
-		{
-			"id":          "change-1",
-			"resource_id": "resource-1",
-			"operation":   "CREATE",
-			"timestamp":   time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
-			"actor":       "user-123",
-		},
-		{
-			"id":          "change-2",
-			"resource_id": "resource-2",
-			"operation":   "UPDATE",
-			"timestamp":   time.Now().Add(-1 * time.Hour).Format(time.RFC3339),
-			"actor":       "system",
-		},
-	}
+		if invalidQuery(err) {
+			views.WriteBadRequest(w, "Invalid query parameters", err)
+			return
+		}
 
-	response := views.APIResponse{
-		Data: map[string]interface{}{
-			"changes": changes,
-			"total":   len(changes),
-		},
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
+		views.WriteInternalError(w, "Failed to list changes", err)
+		return
 	}
 
-	views.WriteJSONResponse(w, http.StatusOK, response)
+	views.WriteChangeListResponse(w, http.StatusOK, records, page)
 }
 
 // VerifyIntegrity handles GET /api/v1/audit/verify/{id}
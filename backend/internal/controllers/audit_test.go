@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// mockAuditService returns a single record with a next cursor
+type mockAuditService struct {
+	query *models.ChangeQuery
+}
+
+func (m *mockAuditService) ListChanges(_ context.Context, query *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error) {
+	m.query = query
+	total := int64(3)
+	return []models.ChangeRecord{{ID: "change-1", ResourceID: query.ResourceID}},
+		&models.PageInfo{NextCursor: "next", Total: &total}, nil
+}
+
+func TestAuditController_ListChanges(t *testing.T) {
+	service := &mockAuditService{}
+	controller := NewAuditController(service, log.New(io.Discard, "", 0))
+
+	req := httptest.NewRequest("GET", "/api/v1/audit/changes?resource_id=r1&since=2024-01-01T00:00:00Z&limit=1&total=exact", http.NoBody)
+	w := httptest.NewRecorder()
+	controller.ListChanges(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if service.query.ResourceID != "r1" || service.query.Since == nil || service.query.Limit != 1 {
+		t.Errorf("Unexpected query %+v", service.query)
+	}
+
+	var response struct {
+		Meta struct {
+			NextCursor string `json:"next_cursor"`
+			Total      *int64 `json:"total"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Meta.NextCursor != "next" || response.Meta.Total == nil || *response.Meta.Total != 3 {
+		t.Errorf("Unexpected meta %+v", response.Meta)
+	}
+}
+
+func TestAuditController_ListChangesErrors(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/audit/changes?since=yesterday", http.NoBody)
+	w := httptest.NewRecorder()
+	NewAuditController(&mockAuditService{}, log.New(io.Discard, "", 0)).ListChanges(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for bad since, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	NewAuditController(nil, log.New(io.Discard, "", 0)).ListChanges(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without a service, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
@@ -8,24 +8,34 @@ import (
 
 // Controllers holds all controller instances
 type Controllers struct {
-	Health    *HealthController
-	Resource  *ResourceController
-	Search    *SearchController
-	Schema    *SchemaController
-	Terraform *TerraformController
-	MCP       *MCPController
-	Audit     *AuditController
+	Health      *HealthController
+	Resource    *ResourceController
+	Import      *ImportController
+	Export      *ExportController
+	Identity    *IdentityController
+	Search      *SearchController
+	SavedSearch *SavedSearchController
+	Proposal    *ProposalController
+	Schema      *SchemaController
+	Terraform   *TerraformController
+	MCP         *MCPController
+	Audit       *AuditController
 }
 
 // NewControllers creates a new Controllers instance with all controllers
 func NewControllers(services *services.Services, logger *log.Logger) *Controllers {
 	return &Controllers{
-		Health:    NewHealthController(logger),
-		Resource:  NewResourceController(services.Resource, logger),
-		Search:    NewSearchController(logger),    // TODO: Add services.Search when available
-		Schema:    NewSchemaController(logger),    // TODO: Add services.Schema when available
-		Terraform: NewTerraformController(logger), // TODO: Add services.Terraform when available
-		MCP:       NewMCPController(logger),       // TODO: Add services.MCP when available
-		Audit:     NewAuditController(logger),
+		Health:      NewHealthController(logger),
+		Resource:    NewResourceController(services.Resource, logger),
+		Import:      NewImportController(services.Import, logger),
+		Export:      NewExportController(services.Export, logger),
+		Identity:    NewIdentityController(services.Identity, logger),
+		Search:      NewSearchController(services.Search, logger),
+		SavedSearch: NewSavedSearchController(services.SavedSearch, logger),
+		Proposal:    NewProposalController(services.Proposal, logger),
+		Schema:      NewSchemaController(services.Schema, logger),
+		Terraform:   NewTerraformController(logger), // TODO: Add services.Terraform when available
+		MCP:         NewMCPController(services.MCP, logger),
+		Audit:       NewAuditController(services.Audit, logger),
 	}
 }
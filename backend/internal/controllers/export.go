@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/export"
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/internal/views"
+)
+
+// ExportController handles bulk export requests
+type ExportController struct {
+	exportService services.ExportService
+	logger        Logger
+}
+
+// NewExportController creates a new export controller
+func NewExportController(exportService services.ExportService, logger Logger) *ExportController {
+	return &ExportController{
+		exportService: exportService,
+		logger:        logger,
+	}
+}
+
+// Export handles GET /api/v1/export. It streams every resource matching the
+// list filters as format=jsonl (the default), csv or parquet; columns selects
+// a comma-separated list of fields and data or metadata paths.
+func (c *ExportController) Export(w http.ResponseWriter, r *http.Request) {
+	if c.exportService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Export is not available", nil)
+		return
+	}
+
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = export.FormatJSONL
+	}
+	if format != export.FormatJSONL && format != export.FormatCSV && format != export.FormatParquet {
+		views.WriteBadRequest(w, "format must be jsonl, csv or parquet", nil)
+		return
+	}
+	var columns []string
+	for _, column := range strings.Split(params.Get("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	query := parseSearchQuery(r)
+
+	// Large exports outlive the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	out := &exportResponse{ResponseWriter: w}
+	buf := bufio.NewWriterSize(out, 64*1024)
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="siros-export.`+format+`"`)
+
+	err := c.exportService.Export(r.Context(), buf, &query, format, columns)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	c.logger.Printf("Export failed: %v", err)
+	if out.written {
+		// The status is already sent; abort so the client sees a
+		// truncated transfer rather than a complete-looking file
+		panic(http.ErrAbortHandler)
+	}
+	w.Header().Del("Content-Disposition")
+	if invalidQuery(err) {
+		views.WriteBadRequest(w, "Invalid export query", err)
+		return
+	}
+	views.WriteInternalError(w, "Failed to export resources", err)
+}
+
+// exportResponse records whether any of the export reached the client
+type exportResponse struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *exportResponse) Write(p []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(p)
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// mockExportService writes the query it was given, or fails before writing
+type mockExportService struct {
+	columns []string
+	err     error
+}
+
+func (m *mockExportService) Export(_ context.Context, w io.Writer, query *models.SearchQuery, format string, columns []string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.columns = columns
+	_, err := io.WriteString(w, query.Provider+","+format+"\n")
+	return err
+}
+
+func TestExportController_Export(t *testing.T) {
+	service := &mockExportService{}
+	controller := NewExportController(service, log.New(io.Discard, "", 0))
+
+	req := httptest.NewRequest("GET", "/api/v1/export?format=csv&provider=aws&columns=id,+data.instance_type,", http.NoBody)
+	w := httptest.NewRecorder()
+	controller.Export(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("Expected a CSV download, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Body.String() != "aws,csv\n" {
+		t.Errorf("Expected the streamed body, got %q", w.Body.String())
+	}
+	if strings.Join(service.columns, "|") != "id|data.instance_type" {
+		t.Errorf("Unexpected columns: %v", service.columns)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/export?format=xml", http.NoBody)
+	w = httptest.NewRecorder()
+	controller.Export(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Failures before anything is streamed are reported as errors
+	service.err = errors.New("connection refused")
+	req = httptest.NewRequest("GET", "/api/v1/export", http.NoBody)
+	w = httptest.NewRecorder()
+	controller.Export(w, req)
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Disposition") != "" {
+		t.Errorf("Expected a plain error response, got %d %v", w.Code, w.Header())
+	}
+}
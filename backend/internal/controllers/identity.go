@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/internal/views"
+)
+
+// IdentityController handles resource identity maintenance requests
+type IdentityController struct {
+	identityService services.IdentityService
+	logger          Logger
+}
+
+// NewIdentityController creates a new identity controller
+func NewIdentityController(identityService services.IdentityService, logger Logger) *IdentityController {
+	return &IdentityController{
+		identityService: identityService,
+		logger:          logger,
+	}
+}
+
+// Deduplicate handles POST /api/v1/resources:deduplicate. Resources stored
+// under native or random IDs are moved to their derived Siros IDs and
+// merged with any resource already stored there.
+func (c *IdentityController) Deduplicate(w http.ResponseWriter, r *http.Request) {
+	if c.identityService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Deduplication is not available", nil)
+		return
+	}
+
+	// TODO: Get the actor from authentication context
+	actor := "system"
+	if authUser := r.Header.Get("X-User"); authUser != "" {
+		actor = authUser
+	}
+
+	report, err := c.identityService.Deduplicate(r.Context(), actor)
+	if err != nil {
+		c.logger.Printf("Deduplication failed: %v", err)
+		views.WriteInternalError(w, "Failed to deduplicate resources", err)
+		return
+	}
+
+	c.logger.Printf("Deduplication by %s: %d renamed, %d merged, %d failed", actor, report.Renamed, report.Merged, report.Failed)
+	views.WriteDeduplicationResponse(w, report)
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/internal/views"
+)
+
+// importFormats maps request content types to bulk import formats
+var importFormats = map[string]string{
+	"application/jsonl":      services.ImportFormatJSONL,
+	"application/x-jsonl":    services.ImportFormatJSONL,
+	"application/x-ndjson":   services.ImportFormatJSONL,
+	"application/json-lines": services.ImportFormatJSONL,
+	"text/csv":               services.ImportFormatCSV,
+}
+
+// ImportController handles bulk import requests
+type ImportController struct {
+	importService services.ImportService
+	logger        Logger
+}
+
+// NewImportController creates a new import controller
+func NewImportController(importService services.ImportService, logger Logger) *ImportController {
+	return &ImportController{
+		importService: importService,
+		logger:        logger,
+	}
+}
+
+// Bulk handles POST /api/v1/resources:bulk. The body is streamed as JSON
+// Lines or CSV, chosen by Content-Type or the format query parameter, and
+// the response lists the outcome of every line.
+func (c *ImportController) Bulk(w http.ResponseWriter, r *http.Request) {
+	if c.importService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Bulk import is not available", nil)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			views.WriteBadRequest(w, "Content-Type must be application/x-ndjson, application/jsonl or text/csv", err)
+			return
+		}
+		format = importFormats[mediaType]
+	}
+	if format != services.ImportFormatJSONL && format != services.ImportFormatCSV {
+		views.WriteError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/x-ndjson, application/jsonl or text/csv", nil)
+		return
+	}
+
+	// Large imports outlive the server's read timeout while streaming, and
+	// its write timeout before the report is written
+	controller := http.NewResponseController(w)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+
+	// TODO: Get the actor from authentication context
+	actor := "system"
+	if authUser := r.Header.Get("X-User"); authUser != "" {
+		actor = authUser
+	}
+
+	report, err := c.importService.Import(r.Context(), r.Body, format, actor)
+	if err != nil {
+		c.logger.Printf("Bulk import failed: %v", err)
+		if report == nil {
+			views.WriteBadRequest(w, "Invalid import", err)
+			return
+		}
+	} else {
+		c.logger.Printf("Bulk import by %s: %d created, %d updated, %d failed", actor, report.Created, report.Updated, report.Failed)
+	}
+	views.WriteImportResponse(w, report, err)
+}
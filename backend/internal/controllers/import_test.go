@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// mockImportService reports one created row per line it reads
+type mockImportService struct {
+	format, actor string
+}
+
+func (m *mockImportService) Import(_ context.Context, r io.Reader, format, actor string) (*models.ImportReport, error) {
+	m.format, m.actor = format, actor
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.ImportReport{}
+	for i := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		report.Add(models.ImportResult{Line: i + 1, Status: models.ImportStatusCreated})
+	}
+	return report, nil
+}
+
+func TestImportController_Bulk(t *testing.T) {
+	service := &mockImportService{}
+	controller := NewImportController(service, log.New(io.Discard, "", 0))
+
+	// Registered as in routes.SetupAPIRoutes
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/resources:bulk", controller.Bulk).Methods("POST")
+	resources := api.PathPrefix("/resources").Subrouter()
+	resources.HandleFunc("/{id}", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) })
+
+	req := httptest.NewRequest("POST", "/api/v1/resources:bulk", strings.NewReader("provider,native_id\ncustom,a\ncustom,b\n"))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	req.Header.Set("X-User", "migration")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if service.format != "csv" || service.actor != "migration" {
+		t.Errorf("Expected a CSV import by the request user, got %q by %q", service.format, service.actor)
+	}
+
+	var response struct {
+		Data models.ImportReport `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Data.Created != 3 || len(response.Data.Results) != 3 {
+		t.Errorf("Expected a result per line, got %+v", response.Data)
+	}
+
+	// The format query parameter overrides the content type
+	req = httptest.NewRequest("POST", "/api/v1/resources:bulk?format=jsonl", strings.NewReader("{}"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || service.format != "jsonl" {
+		t.Errorf("Expected a JSON Lines import, got status %d and format %q", w.Code, service.format)
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/resources:bulk", strings.NewReader("<rows/>"))
+	req.Header.Set("Content-Type", "application/xml")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+}
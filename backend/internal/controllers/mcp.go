@@ -1,573 +1,35 @@
 package controllers
 
 import (
-	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
-	"time"
 
+	"github.com/LederWorks/siros/backend/internal/mcp"
+	"github.com/LederWorks/siros/backend/internal/services"
 	"github.com/LederWorks/siros/backend/internal/views"
 )
 
-// MCPController handles Model Context Protocol related HTTP requests
+// MCPController serves the Model Context Protocol over HTTP
 type MCPController struct {
+	server *mcp.Server
 	logger *log.Logger
-	// TODO: Add MCP service dependencies
 }
 
 // NewMCPController creates a new MCP controller
-func NewMCPController(logger *log.Logger) *MCPController {
-	return &MCPController{
-		logger: logger,
+func NewMCPController(mcpService services.MCPService, logger *log.Logger) *MCPController {
+	c := &MCPController{logger: logger}
+	if mcpService != nil {
+		c.server = mcp.NewServer(mcpService, logger)
 	}
+	return c
 }
 
-// Initialize handles POST /api/v1/mcp/initialize
-func (c *MCPController) Initialize(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid MCP initialization request", err)
+// Handle handles GET, POST and DELETE /api/v1/mcp, the MCP Streamable HTTP
+// endpoint
+func (c *MCPController) Handle(w http.ResponseWriter, r *http.Request) {
+	if c.server == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "MCP is not available", nil)
 		return
 	}
-
-	c.logger.Printf("MCP initialization request: %+v", req)
-
-	// TODO: Implement MCP protocol initialization
-
-	// MCP initialization response following the protocol
-	initResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      req["id"],
-		"result": map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities": map[string]interface{}{
-				"resources": map[string]interface{}{
-					"subscribe":   true,
-					"listChanged": true,
-				},
-				"tools": map[string]interface{}{
-					"listChanged": true,
-				},
-				"prompts": map[string]interface{}{
-					"listChanged": true,
-				},
-			},
-			"serverInfo": map[string]interface{}{
-				"name":    "siros-mcp",
-				"version": "1.0.0",
-			},
-			"instructions": "Siros MCP Server provides AI-powered cloud resource management tools.",
-		},
-	}
-
-	response := views.APIResponse{
-		Data: initResponse,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
-	}
-
-	views.WriteJSONResponse(w, http.StatusOK, response)
-}
-
-// ListResources handles POST /api/v1/mcp/resources/list
-func (c *MCPController) ListResources(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid MCP resources list request", err)
-		return
-	}
-
-	c.logger.Printf("MCP list resources request: %+v", req)
-
-	// TODO: Implement MCP resource listing
-
-	// MCP resources list response
-	resourcesResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      req["id"],
-		"result": map[string]interface{}{
-			"resources": []map[string]interface{}{
-				{
-					"uri":         "siros://resources",
-					"name":        "Cloud Resources",
-					"description": "Access to all cloud resources across providers",
-					"mimeType":    "application/json",
-				},
-				{
-					"uri":         "siros://relationships",
-					"name":        "Resource Relationships",
-					"description": "Resource relationship graph and dependencies",
-					"mimeType":    "application/json",
-				},
-				{
-					"uri":         "siros://audit",
-					"name":        "Audit Trail",
-					"description": "Blockchain-based audit trail for all changes",
-					"mimeType":    "application/json",
-				},
-				{
-					"uri":         "siros://terraform",
-					"name":        "Terraform State",
-					"description": "Terraform-managed resource mappings",
-					"mimeType":    "application/json",
-				},
-				{
-					"uri":         "siros://schemas",
-					"name":        "Resource Schemas",
-					"description": "Resource schemas and custom types",
-					"mimeType":    "application/json",
-				},
-			},
-		},
-	}
-
-	response := views.APIResponse{
-		Data: resourcesResponse,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
-	}
-
-	views.WriteJSONResponse(w, http.StatusOK, response)
-}
-
-// ReadResource handles POST /api/v1/mcp/resources/read
-func (c *MCPController) ReadResource(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid MCP resource read request", err)
-		return
-	}
-
-	c.logger.Printf("MCP read resource request: %+v", req)
-
-	// Extract URI from request
-	params, ok := req["params"].(map[string]interface{})
-	if !ok {
-		views.WriteBadRequest(w, "Missing parameters", nil)
-		return
-	}
-
-	uri, ok := params["uri"].(string)
-	if !ok {
-		views.WriteBadRequest(w, "Missing URI", nil)
-		return
-	}
-
-	// TODO: Implement actual resource reading based on URI
-
-	var contents []map[string]interface{}
-
-	// Handle different URI types
-	switch uri {
-	case "siros://resources":
-		contents = []map[string]interface{}{
-			{
-				"uri":      uri,
-				"mimeType": "application/json",
-				"text":     `{"resources": "placeholder for all cloud resources"}`,
-			},
-		}
-	case "siros://relationships":
-		contents = []map[string]interface{}{
-			{
-				"uri":      uri,
-				"mimeType": "application/json",
-				"text":     `{"relationships": "placeholder for resource relationships"}`,
-			},
-		}
-	case "siros://audit":
-		contents = []map[string]interface{}{
-			{
-				"uri":      uri,
-				"mimeType": "application/json",
-				"text":     `{"audit_trail": "placeholder for blockchain audit records"}`,
-			},
-		}
-	case "siros://terraform":
-		contents = []map[string]interface{}{
-			{
-				"uri":      uri,
-				"mimeType": "application/json",
-				"text":     `{"terraform_state": "placeholder for terraform mappings"}`,
-			},
-		}
-	case "siros://schemas":
-		contents = []map[string]interface{}{
-			{
-				"uri":      uri,
-				"mimeType": "application/json",
-				"text":     `{"schemas": "placeholder for resource schemas"}`,
-			},
-		}
-	default:
-		views.WriteNotFound(w, "Resource not found")
-		return
-	}
-
-	// MCP resource read response
-	readResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      req["id"],
-		"result": map[string]interface{}{
-			"contents": contents,
-		},
-	}
-
-	response := views.APIResponse{
-		Data: readResponse,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
-	}
-
-	views.WriteJSONResponse(w, http.StatusOK, response)
-}
-
-// ListTools handles POST /api/v1/mcp/tools/list
-func (c *MCPController) ListTools(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid MCP tools list request", err)
-		return
-	}
-
-	c.logger.Printf("MCP list tools request: %+v", req)
-
-	// TODO: Implement actual tool discovery
-
-	// MCP tools list response
-	toolsResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      req["id"],
-		"result": map[string]interface{}{
-			"tools": []map[string]interface{}{
-				{
-					"name":        "list_resources",
-					"description": "List cloud resources with filtering capabilities",
-					"inputSchema": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"provider": map[string]interface{}{
-								"type":        "string",
-								"description": "Cloud provider filter (aws, azure, gcp, oci)",
-							},
-							"type": map[string]interface{}{
-								"type":        "string",
-								"description": "Resource type filter",
-							},
-							"environment": map[string]interface{}{
-								"type":        "string",
-								"description": "Environment filter",
-							},
-						},
-					},
-				},
-				{
-					"name":        "search_resources",
-					"description": "Semantic search using vector embeddings",
-					"inputSchema": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"query": map[string]interface{}{
-								"type":        "string",
-								"description": "Search query",
-							},
-							"limit": map[string]interface{}{
-								"type":        "integer",
-								"description": "Maximum number of results",
-							},
-						},
-						"required": []string{"query"},
-					},
-				},
-				{
-					"name":        "get_audit_trail",
-					"description": "Get blockchain-based audit trail for a resource",
-					"inputSchema": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"resource_id": map[string]interface{}{
-								"type":        "string",
-								"description": "Resource ID to get audit trail for",
-							},
-						},
-						"required": []string{"resource_id"},
-					},
-				},
-				{
-					"name":        "analyze_coverage",
-					"description": "Analyze Terraform coverage vs discovered resources",
-					"inputSchema": map[string]interface{}{
-						"type": "object",
-						"properties": map[string]interface{}{
-							"provider": map[string]interface{}{
-								"type":        "string",
-								"description": "Cloud provider to analyze",
-							},
-						},
-					},
-				},
-			},
-		},
-	}
-
-	response := views.APIResponse{
-		Data: toolsResponse,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
-	}
-
-	views.WriteJSONResponse(w, http.StatusOK, response)
-}
-
-// CallTool handles POST /api/v1/mcp/tools/call
-func (c *MCPController) CallTool(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid MCP tool call request", err)
-		return
-	}
-
-	c.logger.Printf("MCP tool call request: %+v", req)
-
-	// Extract tool name and arguments
-	params, ok := req["params"].(map[string]interface{})
-	if !ok {
-		views.WriteBadRequest(w, "Missing parameters", nil)
-		return
-	}
-
-	toolName, ok := params["name"].(string)
-	if !ok {
-		views.WriteBadRequest(w, "Missing tool name", nil)
-		return
-	}
-
-	arguments, _ := params["arguments"].(map[string]interface{})
-
-	// TODO: Implement actual tool execution
-
-	var content []map[string]interface{}
-
-	// Handle different tools
-	switch toolName {
-	case "list_resources":
-		content = []map[string]interface{}{
-			{
-				"type": "text",
-				"text": "Placeholder: Listed cloud resources based on filters",
-			},
-		}
-	case "search_resources":
-		content = []map[string]interface{}{
-			{
-				"type": "text",
-				"text": "Placeholder: Semantic search results for query: " + fmt.Sprintf("%v", arguments["query"]),
-			},
-		}
-	case "get_audit_trail":
-		content = []map[string]interface{}{
-			{
-				"type": "text",
-				"text": "Placeholder: Audit trail for resource: " + fmt.Sprintf("%v", arguments["resource_id"]),
-			},
-		}
-	case "analyze_coverage":
-		content = []map[string]interface{}{
-			{
-				"type": "text",
-				"text": "Placeholder: Coverage analysis completed",
-			},
-		}
-	default:
-		views.WriteBadRequest(w, "Unknown tool: "+toolName, nil)
-		return
-	}
-
-	// MCP tool call response
-	callResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      req["id"],
-		"result": map[string]interface{}{
-			"content": content,
-		},
-	}
-
-	response := views.APIResponse{
-		Data: callResponse,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
-	}
-
-	views.WriteJSONResponse(w, http.StatusOK, response)
-}
-
-// ListPrompts handles POST /api/v1/mcp/prompts/list
-func (c *MCPController) ListPrompts(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid MCP prompts list request", err)
-		return
-	}
-
-	c.logger.Printf("MCP list prompts request: %+v", req)
-
-	// MCP prompts list response
-	promptsResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      req["id"],
-		"result": map[string]interface{}{
-			"prompts": []map[string]interface{}{
-				{
-					"name":        "resource_summary",
-					"description": "Generate comprehensive resource summary",
-					"arguments": []map[string]interface{}{
-						{
-							"name":        "resource_id",
-							"description": "Resource ID to summarize",
-							"required":    true,
-						},
-					},
-				},
-				{
-					"name":        "security_analysis",
-					"description": "Analyze security posture of resources",
-					"arguments": []map[string]interface{}{
-						{
-							"name":        "scope",
-							"description": "Analysis scope (resource, environment, provider)",
-							"required":    false,
-						},
-					},
-				},
-				{
-					"name":        "cost_optimization",
-					"description": "Provide cost optimization recommendations",
-					"arguments": []map[string]interface{}{
-						{
-							"name":        "provider",
-							"description": "Cloud provider to analyze",
-							"required":    false,
-						},
-					},
-				},
-			},
-		},
-	}
-
-	response := views.APIResponse{
-		Data: promptsResponse,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
-	}
-
-	views.WriteJSONResponse(w, http.StatusOK, response)
-}
-
-// GetPrompt handles POST /api/v1/mcp/prompts/get
-func (c *MCPController) GetPrompt(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid MCP prompt get request", err)
-		return
-	}
-
-	c.logger.Printf("MCP get prompt request: %+v", req)
-
-	// Extract prompt name and arguments
-	params, ok := req["params"].(map[string]interface{})
-	if !ok {
-		views.WriteBadRequest(w, "Missing parameters", nil)
-		return
-	}
-
-	name, ok := params["name"].(string)
-	if !ok {
-		views.WriteBadRequest(w, "Missing prompt name", nil)
-		return
-	}
-
-	arguments, _ := params["arguments"].(map[string]interface{})
-
-	// TODO: Implement actual prompt generation
-
-	var messages []map[string]interface{}
-
-	// Handle different prompts
-	switch name {
-	case "resource_summary":
-		resourceID, _ := arguments["resource_id"].(string)
-		messages = []map[string]interface{}{
-			{
-				"role": "user",
-				"content": map[string]interface{}{
-					"type": "text",
-					"text": fmt.Sprintf("Please analyze and summarize the cloud resource with ID: %s. Include details about configuration, dependencies, security posture, and recommendations.", resourceID),
-				},
-			},
-		}
-	case "security_analysis":
-		scope, _ := arguments["scope"].(string)
-		if scope == "" {
-			scope = "environment"
-		}
-		messages = []map[string]interface{}{
-			{
-				"role": "user",
-				"content": map[string]interface{}{
-					"type": "text",
-					"text": fmt.Sprintf("Perform a comprehensive security analysis for the specified %s. Review IAM permissions, network configurations, encryption settings, and identify potential vulnerabilities or misconfigurations.", scope),
-				},
-			},
-		}
-	case "cost_optimization":
-		provider, _ := arguments["provider"].(string)
-		if provider == "" {
-			provider = "all cloud providers"
-		}
-		messages = []map[string]interface{}{
-			{
-				"role": "user",
-				"content": map[string]interface{}{
-					"type": "text",
-					"text": fmt.Sprintf("Analyze resources in %s for cost optimization opportunities. Identify underutilized resources, suggest rightsizing, and recommend cost-effective alternatives.", provider),
-				},
-			},
-		}
-	default:
-		views.WriteBadRequest(w, "Unknown prompt: "+name, nil)
-		return
-	}
-
-	// MCP prompt get response
-	promptResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      req["id"],
-		"result": map[string]interface{}{
-			"description": fmt.Sprintf("Generated prompt for %s", name),
-			"messages":    messages,
-		},
-	}
-
-	response := views.APIResponse{
-		Data: promptResponse,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
-	}
-
-	views.WriteJSONResponse(w, http.StatusOK, response)
+	c.server.ServeHTTP(w, r)
 }
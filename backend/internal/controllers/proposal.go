@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/LederWorks/siros/backend/internal/api/middleware"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/internal/views"
+)
+
+// ProposalController handles the review of changes proposed by AI
+// assistants over MCP
+type ProposalController struct {
+	proposalService services.ProposalService
+	logger          Logger
+}
+
+// NewProposalController creates a new change proposal controller
+func NewProposalController(proposalService services.ProposalService, logger Logger) *ProposalController {
+	return &ProposalController{
+		proposalService: proposalService,
+		logger:          logger,
+	}
+}
+
+// available writes 503 Service Unavailable when no proposal service is
+// configured
+func (c *ProposalController) available(w http.ResponseWriter) bool {
+	if c.proposalService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Change proposals are not available", nil)
+		return false
+	}
+	return true
+}
+
+// List handles GET /api/v1/proposals, optionally filtered by ?status=
+func (c *ProposalController) List(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.ProposalStatusPending, models.ProposalStatusApproved, models.ProposalStatusApplied,
+		models.ProposalStatusFailed, models.ProposalStatusRejected:
+	default:
+		views.WriteBadRequest(w, "Invalid query parameters", fmt.Errorf("unknown proposal status: %s", status))
+		return
+	}
+
+	proposals, err := c.proposalService.ListProposals(r.Context(), status)
+	if err != nil {
+		c.logger.Printf("Failed to list change proposals: %v", err)
+		views.WriteInternalError(w, "Failed to list change proposals", err)
+		return
+	}
+
+	views.WriteProposalListResponse(w, http.StatusOK, proposals)
+}
+
+// Get handles GET /api/v1/proposals/{id}
+func (c *ProposalController) Get(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	proposal, err := c.proposalService.GetProposal(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		c.logger.Printf("Failed to get change proposal: %v", err)
+		c.writeError(w, "Failed to get change proposal", err)
+		return
+	}
+
+	views.WriteProposalResponse(w, http.StatusOK, proposal)
+}
+
+// Approve handles POST /api/v1/proposals/{id}/approve. The change is applied
+// on behalf of the authenticated user, who must not be its proposer.
+func (c *ProposalController) Approve(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	reviewer, ok := requestReviewer(w, r)
+	if !ok {
+		return
+	}
+	review, ok := decodeReview(w, r)
+	if !ok {
+		return
+	}
+
+	proposal, err := c.proposalService.ApproveProposal(r.Context(), mux.Vars(r)["id"], reviewer, review.Note)
+	if err != nil {
+		c.logger.Printf("Failed to approve change proposal: %v", err)
+		if proposal != nil && proposal.Status == models.ProposalStatusFailed {
+			views.WriteError(w, http.StatusConflict, "Change proposal could not be applied", err)
+			return
+		}
+		c.writeError(w, "Failed to approve change proposal", err)
+		return
+	}
+
+	views.WriteProposalResponse(w, http.StatusOK, proposal)
+}
+
+// Reject handles POST /api/v1/proposals/{id}/reject
+func (c *ProposalController) Reject(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	reviewer, ok := requestReviewer(w, r)
+	if !ok {
+		return
+	}
+	review, ok := decodeReview(w, r)
+	if !ok {
+		return
+	}
+
+	proposal, err := c.proposalService.RejectProposal(r.Context(), mux.Vars(r)["id"], reviewer, review.Note)
+	if err != nil {
+		c.logger.Printf("Failed to reject change proposal: %v", err)
+		c.writeError(w, "Failed to reject change proposal", err)
+		return
+	}
+
+	views.WriteProposalResponse(w, http.StatusOK, proposal)
+}
+
+// requestReviewer returns the authenticated user of a review, writing 401
+// Unauthorized when there is none. Unlike other writes, reviews are never
+// attributed to "system" or to a header the middleware did not authenticate,
+// since approving applies the change on the reviewer's behalf.
+func requestReviewer(w http.ResponseWriter, r *http.Request) (string, bool) {
+	reviewer := middleware.GetUser(r)
+	if reviewer == "" {
+		views.WriteUnauthorized(w, "Reviewing a change proposal requires an authenticated user")
+		return "", false
+	}
+	return reviewer, true
+}
+
+// decodeReview reads the optional body of an approval or rejection
+func decodeReview(w http.ResponseWriter, r *http.Request) (models.ProposalReview, bool) {
+	var review models.ProposalReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil && !errors.Is(err, io.EOF) {
+		views.WriteBadRequest(w, "Invalid request body", err)
+		return review, false
+	}
+	return review, true
+}
+
+// writeError maps service errors to their HTTP status
+func (c *ProposalController) writeError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrProposalSelfApproval):
+		views.WriteForbidden(w, err.Error())
+	case errors.Is(err, services.ErrProposalNotPending):
+		views.WriteConflict(w, "Change proposal was already reviewed", err)
+	case errors.Is(err, repositories.ErrNotFound):
+		views.WriteNotFound(w, "Change proposal")
+	default:
+		views.WriteInternalError(w, message, err)
+	}
+}
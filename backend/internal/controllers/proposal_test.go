@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/LederWorks/siros/backend/internal/api/middleware"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+	"github.com/LederWorks/siros/backend/internal/services"
+)
+
+// mockProposalService approves p1, fails to apply p2 and reports anything
+// else as missing
+type mockProposalService struct {
+	services.ProposalService
+	approver, note, status string
+}
+
+func (m *mockProposalService) ListProposals(_ context.Context, status string) ([]models.ChangeProposal, error) {
+	m.status = status
+	return nil, nil
+}
+
+func (m *mockProposalService) ApproveProposal(_ context.Context, id, approver, note string) (*models.ChangeProposal, error) {
+	m.approver, m.note = approver, note
+	switch {
+	case approver == "mcp:assistant":
+		return nil, fmt.Errorf("%w: %s", services.ErrProposalSelfApproval, approver)
+	case id == "p1":
+		return &models.ChangeProposal{ID: id, Status: models.ProposalStatusApplied, ReviewedBy: approver}, nil
+	case id == "p2":
+		return &models.ChangeProposal{ID: id, Status: models.ProposalStatusFailed}, services.ErrProposalStale
+	}
+	return nil, fmt.Errorf("change proposal %w: %s", repositories.ErrNotFound, id)
+}
+
+func (m *mockProposalService) RejectProposal(_ context.Context, id, _, _ string) (*models.ChangeProposal, error) {
+	return nil, fmt.Errorf("%w: %s is applied", services.ErrProposalNotPending, id)
+}
+
+func TestProposalController_Approve(t *testing.T) {
+	service := &mockProposalService{}
+	router := mux.NewRouter()
+	controller := NewProposalController(service, log.New(io.Discard, "", 0))
+	router.HandleFunc("/proposals/{id}/approve", controller.Approve).Methods("POST")
+	router.HandleFunc("/proposals/{id}/reject", controller.Reject).Methods("POST")
+
+	for _, tc := range []struct {
+		path, user, body string
+		status           int
+	}{
+		{"/proposals/p1/approve", "alice", `{"note": "ok"}`, http.StatusOK},
+		{"/proposals/p1/approve", "alice", "", http.StatusOK},
+		{"/proposals/p1/approve", "alice", "{", http.StatusBadRequest},
+		{"/proposals/p1/approve", "mcp:assistant", "", http.StatusForbidden},
+		{"/proposals/p2/approve", "alice", "", http.StatusConflict},
+		{"/proposals/p9/approve", "alice", "", http.StatusNotFound},
+		{"/proposals/p1/reject", "alice", "", http.StatusConflict},
+		{"/proposals/p1/approve", "", "", http.StatusUnauthorized},
+		{"/proposals/p1/reject", "", "", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+		if tc.user != "" {
+			req = req.WithContext(middleware.WithUser(req.Context(), tc.user))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("POST %s as %s with %q: status %d, want %d: %s", tc.path, tc.user, tc.body, w.Code, tc.status, w.Body.String())
+		}
+	}
+
+	// A header the middleware did not authenticate is ignored
+	service.approver = ""
+	req := httptest.NewRequest("POST", "/proposals/p1/approve", http.NoBody)
+	req.Header.Set("X-User", "alice")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || service.approver != "" {
+		t.Errorf("approval with only X-User: status %d by %q, want 401", w.Code, service.approver)
+	}
+
+	req = httptest.NewRequest("POST", "/proposals/p1/approve", strings.NewReader(`{"note": "ok"}`))
+	req = req.WithContext(middleware.WithUser(req.Context(), "alice"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response struct {
+		Data models.ChangeProposal `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Data.Status != models.ProposalStatusApplied || service.approver != "alice" || service.note != "ok" {
+		t.Errorf("approval response %+v by %s with note %q", response.Data, service.approver, service.note)
+	}
+}
+
+func TestProposalController_List(t *testing.T) {
+	service := &mockProposalService{}
+	controller := NewProposalController(service, log.New(io.Discard, "", 0))
+
+	w := httptest.NewRecorder()
+	controller.List(w, httptest.NewRequest("GET", "/api/v1/proposals?status=pending", http.NoBody))
+	if w.Code != http.StatusOK || service.status != models.ProposalStatusPending {
+		t.Errorf("status %d listing %q: %s", w.Code, service.status, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"data":[]`) {
+		t.Errorf("an empty listing is not an empty array: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	controller.List(w, httptest.NewRequest("GET", "/api/v1/proposals?status=done", http.NoBody))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown status, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	NewProposalController(nil, log.New(io.Discard, "", 0)).List(w, httptest.NewRequest("GET", "/api/v1/proposals", http.NoBody))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without a service, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
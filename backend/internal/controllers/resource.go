@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/LederWorks/siros/backend/internal/filter"
 	"github.com/LederWorks/siros/backend/internal/models"
 	"github.com/LederWorks/siros/backend/internal/services"
 	"github.com/LederWorks/siros/backend/internal/views"
@@ -53,6 +55,11 @@ func (c *ResourceController) CreateResource(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
+		if strings.Contains(err.Error(), "already exists") {
+			views.WriteConflict(w, "Resource already exists", err)
+			return
+		}
+
 		views.WriteInternalError(w, "Failed to create resource", err)
 		return
 	}
@@ -269,13 +276,13 @@ func (c *ResourceController) GetParents(w http.ResponseWriter, r *http.Request)
 
 // ListResources handles GET /api/v1/resources
 func (c *ResourceController) ListResources(w http.ResponseWriter, r *http.Request) {
-	query := c.parseSearchQuery(r)
+	query := parseSearchQuery(r)
 
-	resources, err := c.resourceService.ListResources(r.Context(), &query)
+	resources, page, err := c.resourceService.ListResources(r.Context(), &query)
 	if err != nil {
 		c.logger.Printf("Failed to list resources: %v", err)
 
-		if strings.Contains(err.Error(), "validation") {
+		if invalidQuery(err) {
 			views.WriteBadRequest(w, "Invalid query parameters", err)
 			return
 		}
@@ -284,7 +291,7 @@ func (c *ResourceController) ListResources(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	views.WriteResourceListResponse(w, http.StatusOK, resources)
+	views.WriteResourceListResponse(w, http.StatusOK, resources, page)
 }
 
 // SearchResources handles POST /api/v1/search
@@ -297,11 +304,11 @@ func (c *ResourceController) SearchResources(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	resources, err := c.resourceService.SearchResources(r.Context(), &query)
+	resources, page, err := c.resourceService.SearchResources(r.Context(), &query)
 	if err != nil {
 		c.logger.Printf("Failed to search resources: %v", err)
 
-		if strings.Contains(err.Error(), "validation") {
+		if invalidQuery(err) {
 			views.WriteBadRequest(w, "Invalid search query", err)
 			return
 		}
@@ -310,7 +317,7 @@ func (c *ResourceController) SearchResources(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	views.WriteSearchResponse(w, http.StatusOK, resources)
+	views.WriteSearchResponse(w, http.StatusOK, resources, page)
 }
 
 // GetResourcesByParent handles GET /api/v1/resources/{id}/children
@@ -330,11 +337,19 @@ func (c *ResourceController) GetResourcesByParent(w http.ResponseWriter, r *http
 		return
 	}
 
-	views.WriteResourceListResponse(w, http.StatusOK, resources)
+	views.WriteResourceListResponse(w, http.StatusOK, resources, nil)
+}
+
+// invalidQuery reports whether err rejects a request's query: one that failed
+// validation, a filter or sort the planner refused, or a stale cursor
+func invalidQuery(err error) bool {
+	var filterErr *filter.Error
+	return errors.As(err, &filterErr) || errors.Is(err, models.ErrInvalidCursor) ||
+		strings.Contains(err.Error(), "validation")
 }
 
 // parseSearchQuery parses query parameters into a SearchQuery model
-func (c *ResourceController) parseSearchQuery(r *http.Request) models.SearchQuery {
+func parseSearchQuery(r *http.Request) models.SearchQuery {
 	query := models.SearchQuery{
 		Filters: make(map[string]string),
 	}
@@ -343,10 +358,13 @@ func (c *ResourceController) parseSearchQuery(r *http.Request) models.SearchQuer
 
 	// Parse basic parameters
 	query.Query = queryParams.Get("q")
+	query.Filter = queryParams.Get("filter")
 	query.Provider = queryParams.Get("provider")
 	query.Type = queryParams.Get("type")
 	query.SortBy = queryParams.Get("sort_by")
 	query.SortOrder = queryParams.Get("sort_order")
+	query.Cursor = queryParams.Get("cursor")
+	query.Total = queryParams.Get("total")
 
 	// Parse limit and offset
 	if limitStr := queryParams.Get("limit"); limitStr != "" {
@@ -13,12 +13,14 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
 	"github.com/LederWorks/siros/backend/internal/models"
 )
 
 // Mock ResourceService for testing
 type mockResourceService struct {
 	resources map[string]*models.Resource
+	createErr error
 }
 
 func newMockResourceService() *mockResourceService {
@@ -28,6 +30,9 @@ func newMockResourceService() *mockResourceService {
 }
 
 func (m *mockResourceService) CreateResource(_ context.Context, req *models.CreateResourceRequest) (*models.Resource, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
 	resource := &models.Resource{
 		ID:       "test-id",
 		Type:     req.Type,
@@ -69,15 +74,18 @@ func (m *mockResourceService) DeleteResource(_ context.Context, id, _ string) er
 	return fmt.Errorf("resource not found: %s", id)
 }
 
-func (m *mockResourceService) ListResources(_ context.Context, _ *models.SearchQuery) ([]models.Resource, error) {
+func (m *mockResourceService) ListResources(_ context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	if _, err := query.Sort(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list resources: %w", err)
+	}
 	var result []models.Resource
 	for _, resource := range m.resources {
 		result = append(result, *resource)
 	}
-	return result, nil
+	return result, &models.PageInfo{}, nil
 }
 
-func (m *mockResourceService) SearchResources(_ context.Context, query *models.SearchQuery) ([]models.Resource, error) {
+func (m *mockResourceService) SearchResources(_ context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
 	return m.ListResources(context.Background(), query)
 }
 
@@ -147,6 +155,38 @@ func TestResourceController_CreateResource(t *testing.T) {
 	}
 }
 
+func TestResourceController_CreateResourceSchemaViolations(t *testing.T) {
+	mockService := newMockResourceService()
+	mockService.createErr = &models.SchemaValidationError{
+		Schema:  "custom-disk",
+		Version: "1",
+		Violations: []jsonschema.Error{
+			{InstancePath: "/size_gb", KeywordPath: "/properties/size_gb/type", Message: "must be integer, not string"},
+		},
+	}
+	controller := NewResourceController(mockService, log.New(os.Stderr, "test: ", log.LstdFlags))
+
+	body := `{"type": "disk", "provider": "custom", "name": "d1", "data": {"size_gb": "ten"}}`
+	req := httptest.NewRequest("POST", "/api/v1/resources", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	controller.CreateResource(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var response struct {
+		Error struct {
+			Violations []jsonschema.Error `json:"violations"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Error.Violations) != 1 || response.Error.Violations[0].InstancePath != "/size_gb" {
+		t.Errorf("Expected the violation of /size_gb, got %+v", response.Error.Violations)
+	}
+}
+
 func TestResourceController_GetResource(t *testing.T) {
 	// Setup
 	mockService := newMockResourceService()
@@ -326,6 +366,18 @@ func TestResourceController_ListResources(t *testing.T) {
 	}
 }
 
+func TestResourceController_ListResourcesUnknownSort(t *testing.T) {
+	controller := NewResourceController(newMockResourceService(), log.New(os.Stderr, "test: ", log.LstdFlags))
+
+	req := httptest.NewRequest("GET", "/api/v1/resources?sort_by=provider,password", http.NoBody)
+	w := httptest.NewRecorder()
+	controller.ListResources(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown sort field, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestResourceController_SearchResources(t *testing.T) {
 	// Setup
 	mockService := newMockResourceService()
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/internal/views"
+)
+
+// SavedSearchController handles saved search HTTP requests
+type SavedSearchController struct {
+	savedSearchService services.SavedSearchService
+	logger             Logger
+}
+
+// NewSavedSearchController creates a new saved search controller
+func NewSavedSearchController(savedSearchService services.SavedSearchService, logger Logger) *SavedSearchController {
+	return &SavedSearchController{
+		savedSearchService: savedSearchService,
+		logger:             logger,
+	}
+}
+
+// available writes 503 Service Unavailable when no saved search service is
+// configured
+func (c *SavedSearchController) available(w http.ResponseWriter) bool {
+	if c.savedSearchService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Saved searches are not available", nil)
+		return false
+	}
+	return true
+}
+
+// List handles GET /api/v1/saved-searches
+func (c *SavedSearchController) List(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	searches, err := c.savedSearchService.ListSearches(r.Context())
+	if err != nil {
+		c.logger.Printf("Failed to list saved searches: %v", err)
+		views.WriteInternalError(w, "Failed to list saved searches", err)
+		return
+	}
+
+	views.WriteSavedSearchListResponse(w, http.StatusOK, searches)
+}
+
+// Create handles POST /api/v1/saved-searches
+func (c *SavedSearchController) Create(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	var search models.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		views.WriteBadRequest(w, "Invalid request body", err)
+		return
+	}
+
+	if err := c.savedSearchService.CreateSearch(r.Context(), &search, requestActor(r)); err != nil {
+		c.logger.Printf("Failed to create saved search: %v", err)
+		c.writeError(w, "Failed to create saved search", err)
+		return
+	}
+
+	views.WriteSavedSearchResponse(w, http.StatusCreated, &search)
+}
+
+// Get handles GET /api/v1/saved-searches/{id}. Searches can also be named
+// by their name.
+func (c *SavedSearchController) Get(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	search, err := c.savedSearchService.GetSearch(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		c.logger.Printf("Failed to get saved search: %v", err)
+		c.writeError(w, "Failed to get saved search", err)
+		return
+	}
+
+	views.WriteSavedSearchResponse(w, http.StatusOK, search)
+}
+
+// Update handles PUT /api/v1/saved-searches/{id}
+func (c *SavedSearchController) Update(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	var search models.SavedSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		views.WriteBadRequest(w, "Invalid request body", err)
+		return
+	}
+
+	if err := c.savedSearchService.UpdateSearch(r.Context(), mux.Vars(r)["id"], &search, requestActor(r)); err != nil {
+		c.logger.Printf("Failed to update saved search: %v", err)
+		c.writeError(w, "Failed to update saved search", err)
+		return
+	}
+
+	views.WriteSavedSearchResponse(w, http.StatusOK, &search)
+}
+
+// Delete handles DELETE /api/v1/saved-searches/{id}
+func (c *SavedSearchController) Delete(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	if err := c.savedSearchService.DeleteSearch(r.Context(), mux.Vars(r)["id"]); err != nil {
+		c.logger.Printf("Failed to delete saved search: %v", err)
+		c.writeError(w, "Failed to delete saved search", err)
+		return
+	}
+
+	views.WriteNoContent(w)
+}
+
+// Resources handles GET /api/v1/saved-searches/{id}/resources, taking the
+// same parameters as the resource listing, whose filters narrow the search
+func (c *SavedSearchController) Resources(w http.ResponseWriter, r *http.Request) {
+	if !c.available(w) {
+		return
+	}
+
+	query := parseSearchQuery(r)
+	resources, page, err := c.savedSearchService.RunSearch(r.Context(), mux.Vars(r)["id"], &query)
+	if err != nil {
+		c.logger.Printf("Failed to run saved search: %v", err)
+		c.writeError(w, "Failed to run saved search", err)
+		return
+	}
+
+	views.WriteResourceListResponse(w, http.StatusOK, resources, page)
+}
+
+// writeError maps service errors to their HTTP status
+func (c *SavedSearchController) writeError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case invalidQuery(err):
+		views.WriteBadRequest(w, "Validation failed", err)
+	case strings.Contains(err.Error(), "not found"):
+		views.WriteNotFound(w, "Saved search")
+	case strings.Contains(err.Error(), "already exists"):
+		views.WriteConflict(w, "Saved search already exists", err)
+	default:
+		views.WriteInternalError(w, message, err)
+	}
+}
+
+// requestActor returns the user a request is made on behalf of
+func requestActor(r *http.Request) string {
+	// TODO: Get the actor from authentication context
+	if authUser := r.Header.Get("X-User"); authUser != "" {
+		return authUser
+	}
+	return "system"
+}
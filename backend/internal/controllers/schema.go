@@ -2,100 +2,195 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/internal/terraform"
 	"github.com/LederWorks/siros/backend/internal/views"
 )
 
 // SchemaController handles schema management related HTTP requests
 type SchemaController struct {
-	logger *log.Logger
-	// TODO: Add schema service dependencies
+	schemaService services.SchemaService
+	logger        *log.Logger
 }
 
 // NewSchemaController creates a new schema controller
-func NewSchemaController(logger *log.Logger) *SchemaController {
+func NewSchemaController(schemaService services.SchemaService, logger *log.Logger) *SchemaController {
 	return &SchemaController{
-		logger: logger,
+		schemaService: schemaService,
+		logger:        logger,
 	}
 }
 
-// List handles GET /api/v1/schemas
+// List handles GET /api/v1/schemas. Schemas are returned by name and can
+// be filtered by provider, type and custom.
 func (c *SchemaController) List(w http.ResponseWriter, r *http.Request) {
-	c.logger.Printf("List schemas request from %s", r.RemoteAddr)
-
-	// TODO: Implement schema listing
-
-	// Placeholder response
-	schemas := []map[string]interface{}{
-		{
-			"name":        "aws_instance",
-			"version":     "1.0",
-			"description": "AWS EC2 Instance schema",
-			"provider":    "aws",
-			"created_at":  "2024-01-01T00:00:00Z",
-		},
-		{
-			"name":        "azure_vm",
-			"version":     "1.0",
-			"description": "Azure Virtual Machine schema",
-			"provider":    "azure",
-			"created_at":  "2024-01-01T00:00:00Z",
-		},
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema listing is not available", nil)
+		return
 	}
 
-	response := views.APIResponse{
-		Data: map[string]interface{}{
-			"schemas": schemas,
-			"total":   len(schemas),
-		},
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
+	params := r.URL.Query()
+	query := models.SchemaQuery{
+		Provider: params.Get("provider"),
+		Type:     params.Get("type"),
+		Cursor:   params.Get("cursor"),
+		Total:    params.Get("total"),
+	}
+	if limit, err := strconv.Atoi(params.Get("limit")); err == nil {
+		query.Limit = limit
+	}
+	if custom := params.Get("custom"); custom != "" {
+		isCustom, err := strconv.ParseBool(custom)
+		if err != nil {
+			views.WriteBadRequest(w, "Invalid query parameters", err)
+			return
+		}
+		query.Custom = &isCustom
 	}
 
-	views.WriteJSONResponse(w, http.StatusOK, response)
+	schemas, page, err := c.schemaService.ListSchemas(r.Context(), &query)
+	if err != nil {
+		c.logger.Printf("Failed to list schemas: %v", err)
+
+		if invalidQuery(err) {
+			views.WriteBadRequest(w, "Invalid query parameters", err)
+			return
+		}
+
+		views.WriteInternalError(w, "Failed to list schemas", err)
+		return
+	}
+
+	if schemas == nil {
+		schemas = []models.Schema{}
+	}
+	views.WriteSchemaListResponse(w, http.StatusOK, schemas, page)
 }
 
-// Create handles POST /api/v1/schemas
+// Create handles POST /api/v1/schemas. Schemas registered through the API
+// are custom; registering a greater version of an existing schema adds a
+// version.
 func (c *SchemaController) Create(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema management is not available", nil)
+		return
+	}
+
+	var schema models.Schema
+	if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
 		views.WriteBadRequest(w, "Invalid request body", err)
 		return
 	}
+	schema.IsCustom = true
 
-	c.logger.Printf("Create schema request: %+v", req)
+	if err := c.schemaService.CreateSchema(r.Context(), &schema); err != nil {
+		c.logger.Printf("Failed to create schema %s: %v", schema.Name, err)
+		c.writeSchemaError(w, "Failed to create schema", err)
+		return
+	}
 
-	// TODO: Validate and create schema
+	c.logger.Printf("Created schema %s/%s version %s", schema.Provider, schema.Name, schema.Version)
+	views.WriteSchemaResponse(w, http.StatusCreated, &schema)
+}
 
-	// Placeholder response
-	schema := map[string]interface{}{
-		"name":        req["name"],
-		"version":     "1.0",
-		"description": req["description"],
-		"provider":    req["provider"],
-		"created_at":  time.Now().Format(time.RFC3339),
+// Get handles GET /api/v1/schemas/{name}, returning the latest version of
+// the schema registered for the provider query parameter
+func (c *SchemaController) Get(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema management is not available", nil)
+		return
 	}
 
-	response := views.APIResponse{
-		Data: schema,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		views.WriteBadRequest(w, "Schema name is required", nil)
+		return
+	}
+
+	schema, err := c.schemaService.GetSchema(r.Context(), name, r.URL.Query().Get("provider"))
+	if err != nil {
+		c.logger.Printf("Failed to get schema %s: %v", name, err)
+		c.writeSchemaError(w, "Failed to get schema", err)
+		return
 	}
 
-	views.WriteJSONResponse(w, http.StatusCreated, response)
+	views.WriteSchemaResponse(w, http.StatusOK, schema)
 }
 
-// Get handles GET /api/v1/schemas/{name}
-func (c *SchemaController) Get(w http.ResponseWriter, r *http.Request) {
+// Update handles PUT /api/v1/schemas/{name}, replacing the version the body
+// names, by default the latest. Predefined schemas cannot be updated.
+func (c *SchemaController) Update(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema management is not available", nil)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		views.WriteBadRequest(w, "Schema name is required", nil)
+		return
+	}
+
+	var schema models.Schema
+	if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
+		views.WriteBadRequest(w, "Invalid request body", err)
+		return
+	}
+	schema.IsCustom = true
+
+	if err := c.schemaService.UpdateSchema(r.Context(), name, r.URL.Query().Get("provider"), &schema); err != nil {
+		c.logger.Printf("Failed to update schema %s: %v", name, err)
+		c.writeSchemaError(w, "Failed to update schema", err)
+		return
+	}
+
+	c.logger.Printf("Updated schema %s/%s version %s", schema.Provider, schema.Name, schema.Version)
+	views.WriteSchemaResponse(w, http.StatusOK, &schema)
+}
+
+// Delete handles DELETE /api/v1/schemas/{name}, removing every version of
+// a custom schema
+func (c *SchemaController) Delete(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema management is not available", nil)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		views.WriteBadRequest(w, "Schema name is required", nil)
+		return
+	}
+
+	if err := c.schemaService.DeleteSchema(r.Context(), name, r.URL.Query().Get("provider")); err != nil {
+		c.logger.Printf("Failed to delete schema %s: %v", name, err)
+		c.writeSchemaError(w, "Failed to delete schema", err)
+		return
+	}
+
+	c.logger.Printf("Deleted schema %s", name)
+	views.WriteNoContent(w)
+}
+
+// Validate handles POST /api/v1/schemas/{name}/validate. The body is the
+// resource data to check; every violation is reported whatever the schema's
+// mode.
+func (c *SchemaController) Validate(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema validation is not available", nil)
+		return
+	}
+
 	vars := mux.Vars(r)
 	name := vars["name"]
 
@@ -104,33 +199,21 @@ func (c *SchemaController) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c.logger.Printf("Get schema %s request from %s", name, r.RemoteAddr)
-
-	// TODO: Fetch schema by name
-
-	// Placeholder response
-	schema := map[string]interface{}{
-		"name":        name,
-		"version":     "1.0",
-		"description": "Schema description",
-		"provider":    "aws",
-		"created_at":  "2024-01-01T00:00:00Z",
-		"schema": map[string]interface{}{
-			"type": "object",
-			"properties": map[string]interface{}{
-				"id": map[string]interface{}{
-					"type": "string",
-				},
-				"name": map[string]interface{}{
-					"type": "string",
-				},
-			},
-			"required": []string{"id", "name"},
-		},
+	var data map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		views.WriteBadRequest(w, "Invalid request body", err)
+		return
+	}
+
+	result, err := c.schemaService.ValidateData(r.Context(), name, r.URL.Query().Get("provider"), data)
+	if err != nil {
+		c.logger.Printf("Failed to validate against schema %s: %v", name, err)
+		c.writeSchemaError(w, "Failed to validate data", err)
+		return
 	}
 
 	response := views.APIResponse{
-		Data: schema,
+		Data: result,
 		Meta: &views.Meta{
 			Timestamp: time.Now(),
 			Version:   "1.0",
@@ -140,37 +223,55 @@ func (c *SchemaController) Get(w http.ResponseWriter, r *http.Request) {
 	views.WriteJSONResponse(w, http.StatusOK, response)
 }
 
-// Update handles PUT /api/v1/schemas/{name}
-func (c *SchemaController) Update(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	name := vars["name"]
+// Versions handles GET /api/v1/schemas/{name}/versions. Versions are listed
+// oldest first with their compatibility with the version before them.
+func (c *SchemaController) Versions(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema versions are not available", nil)
+		return
+	}
 
+	name := mux.Vars(r)["name"]
 	if name == "" {
 		views.WriteBadRequest(w, "Schema name is required", nil)
 		return
 	}
 
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid request body", err)
+	versions, err := c.schemaService.ListVersions(r.Context(), name, r.URL.Query().Get("provider"))
+	if err != nil {
+		c.logger.Printf("Failed to list versions of schema %s: %v", name, err)
+		c.writeSchemaError(w, "Failed to list schema versions", err)
 		return
 	}
 
-	c.logger.Printf("Update schema %s request: %+v", name, req)
+	views.WriteSchemaListResponse(w, http.StatusOK, versions, nil)
+}
+
+// Compatibility handles GET /api/v1/schemas/{name}/compatibility, comparing
+// version from with version to. To defaults to the latest version and from
+// to the one before it.
+func (c *SchemaController) Compatibility(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema compatibility is not available", nil)
+		return
+	}
 
-	// TODO: Validate and update schema
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		views.WriteBadRequest(w, "Schema name is required", nil)
+		return
+	}
 
-	// Placeholder response
-	schema := map[string]interface{}{
-		"name":        name,
-		"version":     "1.1",
-		"description": req["description"],
-		"provider":    req["provider"],
-		"updated_at":  time.Now().Format(time.RFC3339),
+	params := r.URL.Query()
+	result, err := c.schemaService.CompareVersions(r.Context(), name, params.Get("provider"), params.Get("from"), params.Get("to"))
+	if err != nil {
+		c.logger.Printf("Failed to compare versions of schema %s: %v", name, err)
+		c.writeSchemaError(w, "Failed to compare schema versions", err)
+		return
 	}
 
 	response := views.APIResponse{
-		Data: schema,
+		Data: result,
 		Meta: &views.Meta{
 			Timestamp: time.Now(),
 			Version:   "1.0",
@@ -180,53 +281,80 @@ func (c *SchemaController) Update(w http.ResponseWriter, r *http.Request) {
 	views.WriteJSONResponse(w, http.StatusOK, response)
 }
 
-// Delete handles DELETE /api/v1/schemas/{name}
-func (c *SchemaController) Delete(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	name := vars["name"]
+// Migrate handles POST /api/v1/schemas/{name}/migrate, upgrading the
+// resources of the schema's type to a newer version
+func (c *SchemaController) Migrate(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema migrations are not available", nil)
+		return
+	}
 
+	name := mux.Vars(r)["name"]
 	if name == "" {
 		views.WriteBadRequest(w, "Schema name is required", nil)
 		return
 	}
 
-	c.logger.Printf("Delete schema %s request from %s", name, r.RemoteAddr)
+	var req models.SchemaMigrationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			views.WriteBadRequest(w, "Invalid request body", err)
+			return
+		}
+	}
 
-	// TODO: Delete schema
+	report, err := c.schemaService.MigrateResources(r.Context(), name, r.URL.Query().Get("provider"), &req, requestActor(r))
+	if err != nil {
+		c.logger.Printf("Failed to migrate resources of schema %s: %v", name, err)
+		c.writeSchemaError(w, "Failed to migrate resources", err)
+		return
+	}
 
-	views.WriteNoContent(w)
-}
+	response := views.APIResponse{
+		Data: report,
+		Meta: &views.Meta{
+			Timestamp: time.Now(),
+			Version:   "1.0",
+		},
+	}
 
-// Validate handles POST /api/v1/schemas/{name}/validate
-func (c *SchemaController) Validate(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	name := vars["name"]
+	views.WriteJSONResponse(w, http.StatusOK, response)
+}
 
-	if name == "" {
-		views.WriteBadRequest(w, "Schema name is required", nil)
+// ImportTerraform handles POST /api/v1/schemas:terraform. The body is the
+// output of `terraform providers schema -json`, optionally with the
+// provider_selections of `terraform version -json` to version the schemas
+// by; dry_run=true reports what would change without registering anything.
+func (c *SchemaController) ImportTerraform(w http.ResponseWriter, r *http.Request) {
+	if c.schemaService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Schema import is not available", nil)
 		return
 	}
 
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			views.WriteBadRequest(w, "Invalid query parameters", err)
+			return
+		}
+	}
+
+	var schemas terraform.ProviderSchemas
+	if err := json.NewDecoder(r.Body).Decode(&schemas); err != nil {
 		views.WriteBadRequest(w, "Invalid request body", err)
 		return
 	}
 
-	c.logger.Printf("Validate against schema %s request: %+v", name, req)
-
-	// TODO: Validate data against schema
-
-	// Placeholder response
-	validation := map[string]interface{}{
-		"schema":   name,
-		"valid":    true,
-		"errors":   []string{},
-		"warnings": []string{},
+	report, err := c.schemaService.ImportTerraformSchemas(r.Context(), &schemas, dryRun)
+	if err != nil {
+		c.logger.Printf("Failed to import Terraform schemas: %v", err)
+		c.writeSchemaError(w, "Failed to import Terraform schemas", err)
+		return
 	}
 
 	response := views.APIResponse{
-		Data: validation,
+		Data: report,
 		Meta: &views.Meta{
 			Timestamp: time.Now(),
 			Version:   "1.0",
@@ -235,3 +363,19 @@ func (c *SchemaController) Validate(w http.ResponseWriter, r *http.Request) {
 
 	views.WriteJSONResponse(w, http.StatusOK, response)
 }
+
+// writeSchemaError maps schema service errors to responses
+func (c *SchemaController) writeSchemaError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrPredefinedSchema):
+		views.WriteForbidden(w, err.Error())
+	case strings.Contains(err.Error(), "already exists"):
+		views.WriteConflict(w, "Schema already exists", err)
+	case strings.Contains(err.Error(), "not found"):
+		views.WriteNotFound(w, "Schema")
+	case invalidQuery(err):
+		views.WriteBadRequest(w, message, err)
+	default:
+		views.WriteInternalError(w, message, err)
+	}
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/services"
+)
+
+// mockSchemaService records the schemas passed to it; unused methods panic
+type mockSchemaService struct {
+	services.SchemaService
+	created *models.Schema
+	query   *models.SchemaQuery
+}
+
+func (m *mockSchemaService) CreateSchema(_ context.Context, schema *models.Schema) error {
+	m.created = schema
+	return nil
+}
+
+func (m *mockSchemaService) ListSchemas(_ context.Context, query *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error) {
+	m.query = query
+	return nil, &models.PageInfo{}, nil
+}
+
+func (m *mockSchemaService) DeleteSchema(_ context.Context, name, provider string) error {
+	if provider == "aws" {
+		return fmt.Errorf("cannot delete %s: %w", name, models.ErrPredefinedSchema)
+	}
+	return fmt.Errorf("schema not found: %s/%s", provider, name)
+}
+
+func TestSchemaController(t *testing.T) {
+	service := &mockSchemaService{}
+	controller := NewSchemaController(service, log.New(io.Discard, "", 0))
+	router := mux.NewRouter()
+	router.HandleFunc("/schemas", controller.List).Methods("GET")
+	router.HandleFunc("/schemas", controller.Create).Methods("POST")
+	router.HandleFunc("/schemas/{name}", controller.Delete).Methods("DELETE")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{name: "create", method: "POST", target: "/schemas", body: `{"name":"disk","provider":"custom","is_custom":false}`, want: http.StatusCreated},
+		{name: "list", method: "GET", target: "/schemas?custom=true&provider=aws", want: http.StatusOK},
+		{name: "invalid custom filter", method: "GET", target: "/schemas?custom=maybe", want: http.StatusBadRequest},
+		{name: "delete predefined", method: "DELETE", target: "/schemas/disk?provider=aws", want: http.StatusForbidden},
+		{name: "delete missing", method: "DELETE", target: "/schemas/disk?provider=gcp", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d: %s", tt.method, tt.target, w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	if service.created == nil || !service.created.IsCustom {
+		t.Errorf("Create() passed %+v, want a custom schema", service.created)
+	}
+	if q := service.query; q == nil || q.Provider != "aws" || q.Custom == nil || !*q.Custom {
+		t.Errorf("List() passed query %+v, want custom aws schemas", q)
+	}
+}
@@ -2,23 +2,27 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/LederWorks/siros/backend/internal/providers"
+	"github.com/LederWorks/siros/backend/internal/services"
 	"github.com/LederWorks/siros/backend/internal/views"
 )
 
 // SearchController handles search and discovery related HTTP requests
 type SearchController struct {
-	logger *log.Logger
-	// TODO: Add search service dependencies
+	searchService services.SearchService
+	logger        *log.Logger
 }
 
 // NewSearchController creates a new search controller
-func NewSearchController(logger *log.Logger) *SearchController {
+func NewSearchController(searchService services.SearchService, logger *log.Logger) *SearchController {
 	return &SearchController{
-		logger: logger,
+		searchService: searchService,
+		logger:        logger,
 	}
 }
 
@@ -136,35 +140,41 @@ func (c *SearchController) Similarity(w http.ResponseWriter, r *http.Request) {
 	views.WriteJSONResponse(w, http.StatusOK, response)
 }
 
-// ScanProviders handles POST /api/v1/discovery/scan (trigger cloud provider scanning)
+// ScanProviders handles POST /api/v1/discovery/scan. The named providers,
+// or every configured provider when none are named, are scanned into the
+// inventory before the scan's counts and errors are returned.
 func (c *SearchController) ScanProviders(w http.ResponseWriter, r *http.Request) {
-	var req map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		views.WriteBadRequest(w, "Invalid request body", err)
+	if c.searchService == nil {
+		views.WriteError(w, http.StatusServiceUnavailable, "Provider scanning is not available", nil)
 		return
 	}
 
-	c.logger.Printf("Scan providers request: %+v", req)
-
-	// TODO: Implement cloud provider resource discovery
-
-	// Placeholder response
-	scan := map[string]interface{}{
-		"scan_id":    "scan-123",
-		"status":     "started",
-		"providers":  req["providers"],
-		"started_at": time.Now().Format(time.RFC3339),
+	var req struct {
+		Providers []string `json:"providers"`
 	}
-
-	response := views.APIResponse{
-		Data: scan,
-		Meta: &views.Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-		},
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			views.WriteBadRequest(w, "Invalid request body", err)
+			return
+		}
 	}
 
-	views.WriteJSONResponse(w, http.StatusAccepted, response)
+	result, err := c.searchService.ScanProviders(r.Context(), req.Providers)
+	switch {
+	case errors.Is(err, providers.ErrProviderNotFound):
+		views.WriteBadRequest(w, "Unknown provider", err)
+		return
+	case errors.Is(err, services.ErrScanUnavailable):
+		views.WriteError(w, http.StatusServiceUnavailable, "Provider scanning is not available", err)
+		return
+	case result == nil:
+		views.WriteInternalError(w, "Failed to scan providers", err)
+		return
+	}
+	if err != nil {
+		c.logger.Printf("Provider scan failed: %v", err)
+	}
+	views.WriteScanResponse(w, *result, err)
 }
 
 // DiscoverRelationships handles POST /api/v1/discovery/relationships (discover resource relationships)
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/providers"
+	"github.com/LederWorks/siros/backend/internal/services"
+)
+
+// mockSearchService scans the providers it knows, failing for "broken"
+type mockSearchService struct {
+	services.SearchService
+	scanned []string
+}
+
+func (m *mockSearchService) ScanProviders(_ context.Context, names []string) (*services.ProviderScanResult, error) {
+	m.scanned = names
+	for _, name := range names {
+		switch name {
+		case "missing":
+			return nil, fmt.Errorf("%w: %s", providers.ErrProviderNotFound, name)
+		case "broken":
+			result := services.ProviderScanResult{"status": "failed"}
+			return &result, errors.New("failed to scan provider broken")
+		}
+	}
+	result := services.ProviderScanResult{"status": "completed", "providers": names}
+	return &result, nil
+}
+
+func TestSearchController_ScanProviders(t *testing.T) {
+	service := &mockSearchService{}
+	controller := NewSearchController(service, log.New(io.Discard, "", 0))
+
+	scan := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/discovery/scan", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		controller.ScanProviders(w, req)
+		return w
+	}
+
+	w := scan(`{"providers": ["aws"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if response.Data["status"] != "completed" || len(service.scanned) != 1 || service.scanned[0] != "aws" {
+		t.Errorf("Expected the aws scan result, got %+v", response.Data)
+	}
+
+	if w := scan(""); w.Code != http.StatusOK || service.scanned != nil {
+		t.Errorf("Expected an empty body to scan every provider, got %d %v", w.Code, service.scanned)
+	}
+	if w := scan(`{"providers": ["missing"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown provider, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = scan(`{"providers": ["broken"]}`)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status %d for a failed scan, got %d", http.StatusBadGateway, w.Code)
+	}
+	var failed struct {
+		Data  map[string]interface{}    `json:"data"`
+		Error *struct{ Details string } `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &failed); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if failed.Data["status"] != "failed" || failed.Error == nil {
+		t.Errorf("Expected the failed scan's result and error, got %s", w.Body.String())
+	}
+
+	unavailable := NewSearchController(nil, log.New(io.Discard, "", 0))
+	w = httptest.NewRecorder()
+	unavailable.ScanProviders(w, httptest.NewRequest("POST", "/api/v1/discovery/scan", strings.NewReader("{}")))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without a service, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
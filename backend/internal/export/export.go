@@ -0,0 +1,198 @@
+// Package export writes resources as JSON Lines, CSV or Parquet, one
+// resource at a time, so that inventories of any size can be streamed.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// Export formats
+const (
+	FormatJSONL   = "jsonl"
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// DefaultColumns are the columns of CSV and Parquet exports when none are
+// selected. Maps such as data and metadata.tags are written as JSON.
+var DefaultColumns = []string{
+	"id", "provider", "type", "name", "parent_id",
+	"metadata.region", "metadata.environment", "metadata.cost_center", "metadata.tags",
+	"data", "created_at", "modified_at",
+}
+
+// Writer writes exported resources
+type Writer interface {
+	// Write appends a resource
+	Write(resource *models.Resource) error
+	// Close completes the export; it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter returns a writer for format. Columns are dotted paths into the
+// resource's JSON form, such as data.instance_type or metadata.tags.env.
+// JSON Lines exports write whole resources unless columns are given.
+func NewWriter(w io.Writer, format string, columns []string) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w), columns: columns}, nil
+	case FormatCSV:
+		if len(columns) == 0 {
+			columns = DefaultColumns
+		}
+		return newCSVWriter(w, columns), nil
+	case FormatParquet:
+		if len(columns) == 0 {
+			columns = DefaultColumns
+		}
+		return newParquetWriter(w, columns), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ContentType returns the media type of an export format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// jsonlWriter writes one JSON object per line
+type jsonlWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func (w *jsonlWriter) Write(resource *models.Resource) error {
+	if len(w.columns) == 0 {
+		exported := *resource
+		exported.Vector = nil
+		return w.enc.Encode(&exported)
+	}
+
+	doc, err := document(resource)
+	if err != nil {
+		return err
+	}
+	row := make(map[string]interface{}, len(w.columns))
+	for _, column := range w.columns {
+		if value, ok := lookup(doc, column); ok {
+			row[column] = value
+		}
+	}
+	return w.enc.Encode(row)
+}
+
+func (w *jsonlWriter) Close() error { return nil }
+
+// csvWriter writes a header and one row per resource
+type csvWriter struct {
+	csv     *csv.Writer
+	columns []string
+	header  bool
+	row     []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) *csvWriter {
+	return &csvWriter{csv: csv.NewWriter(w), columns: columns, row: make([]string, len(columns))}
+}
+
+func (w *csvWriter) Write(resource *models.Resource) error {
+	if !w.header {
+		if err := w.csv.Write(w.columns); err != nil {
+			return err
+		}
+		w.header = true
+	}
+
+	doc, err := document(resource)
+	if err != nil {
+		return err
+	}
+	for i, column := range w.columns {
+		w.row[i], _ = flatten(doc, column)
+	}
+	return w.csv.Write(w.row)
+}
+
+func (w *csvWriter) Close() error {
+	if !w.header {
+		if err := w.csv.Write(w.columns); err != nil {
+			return err
+		}
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// document returns the JSON form of a resource as generic values
+func document(resource *models.Resource) (map[string]interface{}, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource %s: %w", resource.ID, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode resource %s: %w", resource.ID, err)
+	}
+	return doc, nil
+}
+
+// lookup resolves a dotted path. Keys may themselves contain dots, as tag
+// keys such as kubernetes.io/name do, so the longest matching key wins.
+func lookup(doc map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := doc[path]; ok {
+		return value, true
+	}
+	for i := strings.LastIndex(path, "."); i > 0; i = strings.LastIndex(path[:i], ".") {
+		value, ok := doc[path[:i]]
+		if !ok {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if found, ok := lookup(nested, path[i+1:]); ok {
+				return found, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// flatten resolves a path to a single cell value. Nested objects and arrays
+// are written as JSON, and paths that do not resolve report false.
+func flatten(doc map[string]interface{}, path string) (string, bool) {
+	value, ok := lookup(doc, path)
+	if !ok || value == nil {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return fmt.Sprint(v), true
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+func testResources() []models.Resource {
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	return []models.Resource{
+		{
+			ID: "i-0abc", Provider: "aws", Type: "ec2.instance", Name: "web-1",
+			Data: map[string]interface{}{"instance_type": "m5.large", "cpu": 2, "nested": map[string]interface{}{"a": 1}},
+			Metadata: models.ResourceMetadata{
+				Region: "eu-west-1",
+				Tags:   map[string]string{"env": "prod", "kubernetes.io/name": "web"},
+			},
+			Vector:    []float32{0.1, 0.2},
+			CreatedAt: at, ModifiedAt: at,
+		},
+		{ID: "bucket", Provider: "aws", Type: "s3.bucket", Name: "logs", CreatedAt: at, ModifiedAt: at},
+	}
+}
+
+func export(t *testing.T, format string, columns []string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, columns)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	resources := testResources()
+	for i := range resources {
+		if err := w.Write(&resources[i]); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriter_JSONL(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(export(t, FormatJSONL, nil))), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	var resource models.Resource
+	if err := json.Unmarshal([]byte(lines[0]), &resource); err != nil {
+		t.Fatalf("Failed to decode line: %v", err)
+	}
+	if resource.ID != "i-0abc" || resource.Vector != nil {
+		t.Errorf("Expected the whole resource without its vector, got %+v", resource)
+	}
+
+	selected := strings.Split(string(export(t, FormatJSONL, []string{"id", "data.nested", "metadata.tags.kubernetes.io/name"})), "\n")
+	if selected[0] != `{"data.nested":{"a":1},"id":"i-0abc","metadata.tags.kubernetes.io/name":"web"}` {
+		t.Errorf("Unexpected selected columns: %s", selected[0])
+	}
+}
+
+func TestWriter_CSV(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewReader(export(t, FormatCSV, []string{"id", "data.cpu", "data.nested", "metadata.tags.env", "created_at"}))).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	want := [][]string{
+		{"id", "data.cpu", "data.nested", "metadata.tags.env", "created_at"},
+		{"i-0abc", "2", `{"a":1}`, "prod", "2026-03-01T10:00:00Z"},
+		{"bucket", "", "", "", "2026-03-01T10:00:00Z"},
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	header, _ := csv.NewReader(bytes.NewReader(export(t, FormatCSV, nil))).Read()
+	if len(header) != len(DefaultColumns) {
+		t.Errorf("Expected the default columns, got %v", header)
+	}
+}
+
+func TestWriter_Parquet(t *testing.T) {
+	file := export(t, FormatParquet, []string{"id", "metadata.region"})
+	if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+		t.Fatal("Expected the file to start and end with PAR1")
+	}
+
+	size := binary.LittleEndian.Uint32(file[len(file)-8:])
+	footer := readThrift(t, bytes.NewReader(file[len(file)-8-int(size):len(file)-8]))
+	if footer[3] != int64(2) {
+		t.Errorf("Expected 2 rows, got %v", footer[3])
+	}
+	schema := footer[2].([]interface{})
+	if len(schema) != 3 || string(schema[2].(map[int16]interface{})[4].([]byte)) != "metadata.region" {
+		t.Errorf("Unexpected schema: %v", schema)
+	}
+
+	// Read the region column chunk back through its page header
+	group := footer[4].([]interface{})[0].(map[int16]interface{})
+	chunk := group[1].([]interface{})[1].(map[int16]interface{})
+	offset := chunk[2].(int64)
+	page := bytes.NewReader(file[offset:])
+	header := readThrift(t, page)
+	if header[5].(map[int16]interface{})[1] != int64(2) {
+		t.Errorf("Expected a page of 2 values, got %v", header)
+	}
+	data := make([]byte, header[2].(int64))
+	_, _ = page.Read(data)
+
+	levelsLength := binary.LittleEndian.Uint32(data)
+	levels := data[4 : 4+levelsLength]
+	if !bytes.Equal(levels, []byte{2, 1, 2, 0}) {
+		t.Errorf("Expected one defined then one null value, got levels %v", levels)
+	}
+	values := data[4+levelsLength:]
+	if n := binary.LittleEndian.Uint32(values); string(values[4:4+n]) != "eu-west-1" || int(4+n) != len(values) {
+		t.Errorf("Unexpected values: %q", values)
+	}
+}
+
+// readThrift decodes a Thrift compact struct into field values keyed by ID
+func readThrift(t *testing.T, r *bytes.Reader) map[int16]interface{} {
+	t.Helper()
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			t.Fatalf("Truncated struct: %v", err)
+		}
+		if b == 0 {
+			return fields
+		}
+		if delta := int16(b >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(readZigzag(t, r))
+		}
+		fields[id] = readThriftValue(t, r, b&0x0f)
+	}
+}
+
+func readThriftValue(t *testing.T, r *bytes.Reader, typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return readZigzag(t, r)
+	case thriftBinary:
+		n, _ := binary.ReadUvarint(r)
+		data := make([]byte, n)
+		_, _ = r.Read(data)
+		return data
+	case thriftStruct:
+		return readThrift(t, r)
+	case thriftList:
+		header, _ := r.ReadByte()
+		n := uint64(header >> 4)
+		if n == 15 {
+			n, _ = binary.ReadUvarint(r)
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = readThriftValue(t, r, header&0x0f)
+		}
+		return list
+	default:
+		t.Fatalf("Unexpected Thrift type %d", typ)
+		return nil
+	}
+}
+
+func readZigzag(t *testing.T, r *bytes.Reader) int64 {
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		t.Fatalf("Bad varint: %v", err)
+	}
+	return int64(v>>1) ^ -int64(v&1)
+}
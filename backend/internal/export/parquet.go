@@ -0,0 +1,329 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// parquetRowGroupSize is the number of rows buffered before a row group is
+// written
+const parquetRowGroupSize = 10000
+
+// parquetMagic starts and ends every Parquet file
+const parquetMagic = "PAR1"
+
+// Parquet enum values, from the parquet-format Thrift definitions
+const (
+	parquetByteArray  = 6 // Type BYTE_ARRAY
+	parquetOptional   = 1 // FieldRepetitionType OPTIONAL
+	parquetUTF8       = 0 // ConvertedType UTF8
+	parquetDataPage   = 0 // PageType DATA_PAGE
+	parquetPlain      = 0 // Encoding PLAIN
+	parquetRLE        = 3 // Encoding RLE
+	parquetCompressed = 0 // CompressionCodec UNCOMPRESSED
+)
+
+// parquetWriter writes an uncompressed Parquet file of optional string
+// columns, one row group per parquetRowGroupSize rows. Only the footer is
+// held back until Close, so row groups stream as they fill.
+type parquetWriter struct {
+	w       io.Writer
+	offset  int64
+	columns []string
+
+	// The open row group: definition levels and PLAIN-encoded values
+	defined [][]bool
+	values  []bytes.Buffer
+	rows    int
+
+	groups    []parquetRowGroup
+	totalRows int64
+}
+
+// parquetRowGroup is the footer metadata of a written row group
+type parquetRowGroup struct {
+	rows   int64
+	size   int64
+	chunks []parquetChunk
+}
+
+// parquetChunk is the footer metadata of a column chunk
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+func newParquetWriter(w io.Writer, columns []string) *parquetWriter {
+	return &parquetWriter{
+		w:       w,
+		columns: columns,
+		defined: make([][]bool, len(columns)),
+		values:  make([]bytes.Buffer, len(columns)),
+	}
+}
+
+func (w *parquetWriter) Write(resource *models.Resource) error {
+	doc, err := document(resource)
+	if err != nil {
+		return err
+	}
+	for i, column := range w.columns {
+		value, ok := flatten(doc, column)
+		w.defined[i] = append(w.defined[i], ok)
+		if ok {
+			_ = binary.Write(&w.values[i], binary.LittleEndian, uint32(len(value)))
+			w.values[i].WriteString(value)
+		}
+	}
+	w.rows++
+	if w.rows == parquetRowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *parquetWriter) Close() error {
+	if w.rows > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	footer := w.footer()
+	if err := w.write(footer); err != nil {
+		return err
+	}
+	trailer := binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))
+	return w.write(append(trailer, parquetMagic...))
+}
+
+// start writes the leading magic number
+func (w *parquetWriter) start() error {
+	if w.offset > 0 {
+		return nil
+	}
+	return w.write([]byte(parquetMagic))
+}
+
+func (w *parquetWriter) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered rows as a row group with a single data page per
+// column
+func (w *parquetWriter) flush() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	group := parquetRowGroup{rows: int64(w.rows)}
+	for i := range w.columns {
+		levels := encodeLevels(w.defined[i])
+		page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+		page = append(page, levels...)
+		page = append(page, w.values[i].Bytes()...)
+
+		header := newThriftEncoder()
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.i32(1, int32(w.rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetChunk{offset: w.offset, values: int64(w.rows)}
+		if err := w.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		chunk.size = w.offset - chunk.offset
+		group.size += chunk.size
+		group.chunks = append(group.chunks, chunk)
+
+		w.defined[i] = w.defined[i][:0]
+		w.values[i].Reset()
+	}
+
+	w.groups = append(w.groups, group)
+	w.totalRows += group.rows
+	w.rows = 0
+	return nil
+}
+
+// footer encodes the FileMetaData
+func (w *parquetWriter) footer() []byte {
+	e := newThriftEncoder()
+	e.i32(1, 1)
+
+	e.list(2, thriftStruct, len(w.columns)+1)
+	e.beginElement()
+	e.binary(4, "schema")
+	e.i32(5, int32(len(w.columns)))
+	e.endStruct()
+	for _, column := range w.columns {
+		e.beginElement()
+		e.i32(1, parquetByteArray)
+		e.i32(3, parquetOptional)
+		e.binary(4, column)
+		e.i32(6, parquetUTF8)
+		e.endStruct()
+	}
+
+	e.i64(3, w.totalRows)
+
+	e.list(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		e.beginElement()
+		e.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			e.beginElement()
+			e.i64(2, chunk.offset)
+			e.beginStruct(3)
+			e.i32(1, parquetByteArray)
+			e.list(2, thriftI32, 2)
+			e.element32(parquetPlain)
+			e.element32(parquetRLE)
+			e.list(3, thriftBinary, 1)
+			e.elementBinary(w.columns[i])
+			e.i32(4, parquetCompressed)
+			e.i64(5, chunk.values)
+			e.i64(6, chunk.size)
+			e.i64(7, chunk.size)
+			e.i64(9, chunk.offset)
+			e.endStruct()
+			e.endStruct()
+		}
+		e.i64(2, group.size)
+		e.i64(3, group.rows)
+		e.endStruct()
+	}
+
+	e.binary(6, "siros")
+	e.stop()
+	return e.buf.Bytes()
+}
+
+// encodeLevels encodes definition levels of bit width 1 as RLE runs
+func encodeLevels(defined []bool) []byte {
+	var out []byte
+	for i := 0; i < len(defined); {
+		j := i + 1
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if defined[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// Thrift compact protocol type IDs
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftEncoder writes the subset of the Thrift compact protocol that
+// Parquet metadata needs
+type thriftEncoder struct {
+	buf bytes.Buffer
+	// last holds the previous field ID of each open struct, from which the
+	// next field header's delta is taken
+	last []int16
+}
+
+func newThriftEncoder() *thriftEncoder {
+	return &thriftEncoder{last: []int16{0}}
+}
+
+func (e *thriftEncoder) field(id int16, typ byte) {
+	last := &e.last[len(e.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		e.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		e.buf.WriteByte(typ)
+		e.varint(int64(id))
+	}
+	*last = id
+}
+
+func (e *thriftEncoder) varint(v int64) {
+	e.buf.Write(binary.AppendUvarint(nil, uint64((v<<1)^(v>>63))))
+}
+
+func (e *thriftEncoder) i32(id int16, v int32) {
+	e.field(id, thriftI32)
+	e.varint(int64(v))
+}
+
+func (e *thriftEncoder) i64(id int16, v int64) {
+	e.field(id, thriftI64)
+	e.varint(v)
+}
+
+func (e *thriftEncoder) binary(id int16, s string) {
+	e.field(id, thriftBinary)
+	e.elementBinary(s)
+}
+
+func (e *thriftEncoder) list(id int16, elem byte, n int) {
+	e.field(id, thriftList)
+	if n < 15 {
+		e.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	e.buf.WriteByte(0xf0 | elem)
+	e.buf.Write(binary.AppendUvarint(nil, uint64(n)))
+}
+
+func (e *thriftEncoder) element32(v int32) {
+	e.varint(int64(v))
+}
+
+func (e *thriftEncoder) elementBinary(s string) {
+	e.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	e.buf.WriteString(s)
+}
+
+// beginStruct opens a struct field; beginElement opens a struct list element
+func (e *thriftEncoder) beginStruct(id int16) {
+	e.field(id, thriftStruct)
+	e.beginElement()
+}
+
+func (e *thriftEncoder) beginElement() {
+	e.last = append(e.last, 0)
+}
+
+func (e *thriftEncoder) endStruct() {
+	e.stop()
+	e.last = e.last[:len(e.last)-1]
+}
+
+// stop ends the current struct
+func (e *thriftEncoder) stop() {
+	e.buf.WriteByte(0)
+}
@@ -0,0 +1,314 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// fieldKind is how a field is stored
+type fieldKind int
+
+const (
+	kindText fieldKind = iota // text column
+	kindTime                  // timestamp column
+	kindJSON                  // path into a JSONB column
+)
+
+// field is a filter field resolved to where it is stored
+type field struct {
+	kind   fieldKind
+	column string
+	path   []string
+}
+
+// columns are the fields stored in columns of their own
+var columns = map[string]field{
+	"id":          {kind: kindText, column: "id"},
+	"provider":    {kind: kindText, column: "provider"},
+	"type":        {kind: kindText, column: "type"},
+	"name":        {kind: kindText, column: "name"},
+	"parent_id":   {kind: kindText, column: "COALESCE(parent_id, '')"},
+	"created_at":  {kind: kindTime, column: "created_at"},
+	"modified_at": {kind: kindTime, column: "modified_at"},
+}
+
+// metadataFields can be named without their metadata prefix
+var metadataFields = map[string]bool{
+	"region":      true,
+	"environment": true,
+	"cost_center": true,
+	"account":     true,
+	"native_id":   true,
+	"created_by":  true,
+	"modified_by": true,
+}
+
+// resolve maps a field name to where it is stored: a column, a metadata
+// shorthand, tags.<key> or a metadata.<path> or data.<path> into JSONB
+func resolve(name string) (field, bool) {
+	if f, ok := columns[name]; ok {
+		return f, true
+	}
+	if metadataFields[name] {
+		return field{kind: kindJSON, column: "metadata", path: []string{name}}, true
+	}
+	if key, ok := strings.CutPrefix(name, "tags."); ok && key != "" {
+		// Tags are a flat map, so dots belong to the key
+		return field{kind: kindJSON, column: "metadata", path: []string{"tags", key}}, true
+	}
+	for _, column := range []string{"metadata", "data"} {
+		if rest, ok := strings.CutPrefix(name, column+"."); ok {
+			path := strings.Split(rest, ".")
+			for _, segment := range path {
+				if segment == "" {
+					return field{}, false
+				}
+			}
+			return field{kind: kindJSON, column: column, path: path}, true
+		}
+	}
+	return field{}, false
+}
+
+// check reports fields that do not exist and values that do not fit their
+// field or operator
+func check(e *Compare) error {
+	f, ok := resolve(e.Field)
+	if !ok {
+		return &Error{Pos: e.Pos, Msg: fmt.Sprintf("unknown field %q", e.Field)}
+	}
+	list := e.Op == OpIn || e.Op == OpNotIn
+	if list && len(e.Values) == 0 || !list && len(e.Values) != 1 {
+		return &Error{Pos: e.Pos, Msg: fmt.Sprintf("wrong number of values for %s", e.Op)}
+	}
+
+	switch e.Op {
+	case OpMatch, OpNotMatch:
+		if f.kind == kindTime {
+			return &Error{Pos: e.Pos, Msg: fmt.Sprintf("%s cannot be matched against a pattern", e.Field)}
+		}
+		if _, err := regexp.Compile(e.Values[0].Text); err != nil {
+			return &Error{Pos: e.Pos, Msg: fmt.Sprintf("invalid pattern: %v", err)}
+		}
+	case OpEq, OpNe, OpGt, OpGe, OpLt, OpLe, OpIn, OpNotIn:
+	default:
+		return &Error{Pos: e.Pos, Msg: fmt.Sprintf("unknown operator %q", e.Op)}
+	}
+
+	if f.kind == kindTime {
+		for _, v := range e.Values {
+			if _, ok := parseTime(v.Text, time.Time{}); !ok {
+				return &Error{Pos: e.Pos, Msg: fmt.Sprintf("%s needs a time such as -7d, now, 2024-01-31 or an RFC 3339 time, not %q", e.Field, v.Text)}
+			}
+		}
+	}
+	return nil
+}
+
+// relativeTime matches times relative to now, as in -7d or +2h
+var relativeTime = regexp.MustCompile(`^([+-])(\d+)([smhdw])$`)
+
+// parseTime parses a time value: now, a duration before or after now, a
+// date or an RFC 3339 time
+func parseTime(text string, now time.Time) (time.Time, bool) {
+	if strings.EqualFold(text, "now") {
+		return now, true
+	}
+	if m := relativeTime.FindStringSubmatch(text); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return time.Time{}, false
+		}
+		unit := map[string]time.Duration{
+			"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour,
+		}[m[3]]
+		d := time.Duration(n) * unit
+		if m[1] == "-" {
+			d = -d
+		}
+		return now.Add(d), true
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Compile returns the SQL condition selecting the resources expr matches.
+// Its arguments are appended to args so that its placeholders are numbered
+// after theirs; relative times are taken back from now.
+func Compile(expr Expr, args []interface{}, now time.Time) (string, []interface{}, error) {
+	c := &compiler{args: args, now: now}
+	sql, err := c.compile(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, c.args, nil
+}
+
+// compiler accumulates the arguments of the SQL it emits
+type compiler struct {
+	args []interface{}
+	now  time.Time
+}
+
+// arg adds an argument and returns its placeholder
+func (c *compiler) arg(v interface{}) string {
+	c.args = append(c.args, v)
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+func (c *compiler) compile(expr Expr) (string, error) {
+	switch e := expr.(type) {
+	case *And:
+		return c.binary(e.Left, "AND", e.Right)
+	case *Or:
+		return c.binary(e.Left, "OR", e.Right)
+	case *Not:
+		return c.not(e.X)
+	case *Compare:
+		return c.compare(e)
+	default:
+		return "", fmt.Errorf("unsupported filter expression %T", expr)
+	}
+}
+
+func (c *compiler) binary(left Expr, op string, right Expr) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// not negates x, matching resources for which x is false or, where a JSON
+// field is missing, unknown
+func (c *compiler) not(x Expr) (string, error) {
+	sql, err := c.compile(x)
+	if err != nil {
+		return "", err
+	}
+	return "NOT COALESCE(" + sql + ", false)", nil
+}
+
+// negations map the negative operators to the positive ones they negate
+var negations = map[Op]Op{OpNe: OpEq, OpNotIn: OpIn, OpNotMatch: OpMatch}
+
+func (c *compiler) compare(e *Compare) (string, error) {
+	if err := check(e); err != nil {
+		return "", err
+	}
+	if positive, ok := negations[e.Op]; ok {
+		return c.not(&Compare{Field: e.Field, Op: positive, Values: e.Values, Pos: e.Pos})
+	}
+
+	f, _ := resolve(e.Field)
+	switch f.kind {
+	case kindJSON:
+		return c.compareJSON(f, e), nil
+	case kindTime:
+		return c.compareColumn(f.column, e, func(v Value) interface{} {
+			t, _ := parseTime(v.Text, c.now)
+			return t
+		}), nil
+	default:
+		return c.compareColumn(f.column, e, func(v Value) interface{} { return v.Text }), nil
+	}
+}
+
+// compareColumn compares a column to values converted by value
+func (c *compiler) compareColumn(column string, e *Compare, value func(Value) interface{}) string {
+	if e.Op == OpIn {
+		placeholders := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			placeholders[i] = c.arg(value(v))
+		}
+		return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", "))
+	}
+	op := string(e.Op)
+	if e.Op == OpEq {
+		op = "="
+	}
+	return fmt.Sprintf("%s %s %s", column, op, c.arg(value(e.Values[0])))
+}
+
+// compareJSON compares a JSONB path. Equality is tested by containment, which
+// the GIN indexes serve; patterns and ordering read the value at the path.
+func (c *compiler) compareJSON(f field, e *Compare) string {
+	switch e.Op {
+	case OpEq, OpIn:
+		var matches []string
+		for _, v := range e.Values {
+			for _, leaf := range candidates(v) {
+				doc := leaf
+				for i := len(f.path) - 1; i >= 0; i-- {
+					doc = map[string]interface{}{f.path[i]: doc}
+				}
+				data, _ := json.Marshal(doc)
+				matches = append(matches, fmt.Sprintf("%s @> %s::jsonb", f.column, c.arg(string(data))))
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0]
+		}
+		return "(" + strings.Join(matches, " OR ") + ")"
+	case OpMatch:
+		return fmt.Sprintf("%s #>> %s::text[] ~ %s", f.column, c.arg(pq.Array(f.path)), c.arg(e.Values[0].Text))
+	}
+
+	// Ordering: numbers compare as JSON numbers, times in their RFC 3339
+	// form and anything else as text
+	v := e.Values[0]
+	path := c.arg(pq.Array(f.path))
+	if number, ok := jsonNumber(v); ok {
+		return fmt.Sprintf("(jsonb_typeof(%s #> %s::text[]) = 'number' AND %s #> %s::text[] %s %s::jsonb)",
+			f.column, path, f.column, path, e.Op, c.arg(string(number)))
+	}
+	text := v.Text
+	if t, ok := parseTime(v.Text, c.now); ok && !v.Quoted {
+		text = t.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%s #>> %s::text[] %s %s", f.column, path, e.Op, c.arg(text))
+}
+
+// candidates returns the JSON values a literal matches: quoted literals are
+// strings, while bare ones also match the number, boolean or null they spell
+func candidates(v Value) []interface{} {
+	leaves := []interface{}{v.Text}
+	if v.Quoted {
+		return leaves
+	}
+	if number, ok := jsonNumber(v); ok {
+		return append(leaves, number)
+	}
+	switch v.Text {
+	case "true", "false":
+		return append(leaves, v.Text == "true")
+	case "null":
+		return append(leaves, nil)
+	}
+	return leaves
+}
+
+// jsonNumber returns a bare literal as a JSON number
+func jsonNumber(v Value) (json.Number, bool) {
+	if v.Quoted {
+		return "", false
+	}
+	if _, err := strconv.ParseFloat(v.Text, 64); err != nil || !json.Valid([]byte(v.Text)) {
+		return "", false
+	}
+	return json.Number(v.Text), true
+}
@@ -0,0 +1,133 @@
+// Package filter implements the resource filter language, as in
+//
+//	provider:aws AND tags.env IN (prod,stage) AND data.instance_type ~ "m5.*" AND modified_at > -7d
+//
+// Expressions are parsed into a syntax tree and compiled to parameterized SQL
+// over the resources table, matching JSONB fields by containment so that the
+// GIN indexes on data and metadata apply.
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxLength caps the length of a filter expression
+const MaxLength = 4096
+
+// maxDepth caps the nesting of parentheses and NOT
+const maxDepth = 32
+
+// Op is a comparison operator
+type Op string
+
+// Comparison operators. "=" is accepted as an alias of ":".
+const (
+	OpEq       Op = ":"
+	OpNe       Op = "!="
+	OpGt       Op = ">"
+	OpGe       Op = ">="
+	OpLt       Op = "<"
+	OpLe       Op = "<="
+	OpMatch    Op = "~"
+	OpNotMatch Op = "!~"
+	OpIn       Op = "IN"
+	OpNotIn    Op = "NOT IN"
+)
+
+// Expr is a node of a parsed filter expression. String returns it in
+// canonical form, which parses back to the same tree.
+type Expr interface {
+	String() string
+}
+
+// And matches resources matching both sides
+type And struct {
+	Left, Right Expr
+}
+
+// Or matches resources matching either side
+type Or struct {
+	Left, Right Expr
+}
+
+// Not matches resources not matching X
+type Not struct {
+	X Expr
+}
+
+// Compare matches a field against one value, or a list of values for IN
+type Compare struct {
+	Field  string
+	Op     Op
+	Values []Value
+	Pos    int
+}
+
+// Value is a literal. Quoted values are always strings; bare values may
+// also match numbers and booleans stored in JSON fields.
+type Value struct {
+	Text   string
+	Quoted bool
+}
+
+func (e *And) String() string { return "(" + e.Left.String() + " AND " + e.Right.String() + ")" }
+func (e *Or) String() string  { return "(" + e.Left.String() + " OR " + e.Right.String() + ")" }
+func (e *Not) String() string { return "NOT " + e.X.String() }
+
+func (e *Compare) String() string {
+	values := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		values[i] = v.String()
+	}
+	if e.Op == OpIn || e.Op == OpNotIn {
+		return fmt.Sprintf("%s %s (%s)", e.Field, e.Op, strings.Join(values, ","))
+	}
+	if e.Op == OpEq {
+		return e.Field + ":" + values[0]
+	}
+	return fmt.Sprintf("%s %s %s", e.Field, e.Op, values[0])
+}
+
+func (v Value) String() string {
+	if v.Quoted || v.Text == "" || strings.ContainsAny(v.Text, " \t\n\"(),:=!<>~") || isKeyword(v.Text) {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v.Text) + `"`
+	}
+	return v.Text
+}
+
+// Error reports an invalid filter expression and where in it the problem is
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// All joins expressions with AND, skipping nil ones. It returns nil when no
+// expression is left.
+func All(exprs ...Expr) Expr {
+	var all Expr
+	for _, expr := range exprs {
+		switch {
+		case expr == nil:
+		case all == nil:
+			all = expr
+		default:
+			all = &And{Left: all, Right: expr}
+		}
+	}
+	return all
+}
+
+// Equal returns the comparison of field to a string value, as used by the
+// filter_<field> query parameters
+func Equal(field, value string) (Expr, error) {
+	expr := &Compare{Field: field, Op: OpEq, Values: []Value{{Text: value, Quoted: true}}}
+	if err := check(expr); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
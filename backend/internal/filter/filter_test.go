@@ -0,0 +1,222 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `provider:aws AND tags.env IN (prod,stage) AND data.instance_type ~ "m5.*" AND modified_at > -7d`,
+			want:  `(((provider:aws AND tags.env IN (prod,stage)) AND data.instance_type ~ "m5.*") AND modified_at > -7d)`,
+		},
+		{input: `provider=aws type:ec2`, want: `(provider:aws AND type:ec2)`},
+		{input: `a.b:1`, want: ``},
+		{input: `provider:aws OR provider:gcp AND region:us`, want: `(provider:aws OR (provider:gcp AND region:us))`},
+		{input: `NOT (region:eu-west-1 or region != "us east")`, want: `NOT (region:eu-west-1 OR region != "us east")`},
+		{input: `tags.team NOT IN ("a b", "AND")`, want: `tags.team NOT IN ("a b","AND")`},
+		{input: `data.cpu >= 4 and created_at < 2024-01-31`, want: `(data.cpu >= 4 AND created_at < 2024-01-31)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Parse() = %s, want error", expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+			again, err := Parse(expr.String())
+			if err != nil || !reflect.DeepEqual(stripPos(again), stripPos(expr)) {
+				t.Errorf("canonical form %s does not parse back: %v", expr, err)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{input: ``, pos: 0},
+		{input: `provider`, pos: 8},
+		{input: `provider:`, pos: 9},
+		{input: `provider:aws AND`, pos: 16},
+		{input: `(provider:aws`, pos: 13},
+		{input: `provider ! aws`, pos: 9},
+		{input: `name:"web`, pos: 5},
+		{input: `region:us OR bogus:1`, pos: 13},
+		{input: `name ~ "("`, pos: 0},
+		{input: `created_at > yesterday`, pos: 0},
+		{input: `created_at ~ 2024`, pos: 0},
+		{input: `tags.env IN ()`, pos: 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var filterErr *Error
+			if !errors.As(err, &filterErr) {
+				t.Fatalf("Parse() error = %v, want *Error", err)
+			}
+			if filterErr.Pos != tt.pos {
+				t.Errorf("Parse() error at %d, want %d: %v", filterErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input string
+		sql   string
+		args  []interface{}
+	}{
+		{
+			input: `provider:aws AND tags.env IN (prod,stage)`,
+			sql:   `(provider = $2 AND (metadata @> $3::jsonb OR metadata @> $4::jsonb))`,
+			args:  []interface{}{"existing", "aws", `{"tags":{"env":"prod"}}`, `{"tags":{"env":"stage"}}`},
+		},
+		{
+			input: `data.instance_type ~ "m5.*" AND modified_at > -7d`,
+			sql:   `(data #>> $2::text[] ~ $3 AND modified_at > $4)`,
+			args:  []interface{}{"existing", pq.Array([]string{"instance_type"}), "m5.*", now.Add(-7 * 24 * time.Hour)},
+		},
+		{
+			input: `data.cpu:4`,
+			sql:   `(data @> $2::jsonb OR data @> $3::jsonb)`,
+			args:  []interface{}{"existing", `{"cpu":"4"}`, `{"cpu":4}`},
+		},
+		{
+			input: `data.cpu > 4`,
+			sql:   `(jsonb_typeof(data #> $2::text[]) = 'number' AND data #> $2::text[] > $3::jsonb)`,
+			args:  []interface{}{"existing", pq.Array([]string{"cpu"}), "4"},
+		},
+		{
+			input: `parent_id != vpc-1`,
+			sql:   `NOT COALESCE(COALESCE(parent_id, '') = $2, false)`,
+			args:  []interface{}{"existing", "vpc-1"},
+		},
+		{
+			input: `region NOT IN (eu-west-1)`,
+			sql:   `NOT COALESCE(metadata @> $2::jsonb, false)`,
+			args:  []interface{}{"existing", `{"region":"eu-west-1"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			sql, args, err := Compile(expr, []interface{}{"existing"}, now)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("Compile() sql = %s, want %s", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Compile() args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	expr, err := Equal("environment", "prod")
+	if err != nil {
+		t.Fatalf("Equal() error = %v", err)
+	}
+	if got := All(nil, expr, nil).String(); got != `environment:"prod"` {
+		t.Errorf("All() = %s", got)
+	}
+	if _, err := Equal("bogus", "x"); err == nil {
+		t.Error("Equal() accepted an unknown field")
+	}
+	if All() != nil {
+		t.Error("All() of nothing is not nil")
+	}
+}
+
+// stripPos clears the positions of a tree so trees parsed from different
+// text can be compared
+func stripPos(expr Expr) Expr {
+	switch e := expr.(type) {
+	case *And:
+		return &And{Left: stripPos(e.Left), Right: stripPos(e.Right)}
+	case *Or:
+		return &Or{Left: stripPos(e.Left), Right: stripPos(e.Right)}
+	case *Not:
+		return &Not{X: stripPos(e.X)}
+	case *Compare:
+		c := *e
+		c.Pos = 0
+		return &c
+	}
+	return expr
+}
+
+func TestParseSort(t *testing.T) {
+	keys, err := ParseSort("provider, -modified_at,tags.env:asc", true)
+	if err != nil {
+		t.Fatalf("ParseSort() error = %v", err)
+	}
+	if got := FormatSort(keys); got != "provider:desc,modified_at:desc,tags.env:asc" {
+		t.Errorf("FormatSort() = %s", got)
+	}
+	want := []string{"provider", "modified_at", `COALESCE(metadata #>> '{tags,env}', '')`}
+	for i, key := range keys {
+		if key.SQL() != want[i] {
+			t.Errorf("SQL() of %s = %s, want %s", key.Field, key.SQL(), want[i])
+		}
+	}
+	if !keys[1].Time() || keys[0].Time() {
+		t.Error("Time() does not tell timestamp columns apart")
+	}
+
+	tests := []struct {
+		spec string
+		pos  int
+	}{
+		{spec: "", pos: 0},
+		{spec: "name,", pos: 5},
+		{spec: "name, password", pos: 6},
+		{spec: "name:up", pos: 0},
+		{spec: "name,-name", pos: 5},
+		{spec: "data.x y", pos: 0},
+		{spec: "created_at; DROP TABLE resources", pos: 0},
+		{spec: "data.'x", pos: 0},
+		{spec: "id,name,type,provider,region", pos: 22},
+	}
+	for _, tt := range tests {
+		_, err := ParseSort(tt.spec, false)
+		var filterErr *Error
+		if !errors.As(err, &filterErr) {
+			t.Errorf("ParseSort(%q) error = %v, want *Error", tt.spec, err)
+			continue
+		}
+		if filterErr.Pos != tt.pos {
+			t.Errorf("ParseSort(%q) error at %d, want %d: %v", tt.spec, filterErr.Pos, tt.pos, err)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies the tokens of a filter expression
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex splits input into tokens. Words run until whitespace or one of the
+// characters that start another token.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '"':
+			text, end, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokString, text, i})
+			i = end
+		case strings.IndexByte(":=<>~!", c) >= 0:
+			op := string(c)
+			if i+1 < len(input) && (c == '<' || c == '>' || c == '!') && input[i+1] == '=' ||
+				c == '!' && i+1 < len(input) && input[i+1] == '~' {
+				op = input[i : i+2]
+			} else if c == '!' {
+				return nil, &Error{Pos: i, Msg: `expected "!=" or "!~"`}
+			}
+			width := len(op)
+			if op == "=" {
+				op = string(OpEq)
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += width
+		default:
+			start := i
+			for i < len(input) && !unicode.IsSpace(rune(input[i])) && strings.IndexByte(`"(),:=<>~!`, input[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, input[start:i], start})
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(input)}), nil
+}
+
+// lexString reads the double-quoted string starting at start, in which a
+// backslash escapes the next character
+func lexString(input string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			if i+1 == len(input) {
+				return "", 0, &Error{Pos: i, Msg: "unterminated escape"}
+			}
+			i++
+			b.WriteByte(input[i])
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(input[i])
+		}
+	}
+	return "", 0, &Error{Pos: start, Msg: "unterminated string"}
+}
+
+// isKeyword reports whether a bare word is a keyword of the language.
+// Keywords are case-insensitive; values spelled like one must be quoted.
+func isKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT", "IN":
+		return true
+	}
+	return false
+}
+
+// parser is a recursive descent parser over the tokens of an expression:
+//
+//	expr    = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = "NOT" unary | "(" expr ")" | compare
+//	compare = field op value | field ["NOT"] "IN" "(" value { "," value } ")"
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+// Parse parses a filter expression, checking its fields and values
+func Parse(input string) (Expr, error) {
+	if len(input) > MaxLength {
+		return nil, &Error{Pos: MaxLength, Msg: "expression is too long"}
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &Error{Pos: 0, Msg: "expression is empty"}
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &Error{Pos: tok.pos, Msg: "unexpected " + describe(tok)}
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the given keyword
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokWord && strings.EqualFold(tok.text, word)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.keyword("AND") {
+			p.advance()
+		} else if tok := p.peek(); tok.kind != tokLParen && (tok.kind != tokWord || p.keyword("OR")) {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.depth++; p.depth > maxDepth {
+		return nil, &Error{Pos: p.peek().pos, Msg: "expression is nested too deeply"}
+	}
+	defer func() { p.depth-- }()
+
+	switch {
+	case p.keyword("NOT"):
+		p.advance()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	case p.peek().kind == tokLParen:
+		p.advance()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.advance(); tok.kind != tokRParen {
+			return nil, &Error{Pos: tok.pos, Msg: "expected ) but found " + describe(tok)}
+		}
+		return expr, nil
+	default:
+		return p.parseCompare()
+	}
+}
+
+func (p *parser) parseCompare() (Expr, error) {
+	field := p.advance()
+	if field.kind != tokWord || isKeyword(field.text) {
+		return nil, &Error{Pos: field.pos, Msg: "expected a field but found " + describe(field)}
+	}
+	expr := &Compare{Field: field.text, Pos: field.pos}
+
+	switch {
+	case p.keyword("IN"):
+		p.advance()
+		expr.Op = OpIn
+	case p.keyword("NOT"):
+		p.advance()
+		if !p.keyword("IN") {
+			return nil, &Error{Pos: p.peek().pos, Msg: "expected IN after NOT"}
+		}
+		p.advance()
+		expr.Op = OpNotIn
+	case p.peek().kind == tokOp:
+		expr.Op = Op(p.advance().text)
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		expr.Values = []Value{value}
+		return expr, check(expr)
+	default:
+		tok := p.peek()
+		return nil, &Error{Pos: tok.pos, Msg: "expected an operator but found " + describe(tok)}
+	}
+
+	if tok := p.advance(); tok.kind != tokLParen {
+		return nil, &Error{Pos: tok.pos, Msg: "expected ( but found " + describe(tok)}
+	}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		expr.Values = append(expr.Values, value)
+		tok := p.advance()
+		if tok.kind == tokRParen {
+			return expr, check(expr)
+		}
+		if tok.kind != tokComma {
+			return nil, &Error{Pos: tok.pos, Msg: "expected , or ) but found " + describe(tok)}
+		}
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	tok := p.advance()
+	switch {
+	case tok.kind == tokString:
+		return Value{Text: tok.text, Quoted: true}, nil
+	case tok.kind == tokWord && !isKeyword(tok.text):
+		return Value{Text: tok.text}, nil
+	default:
+		return Value{}, &Error{Pos: tok.pos, Msg: "expected a value but found " + describe(tok)}
+	}
+}
+
+// describe names a token in error messages
+func describe(tok token) string {
+	switch tok.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return "string"
+	default:
+		return `"` + tok.text + `"`
+	}
+}
@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// maxSortKeys caps the number of fields in a sort
+const maxSortKeys = 4
+
+// sortSegment matches the JSON path segments a sort can name. Sort
+// expressions cannot take placeholders in every position they are used, so
+// their paths are quoted into the SQL and limited to plain characters.
+var sortSegment = regexp.MustCompile(`^[A-Za-z0-9_.:/@-]+$`)
+
+// SortKey orders results by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a sort specification: fields separated by commas, each
+// prefixed with - or suffixed with :desc to sort descending, or suffixed
+// with :asc. Fields without a direction sort descending when defaultDesc is
+// set. Any field a filter can compare can be sorted by.
+func ParseSort(spec string, defaultDesc bool) ([]SortKey, error) {
+	var keys []SortKey
+	pos := 0
+	for _, term := range strings.Split(spec, ",") {
+		start := pos + len(term) - len(strings.TrimLeft(term, " "))
+		pos += len(term) + 1
+		term = strings.TrimSpace(term)
+
+		key := SortKey{Field: term, Desc: defaultDesc}
+		switch {
+		case strings.HasPrefix(term, "-"):
+			key.Field, key.Desc = term[1:], true
+		case strings.HasPrefix(term, "+"):
+			key.Field, key.Desc = term[1:], false
+		default:
+			if field, dir, ok := strings.Cut(term, ":"); ok {
+				switch strings.ToLower(dir) {
+				case "asc":
+					key.Field, key.Desc = field, false
+				case "desc":
+					key.Field, key.Desc = field, true
+				default:
+					return nil, &Error{Pos: start, Msg: fmt.Sprintf("sort direction must be asc or desc, not %q", dir)}
+				}
+			}
+		}
+
+		if key.Field == "" {
+			return nil, &Error{Pos: start, Msg: "expected a sort field"}
+		}
+		if _, ok := sortColumn(key.Field); !ok {
+			return nil, &Error{Pos: start, Msg: fmt.Sprintf("cannot sort by unknown field %q", key.Field)}
+		}
+		for _, other := range keys {
+			if other.Field == key.Field {
+				return nil, &Error{Pos: start, Msg: fmt.Sprintf("%s is sorted by twice", key.Field)}
+			}
+		}
+		if len(keys) == maxSortKeys {
+			return nil, &Error{Pos: start, Msg: fmt.Sprintf("at most %d sort fields are allowed", maxSortKeys)}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// FormatSort returns keys in canonical form, as in
+// "provider:asc,modified_at:desc"
+func FormatSort(keys []SortKey) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		dir := "asc"
+		if key.Desc {
+			dir = "desc"
+		}
+		terms[i] = key.Field + ":" + dir
+	}
+	return strings.Join(terms, ",")
+}
+
+// SQL returns the vetted SQL expression the key orders by. JSON fields
+// order by their text, with missing values first.
+func (k SortKey) SQL() string {
+	column, _ := sortColumn(k.Field)
+	return column
+}
+
+// Time reports whether the key orders by a timestamp column
+func (k SortKey) Time() bool {
+	f, _ := resolve(k.Field)
+	return f.kind == kindTime
+}
+
+// sortColumn maps a sortable field to its SQL expression
+func sortColumn(name string) (string, bool) {
+	f, ok := resolve(name)
+	if !ok {
+		return "", false
+	}
+	if f.kind != kindJSON {
+		return f.column, true
+	}
+	for _, segment := range f.path {
+		if !sortSegment.MatchString(segment) {
+			return "", false
+		}
+	}
+	path := pq.QuoteLiteral("{" + strings.Join(f.path, ",") + "}")
+	return fmt.Sprintf("COALESCE(%s #>> %s, '')", f.column, path), true
+}
@@ -0,0 +1,151 @@
+// Package identity derives stable Siros IDs from the natural identifiers of
+// cloud resources and resolves the other names a resource is known by, so
+// that scans, imports, Terraform state and the API agree on one record.
+package identity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// derivedPrefix starts every derived ID
+const derivedPrefix = "sid-"
+
+// Ref names a resource by its natural identifiers
+type Ref struct {
+	Provider string
+	Account  string
+	Region   string
+	NativeID string
+	ARN      string
+}
+
+// ForResource returns the natural identifiers of a provider resource, whose
+// ID is its native ID
+func ForResource(resource *types.Resource) Ref {
+	account, _ := resource.Metadata["account_id"].(string)
+	return Ref{
+		Provider: resource.Provider,
+		Account:  account,
+		Region:   resource.Region,
+		NativeID: resource.ID,
+		ARN:      resource.ARN,
+	}
+}
+
+// ForStored returns the natural identifiers of a stored resource. Resources
+// stored before IDs were derived carry their native ID as their ID; randomly
+// generated IDs are not natural identifiers.
+func ForStored(resource *models.Resource) Ref {
+	nativeID := resource.Metadata.NativeID
+	if nativeID == "" && !IsDerived(resource.ID) && !isGenerated(resource.ID) {
+		nativeID = resource.ID
+	}
+	arn, _ := resource.Data["arn"].(string)
+	return Ref{
+		Provider: resource.Provider,
+		Account:  resource.Metadata.Account,
+		Region:   resource.Metadata.Region,
+		NativeID: nativeID,
+		ARN:      arn,
+	}
+}
+
+// Valid reports whether the ref carries a native ID or ARN
+func (r Ref) Valid() bool {
+	return r.NativeID != "" || r.ARN != ""
+}
+
+// Normalize lower-cases the provider, fills the account and region from the
+// identifiers where they embed them, and folds the case of identifiers that
+// the provider treats case-insensitively
+func (r Ref) Normalize() Ref {
+	r.Provider = strings.ToLower(strings.TrimSpace(r.Provider))
+	r.NativeID = strings.TrimSpace(r.NativeID)
+	r.ARN = strings.TrimSpace(r.ARN)
+
+	switch r.Provider {
+	case "aws":
+		// arn:partition:service:region:account:resource
+		if parts := strings.SplitN(r.ARN, ":", 6); len(parts) == 6 && parts[0] == "arn" {
+			if r.Region == "" {
+				r.Region = parts[3]
+			}
+			if r.Account == "" {
+				r.Account = parts[4]
+			}
+		}
+	case "azure":
+		// ARM IDs are case-insensitive and unique across regions
+		r.NativeID = strings.ToLower(r.NativeID)
+		r.ARN = strings.ToLower(r.ARN)
+		if r.Account == "" {
+			r.Account = segmentAfter(r.NativeID, "subscriptions")
+		}
+		r.Account = strings.ToLower(r.Account)
+		r.Region = ""
+	case "gcp":
+		// Full resource names are unique across regions
+		if r.Account == "" {
+			r.Account = segmentAfter(r.NativeID, "projects")
+		}
+		r.Region = ""
+	}
+	return r
+}
+
+// ID derives the Siros ID of the resource from its provider, account,
+// region and native ID, or ARN when it has no native ID
+func (r Ref) ID() string {
+	r = r.Normalize()
+	native := r.NativeID
+	if native == "" {
+		native = r.ARN
+	}
+	hash := sha256.Sum256([]byte(strings.Join([]string{r.Provider, r.Account, r.Region, native}, "\x00")))
+	return derivedPrefix + hex.EncodeToString(hash[:16])
+}
+
+// Aliases returns the identifiers the resource can be looked up by
+func (r Ref) Aliases() []string {
+	r = r.Normalize()
+	var aliases []string
+	for _, alias := range []string{r.NativeID, r.ARN} {
+		if alias != "" && (len(aliases) == 0 || aliases[0] != alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// IsDerived reports whether id was derived by Ref.ID
+func IsDerived(id string) bool {
+	return strings.HasPrefix(id, derivedPrefix) && len(id) == len(derivedPrefix)+32
+}
+
+// isGenerated reports whether id looks like one of the random IDs issued
+// before IDs were derived: 32 hex digits, optionally prefixed with siros-
+func isGenerated(id string) bool {
+	id = strings.TrimPrefix(id, "siros-")
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// segmentAfter returns the path segment following name, as in
+// /subscriptions/<id>/ or projects/<id>/
+func segmentAfter(path, name string) string {
+	segments := strings.Split(path, "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if strings.EqualFold(segments[i], name) {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
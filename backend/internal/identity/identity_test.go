@@ -0,0 +1,150 @@
+package identity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+func TestRef_ID(t *testing.T) {
+	instance := Ref{Provider: "aws", Region: "eu-west-1", NativeID: "i-0abc", ARN: "arn:aws:ec2:eu-west-1:111111111111:instance/i-0abc"}
+	id := instance.ID()
+	if !IsDerived(id) {
+		t.Fatalf("Expected a derived ID, got %s", id)
+	}
+
+	// The account embedded in the ARN is part of the key
+	same := Ref{Provider: "AWS", Account: "111111111111", Region: "eu-west-1", NativeID: " i-0abc "}
+	if same.ID() != id {
+		t.Errorf("Expected %s for the same natural key, got %s", id, same.ID())
+	}
+	other := Ref{Provider: "aws", Account: "222222222222", Region: "eu-west-1", NativeID: "i-0abc"}
+	if other.ID() == id {
+		t.Error("Expected resources in different accounts to get different IDs")
+	}
+
+	// ARM IDs are case-insensitive and carry their subscription
+	vm := "/subscriptions/SUB-1/resourceGroups/Web/providers/Microsoft.Compute/virtualMachines/vm-1"
+	upper := Ref{Provider: "azure", Region: "westeurope", NativeID: vm}
+	lower := Ref{Provider: "azure", NativeID: "/subscriptions/sub-1/resourcegroups/web/providers/microsoft.compute/virtualmachines/vm-1"}
+	if upper.ID() != lower.ID() || upper.Normalize().Account != "sub-1" {
+		t.Errorf("Expected ARM IDs to be folded, got %+v", upper.Normalize())
+	}
+}
+
+func TestForStored(t *testing.T) {
+	legacy := &models.Resource{ID: "i-0abc", Provider: "aws", Data: map[string]interface{}{"arn": "arn:aws:ec2:eu-west-1:111111111111:instance/i-0abc"}}
+	if ref := ForStored(legacy); ref.NativeID != "i-0abc" || ref.ARN == "" {
+		t.Errorf("Expected the stored ID to be the native ID, got %+v", ref)
+	}
+
+	generated := &models.Resource{ID: "0123456789abcdef0123456789abcdef", Provider: "custom"}
+	if ForStored(generated).Valid() {
+		t.Error("Expected a random ID not to be a natural key")
+	}
+
+	scanned := ForResource(&types.Resource{ID: "i-0abc", Provider: "aws", Region: "eu-west-1", Metadata: map[string]interface{}{"account_id": "111111111111"}})
+	derived := &models.Resource{
+		ID:       scanned.ID(),
+		Provider: "aws",
+		Metadata: models.ResourceMetadata{NativeID: "i-0abc", Account: "111111111111", Region: "eu-west-1"},
+	}
+	if ForStored(derived).ID() != derived.ID {
+		t.Errorf("Expected a stored resource to derive its own ID, got %+v", ForStored(derived))
+	}
+}
+
+// memoryStore keeps aliases in memory
+type memoryStore struct {
+	aliases []models.ResourceAlias
+}
+
+func (s *memoryStore) FindAliases(_ context.Context, values []string) ([]models.ResourceAlias, error) {
+	var found []models.ResourceAlias
+	for _, alias := range s.aliases {
+		for _, value := range values {
+			if alias.Alias == value {
+				found = append(found, alias)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *memoryStore) AddAliases(_ context.Context, aliases []models.ResourceAlias) error {
+	s.aliases = append(s.aliases, aliases...)
+	return nil
+}
+
+func TestResolver(t *testing.T) {
+	store := &memoryStore{}
+	resolver := NewResolver(store)
+	ctx := t.Context()
+
+	scanned := Ref{Provider: "aws", Region: "eu-west-1", NativeID: "i-0abc", ARN: "arn:aws:ec2:eu-west-1:111111111111:instance/i-0abc"}
+	ids, err := resolver.Resolve(ctx, []Ref{scanned, {Provider: "custom"}})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if ids[0] != scanned.ID() || ids[1] != "" {
+		t.Fatalf("Expected the derived ID and none for a ref without a key, got %v", ids)
+	}
+	if err := resolver.Register(ctx, ids[:1], []Ref{scanned}, "scan"); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if len(store.aliases) != 2 || store.aliases[0].Account != "111111111111" {
+		t.Fatalf("Expected the native ID and ARN to be registered, got %+v", store.aliases)
+	}
+
+	// An import naming the resource by ARN alone, or by native ID without
+	// an account, finds the scanned resource
+	byARN := Ref{Provider: "aws", ARN: scanned.ARN}
+	byNativeID := Ref{Provider: "aws", NativeID: "i-0abc"}
+	elsewhere := Ref{Provider: "aws", Account: "222222222222", NativeID: "i-0abc"}
+	ids, err = resolver.Lookup(ctx, []Ref{byARN, byNativeID, elsewhere})
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if ids[0] != scanned.ID() || ids[1] != scanned.ID() || ids[2] != "" {
+		t.Errorf("Unexpected lookups: %v", ids)
+	}
+}
+
+func TestResolver_Regions(t *testing.T) {
+	store := &memoryStore{}
+	resolver := NewResolver(store)
+	ctx := t.Context()
+
+	// Queues named orders in two regions of one account
+	east := Ref{Provider: "aws", Account: "111111111111", Region: "us-east-1", NativeID: "orders", ARN: "arn:aws:sqs:us-east-1:111111111111:orders"}
+	west := Ref{Provider: "aws", Account: "111111111111", Region: "eu-west-1", NativeID: "orders", ARN: "arn:aws:sqs:eu-west-1:111111111111:orders"}
+	for _, ref := range []Ref{east, west} {
+		ids, err := resolver.Resolve(ctx, []Ref{ref})
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if ids[0] != ref.ID() {
+			t.Fatalf("Expected %s to resolve to its derived ID %s, got %s", ref.Region, ref.ID(), ids[0])
+		}
+		if err := resolver.Register(ctx, ids, []Ref{ref}, "scan"); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	if east.ID() == west.ID() {
+		t.Fatal("Expected the regions to derive different IDs")
+	}
+
+	ids, err := resolver.Lookup(ctx, []Ref{
+		{Provider: "aws", ARN: west.ARN},
+		{Provider: "aws", Account: "111111111111", Region: "us-east-1", NativeID: "orders"},
+		{Provider: "aws", Region: "eu-west-1", NativeID: "orders", ARN: west.ARN},
+	})
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if ids[0] != west.ID() || ids[1] != east.ID() || ids[2] != west.ID() {
+		t.Errorf("Expected each region to resolve to its own resource, got %v", ids)
+	}
+}
@@ -0,0 +1,147 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// Store persists resource aliases
+type Store interface {
+	// FindAliases returns the stored aliases whose value is one of aliases
+	FindAliases(ctx context.Context, aliases []string) ([]models.ResourceAlias, error)
+	// AddAliases stores aliases, keeping existing ones unchanged
+	AddAliases(ctx context.Context, aliases []models.ResourceAlias) error
+}
+
+// Resolver maps natural identifiers to Siros IDs
+type Resolver struct {
+	store Store
+}
+
+// NewResolver creates a resolver over an alias store
+func NewResolver(store Store) *Resolver {
+	return &Resolver{store: store}
+}
+
+// Lookup returns, for each ref, the ID of the stored resource that one of
+// its aliases names, or "" when none does. An alias matches when its provider
+// is the ref's and its account and region are the ref's or unknown on either
+// side. A matching ARN, which names one resource, wins over a native ID that
+// may be reused across accounts and regions; among equals, the alias whose
+// account and then region are known to match wins.
+func (r *Resolver) Lookup(ctx context.Context, refs []Ref) ([]string, error) {
+	ids := make([]string, len(refs))
+	normalized := make([]Ref, len(refs))
+	var values []string
+	for i := range refs {
+		normalized[i] = refs[i].Normalize()
+		values = append(values, normalized[i].Aliases()...)
+	}
+	if len(values) == 0 {
+		return ids, nil
+	}
+
+	stored, err := r.store.FindAliases(ctx, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up aliases: %w", err)
+	}
+	byValue := make(map[string][]models.ResourceAlias, len(stored))
+	for _, alias := range stored {
+		byValue[alias.Alias] = append(byValue[alias.Alias], alias)
+	}
+
+	for i, ref := range normalized {
+		best := -1
+		for _, value := range ref.Aliases() {
+			for _, alias := range byValue[value] {
+				score, ok := matchAlias(ref, value, alias)
+				if ok && score > best {
+					ids[i], best = alias.ResourceID, score
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
+// matchAlias reports whether a stored alias with the given value names the
+// ref's resource, and how closely
+func matchAlias(ref Ref, value string, alias models.ResourceAlias) (int, bool) {
+	if alias.Provider != ref.Provider {
+		return 0, false
+	}
+	score := 0
+	switch {
+	case alias.Account == ref.Account:
+		score += 2
+	case alias.Account != "" && ref.Account != "":
+		return 0, false
+	}
+	switch {
+	case alias.Region == ref.Region:
+		score++
+	case alias.Region != "" && ref.Region != "":
+		return 0, false
+	}
+	if value == ref.ARN {
+		score += 4
+	}
+	return score, true
+}
+
+// Resolve returns the Siros ID of each ref: the resource an alias already
+// names, or else the ID derived from the ref. Refs without natural
+// identifiers resolve to "".
+func (r *Resolver) Resolve(ctx context.Context, refs []Ref) ([]string, error) {
+	ids, err := r.Lookup(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range refs {
+		if ids[i] == "" && refs[i].Valid() {
+			ids[i] = refs[i].ID()
+		}
+	}
+	return ids, nil
+}
+
+// Register stores the aliases of each ref for the resource ids[i]. The
+// resources must already be stored.
+func (r *Resolver) Register(ctx context.Context, ids []string, refs []Ref, source string) error {
+	aliases := Aliases(ids, refs, source)
+	if len(aliases) == 0 {
+		return nil
+	}
+	if err := r.store.AddAliases(ctx, aliases); err != nil {
+		return fmt.Errorf("failed to register aliases: %w", err)
+	}
+	return nil
+}
+
+// Aliases returns the aliases Register stores for refs, for callers that
+// store them together with other writes
+func Aliases(ids []string, refs []Ref, source string) []models.ResourceAlias {
+	now := time.Now().UTC()
+	var aliases []models.ResourceAlias
+	for i := range refs {
+		if ids[i] == "" {
+			continue
+		}
+		ref := refs[i].Normalize()
+		for _, value := range ref.Aliases() {
+			aliases = append(aliases, models.ResourceAlias{
+				Provider:   ref.Provider,
+				Account:    ref.Account,
+				Region:     ref.Region,
+				Alias:      value,
+				ResourceID: ids[i],
+				Source:     source,
+				CreatedAt:  now,
+			})
+		}
+	}
+	return aliases
+}
@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// activityLogRecord covers both the diagnostic settings export of the Azure
+// Activity Log and the Activity Log REST API event format
+type activityLogRecord struct {
+	// Diagnostic settings export
+	Time       time.Time `json:"time"`
+	ResultType string    `json:"resultType"`
+	Identity   struct {
+		Claims        map[string]string `json:"claims"`
+		Authorization struct {
+			Evidence struct {
+				PrincipalID string `json:"principalId"`
+			} `json:"evidence"`
+		} `json:"authorization"`
+	} `json:"identity"`
+
+	// REST API
+	EventDataID    string    `json:"eventDataId"`
+	EventTimestamp time.Time `json:"eventTimestamp"`
+	Caller         string    `json:"caller"`
+	Status         struct {
+		Value string `json:"value"`
+	} `json:"status"`
+
+	CorrelationID string          `json:"correlationId"`
+	ResourceID    string          `json:"resourceId"`
+	OperationName json.RawMessage `json:"operationName"`
+}
+
+// activityLogActorClaims are the token claims that name the caller, in order
+// of preference
+var activityLogActorClaims = []string{
+	"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/upn",
+	"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name",
+	"appid",
+}
+
+// parseActivityLog maps a successful write, action or delete operation to a
+// change of the resource it targets
+func parseActivityLog(raw json.RawMessage) ([]Event, error) {
+	var record activityLogRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode Activity Log record: %w", err)
+	}
+	if record.ResultType != "Success" && record.Status.Value != "Succeeded" {
+		return nil, nil
+	}
+
+	// operationName is a string in exports and {"value": ...} in the REST API
+	var operation string
+	if err := json.Unmarshal(record.OperationName, &operation); err != nil {
+		var named struct {
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(record.OperationName, &named); err != nil {
+			return nil, fmt.Errorf("failed to decode Activity Log operation: %w", err)
+		}
+		operation = named.Value
+	}
+
+	var action Action
+	switch lower := strings.ToLower(operation); {
+	case strings.HasSuffix(lower, "/delete"):
+		action = ActionDelete
+	case strings.HasSuffix(lower, "/write"):
+		action = ActionRefresh
+	case strings.HasSuffix(lower, "/action") && !strings.Contains(lower, "/list"):
+		action = ActionRefresh
+	default:
+		return nil, nil
+	}
+
+	id := record.EventDataID
+	if id == "" {
+		id = record.CorrelationID
+	}
+	at := record.EventTimestamp
+	if at.IsZero() {
+		at = record.Time
+	}
+
+	return []Event{{
+		Source:     SourceActivityLog,
+		Provider:   "azure",
+		ID:         id,
+		Name:       operation,
+		Action:     action,
+		ResourceID: record.ResourceID,
+		Actor:      activityLogActor(&record),
+		Time:       at,
+	}}, nil
+}
+
+// activityLogActor returns the user or service principal that made the call
+func activityLogActor(record *activityLogRecord) string {
+	if record.Caller != "" {
+		return record.Caller
+	}
+	for _, claim := range activityLogActorClaims {
+		if value := record.Identity.Claims[claim]; value != "" {
+			return value
+		}
+	}
+	if principal := record.Identity.Authorization.Evidence.PrincipalID; principal != "" {
+		return principal
+	}
+	return "azure"
+}
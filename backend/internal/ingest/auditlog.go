@@ -0,0 +1,82 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// auditLogEntry is a Cloud Logging entry carrying a Cloud Audit Logs AuditLog
+type auditLogEntry struct {
+	InsertID     string    `json:"insertId"`
+	Timestamp    time.Time `json:"timestamp"`
+	ProtoPayload struct {
+		ServiceName        string `json:"serviceName"`
+		MethodName         string `json:"methodName"`
+		ResourceName       string `json:"resourceName"`
+		AuthenticationInfo struct {
+			PrincipalEmail string `json:"principalEmail"`
+		} `json:"authenticationInfo"`
+		Status *struct {
+			Code int `json:"code"`
+		} `json:"status"`
+	} `json:"protoPayload"`
+
+	// Operation is set on entries of long-running operations, which log
+	// once when the call is made and again when it completes
+	Operation *struct {
+		Last bool `json:"last"`
+	} `json:"operation"`
+}
+
+// auditLogReadMethods identify method verbs that never change a resource
+var auditLogReadMethods = []string{"get", "list", "aggregatedlist", "search", "testiampermissions"}
+
+// parseAuditLog maps a completed Admin Activity audit entry to a change of
+// the resource it names. The resource ID is the Cloud Asset name,
+// //<service>/<resourceName>.
+func parseAuditLog(raw json.RawMessage) ([]Event, error) {
+	var entry auditLogEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode audit log entry: %w", err)
+	}
+	payload := &entry.ProtoPayload
+	if payload.Status != nil && payload.Status.Code != 0 {
+		return nil, nil
+	}
+	if entry.Operation != nil && !entry.Operation.Last {
+		return nil, nil
+	}
+	if payload.ServiceName == "" || payload.ResourceName == "" {
+		return nil, nil
+	}
+
+	// The verb is the last segment, e.g. v1.compute.instances.insert
+	verb := strings.ToLower(payload.MethodName[strings.LastIndex(payload.MethodName, ".")+1:])
+	for _, read := range auditLogReadMethods {
+		if strings.HasPrefix(verb, read) {
+			return nil, nil
+		}
+	}
+	action := ActionRefresh
+	if strings.HasPrefix(verb, "delete") {
+		action = ActionDelete
+	}
+
+	actor := payload.AuthenticationInfo.PrincipalEmail
+	if actor == "" {
+		actor = "gcp"
+	}
+
+	return []Event{{
+		Source:     SourceAuditLog,
+		Provider:   "gcp",
+		ID:         entry.InsertID,
+		Name:       payload.MethodName,
+		Action:     action,
+		ResourceID: "//" + payload.ServiceName + "/" + strings.TrimPrefix(payload.ResourceName, "/"),
+		Actor:      actor,
+		Time:       entry.Timestamp,
+	}}, nil
+}
@@ -0,0 +1,159 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/providers"
+)
+
+// cloudTrailRecord is the subset of a CloudTrail event used for ingestion
+type cloudTrailRecord struct {
+	EventID            string    `json:"eventID"`
+	EventTime          time.Time `json:"eventTime"`
+	EventSource        string    `json:"eventSource"`
+	EventName          string    `json:"eventName"`
+	AWSRegion          string    `json:"awsRegion"`
+	RecipientAccountID string    `json:"recipientAccountId"`
+	ErrorCode          string    `json:"errorCode"`
+	ReadOnly           bool      `json:"readOnly"`
+	UserIdentity       struct {
+		Type        string `json:"type"`
+		ARN         string `json:"arn"`
+		AccountID   string `json:"accountId"`
+		PrincipalID string `json:"principalId"`
+		InvokedBy   string `json:"invokedBy"`
+	} `json:"userIdentity"`
+	RequestParameters cloudTrailParameters `json:"requestParameters"`
+	ResponseElements  cloudTrailParameters `json:"responseElements"`
+}
+
+// cloudTrailParameters holds the request and response fields that name resources
+type cloudTrailParameters struct {
+	InstanceID   string          `json:"instanceId"`
+	InstancesSet cloudTrailItems `json:"instancesSet"`
+	ResourcesSet cloudTrailItems `json:"resourcesSet"`
+	BucketName   string          `json:"bucketName"`
+	DBInstanceID string          `json:"dBInstanceIdentifier"`
+}
+
+// cloudTrailItems is the {"items": [...]} wrapper EC2 uses for lists
+type cloudTrailItems struct {
+	Items []struct {
+		InstanceID string `json:"instanceId"`
+		ResourceID string `json:"resourceId"`
+	} `json:"items"`
+}
+
+// cloudTrailDeletes lists the calls that remove a tracked resource
+var cloudTrailDeletes = map[string]bool{
+	"TerminateInstances": true,
+	"DeleteBucket":       true,
+	"DeleteDBInstance":   true,
+}
+
+// cloudTrailReadPrefixes identify calls that never change a resource. Most
+// carry readOnly, but older and data events do not.
+var cloudTrailReadPrefixes = []string{"Get", "List", "Describe", "Head", "Lookup"}
+
+// parseCloudTrail maps a CloudTrail event to changes of the EC2 instances, S3
+// buckets and RDS instances it names. Events of other services yield no
+// changes; their resources are brought up to date by the next scan.
+func parseCloudTrail(raw json.RawMessage) ([]Event, error) {
+	var record cloudTrailRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode CloudTrail event: %w", err)
+	}
+	if record.ReadOnly || record.ErrorCode != "" {
+		return nil, nil
+	}
+	for _, prefix := range cloudTrailReadPrefixes {
+		if strings.HasPrefix(record.EventName, prefix) {
+			return nil, nil
+		}
+	}
+
+	account := record.RecipientAccountID
+	if account == "" {
+		account = record.UserIdentity.AccountID
+	}
+	action := ActionRefresh
+	if cloudTrailDeletes[record.EventName] {
+		action = ActionDelete
+	}
+
+	event := Event{
+		Source:   SourceCloudTrail,
+		Provider: "aws",
+		ID:       record.EventID,
+		Name:     record.EventName,
+		Action:   action,
+		Actor:    cloudTrailActor(&record),
+		Time:     record.EventTime,
+	}
+
+	var events []Event
+	seen := make(map[string]bool)
+	add := func(id, lookupARN string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		e := event
+		e.ResourceID = id
+		e.LookupID = lookupARN
+		events = append(events, e)
+	}
+
+	instanceARN := func(id string) string {
+		return fmt.Sprintf("arn:%s:ec2:%s:%s:instance/%s", providers.AWSPartition(record.AWSRegion), record.AWSRegion, account, id)
+	}
+	switch record.EventSource {
+	case "ec2.amazonaws.com":
+		params := []cloudTrailParameters{record.RequestParameters, record.ResponseElements}
+		for i := range params {
+			if strings.HasPrefix(params[i].InstanceID, "i-") {
+				add(params[i].InstanceID, instanceARN(params[i].InstanceID))
+			}
+			for _, item := range params[i].InstancesSet.Items {
+				add(item.InstanceID, instanceARN(item.InstanceID))
+			}
+			for _, item := range params[i].ResourcesSet.Items {
+				if strings.HasPrefix(item.ResourceID, "i-") {
+					add(item.ResourceID, instanceARN(item.ResourceID))
+				}
+			}
+		}
+
+	case "s3.amazonaws.com":
+		// Object-level data events also carry bucketName but leave the bucket unchanged
+		if !strings.Contains(record.EventName, "Bucket") {
+			break
+		}
+		bucket := record.RequestParameters.BucketName
+		add(bucket, fmt.Sprintf("arn:%s:s3:::%s", providers.AWSPartition(record.AWSRegion), bucket))
+
+	case "rds.amazonaws.com":
+		id := record.RequestParameters.DBInstanceID
+		add(id, fmt.Sprintf("arn:%s:rds:%s:%s:db:%s", providers.AWSPartition(record.AWSRegion), record.AWSRegion, account, id))
+	}
+	return events, nil
+}
+
+// cloudTrailActor returns the principal that made the call. Assumed-role ARNs
+// keep the session name, which usually identifies the person or pipeline.
+func cloudTrailActor(record *cloudTrailRecord) string {
+	identity := &record.UserIdentity
+	switch {
+	case identity.Type == "AWSService" && identity.InvokedBy != "":
+		return identity.InvokedBy
+	case identity.ARN != "":
+		return identity.ARN
+	case identity.PrincipalID != "":
+		return identity.PrincipalID
+	default:
+		return "aws"
+	}
+}
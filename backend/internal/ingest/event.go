@@ -0,0 +1,143 @@
+// Package ingest applies cloud audit events to the resource store. Each
+// create, modify or delete call recorded by CloudTrail, the Azure Activity Log
+// or GCP Cloud Audit Logs becomes a targeted refresh or deletion, attributed
+// to the caller who made it.
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+)
+
+// Action is what an event asks the ingester to do with its resource
+type Action string
+
+const (
+	// ActionRefresh re-reads the resource from its provider and upserts it
+	ActionRefresh Action = "refresh"
+	// ActionDelete removes the resource from the store
+	ActionDelete Action = "delete"
+)
+
+// Event sources
+const (
+	SourceCloudTrail  = "cloudtrail"
+	SourceActivityLog = "activitylog"
+	SourceAuditLog    = "auditlog"
+)
+
+// Event is a single resource change taken from an audit record
+type Event struct {
+	Source   string `json:"source"`
+	Provider string `json:"provider"`
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Action   Action `json:"action"`
+
+	// ResourceID is the native ID of the resource, which the ingester
+	// resolves to its stored ID like a scan does
+	ResourceID string `json:"resource_id"`
+
+	// LookupID is passed to the provider's GetResource. It defaults to
+	// ResourceID; AWS events use the ARN so the account and region are known.
+	LookupID string `json:"lookup_id,omitempty"`
+
+	Actor string    `json:"actor"`
+	Time  time.Time `json:"time"`
+}
+
+// lookupID returns the ID to fetch the resource with
+func (e *Event) lookupID() string {
+	if e.LookupID != "" {
+		return e.LookupID
+	}
+	return e.ResourceID
+}
+
+// ref returns the natural identifiers of the event's resource. An ARN lookup
+// ID names the account and region of a native ID that may be reused in others.
+func (e *Event) ref() identity.Ref {
+	ref := identity.Ref{Provider: e.Provider, NativeID: e.ResourceID}
+	if strings.HasPrefix(e.LookupID, "arn:") {
+		ref.ARN = e.LookupID
+	}
+	return ref
+}
+
+// errUnknownRecord is returned for records that are not in a supported format
+var errUnknownRecord = errors.New("unrecognized audit record")
+
+// Decoder reads audit records from a stream of JSON values, one record per
+// line or wrapped in the {"Records": [...]} or {"records": [...]} envelopes
+// used by CloudTrail log files and Azure Event Hub exports
+type Decoder struct {
+	dec     *json.Decoder
+	pending []json.RawMessage
+}
+
+// NewDecoder returns a decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r)}
+}
+
+// recordProbe holds the fields used to tell record formats apart. The
+// envelope keys differ only in case, which encoding/json ignores, so they
+// are told apart after decoding.
+type recordProbe struct {
+	Records       []json.RawMessage `json:"records"`
+	EventSource   string            `json:"eventSource"`
+	ProtoPayload  json.RawMessage   `json:"protoPayload"`
+	OperationName json.RawMessage   `json:"operationName"`
+	ResourceID    string            `json:"resourceId"`
+}
+
+// Next returns the events of the next record. Records that describe no
+// resource change, such as reads and failed calls, return no events. It
+// returns io.EOF when the stream is exhausted.
+func (d *Decoder) Next() ([]Event, error) {
+	for len(d.pending) == 0 {
+		var raw json.RawMessage
+		if err := d.dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to decode audit record: %w", err)
+		}
+		var probe recordProbe
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			return nil, fmt.Errorf("failed to decode audit record: %w", err)
+		}
+		if probe.Records == nil {
+			return parseRecord(raw, &probe)
+		}
+		d.pending = probe.Records
+	}
+
+	raw := d.pending[0]
+	d.pending = d.pending[1:]
+	var probe recordProbe
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, fmt.Errorf("failed to decode audit record: %w", err)
+	}
+	return parseRecord(raw, &probe)
+}
+
+// parseRecord parses a record in the format its fields identify
+func parseRecord(raw json.RawMessage, probe *recordProbe) ([]Event, error) {
+	switch {
+	case probe.EventSource != "":
+		return parseCloudTrail(raw)
+	case probe.ProtoPayload != nil:
+		return parseAuditLog(raw)
+	case probe.OperationName != nil && probe.ResourceID != "":
+		return parseActivityLog(raw)
+	default:
+		return nil, errUnknownRecord
+	}
+}
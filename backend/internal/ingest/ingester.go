@@ -0,0 +1,275 @@
+package ingest
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/providers"
+	"github.com/LederWorks/siros/backend/internal/services"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// ProviderLookup returns a registered provider by name
+type ProviderLookup interface {
+	GetProvider(name string) (types.Provider, error)
+}
+
+// ResourceStore deletes stored resources
+type ResourceStore interface {
+	Delete(ctx context.Context, id string) error
+}
+
+// ChangeLedger records resource changes
+type ChangeLedger interface {
+	CreateRecord(ctx context.Context, record *models.ChangeRecord) error
+	GetLatestRecord(ctx context.Context, resourceID string) (*models.ChangeRecord, error)
+}
+
+// Stats counts the outcome of ingesting a stream of audit records
+type Stats struct {
+	Events    int `json:"events"`
+	Refreshed int `json:"refreshed"`
+	Deleted   int `json:"deleted"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+}
+
+// errSkipped marks events that need no change, such as deletes of resources
+// that were never stored
+var errSkipped = errors.New("skipped")
+
+// Ingester applies audit events to the resource store and change ledger
+type Ingester struct {
+	providers ProviderLookup
+	sink      providers.ResourceSink
+	identity  *identity.Resolver
+	resources ResourceStore
+	ledger    ChangeLedger
+	chain     *services.ChangeChain
+	logger    *log.Logger
+}
+
+// NewIngester creates an ingester. Refreshed resources are written through
+// sink, so they are stored exactly as a scan would store them; resolver must
+// be the one the sink assigns IDs with.
+func NewIngester(lookup ProviderLookup, sink providers.ResourceSink, resolver *identity.Resolver, resources ResourceStore, ledger ChangeLedger, logger *log.Logger) *Ingester {
+	return &Ingester{
+		providers: lookup,
+		sink:      sink,
+		identity:  resolver,
+		resources: resources,
+		ledger:    ledger,
+		chain:     services.NewChangeChain(),
+		logger:    logger,
+	}
+}
+
+// Ingest applies every event read from r. Failures of individual events are
+// logged and counted; only an unreadable stream stops ingestion.
+func (i *Ingester) Ingest(ctx context.Context, r io.Reader) (Stats, error) {
+	var stats Stats
+	dec := NewDecoder(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		events, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return stats, nil
+		}
+		if errors.Is(err, errUnknownRecord) {
+			stats.Skipped++
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		for j := range events {
+			stats.Events++
+			err := i.Apply(ctx, &events[j])
+			switch {
+			case errors.Is(err, errSkipped):
+				stats.Skipped++
+			case err != nil:
+				stats.Failed++
+				i.logger.Printf("Failed to apply %s event %s for %s: %v", events[j].Source, events[j].Name, events[j].ResourceID, err)
+			case events[j].Action == ActionDelete:
+				stats.Deleted++
+			default:
+				stats.Refreshed++
+			}
+		}
+	}
+}
+
+// IngestFile ingests a file of audit records, which may be gzip-compressed
+// as CloudTrail delivers them
+func (i *Ingester) IngestFile(ctx context.Context, path string) (Stats, error) {
+	// #nosec G304 -- path is an operator-supplied audit export or a spool directory entry
+	file, err := os.Open(path)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return Stats{}, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	stats, err := i.Ingest(ctx, r)
+	if err != nil {
+		return stats, fmt.Errorf("failed to ingest %s: %w", path, err)
+	}
+	return stats, nil
+}
+
+// Watch treats dir as a local queue: files dropped into it are ingested in
+// name order every interval, then moved to its processed/ or failed/
+// subdirectory. It returns when ctx is cancelled.
+func (i *Ingester) Watch(ctx context.Context, dir string, interval time.Duration) error {
+	for _, sub := range []string{"processed", "failed"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return fmt.Errorf("failed to create spool directory: %w", err)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := i.drain(ctx, dir); err != nil {
+			i.logger.Printf("Failed to read spool directory %s: %v", dir, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain ingests every queued file in dir
+func (i *Ingester) drain(ctx context.Context, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && (strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".jsonl") || strings.HasSuffix(name, ".gz")) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return nil
+		}
+		path := filepath.Join(dir, name)
+		target := "processed"
+		stats, err := i.IngestFile(ctx, path)
+		if err != nil {
+			if ctx.Err() != nil {
+				// Leave the file queued; it is ingested again on restart
+				return nil
+			}
+			i.logger.Printf("Failed to ingest %s: %v", path, err)
+			target = "failed"
+		}
+		i.logger.Printf("Ingested %s: %d events, %d refreshed, %d deleted, %d skipped, %d failed",
+			name, stats.Events, stats.Refreshed, stats.Deleted, stats.Skipped, stats.Failed)
+		if err := os.Rename(path, filepath.Join(dir, target, name)); err != nil {
+			return fmt.Errorf("failed to dequeue %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Apply refreshes or deletes the resource named by an event and records the
+// change with the event's caller as its actor
+func (i *Ingester) Apply(ctx context.Context, event *Event) error {
+	if event.Action == ActionDelete {
+		return i.delete(ctx, event)
+	}
+	return i.refresh(ctx, event)
+}
+
+// refresh re-reads a resource from its provider and upserts it
+func (i *Ingester) refresh(ctx context.Context, event *Event) error {
+	provider, err := i.providers.GetProvider(event.Provider)
+	if err != nil {
+		// Events for providers that are not configured are expected in shared exports
+		return errSkipped
+	}
+
+	resource, err := provider.GetResource(event.lookupID())
+	if err != nil {
+		return fmt.Errorf("failed to fetch resource: %w", err)
+	}
+
+	// The sink stores the resource under the ID one of its aliases names in
+	// its provider, account and region, or else the ID derived from it
+	ref := identity.ForResource(resource)
+	ids, err := i.identity.Lookup(ctx, []identity.Ref{ref})
+	if err != nil {
+		return err
+	}
+	operation, id := "UPDATE", ids[0]
+	if id == "" {
+		operation, id = "CREATE", ref.ID()
+	}
+
+	now := time.Now()
+	resource.LastScannedAt = &now
+	if err := i.sink.UpsertResources(ctx, []types.Resource{*resource}); err != nil {
+		return err
+	}
+	return i.record(ctx, id, operation, event)
+}
+
+// delete removes a stored resource
+func (i *Ingester) delete(ctx context.Context, event *Event) error {
+	ids, err := i.identity.Lookup(ctx, []identity.Ref{event.ref()})
+	if err != nil {
+		return err
+	}
+	if ids[0] == "" {
+		return errSkipped
+	}
+
+	if err := i.resources.Delete(ctx, ids[0]); err != nil {
+		return err
+	}
+	return i.record(ctx, ids[0], "DELETE", event)
+}
+
+// record appends a change record chained to the resource's previous record
+func (i *Ingester) record(ctx context.Context, resourceID, operation string, event *Event) error {
+	_, err := i.chain.Append(ctx, i.ledger, resourceID, operation, event.Actor, event.Time, map[string]interface{}{
+		"source":     event.Source,
+		"event_id":   event.ID,
+		"event_name": event.Name,
+	})
+	return err
+}
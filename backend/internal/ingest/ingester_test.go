@@ -0,0 +1,347 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// fakeProvider serves GetResource from a fixed set of resources keyed by lookup ID
+type fakeProvider struct {
+	name      string
+	resources map[string]types.Resource
+}
+
+func (p *fakeProvider) Name() string                                   { return p.name }
+func (p *fakeProvider) Validate() error                                { return nil }
+func (p *fakeProvider) Scan(context.Context) ([]types.Resource, error) { return nil, nil }
+
+func (p *fakeProvider) GetResource(id string) (*types.Resource, error) {
+	resource, ok := p.resources[id]
+	if !ok {
+		return nil, fmt.Errorf("resource not found: %s", id)
+	}
+	return &resource, nil
+}
+
+type fakeProviders map[string]types.Provider
+
+func (f fakeProviders) GetProvider(name string) (types.Provider, error) {
+	if p, ok := f[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("provider not found: %s", name)
+}
+
+// fakeStore is the resource store, alias store, ledger and sink in one. Like
+// the scan sink, it stores resources under the IDs the resolver assigns.
+type fakeStore struct {
+	resources map[string]*models.Resource
+	aliases   []models.ResourceAlias
+	records   []models.ChangeRecord
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{resources: make(map[string]*models.Resource)}
+}
+
+// add stores a resource under the ID derived from ref, with its aliases
+func (s *fakeStore) add(ref identity.Ref) string {
+	id := ref.ID()
+	s.resources[id] = &models.Resource{ID: id, Provider: ref.Provider}
+	s.aliases = append(s.aliases, identity.Aliases([]string{id}, []identity.Ref{ref}, "scan")...)
+	return id
+}
+
+func (s *fakeStore) Delete(_ context.Context, id string) error {
+	delete(s.resources, id)
+	return nil
+}
+
+func (s *fakeStore) FindAliases(_ context.Context, values []string) ([]models.ResourceAlias, error) {
+	var found []models.ResourceAlias
+	for _, alias := range s.aliases {
+		if slices.Contains(values, alias.Alias) {
+			found = append(found, alias)
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeStore) AddAliases(_ context.Context, aliases []models.ResourceAlias) error {
+	for _, alias := range aliases {
+		if !slices.ContainsFunc(s.aliases, func(a models.ResourceAlias) bool {
+			return a.Provider == alias.Provider && a.Account == alias.Account && a.Region == alias.Region && a.Alias == alias.Alias
+		}) {
+			s.aliases = append(s.aliases, alias)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) UpsertResources(ctx context.Context, resources []types.Resource) error {
+	resolver := identity.NewResolver(s)
+	refs := make([]identity.Ref, len(resources))
+	for i := range resources {
+		refs[i] = identity.ForResource(&resources[i])
+	}
+	ids, err := resolver.Resolve(ctx, refs)
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		s.resources[id] = &models.Resource{ID: id, Provider: resources[i].Provider}
+	}
+	return resolver.Register(ctx, ids, refs, "scan")
+}
+
+func (s *fakeStore) CreateRecord(_ context.Context, record *models.ChangeRecord) error {
+	s.records = append(s.records, *record)
+	return nil
+}
+
+func (s *fakeStore) GetLatestRecord(_ context.Context, resourceID string) (*models.ChangeRecord, error) {
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if s.records[i].ResourceID == resourceID {
+			return &s.records[i], nil
+		}
+	}
+	return nil, fmt.Errorf("change records %w for resource: %s", repositories.ErrNotFound, resourceID)
+}
+
+func newTestIngester(store *fakeStore) *Ingester {
+	lookup := fakeProviders{
+		"aws": &fakeProvider{name: "aws", resources: map[string]types.Resource{
+			"arn:aws:ec2:eu-west-1:111111111111:instance/i-0abc": {ID: "i-0abc", Provider: "aws"},
+		}},
+		"azure": &fakeProvider{name: "azure", resources: map[string]types.Resource{
+			"/subscriptions/sub-1/resourceGroups/web/providers/Microsoft.Storage/storageAccounts/webdata": {
+				ID: "/subscriptions/sub-1/resourceGroups/web/providers/Microsoft.Storage/storageAccounts/webdata", Provider: "azure",
+			},
+		}},
+		"gcp": &fakeProvider{name: "gcp", resources: map[string]types.Resource{
+			"//compute.googleapis.com/projects/web-prod/zones/europe-west1-b/instances/vm-1": {
+				ID: "//compute.googleapis.com/projects/web-prod/zones/europe-west1-b/instances/vm-1", Provider: "gcp",
+			},
+		}},
+	}
+	return NewIngester(lookup, store, identity.NewResolver(store), store, store, log.New(io.Discard, "", 0))
+}
+
+func TestIngester_CloudTrail(t *testing.T) {
+	store := newFakeStore()
+	bucket := store.add(identity.Ref{Provider: "aws", NativeID: "old-logs", ARN: "arn:aws:s3:::old-logs"})
+	stats, err := newTestIngester(store).IngestFile(t.Context(), "testdata/cloudtrail.json")
+	if err != nil {
+		t.Fatalf("IngestFile failed: %v", err)
+	}
+
+	// Reads, object-level events and failed calls produce no events
+	if stats.Events != 2 || stats.Refreshed != 1 || stats.Deleted != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	instance := identity.Ref{Provider: "aws", NativeID: "i-0abc"}.ID()
+	if store.resources[instance] == nil || store.resources[bucket] != nil {
+		t.Errorf("Expected the instance to be stored and the bucket removed, got %v", store.resources)
+	}
+
+	if len(store.records) != 2 {
+		t.Fatalf("Expected 2 change records, got %d", len(store.records))
+	}
+	created := store.records[0]
+	if created.ResourceID != instance {
+		t.Errorf("Expected the creation to be recorded for %s, got %s", instance, created.ResourceID)
+	}
+	if created.Operation != "CREATE" || created.Actor != "arn:aws:sts::111111111111:assumed-role/Deployer/alice@example.com" {
+		t.Errorf("Expected the creation to be attributed to the assumed role session, got %+v", created)
+	}
+	if !created.Timestamp.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) || created.DataHash == "" {
+		t.Errorf("Expected the event time and a hash on the record, got %+v", created)
+	}
+	if deleted := store.records[1]; deleted.Operation != "DELETE" || deleted.Actor != "arn:aws:iam::111111111111:user/bob" {
+		t.Errorf("Unexpected delete record: %+v", deleted)
+	}
+}
+
+func TestParseCloudTrail_Coverage(t *testing.T) {
+	// Only EC2 instances, S3 buckets and RDS instances are mapped; changes
+	// to other scanned services wait for the next scan
+	for _, raw := range []string{
+		`{"eventSource":"lambda.amazonaws.com","eventName":"CreateFunction20150331","requestParameters":{"functionName":"api"}}`,
+		`{"eventSource":"iam.amazonaws.com","eventName":"CreateRole","requestParameters":{"roleName":"deployer"}}`,
+		`{"eventSource":"dynamodb.amazonaws.com","eventName":"DeleteTable","requestParameters":{"tableName":"orders"}}`,
+	} {
+		events, err := parseCloudTrail(json.RawMessage(raw))
+		if err != nil || len(events) != 0 {
+			t.Errorf("Expected %s to be ignored, got %+v, %v", raw, events, err)
+		}
+	}
+
+	events, err := parseCloudTrail(json.RawMessage(`{"eventSource":"ec2.amazonaws.com","eventName":"StartInstances","awsRegion":"us-iso-east-1",` +
+		`"recipientAccountId":"111111111111","requestParameters":{"instancesSet":{"items":[{"instanceId":"i-0abc"}]}}}`))
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected one event, got %+v, %v", events, err)
+	}
+	if want := "arn:aws-iso:ec2:us-iso-east-1:111111111111:instance/i-0abc"; events[0].LookupID != want {
+		t.Errorf("Expected lookup ID %s, got %s", want, events[0].LookupID)
+	}
+}
+
+func TestIngester_ActivityLog(t *testing.T) {
+	vm := "/subscriptions/sub-1/resourceGroups/web/providers/Microsoft.Compute/virtualMachines/vm-1"
+	store := newFakeStore()
+	stored := store.add(identity.Ref{Provider: "azure", NativeID: vm})
+	stats, err := newTestIngester(store).IngestFile(t.Context(), "testdata/activitylog.jsonl")
+	if err != nil {
+		t.Fatalf("IngestFile failed: %v", err)
+	}
+	if stats.Deleted != 1 || stats.Refreshed != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// The export upper-cases the ID, but the stored resource is still found
+	if store.resources[stored] != nil {
+		t.Error("Expected the VM to be deleted")
+	}
+	if store.records[0].ResourceID != stored || store.records[0].Actor != "carol@example.com" {
+		t.Errorf("Unexpected delete record: %+v", store.records[0])
+	}
+	if store.records[1].Actor != "pipeline@example.com" {
+		t.Errorf("Expected the REST caller as actor, got %+v", store.records[1])
+	}
+}
+
+func TestIngester_AuditLog(t *testing.T) {
+	store := newFakeStore()
+	stats, err := newTestIngester(store).IngestFile(t.Context(), "testdata/auditlog.jsonl")
+	if err != nil {
+		t.Fatalf("IngestFile failed: %v", err)
+	}
+
+	// Only the completed insert applies; the failed delete and the
+	// non-audit entry are skipped
+	if stats.Events != 1 || stats.Refreshed != 1 || stats.Skipped != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if len(store.records) != 1 || store.records[0].Actor != "dave@example.com" || store.records[0].Operation != "CREATE" {
+		t.Errorf("Unexpected records: %+v", store.records)
+	}
+}
+
+func TestIngester_ChainsRecords(t *testing.T) {
+	store := newFakeStore()
+	ingester := newTestIngester(store)
+	for i := 0; i < 2; i++ {
+		if _, err := ingester.IngestFile(t.Context(), "testdata/auditlog.jsonl"); err != nil {
+			t.Fatalf("IngestFile failed: %v", err)
+		}
+	}
+
+	if len(store.records) != 2 {
+		t.Fatalf("Expected 2 change records, got %d", len(store.records))
+	}
+	if store.records[1].Operation != "UPDATE" || store.records[1].PreviousHash != store.records[0].DataHash {
+		t.Errorf("Expected the second record to update and chain to the first, got %+v", store.records[1])
+	}
+}
+
+func TestIngester_DeleteByARN(t *testing.T) {
+	// i-0abc is the native ID of instances in two accounts; the ARN names one
+	store := newFakeStore()
+	prod := store.add(identity.Ref{Provider: "aws", Account: "111111111111", Region: "eu-west-1", NativeID: "i-0abc"})
+	dev := store.add(identity.Ref{Provider: "aws", Account: "222222222222", Region: "eu-west-1", NativeID: "i-0abc"})
+
+	err := newTestIngester(store).Apply(t.Context(), &Event{
+		Source: SourceCloudTrail, Provider: "aws", Name: "TerminateInstances", Action: ActionDelete,
+		ResourceID: "i-0abc", LookupID: "arn:aws:ec2:eu-west-1:222222222222:instance/i-0abc", Actor: "arn:aws:iam::111111111111:user/bob", Time: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if store.resources[prod] == nil || store.resources[dev] != nil {
+		t.Errorf("Expected only the instance in the event's account to be deleted, got %v", store.resources)
+	}
+	if len(store.records) != 1 || store.records[0].ResourceID != dev {
+		t.Errorf("Expected the delete to be recorded for %s, got %+v", dev, store.records)
+	}
+}
+
+func TestIngester_RefreshByAccount(t *testing.T) {
+	// The refreshed instance updates the record of its own account, not the
+	// one sharing its native ID elsewhere
+	store := newFakeStore()
+	other := store.add(identity.Ref{Provider: "aws", Account: "222222222222", Region: "eu-west-1", NativeID: "i-0abc"})
+	ingester := newTestIngester(store)
+	ingester.providers = fakeProviders{"aws": &fakeProvider{name: "aws", resources: map[string]types.Resource{
+		"arn:aws:ec2:eu-west-1:111111111111:instance/i-0abc": {
+			ID: "i-0abc", Provider: "aws", Region: "eu-west-1", ARN: "arn:aws:ec2:eu-west-1:111111111111:instance/i-0abc",
+		},
+	}}}
+
+	err := ingester.Apply(t.Context(), &Event{
+		Source: SourceCloudTrail, Provider: "aws", Name: "RunInstances", Action: ActionRefresh,
+		ResourceID: "i-0abc", LookupID: "arn:aws:ec2:eu-west-1:111111111111:instance/i-0abc", Actor: "arn:aws:iam::111111111111:user/bob", Time: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(store.records) != 1 || store.records[0].Operation != "CREATE" || store.records[0].ResourceID == other {
+		t.Errorf("Expected a creation recorded for a new resource, got %+v", store.records)
+	}
+	if store.resources[store.records[0].ResourceID] == nil {
+		t.Errorf("Expected the record to name the stored resource, got %+v", store.records[0])
+	}
+}
+
+func TestIngester_Watch(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("testdata/auditlog.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "001.jsonl"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "002.jsonl"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newFakeStore()
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- newTestIngester(store).Watch(ctx, dir, time.Hour) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "failed", "002.jsonl")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the spool directory to be drained")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "processed", "001.jsonl")); err != nil {
+		t.Errorf("Expected the ingested file to be moved to processed/: %v", err)
+	}
+	if len(store.records) != 1 {
+		t.Errorf("Expected 1 change record, got %d", len(store.records))
+	}
+}
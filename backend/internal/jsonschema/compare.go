@@ -0,0 +1,368 @@
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Compatibility classifies a new version of a schema against an old one
+type Compatibility string
+
+// Compatibility classes. A backward compatible schema accepts the data the
+// old one did, a forward compatible one only data the old one accepts, and
+// a fully compatible one both. Breaking changes are neither.
+const (
+	CompatibilityFull     Compatibility = "full"
+	CompatibilityBackward Compatibility = "backward"
+	CompatibilityForward  Compatibility = "forward"
+	CompatibilityBreaking Compatibility = "breaking"
+)
+
+// Change is a difference between two versions of a schema
+type Change struct {
+	// Path is the JSON pointer of the changed keyword
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Backward and Forward tell whether the change keeps the new version
+	// backward and forward compatible
+	Backward bool `json:"backward"`
+	Forward  bool `json:"forward"`
+}
+
+// Compare lists the changes from the previous to the next version of a
+// schema document and classifies the next version. Changes are classified
+// conservatively: those whose effect cannot be compared, such as a changed
+// pattern or conditional schema, are breaking. Annotations such as
+// description are ignored.
+func Compare(prev, next interface{}) (Compatibility, []Change, error) {
+	prev, err := normalize(prev)
+	if err != nil {
+		return "", nil, err
+	}
+	next, err = normalize(next)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var d differ
+	d.schema("", prev, next)
+
+	backward, forward := true, true
+	for _, change := range d.changes {
+		backward = backward && change.Backward
+		forward = forward && change.Forward
+	}
+	switch {
+	case backward && forward:
+		return CompatibilityFull, d.changes, nil
+	case backward:
+		return CompatibilityBackward, d.changes, nil
+	case forward:
+		return CompatibilityForward, d.changes, nil
+	default:
+		return CompatibilityBreaking, d.changes, nil
+	}
+}
+
+// differ collects the changes between two schema documents
+type differ struct {
+	changes []Change
+}
+
+// tighten records a change that rejects data the old version accepted
+func (d *differ) tighten(path, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{Path: path, Message: fmt.Sprintf(format, args...), Forward: true})
+}
+
+// loosen records a change that accepts data the old version rejected
+func (d *differ) loosen(path, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{Path: path, Message: fmt.Sprintf(format, args...), Backward: true})
+}
+
+// breaking records a change that cannot be classified
+func (d *differ) breaking(path, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// annotations are keywords that do not affect validation
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$anchor": true, "$comment": true,
+	"title": true, "description": true, "default": true, "examples": true,
+	"deprecated": true, "readOnly": true, "writeOnly": true, "format": true,
+	"contentEncoding": true, "contentMediaType": true, "contentSchema": true,
+}
+
+// lowerBounds and upperBounds are the keywords that tighten a schema as
+// they grow and as they shrink
+var (
+	lowerBounds = map[string]bool{
+		"minimum": true, "exclusiveMinimum": true, "minLength": true,
+		"minItems": true, "minProperties": true, "minContains": true,
+	}
+	upperBounds = map[string]bool{
+		"maximum": true, "exclusiveMaximum": true, "maxLength": true,
+		"maxItems": true, "maxProperties": true, "maxContains": true,
+	}
+	// subschemas are the keywords holding one schema, which is true when
+	// absent
+	subschemas = map[string]bool{
+		"additionalProperties": true, "items": true, "propertyNames": true,
+		"unevaluatedProperties": true, "unevaluatedItems": true,
+	}
+)
+
+// schema compares the schemas old and new found at path
+func (d *differ) schema(path string, prev, next interface{}) {
+	if reflect.DeepEqual(prev, next) {
+		return
+	}
+	if b, ok := prev.(bool); ok {
+		if b {
+			prev = map[string]interface{}{}
+		} else {
+			d.loosen(path, "schema no longer rejects every value")
+			return
+		}
+	}
+	if b, ok := next.(bool); ok {
+		if b {
+			next = map[string]interface{}{}
+		} else {
+			d.tighten(path, "schema now rejects every value")
+			return
+		}
+	}
+	oldObj, oldOK := prev.(map[string]interface{})
+	newObj, newOK := next.(map[string]interface{})
+	if !oldOK || !newOK {
+		d.breaking(path, "schema is malformed")
+		return
+	}
+
+	keywords := map[string]bool{}
+	for keyword := range oldObj {
+		keywords[keyword] = true
+	}
+	for keyword := range newObj {
+		keywords[keyword] = true
+	}
+	for _, keyword := range sortedSet(keywords) {
+		oldValue, inOld := oldObj[keyword]
+		newValue, inNew := newObj[keyword]
+		if annotations[keyword] || reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		d.keyword(path+"/"+escape(keyword), keyword, oldValue, inOld, newValue, inNew, oldObj, newObj)
+	}
+}
+
+// keyword compares the values of a keyword that differs between versions
+func (d *differ) keyword(path, keyword string, prev interface{}, inOld bool, next interface{}, inNew bool, oldObj, newObj map[string]interface{}) {
+	switch {
+	case keyword == "type":
+		d.types(path, prev, inOld, next, inNew)
+	case keyword == "required":
+		oldSet, newSet := stringSet(prev), stringSet(next)
+		for _, name := range sortedSet(newSet) {
+			if !oldSet[name] {
+				d.tighten(path, "property %q is now required", name)
+			}
+		}
+		for _, name := range sortedSet(oldSet) {
+			if !newSet[name] {
+				d.loosen(path, "property %q is no longer required", name)
+			}
+		}
+	case keyword == "properties":
+		d.properties(path, asObject(prev), asObject(next), oldObj, newObj)
+	case keyword == "$defs" || keyword == "definitions":
+		oldDefs, newDefs := asObject(prev), asObject(next)
+		for _, name := range sortedKeys(oldDefs) {
+			if def, ok := newDefs[name]; ok {
+				d.schema(path+"/"+escape(name), oldDefs[name], def)
+			}
+		}
+	case keyword == "enum":
+		d.enum(path, prev, inOld, next, inNew)
+	case lowerBounds[keyword] || upperBounds[keyword]:
+		d.bound(path, keyword, prev, inOld, next, inNew)
+	case keyword == "uniqueItems":
+		if next == true {
+			d.tighten(path, "items must now be unique")
+		} else {
+			d.loosen(path, "items no longer need to be unique")
+		}
+	case subschemas[keyword]:
+		if !inOld {
+			prev = true
+		}
+		if !inNew {
+			next = true
+		}
+		d.schema(path, prev, next)
+	case keyword == "if" || keyword == "then" || keyword == "else":
+		d.breaking(path, "%s changed; conditional schemas are not compared", keyword)
+	case !tightensOnly(keyword):
+		// Unknown keywords do not affect validation
+	case !inOld:
+		d.tighten(path, "%s added", keyword)
+	case !inNew:
+		d.loosen(path, "%s removed", keyword)
+	default:
+		d.breaking(path, "%s changed", keyword)
+	}
+}
+
+// tightensOnly tells whether adding a keyword can only reject more values
+// and removing it only accept more
+func tightensOnly(keyword string) bool {
+	switch keyword {
+	case "const", "pattern", "multipleOf", "not", "allOf", "anyOf", "oneOf",
+		"contains", "dependentRequired", "dependentSchemas", "patternProperties", "prefixItems", "$ref":
+		return true
+	}
+	return false
+}
+
+func (d *differ) types(path string, prev interface{}, inOld bool, next interface{}, inNew bool) {
+	oldSet, newSet := stringSet(prev), stringSet(next)
+	if !inOld {
+		d.tighten(path, "type restricted to %s", strings.Join(sortedSet(newSet), ", "))
+		return
+	}
+	if !inNew {
+		d.loosen(path, "type restriction removed")
+		return
+	}
+	widened := covers(newSet, oldSet)
+	narrowed := covers(oldSet, newSet)
+	switch {
+	case widened:
+		d.loosen(path, "type widened from %s to %s", strings.Join(sortedSet(oldSet), ", "), strings.Join(sortedSet(newSet), ", "))
+	case narrowed:
+		d.tighten(path, "type narrowed from %s to %s", strings.Join(sortedSet(oldSet), ", "), strings.Join(sortedSet(newSet), ", "))
+	default:
+		d.breaking(path, "type changed from %s to %s", strings.Join(sortedSet(oldSet), ", "), strings.Join(sortedSet(newSet), ", "))
+	}
+}
+
+// covers tells whether the types of outer accept every value of inner
+func covers(outer, inner map[string]bool) bool {
+	for name := range inner {
+		if !outer[name] && !(name == "integer" && outer["number"]) {
+			return false
+		}
+	}
+	return true
+}
+
+// properties compares property schemas. Adding a property keeps data the
+// old version accepted valid, and removing one keeps data the new version
+// accepts valid, unless additional properties are forbidden.
+func (d *differ) properties(path string, prev, next map[string]interface{}, oldObj, newObj map[string]interface{}) {
+	oldOpen := oldObj["additionalProperties"] != false
+	newOpen := newObj["additionalProperties"] != false
+	names := map[string]bool{}
+	for name := range prev {
+		names[name] = true
+	}
+	for name := range next {
+		names[name] = true
+	}
+	for _, name := range sortedSet(names) {
+		oldProp, inOld := prev[name]
+		newProp, inNew := next[name]
+		propPath := path + "/" + escape(name)
+		switch {
+		case !inOld:
+			d.changes = append(d.changes, Change{Path: propPath, Message: fmt.Sprintf("property %q added", name), Backward: true, Forward: oldOpen})
+		case !inNew:
+			d.changes = append(d.changes, Change{Path: propPath, Message: fmt.Sprintf("property %q removed", name), Backward: newOpen, Forward: true})
+		default:
+			d.schema(propPath, oldProp, newProp)
+		}
+	}
+}
+
+func (d *differ) enum(path string, prev interface{}, inOld bool, next interface{}, inNew bool) {
+	if !inOld {
+		d.tighten(path, "values restricted to an enum")
+		return
+	}
+	if !inNew {
+		d.loosen(path, "enum removed")
+		return
+	}
+	oldValues, newValues := asArray(prev), asArray(next)
+	for _, value := range newValues {
+		if !contains(oldValues, value) {
+			d.loosen(path, "value %s added", literal(value))
+		}
+	}
+	for _, value := range oldValues {
+		if !contains(newValues, value) {
+			d.tighten(path, "value %s removed", literal(value))
+		}
+	}
+}
+
+func (d *differ) bound(path, keyword string, prev interface{}, inOld bool, next interface{}, inNew bool) {
+	oldNum, oldOK := prev.(float64)
+	newNum, newOK := next.(float64)
+	switch {
+	case !inOld:
+		d.tighten(path, "%s %v added", keyword, next)
+	case !inNew:
+		d.loosen(path, "%s %v removed", keyword, prev)
+	case !oldOK || !newOK:
+		d.breaking(path, "%s changed", keyword)
+	case lowerBounds[keyword] == (newNum > oldNum):
+		d.tighten(path, "%s changed from %v to %v", keyword, oldNum, newNum)
+	default:
+		d.loosen(path, "%s changed from %v to %v", keyword, oldNum, newNum)
+	}
+}
+
+func asObject(v interface{}) map[string]interface{} {
+	obj, _ := v.(map[string]interface{})
+	return obj
+}
+
+func asArray(v interface{}) []interface{} {
+	arr, _ := v.([]interface{})
+	return arr
+}
+
+func contains(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if reflect.DeepEqual(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringSet returns a string or array of strings as a set
+func stringSet(v interface{}) map[string]bool {
+	set := map[string]bool{}
+	if s, ok := v.(string); ok {
+		set[s] = true
+	}
+	for _, item := range asArray(v) {
+		if s, ok := item.(string); ok {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+func sortedSet(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
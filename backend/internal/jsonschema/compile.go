@@ -0,0 +1,482 @@
+// Package jsonschema validates JSON documents against JSON Schema draft
+// 2020-12. Schemas are compiled once and can then validate any number of
+// documents, reporting every violation with the JSON pointer of the value
+// at fault and of the keyword it broke.
+//
+// References are resolved within the schema document, by JSON pointer
+// ("#/$defs/port") or by $anchor ("#port"); remote references are not
+// fetched. Patterns use Go's RE2 syntax, which covers the ECMA 262 syntax
+// schemas commonly use but not lookaround or backreferences. As the draft
+// specifies by default, format is an annotation and is not asserted.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Draft is the $schema URI of the supported draft
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	root *node
+}
+
+// node is a compiled schema or subschema
+type node struct {
+	location string // JSON pointer of the schema within its document
+	always   *bool  // set for the boolean schemas true and false
+
+	ref *node
+
+	types    []string
+	enum     []interface{}
+	constant *interface{}
+
+	multipleOf, maximum, exclusiveMaximum, minimum, exclusiveMinimum *float64
+
+	maxLength, minLength *int
+	pattern              *regexp.Regexp
+
+	maxItems, minItems       *int
+	uniqueItems              bool
+	maxContains, minContains *int
+
+	maxProperties, minProperties *int
+	required                     []string
+	dependentRequired            map[string][]string
+
+	allOf, anyOf, oneOf   []*node
+	not, if_, then, else_ *node
+
+	properties           map[string]*node
+	patternProperties    []patternNode
+	additionalProperties *node
+	propertyNames        *node
+	dependentSchemas     map[string]*node
+
+	prefixItems []*node
+	items       *node
+	contains    *node
+
+	unevaluatedProperties, unevaluatedItems *node
+}
+
+// patternNode applies a schema to properties whose names match a pattern
+type patternNode struct {
+	pattern *regexp.Regexp
+	schema  *node
+}
+
+// compiler compiles the subschemas of one document, each once, so that
+// recursive references resolve to the node being compiled
+type compiler struct {
+	doc     interface{}
+	nodes   map[string]*node
+	anchors map[string]string
+}
+
+// Compile compiles a schema document, which is an object or a boolean as
+// decoded by encoding/json. Malformed keywords and unresolvable references
+// are reported as errors.
+func Compile(doc interface{}) (*Schema, error) {
+	doc, err := normalize(doc)
+	if err != nil {
+		return nil, err
+	}
+	if obj, ok := doc.(map[string]interface{}); ok {
+		if uri, ok := obj["$schema"]; ok && strings.TrimSuffix(fmt.Sprint(uri), "#") != Draft {
+			return nil, fmt.Errorf("unsupported $schema %v: only draft 2020-12 is supported", uri)
+		}
+	}
+
+	c := &compiler{doc: doc, nodes: map[string]*node{}, anchors: map[string]string{}}
+	c.findAnchors(doc, "")
+	root, err := c.compile(doc, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// normalize converts a document to the types encoding/json decodes to, so
+// that Go values such as ints and string slices compare as JSON
+func normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	return out, nil
+}
+
+// findAnchors records the location of every $anchor in the document
+func (c *compiler) findAnchors(v interface{}, location string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if anchor, ok := v["$anchor"].(string); ok {
+			c.anchors[anchor] = location
+		}
+		for key, child := range v {
+			c.findAnchors(child, location+"/"+escape(key))
+		}
+	case []interface{}:
+		for i, child := range v {
+			c.findAnchors(child, location+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+// escape escapes a property name for use in a JSON pointer
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// ParsePointer splits a JSON pointer into its reference tokens. The empty
+// pointer, which names the whole document, has none.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// compile compiles the subschema v found at location
+func (c *compiler) compile(v interface{}, location string) (*node, error) {
+	if n, ok := c.nodes[location]; ok {
+		return n, nil
+	}
+	n := &node{location: location}
+	c.nodes[location] = n
+
+	if b, ok := v.(bool); ok {
+		n.always = &b
+		return n, nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, c.errorf(location, "a schema must be an object or a boolean")
+	}
+
+	// Keywords are compiled in a fixed order so that errors are stable
+	keywords := make([]string, 0, len(obj))
+	for keyword := range obj {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		if err := c.keyword(n, keyword, obj[keyword], location+"/"+escape(keyword)); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// keyword compiles one keyword of a schema object. Unknown keywords are
+// ignored, as the draft requires.
+func (c *compiler) keyword(n *node, keyword string, v interface{}, location string) error {
+	var err error
+	switch keyword {
+	case "$ref":
+		ref, ok := v.(string)
+		if !ok {
+			return c.errorf(location, "$ref must be a string")
+		}
+		n.ref, err = c.resolve(ref, location)
+	case "$dynamicRef", "$recursiveRef":
+		return c.errorf(location, "%s is not supported", keyword)
+
+	case "type":
+		n.types, err = c.typeList(v, location)
+	case "enum":
+		list, ok := v.([]interface{})
+		if !ok {
+			return c.errorf(location, "enum must be an array")
+		}
+		n.enum = list
+	case "const":
+		n.constant = &v
+
+	case "multipleOf":
+		n.multipleOf, err = c.number(v, location)
+		if err == nil && *n.multipleOf <= 0 {
+			err = c.errorf(location, "multipleOf must be greater than 0")
+		}
+	case "maximum":
+		n.maximum, err = c.number(v, location)
+	case "exclusiveMaximum":
+		n.exclusiveMaximum, err = c.number(v, location)
+	case "minimum":
+		n.minimum, err = c.number(v, location)
+	case "exclusiveMinimum":
+		n.exclusiveMinimum, err = c.number(v, location)
+
+	case "maxLength":
+		n.maxLength, err = c.count(v, location)
+	case "minLength":
+		n.minLength, err = c.count(v, location)
+	case "pattern":
+		n.pattern, err = c.regexp(v, location)
+
+	case "maxItems":
+		n.maxItems, err = c.count(v, location)
+	case "minItems":
+		n.minItems, err = c.count(v, location)
+	case "uniqueItems":
+		unique, ok := v.(bool)
+		if !ok {
+			return c.errorf(location, "uniqueItems must be a boolean")
+		}
+		n.uniqueItems = unique
+	case "maxContains":
+		n.maxContains, err = c.count(v, location)
+	case "minContains":
+		n.minContains, err = c.count(v, location)
+
+	case "maxProperties":
+		n.maxProperties, err = c.count(v, location)
+	case "minProperties":
+		n.minProperties, err = c.count(v, location)
+	case "required":
+		n.required, err = c.stringList(v, location)
+	case "dependentRequired":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return c.errorf(location, "dependentRequired must be an object")
+		}
+		n.dependentRequired = map[string][]string{}
+		for name, list := range obj {
+			if n.dependentRequired[name], err = c.stringList(list, location+"/"+escape(name)); err != nil {
+				return err
+			}
+		}
+
+	case "allOf":
+		n.allOf, err = c.schemaList(v, location)
+	case "anyOf":
+		n.anyOf, err = c.schemaList(v, location)
+	case "oneOf":
+		n.oneOf, err = c.schemaList(v, location)
+	case "not":
+		n.not, err = c.compile(v, location)
+	case "if":
+		n.if_, err = c.compile(v, location)
+	case "then":
+		n.then, err = c.compile(v, location)
+	case "else":
+		n.else_, err = c.compile(v, location)
+
+	case "properties":
+		n.properties, err = c.schemaMap(v, location)
+	case "patternProperties":
+		var schemas map[string]*node
+		if schemas, err = c.schemaMap(v, location); err != nil {
+			return err
+		}
+		patterns := make([]string, 0, len(schemas))
+		for pattern := range schemas {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			re, err := c.regexp(pattern, location+"/"+escape(pattern))
+			if err != nil {
+				return err
+			}
+			n.patternProperties = append(n.patternProperties, patternNode{pattern: re, schema: schemas[pattern]})
+		}
+	case "additionalProperties":
+		n.additionalProperties, err = c.compile(v, location)
+	case "propertyNames":
+		n.propertyNames, err = c.compile(v, location)
+	case "dependentSchemas":
+		n.dependentSchemas, err = c.schemaMap(v, location)
+
+	case "prefixItems":
+		n.prefixItems, err = c.schemaList(v, location)
+	case "items":
+		if _, ok := v.([]interface{}); ok {
+			return c.errorf(location, "items must be a schema; use prefixItems for tuples")
+		}
+		n.items, err = c.compile(v, location)
+	case "contains":
+		n.contains, err = c.compile(v, location)
+
+	case "unevaluatedProperties":
+		n.unevaluatedProperties, err = c.compile(v, location)
+	case "unevaluatedItems":
+		n.unevaluatedItems, err = c.compile(v, location)
+
+	case "$defs", "definitions":
+		defs, ok := v.(map[string]interface{})
+		if !ok {
+			return c.errorf(location, "%s must be an object", keyword)
+		}
+		for name, def := range defs {
+			if _, err := c.compile(def, location+"/"+escape(name)); err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// resolve compiles the subschema a reference within the document names
+func (c *compiler) resolve(ref, location string) (*node, error) {
+	fragment, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, c.errorf(location, "cannot resolve remote reference %q", ref)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		target, ok := c.anchors[fragment]
+		if !ok {
+			return nil, c.errorf(location, "unknown anchor %q", ref)
+		}
+		fragment = target
+	}
+
+	tokens, err := ParsePointer(fragment)
+	if err != nil {
+		return nil, c.errorf(location, "cannot resolve reference %q", ref)
+	}
+	v := c.doc
+	for _, token := range tokens {
+		switch container := v.(type) {
+		case map[string]interface{}:
+			v, ok = container[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			ok = err == nil && i >= 0 && i < len(container)
+			if ok {
+				v = container[i]
+			}
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, c.errorf(location, "cannot resolve reference %q", ref)
+		}
+	}
+	return c.compile(v, fragment)
+}
+
+func (c *compiler) errorf(location, format string, args ...interface{}) error {
+	if location == "" {
+		location = "/"
+	}
+	return fmt.Errorf("invalid schema at %s: %s", location, fmt.Sprintf(format, args...))
+}
+
+func (c *compiler) number(v interface{}, location string) (*float64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return nil, c.errorf(location, "must be a number")
+	}
+	return &f, nil
+}
+
+func (c *compiler) count(v interface{}, location string) (*int, error) {
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, c.errorf(location, "must be a non-negative integer")
+	}
+	i := int(f)
+	return &i, nil
+}
+
+func (c *compiler) regexp(v interface{}, location string) (*regexp.Regexp, error) {
+	pattern, ok := v.(string)
+	if !ok {
+		return nil, c.errorf(location, "must be a string")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, c.errorf(location, "invalid pattern: %v", err)
+	}
+	return re, nil
+}
+
+func (c *compiler) stringList(v interface{}, location string) ([]string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, c.errorf(location, "must be an array of strings")
+	}
+	strs := make([]string, len(list))
+	for i, item := range list {
+		if strs[i], ok = item.(string); !ok {
+			return nil, c.errorf(location, "must be an array of strings")
+		}
+	}
+	return strs, nil
+}
+
+// jsonTypes are the type names a schema can require
+var jsonTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "string": true, "integer": true,
+}
+
+func (c *compiler) typeList(v interface{}, location string) ([]string, error) {
+	types := []string{}
+	if name, ok := v.(string); ok {
+		types = append(types, name)
+	} else if list, err := c.stringList(v, location); err == nil {
+		types = list
+	} else {
+		return nil, c.errorf(location, "type must be a string or an array of strings")
+	}
+	for _, name := range types {
+		if !jsonTypes[name] {
+			return nil, c.errorf(location, "unknown type %q", name)
+		}
+	}
+	return types, nil
+}
+
+func (c *compiler) schemaList(v interface{}, location string) ([]*node, error) {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, c.errorf(location, "must be a non-empty array of schemas")
+	}
+	nodes := make([]*node, len(list))
+	for i, item := range list {
+		n, err := c.compile(item, location+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = n
+	}
+	return nodes, nil
+}
+
+func (c *compiler) schemaMap(v interface{}, location string) (map[string]*node, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, c.errorf(location, "must be an object of schemas")
+	}
+	nodes := make(map[string]*node, len(obj))
+	for name, item := range obj {
+		n, err := c.compile(item, location+"/"+escape(name))
+		if err != nil {
+			return nil, err
+		}
+		nodes[name] = n
+	}
+	return nodes, nil
+}
@@ -0,0 +1,237 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func mustCompile(t *testing.T, doc string) *Schema {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("invalid test schema: %v", err)
+	}
+	s, err := Compile(v)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return s
+}
+
+func TestValidate(t *testing.T) {
+	s := mustCompile(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["name", "size"],
+		"properties": {
+			"name": {"type": "string", "minLength": 3, "pattern": "^[a-z-]+$"},
+			"size": {"enum": ["small", "large"]},
+			"cpu": {"type": "integer", "minimum": 1, "multipleOf": 2},
+			"ports": {"type": "array", "items": {"$ref": "#/$defs/port"}, "uniqueItems": true},
+			"tags": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"additionalProperties": false,
+		"$defs": {"port": {"type": "integer", "exclusiveMaximum": 65536}}
+	}`)
+
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{name: "valid", doc: `{"name": "web", "size": "small", "cpu": 4, "ports": [80, 443], "tags": {"env": "prod"}}`},
+		{name: "missing required", doc: `{"name": "web"}`, want: []string{`/: missing required property "size"`}},
+		{name: "wrong type", doc: `[]`, want: []string{"/: must be object, not array"}},
+		{
+			name: "nested violations",
+			doc:  `{"name": "W", "size": "huge", "cpu": 3.5, "ports": [80, 80, 70000], "tags": {"env": 1}, "extra": true}`,
+			want: []string{
+				`/cpu: must be integer, not number`,
+				`/extra: property "extra" is not allowed`,
+				`/name: must be at least 3 characters long`,
+				`/name: must match the pattern "^[a-z-]+$"`,
+				`/ports: items 0 and 1 are equal`,
+				`/ports/2: must be less than 65536`,
+				`/size: must be one of "small", "large"`,
+				`/tags/env: must be string, not number`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, err := range s.Validate(doc) {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidate_Keywords(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		valid   []string
+		invalid []string
+	}{
+		{
+			name:    "oneOf",
+			schema:  `{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`,
+			valid:   []string{`1`, `2.5`},
+			invalid: []string{`3`},
+		},
+		{
+			name:    "if then else",
+			schema:  `{"if": {"properties": {"kind": {"const": "disk"}}}, "then": {"required": ["gb"]}, "else": {"not": {"required": ["gb"]}}}`,
+			valid:   []string{`{"kind": "disk", "gb": 10}`, `{"kind": "nic"}`},
+			invalid: []string{`{"kind": "disk"}`, `{"kind": "nic", "gb": 1}`},
+		},
+		{
+			name:    "unevaluatedProperties",
+			schema:  `{"allOf": [{"properties": {"a": true}}], "anyOf": [{"properties": {"b": true}}], "unevaluatedProperties": false}`,
+			valid:   []string{`{"a": 1, "b": 2}`},
+			invalid: []string{`{"a": 1, "c": 3}`},
+		},
+		{
+			name:    "prefixItems and unevaluatedItems",
+			schema:  `{"prefixItems": [{"type": "string"}], "contains": {"type": "number"}, "unevaluatedItems": false}`,
+			valid:   []string{`["a", 1, 2]`},
+			invalid: []string{`["a", 1, true]`, `[1]`},
+		},
+		{
+			name:    "contains bounds",
+			schema:  `{"contains": {"const": 1}, "minContains": 2, "maxContains": 3}`,
+			valid:   []string{`[1, 1, 2]`},
+			invalid: []string{`[1, 2]`, `[1, 1, 1, 1]`},
+		},
+		{
+			name:    "recursive reference",
+			schema:  `{"$anchor": "tree", "type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#tree"}}}, "required": ["id"]}`,
+			valid:   []string{`{"id": 1, "children": [{"id": 2, "children": []}]}`},
+			invalid: []string{`{"id": 1, "children": [{"children": []}]}`},
+		},
+		{
+			name:    "dependencies and names",
+			schema:  `{"dependentRequired": {"card": ["billing"]}, "dependentSchemas": {"vpn": {"required": ["psk"]}}, "propertyNames": {"maxLength": 7}}`,
+			valid:   []string{`{"card": 1, "billing": 2}`, `{"vpn": 1, "psk": 2}`},
+			invalid: []string{`{"card": 1}`, `{"vpn": 1}`, `{"too-long": 1}`},
+		},
+		{
+			name:    "unicode length",
+			schema:  `{"maxLength": 2}`,
+			valid:   []string{`"éé"`},
+			invalid: []string{`"abc"`},
+		},
+		{
+			name:    "false schema",
+			schema:  `false`,
+			invalid: []string{`null`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustCompile(t, tt.schema)
+			for _, doc := range tt.valid {
+				var v interface{}
+				_ = json.Unmarshal([]byte(doc), &v)
+				if errs := s.Validate(v); len(errs) > 0 {
+					t.Errorf("Validate(%s) = %v, want valid", doc, errs)
+				}
+			}
+			for _, doc := range tt.invalid {
+				var v interface{}
+				_ = json.Unmarshal([]byte(doc), &v)
+				if errs := s.Validate(v); len(errs) == 0 {
+					t.Errorf("Validate(%s) is valid, want violations", doc)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_GoValues(t *testing.T) {
+	s := mustCompile(t, `{"properties": {"count": {"type": "integer"}, "zones": {"items": {"type": "string"}}}}`)
+	doc := map[string]interface{}{"count": 3, "zones": []string{"a", "b"}}
+	if errs := s.Validate(doc); len(errs) > 0 {
+		t.Errorf("Validate() = %v, want valid", errs)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{schema: `{"type": "text"}`, want: `invalid schema at /type: unknown type "text"`},
+		{schema: `{"minLength": -1}`, want: "invalid schema at /minLength: must be a non-negative integer"},
+		{schema: `{"pattern": "("}`, want: "invalid schema at /pattern: invalid pattern"},
+		{schema: `{"properties": {"a": {"$ref": "#/$defs/missing"}}}`, want: `invalid schema at /properties/a/$ref: cannot resolve reference "#/$defs/missing"`},
+		{schema: `{"$ref": "https://example.com/schema.json"}`, want: "cannot resolve remote reference"},
+		{schema: `{"items": [{"type": "string"}]}`, want: "use prefixItems for tuples"},
+		{schema: `{"$schema": "http://json-schema.org/draft-07/schema#"}`, want: "only draft 2020-12 is supported"},
+		{schema: `{"allOf": []}`, want: "must be a non-empty array of schemas"},
+		{schema: `"string"`, want: "a schema must be an object or a boolean"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &v); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Compile(v)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := `{"type": "object", "required": ["size"], "properties": {"size": {"enum": ["small", "large"]}, "gb": {"type": "integer", "maximum": 100}}}`
+
+	tests := []struct {
+		name    string
+		next    string
+		want    Compatibility
+		changes int
+	}{
+		{name: "annotations only", next: `{"type": "object", "required": ["size"], "description": "disk", "properties": {"size": {"enum": ["small", "large"]}, "gb": {"type": "integer", "maximum": 100, "title": "GB"}}}`, want: CompatibilityFull},
+		{name: "optional property added", next: `{"type": "object", "required": ["size"], "properties": {"size": {"enum": ["small", "large"]}, "gb": {"type": "integer", "maximum": 100}, "tier": {"type": "string"}}}`, want: CompatibilityFull, changes: 1},
+		{name: "loosened", next: `{"type": "object", "properties": {"size": {"enum": ["small", "large", "huge"]}, "gb": {"type": "number", "maximum": 200}}}`, want: CompatibilityBackward, changes: 4},
+		{name: "tightened", next: `{"type": "object", "required": ["size", "gb"], "properties": {"size": {"enum": ["small"]}, "gb": {"type": "integer", "maximum": 100, "minimum": 1}}}`, want: CompatibilityForward, changes: 3},
+		{name: "closed and renamed", next: `{"type": "object", "required": ["size"], "additionalProperties": false, "properties": {"size": {"enum": ["small", "large"]}, "size_gb": {"type": "integer"}}}`, want: CompatibilityForward, changes: 3},
+		{name: "type changed", next: `{"type": "object", "required": ["size"], "properties": {"size": {"enum": ["small", "large"]}, "gb": {"type": "string"}}}`, want: CompatibilityBreaking, changes: 2},
+		{name: "conditional added", next: `{"type": "object", "required": ["size"], "if": {"required": ["gb"]}, "then": {"required": ["tier"]}, "properties": {"size": {"enum": ["small", "large"]}, "gb": {"type": "integer", "maximum": 100}}}`, want: CompatibilityBreaking, changes: 2},
+	}
+
+	var prev interface{}
+	if err := json.Unmarshal([]byte(base), &prev); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var next interface{}
+			if err := json.Unmarshal([]byte(tt.next), &next); err != nil {
+				t.Fatal(err)
+			}
+			got, changes, err := Compare(prev, next)
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if got != tt.want || len(changes) != tt.changes {
+				t.Errorf("Compare() = %s with %+v, want %s with %d changes", got, changes, tt.want, tt.changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error is a violation of a schema
+type Error struct {
+	// InstancePath is the JSON pointer of the value at fault
+	InstancePath string `json:"path"`
+	// KeywordPath is the JSON pointer of the keyword within the schema
+	KeywordPath string `json:"keyword"`
+	Message     string `json:"message"`
+}
+
+// Error formats the violation with the path of the value at fault
+func (e Error) Error() string {
+	path := e.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// Validate validates a document against the schema, returning every
+// violation found, or nil when the document is valid
+func (s *Schema) Validate(doc interface{}) []Error {
+	doc, err := normalize(doc)
+	if err != nil {
+		return []Error{{Message: err.Error()}}
+	}
+	var errs []Error
+	s.root.validate(doc, "", &errs)
+	return errs
+}
+
+// evaluated records the properties and items a schema evaluated
+// successfully, which unevaluatedProperties and unevaluatedItems skip
+type evaluated struct {
+	props    map[string]bool
+	items    map[int]bool
+	allItems bool
+}
+
+func (e *evaluated) merge(other evaluated) {
+	for name := range other.props {
+		e.prop(name)
+	}
+	for i := range other.items {
+		e.item(i)
+	}
+	e.allItems = e.allItems || other.allItems
+}
+
+func (e *evaluated) prop(name string) {
+	if e.props == nil {
+		e.props = map[string]bool{}
+	}
+	e.props[name] = true
+}
+
+func (e *evaluated) item(i int) {
+	if e.items == nil {
+		e.items = map[int]bool{}
+	}
+	e.items[i] = true
+}
+
+// fail records a violation of one of the node's keywords
+func (n *node) fail(errs *[]Error, path, keyword, format string, args ...interface{}) {
+	*errs = append(*errs, Error{
+		InstancePath: path,
+		KeywordPath:  n.location + "/" + keyword,
+		Message:      fmt.Sprintf(format, args...),
+	})
+}
+
+// try validates v without recording violations, reporting whether it is
+// valid and what it evaluated
+func (n *node) try(v interface{}, path string) (bool, evaluated) {
+	var errs []Error
+	ev := n.validate(v, path, &errs)
+	return len(errs) == 0, ev
+}
+
+// validate validates the value v found at path, recording violations in
+// errs and returning what it evaluated
+func (n *node) validate(v interface{}, path string, errs *[]Error) evaluated {
+	var ev evaluated
+	if n.always != nil {
+		if !*n.always {
+			*errs = append(*errs, Error{InstancePath: path, KeywordPath: n.location, Message: "no value is allowed here"})
+		}
+		return ev
+	}
+
+	if n.ref != nil {
+		ev.merge(n.ref.validate(v, path, errs))
+	}
+	if !n.validateType(v, path, errs) {
+		// The remaining keywords would only restate the mismatch
+		return ev
+	}
+	n.validateValue(v, path, errs)
+	n.applyInPlace(v, path, errs, &ev)
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		n.validateObject(v, path, errs, &ev)
+	case []interface{}:
+		n.validateArray(v, path, errs, &ev)
+	case string:
+		n.validateString(v, path, errs)
+	case float64:
+		n.validateNumber(v, path, errs)
+	}
+	return ev
+}
+
+// typeOf returns the JSON type of a normalized value
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+func (n *node) validateType(v interface{}, path string, errs *[]Error) bool {
+	if n.types == nil {
+		return true
+	}
+	actual := typeOf(v)
+	for _, want := range n.types {
+		if want == actual || (want == "integer" && actual == "number" && v.(float64) == math.Trunc(v.(float64))) {
+			return true
+		}
+	}
+	n.fail(errs, path, "type", "must be %s, not %s", strings.Join(n.types, " or "), actual)
+	return false
+}
+
+func (n *node) validateValue(v interface{}, path string, errs *[]Error) {
+	if n.constant != nil && !reflect.DeepEqual(v, *n.constant) {
+		n.fail(errs, path, "const", "must be %s", literal(*n.constant))
+	}
+	if n.enum != nil {
+		for _, allowed := range n.enum {
+			if reflect.DeepEqual(v, allowed) {
+				return
+			}
+		}
+		allowed := make([]string, len(n.enum))
+		for i, value := range n.enum {
+			allowed[i] = literal(value)
+		}
+		n.fail(errs, path, "enum", "must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// literal formats a value as JSON for messages
+func literal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// applyInPlace applies the subschemas that validate the value itself
+func (n *node) applyInPlace(v interface{}, path string, errs *[]Error, ev *evaluated) {
+	for _, sub := range n.allOf {
+		ev.merge(sub.validate(v, path, errs))
+	}
+
+	if n.anyOf != nil {
+		matched := false
+		for _, sub := range n.anyOf {
+			if ok, subEv := sub.try(v, path); ok {
+				matched = true
+				ev.merge(subEv)
+			}
+		}
+		if !matched {
+			n.fail(errs, path, "anyOf", "must match at least one of the allowed schemas")
+		}
+	}
+
+	if n.oneOf != nil {
+		var matches []int
+		var matchedEv evaluated
+		for i, sub := range n.oneOf {
+			if ok, subEv := sub.try(v, path); ok {
+				matches = append(matches, i)
+				matchedEv = subEv
+			}
+		}
+		switch len(matches) {
+		case 0:
+			n.fail(errs, path, "oneOf", "must match exactly one of the allowed schemas, matches none")
+		case 1:
+			ev.merge(matchedEv)
+		default:
+			n.fail(errs, path, "oneOf", "must match exactly one of the allowed schemas, matches %d", len(matches))
+		}
+	}
+
+	if n.not != nil {
+		if ok, _ := n.not.try(v, path); ok {
+			n.fail(errs, path, "not", "must not match the schema")
+		}
+	}
+
+	if n.if_ != nil {
+		if ok, subEv := n.if_.try(v, path); ok {
+			ev.merge(subEv)
+			if n.then != nil {
+				ev.merge(n.then.validate(v, path, errs))
+			}
+		} else if n.else_ != nil {
+			ev.merge(n.else_.validate(v, path, errs))
+		}
+	}
+}
+
+func (n *node) validateObject(obj map[string]interface{}, path string, errs *[]Error, ev *evaluated) {
+	if n.maxProperties != nil && len(obj) > *n.maxProperties {
+		n.fail(errs, path, "maxProperties", "must have at most %d properties", *n.maxProperties)
+	}
+	if n.minProperties != nil && len(obj) < *n.minProperties {
+		n.fail(errs, path, "minProperties", "must have at least %d properties", *n.minProperties)
+	}
+	for _, name := range n.required {
+		if _, ok := obj[name]; !ok {
+			n.fail(errs, path, "required", "missing required property %q", name)
+		}
+	}
+
+	names := sortedKeys(obj)
+	for _, name := range names {
+		for _, dependent := range n.dependentRequired[name] {
+			if _, ok := obj[dependent]; !ok {
+				n.fail(errs, path, "dependentRequired/"+escape(name), "property %q is required when %q is present", dependent, name)
+			}
+		}
+		if sub, ok := n.dependentSchemas[name]; ok {
+			ev.merge(sub.validate(obj, path, errs))
+		}
+		if n.propertyNames != nil {
+			var nameErrs []Error
+			n.propertyNames.validate(name, path+"/"+escape(name), &nameErrs)
+			if len(nameErrs) > 0 {
+				n.fail(errs, path, "propertyNames", "property name %q is not allowed: %s", name, nameErrs[0].Message)
+			}
+		}
+	}
+
+	for _, name := range names {
+		childPath := path + "/" + escape(name)
+		matched := false
+		if sub, ok := n.properties[name]; ok {
+			matched = true
+			sub.validate(obj[name], childPath, errs)
+		}
+		for _, pp := range n.patternProperties {
+			if pp.pattern.MatchString(name) {
+				matched = true
+				pp.schema.validate(obj[name], childPath, errs)
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			matched = true
+			n.validateExtra(n.additionalProperties, "additionalProperties", name, obj[name], childPath, errs)
+		}
+		if matched {
+			ev.prop(name)
+		}
+	}
+
+	if n.unevaluatedProperties != nil {
+		for _, name := range names {
+			if !ev.props[name] {
+				n.validateExtra(n.unevaluatedProperties, "unevaluatedProperties", name, obj[name], path+"/"+escape(name), errs)
+				ev.prop(name)
+			}
+		}
+	}
+}
+
+// validateExtra validates a property no other keyword claimed, naming it
+// outright when the schema is false
+func (n *node) validateExtra(sub *node, keyword, name string, v interface{}, path string, errs *[]Error) {
+	if sub.always != nil && !*sub.always {
+		n.fail(errs, path, keyword, "property %q is not allowed", name)
+		return
+	}
+	sub.validate(v, path, errs)
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	// Sorted so that violations are reported in a stable order
+	sort.Strings(names)
+	return names
+}
+
+func (n *node) validateArray(arr []interface{}, path string, errs *[]Error, ev *evaluated) {
+	if n.maxItems != nil && len(arr) > *n.maxItems {
+		n.fail(errs, path, "maxItems", "must have at most %d items", *n.maxItems)
+	}
+	if n.minItems != nil && len(arr) < *n.minItems {
+		n.fail(errs, path, "minItems", "must have at least %d items", *n.minItems)
+	}
+	if n.uniqueItems {
+	unique:
+		for i := 1; i < len(arr); i++ {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(arr[i], arr[j]) {
+					n.fail(errs, path, "uniqueItems", "items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+
+	for i, item := range arr {
+		itemPath := path + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(n.prefixItems):
+			n.prefixItems[i].validate(item, itemPath, errs)
+			ev.item(i)
+		case n.items != nil:
+			n.items.validate(item, itemPath, errs)
+			ev.allItems = true
+		}
+	}
+
+	if n.contains != nil {
+		matches := 0
+		for i, item := range arr {
+			if ok, _ := n.contains.try(item, path+"/"+strconv.Itoa(i)); ok {
+				matches++
+				ev.item(i)
+			}
+		}
+		minContains := 1
+		if n.minContains != nil {
+			minContains = *n.minContains
+		}
+		switch {
+		case matches < minContains && n.minContains == nil:
+			n.fail(errs, path, "contains", "must contain an item matching the schema")
+		case matches < minContains:
+			n.fail(errs, path, "minContains", "must contain at least %d items matching the schema, has %d", minContains, matches)
+		case n.maxContains != nil && matches > *n.maxContains:
+			n.fail(errs, path, "maxContains", "must contain at most %d items matching the schema, has %d", *n.maxContains, matches)
+		}
+	}
+
+	if n.unevaluatedItems != nil && !ev.allItems {
+		for i, item := range arr {
+			if !ev.items[i] {
+				if n.unevaluatedItems.always != nil && !*n.unevaluatedItems.always {
+					n.fail(errs, path+"/"+strconv.Itoa(i), "unevaluatedItems", "item %d is not allowed", i)
+					continue
+				}
+				n.unevaluatedItems.validate(item, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+		ev.allItems = true
+	}
+}
+
+func (n *node) validateString(s, path string, errs *[]Error) {
+	length := utf8.RuneCountInString(s)
+	if n.maxLength != nil && length > *n.maxLength {
+		n.fail(errs, path, "maxLength", "must be at most %d characters long", *n.maxLength)
+	}
+	if n.minLength != nil && length < *n.minLength {
+		n.fail(errs, path, "minLength", "must be at least %d characters long", *n.minLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		n.fail(errs, path, "pattern", "must match the pattern %q", n.pattern.String())
+	}
+}
+
+func (n *node) validateNumber(f float64, path string, errs *[]Error) {
+	if n.multipleOf != nil {
+		q := f / *n.multipleOf
+		if math.IsInf(q, 0) || math.Abs(q-math.Round(q)) > 1e-9 {
+			n.fail(errs, path, "multipleOf", "must be a multiple of %v", *n.multipleOf)
+		}
+	}
+	if n.maximum != nil && f > *n.maximum {
+		n.fail(errs, path, "maximum", "must be at most %v", *n.maximum)
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		n.fail(errs, path, "exclusiveMaximum", "must be less than %v", *n.exclusiveMaximum)
+	}
+	if n.minimum != nil && f < *n.minimum {
+		n.fail(errs, path, "minimum", "must be at least %v", *n.minimum)
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		n.fail(errs, path, "exclusiveMinimum", "must be greater than %v", *n.exclusiveMinimum)
+	}
+}
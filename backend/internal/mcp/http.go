@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Headers of the Streamable HTTP transport
+const (
+	SessionHeader  = "Mcp-Session-Id"
+	ProtocolHeader = "Mcp-Protocol-Version"
+)
+
+const (
+	// maxMessageSize bounds the messages read from clients
+	maxMessageSize = 4 << 20
+	// keepAliveInterval is how often an idle event stream gets a comment
+	keepAliveInterval = 25 * time.Second
+)
+
+// ServeHTTP serves the Streamable HTTP transport on a single endpoint.
+// POST carries client messages, answered as JSON or, for clients that only
+// accept it, an event stream; GET opens an event stream for the session's
+// notifications; DELETE ends the session. The session is created by the
+// initialize request and named by the Mcp-Session-Id header afterwards.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		writeHTTPError(w, http.StatusForbidden, CodeInvalidRequest, "origin not allowed")
+		return
+	}
+	if version := r.Header.Get(ProtocolHeader); version != "" && !slices.Contains(SupportedProtocolVersions, version) {
+		writeHTTPError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("unsupported protocol version %q", version))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.servePost(w, r)
+	case http.MethodGet:
+		s.serveEvents(w, r)
+	case http.MethodDelete:
+		id := r.Header.Get(SessionHeader)
+		if id == "" {
+			writeHTTPError(w, http.StatusBadRequest, CodeInvalidRequest, "missing "+SessionHeader+" header")
+			return
+		}
+		if !s.removeSession(id) {
+			writeHTTPError(w, http.StatusNotFound, CodeInvalidRequest, "session not found")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeHTTPError(w, http.StatusMethodNotAllowed, CodeInvalidRequest, "method not allowed")
+	}
+}
+
+func (s *Server) servePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		writeHTTPError(w, http.StatusRequestEntityTooLarge, CodeInvalidRequest, "message too large")
+		return
+	}
+	if !json.Valid(body) {
+		writeHTTPError(w, http.StatusBadRequest, CodeParseError, "invalid JSON")
+		return
+	}
+
+	var session *Session
+	isNew := false
+	if id := r.Header.Get(SessionHeader); id != "" {
+		if session = s.session(id); session == nil {
+			writeHTTPError(w, http.StatusNotFound, CodeInvalidRequest, "session not found")
+			return
+		}
+	} else {
+		var msg Message
+		if json.Unmarshal(body, &msg) != nil || msg.Method != "initialize" {
+			writeHTTPError(w, http.StatusBadRequest, CodeInvalidRequest, "missing "+SessionHeader+" header")
+			return
+		}
+		if session, err = s.newSession(); err != nil {
+			writeHTTPError(w, http.StatusInternalServerError, CodeInternalError, "failed to create session")
+			return
+		}
+		isNew = true
+	}
+
+	resp := s.Handle(r.Context(), session, body)
+	if isNew {
+		session.mu.Lock()
+		initialized := session.initialized
+		session.mu.Unlock()
+		if initialized {
+			s.addSession(session)
+			w.Header().Set(SessionHeader, session.ID)
+		}
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if accepts(r, "text/event-stream") && !accepts(r, "application/json") {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		_ = writeEvent(w, resp)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp)
+}
+
+// serveEvents streams the session's notifications until the client goes
+// away or the session ends
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if !accepts(r, "text/event-stream") {
+		w.Header().Set("Allow", "POST, DELETE")
+		writeHTTPError(w, http.StatusMethodNotAllowed, CodeInvalidRequest, "GET requires Accept: text/event-stream")
+		return
+	}
+	session := s.session(r.Header.Get(SessionHeader))
+	if session == nil {
+		writeHTTPError(w, http.StatusNotFound, CodeInvalidRequest, "session not found")
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	controller := http.NewResponseController(w)
+	_ = controller.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case msg := <-session.outbound:
+			if writeEvent(w, msg) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			_ = controller.Flush()
+		case <-session.Done():
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes a message as a server-sent event and flushes it
+func writeEvent(w http.ResponseWriter, data []byte) error {
+	if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// accepts reports whether the request's Accept header allows a media type
+func accepts(r *http.Request, mediaType string) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for _, accepted := range strings.Split(value, ",") {
+			accepted, _, _ = strings.Cut(accepted, ";")
+			if accepted = strings.TrimSpace(accepted); accepted == mediaType || accepted == "*/*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin guards against DNS rebinding: browsers may only reach the
+// endpoint from pages of the same host
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// writeHTTPError writes a JSON-RPC error without an ID
+func writeHTTPError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(encode(errorResponse(nil, errorf(code, "%s", message))))
+}
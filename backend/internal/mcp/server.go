@@ -0,0 +1,406 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/services"
+)
+
+// SupportedProtocolVersions are the MCP revisions the server speaks,
+// newest first. A client asking for another is offered the newest.
+var SupportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// sessionIdleTimeout is how long an HTTP session is kept without requests
+const sessionIdleTimeout = time.Hour
+
+// Server dispatches MCP requests to an MCPService. It keeps the sessions
+// of the HTTP transport, stdio serving a single session of its own, and the
+// resource subscriptions of both.
+type Server struct {
+	service      services.MCPService
+	logger       *log.Logger
+	pollInterval time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	subs     subscriptions
+}
+
+// NewServer creates a new MCP server
+func NewServer(service services.MCPService, logger *log.Logger) *Server {
+	return &Server{
+		service:      service,
+		logger:       logger,
+		pollInterval: defaultPollInterval,
+		sessions:     map[string]*Session{},
+	}
+}
+
+// Handle processes a message or batch of messages from a session and
+// returns the encoded response, or nil when nothing needs answering
+func (s *Server) Handle(ctx context.Context, session *Session, data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return encode(s.handleMessage(ctx, session, data, false))
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return encode(errorResponse(nil, errorf(CodeParseError, "invalid JSON: %v", err)))
+	}
+	if len(batch) == 0 {
+		return encode(errorResponse(nil, errorf(CodeInvalidRequest, "empty batch")))
+	}
+	var responses []*response
+	for _, raw := range batch {
+		if resp := s.handleMessage(ctx, session, raw, true); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return encode(responses)
+}
+
+// handleMessage processes one message, returning nil for notifications,
+// responses and cancelled requests
+func (s *Server) handleMessage(ctx context.Context, session *Session, raw json.RawMessage, inBatch bool) *response {
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || len(bytes.TrimSpace(raw)) == 0 {
+			return errorResponse(nil, errorf(CodeParseError, "invalid JSON: %v", err))
+		}
+		return errorResponse(nil, errorf(CodeInvalidRequest, "invalid message: %v", err))
+	}
+
+	id := msg.ID
+	if len(id) > 0 && !validID(id) {
+		return errorResponse(nil, errorf(CodeInvalidRequest, "id must be a string or a number"))
+	}
+	switch {
+	case msg.JSONRPC != jsonrpcVersion:
+		return errorResponse(id, errorf(CodeInvalidRequest, `jsonrpc must be "2.0"`))
+	case msg.Method == "" && len(id) > 0 && (msg.Result != nil || msg.Error != nil):
+		// A response to a server request; none are sent yet
+		return nil
+	case msg.Method == "":
+		return errorResponse(id, errorf(CodeInvalidRequest, "method is required"))
+	case msg.IsNotification():
+		s.notify(session, &msg)
+		return nil
+	case inBatch && msg.Method == "initialize":
+		return errorResponse(id, errorf(CodeInvalidRequest, "initialize must not be part of a batch"))
+	}
+
+	callCtx, done := session.track(ctx, id)
+	defer done()
+	result, rpcErr := s.call(callCtx, session, &msg)
+	if callCtx.Err() != nil && ctx.Err() == nil {
+		// Cancelled by the client, which no longer expects a response
+		return nil
+	}
+	if rpcErr != nil {
+		return errorResponse(id, rpcErr)
+	}
+	if result == nil {
+		result = struct{}{}
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		s.logger.Printf("Failed to encode MCP %s result: %v", msg.Method, err)
+		return errorResponse(id, errorf(CodeInternalError, "failed to encode result"))
+	}
+	return &response{JSONRPC: jsonrpcVersion, ID: id, Result: json.RawMessage(encoded)}
+}
+
+// call runs a request. Everything but initialize and ping needs an
+// initialized session.
+func (s *Server) call(ctx context.Context, session *Session, msg *Message) (interface{}, *Error) {
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(ctx, session, msg.Params)
+	case "ping":
+		return struct{}{}, nil
+	}
+	session.mu.Lock()
+	initialized := session.initialized
+	session.mu.Unlock()
+	if !initialized {
+		return nil, errorf(CodeInvalidRequest, "session is not initialized")
+	}
+
+	switch msg.Method {
+	case "tools/list":
+		tools, err := s.service.ListTools(ctx)
+		if err != nil {
+			return nil, s.internalError(msg.Method, err)
+		}
+		return map[string]interface{}{"tools": nonNil(tools)}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.Name == "" {
+			return nil, errorf(CodeInvalidParams, "name is required")
+		}
+		result, err := s.service.CallTool(services.WithMCPActor(ctx, session.Actor()), params.Name, params.Arguments)
+		if err != nil {
+			return nil, s.internalError(msg.Method, err)
+		}
+		return result, nil
+
+	case "resources/list":
+		var params struct {
+			Cursor string `json:"cursor"`
+		}
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		resources, next, err := s.service.ListResources(ctx, params.Cursor)
+		if errors.Is(err, models.ErrInvalidCursor) {
+			return nil, errorf(CodeInvalidParams, "invalid cursor")
+		}
+		if err != nil {
+			return nil, s.internalError(msg.Method, err)
+		}
+		result := map[string]interface{}{"resources": nonNil(resources)}
+		if next != "" {
+			result["nextCursor"] = next
+		}
+		return result, nil
+
+	case "resources/templates/list":
+		templates, err := s.service.ListResourceTemplates(ctx)
+		if err != nil {
+			return nil, s.internalError(msg.Method, err)
+		}
+		return map[string]interface{}{"resourceTemplates": nonNil(templates)}, nil
+
+	case "resources/read", "resources/subscribe", "resources/unsubscribe":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.URI == "" {
+			return nil, errorf(CodeInvalidParams, "uri is required")
+		}
+		switch msg.Method {
+		case "resources/subscribe":
+			if err := s.subscribe(ctx, session, params.URI); err != nil {
+				return nil, err
+			}
+			return struct{}{}, nil
+		case "resources/unsubscribe":
+			s.unsubscribe(session, params.URI)
+			return struct{}{}, nil
+		}
+		content, err := s.service.ReadResource(ctx, params.URI)
+		if err != nil {
+			return nil, s.resourceError(msg.Method, params.URI, err)
+		}
+		return map[string]interface{}{"contents": []interface{}{content}}, nil
+
+	case "prompts/list":
+		prompts, err := s.service.ListPrompts(ctx)
+		if err != nil {
+			return nil, s.internalError(msg.Method, err)
+		}
+		return map[string]interface{}{"prompts": nonNil(prompts)}, nil
+
+	case "prompts/get":
+		var params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.Name == "" {
+			return nil, errorf(CodeInvalidParams, "name is required")
+		}
+		result, err := s.service.GetPrompt(ctx, params.Name, params.Arguments)
+		if errors.Is(err, services.ErrMCPInvalidPrompt) {
+			return nil, errorf(CodeInvalidParams, "%v", err)
+		}
+		if err != nil {
+			return nil, s.internalError(msg.Method, err)
+		}
+		return result, nil
+	}
+	return nil, errorf(CodeMethodNotFound, "method not found: %s", msg.Method)
+}
+
+// initialize negotiates the protocol version and records the client's
+// capabilities. The server's capabilities and information come from the
+// service.
+func (s *Server) initialize(ctx context.Context, session *Session, raw json.RawMessage) (interface{}, *Error) {
+	var params struct {
+		ProtocolVersion string                 `json:"protocolVersion"`
+		Capabilities    map[string]interface{} `json:"capabilities"`
+		ClientInfo      map[string]interface{} `json:"clientInfo"`
+	}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.ProtocolVersion == "" {
+		return nil, errorf(CodeInvalidParams, "protocolVersion is required")
+	}
+	var req services.MCPInitRequest
+	if err := decodeParams(raw, &req); err != nil {
+		return nil, err
+	}
+
+	session.mu.Lock()
+	if session.initialized {
+		session.mu.Unlock()
+		return nil, errorf(CodeInvalidRequest, "session is already initialized")
+	}
+	session.initialized = true
+	session.mu.Unlock()
+
+	info, err := s.service.Initialize(ctx, req)
+	if err != nil {
+		session.mu.Lock()
+		session.initialized = false
+		session.mu.Unlock()
+		return nil, s.internalError("initialize", err)
+	}
+
+	version := params.ProtocolVersion
+	if !slices.Contains(SupportedProtocolVersions, version) {
+		version = SupportedProtocolVersions[0]
+	}
+	session.mu.Lock()
+	session.protocolVersion = version
+	session.clientInfo = params.ClientInfo
+	session.clientCapabilities = params.Capabilities
+	session.mu.Unlock()
+
+	result := map[string]interface{}{}
+	for key, value := range *info {
+		result[key] = value
+	}
+	result["protocolVersion"] = version
+	return result, nil
+}
+
+// notify handles a notification from the client. Others, including
+// notifications/initialized, need no action and are ignored.
+func (s *Server) notify(session *Session, msg *Message) {
+	switch msg.Method {
+	case "notifications/cancelled":
+		var params struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err == nil && validID(params.RequestID) {
+			session.cancel(params.RequestID)
+		}
+	}
+}
+
+func (s *Server) internalError(method string, err error) *Error {
+	s.logger.Printf("MCP %s failed: %v", method, err)
+	return errorf(CodeInternalError, "%s failed: %v", method, err)
+}
+
+// resourceError reports a failure to read a resource, telling URIs that
+// name no resource apart
+func (s *Server) resourceError(method, uri string, err error) *Error {
+	if errors.Is(err, services.ErrMCPResourceNotFound) {
+		return &Error{Code: CodeResourceNotFound, Message: "resource not found", Data: map[string]interface{}{"uri": uri}}
+	}
+	return s.internalError(method, err)
+}
+
+// newSession creates an HTTP session with a random ID, which is only kept
+// once initialized. Sessions idle for too long are closed first.
+func (s *Server) newSession() (*Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, session := range s.sessions {
+		if time.Since(session.idleSince()) > sessionIdleTimeout {
+			session.Close()
+			s.dropSubscriptions(session)
+			delete(s.sessions, key)
+		}
+	}
+	return newSession(hex.EncodeToString(id)), nil
+}
+
+func (s *Server) addSession(session *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+}
+
+func (s *Server) session(id string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[id]
+}
+
+func (s *Server) removeSession(id string) bool {
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+	if ok {
+		session.Close()
+		s.dropSubscriptions(session)
+	}
+	return ok
+}
+
+func decodeParams(raw json.RawMessage, v interface{}) *Error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errorf(CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+// nonNil keeps empty lists from encoding as null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// encode encodes a response or batch of responses, returning nil for none
+func encode(v interface{}) []byte {
+	if resp, ok := v.(*response); ok && resp == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(errorResponse(nil, errorf(CodeInternalError, "failed to encode response")))
+	}
+	return data
+}
@@ -0,0 +1,443 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/services"
+)
+
+// fakeMCPService echoes tool arguments; its wait tool blocks until
+// cancelled. Its resources are the texts it holds by URI.
+type fakeMCPService struct {
+	cancelled chan string
+
+	mu        sync.Mutex
+	resources map[string]string
+}
+
+// setResource replaces a resource's text, removing it when text is empty
+func (f *fakeMCPService) setResource(uri, text string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if text == "" {
+		delete(f.resources, uri)
+		return
+	}
+	f.resources[uri] = text
+}
+
+func (f *fakeMCPService) Initialize(_ context.Context, _ services.MCPInitRequest) (*services.MCPInitResponse, error) {
+	return &services.MCPInitResponse{
+		"serverInfo":   map[string]interface{}{"name": "fake", "version": "1"},
+		"capabilities": map[string]interface{}{"tools": map[string]interface{}{}},
+	}, nil
+}
+
+func (f *fakeMCPService) ListResources(_ context.Context, cursor string) ([]services.MCPResource, string, error) {
+	if cursor != "" {
+		return nil, "", models.ErrInvalidCursor
+	}
+	return nil, "", nil
+}
+
+func (f *fakeMCPService) ListResourceTemplates(_ context.Context) ([]services.MCPResourceTemplate, error) {
+	return []services.MCPResourceTemplate{{"uriTemplate": "siros://resources/{id}"}}, nil
+}
+
+func (f *fakeMCPService) ReadResource(_ context.Context, uri string) (*services.MCPResourceContent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	text, ok := f.resources[uri]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", services.ErrMCPResourceNotFound, uri)
+	}
+	return &services.MCPResourceContent{"uri": uri, "text": text}, nil
+}
+
+func (f *fakeMCPService) ListTools(_ context.Context) ([]services.MCPTool, error) {
+	return []services.MCPTool{{"name": "echo"}, {"name": "wait"}}, nil
+}
+
+func (f *fakeMCPService) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*services.MCPToolResult, error) {
+	if name == "wait" {
+		<-ctx.Done()
+		f.cancelled <- name
+		return nil, ctx.Err()
+	}
+	return &services.MCPToolResult{"content": []interface{}{map[string]interface{}{"type": "text", "text": arguments["text"]}}}, nil
+}
+
+func (f *fakeMCPService) ListPrompts(_ context.Context) ([]services.MCPPrompt, error) {
+	return nil, nil
+}
+
+func (f *fakeMCPService) GetPrompt(_ context.Context, name string, _ map[string]interface{}) (*services.MCPPromptResult, error) {
+	if name != "summary" {
+		return nil, fmt.Errorf("%w: unknown prompt %s", services.ErrMCPInvalidPrompt, name)
+	}
+	return &services.MCPPromptResult{"description": name}, nil
+}
+
+func newTestServer() (*Server, *fakeMCPService) {
+	service := &fakeMCPService{
+		cancelled: make(chan string, 1),
+		resources: map[string]string{"siros://resources/r1": `{"id":"r1"}`},
+	}
+	server := NewServer(service, log.New(io.Discard, "", 0))
+	server.pollInterval = 10 * time.Millisecond
+	return server, service
+}
+
+// step is a message a scripted client sends and the response it expects,
+// as JSON that must be contained in the response; no response is expected
+// when want is empty
+type step struct {
+	send string
+	want string
+}
+
+// script is a session every transport must serve alike
+var script = []step{
+	{
+		send: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2099-01-01","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		want: `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"fake"},"capabilities":{"tools":{}}}}`,
+	},
+	{send: `{"jsonrpc":"2.0","method":"notifications/initialized"}`},
+	{
+		send: `{"jsonrpc":"2.0","id":"a","method":"tools/list"}`,
+		want: `{"id":"a","result":{"tools":[{"name":"echo"}]}}`,
+	},
+	{
+		send: `[{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}},
+			{"jsonrpc":"2.0","method":"notifications/progress"},
+			{"jsonrpc":"2.0","id":3,"method":"tools/nope"},
+			{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{}},
+			{"jsonrpc":"2.0","id":5,"method":"resources/list"}]`,
+		want: `[{"id":2,"result":{"content":[{"text":"hi"}]}},{"id":3,"error":{"code":-32601}},{"id":4,"error":{"code":-32602}},{"id":5,"result":{"resources":[]}}]`,
+	},
+	{send: `[{"jsonrpc":"2.0","method":"notifications/progress"}]`},
+	{
+		send: `{"jsonrpc":"2.0","id":6,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		want: `{"id":6,"error":{"code":-32600}}`,
+	},
+	{send: `{"id":7,"method":"ping"}`, want: `{"id":7,"error":{"code":-32600}}`},
+	{send: `{"jsonrpc":"2.0","id":null,"method":"ping"}`, want: `{"id":null,"error":{"code":-32600}}`},
+	{send: `[]`, want: `{"id":null,"error":{"code":-32600}}`},
+	{send: `[1]`, want: `[{"id":null,"error":{"code":-32600}}]`},
+	{send: `{"jsonrpc":"2.0","id":8,"method":"resources/read","params":{"uri":5}}`, want: `{"id":8,"error":{"code":-32602}}`},
+	{
+		send: `[{"jsonrpc":"2.0","id":11,"method":"resources/read","params":{"uri":"siros://resources/r1"}},
+			{"jsonrpc":"2.0","id":12,"method":"resources/read","params":{"uri":"siros://resources/r2"}},
+			{"jsonrpc":"2.0","id":13,"method":"resources/templates/list"},
+			{"jsonrpc":"2.0","id":14,"method":"resources/list","params":{"cursor":"bogus"}},
+			{"jsonrpc":"2.0","id":15,"method":"resources/subscribe","params":{"uri":"siros://resources/r2"}},
+			{"jsonrpc":"2.0","id":16,"method":"prompts/get","params":{"name":"summary"}},
+			{"jsonrpc":"2.0","id":17,"method":"prompts/get","params":{"name":"haiku"}}]`,
+		want: `[{"id":11,"result":{"contents":[{"uri":"siros://resources/r1","text":"{\"id\":\"r1\"}"}]}},
+			{"id":12,"error":{"code":-32002,"data":{"uri":"siros://resources/r2"}}},
+			{"id":13,"result":{"resourceTemplates":[{"uriTemplate":"siros://resources/{id}"}]}},
+			{"id":14,"error":{"code":-32602}},
+			{"id":15,"error":{"code":-32002}},
+			{"id":16,"result":{"description":"summary"}},
+			{"id":17,"error":{"code":-32602}}]`,
+	},
+	{send: `{"jsonrpc":"2.0","id":9,"method":"ping"}`, want: `{"jsonrpc":"2.0","id":9,"result":{}}`},
+	{send: `{"jsonrpc":"2.0","id":10,`, want: `{"id":null,"error":{"code":-32700}}`},
+}
+
+// contains reports whether got holds everything in want: objects may have
+// more keys and arrays more elements
+func contains(got, want interface{}) bool {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		obj, ok := got.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range want {
+			if v, ok := obj[key]; !ok || !contains(v, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		arr, ok := got.([]interface{})
+		if !ok || len(arr) < len(want) {
+			return false
+		}
+		for i := range want {
+			if !contains(arr[i], want[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return got == want
+}
+
+func runScript(t *testing.T, send func(msg string, wantResponse bool) string) {
+	t.Helper()
+	for _, s := range script {
+		got := send(s.send, s.want != "")
+		if s.want == "" {
+			if got != "" {
+				t.Errorf("send %s: unexpected response %s", s.send, got)
+			}
+			continue
+		}
+		var gotValue, wantValue interface{}
+		if err := json.Unmarshal([]byte(s.want), &wantValue); err != nil {
+			t.Fatalf("invalid want %s: %v", s.want, err)
+		}
+		if err := json.Unmarshal([]byte(got), &gotValue); err != nil || !contains(gotValue, wantValue) {
+			t.Errorf("send %s:\n got %s\nwant %s", s.send, got, s.want)
+		}
+	}
+}
+
+// stdioClient drives ServeStdio through pipes
+type stdioClient struct {
+	in    *io.PipeWriter
+	lines chan string
+}
+
+func newStdioClient(t *testing.T, server *Server) *stdioClient {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.ServeStdio(context.Background(), inReader, outWriter)
+		_ = outWriter.Close()
+	}()
+	t.Cleanup(func() {
+		_ = inWriter.Close()
+		if err := <-done; err != nil {
+			t.Errorf("ServeStdio() error = %v", err)
+		}
+	})
+
+	client := &stdioClient{in: inWriter, lines: make(chan string, 16)}
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			client.lines <- scanner.Text()
+		}
+		close(client.lines)
+	}()
+	return client
+}
+
+func (c *stdioClient) send(t *testing.T, msg string, wantResponse bool) string {
+	t.Helper()
+	if _, err := io.WriteString(c.in, strings.ReplaceAll(msg, "\n", "")+"\n"); err != nil {
+		t.Fatalf("failed to write %s: %v", msg, err)
+	}
+	timeout := 2 * time.Second
+	if !wantResponse {
+		timeout = 50 * time.Millisecond
+	}
+	select {
+	case line := <-c.lines:
+		return line
+	case <-time.After(timeout):
+		if wantResponse {
+			t.Fatalf("no response to %s", msg)
+		}
+		return ""
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	server, service := newTestServer()
+	client := newStdioClient(t, server)
+	runScript(t, func(msg string, wantResponse bool) string {
+		return client.send(t, msg, wantResponse)
+	})
+
+	// A cancelled request gets no response
+	client.send(t, `{"jsonrpc":"2.0","id":20,"method":"tools/call","params":{"name":"wait"}}`, false)
+	client.send(t, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":20}}`, false)
+	select {
+	case <-service.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("notifications/cancelled did not cancel the request")
+	}
+	if got := client.send(t, `{"jsonrpc":"2.0","id":21,"method":"ping"}`, true); !strings.Contains(got, `"id":21`) {
+		t.Errorf("after cancellation got %s, want the ping response", got)
+	}
+}
+
+func TestServeStdio_Subscriptions(t *testing.T) {
+	server, service := newTestServer()
+	client := newStdioClient(t, server)
+	client.send(t, script[0].send, true)
+
+	updated := `{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"siros://resources/r1"}}`
+	if got := client.send(t, `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"siros://resources/r1"}}`, true); !strings.Contains(got, `"result":{}`) {
+		t.Fatalf("resources/subscribe = %s, want a result", got)
+	}
+	if got := client.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, true); !strings.Contains(got, `"id":2`) {
+		t.Errorf("unchanged resource: got %s, want only the ping response", got)
+	}
+
+	// Changing and then removing the resource notify once each
+	for _, text := range []string{`{"id":"r1","name":"renamed"}`, ""} {
+		service.setResource("siros://resources/r1", text)
+		if got := client.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, true); got != updated {
+			t.Errorf("after setting %q got %s, want %s", text, got, updated)
+		}
+	}
+
+	if got := client.send(t, `{"jsonrpc":"2.0","id":3,"method":"resources/unsubscribe","params":{"uri":"siros://resources/r1"}}`, true); !strings.Contains(got, `"id":3`) {
+		t.Fatalf("resources/unsubscribe = %s, want a result", got)
+	}
+	service.setResource("siros://resources/r1", `{"id":"r1"}`)
+	if got := client.send(t, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, false); got != "" {
+		t.Errorf("after unsubscribing got %s", got)
+	}
+}
+
+func TestServeStdio_NotInitialized(t *testing.T) {
+	server, _ := newTestServer()
+	client := newStdioClient(t, server)
+	if got := client.send(t, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, true); !strings.Contains(got, `-32600`) {
+		t.Errorf("tools/list before initialize = %s, want an invalid request error", got)
+	}
+	if got := client.send(t, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, true); !strings.Contains(got, `"result":{}`) {
+		t.Errorf("ping before initialize = %s, want a result", got)
+	}
+}
+
+func TestSession_Actor(t *testing.T) {
+	session := newSession("s1")
+	if got := session.Actor(); got != "mcp" {
+		t.Errorf("Actor before initialize = %s, want mcp", got)
+	}
+	session.clientInfo = map[string]interface{}{"name": "assistant", "version": "1"}
+	if got := session.Actor(); got != "mcp:assistant" {
+		t.Errorf("Actor = %s, want mcp:assistant", got)
+	}
+}
+
+// httpClient drives ServeHTTP, keeping the session it is given
+type httpClient struct {
+	t       *testing.T
+	url     string
+	session string
+}
+
+func (c *httpClient) do(method, body string, header map[string]string) *http.Response {
+	c.t.Helper()
+	req, err := http.NewRequest(method, c.url, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if c.session != "" {
+		req.Header.Set(SessionHeader, c.session)
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, body, err)
+	}
+	return resp
+}
+
+func (c *httpClient) send(msg string) (int, string) {
+	c.t.Helper()
+	resp := c.do(http.MethodPost, msg, nil)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if id := resp.Header.Get(SessionHeader); id != "" {
+		c.session = id
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestServeHTTP(t *testing.T) {
+	server, _ := newTestServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client := &httpClient{t: t, url: ts.URL}
+
+	if status, _ := client.send(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`); status != http.StatusBadRequest {
+		t.Errorf("request without a session = %d, want 400", status)
+	}
+
+	runScript(t, func(msg string, wantResponse bool) string {
+		status, body := client.send(msg)
+		switch {
+		case !wantResponse && status != http.StatusAccepted:
+			t.Errorf("send %s = %d, want 202", msg, status)
+		case strings.Contains(body, "-32700") && status != http.StatusBadRequest:
+			t.Errorf("send %s = %d, want 400", msg, status)
+		}
+		return body
+	})
+	if client.session == "" {
+		t.Fatal("initialize did not return a session ID")
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		want   int
+	}{
+		{name: "unknown session", method: http.MethodPost, header: map[string]string{SessionHeader: "nope"}, want: http.StatusNotFound},
+		{name: "other origin", method: http.MethodPost, header: map[string]string{"Origin": "http://evil.example"}, want: http.StatusForbidden},
+		{name: "unsupported version", method: http.MethodPost, header: map[string]string{ProtocolHeader: "2000-01-01"}, want: http.StatusBadRequest},
+		{name: "event stream only", method: http.MethodPost, header: map[string]string{"Accept": "text/event-stream"}, want: http.StatusOK},
+		{name: "GET without event stream", method: http.MethodGet, header: map[string]string{"Accept": "application/json"}, want: http.StatusMethodNotAllowed},
+		{name: "PUT", method: http.MethodPut, want: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		resp := client.do(tt.method, `{"jsonrpc":"2.0","id":1,"method":"ping"}`, tt.header)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, resp.StatusCode, tt.want, body)
+		}
+		if tt.name == "event stream only" && !strings.HasPrefix(string(body), "event: message\ndata: {") {
+			t.Errorf("%s: body = %q, want a server-sent event", tt.name, body)
+		}
+	}
+
+	// Notifications reach the session's event stream
+	stream := client.do(http.MethodGet, "", map[string]string{"Accept": "text/event-stream"})
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK {
+		t.Fatalf("GET event stream = %d, want 200", stream.StatusCode)
+	}
+	if err := server.session(client.session).Notify("notifications/tools/list_changed", nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	reader := bufio.NewReader(stream.Body)
+	for _, want := range []string{"event: message\n", `data: {"jsonrpc":"2.0","method":"notifications/tools/list_changed"}` + "\n"} {
+		if line, err := reader.ReadString('\n'); err != nil || line != want {
+			t.Fatalf("event stream line = %q, %v; want %q", line, err, want)
+		}
+	}
+
+	if resp := client.do(http.MethodDelete, "", nil); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE = %d, want 204", resp.StatusCode)
+	}
+	if status, _ := client.send(`{"jsonrpc":"2.0","id":1,"method":"ping"}`); status != http.StatusNotFound {
+		t.Errorf("request after DELETE = %d, want 404", status)
+	}
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// outboundBuffer is the number of server-initiated messages a session
+// queues for a client that is not reading them
+const outboundBuffer = 64
+
+// ErrSessionClosed is returned when notifying a closed session
+var ErrSessionClosed = errors.New("MCP session closed")
+
+// Session is the state of one client connection: the negotiated protocol
+// version, the client's capabilities, its in-flight requests and the
+// messages queued for it.
+type Session struct {
+	ID string
+
+	mu                 sync.Mutex
+	initialized        bool
+	protocolVersion    string
+	clientInfo         map[string]interface{}
+	clientCapabilities map[string]interface{}
+	inflight           map[string]context.CancelFunc
+	lastSeen           time.Time
+
+	outbound chan []byte
+	done     chan struct{}
+	closed   sync.Once
+}
+
+func newSession(id string) *Session {
+	return &Session{
+		ID:       id,
+		inflight: map[string]context.CancelFunc{},
+		lastSeen: time.Now(),
+		outbound: make(chan []byte, outboundBuffer),
+		done:     make(chan struct{}),
+	}
+}
+
+// ProtocolVersion returns the protocol version negotiated by initialize
+func (s *Session) ProtocolVersion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.protocolVersion
+}
+
+// ClientCapabilities returns the capabilities the client declared
+func (s *Session) ClientCapabilities() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientCapabilities
+}
+
+// Actor names the client in the records of the changes it proposes, from
+// the clientInfo it sent with initialize
+func (s *Session) Actor() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name, ok := s.clientInfo["name"].(string); ok && name != "" {
+		return "mcp:" + name
+	}
+	return "mcp"
+}
+
+// Notify queues a notification for the client. It fails rather than block
+// when the client is not reading its messages.
+func (s *Session) Notify(method string, params interface{}) error {
+	data, err := json.Marshal(notification{JSONRPC: jsonrpcVersion, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	select {
+	case <-s.done:
+		return ErrSessionClosed
+	default:
+	}
+	select {
+	case s.outbound <- data:
+		return nil
+	default:
+		return errors.New("MCP session is not reading notifications")
+	}
+}
+
+// Close ends the session, cancelling its in-flight requests
+func (s *Session) Close() {
+	s.closed.Do(func() {
+		s.mu.Lock()
+		for _, cancel := range s.inflight {
+			cancel()
+		}
+		s.mu.Unlock()
+		close(s.done)
+	})
+}
+
+// Done is closed when the session ends
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// track registers an in-flight request so that a cancellation can stop it
+func (s *Session) track(ctx context.Context, id json.RawMessage) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := idKey(id)
+	s.mu.Lock()
+	s.inflight[key] = cancel
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel stops an in-flight request, if it is still running
+func (s *Session) cancel(id json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.inflight[idKey(id)]; ok {
+		cancel()
+	}
+}
+
+func (s *Session) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSeen
+}
+
+// idKey normalizes a request ID, so that 1 and 1.0 name the same request
+func idKey(id json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return string(id)
+	}
+	key, _ := json.Marshal(v)
+	return string(key)
+}
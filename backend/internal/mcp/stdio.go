@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// ServeStdio serves one session over newline-delimited messages read from
+// in and written to out, until in is exhausted or ctx is done. Requests are
+// handled concurrently so that long ones can be cancelled; responses may
+// therefore be written out of order.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	session := newSession("")
+	defer s.dropSubscriptions(session)
+	defer session.Close()
+
+	var mu sync.Mutex
+	write := func(data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			s.logger.Printf("Failed to write MCP message: %v", err)
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case msg := <-session.outbound:
+				write(msg)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64<<10), maxMessageSize)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			select {
+			case lines <- append([]byte(nil), line...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case line := <-lines:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp := s.Handle(ctx, session, line); resp != nil {
+					write(resp)
+				}
+			}()
+		case err := <-scanErr:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/services"
+)
+
+// defaultPollInterval is how often subscribed resources are read again to
+// find changes
+const defaultPollInterval = 5 * time.Second
+
+// subscriptions tracks the resources sessions subscribed to. Changes are
+// found by reading each subscribed resource again every poll interval and
+// comparing digests of the content, which also catches changes made by
+// other processes sharing the database.
+type subscriptions struct {
+	mu       sync.Mutex
+	sessions map[string]map[*Session]bool
+	digests  map[string][sha256.Size]byte
+	watching bool
+}
+
+// subscribe adds a subscription to a resource, which must be readable
+func (s *Server) subscribe(ctx context.Context, session *Session, uri string) *Error {
+	digest, err := s.digest(ctx, uri)
+	if err != nil {
+		return s.resourceError("resources/subscribe", uri, err)
+	}
+
+	s.subs.mu.Lock()
+	defer s.subs.mu.Unlock()
+	if s.subs.sessions == nil {
+		s.subs.sessions = map[string]map[*Session]bool{}
+		s.subs.digests = map[string][sha256.Size]byte{}
+	}
+	if s.subs.sessions[uri] == nil {
+		s.subs.sessions[uri] = map[*Session]bool{}
+		s.subs.digests[uri] = digest
+	}
+	s.subs.sessions[uri][session] = true
+	if !s.subs.watching {
+		s.subs.watching = true
+		go s.watch()
+	}
+	return nil
+}
+
+// unsubscribe removes a session's subscription to a resource
+func (s *Server) unsubscribe(session *Session, uri string) {
+	s.subs.mu.Lock()
+	defer s.subs.mu.Unlock()
+	s.dropSubscriptionLocked(session, uri)
+}
+
+// dropSubscriptions removes every subscription of a session that ended
+func (s *Server) dropSubscriptions(session *Session) {
+	s.subs.mu.Lock()
+	defer s.subs.mu.Unlock()
+	for uri := range s.subs.sessions {
+		s.dropSubscriptionLocked(session, uri)
+	}
+}
+
+func (s *Server) dropSubscriptionLocked(session *Session, uri string) {
+	delete(s.subs.sessions[uri], session)
+	if len(s.subs.sessions[uri]) == 0 {
+		delete(s.subs.sessions, uri)
+		delete(s.subs.digests, uri)
+	}
+}
+
+// watch polls the subscribed resources until none are left, notifying the
+// subscribers of those that changed
+func (s *Server) watch() {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.subs.mu.Lock()
+		if len(s.subs.sessions) == 0 {
+			s.subs.watching = false
+			s.subs.mu.Unlock()
+			return
+		}
+		uris := make([]string, 0, len(s.subs.sessions))
+		for uri := range s.subs.sessions {
+			uris = append(uris, uri)
+		}
+		s.subs.mu.Unlock()
+
+		for _, uri := range uris {
+			s.poll(uri)
+		}
+	}
+}
+
+// poll reads a subscribed resource and notifies its subscribers when its
+// content changed. A resource that disappeared counts as changed once.
+func (s *Server) poll(uri string) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.pollInterval)
+	defer cancel()
+	digest, err := s.digest(ctx, uri)
+	if err != nil && !errors.Is(err, services.ErrMCPResourceNotFound) {
+		s.logger.Printf("Failed to poll MCP resource %s: %v", uri, err)
+		return
+	}
+
+	s.subs.mu.Lock()
+	previous, ok := s.subs.digests[uri]
+	if !ok || previous == digest {
+		s.subs.mu.Unlock()
+		return
+	}
+	s.subs.digests[uri] = digest
+	subscribers := make([]*Session, 0, len(s.subs.sessions[uri]))
+	for session := range s.subs.sessions[uri] {
+		subscribers = append(subscribers, session)
+	}
+	s.subs.mu.Unlock()
+
+	for _, session := range subscribers {
+		err := session.Notify("notifications/resources/updated", map[string]interface{}{"uri": uri})
+		if errors.Is(err, ErrSessionClosed) {
+			s.dropSubscriptions(session)
+		} else if err != nil {
+			s.logger.Printf("Failed to notify MCP session %s of %s: %v", session.ID, uri, err)
+		}
+	}
+}
+
+// digest reads a resource and hashes its content; a resource that does not
+// exist has the zero digest
+func (s *Server) digest(ctx context.Context, uri string) ([sha256.Size]byte, error) {
+	content, err := s.service.ReadResource(ctx, uri)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256([]byte(fmt.Sprint((*content)["text"], (*content)["blob"]))), nil
+}
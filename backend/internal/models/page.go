@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Total count modes of a paginated listing
+const (
+	TotalExact    = "exact"
+	TotalEstimate = "estimate"
+)
+
+// MaxPageSize caps the number of items returned in one page
+const MaxPageSize = 1000
+
+// ErrInvalidCursor is wrapped by errors for cursors that cannot be decoded or
+// were issued for another ordering
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a page boundary in a keyset-paginated listing: the sort keys
+// and tie-breaking ID of the item next to the boundary, and whether the page
+// lies before it. Clients only see it encoded.
+type Cursor struct {
+	Order  string   `json:"o"`
+	Values []string `json:"v"`
+	ID     string   `json:"id"`
+	Before bool     `json:"b,omitempty"`
+}
+
+// Encode returns the opaque form of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor issued for order, the listing's sort field
+// and direction
+func DecodeCursor(encoded, order string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if cursor.Order != order {
+		return nil, fmt.Errorf("%w: issued for %s, not %s", ErrInvalidCursor, cursor.Order, order)
+	}
+	return &cursor, nil
+}
+
+// PageInfo describes where a page sits in its listing. Total is only set
+// when it was requested; an estimated total comes from the query planner.
+type PageInfo struct {
+	NextCursor     string `json:"next_cursor,omitempty"`
+	PrevCursor     string `json:"prev_cursor,omitempty"`
+	Total          *int64 `json:"total,omitempty"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+}
+
+// validatePage checks the paging parameters shared by listing queries
+func validatePage(limit int, total string) error {
+	if limit < 0 {
+		return errors.New("limit cannot be negative")
+	}
+	if total != "" && total != TotalExact && total != TotalEstimate {
+		return errors.New("total must be 'exact' or 'estimate'")
+	}
+	return nil
+}
+
+// PageSize returns limit bounded to (0, MaxPageSize], defaulting to 50
+func PageSize(limit int) int {
+	switch {
+	case limit <= 0:
+		return 50
+	case limit > MaxPageSize:
+		return MaxPageSize
+	default:
+		return limit
+	}
+}
+
+// SchemaQuery selects a page of registered schemas, ordered by name. Custom
+// selects custom or predefined schemas when set.
+type SchemaQuery struct {
+	Provider string `json:"provider,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Custom   *bool  `json:"custom,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Total    string `json:"total,omitempty"`
+}
+
+// SchemaOrder is the ordering schema cursors are issued for
+const SchemaOrder = "name:asc"
+
+// Validate performs validation on the schema query
+func (sq *SchemaQuery) Validate() error {
+	if err := validatePage(sq.Limit, sq.Total); err != nil {
+		return err
+	}
+	if sq.Cursor != "" {
+		if _, err := DecodeCursor(sq.Cursor, SchemaOrder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetDefaults sets default values for the schema query
+func (sq *SchemaQuery) SetDefaults() {
+	sq.Limit = PageSize(sq.Limit)
+}
+
+// ChangeQuery selects a page of change records, newest first
+type ChangeQuery struct {
+	ResourceID string     `json:"resource_id,omitempty"`
+	Actor      string     `json:"actor,omitempty"`
+	Operation  string     `json:"operation,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+	Cursor     string     `json:"cursor,omitempty"`
+	Limit      int        `json:"limit,omitempty"`
+	Total      string     `json:"total,omitempty"`
+}
+
+// ChangeOrder is the ordering change record cursors are issued for
+const ChangeOrder = "timestamp:desc"
+
+// Validate performs validation on the change query
+func (cq *ChangeQuery) Validate() error {
+	if err := validatePage(cq.Limit, cq.Total); err != nil {
+		return err
+	}
+	if cq.Cursor != "" {
+		if _, err := DecodeCursor(cq.Cursor, ChangeOrder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetDefaults sets default values for the change query
+func (cq *ChangeQuery) SetDefaults() {
+	cq.Limit = PageSize(cq.Limit)
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := Cursor{Order: "name:asc", Values: []string{"web"}, ID: "sid-1", Before: true}
+
+	decoded, err := DecodeCursor(cursor.Encode(), "name:asc")
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(*decoded, cursor) {
+		t.Errorf("DecodeCursor() = %+v, want %+v", *decoded, cursor)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "not base64", encoded: "!!"},
+		{name: "not json", encoded: "bm90LWpzb24"},
+		{name: "other order", encoded: Cursor{Order: "created_at:desc", Values: []string{"x"}, ID: "1"}.Encode()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.encoded, "name:asc"); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestSearchQuery_ValidatePage(t *testing.T) {
+	valid := Cursor{Order: "created_at:desc", Values: []string{"2024-01-01T00:00:00Z"}, ID: "1"}.Encode()
+
+	tests := []struct {
+		name      string
+		query     SearchQuery
+		wantError bool
+	}{
+		{name: "cursor", query: SearchQuery{Cursor: valid}},
+		{name: "cursor with offset", query: SearchQuery{Cursor: valid, Offset: 10}, wantError: true},
+		{name: "cursor for other sort", query: SearchQuery{Cursor: valid, SortBy: "name"}, wantError: true},
+		{name: "exact total", query: SearchQuery{Total: TotalExact}},
+		{name: "unknown total", query: SearchQuery{Total: "all"}, wantError: true},
+		{name: "unknown sort field", query: SearchQuery{SortBy: "data"}, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantError {
+				t.Errorf("Validate() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for limit, want := range map[int]int{0: 50, -1: 50, 10: 10, MaxPageSize + 1: MaxPageSize} {
+		if got := PageSize(limit); got != want {
+			t.Errorf("PageSize(%d) = %d, want %d", limit, got, want)
+		}
+	}
+}
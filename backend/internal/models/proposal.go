@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Change proposal statuses. A proposal is approved while it is being
+// applied, then applied or failed.
+const (
+	ProposalStatusPending  = "pending"
+	ProposalStatusApproved = "approved"
+	ProposalStatusApplied  = "applied"
+	ProposalStatusFailed   = "failed"
+	ProposalStatusRejected = "rejected"
+)
+
+// ChangeProposal is a change to a resource proposed on behalf of an AI
+// assistant, which a person must approve before it is applied. Diff holds
+// what a dry run of the change found it would do: for each field it sets,
+// an {"old", "new"} pair.
+type ChangeProposal struct {
+	ID         string                 `json:"id" db:"id"`
+	Operation  string                 `json:"operation" db:"operation"`
+	ResourceID string                 `json:"resource_id,omitempty" db:"resource_id"`
+	Create     *CreateResourceRequest `json:"create,omitempty" db:"-"`
+	Update     *UpdateResourceRequest `json:"update,omitempty" db:"-"`
+	Diff       map[string]interface{} `json:"diff" db:"diff"`
+	Warnings   []string               `json:"warnings,omitempty" db:"warnings"`
+	Reason     string                 `json:"reason" db:"reason"`
+	Status     string                 `json:"status" db:"status"`
+	ProposedBy string                 `json:"proposed_by" db:"proposed_by"`
+	ReviewedBy string                 `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNote string                 `json:"review_note,omitempty" db:"review_note"`
+	// Error is why an approved proposal failed to apply, or why an applied
+	// one is missing from the change ledger
+	Error string `json:"error,omitempty" db:"error"`
+	// BaseModifiedAt is when the resource an update targets was modified
+	// last before the dry run; the update is not applied over later changes
+	BaseModifiedAt *time.Time `json:"base_modified_at,omitempty" db:"base_modified_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// Validate performs validation on the change proposal
+func (p *ChangeProposal) Validate() error {
+	switch p.Operation {
+	case "CREATE":
+		if p.Create == nil {
+			return errors.New("a create proposal needs the resource to create")
+		}
+	case "UPDATE":
+		if strings.TrimSpace(p.ResourceID) == "" || p.Update == nil {
+			return errors.New("an update proposal needs a resource ID and the update")
+		}
+	default:
+		return fmt.Errorf("invalid proposal operation: %s", p.Operation)
+	}
+
+	if strings.TrimSpace(p.Reason) == "" {
+		return errors.New("reason is required for change proposal")
+	}
+
+	if strings.TrimSpace(p.ProposedBy) == "" {
+		return errors.New("proposed_by is required for change proposal")
+	}
+
+	return nil
+}
+
+// Request returns the create or update request the proposal applies
+func (p *ChangeProposal) Request() interface{} {
+	if p.Operation == "CREATE" {
+		return p.Create
+	}
+	return p.Update
+}
+
+// ProposalReview is the body of an approval or rejection
+type ProposalReview struct {
+	Note string `json:"note,omitempty"`
+}
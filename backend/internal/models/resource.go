@@ -1,10 +1,18 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/LederWorks/siros/backend/internal/filter"
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
 )
 
 // Sort order constants
@@ -25,6 +33,9 @@ type Resource struct {
 	ParentID   *string                `json:"parent_id,omitempty" db:"parent_id"`
 	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
 	ModifiedAt time.Time              `json:"modified_at" db:"modified_at"`
+	// Warnings lists schema violations accepted under the warn mode. They
+	// are reported with the response and not stored.
+	Warnings []string `json:"warnings,omitempty" db:"-"`
 }
 
 // ResourceMetadata contains enriched metadata for resources
@@ -37,6 +48,14 @@ type ResourceMetadata struct {
 	Environment string                 `json:"environment,omitempty"`
 	CostCenter  string                 `json:"cost_center,omitempty"`
 	Custom      map[string]interface{} `json:"custom,omitempty"`
+
+	// NativeID and Account are the resource's identifiers at its provider
+	NativeID string `json:"native_id,omitempty"`
+	Account  string `json:"account,omitempty"`
+
+	// SchemaVersion pins the resource to the version of its type's schema
+	// its data conforms to
+	SchemaVersion string `json:"schema_version,omitempty"`
 }
 
 // Validate performs business rule validation on the resource
@@ -59,11 +78,12 @@ func (r *Resource) Validate() error {
 
 	// Validate provider is supported
 	validProviders := map[string]bool{
-		"aws":    true,
-		"azure":  true,
-		"gcp":    true,
-		"oci":    true,
-		"custom": true,
+		"aws":        true,
+		"azure":      true,
+		"gcp":        true,
+		"oci":        true,
+		"kubernetes": true,
+		"custom":     true,
 	}
 
 	if !validProviders[strings.ToLower(r.Provider)] {
@@ -123,15 +143,67 @@ func (r *Resource) UpdateModified(modifiedBy string) {
 	r.Metadata.ModifiedBy = modifiedBy
 }
 
-// Schema represents a resource schema definition
+// ResourceAlias maps another identifier of a resource, such as its native
+// ID, ARN or a former Siros ID, to the resource's Siros ID
+type ResourceAlias struct {
+	Provider   string    `json:"provider" db:"provider"`
+	Account    string    `json:"account" db:"account"`
+	Region     string    `json:"region" db:"region"`
+	Alias      string    `json:"alias" db:"alias"`
+	ResourceID string    `json:"resource_id" db:"resource_id"`
+	Source     string    `json:"source" db:"source"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// DeduplicationReport summarizes moving resources stored under native or
+// random IDs to their derived Siros IDs
+type DeduplicationReport struct {
+	Scanned int `json:"scanned"`
+	Renamed int `json:"renamed"`
+	Merged  int `json:"merged"`
+	Failed  int `json:"failed"`
+}
+
+// Schema validation modes. Under enforce, resources whose data violates
+// their schema are rejected; under warn they are stored and the violations
+// reported; off disables validation.
+const (
+	SchemaModeEnforce = "enforce"
+	SchemaModeWarn    = "warn"
+	SchemaModeOff     = "off"
+)
+
+// Schema represents a resource schema definition. The schema is a JSON
+// Schema (draft 2020-12) for the data of the provider's resources of Type.
+// A schema has any number of versions; Compatibility classifies a version
+// against the one before it, whose data Transform upgrades. Custom schemas
+// are registered by users; the others are predefined by Siros and cannot
+// be changed through the API.
 type Schema struct {
-	Name        string                 `json:"name" db:"name"`
-	Provider    string                 `json:"provider" db:"provider"`
-	Type        string                 `json:"type" db:"type"`
-	Version     string                 `json:"version" db:"version"`
-	Schema      map[string]interface{} `json:"schema" db:"schema"`
-	Description string                 `json:"description" db:"description"`
-	CreatedAt   time.Time              `json:"created_at" db:"created_at"`
+	Name          string                 `json:"name" db:"name"`
+	Provider      string                 `json:"provider" db:"provider"`
+	Type          string                 `json:"type" db:"type"`
+	Version       string                 `json:"version" db:"version"`
+	Schema        map[string]interface{} `json:"schema" db:"schema"`
+	Mode          string                 `json:"mode,omitempty" db:"mode"`
+	Compatibility string                 `json:"compatibility,omitempty" db:"compatibility"`
+	Transform     *SchemaTransform       `json:"transform,omitempty" db:"transform"`
+	Description   string                 `json:"description" db:"description"`
+	IsCustom      bool                   `json:"is_custom" db:"is_custom"`
+	CreatedAt     time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// ErrPredefinedSchema is returned when a predefined schema would be changed
+// as a custom one
+var ErrPredefinedSchema = errors.New("schema is predefined")
+
+// ValidationMode returns the schema's mode, enforce unless set
+func (s *Schema) ValidationMode() string {
+	if s.Mode == "" {
+		return SchemaModeEnforce
+	}
+	return s.Mode
 }
 
 // Validate performs validation on the schema
@@ -152,13 +224,59 @@ func (s *Schema) Validate() error {
 		return errors.New("schema version is required")
 	}
 
+	if _, err := ParseSchemaVersion(s.Version); err != nil {
+		return err
+	}
+
 	if len(s.Schema) == 0 {
 		return errors.New("schema definition is required")
 	}
 
+	switch s.ValidationMode() {
+	case SchemaModeEnforce, SchemaModeWarn, SchemaModeOff:
+	default:
+		return fmt.Errorf("invalid schema mode %q: must be enforce, warn or off", s.Mode)
+	}
+
+	if _, err := jsonschema.Compile(s.Schema); err != nil {
+		return fmt.Errorf("schema definition is invalid: %w", err)
+	}
+
+	if s.Transform != nil {
+		if err := s.Transform.Validate(); err != nil {
+			return fmt.Errorf("schema transform is invalid: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// SchemaValidationError reports the violations of a resource's data against
+// its schema
+type SchemaValidationError struct {
+	Schema     string             `json:"schema"`
+	Version    string             `json:"version"`
+	Violations []jsonschema.Error `json:"violations"`
+}
+
+// Error summarizes the violations, naming the first
+func (e *SchemaValidationError) Error() string {
+	msg := fmt.Sprintf("schema validation failed: data does not match schema %s version %s", e.Schema, e.Version)
+	if len(e.Violations) > 0 {
+		msg += fmt.Sprintf(" (%d violations, first %s)", len(e.Violations), e.Violations[0].Error())
+	}
+	return msg
+}
+
+// SchemaValidationResult is the outcome of validating data against a schema
+type SchemaValidationResult struct {
+	Schema  string             `json:"schema"`
+	Version string             `json:"version"`
+	Mode    string             `json:"mode"`
+	Valid   bool               `json:"valid"`
+	Errors  []jsonschema.Error `json:"errors"`
+}
+
 // ChangeRecord represents a blockchain change record
 type ChangeRecord struct {
 	ID           string                 `json:"id" db:"id"`
@@ -187,6 +305,7 @@ func (cr *ChangeRecord) Validate() error {
 		"CREATE": true,
 		"UPDATE": true,
 		"DELETE": true,
+		"MERGE":  true,
 	}
 
 	if !validOps[strings.ToUpper(cr.Operation)] {
@@ -200,12 +319,25 @@ func (cr *ChangeRecord) Validate() error {
 	return nil
 }
 
+// ComputeHash hashes the record's content together with its predecessor's
+// hash, chaining the records of a resource
+func (cr *ChangeRecord) ComputeHash() string {
+	changes, _ := json.Marshal(cr.Changes)
+	data := fmt.Sprintf("%s:%s:%s:%d:%s:%s",
+		cr.ResourceID, cr.Operation, cr.Actor, cr.Timestamp.Unix(), changes, cr.PreviousHash)
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
 // SearchQuery represents a search query with filters
 type SearchQuery struct {
 	Query     string            `json:"query"`
+	Filter    string            `json:"filter,omitempty"`
 	Filters   map[string]string `json:"filters,omitempty"`
 	Limit     int               `json:"limit,omitempty"`
 	Offset    int               `json:"offset,omitempty"`
+	Cursor    string            `json:"cursor,omitempty"`
+	Total     string            `json:"total,omitempty"`
 	Provider  string            `json:"provider,omitempty"`
 	Type      string            `json:"type,omitempty"`
 	SortBy    string            `json:"sort_by,omitempty"`
@@ -214,8 +346,8 @@ type SearchQuery struct {
 
 // Validate performs validation on the search query
 func (sq *SearchQuery) Validate() error {
-	if sq.Limit < 0 {
-		return errors.New("limit cannot be negative")
+	if err := validatePage(sq.Limit, sq.Total); err != nil {
+		return err
 	}
 
 	if sq.Offset < 0 {
@@ -226,18 +358,73 @@ func (sq *SearchQuery) Validate() error {
 		return errors.New("sort_order must be 'asc' or 'desc'")
 	}
 
+	if _, err := sq.Sort(); err != nil {
+		return err
+	}
+
+	if _, err := sq.Expr(); err != nil {
+		return err
+	}
+
+	if sq.Cursor != "" {
+		if sq.Offset != 0 {
+			return errors.New("offset cannot be combined with cursor")
+		}
+		if _, err := DecodeCursor(sq.Cursor, sq.Order()); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// SetDefaults sets default values for the search query
-func (sq *SearchQuery) SetDefaults() {
-	if sq.Limit == 0 {
-		sq.Limit = 50 // Default limit
+// Expr returns the query's filter expression joined with its per-field
+// equality filters, or nil when it has neither
+func (sq *SearchQuery) Expr() (filter.Expr, error) {
+	var exprs []filter.Expr
+	if strings.TrimSpace(sq.Filter) != "" {
+		expr, err := filter.Parse(sq.Filter)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+
+	fields := slices.Sorted(maps.Keys(sq.Filters))
+	for _, field := range fields {
+		expr, err := filter.Equal(field, sq.Filters[field])
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return filter.All(exprs...), nil
+}
+
+// Sort returns the fields the query sorts by, applying the defaults.
+// SortBy lists fields as in "provider,-modified_at"; SortOrder is the
+// direction of fields that do not give their own.
+func (sq *SearchQuery) Sort() ([]filter.SortKey, error) {
+	sortBy := sq.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
 	}
+	return filter.ParseSort(sortBy, sq.SortOrder != SortOrderAsc)
+}
 
-	if sq.Limit > 1000 {
-		sq.Limit = 1000 // Max limit
+// Order returns the query's sort in canonical form, as in
+// "provider:asc,created_at:desc", which cursors are issued for
+func (sq *SearchQuery) Order() string {
+	keys, err := sq.Sort()
+	if err != nil {
+		return ""
 	}
+	return filter.FormatSort(keys)
+}
+
+// SetDefaults sets default values for the search query
+func (sq *SearchQuery) SetDefaults() {
+	sq.Limit = PageSize(sq.Limit)
 
 	if sq.SortBy == "" {
 		sq.SortBy = "created_at"
@@ -358,3 +545,106 @@ func (urr *UpdateResourceRequest) ApplyTo(resource *Resource, modifiedBy string)
 
 	resource.UpdateModified(modifiedBy)
 }
+
+// Import result statuses
+const (
+	ImportStatusCreated = "created"
+	ImportStatusUpdated = "updated"
+	ImportStatusFailed  = "failed"
+)
+
+// ImportRecord is one row of a bulk import. Rows are matched to stored
+// resources by their natural key: the provider, account and region together
+// with the native ID or, when that is not given, the ARN.
+type ImportRecord struct {
+	Line     int                    `json:"-"`
+	Provider string                 `json:"provider"`
+	Account  string                 `json:"account,omitempty"`
+	NativeID string                 `json:"native_id,omitempty"`
+	ARN      string                 `json:"arn,omitempty"`
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Metadata ResourceMetadata       `json:"metadata"`
+	ParentID *string                `json:"parent_id,omitempty"`
+}
+
+// Key returns the native identifier the row is matched on
+func (ir *ImportRecord) Key() string {
+	if ir.NativeID != "" {
+		return ir.NativeID
+	}
+	return ir.ARN
+}
+
+// ToResource converts the row to a Resource stored under id and attributed
+// to actor
+func (ir *ImportRecord) ToResource(id, actor string) (*Resource, error) {
+	if strings.TrimSpace(ir.Key()) == "" {
+		return nil, errors.New("native_id or arn is required")
+	}
+
+	now := time.Now()
+	resource := &Resource{
+		ID:         id,
+		Type:       ir.Type,
+		Provider:   strings.ToLower(ir.Provider),
+		Name:       ir.Name,
+		Data:       ir.Data,
+		Metadata:   ir.Metadata,
+		ParentID:   ir.ParentID,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+	if resource.Data == nil {
+		resource.Data = make(map[string]interface{})
+	}
+	if ir.ARN != "" {
+		resource.Data["arn"] = ir.ARN
+	}
+	if ir.NativeID != "" {
+		resource.Metadata.NativeID = ir.NativeID
+	}
+	if ir.Account != "" {
+		resource.Metadata.Account = ir.Account
+	}
+	resource.Metadata.CreatedBy = actor
+	resource.Metadata.ModifiedBy = actor
+
+	if err := resource.Validate(); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// ImportResult is the outcome of importing one row
+type ImportResult struct {
+	Line     int      `json:"line"`
+	ID       string   `json:"id,omitempty"`
+	Status   string   `json:"status"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ImportReport summarizes a bulk import
+type ImportReport struct {
+	Total   int            `json:"total"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Failed  int            `json:"failed"`
+	Results []ImportResult `json:"results"`
+}
+
+// Add records the outcome of a row
+func (r *ImportReport) Add(result ImportResult) {
+	r.Total++
+	switch result.Status {
+	case ImportStatusCreated:
+		r.Created++
+	case ImportStatusUpdated:
+		r.Updated++
+	default:
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
@@ -322,3 +322,31 @@ func TestSearchQuery_SetDefaults(t *testing.T) {
 		t.Errorf("Expected capped limit 1000, got %d", query.Limit)
 	}
 }
+
+func TestSearchQuery_Expr(t *testing.T) {
+	query := SearchQuery{
+		Filter:  `provider:aws OR provider:gcp`,
+		Filters: map[string]string{"tags.team": "core", "environment": "prod"},
+	}
+	expr, err := query.Expr()
+	if err != nil {
+		t.Fatalf("Expr() error = %v", err)
+	}
+	want := `(((provider:aws OR provider:gcp) AND environment:"prod") AND tags.team:"core")`
+	if got := expr.String(); got != want {
+		t.Errorf("Expr() = %s, want %s", got, want)
+	}
+
+	for _, invalid := range []SearchQuery{
+		{Filter: `provider:`},
+		{Filters: map[string]string{"unknown": "x"}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v", invalid)
+		}
+	}
+
+	if expr, err := (&SearchQuery{}).Expr(); expr != nil || err != nil {
+		t.Errorf("Expr() of an unfiltered query = %v, %v", expr, err)
+	}
+}
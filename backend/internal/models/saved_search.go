@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/filter"
+)
+
+// SavedSearch is a named resource filter expression that can be run again
+// later, over the API or from MCP tools
+type SavedSearch struct {
+	ID          string    `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	Filter      string    `json:"filter" db:"filter"`
+	CreatedBy   string    `json:"created_by" db:"created_by"`
+	ModifiedBy  string    `json:"modified_by" db:"modified_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ModifiedAt  time.Time `json:"modified_at" db:"modified_at"`
+}
+
+// Validate performs validation on the saved search
+func (s *SavedSearch) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("saved search name is required")
+	}
+	if _, err := filter.Parse(s.Filter); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
+)
+
+// ParseSchemaVersion parses a schema version of up to three dot-separated
+// numbers, such as 2, 1.4 or 1.4.2
+func ParseSchemaVersion(version string) ([3]int, error) {
+	var parts [3]int
+	fields := strings.Split(version, ".")
+	if len(fields) > len(parts) {
+		return parts, fmt.Errorf("invalid schema version %q: at most three numbers", version)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 || field != strconv.Itoa(n) {
+			return parts, fmt.Errorf("invalid schema version %q: must be numbers separated by dots", version)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// CompareSchemaVersions compares two schema versions numerically, returning
+// -1, 0 or 1. Missing numbers count as zero, so 1.0 equals 1. Versions that
+// do not parse sort first.
+func CompareSchemaVersions(a, b string) int {
+	pa, errA := ParseSchemaVersion(a)
+	pb, errB := ParseSchemaVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// SchemaTransform upgrades resource data written for the previous version
+// of a schema. Paths are JSON pointers into the data: Renames moves values
+// from one path to another, and Defaults sets values where none is present.
+// Renames are applied first.
+type SchemaTransform struct {
+	Renames  map[string]string      `json:"renames,omitempty"`
+	Defaults map[string]interface{} `json:"defaults,omitempty"`
+}
+
+// Validate checks that every path is a JSON pointer below the data's root
+func (t *SchemaTransform) Validate() error {
+	for from, to := range t.Renames {
+		for _, path := range []string{from, to} {
+			if tokens, err := jsonschema.ParsePointer(path); err != nil || len(tokens) == 0 {
+				return fmt.Errorf("invalid rename path %q: must be a JSON pointer such as /size", path)
+			}
+		}
+	}
+	for path := range t.Defaults {
+		if tokens, err := jsonschema.ParsePointer(path); err != nil || len(tokens) == 0 {
+			return fmt.Errorf("invalid default path %q: must be a JSON pointer such as /size", path)
+		}
+	}
+	return nil
+}
+
+// Apply transforms data in place, returning a description of each change
+// made. A rename whose source is missing, or whose target is already set,
+// is skipped.
+func (t *SchemaTransform) Apply(data map[string]interface{}) ([]string, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	var applied []string
+	for _, from := range sortedStrings(t.Renames) {
+		to := t.Renames[from]
+		value, ok, err := pointerGet(data, from)
+		if err != nil || !ok {
+			continue
+		}
+		if _, exists, _ := pointerGet(data, to); exists {
+			continue
+		}
+		if err := pointerSet(data, to, value); err != nil {
+			return nil, fmt.Errorf("failed to rename %s to %s: %w", from, to, err)
+		}
+		pointerDelete(data, from)
+		applied = append(applied, fmt.Sprintf("renamed %s to %s", from, to))
+	}
+
+	for _, path := range sortedStrings(t.Defaults) {
+		if _, exists, _ := pointerGet(data, path); exists {
+			continue
+		}
+		// Copied so that resources never share a default object
+		value, err := copyJSON(t.Defaults[path])
+		if err != nil {
+			return nil, fmt.Errorf("failed to default %s: %w", path, err)
+		}
+		if err := pointerSet(data, path, value); err != nil {
+			return nil, fmt.Errorf("failed to default %s: %w", path, err)
+		}
+		applied = append(applied, fmt.Sprintf("defaulted %s", path))
+	}
+	return applied, nil
+}
+
+func sortedStrings[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func copyJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// pointerGet returns the value a JSON pointer names within objects
+func pointerGet(data map[string]interface{}, pointer string) (interface{}, bool, error) {
+	tokens, err := jsonschema.ParsePointer(pointer)
+	if err != nil {
+		return nil, false, err
+	}
+	var v interface{} = data
+	for _, token := range tokens {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false, nil
+		}
+		if v, ok = obj[token]; !ok {
+			return nil, false, nil
+		}
+	}
+	return v, true, nil
+}
+
+// pointerSet sets the value a JSON pointer names, creating the objects on
+// its way
+func pointerSet(data map[string]interface{}, pointer string, value interface{}) error {
+	tokens, err := jsonschema.ParsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	obj := data
+	for _, token := range tokens[:len(tokens)-1] {
+		next, ok := obj[token]
+		if !ok {
+			child := map[string]interface{}{}
+			obj[token] = child
+			obj = child
+			continue
+		}
+		if obj, ok = next.(map[string]interface{}); !ok {
+			return errors.New("path crosses a value that is not an object")
+		}
+	}
+	obj[tokens[len(tokens)-1]] = value
+	return nil
+}
+
+// pointerDelete removes the value a JSON pointer names, if any
+func pointerDelete(data map[string]interface{}, pointer string) {
+	tokens, err := jsonschema.ParsePointer(pointer)
+	if err != nil || len(tokens) == 0 {
+		return
+	}
+	obj := data
+	for _, token := range tokens[:len(tokens)-1] {
+		next, ok := obj[token].(map[string]interface{})
+		if !ok {
+			return
+		}
+		obj = next
+	}
+	delete(obj, tokens[len(tokens)-1])
+}
+
+// SchemaCompatibility reports how one version of a schema relates to another
+type SchemaCompatibility struct {
+	Schema        string              `json:"schema"`
+	Provider      string              `json:"provider"`
+	From          string              `json:"from"`
+	To            string              `json:"to"`
+	Compatibility string              `json:"compatibility"`
+	Changes       []jsonschema.Change `json:"changes"`
+}
+
+// SchemaMigrationRequest asks to upgrade the resources of a schema's type to
+// version To, by default the latest. When From is set, only resources pinned
+// to that version are upgraded. A dry run reports without writing.
+type SchemaMigrationRequest struct {
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// Validate checks the request's versions
+func (r *SchemaMigrationRequest) Validate() error {
+	for _, version := range []string{r.From, r.To} {
+		if version == "" {
+			continue
+		}
+		if _, err := ParseSchemaVersion(version); err != nil {
+			return err
+		}
+	}
+	if r.From != "" && r.To != "" && CompareSchemaVersions(r.From, r.To) >= 0 {
+		return fmt.Errorf("cannot migrate from version %s to %s: resources can only be upgraded", r.From, r.To)
+	}
+	return nil
+}
+
+// SchemaMigrationReport summarizes a schema migration. Resources already at
+// the target version, pinned to another From version, or pinned to a newer
+// version are skipped; those whose upgraded data is rejected by the target
+// schema are left unchanged and reported as failed.
+type SchemaMigrationReport struct {
+	Schema   string                   `json:"schema"`
+	Provider string                   `json:"provider"`
+	Type     string                   `json:"type"`
+	To       string                   `json:"to"`
+	DryRun   bool                     `json:"dry_run,omitempty"`
+	Scanned  int                      `json:"scanned"`
+	Migrated int                      `json:"migrated"`
+	Skipped  int                      `json:"skipped"`
+	Failed   int                      `json:"failed"`
+	Failures []SchemaMigrationFailure `json:"failures,omitempty"`
+}
+
+// SchemaMigrationFailure is a resource a migration could not upgrade
+type SchemaMigrationFailure struct {
+	ResourceID string `json:"resource_id"`
+	From       string `json:"from"`
+	Error      string `json:"error"`
+}
+
+// Statuses of the schemas in a SchemaCatalogReport
+const (
+	SchemaCatalogCreated   = "created"
+	SchemaCatalogUpdated   = "updated"
+	SchemaCatalogUnchanged = "unchanged"
+	SchemaCatalogSkipped   = "skipped"
+	SchemaCatalogFailed    = "failed"
+)
+
+// SchemaCatalogReport summarizes an import of predefined schemas from
+// Terraform provider schemas. Unchanged schemas are counted but not listed.
+type SchemaCatalogReport struct {
+	DryRun    bool                 `json:"dry_run,omitempty"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Skipped   int                  `json:"skipped"`
+	Failed    int                  `json:"failed"`
+	Schemas   []SchemaCatalogEntry `json:"schemas"`
+}
+
+// SchemaCatalogEntry is the outcome for one Siros resource type. An updated
+// schema reports its changes from the previous version; a skipped one
+// names the schema already registered for the type in Error.
+type SchemaCatalogEntry struct {
+	Name            string              `json:"name"`
+	Provider        string              `json:"provider"`
+	Type            string              `json:"type"`
+	TerraformTypes  []string            `json:"terraform_types"`
+	Status          string              `json:"status"`
+	Version         string              `json:"version,omitempty"`
+	PreviousVersion string              `json:"previous_version,omitempty"`
+	Compatibility   string              `json:"compatibility,omitempty"`
+	Changes         []jsonschema.Change `json:"changes,omitempty"`
+	Error           string              `json:"error,omitempty"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareSchemaVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1", b: "1.0", want: 0},
+		{a: "1.2", b: "1.10", want: -1},
+		{a: "2", b: "1.9.9", want: 1},
+		{a: "1.0.1", b: "1", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareSchemaVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareSchemaVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	for _, version := range []string{"", "v1", "1.2.3.4", "01", "1.-2"} {
+		if _, err := ParseSchemaVersion(version); err == nil {
+			t.Errorf("ParseSchemaVersion(%q) accepted an invalid version", version)
+		}
+	}
+}
+
+func TestSchemaTransform_Apply(t *testing.T) {
+	transform := &SchemaTransform{
+		Renames: map[string]string{
+			"/size":    "/disk/size_gb",
+			"/missing": "/other",
+			"/zone":    "/region",
+		},
+		Defaults: map[string]interface{}{
+			"/tier":         "standard",
+			"/disk/tags":    map[string]interface{}{"managed": true},
+			"/disk/size_gb": 0,
+		},
+	}
+	if err := transform.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	data := map[string]interface{}{"size": 20.0, "zone": "a", "region": "eu"}
+	applied, err := transform.Apply(data)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"disk":   map[string]interface{}{"size_gb": 20.0, "tags": map[string]interface{}{"managed": true}},
+		"zone":   "a",
+		"region": "eu",
+		"tier":   "standard",
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Apply() data = %v, want %v", data, want)
+	}
+	wantApplied := []string{"renamed /size to /disk/size_gb", "defaulted /disk/tags", "defaulted /tier"}
+	if !reflect.DeepEqual(applied, wantApplied) {
+		t.Errorf("Apply() changes = %v, want %v", applied, wantApplied)
+	}
+
+	if _, err := (&SchemaTransform{Defaults: map[string]interface{}{"/zone/name": "x"}}).Apply(data); err == nil {
+		t.Error("Apply() set a default below a value that is not an object")
+	}
+	if err := (&SchemaTransform{Renames: map[string]string{"size": "/size"}}).Validate(); err == nil {
+		t.Error("Validate() accepted a path that is not a JSON pointer")
+	}
+}
@@ -3,39 +3,252 @@ package providers
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/LederWorks/siros/backend/internal/config"
 	"github.com/LederWorks/siros/backend/pkg/types"
 )
 
+const (
+	awsDefaultRegion      = "us-east-1"
+	awsDefaultConcurrency = 8
+	awsRoleSessionName    = "siros-scan"
+)
+
+// awsEC2API is the subset of the EC2 client used by the AWS provider
+type awsEC2API interface {
+	ec2.DescribeInstancesAPIClient
+	ec2.DescribeVpcsAPIClient
+	ec2.DescribeSubnetsAPIClient
+	ec2.DescribeSecurityGroupsAPIClient
+	ec2.DescribeSecurityGroupRulesAPIClient
+	DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error)
+}
+
+// awsS3API is the subset of the S3 client used by the AWS provider
+type awsS3API interface {
+	s3.ListBucketsAPIClient
+	GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error)
+}
+
+// awsRDSAPI is the subset of the RDS client used by the AWS provider
+type awsRDSAPI interface {
+	rds.DescribeDBInstancesAPIClient
+}
+
+// awsIAMAPI is the subset of the IAM client used by the AWS provider
+type awsIAMAPI interface {
+	iam.ListRolesAPIClient
+	iam.ListPoliciesAPIClient
+	ListRoleTags(ctx context.Context, params *iam.ListRoleTagsInput, optFns ...func(*iam.Options)) (*iam.ListRoleTagsOutput, error)
+	ListPolicyTags(ctx context.Context, params *iam.ListPolicyTagsInput, optFns ...func(*iam.Options)) (*iam.ListPolicyTagsOutput, error)
+}
+
+// awsLambdaAPI is the subset of the Lambda client used by the AWS provider
+type awsLambdaAPI interface {
+	lambda.ListFunctionsAPIClient
+}
+
+// awsEKSAPI is the subset of the EKS client used by the AWS provider
+type awsEKSAPI interface {
+	eks.ListClustersAPIClient
+	eks.DescribeClusterAPIClient
+}
+
+// awsELBv2API is the subset of the ELBv2 client used by the AWS provider
+type awsELBv2API interface {
+	elbv2.DescribeLoadBalancersAPIClient
+}
+
+// awsDynamoDBAPI is the subset of the DynamoDB client used by the AWS provider
+type awsDynamoDBAPI interface {
+	dynamodb.ListTablesAPIClient
+	dynamodb.DescribeTableAPIClient
+}
+
+// awsSQSAPI is the subset of the SQS client used by the AWS provider
+type awsSQSAPI interface {
+	sqs.ListQueuesAPIClient
+}
+
+// awsSNSAPI is the subset of the SNS client used by the AWS provider
+type awsSNSAPI interface {
+	sns.ListTopicsAPIClient
+}
+
+// awsKMSAPI is the subset of the KMS client used by the AWS provider
+type awsKMSAPI interface {
+	kms.ListKeysAPIClient
+	kms.ListAliasesAPIClient
+	DescribeKey(ctx context.Context, params *kms.DescribeKeyInput, optFns ...func(*kms.Options)) (*kms.DescribeKeyOutput, error)
+}
+
+// awsSTSAPI is the subset of the STS client used by the AWS provider
+type awsSTSAPI interface {
+	stscreds.AssumeRoleAPIClient
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// awsClientFactory builds service clients for a resolved aws.Config. Tests
+// replace it to serve recorded responses instead of calling AWS.
+type awsClientFactory interface {
+	EC2(cfg aws.Config) awsEC2API
+	S3(cfg aws.Config) awsS3API
+	RDS(cfg aws.Config) awsRDSAPI
+	IAM(cfg aws.Config) awsIAMAPI
+	Lambda(cfg aws.Config) awsLambdaAPI
+	EKS(cfg aws.Config) awsEKSAPI
+	ELBv2(cfg aws.Config) awsELBv2API
+	DynamoDB(cfg aws.Config) awsDynamoDBAPI
+	SQS(cfg aws.Config) awsSQSAPI
+	SNS(cfg aws.Config) awsSNSAPI
+	KMS(cfg aws.Config) awsKMSAPI
+	STS(cfg aws.Config) awsSTSAPI
+}
+
+// sdkClientFactory creates real AWS SDK clients
+type sdkClientFactory struct{}
+
+func (sdkClientFactory) EC2(cfg aws.Config) awsEC2API           { return ec2.NewFromConfig(cfg) }
+func (sdkClientFactory) S3(cfg aws.Config) awsS3API             { return s3.NewFromConfig(cfg) }
+func (sdkClientFactory) RDS(cfg aws.Config) awsRDSAPI           { return rds.NewFromConfig(cfg) }
+func (sdkClientFactory) IAM(cfg aws.Config) awsIAMAPI           { return iam.NewFromConfig(cfg) }
+func (sdkClientFactory) Lambda(cfg aws.Config) awsLambdaAPI     { return lambda.NewFromConfig(cfg) }
+func (sdkClientFactory) EKS(cfg aws.Config) awsEKSAPI           { return eks.NewFromConfig(cfg) }
+func (sdkClientFactory) ELBv2(cfg aws.Config) awsELBv2API       { return elbv2.NewFromConfig(cfg) }
+func (sdkClientFactory) DynamoDB(cfg aws.Config) awsDynamoDBAPI { return dynamodb.NewFromConfig(cfg) }
+func (sdkClientFactory) SQS(cfg aws.Config) awsSQSAPI           { return sqs.NewFromConfig(cfg) }
+func (sdkClientFactory) SNS(cfg aws.Config) awsSNSAPI           { return sns.NewFromConfig(cfg) }
+func (sdkClientFactory) KMS(cfg aws.Config) awsKMSAPI           { return kms.NewFromConfig(cfg) }
+func (sdkClientFactory) STS(cfg aws.Config) awsSTSAPI           { return sts.NewFromConfig(cfg) }
+
+// awsAccount is an account whose credentials have been resolved
+type awsAccount struct {
+	id   string
+	name string
+	cfg  aws.Config
+}
+
+// awsScope identifies the account and region a scanner runs against
+type awsScope struct {
+	cfg       aws.Config
+	accountID string
+	region    string
+	partition string
+}
+
+// arn builds an ARN for a resource within the scope
+func (s awsScope) arn(service, resource string) string {
+	return arn.ARN{
+		Partition: s.partition,
+		Service:   service,
+		Region:    s.region,
+		AccountID: s.accountID,
+		Resource:  resource,
+	}.String()
+}
+
+// awsScanner discovers one kind of resource. Global scanners run once per
+// account from the home region; all others run once per account and region.
+type awsScanner struct {
+	service string
+	global  bool
+	scan    func(p *AWSProvider, ctx context.Context, scope awsScope) ([]types.Resource, error)
+}
+
+// awsScanners lists every scanner run by AWSProvider.Scan
+var awsScanners = []awsScanner{
+	{service: "ec2.instance", scan: (*AWSProvider).scanEC2Instances},
+	{service: "s3.bucket", global: true, scan: (*AWSProvider).scanS3Buckets},
+	{service: "rds.instance", scan: (*AWSProvider).scanRDSInstances},
+	{service: "ec2.vpc", scan: (*AWSProvider).scanVPCs},
+	{service: "ec2.subnet", scan: (*AWSProvider).scanSubnets},
+	{service: "ec2.security_group", scan: (*AWSProvider).scanSecurityGroups},
+	{service: "iam.role", global: true, scan: (*AWSProvider).scanIAMRoles},
+	{service: "iam.policy", global: true, scan: (*AWSProvider).scanIAMPolicies},
+	{service: "lambda.function", scan: (*AWSProvider).scanLambdaFunctions},
+	{service: "eks.cluster", scan: (*AWSProvider).scanEKSClusters},
+	{service: "elbv2.load_balancer", scan: (*AWSProvider).scanLoadBalancers},
+	{service: "dynamodb.table", scan: (*AWSProvider).scanDynamoDBTables},
+	{service: "sqs.queue", scan: (*AWSProvider).scanSQSQueues},
+	{service: "sns.topic", scan: (*AWSProvider).scanSNSTopics},
+	{service: "kms.key", scan: (*AWSProvider).scanKMSKeys},
+}
+
+// newAWSResource creates a resource with the fields every AWS scanner shares.
+// Scanners fill in tags, state and type-specific metadata.
+func newAWSResource(scope awsScope, resourceType, id, name, resourceARN string) types.Resource {
+	now := time.Now()
+	return types.Resource{
+		ID:        id,
+		Type:      resourceType,
+		Provider:  "aws",
+		Region:    scope.region,
+		Name:      name,
+		ARN:       resourceARN,
+		Tags:      make(map[string]string),
+		Metadata:  map[string]interface{}{"account_id": scope.accountID},
+		State:     types.ResourceStateActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
 // AWSProvider implements the Provider interface for AWS
 type AWSProvider struct {
-	config config.AWSConfig
-	awsCfg aws.Config
+	config  config.AWSConfig
+	awsCfg  aws.Config
+	clients awsClientFactory
 }
 
 // NewAWSProvider creates a new AWS provider
 func NewAWSProvider(cfg config.AWSConfig) (*AWSProvider, error) {
-	// Load AWS configuration
-	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(),
+	if cfg.Region == "" {
+		cfg.Region = awsDefaultRegion
+	}
+
+	opts := []func(*awsconfig.LoadOptions) error{
 		awsconfig.WithRegion(cfg.Region),
-	)
+	}
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken),
+		))
+	}
+
+	// Load AWS configuration
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
 	return &AWSProvider{
-		config: cfg,
-		awsCfg: awsCfg,
+		config:  cfg,
+		awsCfg:  awsCfg,
+		clients: sdkClientFactory{},
 	}, nil
 }
 
@@ -46,184 +259,549 @@ func (p *AWSProvider) Name() string {
 
 // Validate validates the AWS configuration
 func (p *AWSProvider) Validate() error {
+	for i := range p.config.Accounts {
+		acct := &p.config.Accounts[i]
+		if acct.ID == "" && acct.RoleARN == "" {
+			return fmt.Errorf("AWS account %d requires an id or role_arn", i)
+		}
+		if acct.RoleARN == "" && p.config.RoleName == "" {
+			return fmt.Errorf("AWS account %s requires role_arn or a provider-level role_name", acct.ID)
+		}
+	}
+
 	// Test AWS credentials by making a simple API call
-	ec2Client := ec2.NewFromConfig(p.awsCfg)
-	_, err := ec2Client.DescribeRegions(context.Background(), &ec2.DescribeRegionsInput{})
-	if err != nil {
+	if _, err := p.clients.STS(p.awsCfg).GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{}); err != nil {
 		return fmt.Errorf("AWS credential validation failed: %w", err)
 	}
 	return nil
 }
 
-// Scan scans AWS for resources
+// Scan scans every configured account and enabled region for resources.
+// Failures in individual accounts, regions or services are isolated and
+// reported through a *ScanError alongside the resources that were found.
 func (p *AWSProvider) Scan(ctx context.Context) ([]types.Resource, error) {
-	var resources []types.Resource
+	return collectScan(ctx, p.ScanStream)
+}
 
-	// Scan EC2 instances
-	ec2Resources, err := p.scanEC2Instances(ctx)
+// ScanStream is Scan, sending each service's resources to out as soon as the
+// service has been listed
+func (p *AWSProvider) ScanStream(ctx context.Context, out chan<- types.Resource) error {
+	accounts, failures, err := p.resolveAccounts(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan EC2 instances: %w", err)
+		return err
 	}
-	resources = append(resources, ec2Resources...)
 
-	// Scan S3 buckets
-	s3Resources, err := p.scanS3Buckets(ctx)
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	sem := make(chan struct{}, p.concurrency())
+
+	record := func(found []types.Resource, failure *ScanFailure) {
+		// A cancelled send means the whole scan is being abandoned
+		_ = sendResources(ctx, out, found)
+		if failure != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			failures = append(failures, *failure)
+		}
+	}
+
+	run := func(scanner awsScanner, scope awsScope) {
+		defer wg.Done()
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			record(nil, &ScanFailure{Account: scope.accountID, Region: scope.region, Service: scanner.service, Err: ctx.Err()})
+			return
+		}
+		defer func() { <-sem }()
+
+		found, err := scanner.scan(p, ctx, scope)
+		if err != nil {
+			record(found, &ScanFailure{Account: scope.accountID, Region: scope.region, Service: scanner.service, Err: err})
+			return
+		}
+		record(found, nil)
+	}
+
+	for i := range accounts {
+		acct := &accounts[i]
+
+		regions, err := p.resolveRegions(ctx, acct.cfg)
+		if err != nil {
+			record(nil, &ScanFailure{Account: acct.id, Service: "ec2.regions", Err: err})
+			continue
+		}
+
+		for _, scanner := range awsScanners {
+			if scanner.global {
+				wg.Add(1)
+				go run(scanner, p.scope(acct, p.config.Region))
+				continue
+			}
+			for _, region := range regions {
+				wg.Add(1)
+				go run(scanner, p.scope(acct, region))
+			}
+		}
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		for _, f := range failures {
+			log.Printf("AWS scan failure: %v", f)
+		}
+		return &ScanError{Provider: p.Name(), Failures: failures}
+	}
+	return nil
+}
+
+// concurrency returns the configured scan concurrency
+func (p *AWSProvider) concurrency() int {
+	if p.config.MaxConcurrency > 0 {
+		return p.config.MaxConcurrency
+	}
+	return awsDefaultConcurrency
+}
+
+// scope creates a scan scope for an account and region
+func (p *AWSProvider) scope(acct *awsAccount, region string) awsScope {
+	cfg := acct.cfg.Copy()
+	cfg.Region = region
+	return awsScope{
+		cfg:       cfg,
+		accountID: acct.id,
+		region:    region,
+		partition: AWSPartition(region),
+	}
+}
+
+// resolveAccounts resolves credentials for every configured account. Accounts
+// whose role cannot be assumed are reported as failures and skipped.
+func (p *AWSProvider) resolveAccounts(ctx context.Context) ([]awsAccount, []ScanFailure, error) {
+	if len(p.config.Accounts) == 0 {
+		acct, err := p.homeAccount(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve AWS account: %w", err)
+		}
+		return []awsAccount{*acct}, nil, nil
+	}
+
+	var (
+		accounts []awsAccount
+		failures []ScanFailure
+	)
+	for i := range p.config.Accounts {
+		acct, err := p.assumeAccount(ctx, &p.config.Accounts[i])
+		if err != nil {
+			failures = append(failures, ScanFailure{Account: p.config.Accounts[i].ID, Service: "sts", Err: err})
+			continue
+		}
+		accounts = append(accounts, *acct)
+	}
+
+	if len(accounts) == 0 {
+		return nil, nil, &ScanError{Provider: p.Name(), Failures: failures}
+	}
+	return accounts, failures, nil
+}
+
+// homeAccount returns the account owning the base credentials
+func (p *AWSProvider) homeAccount(ctx context.Context) (*awsAccount, error) {
+	identity, err := p.clients.STS(p.awsCfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan S3 buckets: %w", err)
+		return nil, err
+	}
+	return &awsAccount{
+		id:  aws.ToString(identity.Account),
+		cfg: p.awsCfg,
+	}, nil
+}
+
+// assumeAccount assumes the role configured for an account and verifies it
+func (p *AWSProvider) assumeAccount(ctx context.Context, acct *config.AWSAccountConfig) (*awsAccount, error) {
+	roleARN := acct.RoleARN
+	if roleARN == "" {
+		roleARN = arn.ARN{
+			Partition: AWSPartition(p.config.Region),
+			Service:   "iam",
+			AccountID: acct.ID,
+			Resource:  "role/" + p.config.RoleName,
+		}.String()
 	}
-	resources = append(resources, s3Resources...)
 
-	// Scan RDS instances
-	rdsResources, err := p.scanRDSInstances(ctx)
+	externalID := acct.ExternalID
+	if externalID == "" {
+		externalID = p.config.ExternalID
+	}
+
+	provider := stscreds.NewAssumeRoleProvider(p.clients.STS(p.awsCfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = awsRoleSessionName
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+
+	cfg := p.awsCfg.Copy()
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+
+	identity, err := p.clients.STS(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan RDS instances: %w", err)
+		return nil, fmt.Errorf("failed to assume role %s: %w", roleARN, err)
 	}
-	resources = append(resources, rdsResources...)
 
-	return resources, nil
+	accountID := aws.ToString(identity.Account)
+	if acct.ID != "" && accountID != acct.ID {
+		return nil, fmt.Errorf("role %s belongs to account %s, expected %s", roleARN, accountID, acct.ID)
+	}
+
+	return &awsAccount{id: accountID, name: acct.Name, cfg: cfg}, nil
 }
 
-// scanEC2Instances scans for EC2 instances
-func (p *AWSProvider) scanEC2Instances(ctx context.Context) ([]types.Resource, error) {
-	ec2Client := ec2.NewFromConfig(p.awsCfg)
+// resolveRegions returns the configured regions or every region enabled for the account
+func (p *AWSProvider) resolveRegions(ctx context.Context, cfg aws.Config) ([]string, error) {
+	if len(p.config.Regions) > 0 {
+		return p.config.Regions, nil
+	}
 
-	result, err := ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+	// Without AllRegions, DescribeRegions only returns regions enabled for the account
+	result, err := p.clients.EC2(cfg).DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
 	if err != nil {
 		return nil, err
 	}
 
+	regions := make([]string, 0, len(result.Regions))
+	for _, region := range result.Regions {
+		regions = append(regions, aws.ToString(region.RegionName))
+	}
+	return regions, nil
+}
+
+// scanEC2Instances scans for EC2 instances
+func (p *AWSProvider) scanEC2Instances(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := ec2.NewDescribeInstancesPaginator(p.clients.EC2(scope.cfg), &ec2.DescribeInstancesInput{})
+
 	var resources []types.Resource
-	for _, reservation := range result.Reservations {
-		for i := range reservation.Instances {
-			instance := &reservation.Instances[i] // Pointer iteration to avoid 688-byte copy
-			resource := types.Resource{
-				ID:       aws.ToString(instance.InstanceId),
-				Type:     "ec2.instance",
-				Provider: "aws",
-				Region:   p.config.Region,
-				Name:     p.getInstanceName(instance.Tags),
-				ARN:      fmt.Sprintf("arn:aws:ec2:%s::instance/%s", p.config.Region, aws.ToString(instance.InstanceId)),
-				Tags:     p.convertEC2Tags(instance.Tags),
-				Metadata: map[string]interface{}{
-					"instance_type":   string(instance.InstanceType),
-					"state":           string(instance.State.Name),
-					"vpc_id":          aws.ToString(instance.VpcId),
-					"subnet_id":       aws.ToString(instance.SubnetId),
-					"private_ip":      aws.ToString(instance.PrivateIpAddress),
-					"public_ip":       aws.ToString(instance.PublicIpAddress),
-					"launch_time":     instance.LaunchTime,
-					"security_groups": p.convertSecurityGroups(instance.SecurityGroups),
-				},
-				State:     p.convertEC2State(instance.State.Name),
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for _, reservation := range page.Reservations {
+			for i := range reservation.Instances {
+				instance := &reservation.Instances[i] // Pointer iteration to avoid 688-byte copy
+				resources = append(resources, p.ec2InstanceResource(scope, instance))
 			}
-			resources = append(resources, resource)
 		}
 	}
 
 	return resources, nil
 }
 
-// scanS3Buckets scans for S3 buckets
-func (p *AWSProvider) scanS3Buckets(ctx context.Context) ([]types.Resource, error) {
-	s3Client := s3.NewFromConfig(p.awsCfg)
+// ec2InstanceResource converts an EC2 instance to a Siros resource
+func (p *AWSProvider) ec2InstanceResource(scope awsScope, instance *ec2types.Instance) types.Resource {
+	var state ec2types.InstanceStateName
+	if instance.State != nil {
+		state = instance.State.Name
+	}
 
-	result, err := s3Client.ListBuckets(ctx, &s3.ListBucketsInput{})
-	if err != nil {
-		return nil, err
+	now := time.Now()
+	return types.Resource{
+		ID:       aws.ToString(instance.InstanceId),
+		Type:     "ec2.instance",
+		Provider: "aws",
+		Region:   scope.region,
+		Name:     p.getInstanceName(instance.Tags),
+		ARN:      scope.arn("ec2", "instance/"+aws.ToString(instance.InstanceId)),
+		Tags:     p.convertEC2Tags(instance.Tags),
+		Metadata: map[string]interface{}{
+			"account_id":      scope.accountID,
+			"instance_type":   string(instance.InstanceType),
+			"state":           string(state),
+			"vpc_id":          aws.ToString(instance.VpcId),
+			"subnet_id":       aws.ToString(instance.SubnetId),
+			"private_ip":      aws.ToString(instance.PrivateIpAddress),
+			"public_ip":       aws.ToString(instance.PublicIpAddress),
+			"launch_time":     instance.LaunchTime,
+			"security_groups": p.convertSecurityGroups(instance.SecurityGroups),
+		},
+		State:     p.convertEC2State(state),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
+}
+
+// scanS3Buckets scans for S3 buckets. Bucket listing is global, so this runs
+// once per account and resolves each bucket's region individually.
+func (p *AWSProvider) scanS3Buckets(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	s3Client := p.clients.S3(scope.cfg)
+	paginator := s3.NewListBucketsPaginator(s3Client, &s3.ListBucketsInput{})
 
 	var resources []types.Resource
-	for _, bucket := range result.Buckets {
-		bucketName := aws.ToString(bucket.Name)
-
-		// Get bucket location
-		location, err := s3Client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
-			Bucket: bucket.Name,
-		})
-		region := p.config.Region
-		if err == nil && location.LocationConstraint != "" {
-			region = string(location.LocationConstraint)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
 		}
 
-		resource := types.Resource{
-			ID:       bucketName,
-			Type:     "s3.bucket",
-			Provider: "aws",
-			Region:   region,
-			Name:     bucketName,
-			ARN:      fmt.Sprintf("arn:aws:s3:::%s", bucketName),
-			Tags:     make(map[string]string),
-			Metadata: map[string]interface{}{
-				"creation_date": bucket.CreationDate,
-			},
-			State:     types.ResourceStateActive,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+		for _, bucket := range page.Buckets {
+			bucketName := aws.ToString(bucket.Name)
+
+			region := aws.ToString(bucket.BucketRegion)
+			if region == "" {
+				location, err := s3Client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+					Bucket: bucket.Name,
+				})
+				switch {
+				case err != nil:
+					region = scope.region
+				case location.LocationConstraint == "":
+					// An empty constraint means the bucket lives in us-east-1
+					region = awsDefaultRegion
+				default:
+					region = string(location.LocationConstraint)
+				}
+			}
+
+			now := time.Now()
+			resources = append(resources, types.Resource{
+				ID:       bucketName,
+				Type:     "s3.bucket",
+				Provider: "aws",
+				Region:   region,
+				Name:     bucketName,
+				ARN:      arn.ARN{Partition: scope.partition, Service: "s3", Resource: bucketName}.String(),
+				Tags:     make(map[string]string),
+				Metadata: map[string]interface{}{
+					"account_id":    scope.accountID,
+					"creation_date": bucket.CreationDate,
+				},
+				State:     types.ResourceStateActive,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
 		}
-		resources = append(resources, resource)
 	}
 
 	return resources, nil
 }
 
 // scanRDSInstances scans for RDS instances
-func (p *AWSProvider) scanRDSInstances(ctx context.Context) ([]types.Resource, error) {
-	rdsClient := rds.NewFromConfig(p.awsCfg)
-
-	result, err := rdsClient.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{})
-	if err != nil {
-		return nil, err
-	}
+func (p *AWSProvider) scanRDSInstances(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := rds.NewDescribeDBInstancesPaginator(p.clients.RDS(scope.cfg), &rds.DescribeDBInstancesInput{})
 
 	var resources []types.Resource
-	for i := range result.DBInstances {
-		instance := &result.DBInstances[i] // Pointer iteration to avoid 960-byte copy
-		resource := types.Resource{
-			ID:       aws.ToString(instance.DBInstanceIdentifier),
-			Type:     "rds.instance",
-			Provider: "aws",
-			Region:   p.config.Region,
-			Name:     aws.ToString(instance.DBInstanceIdentifier),
-			ARN:      aws.ToString(instance.DBInstanceArn),
-			Tags:     make(map[string]string), // RDS tags require separate API call
-			Metadata: map[string]interface{}{
-				"engine":         aws.ToString(instance.Engine),
-				"engine_version": aws.ToString(instance.EngineVersion),
-				"instance_class": aws.ToString(instance.DBInstanceClass),
-				"status":         aws.ToString(instance.DBInstanceStatus),
-				"endpoint":       aws.ToString(instance.Endpoint.Address),
-				"port":           instance.Endpoint.Port,
-				"storage":        instance.AllocatedStorage,
-				"storage_type":   aws.ToString(instance.StorageType),
-			},
-			State:     p.convertRDSState(aws.ToString(instance.DBInstanceStatus)),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.DBInstances {
+			instance := &page.DBInstances[i] // Pointer iteration to avoid 960-byte copy
+			resources = append(resources, p.rdsInstanceResource(scope, instance))
 		}
-		resources = append(resources, resource)
 	}
 
 	return resources, nil
 }
 
-// GetResource retrieves a specific resource by ID
+// rdsInstanceResource converts an RDS instance to a Siros resource
+func (p *AWSProvider) rdsInstanceResource(scope awsScope, instance *rdstypes.DBInstance) types.Resource {
+	identifier := aws.ToString(instance.DBInstanceIdentifier)
+
+	resourceARN := aws.ToString(instance.DBInstanceArn)
+	if resourceARN == "" {
+		resourceARN = scope.arn("rds", "db:"+identifier)
+	}
+
+	metadata := map[string]interface{}{
+		"account_id":     scope.accountID,
+		"engine":         aws.ToString(instance.Engine),
+		"engine_version": aws.ToString(instance.EngineVersion),
+		"instance_class": aws.ToString(instance.DBInstanceClass),
+		"status":         aws.ToString(instance.DBInstanceStatus),
+		"storage":        instance.AllocatedStorage,
+		"storage_type":   aws.ToString(instance.StorageType),
+	}
+	if instance.Endpoint != nil {
+		metadata["endpoint"] = aws.ToString(instance.Endpoint.Address)
+		metadata["port"] = instance.Endpoint.Port
+	}
+
+	tags := make(map[string]string, len(instance.TagList))
+	for _, tag := range instance.TagList {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	now := time.Now()
+	return types.Resource{
+		ID:        identifier,
+		Type:      "rds.instance",
+		Provider:  "aws",
+		Region:    scope.region,
+		Name:      identifier,
+		ARN:       resourceARN,
+		Tags:      tags,
+		Metadata:  metadata,
+		State:     p.convertRDSState(aws.ToString(instance.DBInstanceStatus)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// GetResource retrieves a specific resource by ARN or EC2 instance ID
 func (p *AWSProvider) GetResource(id string) (*types.Resource, error) {
+	ctx := context.Background()
+
+	if arn.IsARN(id) {
+		return p.getResourceByARN(ctx, id)
+	}
+
 	// Determine resource type from ID and fetch accordingly
 	if strings.HasPrefix(id, "i-") {
-		return p.getEC2Instance(id)
+		acct, err := p.homeAccount(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve AWS account: %w", err)
+		}
+		return p.getEC2Instance(ctx, p.scope(acct, p.config.Region), id)
 	}
 
-	// For S3 buckets and RDS, we'd need different logic
+	// For S3 buckets and RDS, an ARN is needed to locate the account and region
 	return nil, fmt.Errorf("resource type not supported for direct fetch: %s", id)
 }
 
-// getEC2Instance retrieves a specific EC2 instance
-func (p *AWSProvider) getEC2Instance(instanceID string) (*types.Resource, error) {
-	ec2Client := ec2.NewFromConfig(p.awsCfg)
+// getResourceByARN fetches a resource in the account and region named by its ARN
+func (p *AWSProvider) getResourceByARN(ctx context.Context, resourceARN string) (*types.Resource, error) {
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ARN %s: %w", resourceARN, err)
+	}
+
+	acct, err := p.accountFor(ctx, parsed.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	region := parsed.Region
+	if region == "" {
+		region = p.config.Region
+	}
+	scope := p.scope(acct, region)
+
+	switch {
+	case parsed.Service == "ec2" && strings.HasPrefix(parsed.Resource, "instance/"):
+		return p.getEC2Instance(ctx, scope, strings.TrimPrefix(parsed.Resource, "instance/"))
+	case parsed.Service == "rds" && strings.HasPrefix(parsed.Resource, "db:"):
+		return p.getRDSInstance(ctx, scope, strings.TrimPrefix(parsed.Resource, "db:"))
+	case parsed.Service == "s3" && !strings.Contains(parsed.Resource, "/"):
+		return p.getS3Bucket(ctx, scope, parsed.Resource)
+	}
+
+	scanner, ok := awsScannerForARN(parsed)
+	if !ok {
+		return nil, fmt.Errorf("resource type not supported for direct fetch: %s", resourceARN)
+	}
+	if parsed.Service == "lambda" {
+		// Qualified function ARNs name a version or alias of the function
+		if name, _, qualified := strings.Cut(strings.TrimPrefix(parsed.Resource, "function:"), ":"); qualified {
+			parsed.Resource = "function:" + name
+			resourceARN = parsed.String()
+		}
+	}
+	return p.getScannedResource(ctx, scope, scanner, resourceARN)
+}
+
+// awsARNTypes maps the service and resource prefix of an ARN to the scanner
+// that emits it. An empty prefix matches ARNs whose resource is a bare name.
+var awsARNTypes = []struct {
+	service, prefix, scanner string
+}{
+	{"ec2", "vpc/", "ec2.vpc"},
+	{"ec2", "subnet/", "ec2.subnet"},
+	{"ec2", "security-group/", "ec2.security_group"},
+	{"iam", "role/", "iam.role"},
+	{"iam", "policy/", "iam.policy"},
+	{"lambda", "function:", "lambda.function"},
+	{"eks", "cluster/", "eks.cluster"},
+	{"elasticloadbalancing", "loadbalancer/", "elbv2.load_balancer"},
+	{"dynamodb", "table/", "dynamodb.table"},
+	{"sqs", "", "sqs.queue"},
+	{"sns", "", "sns.topic"},
+	{"kms", "key/", "kms.key"},
+}
+
+// awsScannerForARN returns the scanner that emits the resource an ARN names
+func awsScannerForARN(parsed arn.ARN) (awsScanner, bool) {
+	for _, t := range awsARNTypes {
+		if t.service != parsed.Service {
+			continue
+		}
+		if t.prefix == "" && strings.ContainsAny(parsed.Resource, "/:") {
+			continue
+		}
+		if !strings.HasPrefix(parsed.Resource, t.prefix) {
+			continue
+		}
+		for _, scanner := range awsScanners {
+			if scanner.service == t.scanner {
+				return scanner, true
+			}
+		}
+	}
+	return awsScanner{}, false
+}
+
+// getScannedResource runs a scanner in the scope and returns the resource
+// with the ARN, so it is described exactly as Scan describes it
+func (p *AWSProvider) getScannedResource(ctx context.Context, scope awsScope, scanner awsScanner, resourceARN string) (*types.Resource, error) {
+	resources, err := scanner.scan(p, ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", scanner.service, err)
+	}
+	for i := range resources {
+		if resources[i].ARN == resourceARN {
+			return &resources[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s not found: %s", scanner.service, resourceARN)
+}
+
+// accountFor returns resolved credentials for an account ID. An empty ID
+// (as in S3 ARNs) resolves to the home account.
+func (p *AWSProvider) accountFor(ctx context.Context, accountID string) (*awsAccount, error) {
+	if accountID != "" {
+		for i := range p.config.Accounts {
+			acct := &p.config.Accounts[i]
+			if acct.ID == accountID || roleAccount(acct.RoleARN) == accountID {
+				return p.assumeAccount(ctx, acct)
+			}
+		}
+	}
+
+	home, err := p.homeAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve AWS account: %w", err)
+	}
+	if accountID != "" && home.id != accountID {
+		return nil, fmt.Errorf("AWS account %s is not configured", accountID)
+	}
+	return home, nil
+}
 
-	result, err := ec2Client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{
+// roleAccount returns the account ID of a role ARN, or "" when there is no
+// role ARN or it does not parse
+func roleAccount(roleARN string) string {
+	parsed, err := arn.Parse(roleARN)
+	if err != nil {
+		return ""
+	}
+	return parsed.AccountID
+}
+
+// getEC2Instance retrieves a specific EC2 instance
+func (p *AWSProvider) getEC2Instance(ctx context.Context, scope awsScope, instanceID string) (*types.Resource, error) {
+	result, err := p.clients.EC2(scope.cfg).DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 		InstanceIds: []string{instanceID},
 	})
 	if err != nil {
@@ -234,33 +812,57 @@ func (p *AWSProvider) getEC2Instance(instanceID string) (*types.Resource, error)
 		return nil, fmt.Errorf("instance not found: %s", instanceID)
 	}
 
-	instance := result.Reservations[0].Instances[0]
-	resource := types.Resource{
-		ID:       aws.ToString(instance.InstanceId),
-		Type:     "ec2.instance",
-		Provider: "aws",
-		Region:   p.config.Region,
-		Name:     p.getInstanceName(instance.Tags),
-		ARN:      fmt.Sprintf("arn:aws:ec2:%s::instance/%s", p.config.Region, aws.ToString(instance.InstanceId)),
-		Tags:     p.convertEC2Tags(instance.Tags),
-		Metadata: map[string]interface{}{
-			"instance_type":   string(instance.InstanceType),
-			"state":           string(instance.State.Name),
-			"vpc_id":          aws.ToString(instance.VpcId),
-			"subnet_id":       aws.ToString(instance.SubnetId),
-			"private_ip":      aws.ToString(instance.PrivateIpAddress),
-			"public_ip":       aws.ToString(instance.PublicIpAddress),
-			"launch_time":     instance.LaunchTime,
-			"security_groups": p.convertSecurityGroups(instance.SecurityGroups),
-		},
-		State:     p.convertEC2State(instance.State.Name),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	resource := p.ec2InstanceResource(scope, &result.Reservations[0].Instances[0])
+	return &resource, nil
+}
+
+// getRDSInstance retrieves a specific RDS instance
+func (p *AWSProvider) getRDSInstance(ctx context.Context, scope awsScope, identifier string) (*types.Resource, error) {
+	result, err := p.clients.RDS(scope.cfg).DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(identifier),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.DBInstances) == 0 {
+		return nil, fmt.Errorf("DB instance not found: %s", identifier)
 	}
 
+	resource := p.rdsInstanceResource(scope, &result.DBInstances[0])
 	return &resource, nil
 }
 
+// getS3Bucket retrieves a specific S3 bucket
+func (p *AWSProvider) getS3Bucket(ctx context.Context, scope awsScope, bucket string) (*types.Resource, error) {
+	resources, err := p.scanS3Buckets(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resources {
+		if resources[i].ID == bucket {
+			return &resources[i], nil
+		}
+	}
+	return nil, fmt.Errorf("bucket not found: %s", bucket)
+}
+
+// AWSPartition returns the ARN partition for a region
+func AWSPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	case strings.HasPrefix(region, "us-iso-"):
+		return "aws-iso"
+	case strings.HasPrefix(region, "us-isob-"):
+		return "aws-iso-b"
+	default:
+		return "aws"
+	}
+}
+
 // Helper methods
 func (p *AWSProvider) getInstanceName(tags []ec2types.Tag) string {
 	for _, tag := range tags {
@@ -0,0 +1,182 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// awsGlobalRegion is the region recorded for account-wide resources such as IAM
+const awsGlobalRegion = "global"
+
+// awsIAMMaxTags is the most tags an IAM resource can carry, so one page of
+// ListRoleTags or ListPolicyTags holds them all
+const awsIAMMaxTags = 50
+
+// scanIAMRoles scans for IAM roles. IAM is global, so this runs once per
+// account. ListRoles omits tags, which are listed per role.
+func (p *AWSProvider) scanIAMRoles(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	client := p.clients.IAM(scope.cfg)
+	paginator := iam.NewListRolesPaginator(client, &iam.ListRolesInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.Roles {
+			role := &page.Roles[i]
+			tags, err := client.ListRoleTags(ctx, &iam.ListRoleTagsInput{RoleName: role.RoleName, MaxItems: aws.Int32(awsIAMMaxTags)})
+			if err != nil {
+				return resources, fmt.Errorf("failed to list tags of IAM role %s: %w", aws.ToString(role.RoleName), err)
+			}
+
+			resource := newAWSResource(scope, "iam.role", aws.ToString(role.RoleId), aws.ToString(role.RoleName), aws.ToString(role.Arn))
+			resource.Region = awsGlobalRegion
+			resource.Tags = convertIAMTags(tags.Tags)
+			resource.Metadata["path"] = aws.ToString(role.Path)
+			resource.Metadata["description"] = aws.ToString(role.Description)
+			resource.Metadata["create_date"] = role.CreateDate
+			resource.Metadata["max_session_duration"] = aws.ToInt32(role.MaxSessionDuration)
+			resource.Metadata["assume_role_policy"] = aws.ToString(role.AssumeRolePolicyDocument)
+			if role.PermissionsBoundary != nil {
+				resource.Metadata["permissions_boundary"] = aws.ToString(role.PermissionsBoundary.PermissionsBoundaryArn)
+			}
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanIAMPolicies scans for customer managed IAM policies. AWS managed
+// policies are shared by every account and are not inventoried. ListPolicies
+// omits tags, which are listed per policy.
+func (p *AWSProvider) scanIAMPolicies(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	client := p.clients.IAM(scope.cfg)
+	paginator := iam.NewListPoliciesPaginator(client, &iam.ListPoliciesInput{
+		Scope: iamtypes.PolicyScopeTypeLocal,
+	})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.Policies {
+			policy := &page.Policies[i]
+			tags, err := client.ListPolicyTags(ctx, &iam.ListPolicyTagsInput{PolicyArn: policy.Arn, MaxItems: aws.Int32(awsIAMMaxTags)})
+			if err != nil {
+				return resources, fmt.Errorf("failed to list tags of IAM policy %s: %w", aws.ToString(policy.PolicyName), err)
+			}
+
+			resource := newAWSResource(scope, "iam.policy", aws.ToString(policy.PolicyId), aws.ToString(policy.PolicyName), aws.ToString(policy.Arn))
+			resource.Region = awsGlobalRegion
+			resource.Tags = convertIAMTags(tags.Tags)
+			resource.Metadata["path"] = aws.ToString(policy.Path)
+			resource.Metadata["description"] = aws.ToString(policy.Description)
+			resource.Metadata["default_version"] = aws.ToString(policy.DefaultVersionId)
+			resource.Metadata["attachment_count"] = aws.ToInt32(policy.AttachmentCount)
+			resource.Metadata["attachable"] = policy.IsAttachable
+			resource.Metadata["create_date"] = policy.CreateDate
+			resource.Metadata["update_date"] = policy.UpdateDate
+			if aws.ToInt32(policy.AttachmentCount) == 0 {
+				resource.State = types.ResourceStateInactive
+			}
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanKMSKeys scans for KMS keys and resolves their aliases
+func (p *AWSProvider) scanKMSKeys(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	client := p.clients.KMS(scope.cfg)
+
+	aliases := make(map[string][]string)
+	aliasPages := kms.NewListAliasesPaginator(client, &kms.ListAliasesInput{})
+	for aliasPages.HasMorePages() {
+		page, err := aliasPages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list KMS aliases: %w", err)
+		}
+		for _, alias := range page.Aliases {
+			if keyID := aws.ToString(alias.TargetKeyId); keyID != "" {
+				aliases[keyID] = append(aliases[keyID], aws.ToString(alias.AliasName))
+			}
+		}
+	}
+
+	paginator := kms.NewListKeysPaginator(client, &kms.ListKeysInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for _, entry := range page.Keys {
+			keyID := aws.ToString(entry.KeyId)
+
+			described, err := client.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: entry.KeyId})
+			if err != nil {
+				return resources, fmt.Errorf("failed to describe KMS key %s: %w", keyID, err)
+			}
+			key := described.KeyMetadata
+			if key == nil {
+				continue
+			}
+
+			name := keyID
+			if keyAliases := aliases[keyID]; len(keyAliases) > 0 {
+				name = keyAliases[0]
+			}
+
+			resource := newAWSResource(scope, "kms.key", keyID, name, aws.ToString(key.Arn))
+			resource.State = convertKMSKeyState(key.KeyState)
+			resource.Metadata["aliases"] = aliases[keyID]
+			resource.Metadata["description"] = aws.ToString(key.Description)
+			resource.Metadata["key_manager"] = string(key.KeyManager)
+			resource.Metadata["key_spec"] = string(key.KeySpec)
+			resource.Metadata["key_usage"] = string(key.KeyUsage)
+			resource.Metadata["key_state"] = string(key.KeyState)
+			resource.Metadata["origin"] = string(key.Origin)
+			resource.Metadata["multi_region"] = aws.ToBool(key.MultiRegion)
+			resource.Metadata["creation_date"] = key.CreationDate
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+func convertIAMTags(tags []iamtypes.Tag) map[string]string {
+	result := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		result[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return result
+}
+
+func convertKMSKeyState(state kmstypes.KeyState) types.ResourceState {
+	switch state {
+	case kmstypes.KeyStateEnabled:
+		return types.ResourceStateActive
+	case kmstypes.KeyStateDisabled, kmstypes.KeyStatePendingImport, kmstypes.KeyStateUnavailable:
+		return types.ResourceStateInactive
+	case kmstypes.KeyStatePendingDeletion, kmstypes.KeyStatePendingReplicaDeletion:
+		return types.ResourceStateTerminated
+	default:
+		return types.ResourceStateUnknown
+	}
+}
@@ -0,0 +1,249 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// scanVPCs scans for VPCs
+func (p *AWSProvider) scanVPCs(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := ec2.NewDescribeVpcsPaginator(p.clients.EC2(scope.cfg), &ec2.DescribeVpcsInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.Vpcs {
+			vpc := &page.Vpcs[i]
+			vpcID := aws.ToString(vpc.VpcId)
+
+			resource := newAWSResource(scope, "ec2.vpc", vpcID, ec2Name(vpc.Tags, vpcID), scope.arn("ec2", "vpc/"+vpcID))
+			resource.Tags = p.convertEC2Tags(vpc.Tags)
+			resource.State = awsAvailabilityState(string(vpc.State))
+			resource.Metadata["cidr_block"] = aws.ToString(vpc.CidrBlock)
+			resource.Metadata["is_default"] = aws.ToBool(vpc.IsDefault)
+			resource.Metadata["state"] = string(vpc.State)
+			resource.Metadata["dhcp_options_id"] = aws.ToString(vpc.DhcpOptionsId)
+			resource.Metadata["instance_tenancy"] = string(vpc.InstanceTenancy)
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanSubnets scans for subnets. Each subnet is parented to its VPC.
+func (p *AWSProvider) scanSubnets(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := ec2.NewDescribeSubnetsPaginator(p.clients.EC2(scope.cfg), &ec2.DescribeSubnetsInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.Subnets {
+			subnet := &page.Subnets[i]
+			subnetID := aws.ToString(subnet.SubnetId)
+
+			subnetARN := aws.ToString(subnet.SubnetArn)
+			if subnetARN == "" {
+				subnetARN = scope.arn("ec2", "subnet/"+subnetID)
+			}
+
+			resource := newAWSResource(scope, "ec2.subnet", subnetID, ec2Name(subnet.Tags, subnetID), subnetARN)
+			resource.ParentID = subnet.VpcId
+			resource.Tags = p.convertEC2Tags(subnet.Tags)
+			resource.State = awsAvailabilityState(string(subnet.State))
+			resource.Metadata["vpc_id"] = aws.ToString(subnet.VpcId)
+			resource.Metadata["cidr_block"] = aws.ToString(subnet.CidrBlock)
+			resource.Metadata["availability_zone"] = aws.ToString(subnet.AvailabilityZone)
+			resource.Metadata["available_ip_addresses"] = aws.ToInt32(subnet.AvailableIpAddressCount)
+			resource.Metadata["map_public_ip_on_launch"] = aws.ToBool(subnet.MapPublicIpOnLaunch)
+			resource.Metadata["state"] = string(subnet.State)
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanSecurityGroups scans for security groups together with their rules.
+// Rules are listed once per region and attached to their group's metadata.
+func (p *AWSProvider) scanSecurityGroups(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	client := p.clients.EC2(scope.cfg)
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(client, &ec2.DescribeSecurityGroupsInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.SecurityGroups {
+			group := &page.SecurityGroups[i]
+			groupID := aws.ToString(group.GroupId)
+
+			groupARN := aws.ToString(group.SecurityGroupArn)
+			if groupARN == "" {
+				groupARN = scope.arn("ec2", "security-group/"+groupID)
+			}
+
+			resource := newAWSResource(scope, "ec2.security_group", groupID, aws.ToString(group.GroupName), groupARN)
+			resource.ParentID = group.VpcId
+			resource.Tags = p.convertEC2Tags(group.Tags)
+			resource.Metadata["vpc_id"] = aws.ToString(group.VpcId)
+			resource.Metadata["description"] = aws.ToString(group.Description)
+			resource.Metadata["ingress_rules"] = []map[string]interface{}{}
+			resource.Metadata["egress_rules"] = []map[string]interface{}{}
+			resources = append(resources, resource)
+		}
+	}
+
+	if len(resources) == 0 {
+		return resources, nil
+	}
+
+	byGroup := make(map[string]*types.Resource, len(resources))
+	for i := range resources {
+		byGroup[resources[i].ID] = &resources[i]
+	}
+
+	rules := ec2.NewDescribeSecurityGroupRulesPaginator(client, &ec2.DescribeSecurityGroupRulesInput{})
+	for rules.HasMorePages() {
+		page, err := rules.NextPage(ctx)
+		if err != nil {
+			return resources, fmt.Errorf("failed to list security group rules: %w", err)
+		}
+		for i := range page.SecurityGroupRules {
+			rule := &page.SecurityGroupRules[i]
+			group, ok := byGroup[aws.ToString(rule.GroupId)]
+			if !ok {
+				continue
+			}
+			key := "ingress_rules"
+			if aws.ToBool(rule.IsEgress) {
+				key = "egress_rules"
+			}
+			group.Metadata[key] = append(group.Metadata[key].([]map[string]interface{}), convertSecurityGroupRule(rule))
+		}
+	}
+
+	return resources, nil
+}
+
+// convertSecurityGroupRule flattens a security group rule into metadata
+func convertSecurityGroupRule(rule *ec2types.SecurityGroupRule) map[string]interface{} {
+	result := map[string]interface{}{
+		"id":       aws.ToString(rule.SecurityGroupRuleId),
+		"protocol": aws.ToString(rule.IpProtocol),
+		"from":     aws.ToInt32(rule.FromPort),
+		"to":       aws.ToInt32(rule.ToPort),
+	}
+	if v := aws.ToString(rule.CidrIpv4); v != "" {
+		result["cidr_ipv4"] = v
+	}
+	if v := aws.ToString(rule.CidrIpv6); v != "" {
+		result["cidr_ipv6"] = v
+	}
+	if v := aws.ToString(rule.PrefixListId); v != "" {
+		result["prefix_list_id"] = v
+	}
+	if rule.ReferencedGroupInfo != nil {
+		result["referenced_group_id"] = aws.ToString(rule.ReferencedGroupInfo.GroupId)
+	}
+	if v := aws.ToString(rule.Description); v != "" {
+		result["description"] = v
+	}
+	return result
+}
+
+// scanLoadBalancers scans for application, network and gateway load balancers
+func (p *AWSProvider) scanLoadBalancers(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := elbv2.NewDescribeLoadBalancersPaginator(p.clients.ELBv2(scope.cfg), &elbv2.DescribeLoadBalancersInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.LoadBalancers {
+			lb := &page.LoadBalancers[i]
+			lbARN := aws.ToString(lb.LoadBalancerArn)
+
+			var state elbv2types.LoadBalancerStateEnum
+			if lb.State != nil {
+				state = lb.State.Code
+			}
+
+			subnets := make([]string, 0, len(lb.AvailabilityZones))
+			zones := make([]string, 0, len(lb.AvailabilityZones))
+			for _, az := range lb.AvailabilityZones {
+				subnets = append(subnets, aws.ToString(az.SubnetId))
+				zones = append(zones, aws.ToString(az.ZoneName))
+			}
+
+			resource := newAWSResource(scope, "elbv2.load_balancer", lbARN, aws.ToString(lb.LoadBalancerName), lbARN)
+			resource.ParentID = lb.VpcId
+			resource.State = convertLoadBalancerState(state)
+			resource.Metadata["type"] = string(lb.Type)
+			resource.Metadata["scheme"] = string(lb.Scheme)
+			resource.Metadata["dns_name"] = aws.ToString(lb.DNSName)
+			resource.Metadata["vpc_id"] = aws.ToString(lb.VpcId)
+			resource.Metadata["state"] = string(state)
+			resource.Metadata["subnet_ids"] = subnets
+			resource.Metadata["availability_zones"] = zones
+			resource.Metadata["security_groups"] = lb.SecurityGroups
+			resource.Metadata["created_time"] = lb.CreatedTime
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// ec2Name returns the Name tag, falling back to the resource ID
+func ec2Name(tags []ec2types.Tag, fallback string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == "Name" && aws.ToString(tag.Value) != "" {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return fallback
+}
+
+// awsAvailabilityState maps the pending/available lifecycle shared by VPCs and subnets
+func awsAvailabilityState(state string) types.ResourceState {
+	switch state {
+	case "available":
+		return types.ResourceStateActive
+	case "pending":
+		return types.ResourceStateInactive
+	default:
+		return types.ResourceStateUnknown
+	}
+}
+
+func convertLoadBalancerState(state elbv2types.LoadBalancerStateEnum) types.ResourceState {
+	switch state {
+	case elbv2types.LoadBalancerStateEnumActive, elbv2types.LoadBalancerStateEnumActiveImpaired:
+		return types.ResourceStateActive
+	case elbv2types.LoadBalancerStateEnumProvisioning:
+		return types.ResourceStateInactive
+	case elbv2types.LoadBalancerStateEnumFailed:
+		return types.ResourceStateError
+	default:
+		return types.ResourceStateUnknown
+	}
+}
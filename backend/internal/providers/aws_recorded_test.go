@@ -0,0 +1,382 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// awsRecording replays SDK responses recorded under testdata/aws/<dir>. Each
+// fixture is named <service>.<Operation>.json and maps a request key (the
+// pagination token, or the resource name or ARN for per-resource calls; ""
+// for the first page) to the recorded response. A missing fixture replays an
+// empty response.
+type awsRecording struct {
+	dir string
+}
+
+func (r awsRecording) replay(operation, key string, out interface{}) error {
+	if r.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "aws", r.dir, operation+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var responses map[string]json.RawMessage
+	if err := json.Unmarshal(data, &responses); err != nil {
+		return fmt.Errorf("invalid fixture %s: %w", operation, err)
+	}
+	response, ok := responses[key]
+	if !ok {
+		return fmt.Errorf("no recorded %s response for %q", operation, key)
+	}
+	return json.Unmarshal(response, out)
+}
+
+// recordedOutput replays a response into a new output value
+func recordedOutput[T any](r awsRecording, operation string, key *string) (*T, error) {
+	out := new(T)
+	if err := r.replay(operation, aws.ToString(key), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fakeEC2) DescribeVpcs(_ context.Context, in *ec2.DescribeVpcsInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return recordedOutput[ec2.DescribeVpcsOutput](c.rec, "ec2.DescribeVpcs", in.NextToken)
+}
+
+func (c *fakeEC2) DescribeSubnets(_ context.Context, in *ec2.DescribeSubnetsInput, _ ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return recordedOutput[ec2.DescribeSubnetsOutput](c.rec, "ec2.DescribeSubnets", in.NextToken)
+}
+
+func (c *fakeEC2) DescribeSecurityGroups(_ context.Context, in *ec2.DescribeSecurityGroupsInput, _ ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return recordedOutput[ec2.DescribeSecurityGroupsOutput](c.rec, "ec2.DescribeSecurityGroups", in.NextToken)
+}
+
+func (c *fakeEC2) DescribeSecurityGroupRules(_ context.Context, in *ec2.DescribeSecurityGroupRulesInput, _ ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupRulesOutput, error) {
+	return recordedOutput[ec2.DescribeSecurityGroupRulesOutput](c.rec, "ec2.DescribeSecurityGroupRules", in.NextToken)
+}
+
+// recordedAWSClient serves every non-EC2 service from a recording
+type recordedAWSClient struct {
+	rec awsRecording
+}
+
+func (c *recordedAWSClient) ListRoles(_ context.Context, in *iam.ListRolesInput, _ ...func(*iam.Options)) (*iam.ListRolesOutput, error) {
+	return recordedOutput[iam.ListRolesOutput](c.rec, "iam.ListRoles", in.Marker)
+}
+
+func (c *recordedAWSClient) ListPolicies(_ context.Context, in *iam.ListPoliciesInput, _ ...func(*iam.Options)) (*iam.ListPoliciesOutput, error) {
+	return recordedOutput[iam.ListPoliciesOutput](c.rec, "iam.ListPolicies", in.Marker)
+}
+
+func (c *recordedAWSClient) ListRoleTags(_ context.Context, in *iam.ListRoleTagsInput, _ ...func(*iam.Options)) (*iam.ListRoleTagsOutput, error) {
+	return recordedOutput[iam.ListRoleTagsOutput](c.rec, "iam.ListRoleTags", in.RoleName)
+}
+
+func (c *recordedAWSClient) ListPolicyTags(_ context.Context, in *iam.ListPolicyTagsInput, _ ...func(*iam.Options)) (*iam.ListPolicyTagsOutput, error) {
+	return recordedOutput[iam.ListPolicyTagsOutput](c.rec, "iam.ListPolicyTags", in.PolicyArn)
+}
+
+func (c *recordedAWSClient) ListFunctions(_ context.Context, in *lambda.ListFunctionsInput, _ ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	return recordedOutput[lambda.ListFunctionsOutput](c.rec, "lambda.ListFunctions", in.Marker)
+}
+
+func (c *recordedAWSClient) ListClusters(_ context.Context, in *eks.ListClustersInput, _ ...func(*eks.Options)) (*eks.ListClustersOutput, error) {
+	return recordedOutput[eks.ListClustersOutput](c.rec, "eks.ListClusters", in.NextToken)
+}
+
+func (c *recordedAWSClient) DescribeCluster(_ context.Context, in *eks.DescribeClusterInput, _ ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	return recordedOutput[eks.DescribeClusterOutput](c.rec, "eks.DescribeCluster", in.Name)
+}
+
+func (c *recordedAWSClient) DescribeLoadBalancers(_ context.Context, in *elbv2.DescribeLoadBalancersInput, _ ...func(*elbv2.Options)) (*elbv2.DescribeLoadBalancersOutput, error) {
+	return recordedOutput[elbv2.DescribeLoadBalancersOutput](c.rec, "elbv2.DescribeLoadBalancers", in.Marker)
+}
+
+func (c *recordedAWSClient) ListTables(_ context.Context, in *dynamodb.ListTablesInput, _ ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	return recordedOutput[dynamodb.ListTablesOutput](c.rec, "dynamodb.ListTables", in.ExclusiveStartTableName)
+}
+
+func (c *recordedAWSClient) DescribeTable(_ context.Context, in *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return recordedOutput[dynamodb.DescribeTableOutput](c.rec, "dynamodb.DescribeTable", in.TableName)
+}
+
+func (c *recordedAWSClient) ListQueues(_ context.Context, in *sqs.ListQueuesInput, _ ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error) {
+	return recordedOutput[sqs.ListQueuesOutput](c.rec, "sqs.ListQueues", in.NextToken)
+}
+
+func (c *recordedAWSClient) ListTopics(_ context.Context, in *sns.ListTopicsInput, _ ...func(*sns.Options)) (*sns.ListTopicsOutput, error) {
+	return recordedOutput[sns.ListTopicsOutput](c.rec, "sns.ListTopics", in.NextToken)
+}
+
+func (c *recordedAWSClient) ListKeys(_ context.Context, in *kms.ListKeysInput, _ ...func(*kms.Options)) (*kms.ListKeysOutput, error) {
+	return recordedOutput[kms.ListKeysOutput](c.rec, "kms.ListKeys", in.Marker)
+}
+
+func (c *recordedAWSClient) ListAliases(_ context.Context, in *kms.ListAliasesInput, _ ...func(*kms.Options)) (*kms.ListAliasesOutput, error) {
+	return recordedOutput[kms.ListAliasesOutput](c.rec, "kms.ListAliases", in.Marker)
+}
+
+func (c *recordedAWSClient) DescribeKey(_ context.Context, in *kms.DescribeKeyInput, _ ...func(*kms.Options)) (*kms.DescribeKeyOutput, error) {
+	return recordedOutput[kms.DescribeKeyOutput](c.rec, "kms.DescribeKey", in.KeyId)
+}
+
+// scanRecorded runs a single scanner against the recording for the home account
+func scanRecorded(t *testing.T, dir string, scanner func(*AWSProvider, context.Context, awsScope) ([]types.Resource, error)) []types.Resource {
+	t.Helper()
+
+	fake := newFakeAWS()
+	fake.recordings[testHomeAccount+"/us-east-1"] = dir
+	provider := newTestAWSProvider(config.AWSConfig{}, fake)
+
+	acct, err := provider.homeAccount(context.Background())
+	if err != nil {
+		t.Fatalf("homeAccount failed: %v", err)
+	}
+	resources, err := scanner(provider, context.Background(), provider.scope(acct, "us-east-1"))
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	return resources
+}
+
+func resourcesByID(resources []types.Resource) map[string]types.Resource {
+	result := make(map[string]types.Resource, len(resources))
+	for _, r := range resources {
+		result[r.ID] = r
+	}
+	return result
+}
+
+// assertAWSResource checks the fields every scanner is expected to populate
+func assertAWSResource(t *testing.T, r types.Resource, resourceType, arn string) {
+	t.Helper()
+	if r.Type != resourceType {
+		t.Errorf("%s: expected type %s, got %s", r.ID, resourceType, r.Type)
+	}
+	if r.Provider != "aws" {
+		t.Errorf("%s: expected provider aws, got %s", r.ID, r.Provider)
+	}
+	if r.ARN != arn {
+		t.Errorf("%s: expected ARN %s, got %s", r.ID, arn, r.ARN)
+	}
+	if r.Tags == nil {
+		t.Errorf("%s: expected non-nil tags", r.ID)
+	}
+	if r.Metadata["account_id"] != testHomeAccount {
+		t.Errorf("%s: expected account_id %s, got %v", r.ID, testHomeAccount, r.Metadata["account_id"])
+	}
+}
+
+func TestAWSProvider_ScanNetworking(t *testing.T) {
+	vpcs := resourcesByID(scanRecorded(t, "network", (*AWSProvider).scanVPCs))
+	if len(vpcs) != 2 {
+		t.Fatalf("Expected 2 VPCs across both pages, got %d", len(vpcs))
+	}
+	vpc := vpcs["vpc-0a1b2c3d"]
+	assertAWSResource(t, vpc, "ec2.vpc", "arn:aws:ec2:us-east-1:111111111111:vpc/vpc-0a1b2c3d")
+	if vpc.Name != "prod" || vpc.Metadata["cidr_block"] != "10.0.0.0/16" || vpc.State != types.ResourceStateActive {
+		t.Errorf("Unexpected VPC: %+v", vpc)
+	}
+	if got := vpcs["vpc-default"]; got.Name != "vpc-default" || got.Metadata["is_default"] != true {
+		t.Errorf("Expected unnamed default VPC to fall back to its ID: %+v", got)
+	}
+
+	subnets := resourcesByID(scanRecorded(t, "network", (*AWSProvider).scanSubnets))
+	subnet := subnets["subnet-11111111"]
+	assertAWSResource(t, subnet, "ec2.subnet", "arn:aws:ec2:us-east-1:111111111111:subnet/subnet-11111111")
+	if subnet.ParentID == nil || *subnet.ParentID != "vpc-0a1b2c3d" {
+		t.Errorf("Expected subnet to be parented to its VPC, got %v", subnet.ParentID)
+	}
+	if subnet.Metadata["availability_zone"] != "us-east-1a" {
+		t.Errorf("Unexpected subnet metadata: %v", subnet.Metadata)
+	}
+
+	groups := resourcesByID(scanRecorded(t, "network", (*AWSProvider).scanSecurityGroups))
+	web := groups["sg-0123456789"]
+	assertAWSResource(t, web, "ec2.security_group", "arn:aws:ec2:us-east-1:111111111111:security-group/sg-0123456789")
+	if web.Name != "web" || web.ParentID == nil || *web.ParentID != "vpc-0a1b2c3d" {
+		t.Errorf("Unexpected security group: %+v", web)
+	}
+	ingress := web.Metadata["ingress_rules"].([]map[string]interface{})
+	if len(ingress) != 2 {
+		t.Fatalf("Expected 2 ingress rules across both pages, got %v", ingress)
+	}
+	if ingress[0]["cidr_ipv4"] != "0.0.0.0/0" || ingress[0]["from"] != int32(443) {
+		t.Errorf("Unexpected ingress rule: %v", ingress[0])
+	}
+	if ingress[1]["referenced_group_id"] != "sg-lb" {
+		t.Errorf("Expected rule referencing the load balancer group, got %v", ingress[1])
+	}
+	if egress := web.Metadata["egress_rules"].([]map[string]interface{}); len(egress) != 1 || egress[0]["protocol"] != "-1" {
+		t.Errorf("Unexpected egress rules: %v", egress)
+	}
+
+	lbs := scanRecorded(t, "network", (*AWSProvider).scanLoadBalancers)
+	if len(lbs) != 1 {
+		t.Fatalf("Expected 1 load balancer, got %d", len(lbs))
+	}
+	lbARN := "arn:aws:elasticloadbalancing:us-east-1:111111111111:loadbalancer/app/web/50dc6c495c0c9188"
+	assertAWSResource(t, lbs[0], "elbv2.load_balancer", lbARN)
+	if lbs[0].Name != "web" || lbs[0].Metadata["type"] != "application" || lbs[0].State != types.ResourceStateActive {
+		t.Errorf("Unexpected load balancer: %+v", lbs[0])
+	}
+}
+
+func TestAWSProvider_ScanIAM(t *testing.T) {
+	roles := resourcesByID(scanRecorded(t, "iam", (*AWSProvider).scanIAMRoles))
+	if len(roles) != 2 {
+		t.Fatalf("Expected 2 roles across both pages, got %d", len(roles))
+	}
+	role := roles["AROAEXAMPLEROLE1"]
+	assertAWSResource(t, role, "iam.role", "arn:aws:iam::111111111111:role/siros-reader")
+	if role.Name != "siros-reader" || role.Region != awsGlobalRegion || role.Tags["team"] != "platform" {
+		t.Errorf("Unexpected role: %+v", role)
+	}
+
+	policies := resourcesByID(scanRecorded(t, "iam", (*AWSProvider).scanIAMPolicies))
+	if len(policies) != 2 {
+		t.Fatalf("Expected 2 policies, got %d", len(policies))
+	}
+	policy := policies["ANPAEXAMPLEPOL1"]
+	assertAWSResource(t, policy, "iam.policy", "arn:aws:iam::111111111111:policy/read-s3")
+	if policy.State != types.ResourceStateActive || policy.Tags["owner"] != "data" {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+	if got := policies["ANPAEXAMPLEPOL2"]; got.State != types.ResourceStateInactive {
+		t.Errorf("Expected unattached policy to be inactive, got %s", got.State)
+	}
+
+	keys := resourcesByID(scanRecorded(t, "iam", (*AWSProvider).scanKMSKeys))
+	key := keys["1234abcd-12ab-34cd-56ef-1234567890ab"]
+	assertAWSResource(t, key, "kms.key", "arn:aws:kms:us-east-1:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab")
+	if key.Name != "alias/app" || key.Metadata["key_manager"] != "CUSTOMER" || key.State != types.ResourceStateActive {
+		t.Errorf("Unexpected KMS key: %+v", key)
+	}
+	if got := keys["0987dcba-09fe-87dc-65ba-ab0987654321"]; got.State != types.ResourceStateTerminated {
+		t.Errorf("Expected key pending deletion to be terminated, got %s", got.State)
+	}
+}
+
+func TestAWSProvider_ScanServices(t *testing.T) {
+	functions := resourcesByID(scanRecorded(t, "services", (*AWSProvider).scanLambdaFunctions))
+	if len(functions) != 2 {
+		t.Fatalf("Expected 2 functions across both pages, got %d", len(functions))
+	}
+	fn := functions["thumbnailer"]
+	assertAWSResource(t, fn, "lambda.function", "arn:aws:lambda:us-east-1:111111111111:function:thumbnailer")
+	if fn.Metadata["runtime"] != "python3.12" || fn.Metadata["vpc_id"] != "vpc-0a1b2c3d" {
+		t.Errorf("Unexpected function metadata: %v", fn.Metadata)
+	}
+
+	clusters := scanRecorded(t, "services", (*AWSProvider).scanEKSClusters)
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+	}
+	assertAWSResource(t, clusters[0], "eks.cluster", "arn:aws:eks:us-east-1:111111111111:cluster/prod")
+	if clusters[0].Metadata["version"] != "1.30" || clusters[0].Tags["env"] != "prod" {
+		t.Errorf("Unexpected cluster: %+v", clusters[0])
+	}
+	if clusters[0].ParentID == nil || *clusters[0].ParentID != "vpc-0a1b2c3d" {
+		t.Errorf("Expected cluster to be parented to its VPC, got %v", clusters[0].ParentID)
+	}
+
+	tables := scanRecorded(t, "services", (*AWSProvider).scanDynamoDBTables)
+	if len(tables) != 1 {
+		t.Fatalf("Expected 1 table, got %d", len(tables))
+	}
+	assertAWSResource(t, tables[0], "dynamodb.table", "arn:aws:dynamodb:us-east-1:111111111111:table/orders")
+	if tables[0].Metadata["billing_mode"] != "PAY_PER_REQUEST" || tables[0].Metadata["item_count"] != int64(42) {
+		t.Errorf("Unexpected table metadata: %v", tables[0].Metadata)
+	}
+
+	queues := resourcesByID(scanRecorded(t, "services", (*AWSProvider).scanSQSQueues))
+	queue := queues["orders.fifo"]
+	assertAWSResource(t, queue, "sqs.queue", "arn:aws:sqs:us-east-1:111111111111:orders.fifo")
+	if queue.Metadata["fifo"] != true {
+		t.Errorf("Expected FIFO queue, got %v", queue.Metadata)
+	}
+
+	topics := scanRecorded(t, "services", (*AWSProvider).scanSNSTopics)
+	if len(topics) != 1 {
+		t.Fatalf("Expected 1 topic, got %d", len(topics))
+	}
+	assertAWSResource(t, topics[0], "sns.topic", "arn:aws:sns:us-east-1:111111111111:alerts")
+	if topics[0].Name != "alerts" {
+		t.Errorf("Unexpected topic name: %s", topics[0].Name)
+	}
+}
+
+func TestAWSProvider_GetRecordedResourceByARN(t *testing.T) {
+	tests := []struct {
+		dir, arn, resourceType, id string
+	}{
+		{"network", "arn:aws:ec2:us-east-1:111111111111:vpc/vpc-0a1b2c3d", "ec2.vpc", "vpc-0a1b2c3d"},
+		{"network", "arn:aws:ec2:us-east-1:111111111111:subnet/subnet-11111111", "ec2.subnet", "subnet-11111111"},
+		{"network", "arn:aws:ec2:us-east-1:111111111111:security-group/sg-0123456789", "ec2.security_group", "sg-0123456789"},
+		{"network", "arn:aws:elasticloadbalancing:us-east-1:111111111111:loadbalancer/app/web/50dc6c495c0c9188", "elbv2.load_balancer", "arn:aws:elasticloadbalancing:us-east-1:111111111111:loadbalancer/app/web/50dc6c495c0c9188"},
+		{"iam", "arn:aws:iam::111111111111:role/siros-reader", "iam.role", "AROAEXAMPLEROLE1"},
+		{"iam", "arn:aws:iam::111111111111:policy/read-s3", "iam.policy", "ANPAEXAMPLEPOL1"},
+		{"iam", "arn:aws:kms:us-east-1:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab", "kms.key", "1234abcd-12ab-34cd-56ef-1234567890ab"},
+		{"services", "arn:aws:lambda:us-east-1:111111111111:function:thumbnailer", "lambda.function", "thumbnailer"},
+		{"services", "arn:aws:lambda:us-east-1:111111111111:function:thumbnailer:live", "lambda.function", "thumbnailer"},
+		{"services", "arn:aws:eks:us-east-1:111111111111:cluster/prod", "eks.cluster", "prod"},
+		{"services", "arn:aws:dynamodb:us-east-1:111111111111:table/orders", "dynamodb.table", "orders"},
+		{"services", "arn:aws:sqs:us-east-1:111111111111:orders.fifo", "sqs.queue", "orders.fifo"},
+		{"services", "arn:aws:sns:us-east-1:111111111111:alerts", "sns.topic", "alerts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			fake := newFakeAWS()
+			fake.recordings[testHomeAccount+"/us-east-1"] = tt.dir
+			provider := newTestAWSProvider(config.AWSConfig{}, fake)
+
+			resource, err := provider.GetResource(tt.arn)
+			if err != nil {
+				t.Fatalf("GetResource(%s) failed: %v", tt.arn, err)
+			}
+			if resource.ID != tt.id || resource.Type != tt.resourceType {
+				t.Errorf("GetResource(%s) = %s %s, want %s %s", tt.arn, resource.Type, resource.ID, tt.resourceType, tt.id)
+			}
+			if resource.Metadata["account_id"] != testHomeAccount {
+				t.Errorf("Expected account_id %s, got %v", testHomeAccount, resource.Metadata["account_id"])
+			}
+		})
+	}
+
+	fake := newFakeAWS()
+	fake.recordings[testHomeAccount+"/us-east-1"] = "services"
+	provider := newTestAWSProvider(config.AWSConfig{}, fake)
+	if _, err := provider.GetResource("arn:aws:sqs:us-east-1:111111111111:missing"); err == nil {
+		t.Error("Expected error for a queue that does not exist")
+	}
+	if _, err := provider.GetResource("arn:aws:ec2:us-east-1:111111111111:volume/vol-1"); err == nil {
+		t.Error("Expected error for an unsupported resource type")
+	}
+}
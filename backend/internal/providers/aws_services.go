@@ -0,0 +1,248 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// scanLambdaFunctions scans for Lambda functions
+func (p *AWSProvider) scanLambdaFunctions(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := lambda.NewListFunctionsPaginator(p.clients.Lambda(scope.cfg), &lambda.ListFunctionsInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for i := range page.Functions {
+			fn := &page.Functions[i]
+			name := aws.ToString(fn.FunctionName)
+
+			resource := newAWSResource(scope, "lambda.function", name, name, aws.ToString(fn.FunctionArn))
+			resource.State = convertLambdaState(fn.State)
+			resource.Metadata["runtime"] = string(fn.Runtime)
+			resource.Metadata["handler"] = aws.ToString(fn.Handler)
+			resource.Metadata["package_type"] = string(fn.PackageType)
+			resource.Metadata["memory_size"] = aws.ToInt32(fn.MemorySize)
+			resource.Metadata["timeout"] = aws.ToInt32(fn.Timeout)
+			resource.Metadata["role"] = aws.ToString(fn.Role)
+			resource.Metadata["last_modified"] = aws.ToString(fn.LastModified)
+			if fn.VpcConfig != nil && aws.ToString(fn.VpcConfig.VpcId) != "" {
+				resource.Metadata["vpc_id"] = aws.ToString(fn.VpcConfig.VpcId)
+				resource.Metadata["subnet_ids"] = fn.VpcConfig.SubnetIds
+				resource.Metadata["security_groups"] = fn.VpcConfig.SecurityGroupIds
+			}
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanEKSClusters scans for EKS clusters
+func (p *AWSProvider) scanEKSClusters(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	client := p.clients.EKS(scope.cfg)
+	paginator := eks.NewListClustersPaginator(client, &eks.ListClustersInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for _, name := range page.Clusters {
+			described, err := client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(name)})
+			if err != nil {
+				return resources, fmt.Errorf("failed to describe EKS cluster %s: %w", name, err)
+			}
+			cluster := described.Cluster
+			if cluster == nil {
+				continue
+			}
+
+			resource := newAWSResource(scope, "eks.cluster", name, name, aws.ToString(cluster.Arn))
+			resource.State = convertEKSState(cluster.Status)
+			resource.Metadata["version"] = aws.ToString(cluster.Version)
+			resource.Metadata["platform_version"] = aws.ToString(cluster.PlatformVersion)
+			resource.Metadata["status"] = string(cluster.Status)
+			resource.Metadata["endpoint"] = aws.ToString(cluster.Endpoint)
+			resource.Metadata["role_arn"] = aws.ToString(cluster.RoleArn)
+			resource.Metadata["created_at"] = cluster.CreatedAt
+			if vpc := cluster.ResourcesVpcConfig; vpc != nil {
+				resource.ParentID = vpc.VpcId
+				resource.Metadata["vpc_id"] = aws.ToString(vpc.VpcId)
+				resource.Metadata["subnet_ids"] = vpc.SubnetIds
+				resource.Metadata["security_groups"] = vpc.SecurityGroupIds
+			}
+			for k, v := range cluster.Tags {
+				resource.Tags[k] = v
+			}
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanDynamoDBTables scans for DynamoDB tables
+func (p *AWSProvider) scanDynamoDBTables(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	client := p.clients.DynamoDB(scope.cfg)
+	paginator := dynamodb.NewListTablesPaginator(client, &dynamodb.ListTablesInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for _, name := range page.TableNames {
+			described, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+			if err != nil {
+				return resources, fmt.Errorf("failed to describe DynamoDB table %s: %w", name, err)
+			}
+			table := described.Table
+			if table == nil {
+				continue
+			}
+
+			tableARN := aws.ToString(table.TableArn)
+			if tableARN == "" {
+				tableARN = scope.arn("dynamodb", "table/"+name)
+			}
+
+			keys := make(map[string]string, len(table.KeySchema))
+			for _, key := range table.KeySchema {
+				keys[aws.ToString(key.AttributeName)] = string(key.KeyType)
+			}
+
+			billingMode := dynamodbtypes.BillingModeProvisioned
+			if table.BillingModeSummary != nil {
+				billingMode = table.BillingModeSummary.BillingMode
+			}
+
+			resource := newAWSResource(scope, "dynamodb.table", name, name, tableARN)
+			resource.State = convertDynamoDBState(table.TableStatus)
+			resource.Metadata["status"] = string(table.TableStatus)
+			resource.Metadata["billing_mode"] = string(billingMode)
+			resource.Metadata["key_schema"] = keys
+			resource.Metadata["item_count"] = aws.ToInt64(table.ItemCount)
+			resource.Metadata["size_bytes"] = aws.ToInt64(table.TableSizeBytes)
+			resource.Metadata["creation_date"] = table.CreationDateTime
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanSQSQueues scans for SQS queues. Queue ARNs are derived from the queue URL.
+func (p *AWSProvider) scanSQSQueues(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := sqs.NewListQueuesPaginator(p.clients.SQS(scope.cfg), &sqs.ListQueuesInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for _, queueURL := range page.QueueUrls {
+			parsed, err := url.Parse(queueURL)
+			if err != nil {
+				return resources, fmt.Errorf("invalid SQS queue URL %s: %w", queueURL, err)
+			}
+			name := path.Base(parsed.Path)
+
+			resource := newAWSResource(scope, "sqs.queue", name, name, scope.arn("sqs", name))
+			resource.Metadata["url"] = queueURL
+			resource.Metadata["fifo"] = path.Ext(name) == ".fifo"
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// scanSNSTopics scans for SNS topics
+func (p *AWSProvider) scanSNSTopics(ctx context.Context, scope awsScope) ([]types.Resource, error) {
+	paginator := sns.NewListTopicsPaginator(p.clients.SNS(scope.cfg), &sns.ListTopicsInput{})
+
+	var resources []types.Resource
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+		for _, topic := range page.Topics {
+			topicARN := aws.ToString(topic.TopicArn)
+			parsed, err := arn.Parse(topicARN)
+			if err != nil {
+				return resources, fmt.Errorf("invalid SNS topic ARN %s: %w", topicARN, err)
+			}
+
+			resource := newAWSResource(scope, "sns.topic", parsed.Resource, parsed.Resource, topicARN)
+			resource.Metadata["fifo"] = path.Ext(parsed.Resource) == ".fifo"
+			resources = append(resources, resource)
+		}
+	}
+
+	return resources, nil
+}
+
+func convertLambdaState(state lambdatypes.State) types.ResourceState {
+	switch state {
+	case lambdatypes.StateActive, "":
+		// ListFunctions omits the state for functions that have never been invoked
+		return types.ResourceStateActive
+	case lambdatypes.StateInactive, lambdatypes.StatePending:
+		return types.ResourceStateInactive
+	case lambdatypes.StateFailed:
+		return types.ResourceStateError
+	default:
+		return types.ResourceStateUnknown
+	}
+}
+
+func convertEKSState(status ekstypes.ClusterStatus) types.ResourceState {
+	switch status {
+	case ekstypes.ClusterStatusActive:
+		return types.ResourceStateActive
+	case ekstypes.ClusterStatusCreating, ekstypes.ClusterStatusUpdating, ekstypes.ClusterStatusPending:
+		return types.ResourceStateInactive
+	case ekstypes.ClusterStatusDeleting:
+		return types.ResourceStateTerminated
+	case ekstypes.ClusterStatusFailed:
+		return types.ResourceStateError
+	default:
+		return types.ResourceStateUnknown
+	}
+}
+
+func convertDynamoDBState(status dynamodbtypes.TableStatus) types.ResourceState {
+	switch status {
+	case dynamodbtypes.TableStatusActive, dynamodbtypes.TableStatusUpdating:
+		return types.ResourceStateActive
+	case dynamodbtypes.TableStatusCreating, dynamodbtypes.TableStatusArchiving, dynamodbtypes.TableStatusArchived,
+		dynamodbtypes.TableStatusInaccessibleEncryptionCredentials:
+		return types.ResourceStateInactive
+	case dynamodbtypes.TableStatusDeleting:
+		return types.ResourceStateTerminated
+	default:
+		return types.ResourceStateUnknown
+	}
+}
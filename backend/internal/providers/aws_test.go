@@ -0,0 +1,413 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const testHomeAccount = "111111111111"
+
+// fakeAWS serves canned responses keyed by account and region. Assumed-role
+// credentials carry the target account ID as their access key so that fake
+// clients can tell which account they were built for.
+type fakeAWS struct {
+	mu          sync.Mutex
+	instances   map[string][][]ec2types.Instance // account/region -> pages
+	buckets     map[string][]s3types.Bucket      // account -> buckets
+	dbInstances map[string][]rdstypes.DBInstance // account/region -> instances
+	failRDS     map[string]bool                  // account/region -> fail
+	denyAccount map[string]bool
+	externalIDs map[string]string // role ARN -> external ID seen
+	recordings  map[string]string // account/region -> testdata/aws directory
+}
+
+func newFakeAWS() *fakeAWS {
+	return &fakeAWS{
+		instances:   make(map[string][][]ec2types.Instance),
+		buckets:     make(map[string][]s3types.Bucket),
+		dbInstances: make(map[string][]rdstypes.DBInstance),
+		failRDS:     make(map[string]bool),
+		denyAccount: make(map[string]bool),
+		externalIDs: make(map[string]string),
+		recordings:  make(map[string]string),
+	}
+}
+
+func (f *fakeAWS) account(cfg aws.Config) string {
+	if cfg.Credentials == nil {
+		return testHomeAccount
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		return ""
+	}
+	return creds.AccessKeyID
+}
+
+func (f *fakeAWS) recording(cfg aws.Config) awsRecording {
+	return awsRecording{dir: f.recordings[f.account(cfg)+"/"+cfg.Region]}
+}
+
+func (f *fakeAWS) EC2(cfg aws.Config) awsEC2API {
+	return &fakeEC2{fake: f, key: f.account(cfg) + "/" + cfg.Region, rec: f.recording(cfg)}
+}
+
+func (f *fakeAWS) S3(cfg aws.Config) awsS3API {
+	return &fakeS3{fake: f, account: f.account(cfg)}
+}
+
+func (f *fakeAWS) RDS(cfg aws.Config) awsRDSAPI {
+	return &fakeRDS{fake: f, key: f.account(cfg) + "/" + cfg.Region}
+}
+
+func (f *fakeAWS) IAM(cfg aws.Config) awsIAMAPI { return &recordedAWSClient{rec: f.recording(cfg)} }
+
+func (f *fakeAWS) Lambda(cfg aws.Config) awsLambdaAPI {
+	return &recordedAWSClient{rec: f.recording(cfg)}
+}
+
+func (f *fakeAWS) EKS(cfg aws.Config) awsEKSAPI { return &recordedAWSClient{rec: f.recording(cfg)} }
+
+func (f *fakeAWS) ELBv2(cfg aws.Config) awsELBv2API { return &recordedAWSClient{rec: f.recording(cfg)} }
+
+func (f *fakeAWS) DynamoDB(cfg aws.Config) awsDynamoDBAPI {
+	return &recordedAWSClient{rec: f.recording(cfg)}
+}
+
+func (f *fakeAWS) SQS(cfg aws.Config) awsSQSAPI { return &recordedAWSClient{rec: f.recording(cfg)} }
+
+func (f *fakeAWS) SNS(cfg aws.Config) awsSNSAPI { return &recordedAWSClient{rec: f.recording(cfg)} }
+
+func (f *fakeAWS) KMS(cfg aws.Config) awsKMSAPI { return &recordedAWSClient{rec: f.recording(cfg)} }
+
+func (f *fakeAWS) STS(cfg aws.Config) awsSTSAPI {
+	return &fakeSTS{fake: f, cfg: cfg}
+}
+
+type fakeEC2 struct {
+	fake *fakeAWS
+	key  string
+	rec  awsRecording
+}
+
+func (c *fakeEC2) DescribeInstances(_ context.Context, in *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	pages := c.fake.instances[c.key]
+	page := 0
+	if in.NextToken != nil {
+		fmt.Sscanf(*in.NextToken, "page-%d", &page)
+	}
+	out := &ec2.DescribeInstancesOutput{}
+	if page < len(pages) {
+		out.Reservations = []ec2types.Reservation{{Instances: pages[page]}}
+	}
+	if page+1 < len(pages) {
+		out.NextToken = aws.String(fmt.Sprintf("page-%d", page+1))
+	}
+	return out, nil
+}
+
+func (c *fakeEC2) DescribeRegions(_ context.Context, _ *ec2.DescribeRegionsInput, _ ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	return &ec2.DescribeRegionsOutput{Regions: []ec2types.Region{
+		{RegionName: aws.String("us-east-1")},
+		{RegionName: aws.String("eu-west-1")},
+	}}, nil
+}
+
+type fakeS3 struct {
+	fake    *fakeAWS
+	account string
+}
+
+func (c *fakeS3) ListBuckets(_ context.Context, _ *s3.ListBucketsInput, _ ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return &s3.ListBucketsOutput{Buckets: c.fake.buckets[c.account]}, nil
+}
+
+func (c *fakeS3) GetBucketLocation(_ context.Context, _ *s3.GetBucketLocationInput, _ ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	return &s3.GetBucketLocationOutput{}, nil
+}
+
+type fakeRDS struct {
+	fake *fakeAWS
+	key  string
+}
+
+func (c *fakeRDS) DescribeDBInstances(_ context.Context, _ *rds.DescribeDBInstancesInput, _ ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	if c.fake.failRDS[c.key] {
+		return nil, errors.New("AccessDenied")
+	}
+	return &rds.DescribeDBInstancesOutput{DBInstances: c.fake.dbInstances[c.key]}, nil
+}
+
+type fakeSTS struct {
+	fake *fakeAWS
+	cfg  aws.Config
+}
+
+func (c *fakeSTS) AssumeRole(_ context.Context, in *sts.AssumeRoleInput, _ ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	roleARN := aws.ToString(in.RoleArn)
+	account := strings.Split(roleARN, ":")[4]
+
+	c.fake.mu.Lock()
+	c.fake.externalIDs[roleARN] = aws.ToString(in.ExternalId)
+	c.fake.mu.Unlock()
+
+	if c.fake.denyAccount[account] {
+		return nil, errors.New("AccessDenied: not authorized to perform sts:AssumeRole")
+	}
+	return &sts.AssumeRoleOutput{Credentials: &ststypes.Credentials{
+		AccessKeyId:     aws.String(account),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("token"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+func (c *fakeSTS) GetCallerIdentity(_ context.Context, _ *sts.GetCallerIdentityInput, _ ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	account := c.fake.account(c.cfg)
+	if account == "" {
+		return nil, errors.New("failed to retrieve credentials")
+	}
+	return &sts.GetCallerIdentityOutput{Account: aws.String(account)}, nil
+}
+
+func newTestAWSProvider(cfg config.AWSConfig, fake *fakeAWS) *AWSProvider {
+	if cfg.Region == "" {
+		cfg.Region = awsDefaultRegion
+	}
+	return &AWSProvider{
+		config:  cfg,
+		awsCfg:  aws.Config{Region: cfg.Region},
+		clients: fake,
+	}
+}
+
+func resourcesByARN(resources []types.Resource) map[string]types.Resource {
+	result := make(map[string]types.Resource, len(resources))
+	for _, r := range resources {
+		result[r.ARN] = r
+	}
+	return result
+}
+
+func TestAWSProvider_ScanPaginatesAcrossRegions(t *testing.T) {
+	fake := newFakeAWS()
+	fake.instances[testHomeAccount+"/us-east-1"] = [][]ec2types.Instance{
+		{{InstanceId: aws.String("i-1"), State: &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning}}},
+		{{InstanceId: aws.String("i-2"), State: &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped}}},
+	}
+	fake.instances[testHomeAccount+"/eu-west-1"] = [][]ec2types.Instance{
+		{{InstanceId: aws.String("i-3"), Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}}}},
+	}
+	fake.buckets[testHomeAccount] = []s3types.Bucket{
+		{Name: aws.String("logs"), BucketRegion: aws.String("eu-west-1")},
+		{Name: aws.String("assets")},
+	}
+
+	provider := newTestAWSProvider(config.AWSConfig{}, fake)
+	resources, err := provider.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	byARN := resourcesByARN(resources)
+	expected := []string{
+		"arn:aws:ec2:us-east-1:111111111111:instance/i-1",
+		"arn:aws:ec2:us-east-1:111111111111:instance/i-2",
+		"arn:aws:ec2:eu-west-1:111111111111:instance/i-3",
+		"arn:aws:s3:::logs",
+		"arn:aws:s3:::assets",
+	}
+	for _, arn := range expected {
+		if _, ok := byARN[arn]; !ok {
+			t.Errorf("Expected resource %s, got %v", arn, byARN)
+		}
+	}
+	if len(resources) != len(expected) {
+		t.Errorf("Expected %d resources, got %d", len(expected), len(resources))
+	}
+
+	if got := byARN["arn:aws:ec2:eu-west-1:111111111111:instance/i-3"]; got.Name != "web" || got.Region != "eu-west-1" {
+		t.Errorf("Unexpected instance i-3: %+v", got)
+	}
+	if got := byARN["arn:aws:ec2:us-east-1:111111111111:instance/i-2"]; got.State != types.ResourceStateInactive {
+		t.Errorf("Expected i-2 to be inactive, got %s", got.State)
+	}
+	if got := byARN["arn:aws:s3:::assets"]; got.Region != "us-east-1" {
+		t.Errorf("Expected bucket without location constraint in us-east-1, got %s", got.Region)
+	}
+}
+
+func TestAWSProvider_ScanAssumesRolePerAccount(t *testing.T) {
+	fake := newFakeAWS()
+	fake.instances["222222222222/us-east-1"] = [][]ec2types.Instance{{{InstanceId: aws.String("i-a")}}}
+	fake.instances["333333333333/us-east-1"] = [][]ec2types.Instance{{{InstanceId: aws.String("i-b")}}}
+
+	provider := newTestAWSProvider(config.AWSConfig{
+		Regions:    []string{"us-east-1"},
+		RoleName:   "SirosReadOnly",
+		ExternalID: "org-external-id",
+		Accounts: []config.AWSAccountConfig{
+			{ID: "222222222222"},
+			{ID: "333333333333", ExternalID: "account-external-id"},
+		},
+	}, fake)
+
+	resources, err := provider.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	byARN := resourcesByARN(resources)
+	for _, arn := range []string{
+		"arn:aws:ec2:us-east-1:222222222222:instance/i-a",
+		"arn:aws:ec2:us-east-1:333333333333:instance/i-b",
+	} {
+		if _, ok := byARN[arn]; !ok {
+			t.Errorf("Expected resource %s", arn)
+		}
+	}
+
+	if got := fake.externalIDs["arn:aws:iam::222222222222:role/SirosReadOnly"]; got != "org-external-id" {
+		t.Errorf("Expected provider-level external ID, got %q", got)
+	}
+	if got := fake.externalIDs["arn:aws:iam::333333333333:role/SirosReadOnly"]; got != "account-external-id" {
+		t.Errorf("Expected account-level external ID, got %q", got)
+	}
+}
+
+func TestAWSProvider_ScanIsolatesFailures(t *testing.T) {
+	fake := newFakeAWS()
+	fake.instances["222222222222/us-east-1"] = [][]ec2types.Instance{{{InstanceId: aws.String("i-a")}}}
+	fake.dbInstances["222222222222/eu-west-1"] = []rdstypes.DBInstance{{
+		DBInstanceIdentifier: aws.String("orders"),
+		DBInstanceStatus:     aws.String("available"),
+	}}
+	fake.failRDS["222222222222/us-east-1"] = true
+	fake.denyAccount["333333333333"] = true
+
+	provider := newTestAWSProvider(config.AWSConfig{
+		Regions:  []string{"us-east-1", "eu-west-1"},
+		RoleName: "SirosReadOnly",
+		Accounts: []config.AWSAccountConfig{{ID: "222222222222"}, {ID: "333333333333"}},
+	}, fake)
+
+	resources, err := provider.Scan(context.Background())
+	if !IsPartialScan(err) {
+		t.Fatalf("Expected partial scan error, got %v", err)
+	}
+
+	var scanErr *ScanError
+	errors.As(err, &scanErr)
+	if len(scanErr.Failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %d: %v", len(scanErr.Failures), scanErr)
+	}
+
+	byARN := resourcesByARN(resources)
+	if _, ok := byARN["arn:aws:ec2:us-east-1:222222222222:instance/i-a"]; !ok {
+		t.Error("Expected EC2 results to survive the RDS failure")
+	}
+	db, ok := byARN["arn:aws:rds:eu-west-1:222222222222:db:orders"]
+	if !ok {
+		t.Fatal("Expected RDS results from the healthy region")
+	}
+	if db.State != types.ResourceStateActive {
+		t.Errorf("Expected RDS instance to be active, got %s", db.State)
+	}
+}
+
+func TestAWSProvider_ScanFailsWhenNoAccountIsReachable(t *testing.T) {
+	fake := newFakeAWS()
+	fake.denyAccount["222222222222"] = true
+
+	provider := newTestAWSProvider(config.AWSConfig{
+		RoleName: "SirosReadOnly",
+		Accounts: []config.AWSAccountConfig{{ID: "222222222222"}},
+	}, fake)
+
+	resources, err := provider.Scan(context.Background())
+	if err == nil {
+		t.Fatal("Expected error when every account fails")
+	}
+	if len(resources) != 0 {
+		t.Errorf("Expected no resources, got %d", len(resources))
+	}
+}
+
+func TestAWSProvider_GetResourceByARN(t *testing.T) {
+	fake := newFakeAWS()
+	fake.instances["222222222222/eu-west-1"] = [][]ec2types.Instance{{{InstanceId: aws.String("i-a")}}}
+
+	provider := newTestAWSProvider(config.AWSConfig{
+		RoleName: "SirosReadOnly",
+		Accounts: []config.AWSAccountConfig{{ID: "222222222222"}},
+	}, fake)
+
+	resource, err := provider.GetResource("arn:aws:ec2:eu-west-1:222222222222:instance/i-a")
+	if err != nil {
+		t.Fatalf("GetResource failed: %v", err)
+	}
+	if resource.ARN != "arn:aws:ec2:eu-west-1:222222222222:instance/i-a" {
+		t.Errorf("Unexpected ARN: %s", resource.ARN)
+	}
+
+	if _, err := provider.GetResource("arn:aws:ec2:eu-west-1:999999999999:instance/i-z"); err == nil {
+		t.Error("Expected error for unconfigured account")
+	}
+}
+
+func TestAWSProvider_GetResourceWithoutAccount(t *testing.T) {
+	fake := newFakeAWS()
+	fake.buckets[testHomeAccount] = []s3types.Bucket{{Name: aws.String("home-logs")}}
+	fake.buckets["222222222222"] = []s3types.Bucket{{Name: aws.String("member-logs")}}
+
+	// Role ARNs hold "::" too, which an account-less ARN must not match
+	provider := newTestAWSProvider(config.AWSConfig{
+		Accounts: []config.AWSAccountConfig{{RoleARN: "arn:aws:iam::222222222222:role/SirosReadOnly"}},
+	}, fake)
+
+	resource, err := provider.GetResource("arn:aws:s3:::home-logs")
+	if err != nil {
+		t.Fatalf("GetResource failed: %v", err)
+	}
+	if resource.Metadata["account_id"] != testHomeAccount {
+		t.Errorf("bucket resolved in account %v, want the home account %s", resource.Metadata["account_id"], testHomeAccount)
+	}
+
+	account, err := provider.accountFor(context.Background(), "222222222222")
+	if err != nil || account.id != "222222222222" {
+		t.Errorf("accountFor(222222222222) = %+v, %v; want the account of the role ARN", account, err)
+	}
+}
+
+func TestAWSPartition(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":      "aws",
+		"cn-north-1":     "aws-cn",
+		"us-gov-west-1":  "aws-us-gov",
+		"us-iso-east-1":  "aws-iso",
+		"us-isob-east-1": "aws-iso-b",
+	}
+	for region, expected := range tests {
+		if got := AWSPartition(region); got != expected {
+			t.Errorf("AWSPartition(%s) = %s, expected %s", region, got, expected)
+		}
+	}
+}
@@ -1,23 +1,89 @@
 package providers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/LederWorks/siros/backend/internal/config"
 	"github.com/LederWorks/siros/backend/pkg/types"
 )
 
+const (
+	azureDefaultAuthorityHost   = "https://login.microsoftonline.com"
+	azureDefaultResourceManager = "https://management.azure.com"
+	azureSubscriptionsAPI       = "2022-12-01"
+	azureResourceGraphAPI       = "2022-10-01"
+	azureResourceGraphPageSize  = 1000
+
+	// Resource Graph accepts at most this many subscriptions per request
+	azureResourceGraphMaxSubscriptions = 1000
+)
+
+// Resource Graph queries used by Scan. Containers are subscriptions and
+// resource groups; they are scanned separately so resources can be parented.
+const (
+	azureResourcesQuery  = "Resources | project id, name, type, location, resourceGroup, subscriptionId, tags, kind, sku, properties"
+	azureContainersQuery = "ResourceContainers | where type =~ 'microsoft.resources/subscriptions' or type =~ 'microsoft.resources/subscriptions/resourcegroups' | project id, name, type, location, resourceGroup, subscriptionId, tags, properties"
+)
+
+// azureResourceTypes maps ARM resource types to Siros types. Unlisted types
+// are derived from the ARM type by azureResourceType.
+var azureResourceTypes = map[string]string{
+	"microsoft.resources/subscriptions":                "azure.subscription",
+	"microsoft.resources/subscriptions/resourcegroups": "azure.resourcegroup",
+	"microsoft.compute/virtualmachines":                "azure.virtualmachine",
+	"microsoft.storage/storageaccounts":                "azure.storageaccount",
+}
+
+// azureSubscription is an entry in the ARM subscriptions list
+type azureSubscription struct {
+	SubscriptionID string `json:"subscriptionId"`
+	DisplayName    string `json:"displayName"`
+	State          string `json:"state"`
+}
+
+// azureGraphRow is a single row returned by a Resource Graph query
+type azureGraphRow struct {
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	Type           string                 `json:"type"`
+	Location       string                 `json:"location"`
+	ResourceGroup  string                 `json:"resourceGroup"`
+	SubscriptionID string                 `json:"subscriptionId"`
+	Tags           map[string]string      `json:"tags"`
+	Kind           string                 `json:"kind"`
+	SKU            map[string]interface{} `json:"sku"`
+	Properties     map[string]interface{} `json:"properties"`
+}
+
 // AzureProvider implements the Provider interface for Azure
 type AzureProvider struct {
 	config config.AzureConfig
+	client *http.Client
+	token  cachedToken
 }
 
 // NewAzureProvider creates a new Azure provider
 func NewAzureProvider(cfg config.AzureConfig) (*AzureProvider, error) {
+	if cfg.AuthorityHost == "" {
+		cfg.AuthorityHost = azureDefaultAuthorityHost
+	}
+	if cfg.ResourceManagerEndpoint == "" {
+		cfg.ResourceManagerEndpoint = azureDefaultResourceManager
+	}
+	cfg.AuthorityHost = strings.TrimRight(cfg.AuthorityHost, "/")
+	cfg.ResourceManagerEndpoint = strings.TrimRight(cfg.ResourceManagerEndpoint, "/")
+
 	return &AzureProvider{
 		config: cfg,
+		client: newHTTPClient(),
 	}, nil
 }
 
@@ -26,65 +92,429 @@ func (p *AzureProvider) Name() string {
 	return "azure"
 }
 
-// Validate validates the Azure configuration
+// Validate validates the Azure configuration and credentials
 func (p *AzureProvider) Validate() error {
-	// Basic validation - check required fields
-	if p.config.TenantID == "" || p.config.ClientID == "" || p.config.SubscriptionID == "" {
-		return fmt.Errorf("Azure configuration incomplete: missing tenant_id, client_id, or subscription_id")
+	if p.config.TenantID == "" || p.config.ClientID == "" || p.config.ClientSecret == "" {
+		return fmt.Errorf("Azure configuration incomplete: missing tenant_id, client_id, or client_secret")
+	}
+
+	subscriptions, err := p.listSubscriptions(context.Background())
+	if err != nil {
+		return fmt.Errorf("Azure credential validation failed: %w", err)
+	}
+	if p.config.SubscriptionID == "" {
+		return nil
+	}
+	for _, sub := range subscriptions {
+		if strings.EqualFold(sub.SubscriptionID, p.config.SubscriptionID) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Azure subscription %s is not accessible to client %s", p.config.SubscriptionID, p.config.ClientID)
+}
+
+// Scan queries Azure Resource Graph for every resource group and resource in
+// the accessible subscriptions. Resources are parented to their resource
+// group, and resource groups to their subscription.
+func (p *AzureProvider) Scan(ctx context.Context) ([]types.Resource, error) {
+	return collectScan(ctx, p.ScanStream)
+}
+
+// ScanStream is Scan, sending resources to out a Resource Graph page at a
+// time. Containers are listed first so resources can be parented to them.
+func (p *AzureProvider) ScanStream(ctx context.Context, out chan<- types.Resource) error {
+	subscriptions, err := p.resolveSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve Azure subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	var failures []ScanFailure
+
+	containers, err := p.queryResourceGraph(ctx, subscriptions, azureContainersQuery)
+	if err != nil {
+		failures = append(failures, ScanFailure{Service: "resourcegraph.containers", Err: err})
+	}
+
+	// ARM IDs are case-insensitive; parents are matched on the lowercased ID
+	// but recorded with the casing Resource Graph returned for the container.
+	known := make(map[string]string, len(containers))
+	for i := range containers {
+		known[strings.ToLower(containers[i].ID)] = containers[i].ID
+	}
+
+	send := func(rows []azureGraphRow) error {
+		for i := range rows {
+			select {
+			case out <- p.convertRow(&rows[i], known):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+	if err := send(containers); err != nil {
+		return err
+	}
+	if err := p.queryResourceGraphPages(ctx, subscriptions, azureResourcesQuery, send); err != nil {
+		failures = append(failures, ScanFailure{Service: "resourcegraph.resources", Err: err})
+	}
+
+	if len(failures) > 0 {
+		return &ScanError{Provider: p.Name(), Failures: failures}
 	}
 	return nil
 }
 
-// Scan scans Azure for resources (placeholder implementation)
-func (p *AzureProvider) Scan(_ context.Context) ([]types.Resource, error) {
-	// This is a placeholder implementation
-	// In a real implementation, you would use the Azure SDK to enumerate resources
-	return []types.Resource{
-		{
-			ID:       "mock-azure-vm-1",
-			Type:     "azure.virtualmachine",
-			Provider: "azure",
-			Region:   "eastus",
-			Name:     "mock-vm-1",
-			ARN:      fmt.Sprintf("/subscriptions/%s/resourceGroups/mock-rg/providers/Microsoft.Compute/virtualMachines/mock-vm-1", p.config.SubscriptionID),
-			Tags: map[string]string{
-				"environment": "demo",
-				"team":        "platform",
-			},
-			Metadata: map[string]interface{}{
-				"size":           "Standard_B2s",
-				"os_type":        "Linux",
-				"resource_group": "mock-rg",
-				"state":          "running",
-			},
-			State:     types.ResourceStateActive,
-			CreatedAt: time.Now().Add(-24 * time.Hour),
-			UpdatedAt: time.Now(),
-		},
-		{
-			ID:       "mock-azure-storage-1",
-			Type:     "azure.storageaccount",
-			Provider: "azure",
-			Region:   "eastus",
-			Name:     "mockstorage1",
-			ARN:      fmt.Sprintf("/subscriptions/%s/resourceGroups/mock-rg/providers/Microsoft.Storage/storageAccounts/mockstorage1", p.config.SubscriptionID),
-			Tags: map[string]string{
-				"environment": "demo",
-			},
-			Metadata: map[string]interface{}{
-				"sku":            "Standard_LRS",
-				"kind":           "StorageV2",
-				"resource_group": "mock-rg",
-			},
-			State:     types.ResourceStateActive,
-			CreatedAt: time.Now().Add(-48 * time.Hour),
-			UpdatedAt: time.Now(),
-		},
-	}, nil
+// GetResource retrieves a specific resource, resource group or subscription by its ARM ID
+func (p *AzureProvider) GetResource(id string) (*types.Resource, error) {
+	subscriptionID, ok := azureSubscriptionFromID(id)
+	if !ok || strings.ContainsAny(id, `'"\`) {
+		return nil, fmt.Errorf("invalid Azure resource ID: %s", id)
+	}
+
+	table := "Resources"
+	if !strings.Contains(strings.ToLower(id), "/providers/") {
+		table = "ResourceContainers"
+	}
+	query := fmt.Sprintf("%s | where id =~ '%s' | project id, name, type, location, resourceGroup, subscriptionId, tags, kind, sku, properties", table, id)
+
+	rows, err := p.queryResourceGraph(context.Background(), []string{subscriptionID}, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("resource not found: %s", id)
+	}
+
+	resource := p.convertRow(&rows[0], nil)
+	return &resource, nil
+}
+
+// resolveSubscriptions returns the configured subscriptions or every enabled subscription
+func (p *AzureProvider) resolveSubscriptions(ctx context.Context) ([]string, error) {
+	if len(p.config.Subscriptions) > 0 {
+		return p.config.Subscriptions, nil
+	}
+
+	subscriptions, err := p.listSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		// Disabled and deleted subscriptions reject Resource Graph queries
+		if sub.State == "Disabled" || sub.State == "Deleted" {
+			continue
+		}
+		ids = append(ids, sub.SubscriptionID)
+	}
+	return ids, nil
 }
 
-// GetResource retrieves a specific resource by ID (placeholder implementation)
-func (p *AzureProvider) GetResource(_ string) (*types.Resource, error) {
-	// This is a placeholder implementation
-	return nil, fmt.Errorf("Azure GetResource not implemented")
+// listSubscriptions lists every subscription visible to the service principal
+func (p *AzureProvider) listSubscriptions(ctx context.Context) ([]azureSubscription, error) {
+	next := p.config.ResourceManagerEndpoint + "/subscriptions?api-version=" + azureSubscriptionsAPI
+
+	var subscriptions []azureSubscription
+	for next != "" {
+		req, err := p.newRequest(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Value    []azureSubscription `json:"value"`
+			NextLink string              `json:"nextLink"`
+		}
+		if err := doJSON(p.client, req, &page); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, page.Value...)
+		next = page.NextLink
+	}
+	return subscriptions, nil
+}
+
+// queryResourceGraph runs a query across subscriptions and returns every row
+func (p *AzureProvider) queryResourceGraph(ctx context.Context, subscriptions []string, query string) ([]azureGraphRow, error) {
+	var rows []azureGraphRow
+	err := p.queryResourceGraphPages(ctx, subscriptions, query, func(page []azureGraphRow) error {
+		rows = append(rows, page...)
+		return nil
+	})
+	return rows, err
+}
+
+// queryResourceGraphPages runs a query across subscriptions, passing each page
+// of rows to fn. It follows $skipToken paging and batches subscriptions to
+// stay within the per-request limit.
+func (p *AzureProvider) queryResourceGraphPages(ctx context.Context, subscriptions []string, query string, fn func([]azureGraphRow) error) error {
+	endpoint := p.config.ResourceManagerEndpoint + "/providers/Microsoft.ResourceGraph/resources?api-version=" + azureResourceGraphAPI
+
+	for start := 0; start < len(subscriptions); start += azureResourceGraphMaxSubscriptions {
+		end := start + azureResourceGraphMaxSubscriptions
+		if end > len(subscriptions) {
+			end = len(subscriptions)
+		}
+
+		skipToken := ""
+		for {
+			options := map[string]interface{}{
+				"$top":         azureResourceGraphPageSize,
+				"resultFormat": "objectArray",
+			}
+			if skipToken != "" {
+				options["$skipToken"] = skipToken
+			}
+
+			req, err := p.newRequest(ctx, http.MethodPost, endpoint, map[string]interface{}{
+				"subscriptions": subscriptions[start:end],
+				"query":         query,
+				"options":       options,
+			})
+			if err != nil {
+				return err
+			}
+
+			var page struct {
+				Data      []azureGraphRow `json:"data"`
+				SkipToken string          `json:"$skipToken"`
+			}
+			if err := doJSON(p.client, req, &page); err != nil {
+				return err
+			}
+			if err := fn(page.Data); err != nil {
+				return err
+			}
+
+			if page.SkipToken == "" {
+				break
+			}
+			skipToken = page.SkipToken
+		}
+	}
+	return nil
+}
+
+// newRequest builds an authenticated Resource Manager request
+func (p *AzureProvider) newRequest(ctx context.Context, method, endpoint string, body interface{}) (*http.Request, error) {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// accessToken returns a cached token, requesting a new one with the client
+// credentials grant when it is missing or about to expire
+func (p *AzureProvider) accessToken(ctx context.Context) (string, error) {
+	return p.token.get(ctx, p.requestToken)
+}
+
+// requestToken requests a Resource Manager token with the client credentials grant
+func (p *AzureProvider) requestToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"scope":         {p.config.ResourceManagerEndpoint + "/.default"},
+	}
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", p.config.AuthorityHost, url.PathEscape(p.config.TenantID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var result struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := doJSON(p.client, req, &result); err != nil {
+		return "", 0, fmt.Errorf("failed to acquire Azure token: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", 0, fmt.Errorf("failed to acquire Azure token: empty access_token")
+	}
+
+	expiresIn, _ := result.ExpiresIn.Int64()
+	return result.AccessToken, time.Duration(expiresIn) * time.Second, nil
+}
+
+// convertRow converts a Resource Graph row to a Siros resource. known maps
+// lowercased container IDs to their canonical form and may be nil.
+func (p *AzureProvider) convertRow(row *azureGraphRow, known map[string]string) types.Resource {
+	tags := row.Tags
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+
+	metadata := map[string]interface{}{
+		"subscription_id": row.SubscriptionID,
+		"azure_type":      row.Type,
+	}
+	if row.ResourceGroup != "" {
+		metadata["resource_group"] = row.ResourceGroup
+	}
+	if row.Kind != "" {
+		metadata["kind"] = row.Kind
+	}
+	if row.SKU != nil {
+		metadata["sku"] = row.SKU
+	}
+	if state, ok := row.Properties["provisioningState"].(string); ok {
+		metadata["provisioning_state"] = state
+	}
+	if row.Properties != nil {
+		metadata["properties"] = row.Properties
+	}
+
+	name := row.Name
+	if strings.EqualFold(row.Type, "microsoft.resources/subscriptions") {
+		// Subscription rows carry the display name in properties
+		if displayName, ok := row.Properties["displayName"].(string); ok && displayName != "" {
+			name = displayName
+		}
+	}
+
+	now := time.Now()
+	return types.Resource{
+		ID:        row.ID,
+		Type:      azureResourceType(row.Type),
+		Provider:  "azure",
+		Region:    row.Location,
+		Name:      name,
+		ARN:       row.ID,
+		Tags:      tags,
+		Metadata:  metadata,
+		State:     azureState(row),
+		ParentID:  azureParentID(row, known),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// azureParentID returns the ID of the container a row belongs to: the parent
+// resource for child resources, else the resource group, else the subscription
+func azureParentID(row *azureGraphRow, known map[string]string) *string {
+	var parent string
+	armType := strings.ToLower(row.Type)
+
+	switch {
+	case armType == "microsoft.resources/subscriptions":
+		return nil
+	case armType == "microsoft.resources/subscriptions/resourcegroups":
+		parent = "/subscriptions/" + row.SubscriptionID
+	case strings.Count(armType, "/") >= 2:
+		// Child resources such as microsoft.sql/servers/databases nest under
+		// their parent's ID: drop the trailing "/<type>/<name>" pair
+		segments := strings.Split(row.ID, "/")
+		if len(segments) < 4 {
+			return nil
+		}
+		parent = strings.Join(segments[:len(segments)-2], "/")
+	case row.ResourceGroup != "":
+		parent = "/subscriptions/" + row.SubscriptionID + "/resourceGroups/" + row.ResourceGroup
+	default:
+		parent = "/subscriptions/" + row.SubscriptionID
+	}
+
+	if canonical, ok := known[strings.ToLower(parent)]; ok {
+		parent = canonical
+	}
+	return &parent
+}
+
+// azureResourceType maps an ARM type such as microsoft.network/virtualnetworks
+// to a Siros type such as azure.network.virtualnetwork
+func azureResourceType(armType string) string {
+	armType = strings.ToLower(armType)
+	if mapped, ok := azureResourceTypes[armType]; ok {
+		return mapped
+	}
+
+	segments := strings.Split(armType, "/")
+	segments[0] = strings.TrimPrefix(segments[0], "microsoft.")
+	for i := 1; i < len(segments); i++ {
+		segments[i] = singular(segments[i])
+	}
+	return "azure." + strings.Join(segments, ".")
+}
+
+// azureState derives the resource state from the provisioning state and,
+// for virtual machines, the power state Resource Graph exposes
+func azureState(row *azureGraphRow) types.ResourceState {
+	if extended, ok := row.Properties["extended"].(map[string]interface{}); ok {
+		if view, ok := extended["instanceView"].(map[string]interface{}); ok {
+			if power, ok := view["powerState"].(map[string]interface{}); ok {
+				switch power["code"] {
+				case "PowerState/running", "PowerState/starting":
+					return types.ResourceStateActive
+				case "PowerState/stopped", "PowerState/stopping", "PowerState/deallocated", "PowerState/deallocating":
+					return types.ResourceStateInactive
+				}
+			}
+		}
+	}
+
+	state, ok := row.Properties["provisioningState"].(string)
+	if !ok {
+		return types.ResourceStateActive
+	}
+	switch strings.ToLower(state) {
+	case "succeeded":
+		return types.ResourceStateActive
+	case "failed", "canceled":
+		return types.ResourceStateError
+	case "deleting", "deleted":
+		return types.ResourceStateTerminated
+	default:
+		return types.ResourceStateUnknown
+	}
+}
+
+// azureSubscriptionFromID extracts the subscription ID from an ARM ID
+func azureSubscriptionFromID(id string) (string, bool) {
+	segments := strings.Split(strings.TrimPrefix(id, "/"), "/")
+	if len(segments) < 2 || !strings.EqualFold(segments[0], "subscriptions") || segments[1] == "" {
+		return "", false
+	}
+	return segments[1], true
+}
+
+// singular returns the singular form of a lowercase plural resource type name
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss"):
+		return strings.TrimSuffix(name, "s")
+	default:
+		return name
+	}
 }
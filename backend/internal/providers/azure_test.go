@@ -0,0 +1,339 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const (
+	testAzureSubA = "00000000-0000-0000-0000-00000000000a"
+	testAzureSubB = "00000000-0000-0000-0000-00000000000b"
+)
+
+// fakeAzure stands in for the Entra ID token endpoint, the ARM subscriptions
+// list and Resource Graph. Graph pages are keyed by table and $skipToken.
+type fakeAzure struct {
+	t           *testing.T
+	mu          sync.Mutex
+	tokens      int
+	graphPages  map[string][]map[string]interface{}
+	graphBodies []map[string]interface{}
+	failTable   string
+}
+
+func newFakeAzure(t *testing.T) (*fakeAzure, *httptest.Server) {
+	fake := &fakeAzure{t: t, graphPages: make(map[string][]map[string]interface{})}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/tenant-1/oauth2/v2.0/token":
+		f.serveToken(w, r)
+	case r.Header.Get("Authorization") != "Bearer test-token":
+		http.Error(w, `{"error":{"code":"InvalidAuthenticationToken"}}`, http.StatusUnauthorized)
+	case r.Method == http.MethodGet && r.URL.Path == "/subscriptions":
+		f.serveSubscriptions(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/providers/Microsoft.ResourceGraph/resources":
+		f.serveGraph(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAzure) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		f.t.Fatalf("invalid token request: %v", err)
+	}
+	if r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("client_secret") != "secret" {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if !strings.HasSuffix(r.Form.Get("scope"), "/.default") {
+		f.t.Errorf("unexpected scope %q", r.Form.Get("scope"))
+	}
+
+	f.mu.Lock()
+	f.tokens++
+	f.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"access_token": "test-token", "expires_in": 3599, "token_type": "Bearer"})
+}
+
+func (f *fakeAzure) serveSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("page") == "" {
+		writeJSON(w, map[string]interface{}{
+			"value": []map[string]string{
+				{"subscriptionId": testAzureSubA, "displayName": "Production", "state": "Enabled"},
+				{"subscriptionId": "00000000-0000-0000-0000-00000000000d", "displayName": "Retired", "state": "Disabled"},
+			},
+			"nextLink": "http://" + r.Host + "/subscriptions?api-version=2022-12-01&page=2",
+		})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"value": []map[string]string{{"subscriptionId": testAzureSubB, "displayName": "Staging", "state": "Enabled"}},
+	})
+}
+
+func (f *fakeAzure) serveGraph(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		f.t.Fatalf("invalid graph request: %v", err)
+	}
+	f.mu.Lock()
+	f.graphBodies = append(f.graphBodies, body)
+	f.mu.Unlock()
+
+	query := body["query"].(string)
+	table := strings.TrimSpace(strings.SplitN(query, "|", 2)[0])
+	if table == f.failTable {
+		http.Error(w, `{"error":{"code":"RateLimiting"}}`, http.StatusTooManyRequests)
+		return
+	}
+	if strings.Contains(query, "where id =~") {
+		table += ":lookup"
+	}
+
+	options, _ := body["options"].(map[string]interface{})
+	skipToken, _ := options["$skipToken"].(string)
+
+	pages := f.graphPages[table]
+	page := 0
+	if skipToken != "" {
+		page = int(skipToken[len(skipToken)-1] - '0')
+	}
+	response := map[string]interface{}{"data": []interface{}{}}
+	if page < len(pages) {
+		response = pages[page]
+	}
+	if page+1 < len(pages) {
+		response["$skipToken"] = "token-" + string(rune('0'+page+1))
+	}
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestAzureProvider(t *testing.T, server *httptest.Server, mutate func(*config.AzureConfig)) *AzureProvider {
+	cfg := config.AzureConfig{
+		TenantID:                "tenant-1",
+		ClientID:                "client-1",
+		ClientSecret:            "secret",
+		AuthorityHost:           server.URL,
+		ResourceManagerEndpoint: server.URL + "/",
+	}
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	provider, err := NewAzureProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewAzureProvider failed: %v", err)
+	}
+	return provider
+}
+
+func azureContainerPages() []map[string]interface{} {
+	return []map[string]interface{}{{
+		"data": []map[string]interface{}{
+			{
+				"id": "/subscriptions/" + testAzureSubA, "name": testAzureSubA, "type": "microsoft.resources/subscriptions",
+				"subscriptionId": testAzureSubA, "properties": map[string]interface{}{"displayName": "Production"},
+			},
+			{
+				"id": "/subscriptions/" + testAzureSubA + "/resourceGroups/Web-RG", "name": "Web-RG",
+				"type": "microsoft.resources/subscriptions/resourcegroups", "location": "westeurope",
+				"resourceGroup": "web-rg", "subscriptionId": testAzureSubA, "tags": map[string]string{"env": "prod"},
+				"properties": map[string]interface{}{"provisioningState": "Succeeded"},
+			},
+		},
+	}}
+}
+
+func azureResourcePages() []map[string]interface{} {
+	rg := "/subscriptions/" + testAzureSubA + "/resourceGroups/web-rg"
+	return []map[string]interface{}{
+		{"data": []map[string]interface{}{{
+			"id": rg + "/providers/Microsoft.Compute/virtualMachines/web-1", "name": "web-1",
+			"type": "microsoft.compute/virtualmachines", "location": "westeurope", "resourceGroup": "web-rg",
+			"subscriptionId": testAzureSubA, "tags": map[string]string{"team": "web"},
+			"properties": map[string]interface{}{
+				"provisioningState": "Succeeded",
+				"extended":          map[string]interface{}{"instanceView": map[string]interface{}{"powerState": map[string]interface{}{"code": "PowerState/deallocated"}}},
+			},
+		}}},
+		{"data": []map[string]interface{}{
+			{
+				"id": rg + "/providers/Microsoft.Sql/servers/orders", "name": "orders", "type": "microsoft.sql/servers",
+				"location": "westeurope", "resourceGroup": "web-rg", "subscriptionId": testAzureSubA,
+				"sku": map[string]interface{}{"name": "GP_Gen5"}, "properties": map[string]interface{}{"provisioningState": "Failed"},
+			},
+			{
+				"id": rg + "/providers/Microsoft.Sql/servers/orders/databases/main", "name": "orders/main",
+				"type": "microsoft.sql/servers/databases", "location": "westeurope", "resourceGroup": "web-rg",
+				"subscriptionId": testAzureSubA,
+			},
+		}},
+	}
+}
+
+func TestAzureProvider_ScanUsesResourceGraph(t *testing.T) {
+	fake, server := newFakeAzure(t)
+	fake.graphPages["ResourceContainers"] = azureContainerPages()
+	fake.graphPages["Resources"] = azureResourcePages()
+
+	provider := newTestAzureProvider(t, server, nil)
+	resources, err := provider.Scan(t.Context())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(resources) != 5 {
+		t.Fatalf("Expected 5 resources, got %d", len(resources))
+	}
+	if fake.tokens != 1 {
+		t.Errorf("Expected the token to be reused, got %d token requests", fake.tokens)
+	}
+
+	// Subscriptions come from both pages of the ARM list, minus disabled ones
+	subs := fake.graphBodies[0]["subscriptions"].([]interface{})
+	if len(subs) != 2 || subs[0] != testAzureSubA || subs[1] != testAzureSubB {
+		t.Errorf("Unexpected subscriptions queried: %v", subs)
+	}
+
+	byID := resourcesByID(resources)
+	rgID := "/subscriptions/" + testAzureSubA + "/resourceGroups/Web-RG"
+
+	sub := byID["/subscriptions/"+testAzureSubA]
+	if sub.Type != "azure.subscription" || sub.Name != "Production" || sub.ParentID != nil {
+		t.Errorf("Unexpected subscription: %+v", sub)
+	}
+
+	rg := byID[rgID]
+	if rg.Type != "azure.resourcegroup" || rg.ParentID == nil || *rg.ParentID != "/subscriptions/"+testAzureSubA {
+		t.Errorf("Expected resource group parented to its subscription: %+v", rg)
+	}
+
+	vm := byID["/subscriptions/"+testAzureSubA+"/resourceGroups/web-rg/providers/Microsoft.Compute/virtualMachines/web-1"]
+	if vm.Type != "azure.virtualmachine" || vm.Provider != "azure" || vm.Region != "westeurope" {
+		t.Errorf("Unexpected VM: %+v", vm)
+	}
+	if vm.ParentID == nil || *vm.ParentID != rgID {
+		t.Errorf("Expected VM parented to the resource group's canonical ID, got %v", vm.ParentID)
+	}
+	if vm.Tags["team"] != "web" || vm.State != types.ResourceStateInactive {
+		t.Errorf("Expected deallocated VM with tags, got state %s tags %v", vm.State, vm.Tags)
+	}
+
+	sqlServer := byID["/subscriptions/"+testAzureSubA+"/resourceGroups/web-rg/providers/Microsoft.Sql/servers/orders"]
+	if sqlServer.Type != "azure.sql.server" || sqlServer.State != types.ResourceStateError {
+		t.Errorf("Unexpected SQL server: %+v", sqlServer)
+	}
+	if sqlServer.Tags == nil {
+		t.Error("Expected non-nil tags for untagged resource")
+	}
+
+	db := byID["/subscriptions/"+testAzureSubA+"/resourceGroups/web-rg/providers/Microsoft.Sql/servers/orders/databases/main"]
+	if db.Type != "azure.sql.server.database" || db.ParentID == nil || *db.ParentID != sqlServer.ID {
+		t.Errorf("Expected database parented to its server: %+v", db)
+	}
+}
+
+func TestAzureProvider_ScanConfiguredSubscriptions(t *testing.T) {
+	fake, server := newFakeAzure(t)
+	provider := newTestAzureProvider(t, server, func(cfg *config.AzureConfig) {
+		cfg.Subscriptions = []string{testAzureSubB}
+	})
+
+	if _, err := provider.Scan(t.Context()); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	for _, body := range fake.graphBodies {
+		subs := body["subscriptions"].([]interface{})
+		if len(subs) != 1 || subs[0] != testAzureSubB {
+			t.Errorf("Expected only the configured subscription, got %v", subs)
+		}
+	}
+}
+
+func TestAzureProvider_ScanIsolatesGraphFailures(t *testing.T) {
+	fake, server := newFakeAzure(t)
+	fake.graphPages["ResourceContainers"] = azureContainerPages()
+	fake.failTable = "Resources"
+
+	provider := newTestAzureProvider(t, server, nil)
+	resources, err := provider.Scan(t.Context())
+	if !IsPartialScan(err) {
+		t.Fatalf("Expected partial scan error, got %v", err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("Expected containers to survive the failed resource query, got %d", len(resources))
+	}
+}
+
+func TestAzureProvider_GetResource(t *testing.T) {
+	fake, server := newFakeAzure(t)
+	vmID := "/subscriptions/" + testAzureSubA + "/resourceGroups/web-rg/providers/Microsoft.Compute/virtualMachines/web-1"
+	fake.graphPages["Resources:lookup"] = azureResourcePages()[:1]
+
+	provider := newTestAzureProvider(t, server, nil)
+	resource, err := provider.GetResource(vmID)
+	if err != nil {
+		t.Fatalf("GetResource failed: %v", err)
+	}
+	if resource.ID != vmID || resource.ParentID == nil || *resource.ParentID != "/subscriptions/"+testAzureSubA+"/resourceGroups/web-rg" {
+		t.Errorf("Unexpected resource: %+v", resource)
+	}
+	if subs := fake.graphBodies[0]["subscriptions"].([]interface{}); len(subs) != 1 || subs[0] != testAzureSubA {
+		t.Errorf("Expected lookup scoped to the ID's subscription, got %v", subs)
+	}
+
+	if _, err := provider.GetResource("/subscriptions/" + testAzureSubA + "/resourceGroups/missing"); err == nil {
+		t.Error("Expected not found error")
+	}
+	if _, err := provider.GetResource("web-1"); err == nil {
+		t.Error("Expected error for a non-ARM ID")
+	}
+}
+
+func TestAzureProvider_ValidateRejectsBadCredentials(t *testing.T) {
+	_, server := newFakeAzure(t)
+	provider := newTestAzureProvider(t, server, func(cfg *config.AzureConfig) {
+		cfg.ClientSecret = "wrong"
+	})
+	if err := provider.Validate(); err == nil {
+		t.Error("Expected validation to fail with bad credentials")
+	}
+
+	provider = newTestAzureProvider(t, server, func(cfg *config.AzureConfig) {
+		cfg.SubscriptionID = testAzureSubB
+	})
+	if err := provider.Validate(); err != nil {
+		t.Errorf("Expected accessible subscription to validate, got %v", err)
+	}
+}
+
+func TestAzureResourceType(t *testing.T) {
+	tests := map[string]string{
+		"Microsoft.Compute/virtualMachines":          "azure.virtualmachine",
+		"microsoft.network/publicipaddresses":        "azure.network.publicipaddress",
+		"microsoft.network/networksecuritygroups":    "azure.network.networksecuritygroup",
+		"microsoft.containerservice/managedclusters": "azure.containerservice.managedcluster",
+		"microsoft.authorization/policies":           "azure.authorization.policy",
+	}
+	for armType, expected := range tests {
+		if got := azureResourceType(armType); got != expected {
+			t.Errorf("azureResourceType(%s) = %s, expected %s", armType, got, expected)
+		}
+	}
+}
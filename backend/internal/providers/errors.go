@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ScanFailure describes a single unit of scan work that failed
+type ScanFailure struct {
+	Account string `json:"account,omitempty"`
+	Region  string `json:"region,omitempty"`
+	Service string `json:"service"`
+	Err     error  `json:"-"`
+}
+
+// Error implements the error interface
+func (f ScanFailure) Error() string {
+	var scope []string
+	if f.Account != "" {
+		scope = append(scope, "account "+f.Account)
+	}
+	if f.Region != "" {
+		scope = append(scope, "region "+f.Region)
+	}
+	if len(scope) == 0 {
+		return fmt.Sprintf("%s: %v", f.Service, f.Err)
+	}
+	return fmt.Sprintf("%s (%s): %v", f.Service, strings.Join(scope, ", "), f.Err)
+}
+
+// Unwrap returns the underlying error
+func (f ScanFailure) Unwrap() error {
+	return f.Err
+}
+
+// ScanError reports failures that were isolated during a scan. Resources
+// returned alongside a ScanError are valid; only the listed scopes are missing.
+type ScanError struct {
+	Provider string
+	Failures []ScanFailure
+}
+
+// Error implements the error interface
+func (e *ScanError) Error() string {
+	if len(e.Failures) == 1 {
+		return fmt.Sprintf("%s scan partially failed: %v", e.Provider, e.Failures[0])
+	}
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%s scan partially failed (%d failures): %s", e.Provider, len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual failures
+func (e *ScanError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
+
+// IsPartialScan reports whether err only describes isolated scan failures
+func IsPartialScan(err error) bool {
+	var scanErr *ScanError
+	return errors.As(err, &scanErr)
+}
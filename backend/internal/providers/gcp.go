@@ -2,22 +2,127 @@ package providers
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/LederWorks/siros/backend/internal/config"
 	"github.com/LederWorks/siros/backend/pkg/types"
 )
 
+const (
+	gcpDefaultAssetEndpoint = "https://cloudasset.googleapis.com"
+	gcpDefaultTokenURI      = "https://oauth2.googleapis.com/token"
+	gcpCloudPlatformScope   = "https://www.googleapis.com/auth/cloud-platform"
+	gcpJWTBearerGrant       = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	gcpTokenLifetime        = time.Hour
+	gcpAssetPageSize        = 500
+
+	gcpAssetAPISearch = "search"
+	gcpAssetAPIList   = "list"
+)
+
+// gcpResourceTypes maps Cloud Asset types to Siros types. Unlisted types are
+// derived from the asset type by gcpResourceType.
+var gcpResourceTypes = map[string]string{
+	"cloudresourcemanager.googleapis.com/Organization": "gcp.organization",
+	"cloudresourcemanager.googleapis.com/Folder":       "gcp.folder",
+	"cloudresourcemanager.googleapis.com/Project":      "gcp.project",
+}
+
+// gcpServiceAccountKey is the subset of a service account key file used for signing
+type gcpServiceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// gcpAsset is the common form of a searchAllResources result and a listAssets asset
+type gcpAsset struct {
+	Name        string
+	AssetType   string
+	DisplayName string
+	Location    string
+	Labels      map[string]string
+	State       string
+	Parent      string
+	Ancestors   []string
+	Attributes  map[string]interface{}
+	CreateTime  string
+	UpdateTime  string
+}
+
+// gcpSearchResult is a ResourceSearchResult from searchAllResources
+type gcpSearchResult struct {
+	Name                   string                 `json:"name"`
+	AssetType              string                 `json:"assetType"`
+	Project                string                 `json:"project"`
+	Folders                []string               `json:"folders"`
+	Organization           string                 `json:"organization"`
+	DisplayName            string                 `json:"displayName"`
+	Description            string                 `json:"description"`
+	Location               string                 `json:"location"`
+	Labels                 map[string]string      `json:"labels"`
+	NetworkTags            []string               `json:"networkTags"`
+	State                  string                 `json:"state"`
+	CreateTime             string                 `json:"createTime"`
+	UpdateTime             string                 `json:"updateTime"`
+	ParentFullResourceName string                 `json:"parentFullResourceName"`
+	AdditionalAttributes   map[string]interface{} `json:"additionalAttributes"`
+}
+
+// gcpListedAsset is an Asset from listAssets with RESOURCE content
+type gcpListedAsset struct {
+	Name       string   `json:"name"`
+	AssetType  string   `json:"assetType"`
+	Ancestors  []string `json:"ancestors"`
+	UpdateTime string   `json:"updateTime"`
+	Resource   struct {
+		Parent   string                 `json:"parent"`
+		Location string                 `json:"location"`
+		Data     map[string]interface{} `json:"data"`
+	} `json:"resource"`
+}
+
 // GCPProvider implements the Provider interface for Google Cloud Platform
 type GCPProvider struct {
 	config config.GCPConfig
+	client *http.Client
+	token  cachedToken
+
+	key        *gcpServiceAccountKey
+	signingKey *rsa.PrivateKey
 }
 
 // NewGCPProvider creates a new GCP provider
 func NewGCPProvider(cfg config.GCPConfig) (*GCPProvider, error) {
+	if cfg.AssetEndpoint == "" {
+		cfg.AssetEndpoint = gcpDefaultAssetEndpoint
+	}
+	cfg.AssetEndpoint = strings.TrimRight(cfg.AssetEndpoint, "/")
+	if cfg.AssetAPI == "" {
+		cfg.AssetAPI = gcpAssetAPISearch
+	}
+
 	return &GCPProvider{
 		config: cfg,
+		client: newHTTPClient(),
 	}, nil
 }
 
@@ -26,65 +131,498 @@ func (p *GCPProvider) Name() string {
 	return "gcp"
 }
 
-// Validate validates the GCP configuration
+// Validate validates the GCP configuration and credentials
 func (p *GCPProvider) Validate() error {
-	// Basic validation - check required fields
-	if p.config.ProjectID == "" {
-		return fmt.Errorf("GCP configuration incomplete: missing project_id")
+	if p.scope() == "" {
+		return fmt.Errorf("GCP configuration incomplete: one of project_id, folder_id, or organization_id is required")
+	}
+	if p.config.ServiceAccountKeyFile == "" {
+		return fmt.Errorf("GCP configuration incomplete: missing service_account_key_file")
+	}
+	if p.config.AssetAPI != gcpAssetAPISearch && p.config.AssetAPI != gcpAssetAPIList {
+		return fmt.Errorf("invalid GCP asset_api %q: expected %q or %q", p.config.AssetAPI, gcpAssetAPISearch, gcpAssetAPIList)
+	}
+
+	if _, err := p.accessToken(context.Background()); err != nil {
+		return fmt.Errorf("GCP credential validation failed: %w", err)
 	}
 	return nil
 }
 
-// Scan scans GCP for resources (placeholder implementation)
-func (p *GCPProvider) Scan(_ context.Context) ([]types.Resource, error) {
-	// This is a placeholder implementation
-	// In a real implementation, you would use the GCP SDK to enumerate resources
-	return []types.Resource{
-		{
-			ID:       "mock-gcp-instance-1",
-			Type:     "gcp.compute.instance",
-			Provider: "gcp",
-			Region:   p.config.Region,
-			Name:     "mock-instance-1",
-			ARN:      fmt.Sprintf("projects/%s/zones/%s-a/instances/mock-instance-1", p.config.ProjectID, p.config.Region),
-			Tags: map[string]string{
-				"environment": "demo",
-				"team":        "platform",
-			},
-			Metadata: map[string]interface{}{
-				"machine_type": "e2-medium",
-				"zone":         fmt.Sprintf("%s-a", p.config.Region),
-				"status":       "RUNNING",
-				"project":      p.config.ProjectID,
-			},
-			State:     types.ResourceStateActive,
-			CreatedAt: time.Now().Add(-24 * time.Hour),
-			UpdatedAt: time.Now(),
-		},
-		{
-			ID:       "mock-gcp-bucket-1",
-			Type:     "gcp.storage.bucket",
-			Provider: "gcp",
-			Region:   p.config.Region,
-			Name:     "mock-bucket-1",
-			ARN:      fmt.Sprintf("projects/%s/buckets/mock-bucket-1", p.config.ProjectID),
-			Tags: map[string]string{
-				"environment": "demo",
-			},
-			Metadata: map[string]interface{}{
-				"storage_class": "STANDARD",
-				"location":      p.config.Region,
-				"project":       p.config.ProjectID,
-			},
-			State:     types.ResourceStateActive,
-			CreatedAt: time.Now().Add(-48 * time.Hour),
-			UpdatedAt: time.Now(),
-		},
-	}, nil
+// Scan lists every asset in the configured project, folder or organization
+// through Cloud Asset Inventory
+func (p *GCPProvider) Scan(ctx context.Context) ([]types.Resource, error) {
+	return collectScan(ctx, p.ScanStream)
+}
+
+// ScanStream is Scan, sending each asset to out as its page is read
+func (p *GCPProvider) ScanStream(ctx context.Context, out chan<- types.Resource) error {
+	scope := p.scope()
+	if scope == "" {
+		return fmt.Errorf("GCP scan scope is not configured")
+	}
+
+	sent := 0
+	send := func(asset *gcpAsset) error {
+		select {
+		case out <- p.convertAsset(asset):
+			sent++
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var err error
+	if p.config.AssetAPI == gcpAssetAPIList {
+		err = p.listAssets(ctx, scope, send)
+	} else {
+		err = p.searchAllResources(ctx, scope, "", send)
+	}
+
+	if err != nil {
+		if sent == 0 {
+			return fmt.Errorf("failed to scan %s: %w", scope, err)
+		}
+		// Keep the pages that were read before the failure
+		return &ScanError{Provider: p.Name(), Failures: []ScanFailure{{Service: "cloudasset", Err: err}}}
+	}
+	return nil
+}
+
+// GetResource retrieves a specific resource by its full resource name, e.g.
+// //compute.googleapis.com/projects/p/zones/z/instances/i
+func (p *GCPProvider) GetResource(id string) (*types.Resource, error) {
+	if !strings.HasPrefix(id, "//") || strings.ContainsAny(id, `"\`) {
+		return nil, fmt.Errorf("invalid GCP resource name: %s", id)
+	}
+
+	var found *types.Resource
+	err := p.searchAllResources(context.Background(), p.scope(), fmt.Sprintf("name=%q", id), func(asset *gcpAsset) error {
+		if found == nil && asset.Name == id {
+			resource := p.convertAsset(asset)
+			found = &resource
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("resource not found: %s", id)
+	}
+	return found, nil
+}
+
+// scope returns the broadest configured Cloud Asset scope
+func (p *GCPProvider) scope() string {
+	switch {
+	case p.config.OrganizationID != "":
+		return "organizations/" + p.config.OrganizationID
+	case p.config.FolderID != "":
+		return "folders/" + p.config.FolderID
+	case p.config.ProjectID != "":
+		return "projects/" + p.config.ProjectID
+	default:
+		return ""
+	}
+}
+
+// searchAllResources pages through searchAllResources, optionally filtered by
+// query, passing each asset to each
+func (p *GCPProvider) searchAllResources(ctx context.Context, scope, query string, each func(*gcpAsset) error) error {
+	params := url.Values{"pageSize": {fmt.Sprint(gcpAssetPageSize)}}
+	if query != "" {
+		params.Set("query", query)
+	}
+	for _, assetType := range p.config.AssetTypes {
+		params.Add("assetTypes", assetType)
+	}
+
+	return p.paginate(ctx, fmt.Sprintf("%s/v1/%s:searchAllResources", p.config.AssetEndpoint, scope), params, func(body json.RawMessage) error {
+		var page struct {
+			Results []gcpSearchResult `json:"results"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to decode search results: %w", err)
+		}
+		for i := range page.Results {
+			asset := page.Results[i].asset()
+			if err := each(&asset); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// listAssets pages through listAssets with RESOURCE content, passing each asset to each
+func (p *GCPProvider) listAssets(ctx context.Context, scope string, each func(*gcpAsset) error) error {
+	params := url.Values{
+		"contentType": {"RESOURCE"},
+		"pageSize":    {fmt.Sprint(gcpAssetPageSize)},
+	}
+	for _, assetType := range p.config.AssetTypes {
+		params.Add("assetTypes", assetType)
+	}
+
+	return p.paginate(ctx, fmt.Sprintf("%s/v1/%s/assets", p.config.AssetEndpoint, scope), params, func(body json.RawMessage) error {
+		var page struct {
+			Assets []gcpListedAsset `json:"assets"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to decode assets: %w", err)
+		}
+		for i := range page.Assets {
+			asset := page.Assets[i].asset()
+			if err := each(&asset); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// paginate issues GET requests following nextPageToken, passing each page to handle
+func (p *GCPProvider) paginate(ctx context.Context, endpoint string, params url.Values, handle func(json.RawMessage) error) error {
+	for {
+		token, err := p.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), http.NoBody)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		var page json.RawMessage
+		if err := doJSON(p.client, req, &page); err != nil {
+			return err
+		}
+		if err := handle(page); err != nil {
+			return err
+		}
+
+		var next struct {
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(page, &next); err != nil {
+			return err
+		}
+		if next.NextPageToken == "" {
+			return nil
+		}
+		params.Set("pageToken", next.NextPageToken)
+	}
+}
+
+// accessToken returns a cached OAuth token for the service account
+func (p *GCPProvider) accessToken(ctx context.Context) (string, error) {
+	return p.token.get(ctx, p.requestToken)
+}
+
+// requestToken exchanges a signed JWT assertion for an access token
+func (p *GCPProvider) requestToken(ctx context.Context) (string, time.Duration, error) {
+	if err := p.loadKey(); err != nil {
+		return "", 0, err
+	}
+
+	assertion, err := p.signAssertion(time.Now())
+	if err != nil {
+		return "", 0, err
+	}
+
+	form := url.Values{
+		"grant_type": {gcpJWTBearerGrant},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.key.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := doJSON(p.client, req, &result); err != nil {
+		return "", 0, fmt.Errorf("failed to acquire GCP token: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", 0, fmt.Errorf("failed to acquire GCP token: empty access_token")
+	}
+	return result.AccessToken, time.Duration(result.ExpiresIn) * time.Second, nil
 }
 
-// GetResource retrieves a specific resource by ID (placeholder implementation)
-func (p *GCPProvider) GetResource(_ string) (*types.Resource, error) {
-	// This is a placeholder implementation
-	return nil, fmt.Errorf("GCP GetResource not implemented")
+// loadKey reads and parses the service account key file once
+func (p *GCPProvider) loadKey() error {
+	if p.key != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(p.config.ServiceAccountKeyFile))
+	if err != nil {
+		return fmt.Errorf("failed to read service account key: %w", err)
+	}
+
+	var key gcpServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return fmt.Errorf("service account key file is not a service_account key")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = gcpDefaultTokenURI
+	}
+
+	signingKey, err := parseRSAPrivateKey([]byte(key.PrivateKey))
+	if err != nil {
+		return fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+
+	p.key = &key
+	p.signingKey = signingKey
+	return nil
+}
+
+// signAssertion builds the RS256-signed JWT used for the jwt-bearer grant
+func (p *GCPProvider) signAssertion(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": p.key.PrivateKeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   p.key.ClientEmail,
+		"scope": gcpCloudPlatformScope,
+		"aud":   p.key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(gcpTokenLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.signingKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// convertAsset converts a Cloud Asset to a Siros resource
+func (p *GCPProvider) convertAsset(asset *gcpAsset) types.Resource {
+	labels := asset.Labels
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	name := asset.DisplayName
+	if name == "" {
+		name = asset.Name[strings.LastIndex(asset.Name, "/")+1:]
+	}
+
+	metadata := map[string]interface{}{
+		"asset_type": asset.AssetType,
+		"location":   asset.Location,
+		"ancestors":  asset.Ancestors,
+	}
+	for _, ancestor := range asset.Ancestors {
+		if strings.HasPrefix(ancestor, "projects/") {
+			metadata["project"] = ancestor
+			break
+		}
+	}
+	if asset.State != "" {
+		metadata["state"] = asset.State
+	}
+	if asset.CreateTime != "" {
+		metadata["create_time"] = asset.CreateTime
+	}
+	if asset.UpdateTime != "" {
+		metadata["update_time"] = asset.UpdateTime
+	}
+	if len(asset.Attributes) > 0 {
+		metadata["attributes"] = asset.Attributes
+	}
+
+	var parentID *string
+	if asset.Parent != "" {
+		parent := asset.Parent
+		parentID = &parent
+	}
+
+	now := time.Now()
+	return types.Resource{
+		ID:        asset.Name,
+		Type:      gcpResourceType(asset.AssetType),
+		Provider:  "gcp",
+		Region:    asset.Location,
+		Name:      name,
+		ARN:       asset.Name,
+		Tags:      labels,
+		Metadata:  metadata,
+		State:     gcpState(asset.State),
+		ParentID:  parentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// asset converts a search result to the common asset form. The ancestor chain
+// runs from the nearest container up to the organization.
+func (r *gcpSearchResult) asset() gcpAsset {
+	var ancestors []string
+	if r.Project != "" {
+		ancestors = append(ancestors, r.Project)
+	}
+	ancestors = append(ancestors, r.Folders...)
+	if r.Organization != "" {
+		ancestors = append(ancestors, r.Organization)
+	}
+
+	return gcpAsset{
+		Name:        r.Name,
+		AssetType:   r.AssetType,
+		DisplayName: r.DisplayName,
+		Location:    r.Location,
+		Labels:      r.Labels,
+		State:       r.State,
+		Parent:      r.ParentFullResourceName,
+		Ancestors:   ancestors,
+		Attributes:  r.AdditionalAttributes,
+		CreateTime:  r.CreateTime,
+		UpdateTime:  r.UpdateTime,
+	}
+}
+
+// asset converts a listed asset to the common asset form. listAssets reports
+// the asset itself as the first ancestor, which is dropped.
+func (a *gcpListedAsset) asset() gcpAsset {
+	data := a.Resource.Data
+
+	ancestors := a.Ancestors
+	if len(ancestors) > 0 && strings.HasSuffix(a.Name, "/"+ancestors[0]) {
+		ancestors = ancestors[1:]
+	}
+
+	labels := make(map[string]string)
+	if raw, ok := data["labels"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			labels[k] = fmt.Sprint(v)
+		}
+	}
+
+	displayName, _ := data["displayName"].(string)
+	if displayName == "" {
+		displayName, _ = data["name"].(string)
+		displayName = displayName[strings.LastIndex(displayName, "/")+1:]
+	}
+
+	var state string
+	for _, field := range []string{"status", "state", "lifecycleState"} {
+		if s, ok := data[field].(string); ok {
+			state = s
+			break
+		}
+	}
+	createTime, _ := data["creationTimestamp"].(string)
+	if createTime == "" {
+		createTime, _ = data["createTime"].(string)
+	}
+
+	return gcpAsset{
+		Name:        a.Name,
+		AssetType:   a.AssetType,
+		DisplayName: displayName,
+		Location:    a.Resource.Location,
+		Labels:      labels,
+		State:       state,
+		Parent:      a.Resource.Parent,
+		Ancestors:   ancestors,
+		Attributes:  data,
+		CreateTime:  createTime,
+		UpdateTime:  a.UpdateTime,
+	}
+}
+
+// gcpResourceType maps an asset type such as compute.googleapis.com/ForwardingRule
+// to a Siros type such as gcp.compute.forwarding_rule
+func gcpResourceType(assetType string) string {
+	if mapped, ok := gcpResourceTypes[assetType]; ok {
+		return mapped
+	}
+
+	service, kind, ok := strings.Cut(assetType, "/")
+	if !ok {
+		return "gcp." + strings.ToLower(assetType)
+	}
+	service, _, _ = strings.Cut(service, ".")
+	return "gcp." + strings.ToLower(service) + "." + snakeCase(kind)
+}
+
+// gcpState maps the asset state reported by Cloud Asset Inventory. Compute
+// instances report TERMINATED when stopped, so it maps to inactive.
+func gcpState(state string) types.ResourceState {
+	switch strings.ToUpper(state) {
+	case "", "RUNNING", "ACTIVE", "READY", "ENABLED", "RUNNABLE", "AVAILABLE":
+		return types.ResourceStateActive
+	case "STOPPED", "STOPPING", "TERMINATED", "SUSPENDED", "DISABLED", "PROVISIONING", "STAGING":
+		return types.ResourceStateInactive
+	case "DELETE_REQUESTED", "DELETE_IN_PROGRESS", "DELETING", "DELETED":
+		return types.ResourceStateTerminated
+	case "ERROR", "FAILED", "DEGRADED":
+		return types.ResourceStateError
+	default:
+		return types.ResourceStateUnknown
+	}
+}
+
+// parseRSAPrivateKey parses a PEM encoded PKCS#8 or PKCS#1 RSA private key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// snakeCase converts a CamelCase name to snake_case
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Break before an upper-case letter that starts a new word, keeping
+			// acronyms such as "SQLInstance" together as "sql_instance"
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
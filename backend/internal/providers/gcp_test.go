@@ -0,0 +1,284 @@
+package providers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// fakeGCP stands in for the OAuth token endpoint and Cloud Asset Inventory.
+// Responses are served from testdata/gcp/<file>.json, keyed by page token.
+type fakeGCP struct {
+	t        *testing.T
+	key      *rsa.PublicKey
+	tokens   int
+	requests []*http.Request
+}
+
+func (f *fakeGCP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		f.serveToken(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer gcp-token" {
+		http.Error(w, `{"error":{"code":401}}`, http.StatusUnauthorized)
+		return
+	}
+	f.requests = append(f.requests, r)
+
+	var fixture string
+	switch r.URL.Path {
+	case "/v1/organizations/123:searchAllResources":
+		fixture = "searchAllResources"
+	case "/v1/projects/web-prod:searchAllResources":
+		fixture = "searchLookup"
+	case "/v1/folders/456/assets":
+		fixture = "listAssets"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", "gcp", fixture+".json"))
+	if err != nil {
+		f.t.Fatalf("failed to read fixture: %v", err)
+	}
+	var pages map[string]json.RawMessage
+	if err := json.Unmarshal(data, &pages); err != nil {
+		f.t.Fatalf("invalid fixture %s: %v", fixture, err)
+	}
+	page, ok := pages[r.URL.Query().Get("pageToken")]
+	if !ok {
+		http.Error(w, `{"error":{"code":400,"message":"invalid page token"}}`, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(page)
+}
+
+// serveToken verifies the signed JWT assertion before issuing a token
+func (f *fakeGCP) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != gcpJWTBearerGrant {
+		http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(r.Form.Get("assertion"), ".")
+	if len(parts) != 3 {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, digest[:], signature); err != nil {
+		http.Error(w, `{"error":"invalid_grant","error_description":"Invalid JWT Signature."}`, http.StatusBadRequest)
+		return
+	}
+
+	var claims map[string]interface{}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	_ = json.Unmarshal(payload, &claims)
+	if claims["iss"] != "siros@web-prod.iam.gserviceaccount.com" || claims["scope"] != gcpCloudPlatformScope {
+		f.t.Errorf("unexpected JWT claims: %v", claims)
+	}
+	if claims["aud"] != "http://"+r.Host+"/token" {
+		f.t.Errorf("expected aud to be the token URI, got %v", claims["aud"])
+	}
+
+	f.tokens++
+	writeJSON(w, map[string]interface{}{"access_token": "gcp-token", "expires_in": 3599, "token_type": "Bearer"})
+}
+
+func newTestGCPProvider(t *testing.T, mutate func(*config.GCPConfig)) (*GCPProvider, *fakeGCP) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	fake := &fakeGCP{t: t, key: &key.PublicKey}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	keyFile, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "web-prod",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "siros@web-prod.iam.gserviceaccount.com",
+		"token_uri":      server.URL + "/token",
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal key file: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(keyPath, keyFile, 0o600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	cfg := config.GCPConfig{
+		ProjectID:             "web-prod",
+		ServiceAccountKeyFile: keyPath,
+		AssetEndpoint:         server.URL,
+	}
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	provider, err := NewGCPProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewGCPProvider failed: %v", err)
+	}
+	return provider, fake
+}
+
+func TestGCPProvider_ScanSearchesOrganization(t *testing.T) {
+	provider, fake := newTestGCPProvider(t, func(cfg *config.GCPConfig) {
+		cfg.FolderID = "456"
+		cfg.OrganizationID = "123"
+		cfg.AssetTypes = []string{"compute.googleapis.com/Instance", "storage.googleapis.com/Bucket"}
+	})
+
+	resources, err := provider.Scan(t.Context())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(resources) != 4 {
+		t.Fatalf("Expected 4 resources across both pages, got %d", len(resources))
+	}
+	if fake.tokens != 1 {
+		t.Errorf("Expected the token to be reused across pages, got %d token requests", fake.tokens)
+	}
+	if got := fake.requests[0].URL.Query()["assetTypes"]; len(got) != 2 {
+		t.Errorf("Expected asset type filter to be sent, got %v", got)
+	}
+
+	byID := resourcesByID(resources)
+	vm := byID["//compute.googleapis.com/projects/web-prod/zones/us-central1-a/instances/web-1"]
+	if vm.Type != "gcp.compute.instance" || vm.Provider != "gcp" || vm.Region != "us-central1-a" {
+		t.Errorf("Unexpected instance: %+v", vm)
+	}
+	if vm.Tags["env"] != "prod" || vm.State != types.ResourceStateActive {
+		t.Errorf("Expected running instance with labels, got state %s labels %v", vm.State, vm.Tags)
+	}
+	ancestors, _ := vm.Metadata["ancestors"].([]string)
+	if strings.Join(ancestors, ",") != "projects/111,folders/456,organizations/123" {
+		t.Errorf("Unexpected ancestor chain: %v", vm.Metadata["ancestors"])
+	}
+	if vm.ParentID == nil || *vm.ParentID != "//cloudresourcemanager.googleapis.com/projects/111" {
+		t.Errorf("Expected instance parented to its project, got %v", vm.ParentID)
+	}
+
+	stopped := byID["//compute.googleapis.com/projects/web-prod/zones/us-central1-b/instances/batch-1"]
+	if stopped.State != types.ResourceStateInactive || stopped.Name != "batch-1" {
+		t.Errorf("Expected TERMINATED instance to be inactive: %+v", stopped)
+	}
+
+	project := byID["//cloudresourcemanager.googleapis.com/projects/111"]
+	if project.Type != "gcp.project" || project.Name != "Web Production" {
+		t.Errorf("Unexpected project: %+v", project)
+	}
+
+	bucket := byID["//storage.googleapis.com/web-prod-assets"]
+	if bucket.Type != "gcp.storage.bucket" || bucket.Region != "us" || bucket.Tags == nil {
+		t.Errorf("Unexpected bucket: %+v", bucket)
+	}
+}
+
+func TestGCPProvider_ScanListsAssets(t *testing.T) {
+	provider, _ := newTestGCPProvider(t, func(cfg *config.GCPConfig) {
+		cfg.FolderID = "456"
+		cfg.AssetAPI = gcpAssetAPIList
+	})
+
+	resources, err := provider.Scan(t.Context())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(resources))
+	}
+
+	byID := resourcesByID(resources)
+	sql := byID["//cloudsql.googleapis.com/projects/web-prod/instances/orders"]
+	if sql.Type != "gcp.sqladmin.instance" || sql.Tags["team"] != "data" || sql.State != types.ResourceStateActive {
+		t.Errorf("Unexpected Cloud SQL instance: %+v", sql)
+	}
+	ancestors, _ := sql.Metadata["ancestors"].([]string)
+	if strings.Join(ancestors, ",") != "projects/111,folders/456,organizations/123" {
+		t.Errorf("Unexpected ancestor chain: %v", sql.Metadata["ancestors"])
+	}
+
+	project := byID["//cloudresourcemanager.googleapis.com/projects/111"]
+	ancestors, _ = project.Metadata["ancestors"].([]string)
+	if strings.Join(ancestors, ",") != "folders/456,organizations/123" {
+		t.Errorf("Expected the project to be dropped from its own ancestors, got %v", ancestors)
+	}
+}
+
+func TestGCPProvider_GetResource(t *testing.T) {
+	provider, fake := newTestGCPProvider(t, nil)
+
+	id := "//compute.googleapis.com/projects/web-prod/zones/us-central1-a/instances/web-1"
+	resource, err := provider.GetResource(id)
+	if err != nil {
+		t.Fatalf("GetResource failed: %v", err)
+	}
+	if resource.ID != id {
+		t.Errorf("Expected exact match, got %s", resource.ID)
+	}
+	if got := fake.requests[0].URL.Query().Get("query"); got != `name="`+id+`"` {
+		t.Errorf("Unexpected search query: %s", got)
+	}
+
+	if _, err := provider.GetResource("web-1"); err == nil {
+		t.Error("Expected error for a name that is not a full resource name")
+	}
+}
+
+func TestGCPProvider_ValidateRequiresScope(t *testing.T) {
+	provider, _ := newTestGCPProvider(t, func(cfg *config.GCPConfig) { cfg.ProjectID = "" })
+	if err := provider.Validate(); err == nil {
+		t.Error("Expected validation to fail without a scope")
+	}
+
+	provider, _ = newTestGCPProvider(t, nil)
+	if err := provider.Validate(); err != nil {
+		t.Errorf("Expected validation to succeed, got %v", err)
+	}
+}
+
+func TestGCPResourceType(t *testing.T) {
+	tests := map[string]string{
+		"compute.googleapis.com/Instance":            "gcp.compute.instance",
+		"compute.googleapis.com/ForwardingRule":      "gcp.compute.forwarding_rule",
+		"sqladmin.googleapis.com/Instance":           "gcp.sqladmin.instance",
+		"container.googleapis.com/Cluster":           "gcp.container.cluster",
+		"iam.googleapis.com/ServiceAccount":          "gcp.iam.service_account",
+		"cloudresourcemanager.googleapis.com/Folder": "gcp.folder",
+		"k8s.io/Pod":                            "gcp.k8s.pod",
+		"compute.googleapis.com/SSLCertificate": "gcp.compute.ssl_certificate",
+	}
+	for assetType, expected := range tests {
+		if got := gcpResourceType(assetType); got != expected {
+			t.Errorf("gcpResourceType(%s) = %s, expected %s", assetType, got, expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHTTPTimeout bounds a single request made by the REST-based providers
+const defaultHTTPTimeout = 60 * time.Second
+
+// tokenExpiryMargin is how long before expiry a cached token is refreshed
+const tokenExpiryMargin = 2 * time.Minute
+
+// maxErrorBody caps how much of an error response is included in errors
+const maxErrorBody = 1024
+
+// APIError is returned when a cloud REST API responds with a non-2xx status
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// newHTTPClient returns the HTTP client used by the REST-based providers
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: defaultHTTPTimeout}
+}
+
+// doJSON sends a request and decodes a JSON response into out. Non-2xx
+// responses are returned as *APIError. out may be nil to discard the body.
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	_, err := doJSONHeader(client, req, out)
+	return err
+}
+
+// doJSONHeader is doJSON for APIs that return paging state in response headers
+func doJSONHeader(client *http.Client, req *http.Request, out interface{}) (http.Header, error) {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, &APIError{
+			Method:     req.Method,
+			URL:        req.URL.Redacted(),
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(body)),
+		}
+	}
+
+	if out == nil {
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return resp.Header, nil
+}
+
+// cachedToken caches an OAuth access token until shortly before it expires
+type cachedToken struct {
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// get returns the cached token or obtains a new one with fetch, which
+// returns the token and its lifetime
+func (c *cachedToken) get(ctx context.Context, fetch func(context.Context) (string, time.Duration, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiry) {
+		return c.token, nil
+	}
+
+	token, lifetime, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	c.expiry = time.Now().Add(lifetime - tokenExpiryMargin)
+	return token, nil
+}
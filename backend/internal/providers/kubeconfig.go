@@ -0,0 +1,311 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeExecDefaultLifetime is how long an exec credential without an
+// expirationTimestamp is reused
+const kubeExecDefaultLifetime = 10 * time.Minute
+
+// kubeconfig is the subset of a kubeconfig file needed to reach a cluster
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string      `yaml:"name"`
+		Cluster kubeCluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string      `yaml:"name"`
+		Context kubeContext `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string   `yaml:"name"`
+		User kubeUser `yaml:"user"`
+	} `yaml:"users"`
+}
+
+type kubeCluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+	TLSServerName            string `yaml:"tls-server-name"`
+}
+
+type kubeContext struct {
+	Cluster   string `yaml:"cluster"`
+	User      string `yaml:"user"`
+	Namespace string `yaml:"namespace"`
+}
+
+type kubeUser struct {
+	Token                 string    `yaml:"token"`
+	TokenFile             string    `yaml:"tokenFile"`
+	ClientCertificate     string    `yaml:"client-certificate"`
+	ClientCertificateData string    `yaml:"client-certificate-data"`
+	ClientKey             string    `yaml:"client-key"`
+	ClientKeyData         string    `yaml:"client-key-data"`
+	Username              string    `yaml:"username"`
+	Password              string    `yaml:"password"`
+	Exec                  *kubeExec `yaml:"exec"`
+}
+
+// kubeExec is a client-go credential plugin, e.g. "aws eks get-token"
+type kubeExec struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Command    string   `yaml:"command"`
+	Args       []string `yaml:"args"`
+	Env        []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+}
+
+// loadKubeconfig reads a kubeconfig file. Relative file references inside it
+// are resolved against the file's directory, as kubectl does.
+func loadKubeconfig(path string) (*kubeconfig, string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve kubeconfig path: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var cfg kubeconfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, "", fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+	return &cfg, filepath.Dir(path), nil
+}
+
+// defaultKubeconfigPath returns the first $KUBECONFIG entry or ~/.kube/config
+func defaultKubeconfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// expandHome expands a leading ~/ to the user's home directory and cleans the path
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return filepath.Clean(path), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// kubeClient talks to the API server of a single kubeconfig context
+type kubeClient struct {
+	context     string
+	clusterName string
+	server      string
+	user        kubeUser
+	dir         string
+	client      *http.Client
+	token       cachedToken
+}
+
+// newKubeClient builds a client for the named context
+func (k *kubeconfig) newKubeClient(name, dir string) (*kubeClient, error) {
+	var (
+		ctx     *kubeContext
+		cluster *kubeCluster
+		user    kubeUser
+	)
+	for i := range k.Contexts {
+		if k.Contexts[i].Name == name {
+			ctx = &k.Contexts[i].Context
+			break
+		}
+	}
+	if ctx == nil {
+		return nil, fmt.Errorf("context %q not found in kubeconfig", name)
+	}
+	for i := range k.Clusters {
+		if k.Clusters[i].Name == ctx.Cluster {
+			cluster = &k.Clusters[i].Cluster
+			break
+		}
+	}
+	if cluster == nil || cluster.Server == "" {
+		return nil, fmt.Errorf("context %q: cluster %q not found in kubeconfig", name, ctx.Cluster)
+	}
+	for i := range k.Users {
+		if k.Users[i].Name == ctx.User {
+			user = k.Users[i].User
+			break
+		}
+	}
+
+	c := &kubeClient{
+		context:     name,
+		clusterName: ctx.Cluster,
+		server:      strings.TrimRight(cluster.Server, "/"),
+		user:        user,
+		dir:         dir,
+	}
+	tlsConfig, err := c.tlsConfig(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("context %q: %w", name, err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c.client = &http.Client{Timeout: defaultHTTPTimeout, Transport: transport}
+	return c, nil
+}
+
+// tlsConfig builds the TLS settings for the cluster and client certificate
+func (c *kubeClient) tlsConfig(cluster *kubeCluster) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cluster.TLSServerName,
+		InsecureSkipVerify: cluster.InsecureSkipTLSVerify, // #nosec G402 -- explicitly requested in kubeconfig
+	}
+
+	ca, err := c.readData(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate authority: %w", err)
+	}
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("certificate authority contains no PEM certificates")
+		}
+		cfg.RootCAs = pool
+	}
+
+	cert, err := c.readData(c.user.ClientCertificateData, c.user.ClientCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate: %w", err)
+	}
+	key, err := c.readData(c.user.ClientKeyData, c.user.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key: %w", err)
+	}
+	if len(cert) > 0 && len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return cfg, nil
+}
+
+// readData returns base64 inline data, or the contents of file when data is empty
+func (c *kubeClient) readData(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file == "" {
+		return nil, nil
+	}
+	return os.ReadFile(c.path(file))
+}
+
+// path resolves a kubeconfig file reference
+func (c *kubeClient) path(file string) string {
+	if filepath.IsAbs(file) {
+		return filepath.Clean(file)
+	}
+	return filepath.Join(c.dir, file)
+}
+
+// get issues an authenticated GET against the API server and decodes the response
+func (c *kubeClient) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	endpoint := c.server + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return err
+	}
+	if err := c.authorize(ctx, req); err != nil {
+		return err
+	}
+	return doJSON(c.client, req, out)
+}
+
+// authorize adds the user's bearer token or basic credentials to req
+func (c *kubeClient) authorize(ctx context.Context, req *http.Request) error {
+	switch {
+	case c.user.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.user.Token)
+	case c.user.TokenFile != "":
+		token, err := os.ReadFile(c.path(c.user.TokenFile))
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	case c.user.Exec != nil:
+		token, err := c.token.get(ctx, c.execToken)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.user.Username != "":
+		req.SetBasicAuth(c.user.Username, c.user.Password)
+	}
+	return nil
+}
+
+// execToken runs the exec credential plugin and returns its token
+func (c *kubeClient) execToken(ctx context.Context) (string, time.Duration, error) {
+	plugin := c.user.Exec
+	cmd := exec.CommandContext(ctx, plugin.Command, plugin.Args...) // #nosec G204 -- command comes from the operator's kubeconfig
+	cmd.Env = os.Environ()
+	for _, env := range plugin.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf(`KUBERNETES_EXEC_INFO={"apiVersion":%q,"kind":"ExecCredential","spec":{"interactive":false}}`, plugin.APIVersion))
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", 0, fmt.Errorf("exec credential plugin %s failed: %w", plugin.Command, err)
+	}
+
+	var credential struct {
+		Status struct {
+			Token               string    `json:"token"`
+			ExpirationTimestamp time.Time `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(output, &credential); err != nil {
+		return "", 0, fmt.Errorf("exec credential plugin %s returned invalid output: %w", plugin.Command, err)
+	}
+	if credential.Status.Token == "" {
+		return "", 0, fmt.Errorf("exec credential plugin %s returned no token", plugin.Command)
+	}
+
+	lifetime := kubeExecDefaultLifetime
+	if !credential.Status.ExpirationTimestamp.IsZero() {
+		lifetime = time.Until(credential.Status.ExpirationTimestamp)
+	}
+	return credential.Status.Token, lifetime, nil
+}
@@ -0,0 +1,498 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const (
+	kubeIDPrefix = "k8s://"
+	kubePageSize = 500
+)
+
+// kubeKind describes an API resource listed during a scan
+type kubeKind struct {
+	resourceType string
+	apiPath      string
+	plural       string
+	namespaced   bool
+}
+
+// kubeKinds lists the API resources discovered in each cluster. Namespaces
+// come first so that workloads can be parented to them.
+var kubeKinds = []kubeKind{
+	{"kubernetes.namespace", "/api/v1", "namespaces", false},
+	{"kubernetes.deployment", "/apis/apps/v1", "deployments", true},
+	{"kubernetes.stateful_set", "/apis/apps/v1", "statefulsets", true},
+	{"kubernetes.service", "/api/v1", "services", true},
+	{"kubernetes.ingress", "/apis/networking.k8s.io/v1", "ingresses", true},
+	{"kubernetes.persistent_volume_claim", "/api/v1", "persistentvolumeclaims", true},
+	{"kubernetes.custom_resource_definition", "/apis/apiextensions.k8s.io/v1", "customresourcedefinitions", false},
+}
+
+// kubeObject is the common shape of the listed API objects
+type kubeObject struct {
+	Metadata struct {
+		Name              string            `json:"name"`
+		Namespace         string            `json:"namespace"`
+		UID               string            `json:"uid"`
+		CreationTimestamp string            `json:"creationTimestamp"`
+		DeletionTimestamp string            `json:"deletionTimestamp"`
+		Labels            map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec   map[string]interface{} `json:"spec"`
+	Status map[string]interface{} `json:"status"`
+}
+
+// kubeCloudLink identifies the managed cluster resource behind a context
+type kubeCloudLink struct {
+	provider string
+	account  string
+	id       string
+	region   string
+}
+
+// KubernetesProvider implements the Provider interface for Kubernetes clusters
+// reachable through kubeconfig contexts
+type KubernetesProvider struct {
+	config  config.KubernetesConfig
+	clients []*kubeClient
+}
+
+// NewKubernetesProvider creates a new Kubernetes provider
+func NewKubernetesProvider(cfg config.KubernetesConfig) (*KubernetesProvider, error) {
+	if cfg.Kubeconfig == "" {
+		cfg.Kubeconfig = defaultKubeconfigPath()
+	}
+	return &KubernetesProvider{config: cfg}, nil
+}
+
+// Name returns the provider name
+func (p *KubernetesProvider) Name() string {
+	return "kubernetes"
+}
+
+// Validate checks that every configured context can reach its API server
+func (p *KubernetesProvider) Validate() error {
+	clients, err := p.loadClients()
+	if err != nil {
+		return err
+	}
+	for _, client := range clients {
+		if _, err := p.serverVersion(context.Background(), client); err != nil {
+			return fmt.Errorf("kubernetes context %s validation failed: %w", client.context, err)
+		}
+	}
+	return nil
+}
+
+// Scan lists the cluster, its namespaces and the workloads, services,
+// ingresses, claims and CRDs in every configured context
+func (p *KubernetesProvider) Scan(ctx context.Context) ([]types.Resource, error) {
+	return collectScan(ctx, p.ScanStream)
+}
+
+// ScanStream is Scan, sending resources to out a list page at a time
+func (p *KubernetesProvider) ScanStream(ctx context.Context, out chan<- types.Resource) error {
+	clients, err := p.loadClients()
+	if err != nil {
+		return err
+	}
+
+	var failures []ScanFailure
+	for _, client := range clients {
+		cluster, err := p.scanCluster(ctx, client)
+		if err != nil {
+			failures = append(failures, ScanFailure{Account: client.context, Service: "kubernetes", Err: err})
+			continue
+		}
+		if err := sendResources(ctx, out, []types.Resource{cluster}); err != nil {
+			return err
+		}
+
+		for _, kind := range kubeKinds {
+			if err := p.scanKind(ctx, client, &cluster, kind, out); err != nil {
+				failures = append(failures, ScanFailure{Account: client.context, Service: kind.plural, Err: err})
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return &ScanError{Provider: p.Name(), Failures: failures}
+	}
+	return nil
+}
+
+// GetResource retrieves a resource by its Siros ID, which is the object's API
+// path prefixed with its context, e.g. k8s://prod/apis/apps/v1/namespaces/web/deployments/api
+func (p *KubernetesProvider) GetResource(id string) (*types.Resource, error) {
+	rest, ok := strings.CutPrefix(id, kubeIDPrefix)
+	if !ok {
+		return nil, fmt.Errorf("invalid Kubernetes resource ID: %s", id)
+	}
+	clients, err := p.loadClients()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, client := range clients {
+		path, ok := strings.CutPrefix(rest, client.context)
+		if !ok || (path != "" && !strings.HasPrefix(path, "/")) {
+			continue
+		}
+
+		cluster, err := p.scanCluster(context.Background(), client)
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return &cluster, nil
+		}
+
+		for _, kind := range kubeKinds {
+			if !kind.matches(path) {
+				continue
+			}
+			var obj kubeObject
+			if err := client.get(context.Background(), path, nil, &obj); err != nil {
+				return nil, err
+			}
+			resource := p.convertObject(client, &cluster, kind, &obj)
+			return &resource, nil
+		}
+		return nil, fmt.Errorf("unsupported Kubernetes resource: %s", id)
+	}
+	return nil, fmt.Errorf("resource not found: %s", id)
+}
+
+// loadClients reads the kubeconfig and builds a client per context once
+func (p *KubernetesProvider) loadClients() ([]*kubeClient, error) {
+	if p.clients != nil {
+		return p.clients, nil
+	}
+	if p.config.Kubeconfig == "" {
+		return nil, fmt.Errorf("kubeconfig path could not be determined")
+	}
+
+	kubecfg, dir, err := loadKubeconfig(p.config.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := p.config.Contexts
+	if len(contexts) == 0 {
+		if kubecfg.CurrentContext == "" {
+			return nil, fmt.Errorf("kubeconfig has no current-context and no contexts are configured")
+		}
+		contexts = []config.KubernetesContextConfig{{Name: kubecfg.CurrentContext}}
+	}
+
+	clients := make([]*kubeClient, 0, len(contexts))
+	for _, contextCfg := range contexts {
+		client, err := kubecfg.newKubeClient(contextCfg.Name, dir)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	p.clients = clients
+	return clients, nil
+}
+
+// serverVersion returns the API server's git version
+func (p *KubernetesProvider) serverVersion(ctx context.Context, client *kubeClient) (string, error) {
+	var version struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if err := client.get(ctx, "/version", nil, &version); err != nil {
+		return "", err
+	}
+	return version.GitVersion, nil
+}
+
+// scanCluster returns the resource representing the cluster itself, parented
+// to its managed cloud resource when one is known
+func (p *KubernetesProvider) scanCluster(ctx context.Context, client *kubeClient) (types.Resource, error) {
+	version, err := p.serverVersion(ctx, client)
+	if err != nil {
+		return types.Resource{}, err
+	}
+
+	metadata := map[string]interface{}{
+		"context": client.context,
+		"cluster": client.clusterName,
+		"server":  client.server,
+		"version": version,
+	}
+
+	var (
+		region   string
+		parentID *string
+	)
+	if link, ok := p.cloudLink(client); ok {
+		metadata["cloud_provider"] = link.provider
+		metadata["cloud_resource_id"] = link.id
+		metadata[types.MetadataParentProvider] = link.provider
+		if link.account != "" {
+			metadata[types.MetadataParentAccount] = link.account
+		}
+		region = link.region
+		parentID = &link.id
+	}
+
+	now := time.Now()
+	return types.Resource{
+		ID:        kubeIDPrefix + client.context,
+		Type:      "kubernetes.cluster",
+		Provider:  "kubernetes",
+		Region:    region,
+		Name:      client.context,
+		ARN:       client.server,
+		Tags:      make(map[string]string),
+		Metadata:  metadata,
+		State:     types.ResourceStateActive,
+		ParentID:  parentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// cloudLink returns the configured cloud resource for a context, or derives
+// it from EKS ARNs and GKE cluster names written by aws and gcloud
+func (p *KubernetesProvider) cloudLink(client *kubeClient) (kubeCloudLink, bool) {
+	for _, contextCfg := range p.config.Contexts {
+		if contextCfg.Name == client.context && contextCfg.CloudResourceID != "" {
+			return kubeCloudLink{provider: cloudProviderOf(contextCfg.CloudResourceID), id: contextCfg.CloudResourceID}, true
+		}
+	}
+
+	for _, name := range []string{client.clusterName, client.context} {
+		// arn:aws:eks:<region>:<account>:cluster/<name>
+		if strings.HasPrefix(name, "arn:aws:eks:") {
+			parts := strings.SplitN(name, ":", 6)
+			if len(parts) == 6 && strings.HasPrefix(parts[5], "cluster/") {
+				// The AWS provider identifies EKS clusters by name
+				return kubeCloudLink{provider: "aws", account: parts[4], id: strings.TrimPrefix(parts[5], "cluster/"), region: parts[3]}, true
+			}
+		}
+		// gke_<project>_<location>_<name>
+		if strings.HasPrefix(name, "gke_") {
+			parts := strings.SplitN(name, "_", 4)
+			if len(parts) == 4 {
+				return kubeCloudLink{
+					provider: "gcp",
+					account:  parts[1],
+					id:       fmt.Sprintf("//container.googleapis.com/projects/%s/locations/%s/clusters/%s", parts[1], parts[2], parts[3]),
+					region:   parts[2],
+				}, true
+			}
+		}
+	}
+	return kubeCloudLink{}, false
+}
+
+// cloudProviderOf guesses the provider of a configured cloud resource ID
+func cloudProviderOf(id string) string {
+	switch {
+	case strings.HasPrefix(strings.ToLower(id), "/subscriptions/"):
+		return "azure"
+	case strings.HasPrefix(id, "//"):
+		return "gcp"
+	case strings.HasPrefix(id, "ocid1."):
+		return "oci"
+	default:
+		return "aws"
+	}
+}
+
+// scanKind sends every object of kind across the cluster to out, following continue tokens
+func (p *KubernetesProvider) scanKind(ctx context.Context, client *kubeClient, cluster *types.Resource, kind kubeKind, out chan<- types.Resource) error {
+	params := url.Values{"limit": {fmt.Sprint(kubePageSize)}}
+
+	for {
+		var list struct {
+			Metadata struct {
+				Continue string `json:"continue"`
+			} `json:"metadata"`
+			Items []kubeObject `json:"items"`
+		}
+		if err := client.get(ctx, kind.apiPath+"/"+kind.plural, params, &list); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			select {
+			case out <- p.convertObject(client, cluster, kind, &list.Items[i]):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if list.Metadata.Continue == "" {
+			return nil
+		}
+		params.Set("continue", list.Metadata.Continue)
+	}
+}
+
+// objectPath returns the API path of an object of this kind
+func (k kubeKind) objectPath(namespace, name string) string {
+	if k.namespaced {
+		return fmt.Sprintf("%s/namespaces/%s/%s/%s", k.apiPath, namespace, k.plural, name)
+	}
+	return fmt.Sprintf("%s/%s/%s", k.apiPath, k.plural, name)
+}
+
+// matches reports whether path addresses a single object of this kind
+func (k kubeKind) matches(path string) bool {
+	rest, ok := strings.CutPrefix(path, k.apiPath+"/")
+	if !ok {
+		return false
+	}
+	parts := strings.Split(rest, "/")
+	if k.namespaced {
+		return len(parts) == 4 && parts[0] == "namespaces" && parts[2] == k.plural
+	}
+	return len(parts) == 2 && parts[0] == k.plural
+}
+
+// convertObject converts an API object to a Siros resource. Namespaced objects
+// are children of their namespace; cluster-scoped objects of the cluster.
+func (p *KubernetesProvider) convertObject(client *kubeClient, cluster *types.Resource, kind kubeKind, obj *kubeObject) types.Resource {
+	parentID := cluster.ID
+	if kind.namespaced {
+		parentID = kubeIDPrefix + client.context + kubeKinds[0].objectPath("", obj.Metadata.Namespace)
+	}
+
+	tags := obj.Metadata.Labels
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+
+	metadata := map[string]interface{}{
+		"context": client.context,
+		"uid":     obj.Metadata.UID,
+	}
+	if obj.Metadata.Namespace != "" {
+		metadata["namespace"] = obj.Metadata.Namespace
+	}
+	if obj.Metadata.CreationTimestamp != "" {
+		metadata["creation_timestamp"] = obj.Metadata.CreationTimestamp
+	}
+	for key, value := range kubeDetails(kind, obj) {
+		metadata[key] = value
+	}
+
+	now := time.Now()
+	path := kind.objectPath(obj.Metadata.Namespace, obj.Metadata.Name)
+	return types.Resource{
+		ID:        kubeIDPrefix + client.context + path,
+		Type:      kind.resourceType,
+		Provider:  "kubernetes",
+		Region:    cluster.Region,
+		Name:      obj.Metadata.Name,
+		ARN:       client.server + path,
+		Tags:      tags,
+		Metadata:  metadata,
+		State:     kubeState(kind, obj),
+		ParentID:  &parentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// kubeDetails extracts the kind-specific fields worth recording in metadata
+func kubeDetails(kind kubeKind, obj *kubeObject) map[string]interface{} {
+	details := make(map[string]interface{})
+	copyField := func(key string, from map[string]interface{}, field string) {
+		if value, ok := from[field]; ok {
+			details[key] = value
+		}
+	}
+
+	switch kind.plural {
+	case "deployments", "statefulsets":
+		copyField("replicas", obj.Spec, "replicas")
+		copyField("ready_replicas", obj.Status, "readyReplicas")
+	case "services":
+		copyField("service_type", obj.Spec, "type")
+		copyField("cluster_ip", obj.Spec, "clusterIP")
+	case "ingresses":
+		copyField("ingress_class", obj.Spec, "ingressClassName")
+		var hosts []string
+		rules, _ := obj.Spec["rules"].([]interface{})
+		for _, rule := range rules {
+			if r, ok := rule.(map[string]interface{}); ok {
+				if host, ok := r["host"].(string); ok {
+					hosts = append(hosts, host)
+				}
+			}
+		}
+		if len(hosts) > 0 {
+			details["hosts"] = hosts
+		}
+	case "persistentvolumeclaims":
+		copyField("storage_class", obj.Spec, "storageClassName")
+		copyField("volume_name", obj.Spec, "volumeName")
+		copyField("capacity", obj.Status, "capacity")
+	case "customresourcedefinitions":
+		copyField("group", obj.Spec, "group")
+		copyField("scope", obj.Spec, "scope")
+	}
+	return details
+}
+
+// kubeState derives a resource state from an object's status
+func kubeState(kind kubeKind, obj *kubeObject) types.ResourceState {
+	if obj.Metadata.DeletionTimestamp != "" {
+		return types.ResourceStateTerminated
+	}
+
+	switch kind.plural {
+	case "namespaces":
+		if phase, _ := obj.Status["phase"].(string); phase == "Terminating" {
+			return types.ResourceStateTerminated
+		}
+	case "deployments", "statefulsets":
+		desired, ok := obj.Spec["replicas"].(float64)
+		if !ok {
+			desired = 1
+		}
+		ready, _ := obj.Status["readyReplicas"].(float64)
+		switch {
+		case desired == 0:
+			return types.ResourceStateInactive
+		case ready == 0:
+			return types.ResourceStateError
+		}
+	case "persistentvolumeclaims":
+		switch obj.Status["phase"] {
+		case "Pending":
+			return types.ResourceStateInactive
+		case "Lost":
+			return types.ResourceStateError
+		}
+	case "customresourcedefinitions":
+		if !kubeConditionTrue(obj, "Established") {
+			return types.ResourceStateInactive
+		}
+	}
+	return types.ResourceStateActive
+}
+
+// kubeConditionTrue reports whether the named status condition is True
+func kubeConditionTrue(obj *kubeObject, conditionType string) bool {
+	conditions, _ := obj.Status["conditions"].([]interface{})
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == conditionType {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
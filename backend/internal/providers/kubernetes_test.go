@@ -0,0 +1,292 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const (
+	testGKEContext       = "gke_web-prod_us-central1_prod"
+	testAKSContext       = "staging"
+	testAKSResourceID    = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/k8s/providers/Microsoft.ContainerService/managedClusters/staging"
+	testKubeconfigLayout = `apiVersion: v1
+kind: Config
+current-context: %[2]s
+clusters:
+  - name: %[2]s
+    cluster:
+      server: %[1]s
+  - name: staging
+    cluster:
+      server: %[1]s/
+users:
+  - name: prod-admin
+    user:
+      token: prod-token
+  - name: staging-reader
+    user:
+      tokenFile: staging.token
+contexts:
+  - name: %[2]s
+    context:
+      cluster: %[2]s
+      user: prod-admin
+  - name: staging
+    context:
+      cluster: staging
+      user: staging-reader
+`
+)
+
+// fakeKubernetes stands in for an API server. List responses are served from
+// testdata/kubernetes/<plural>.json, keyed by continue token; single objects
+// are looked up in the same fixtures.
+type fakeKubernetes struct {
+	t        *testing.T
+	fail     map[string]string // bearer token -> failing resource plural
+	requests []*http.Request
+}
+
+func (f *fakeKubernetes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token != "prod-token" && token != "staging-token" {
+		http.Error(w, `{"kind":"Status","code":401,"reason":"Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+	f.requests = append(f.requests, r)
+
+	if r.URL.Path == "/version" {
+		writeJSON(w, map[string]string{"gitVersion": "v1.30.2"})
+		return
+	}
+
+	for _, kind := range kubeKinds {
+		if r.URL.Path == kind.apiPath+"/"+kind.plural {
+			if f.fail[token] == kind.plural {
+				http.Error(w, `{"kind":"Status","code":403,"reason":"Forbidden"}`, http.StatusForbidden)
+				return
+			}
+			page, ok := f.pages(kind)[r.URL.Query().Get("continue")]
+			if !ok {
+				http.Error(w, `{"kind":"Status","code":410,"reason":"Expired"}`, http.StatusGone)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(page)
+			return
+		}
+		if kind.matches(r.URL.Path) {
+			f.serveObject(w, r, kind)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (f *fakeKubernetes) pages(kind kubeKind) map[string]json.RawMessage {
+	data, err := os.ReadFile(filepath.Join("testdata", "kubernetes", kind.plural+".json"))
+	if err != nil {
+		f.t.Fatalf("failed to read fixture: %v", err)
+	}
+	var pages map[string]json.RawMessage
+	if err := json.Unmarshal(data, &pages); err != nil {
+		f.t.Fatalf("invalid fixture %s: %v", kind.plural, err)
+	}
+	return pages
+}
+
+func (f *fakeKubernetes) serveObject(w http.ResponseWriter, r *http.Request, kind kubeKind) {
+	for _, page := range f.pages(kind) {
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(page, &list)
+		for _, item := range list.Items {
+			var obj kubeObject
+			_ = json.Unmarshal(item, &obj)
+			if kind.objectPath(obj.Metadata.Namespace, obj.Metadata.Name) == r.URL.Path {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(item)
+				return
+			}
+		}
+	}
+	http.Error(w, `{"kind":"Status","code":404,"reason":"NotFound"}`, http.StatusNotFound)
+}
+
+func newTestKubernetesProvider(t *testing.T, contexts []config.KubernetesContextConfig) (*KubernetesProvider, *fakeKubernetes) {
+	t.Helper()
+
+	fake := &fakeKubernetes{t: t, fail: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	kubeconfigPath := filepath.Join(dir, "config")
+	kubeconfig := fmt.Sprintf(testKubeconfigLayout, server.URL, testGKEContext)
+	if err := os.WriteFile(kubeconfigPath, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	// tokenFile is relative to the kubeconfig
+	if err := os.WriteFile(filepath.Join(dir, "staging.token"), []byte("staging-token\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	provider, err := NewKubernetesProvider(config.KubernetesConfig{Kubeconfig: kubeconfigPath, Contexts: contexts})
+	if err != nil {
+		t.Fatalf("NewKubernetesProvider failed: %v", err)
+	}
+	return provider, fake
+}
+
+func TestKubernetesProvider_ScanCurrentContext(t *testing.T) {
+	provider, _ := newTestKubernetesProvider(t, nil)
+
+	resources, err := provider.Scan(t.Context())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(resources) != 13 {
+		t.Fatalf("Expected the cluster and 12 objects, got %d", len(resources))
+	}
+
+	byID := resourcesByID(resources)
+	clusterID := kubeIDPrefix + testGKEContext
+	cluster := byID[clusterID]
+	if cluster.Type != "kubernetes.cluster" || cluster.Metadata["version"] != "v1.30.2" || cluster.Region != "us-central1" {
+		t.Errorf("Unexpected cluster: %+v", cluster)
+	}
+	gkeID := "//container.googleapis.com/projects/web-prod/locations/us-central1/clusters/prod"
+	if cluster.ParentID == nil || *cluster.ParentID != gkeID || cluster.Metadata["cloud_provider"] != "gcp" ||
+		cluster.Metadata[types.MetadataParentProvider] != "gcp" || cluster.Metadata[types.MetadataParentAccount] != "web-prod" {
+		t.Errorf("Expected cluster linked to its GKE resource, got parent %v metadata %v", cluster.ParentID, cluster.Metadata)
+	}
+
+	namespaceID := clusterID + "/api/v1/namespaces/web"
+	namespace := byID[namespaceID]
+	if namespace.Type != "kubernetes.namespace" || namespace.ParentID == nil || *namespace.ParentID != clusterID {
+		t.Errorf("Expected namespace parented to the cluster: %+v", namespace)
+	}
+	if namespace.Tags["team"] != "web" {
+		t.Errorf("Expected labels as tags, got %v", namespace.Tags)
+	}
+	if byID[clusterID+"/api/v1/namespaces/old"].State != types.ResourceStateTerminated {
+		t.Error("Expected terminating namespace to be terminated")
+	}
+
+	deployment := byID[clusterID+"/apis/apps/v1/namespaces/web/deployments/api"]
+	if deployment.Type != "kubernetes.deployment" || deployment.ParentID == nil || *deployment.ParentID != namespaceID {
+		t.Errorf("Expected deployment parented to its namespace: %+v", deployment)
+	}
+	if deployment.State != types.ResourceStateActive || deployment.Metadata["namespace"] != "web" {
+		t.Errorf("Unexpected deployment: %+v", deployment)
+	}
+	if byID[clusterID+"/apis/apps/v1/namespaces/web/deployments/worker"].State != types.ResourceStateInactive {
+		t.Error("Expected deployment scaled to zero to be inactive")
+	}
+	if byID[clusterID+"/apis/apps/v1/namespaces/default/deployments/broken"].State != types.ResourceStateError {
+		t.Error("Expected deployment without ready replicas to be in error")
+	}
+
+	if sts := byID[clusterID+"/apis/apps/v1/namespaces/web/statefulsets/postgres"]; sts.Type != "kubernetes.stateful_set" {
+		t.Errorf("Unexpected stateful set: %+v", sts)
+	}
+	if svc := byID[clusterID+"/api/v1/namespaces/web/services/api"]; svc.Metadata["service_type"] != "ClusterIP" {
+		t.Errorf("Unexpected service: %+v", svc)
+	}
+	ingress := byID[clusterID+"/apis/networking.k8s.io/v1/namespaces/web/ingresses/api"]
+	if hosts, _ := ingress.Metadata["hosts"].([]string); strings.Join(hosts, ",") != "api.example.com,www.example.com" {
+		t.Errorf("Expected ingress hosts in metadata, got %v", ingress.Metadata)
+	}
+	if byID[clusterID+"/api/v1/namespaces/web/persistentvolumeclaims/scratch"].State != types.ResourceStateInactive {
+		t.Error("Expected pending claim to be inactive")
+	}
+
+	crd := byID[clusterID+"/apis/apiextensions.k8s.io/v1/customresourcedefinitions/certificates.cert-manager.io"]
+	if crd.Type != "kubernetes.custom_resource_definition" || crd.State != types.ResourceStateActive {
+		t.Errorf("Unexpected CRD: %+v", crd)
+	}
+	if crd.ParentID == nil || *crd.ParentID != clusterID {
+		t.Errorf("Expected CRD parented to the cluster, got %v", crd.ParentID)
+	}
+}
+
+func TestKubernetesProvider_ScanIsolatesFailures(t *testing.T) {
+	provider, fake := newTestKubernetesProvider(t, []config.KubernetesContextConfig{
+		{Name: testGKEContext},
+		{Name: testAKSContext, CloudResourceID: testAKSResourceID},
+	})
+	fake.fail["staging-token"] = "customresourcedefinitions"
+
+	resources, err := provider.Scan(t.Context())
+	if !IsPartialScan(err) {
+		t.Fatalf("Expected a partial scan error, got %v", err)
+	}
+	if len(resources) != 25 {
+		t.Errorf("Expected both clusters without the staging CRDs, got %d resources", len(resources))
+	}
+	var scanErr *ScanError
+	if errors.As(err, &scanErr) {
+		if len(scanErr.Failures) != 1 || scanErr.Failures[0].Account != testAKSContext {
+			t.Errorf("Expected a single staging failure, got %+v", scanErr.Failures)
+		}
+	}
+
+	staging := resourcesByID(resources)[kubeIDPrefix+testAKSContext]
+	if staging.ParentID == nil || *staging.ParentID != testAKSResourceID || staging.Metadata["cloud_provider"] != "azure" {
+		t.Errorf("Expected staging linked to its configured AKS resource, got %+v", staging)
+	}
+}
+
+func TestKubernetesProvider_GetResource(t *testing.T) {
+	provider, _ := newTestKubernetesProvider(t, []config.KubernetesContextConfig{
+		{Name: testGKEContext},
+		{Name: testAKSContext},
+	})
+
+	id := kubeIDPrefix + testAKSContext + "/apis/apps/v1/namespaces/web/deployments/api"
+	resource, err := provider.GetResource(id)
+	if err != nil {
+		t.Fatalf("GetResource failed: %v", err)
+	}
+	if resource.ID != id || resource.Metadata["replicas"] != float64(3) {
+		t.Errorf("Unexpected resource: %+v", resource)
+	}
+
+	cluster, err := provider.GetResource(kubeIDPrefix + testAKSContext)
+	if err != nil || cluster.Type != "kubernetes.cluster" {
+		t.Errorf("Expected the cluster, got %+v (%v)", cluster, err)
+	}
+
+	if _, err := provider.GetResource(kubeIDPrefix + testAKSContext + "/api/v1/namespaces/web/pods/api-0"); err == nil {
+		t.Error("Expected error for an unsupported kind")
+	}
+	if _, err := provider.GetResource(kubeIDPrefix + "unknown/api/v1/namespaces/web"); err == nil {
+		t.Error("Expected error for an unknown context")
+	}
+}
+
+func TestKubernetesProvider_Validate(t *testing.T) {
+	provider, _ := newTestKubernetesProvider(t, []config.KubernetesContextConfig{{Name: "missing"}})
+	if err := provider.Validate(); err == nil {
+		t.Error("Expected validation to fail for an unknown context")
+	}
+
+	provider, fake := newTestKubernetesProvider(t, nil)
+	if err := provider.Validate(); err != nil {
+		t.Errorf("Expected validation to succeed, got %v", err)
+	}
+	if len(fake.requests) != 1 || fake.requests[0].URL.Path != "/version" {
+		t.Errorf("Expected validation to query the server version")
+	}
+}
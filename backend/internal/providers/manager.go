@@ -2,13 +2,29 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"time"
 
 	"github.com/LederWorks/siros/backend/internal/config"
 	"github.com/LederWorks/siros/backend/pkg/types"
 )
 
+// Compile-time checks that each provider implements types.StreamingProvider
+var (
+	_ types.StreamingProvider = (*AWSProvider)(nil)
+	_ types.StreamingProvider = (*AzureProvider)(nil)
+	_ types.StreamingProvider = (*GCPProvider)(nil)
+	_ types.StreamingProvider = (*OCIProvider)(nil)
+	_ types.StreamingProvider = (*KubernetesProvider)(nil)
+	_ types.StreamingProvider = (*PluginProvider)(nil)
+)
+
+// ErrProviderNotFound is returned for a provider name that is not registered
+var ErrProviderNotFound = errors.New("provider not found")
+
 // Manager manages multiple cloud providers
 type Manager struct {
 	providers map[string]types.Provider
@@ -28,11 +44,93 @@ func (m *Manager) RegisterProvider(name string, provider types.Provider) {
 	m.providers[name] = provider
 }
 
+// RegisterConfigured creates and registers every provider enabled in the configuration
+func (m *Manager) RegisterConfigured() error {
+	if m.config.AWS.Enabled {
+		provider, err := NewAWSProvider(m.config.AWS)
+		if err != nil {
+			return fmt.Errorf("failed to create AWS provider: %w", err)
+		}
+		m.RegisterProvider(provider.Name(), provider)
+	}
+	if m.config.Azure.Enabled {
+		provider, err := NewAzureProvider(m.config.Azure)
+		if err != nil {
+			return fmt.Errorf("failed to create Azure provider: %w", err)
+		}
+		m.RegisterProvider(provider.Name(), provider)
+	}
+	if m.config.GCP.Enabled {
+		provider, err := NewGCPProvider(m.config.GCP)
+		if err != nil {
+			return fmt.Errorf("failed to create GCP provider: %w", err)
+		}
+		m.RegisterProvider(provider.Name(), provider)
+	}
+	if m.config.OCI.Enabled {
+		provider, err := NewOCIProvider(m.config.OCI)
+		if err != nil {
+			return fmt.Errorf("failed to create OCI provider: %w", err)
+		}
+		m.RegisterProvider(provider.Name(), provider)
+	}
+	if m.config.Kubernetes.Enabled {
+		provider, err := NewKubernetesProvider(m.config.Kubernetes)
+		if err != nil {
+			return fmt.Errorf("failed to create Kubernetes provider: %w", err)
+		}
+		m.RegisterProvider(provider.Name(), provider)
+	}
+	if m.config.Plugins.Enabled {
+		if err := m.LoadPlugins(m.config.Plugins.Dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadPlugins starts every provider plugin found in dir and registers it under
+// the name it reports. A plugin that fails to start is logged and skipped.
+func (m *Manager) LoadPlugins(dir string) error {
+	plugins, err := discoverPlugins(dir)
+	if err != nil {
+		return err
+	}
+
+	for name, path := range plugins {
+		provider, err := NewPluginProvider(path, m.config.Plugins.Config[name])
+		if err != nil {
+			log.Printf("Skipping provider plugin %s: %v", path, err)
+			continue
+		}
+		if _, exists := m.providers[provider.Name()]; exists {
+			log.Printf("Skipping provider plugin %s: provider %s is already registered", path, provider.Name())
+			_ = provider.Close()
+			continue
+		}
+		m.RegisterProvider(provider.Name(), provider)
+	}
+	return nil
+}
+
+// Close releases provider resources, stopping any plugin processes
+func (m *Manager) Close() error {
+	var errs []error
+	for name, provider := range m.providers {
+		if closer, ok := provider.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close provider %s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // GetProvider returns a provider by name
 func (m *Manager) GetProvider(name string) (types.Provider, error) {
 	provider, exists := m.providers[name]
 	if !exists {
-		return nil, fmt.Errorf("provider %s not found", name)
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, name)
 	}
 	return provider, nil
 }
@@ -44,7 +142,11 @@ func (m *Manager) ScanAll(ctx context.Context) ([]types.Resource, error) {
 	for name, provider := range m.providers {
 		resources, err := provider.Scan(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan provider %s: %w", name, err)
+			if !IsPartialScan(err) {
+				return nil, fmt.Errorf("failed to scan provider %s: %w", name, err)
+			}
+			// Keep what was found; the failed scopes are reported but not fatal
+			log.Printf("Provider %s scan completed with errors: %v", name, err)
 		}
 
 		// Set scan timestamp
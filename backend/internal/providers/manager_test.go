@@ -0,0 +1,32 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+)
+
+func TestManager_RegisterConfigured(t *testing.T) {
+	cfg := &config.ProvidersConfig{
+		Azure: config.AzureConfig{Enabled: true},
+		GCP:   config.GCPConfig{ProjectID: "web-prod"},
+		OCI:   config.OCIConfig{Enabled: true, Region: "us-ashburn-1"},
+
+		Kubernetes: config.KubernetesConfig{Enabled: true, Kubeconfig: "testdata/kubernetes/missing"},
+	}
+	manager := NewManager(cfg)
+	if err := manager.RegisterConfigured(); err != nil {
+		t.Fatalf("RegisterConfigured failed: %v", err)
+	}
+
+	for _, name := range []string{"azure", "oci", "kubernetes"} {
+		if _, err := manager.GetProvider(name); err != nil {
+			t.Errorf("Expected %s to be registered: %v", name, err)
+		}
+	}
+	for _, name := range []string{"aws", "gcp"} {
+		if _, err := manager.GetProvider(name); err == nil {
+			t.Errorf("Expected disabled provider %s not to be registered", name)
+		}
+	}
+}
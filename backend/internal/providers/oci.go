@@ -0,0 +1,370 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const (
+	ociDefaultSearchEndpoint = "https://query.{region}.oraclecloud.com"
+	ociSearchPath            = "/20180409/resources"
+	ociSearchPageSize        = 1000
+	ociScanAllQuery          = "query all resources"
+	ociGlobalRegion          = "global"
+)
+
+// ociResourceSummary is a ResourceSummary returned by the Search service
+type ociResourceSummary struct {
+	ResourceType       string                            `json:"resourceType"`
+	Identifier         string                            `json:"identifier"`
+	CompartmentID      string                            `json:"compartmentId"`
+	DisplayName        string                            `json:"displayName"`
+	AvailabilityDomain string                            `json:"availabilityDomain"`
+	LifecycleState     string                            `json:"lifecycleState"`
+	TimeCreated        string                            `json:"timeCreated"`
+	FreeformTags       map[string]string                 `json:"freeformTags"`
+	DefinedTags        map[string]map[string]interface{} `json:"definedTags"`
+}
+
+// OCIProvider implements the Provider interface for Oracle Cloud Infrastructure
+type OCIProvider struct {
+	config config.OCIConfig
+	client *http.Client
+
+	signingKey *rsa.PrivateKey
+}
+
+// NewOCIProvider creates a new OCI provider
+func NewOCIProvider(cfg config.OCIConfig) (*OCIProvider, error) {
+	if cfg.SearchEndpoint == "" {
+		cfg.SearchEndpoint = ociDefaultSearchEndpoint
+	}
+	cfg.SearchEndpoint = strings.TrimRight(cfg.SearchEndpoint, "/")
+
+	return &OCIProvider{
+		config: cfg,
+		client: newHTTPClient(),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *OCIProvider) Name() string {
+	return "oci"
+}
+
+// Validate validates the OCI configuration and API key
+func (p *OCIProvider) Validate() error {
+	if p.config.TenancyOCID == "" || p.config.UserOCID == "" || p.config.Fingerprint == "" || p.config.KeyFile == "" {
+		return fmt.Errorf("OCI configuration incomplete: missing tenancy_ocid, user_ocid, fingerprint, or key_file")
+	}
+	regions := p.regions()
+	if len(regions) == 0 {
+		return fmt.Errorf("OCI configuration incomplete: missing region")
+	}
+
+	if _, err := p.search(context.Background(), regions[0], "query compartment resources", 1); err != nil {
+		return fmt.Errorf("OCI credential validation failed: %w", err)
+	}
+	return nil
+}
+
+// Scan enumerates compartments and resources in every configured region
+// through the Search service. Resources that are not regional, such as
+// compartments, are returned by every region and are only reported once.
+func (p *OCIProvider) Scan(ctx context.Context) ([]types.Resource, error) {
+	return collectScan(ctx, p.ScanStream)
+}
+
+// ScanStream is Scan, sending resources to out a Search page at a time
+func (p *OCIProvider) ScanStream(ctx context.Context, out chan<- types.Resource) error {
+	regions := p.regions()
+	if len(regions) == 0 {
+		return fmt.Errorf("OCI region is not configured")
+	}
+
+	var (
+		failures []ScanFailure
+		seen     = make(map[string]bool)
+	)
+	for _, region := range regions {
+		err := p.searchPages(ctx, region, ociScanAllQuery, 0, func(summaries []ociResourceSummary) error {
+			for i := range summaries {
+				if seen[summaries[i].Identifier] {
+					continue
+				}
+				seen[summaries[i].Identifier] = true
+				select {
+				case out <- p.convertSummary(&summaries[i], region):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			failures = append(failures, ScanFailure{Region: region, Service: "search", Err: err})
+		}
+	}
+
+	if len(failures) > 0 {
+		return &ScanError{Provider: p.Name(), Failures: failures}
+	}
+	return nil
+}
+
+// GetResource retrieves a specific resource by its OCID
+func (p *OCIProvider) GetResource(id string) (*types.Resource, error) {
+	if !strings.HasPrefix(id, "ocid1.") || strings.ContainsAny(id, `'\`) {
+		return nil, fmt.Errorf("invalid OCI resource ID: %s", id)
+	}
+
+	regions := p.regions()
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("OCI region is not configured")
+	}
+
+	// Search only sees resources in the region it is queried in, so try each
+	query := fmt.Sprintf("query all resources where identifier = '%s'", id)
+	for _, region := range regions {
+		summaries, err := p.search(context.Background(), region, query, 0)
+		if err != nil {
+			return nil, err
+		}
+		for i := range summaries {
+			if summaries[i].Identifier == id {
+				resource := p.convertSummary(&summaries[i], region)
+				return &resource, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("resource not found: %s", id)
+}
+
+// regions returns the regions to search, defaulting to the home region
+func (p *OCIProvider) regions() []string {
+	if len(p.config.Regions) > 0 {
+		return p.config.Regions
+	}
+	if p.config.Region != "" {
+		return []string{p.config.Region}
+	}
+	return nil
+}
+
+// search runs a structured query in region and returns every summary
+func (p *OCIProvider) search(ctx context.Context, region, query string, limit int) ([]ociResourceSummary, error) {
+	var summaries []ociResourceSummary
+	err := p.searchPages(ctx, region, query, limit, func(page []ociResourceSummary) error {
+		summaries = append(summaries, page...)
+		return nil
+	})
+	return summaries, err
+}
+
+// searchPages runs a structured query in region, passing each page to fn and
+// following opc-next-page. A limit of zero reads every page; otherwise only
+// the first page of limit results.
+func (p *OCIProvider) searchPages(ctx context.Context, region, query string, limit int, fn func([]ociResourceSummary) error) error {
+	body, err := json.Marshal(map[string]string{
+		"type":                "Structured",
+		"query":               query,
+		"matchingContextType": "NONE",
+	})
+	if err != nil {
+		return err
+	}
+
+	pageSize := limit
+	if pageSize == 0 {
+		pageSize = ociSearchPageSize
+	}
+	params := url.Values{"limit": {fmt.Sprint(pageSize)}}
+	endpoint := strings.ReplaceAll(p.config.SearchEndpoint, "{region}", region) + ociSearchPath
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"?"+params.Encode(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if err := p.sign(req, body, time.Now()); err != nil {
+			return err
+		}
+
+		var page struct {
+			Items []ociResourceSummary `json:"items"`
+		}
+		header, err := doJSONHeader(p.client, req, &page)
+		if err != nil {
+			return err
+		}
+		if err := fn(page.Items); err != nil {
+			return err
+		}
+
+		next := header.Get("opc-next-page")
+		if next == "" || limit > 0 {
+			return nil
+		}
+		params.Set("page", next)
+	}
+}
+
+// sign adds an OCI HTTP Signature to req using the configured API key. Body
+// headers are only signed for requests that carry a body.
+func (p *OCIProvider) sign(req *http.Request, body []byte, now time.Time) error {
+	if err := p.loadKey(); err != nil {
+		return err
+	}
+
+	req.Header.Set("Date", now.UTC().Format(http.TimeFormat))
+	headers := []string{"date", "(request-target)", "host"}
+	if req.Method == http.MethodPost || req.Method == http.MethodPut || req.Method == http.MethodPatch {
+		digest := sha256.Sum256(body)
+		req.Header.Set("Content-Length", fmt.Sprint(len(body)))
+		req.Header.Set("X-Content-Sha256", base64.StdEncoding.EncodeToString(digest[:]))
+		headers = append(headers, "content-length", "content-type", "x-content-sha256")
+	}
+
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		switch header {
+		case "(request-target)":
+			lines[i] = header + ": " + strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			lines[i] = header + ": " + req.URL.Host
+		default:
+			lines[i] = header + ": " + req.Header.Get(header)
+		}
+	}
+
+	digest := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.signingKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign OCI request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		`Signature version="1",keyId="%s/%s/%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		p.config.TenancyOCID, p.config.UserOCID, p.config.Fingerprint,
+		strings.Join(headers, " "), base64.StdEncoding.EncodeToString(signature),
+	))
+	return nil
+}
+
+// loadKey reads and parses the API signing key once. A leading ~ in the key
+// path is expanded as the OCI CLI does.
+func (p *OCIProvider) loadKey() error {
+	if p.signingKey != nil {
+		return nil
+	}
+
+	path, err := expandHome(p.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve OCI key_file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read OCI API key: %w", err)
+	}
+	key, err := parseRSAPrivateKey(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse OCI API key: %w", err)
+	}
+	p.signingKey = key
+	return nil
+}
+
+// convertSummary converts a Search service result to a Siros resource
+func (p *OCIProvider) convertSummary(summary *ociResourceSummary, region string) types.Resource {
+	tags := make(map[string]string, len(summary.FreeformTags))
+	for k, v := range summary.FreeformTags {
+		tags[k] = v
+	}
+	// Defined tags are namespaced; flatten them to namespace.key
+	for namespace, values := range summary.DefinedTags {
+		for k, v := range values {
+			tags[namespace+"."+k] = fmt.Sprint(v)
+		}
+	}
+
+	name := summary.DisplayName
+	if name == "" {
+		name = summary.Identifier
+	}
+
+	metadata := map[string]interface{}{
+		"resource_type":   summary.ResourceType,
+		"compartment_id":  summary.CompartmentID,
+		"lifecycle_state": summary.LifecycleState,
+	}
+	if summary.AvailabilityDomain != "" {
+		metadata["availability_domain"] = summary.AvailabilityDomain
+	}
+	if summary.TimeCreated != "" {
+		metadata["time_created"] = summary.TimeCreated
+	}
+
+	var parentID *string
+	if summary.CompartmentID != "" {
+		parent := summary.CompartmentID
+		parentID = &parent
+	}
+
+	now := time.Now()
+	return types.Resource{
+		ID:        summary.Identifier,
+		Type:      "oci." + snakeCase(summary.ResourceType),
+		Provider:  "oci",
+		Region:    ociRegion(summary.Identifier, region),
+		Name:      name,
+		ARN:       summary.Identifier,
+		Tags:      tags,
+		Metadata:  metadata,
+		State:     ociState(summary.LifecycleState),
+		ParentID:  parentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// ociRegion returns region for a regional OCID and "global" for OCIDs with an
+// empty region segment, e.g. ocid1.compartment.oc1..aaaa
+func ociRegion(ocid, region string) string {
+	parts := strings.SplitN(ocid, ".", 5)
+	if len(parts) == 5 && parts[3] == "" {
+		return ociGlobalRegion
+	}
+	return region
+}
+
+// ociState maps an OCI lifecycle state to a resource state
+func ociState(state string) types.ResourceState {
+	switch strings.ToUpper(state) {
+	case "ACTIVE", "RUNNING", "AVAILABLE", "ENABLED":
+		return types.ResourceStateActive
+	case "INACTIVE", "STOPPED", "STOPPING", "STARTING", "CREATING", "PROVISIONING", "UPDATING", "DISABLED":
+		return types.ResourceStateInactive
+	case "TERMINATING", "TERMINATED", "DELETING", "DELETED":
+		return types.ResourceStateTerminated
+	case "FAILED":
+		return types.ResourceStateError
+	default:
+		return types.ResourceStateUnknown
+	}
+}
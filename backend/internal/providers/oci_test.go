@@ -0,0 +1,286 @@
+package providers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const (
+	testOCITenancy     = "ocid1.tenancy.oc1..aaaatenancy"
+	testOCIUser        = "ocid1.user.oc1..aaaauser"
+	testOCIFingerprint = "20:3b:97:13:55:1c:5b:0d:d3:37:d8:50:4e:c5:3a:34"
+)
+
+var ociAuthorization = regexp.MustCompile(`^Signature version="1",keyId="([^"]+)",algorithm="rsa-sha256",headers="([^"]+)",signature="([^"]+)"$`)
+
+// fakeOCI stands in for the Search service of several regions. Responses are
+// served from testdata/oci/<fixture>.<region>.json, keyed by page token; a
+// page's nextPage is returned in the opc-next-page header.
+type fakeOCI struct {
+	t        *testing.T
+	key      *rsa.PublicKey
+	fail     map[string]bool // region -> fail
+	queries  []string
+	requests []*http.Request
+}
+
+func (f *fakeOCI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	region, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if r.Method != http.MethodPost || "/"+path != ociSearchPath {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	if err := f.verify(r, body); err != nil {
+		http.Error(w, `{"code":"NotAuthenticated","message":"`+err.Error()+`"}`, http.StatusUnauthorized)
+		return
+	}
+	f.requests = append(f.requests, r)
+	if f.fail[region] {
+		http.Error(w, `{"code":"InternalError"}`, http.StatusInternalServerError)
+		return
+	}
+
+	var details struct {
+		Type  string `json:"type"`
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &details); err != nil || details.Type != "Structured" {
+		http.Error(w, `{"code":"InvalidParameter"}`, http.StatusBadRequest)
+		return
+	}
+	f.queries = append(f.queries, details.Query)
+
+	fixture := "search"
+	if strings.Contains(details.Query, "where identifier") {
+		fixture = "lookup"
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "oci", fixture+"."+region+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		writeJSON(w, map[string]interface{}{"items": []interface{}{}})
+		return
+	}
+	if err != nil {
+		f.t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var pages map[string]struct {
+		NextPage string          `json:"nextPage"`
+		Items    json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &pages); err != nil {
+		f.t.Fatalf("invalid fixture %s.%s: %v", fixture, region, err)
+	}
+	page, ok := pages[r.URL.Query().Get("page")]
+	if !ok {
+		http.Error(w, `{"code":"InvalidParameter","message":"invalid page"}`, http.StatusBadRequest)
+		return
+	}
+	if page.NextPage != "" {
+		w.Header().Set("opc-next-page", page.NextPage)
+	}
+	writeJSON(w, map[string]json.RawMessage{"items": page.Items})
+}
+
+// verify checks the HTTP Signature the way the OCI API gateway does
+func (f *fakeOCI) verify(r *http.Request, body []byte) error {
+	match := ociAuthorization.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil {
+		return errors.New("missing signature")
+	}
+	if match[1] != testOCITenancy+"/"+testOCIUser+"/"+testOCIFingerprint {
+		return errors.New("unknown key")
+	}
+
+	headers := strings.Fields(match[2])
+	if strings.Join(headers, " ") != "date (request-target) host content-length content-type x-content-sha256" {
+		return errors.New("required headers not signed")
+	}
+	digest := sha256.Sum256(body)
+	if r.Header.Get("X-Content-Sha256") != base64.StdEncoding.EncodeToString(digest[:]) {
+		return errors.New("body digest mismatch")
+	}
+
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		switch header {
+		case "(request-target)":
+			lines[i] = header + ": " + strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+		case "host":
+			lines[i] = header + ": " + r.Host
+		case "content-length":
+			lines[i] = header + ": " + r.Header.Get("Content-Length")
+		default:
+			lines[i] = header + ": " + r.Header.Get(header)
+		}
+	}
+	signature, _ := base64.StdEncoding.DecodeString(match[3])
+	signed := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return rsa.VerifyPKCS1v15(f.key, crypto.SHA256, signed[:], signature)
+}
+
+func newTestOCIProvider(t *testing.T, mutate func(*config.OCIConfig)) (*OCIProvider, *fakeOCI) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	fake := &fakeOCI{t: t, key: &key.PublicKey, fail: make(map[string]bool)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	keyPath := filepath.Join(t.TempDir(), "oci_api_key.pem")
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, pemKey, 0o600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	cfg := config.OCIConfig{
+		TenancyOCID:    testOCITenancy,
+		UserOCID:       testOCIUser,
+		Fingerprint:    testOCIFingerprint,
+		KeyFile:        keyPath,
+		Region:         "us-ashburn-1",
+		SearchEndpoint: server.URL + "/{region}",
+	}
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	provider, err := NewOCIProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewOCIProvider failed: %v", err)
+	}
+	return provider, fake
+}
+
+func TestOCIProvider_ScanSearchesRegions(t *testing.T) {
+	provider, fake := newTestOCIProvider(t, func(cfg *config.OCIConfig) {
+		cfg.Regions = []string{"us-ashburn-1", "eu-frankfurt-1"}
+	})
+
+	resources, err := provider.Scan(t.Context())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(resources) != 5 {
+		t.Fatalf("Expected 5 resources with the shared compartment reported once, got %d", len(resources))
+	}
+	if len(fake.requests) != 3 {
+		t.Errorf("Expected two pages in Ashburn and one in Frankfurt, got %d requests", len(fake.requests))
+	}
+	if fake.queries[0] != ociScanAllQuery {
+		t.Errorf("Unexpected search query: %s", fake.queries[0])
+	}
+
+	byID := resourcesByID(resources)
+	compartment := byID["ocid1.compartment.oc1..aaaaprod"]
+	if compartment.Type != "oci.compartment" || compartment.Region != ociGlobalRegion {
+		t.Errorf("Unexpected compartment: %+v", compartment)
+	}
+	if compartment.ParentID == nil || *compartment.ParentID != testOCITenancy {
+		t.Errorf("Expected compartment parented to the tenancy, got %v", compartment.ParentID)
+	}
+
+	vm := byID["ocid1.instance.oc1.iad.aaaaweb1"]
+	if vm.Type != "oci.instance" || vm.Provider != "oci" || vm.Region != "us-ashburn-1" || vm.Name != "web-1" {
+		t.Errorf("Unexpected instance: %+v", vm)
+	}
+	if vm.State != types.ResourceStateActive || vm.Tags["role"] != "web" || vm.Tags["Operations.CostCenter"] != "42" {
+		t.Errorf("Expected running instance with freeform and defined tags, got state %s tags %v", vm.State, vm.Tags)
+	}
+	if vm.Metadata["availability_domain"] != "Uocm:US-ASHBURN-AD-1" {
+		t.Errorf("Expected availability domain in metadata, got %v", vm.Metadata)
+	}
+
+	if byID["ocid1.instance.oc1.iad.aaaabatch"].State != types.ResourceStateInactive {
+		t.Error("Expected stopped instance to be inactive")
+	}
+	if byID["ocid1.autonomousdatabase.oc1.iad.aaaaorders"].Type != "oci.autonomous_database" {
+		t.Errorf("Unexpected autonomous database type: %s", byID["ocid1.autonomousdatabase.oc1.iad.aaaaorders"].Type)
+	}
+	if vcn := byID["ocid1.vcn.oc1.eu-frankfurt-1.aaaavcn"]; vcn.Type != "oci.vcn" || vcn.Region != "eu-frankfurt-1" {
+		t.Errorf("Unexpected VCN: %+v", vcn)
+	}
+}
+
+func TestOCIProvider_ScanIsolatesRegionFailures(t *testing.T) {
+	provider, fake := newTestOCIProvider(t, func(cfg *config.OCIConfig) {
+		cfg.Regions = []string{"us-ashburn-1", "eu-frankfurt-1"}
+	})
+	fake.fail["us-ashburn-1"] = true
+
+	resources, err := provider.Scan(t.Context())
+	if !IsPartialScan(err) {
+		t.Fatalf("Expected a partial scan error, got %v", err)
+	}
+	if len(resources) != 2 {
+		t.Errorf("Expected the Frankfurt resources to be kept, got %d", len(resources))
+	}
+	var scanErr *ScanError
+	if errors.As(err, &scanErr) && scanErr.Failures[0].Region != "us-ashburn-1" {
+		t.Errorf("Expected the failure to name the region, got %+v", scanErr.Failures[0])
+	}
+}
+
+func TestOCIProvider_GetResource(t *testing.T) {
+	provider, fake := newTestOCIProvider(t, nil)
+
+	id := "ocid1.instance.oc1.iad.aaaaweb1"
+	resource, err := provider.GetResource(id)
+	if err != nil {
+		t.Fatalf("GetResource failed: %v", err)
+	}
+	if resource.ID != id || resource.Region != "us-ashburn-1" {
+		t.Errorf("Unexpected resource: %+v", resource)
+	}
+	if fake.queries[0] != "query all resources where identifier = '"+id+"'" {
+		t.Errorf("Unexpected search query: %s", fake.queries[0])
+	}
+
+	if _, err := provider.GetResource("ocid1.instance.oc1.iad.missing"); err == nil {
+		t.Error("Expected error for an unknown OCID")
+	}
+	if _, err := provider.GetResource("web-1"); err == nil {
+		t.Error("Expected error for an ID that is not an OCID")
+	}
+}
+
+func TestOCIProvider_Validate(t *testing.T) {
+	provider, _ := newTestOCIProvider(t, func(cfg *config.OCIConfig) { cfg.Fingerprint = "" })
+	if err := provider.Validate(); err == nil {
+		t.Error("Expected validation to fail without a fingerprint")
+	}
+
+	provider, _ = newTestOCIProvider(t, func(cfg *config.OCIConfig) { cfg.UserOCID = "ocid1.user.oc1..other" })
+	if err := provider.Validate(); err == nil {
+		t.Error("Expected validation to fail with an unknown key")
+	}
+
+	provider, fake := newTestOCIProvider(t, nil)
+	if err := provider.Validate(); err != nil {
+		t.Errorf("Expected validation to succeed, got %v", err)
+	}
+	if got := fake.requests[0].URL.Query().Get("limit"); got != "1" {
+		t.Errorf("Expected validation to request a single result, got limit %s", got)
+	}
+}
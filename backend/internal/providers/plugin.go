@@ -0,0 +1,443 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LederWorks/siros/backend/pkg/plugin"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const (
+	// PluginPrefix is the file name prefix of provider plugin executables
+	PluginPrefix = "siros-provider-"
+
+	pluginHandshakeTimeout = 30 * time.Second
+	pluginCallTimeout      = 5 * time.Minute
+	pluginStopTimeout      = 5 * time.Second
+	pluginMinBackoff       = time.Second
+	pluginMaxBackoff       = time.Minute
+)
+
+// errPluginExited is returned for calls in flight when a plugin process exits
+var errPluginExited = errors.New("plugin process exited")
+
+// PluginProvider implements the Provider interface by delegating to an
+// out-of-process plugin. The process is started on creation and restarted on
+// the next call if it exits; failed restarts back off exponentially.
+type PluginProvider struct {
+	path   string
+	config map[string]interface{}
+
+	mu          sync.Mutex
+	desc        plugin.Description
+	conn        *pluginConn
+	backoff     time.Duration
+	nextAttempt time.Time
+	closed      bool
+}
+
+// NewPluginProvider starts the plugin at path and performs the describe handshake
+func NewPluginProvider(path string, cfg map[string]interface{}) (*PluginProvider, error) {
+	p := &PluginProvider{path: path, config: cfg}
+	if _, err := p.connection(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Name returns the provider name reported by the plugin
+func (p *PluginProvider) Name() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.desc.Name
+}
+
+// Description returns the plugin's description from its last handshake
+func (p *PluginProvider) Description() plugin.Description {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.desc
+}
+
+// Validate asks the plugin to validate its configuration
+func (p *PluginProvider) Validate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginCallTimeout)
+	defer cancel()
+	return p.call(ctx, plugin.MethodValidate, nil, nil, nil)
+}
+
+// Scan runs a scan in the plugin, collecting the streamed batches
+func (p *PluginProvider) Scan(ctx context.Context) ([]types.Resource, error) {
+	return collectScan(ctx, p.ScanStream)
+}
+
+// ScanStream runs a scan in the plugin, sending each streamed batch to out.
+// While out is full the plugin's output is not read, which throttles it.
+func (p *PluginProvider) ScanStream(ctx context.Context, out chan<- types.Resource) error {
+	name := p.Name()
+	sent := 0
+	var result plugin.ScanResult
+	err := p.call(ctx, plugin.MethodScan, nil, &result, func(batch *plugin.ScanResources) {
+		for i := range batch.Resources {
+			if batch.Resources[i].Provider == "" {
+				batch.Resources[i].Provider = name
+			}
+			select {
+			case out <- batch.Resources[i]:
+				sent++
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if result.Total != sent {
+		return fmt.Errorf("plugin %s reported %d resources but sent %d", name, result.Total, sent)
+	}
+	return nil
+}
+
+// GetResource retrieves a specific resource from the plugin
+func (p *PluginProvider) GetResource(id string) (*types.Resource, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginCallTimeout)
+	defer cancel()
+
+	var resource *types.Resource
+	if err := p.call(ctx, plugin.MethodGetResource, plugin.GetResourceParams{ID: id}, &resource, nil); err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, fmt.Errorf("resource not found: %s", id)
+	}
+	return resource, nil
+}
+
+// Close stops the plugin process
+func (p *PluginProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.close()
+	p.conn = nil
+	return err
+}
+
+// call invokes method on a running plugin process
+func (p *PluginProvider) call(ctx context.Context, method string, params, result interface{}, onBatch func(*plugin.ScanResources)) error {
+	conn, err := p.connection()
+	if err != nil {
+		return err
+	}
+	if err := conn.call(ctx, method, params, result, onBatch); err != nil {
+		return fmt.Errorf("plugin %s %s: %w", p.Name(), method, err)
+	}
+	return nil
+}
+
+// connection returns the running plugin process, starting or restarting it as needed
+func (p *PluginProvider) connection() (*pluginConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, fmt.Errorf("plugin %s is closed", p.path)
+	}
+	if p.conn != nil && p.conn.alive() {
+		return p.conn, nil
+	}
+	if p.conn != nil {
+		log.Printf("Plugin %s exited (%v), restarting", p.path, p.conn.exitErr())
+		p.conn = nil
+	}
+	if wait := time.Until(p.nextAttempt); wait > 0 {
+		return nil, fmt.Errorf("plugin %s failed to start, retrying in %s", p.path, wait.Round(time.Second))
+	}
+
+	conn, desc, err := p.start()
+	if err != nil {
+		p.backoff = min(max(2*p.backoff, pluginMinBackoff), pluginMaxBackoff)
+		p.nextAttempt = time.Now().Add(p.backoff)
+		return nil, err
+	}
+	if p.desc.Name != "" && desc.Name != p.desc.Name {
+		_ = conn.close()
+		return nil, fmt.Errorf("plugin %s changed its name from %s to %s", p.path, p.desc.Name, desc.Name)
+	}
+
+	p.conn = conn
+	p.desc = *desc
+	p.backoff = 0
+	p.nextAttempt = time.Time{}
+	return conn, nil
+}
+
+// start launches the plugin process and performs the describe handshake
+func (p *PluginProvider) start() (*pluginConn, *plugin.Description, error) {
+	conn, err := startPluginProcess(p.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginHandshakeTimeout)
+	defer cancel()
+
+	var desc plugin.Description
+	params := plugin.DescribeParams{ProtocolVersion: plugin.ProtocolVersion, Config: p.config}
+	if err := conn.call(ctx, plugin.MethodDescribe, params, &desc, nil); err != nil {
+		_ = conn.close()
+		return nil, nil, fmt.Errorf("plugin %s handshake failed: %w", p.path, err)
+	}
+	if desc.ProtocolVersion != plugin.ProtocolVersion {
+		_ = conn.close()
+		return nil, nil, fmt.Errorf("plugin %s speaks protocol version %d, expected %d", p.path, desc.ProtocolVersion, plugin.ProtocolVersion)
+	}
+	if desc.Name == "" {
+		_ = conn.close()
+		return nil, nil, fmt.Errorf("plugin %s did not report a name", p.path)
+	}
+	return conn, &desc, nil
+}
+
+// pluginConn is a JSON-RPC connection to a running plugin process
+type pluginConn struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]*pluginCall
+	done    chan struct{}
+	err     error
+}
+
+// pluginCall is a call awaiting its response
+type pluginCall struct {
+	response chan *plugin.Message
+	onBatch  func(*plugin.ScanResources)
+}
+
+// startPluginProcess launches the plugin and starts reading its stdout
+func startPluginProcess(path string) (*pluginConn, error) {
+	cmd := exec.Command(path) // #nosec G204 -- plugins are discovered in the operator-configured directory
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+
+	c := &pluginConn{
+		cmd:     cmd,
+		stdin:   stdin,
+		enc:     json.NewEncoder(stdin),
+		pending: make(map[int64]*pluginCall),
+		done:    make(chan struct{}),
+	}
+	go c.read(stdout)
+	return c, nil
+}
+
+// read dispatches responses and notifications until the plugin's stdout closes
+func (c *pluginConn) read(stdout io.Reader) {
+	dec := json.NewDecoder(stdout)
+	var readErr error
+	for {
+		var msg plugin.Message
+		if err := dec.Decode(&msg); err != nil {
+			readErr = err
+			break
+		}
+
+		if msg.Method == plugin.MethodScanResources {
+			var batch plugin.ScanResources
+			if err := json.Unmarshal(msg.Params, &batch); err != nil {
+				continue
+			}
+			c.mu.Lock()
+			pending := c.pending[batch.RequestID]
+			c.mu.Unlock()
+			if pending != nil && pending.onBatch != nil {
+				pending.onBatch(&batch)
+			}
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+
+		c.mu.Lock()
+		pending := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if pending != nil {
+			pending.response <- &msg
+		}
+	}
+
+	waitErr := c.cmd.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case waitErr != nil:
+		c.err = waitErr
+	case errors.Is(readErr, io.EOF):
+		c.err = errPluginExited
+	default:
+		c.err = readErr
+	}
+	for id, pending := range c.pending {
+		close(pending.response)
+		delete(c.pending, id)
+	}
+	close(c.done)
+}
+
+// call sends a request and waits for its response. Batches streamed for the
+// call are passed to onBatch from the reader goroutine, in order.
+func (c *pluginConn) call(ctx context.Context, method string, params, result interface{}, onBatch func(*plugin.ScanResources)) error {
+	var rawParams json.RawMessage
+	if params != nil {
+		var err error
+		if rawParams, err = json.Marshal(params); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	pending := &pluginCall{response: make(chan *plugin.Message, 1), onBatch: onBatch}
+	c.pending[id] = pending
+	c.mu.Unlock()
+
+	if err := c.send(plugin.Message{ID: &id, Method: method, Params: rawParams}); err != nil {
+		c.forget(id)
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	select {
+	case msg, ok := <-pending.response:
+		if !ok {
+			return c.exitErr()
+		}
+		if msg.Error != nil {
+			if msg.Error.Code == plugin.CodeNotFound {
+				return fmt.Errorf("%w: %s", plugin.ErrNotFound, msg.Error.Message)
+			}
+			return msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("failed to decode result: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		c.forget(id)
+		if cancel, err := json.Marshal(plugin.CancelParams{ID: id}); err == nil {
+			_ = c.send(plugin.Message{Method: plugin.MethodCancel, Params: cancel})
+		}
+		return ctx.Err()
+	}
+}
+
+func (c *pluginConn) send(msg plugin.Message) error {
+	msg.JSONRPC = "2.0"
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.enc.Encode(msg)
+}
+
+func (c *pluginConn) forget(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// alive reports whether the plugin process is still running
+func (c *pluginConn) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// exitErr returns why the plugin process stopped
+func (c *pluginConn) exitErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// close stops the plugin by closing its stdin, killing it if it does not exit
+func (c *pluginConn) close() error {
+	_ = c.stdin.Close()
+	select {
+	case <-c.done:
+	case <-time.After(pluginStopTimeout):
+		_ = c.cmd.Process.Kill()
+		<-c.done
+	}
+	if err := c.exitErr(); err != nil && !errors.Is(err, errPluginExited) {
+		return err
+	}
+	return nil
+}
+
+// discoverPlugins returns the plugin executables in dir, keyed by the name
+// following PluginPrefix
+func discoverPlugins(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	plugins := make(map[string]string)
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), PluginPrefix)
+		if !ok || name == "" || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0o111 == 0 {
+			continue
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+		plugins[name] = filepath.Join(dir, entry.Name())
+	}
+	return plugins, nil
+}
@@ -0,0 +1,182 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/plugin"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+const testPluginEnv = "SIROS_TEST_PLUGIN"
+
+// helperPlugin is served by the test binary when re-executed as a plugin
+type helperPlugin struct {
+	name string
+}
+
+func (p *helperPlugin) Describe(_ context.Context, cfg map[string]interface{}) (*plugin.Description, error) {
+	if cfg["site"] == nil {
+		return nil, fmt.Errorf("site is required")
+	}
+	return &plugin.Description{Name: p.name, Version: "0.1.0", ResourceTypes: []string{"vmware.vm"}}, nil
+}
+
+func (p *helperPlugin) Validate(context.Context) error {
+	return nil
+}
+
+func (p *helperPlugin) Scan(_ context.Context, emit func(...types.Resource) error) error {
+	for i := 1; i <= 3; i++ {
+		if err := emit(types.Resource{ID: fmt.Sprintf("vm-%d", i), Type: "vmware.vm", Name: fmt.Sprintf("vm-%d", i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *helperPlugin) GetResource(_ context.Context, id string) (*types.Resource, error) {
+	switch id {
+	case "crash":
+		os.Exit(3)
+	case "vm-1":
+		return &types.Resource{ID: id, Type: "vmware.vm", Provider: p.name}, nil
+	}
+	return nil, plugin.ErrNotFound
+}
+
+// TestPluginHelperProcess is not a real test; it serves helperPlugin when the
+// test binary is started by a plugin wrapper script
+func TestPluginHelperProcess(t *testing.T) {
+	name := os.Getenv(testPluginEnv)
+	if name == "" {
+		t.Skip("only runs as a plugin subprocess")
+	}
+	if err := plugin.Serve(&helperPlugin{name: name}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// writeTestPlugin installs a wrapper that re-executes the test binary as a plugin
+func writeTestPlugin(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, PluginPrefix+name)
+	script := fmt.Sprintf("#!/bin/sh\n%s=%s exec %q -test.run=TestPluginHelperProcess\n", testPluginEnv, name, os.Args[0])
+	if err := os.WriteFile(path, []byte(script), 0o700); err != nil {
+		t.Fatalf("failed to write plugin: %v", err)
+	}
+	return path
+}
+
+func TestPluginProvider_Scan(t *testing.T) {
+	path := writeTestPlugin(t, t.TempDir(), "vmware")
+	provider, err := NewPluginProvider(path, map[string]interface{}{"site": "dc1"})
+	if err != nil {
+		t.Fatalf("NewPluginProvider failed: %v", err)
+	}
+	t.Cleanup(func() { _ = provider.Close() })
+
+	if provider.Name() != "vmware" || provider.Description().Version != "0.1.0" {
+		t.Errorf("Unexpected description: %+v", provider.Description())
+	}
+	if err := provider.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	resources, err := provider.Scan(t.Context())
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(resources) != 3 || resources[0].Provider != "vmware" {
+		t.Errorf("Expected 3 streamed resources stamped with the plugin name, got %+v", resources)
+	}
+
+	if _, err := provider.GetResource("vm-9"); !errors.Is(err, plugin.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPluginProvider_RestartsAfterCrash(t *testing.T) {
+	path := writeTestPlugin(t, t.TempDir(), "vmware")
+	provider, err := NewPluginProvider(path, map[string]interface{}{"site": "dc1"})
+	if err != nil {
+		t.Fatalf("NewPluginProvider failed: %v", err)
+	}
+	t.Cleanup(func() { _ = provider.Close() })
+
+	if _, err := provider.GetResource("crash"); err == nil {
+		t.Fatal("Expected the crashing call to fail")
+	}
+
+	resource, err := provider.GetResource("vm-1")
+	if err != nil {
+		t.Fatalf("Expected the plugin to be restarted, got %v", err)
+	}
+	if resource.ID != "vm-1" {
+		t.Errorf("Unexpected resource: %+v", resource)
+	}
+}
+
+func TestPluginProvider_HandshakeFailure(t *testing.T) {
+	path := writeTestPlugin(t, t.TempDir(), "vmware")
+	if _, err := NewPluginProvider(path, nil); err == nil {
+		t.Error("Expected the handshake to fail without configuration")
+	}
+}
+
+func TestManager_LoadPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPlugin(t, dir, "vmware")
+	writeTestPlugin(t, dir, "cmdb")
+	writeTestPlugin(t, dir, "broken")
+	// Files without the prefix or the executable bit are ignored
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("plugins"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, PluginPrefix+"disabled"), []byte("#!/bin/sh\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(&config.ProvidersConfig{
+		Plugins: config.PluginsConfig{
+			Enabled: true,
+			Dir:     dir,
+			Config: map[string]map[string]interface{}{
+				"vmware": {"site": "dc1"},
+				"cmdb":   {"site": "hq"},
+			},
+		},
+	})
+	if err := manager.RegisterConfigured(); err != nil {
+		t.Fatalf("RegisterConfigured failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := manager.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	})
+
+	for _, name := range []string{"vmware", "cmdb"} {
+		if _, err := manager.GetProvider(name); err != nil {
+			t.Errorf("Expected plugin %s to be registered: %v", name, err)
+		}
+	}
+	if _, err := manager.GetProvider("broken"); err == nil {
+		t.Error("Expected the plugin failing its handshake to be skipped")
+	}
+
+	resources, err := manager.ScanAll(t.Context())
+	if err != nil {
+		t.Fatalf("ScanAll failed: %v", err)
+	}
+	if len(resources) != 6 {
+		t.Errorf("Expected 3 resources from each plugin, got %d", len(resources))
+	}
+}
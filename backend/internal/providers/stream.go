@@ -0,0 +1,201 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// DefaultBatchSize is the number of resources persisted per sink call
+const DefaultBatchSize = 500
+
+// ResourceSink persists scanned resources, typically as a batched upsert
+type ResourceSink interface {
+	UpsertResources(ctx context.Context, resources []types.Resource) error
+}
+
+// ScanProgress reports the state of a provider's streaming scan
+type ScanProgress struct {
+	Provider   string    `json:"provider"`
+	Discovered int       `json:"discovered"`
+	Persisted  int       `json:"persisted"`
+	Batches    int       `json:"batches"`
+	StartedAt  time.Time `json:"started_at"`
+	Done       bool      `json:"done"`
+	Err        error     `json:"-"`
+}
+
+// StreamOptions configures Manager.StreamAll
+type StreamOptions struct {
+	// BatchSize bounds how many resources are held before they are flushed
+	// to the sink. Defaults to DefaultBatchSize.
+	BatchSize int
+
+	// Providers restricts the scan to the named providers. Defaults to every
+	// registered provider.
+	Providers []string
+
+	// Progress, when set, is called after every flushed batch and once more
+	// when each provider finishes
+	Progress func(ScanProgress)
+}
+
+// StreamScan streams a provider's resources to out, falling back to Scan for
+// providers that do not implement types.StreamingProvider
+func StreamScan(ctx context.Context, provider types.Provider, out chan<- types.Resource) error {
+	if streaming, ok := provider.(types.StreamingProvider); ok {
+		return streaming.ScanStream(ctx, out)
+	}
+
+	resources, err := provider.Scan(ctx)
+	if sendErr := sendResources(ctx, out, resources); sendErr != nil {
+		return sendErr
+	}
+	return err
+}
+
+// StreamAll scans every registered provider, piping resources into sink in
+// batches of opts.BatchSize. Memory is bounded by the batch size rather than
+// the size of the estate, and batches flushed before a failure are kept. A
+// provider failure does not stop the remaining providers; the failures are
+// returned together once all providers have run. Naming a provider that is
+// not registered fails with ErrProviderNotFound before anything is scanned.
+func (m *Manager) StreamAll(ctx context.Context, sink ResourceSink, opts StreamOptions) ([]ScanProgress, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	selected := m.providers
+	if len(opts.Providers) > 0 {
+		selected = make(map[string]types.Provider, len(opts.Providers))
+		for _, name := range opts.Providers {
+			provider, err := m.GetProvider(name)
+			if err != nil {
+				return nil, err
+			}
+			selected[name] = provider
+		}
+	}
+
+	var (
+		progress []ScanProgress
+		errs     []error
+	)
+	for name, provider := range selected {
+		p := m.streamProvider(ctx, name, provider, sink, opts)
+		progress = append(progress, p)
+		if p.Err == nil {
+			continue
+		}
+		if IsPartialScan(p.Err) {
+			log.Printf("Provider %s scan completed with errors: %v", name, p.Err)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("failed to scan provider %s: %w", name, p.Err))
+	}
+	return progress, errors.Join(errs...)
+}
+
+// streamProvider runs one provider's scan and flushes its resources to sink
+func (m *Manager) streamProvider(ctx context.Context, name string, provider types.Provider, sink ResourceSink, opts StreamOptions) ScanProgress {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	progress := ScanProgress{Provider: name, StartedAt: time.Now()}
+	report := func() {
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	out := make(chan types.Resource, opts.BatchSize)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(out)
+		scanErr <- StreamScan(ctx, provider, out)
+	}()
+
+	batch := make([]types.Resource, 0, opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := sink.UpsertResources(ctx, batch); err != nil {
+			return fmt.Errorf("failed to persist batch of %d resources: %w", len(batch), err)
+		}
+		progress.Persisted += len(batch)
+		progress.Batches++
+		batch = batch[:0]
+		report()
+		return nil
+	}
+
+	var sinkErr error
+	for resource := range out {
+		if sinkErr != nil {
+			// Drain so the scan goroutine can observe cancellation and exit
+			continue
+		}
+		now := time.Now()
+		resource.LastScannedAt = &now
+		progress.Discovered++
+		batch = append(batch, resource)
+		if len(batch) == opts.BatchSize {
+			if sinkErr = flush(); sinkErr != nil {
+				cancel()
+			}
+		}
+	}
+	if sinkErr == nil {
+		sinkErr = flush()
+	}
+
+	err := <-scanErr
+	if sinkErr != nil {
+		err = sinkErr
+	}
+	progress.Done = true
+	progress.Err = err
+	report()
+	return progress
+}
+
+// sendResources sends resources to out, stopping if ctx is cancelled
+func sendResources(ctx context.Context, out chan<- types.Resource, resources []types.Resource) error {
+	for i := range resources {
+		select {
+		case out <- resources[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// collectScan runs a streaming scan to completion and returns everything it
+// sent. It implements Scan for providers that stream natively.
+func collectScan(ctx context.Context, stream func(context.Context, chan<- types.Resource) error) ([]types.Resource, error) {
+	out := make(chan types.Resource, DefaultBatchSize)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(out)
+		scanErr <- stream(ctx, out)
+	}()
+
+	var resources []types.Resource
+	for resource := range out {
+		resources = append(resources, resource)
+	}
+
+	if err := <-scanErr; err != nil {
+		if !IsPartialScan(err) {
+			return nil, err
+		}
+		return resources, err
+	}
+	return resources, nil
+}
@@ -0,0 +1,162 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// streamingProvider streams count resources, then fails with err if set
+type streamingProvider struct {
+	name  string
+	count int
+	err   error
+}
+
+func (p *streamingProvider) Name() string                                { return p.name }
+func (p *streamingProvider) Validate() error                             { return nil }
+func (p *streamingProvider) GetResource(string) (*types.Resource, error) { return nil, nil }
+
+func (p *streamingProvider) Scan(ctx context.Context) ([]types.Resource, error) {
+	return collectScan(ctx, p.ScanStream)
+}
+
+func (p *streamingProvider) ScanStream(ctx context.Context, out chan<- types.Resource) error {
+	for i := 0; i < p.count; i++ {
+		resource := types.Resource{ID: fmt.Sprintf("%s-%d", p.name, i), Provider: p.name}
+		if err := sendResources(ctx, out, []types.Resource{resource}); err != nil {
+			return err
+		}
+	}
+	return p.err
+}
+
+// listProvider only implements the slice-returning Scan
+type listProvider struct {
+	stream streamingProvider
+}
+
+func (p *listProvider) Name() string                                { return p.stream.name }
+func (p *listProvider) Validate() error                             { return nil }
+func (p *listProvider) GetResource(string) (*types.Resource, error) { return nil, nil }
+
+func (p *listProvider) Scan(ctx context.Context) ([]types.Resource, error) {
+	return collectScan(ctx, p.stream.ScanStream)
+}
+
+// recordingSink stores upserted batches and fails after failAfter batches
+type recordingSink struct {
+	batches   [][]types.Resource
+	failAfter int
+}
+
+func (s *recordingSink) UpsertResources(_ context.Context, resources []types.Resource) error {
+	if s.failAfter > 0 && len(s.batches) == s.failAfter {
+		return errors.New("database unavailable")
+	}
+	s.batches = append(s.batches, append([]types.Resource(nil), resources...))
+	return nil
+}
+
+func (s *recordingSink) total() int {
+	n := 0
+	for _, batch := range s.batches {
+		n += len(batch)
+	}
+	return n
+}
+
+func TestManager_StreamAll(t *testing.T) {
+	manager := NewManager(&config.ProvidersConfig{})
+	manager.RegisterProvider("stream", &streamingProvider{name: "stream", count: 25})
+	manager.RegisterProvider("list", &listProvider{stream: streamingProvider{name: "list", count: 7}})
+
+	sink := &recordingSink{}
+	var reports []ScanProgress
+	progress, err := manager.StreamAll(t.Context(), sink, StreamOptions{
+		BatchSize: 10,
+		Progress:  func(p ScanProgress) { reports = append(reports, p) },
+	})
+	if err != nil {
+		t.Fatalf("StreamAll failed: %v", err)
+	}
+
+	if sink.total() != 32 {
+		t.Errorf("Expected 32 persisted resources, got %d", sink.total())
+	}
+	for _, batch := range sink.batches {
+		if len(batch) > 10 {
+			t.Errorf("Batch of %d exceeds the batch size", len(batch))
+		}
+		if batch[0].LastScannedAt == nil {
+			t.Error("Expected LastScannedAt to be set")
+		}
+	}
+
+	// 3 + 1 flushes plus one final report per provider
+	if len(reports) != 6 {
+		t.Errorf("Expected 6 progress reports, got %d", len(reports))
+	}
+	for _, p := range progress {
+		if !p.Done || p.Discovered != p.Persisted {
+			t.Errorf("Unexpected final progress: %+v", p)
+		}
+	}
+}
+
+func TestManager_StreamAllKeepsBatchesBeforeFailure(t *testing.T) {
+	manager := NewManager(&config.ProvidersConfig{})
+	manager.RegisterProvider("stream", &streamingProvider{name: "stream", count: 25, err: errors.New("throttled")})
+
+	sink := &recordingSink{}
+	progress, err := manager.StreamAll(t.Context(), sink, StreamOptions{BatchSize: 10})
+	if err == nil {
+		t.Fatal("Expected the late failure to be reported")
+	}
+	if sink.total() != 25 {
+		t.Errorf("Expected resources sent before the failure to be persisted, got %d", sink.total())
+	}
+	if progress[0].Persisted != 25 || progress[0].Err == nil {
+		t.Errorf("Unexpected progress: %+v", progress[0])
+	}
+}
+
+func TestManager_StreamAllStopsOnSinkError(t *testing.T) {
+	manager := NewManager(&config.ProvidersConfig{})
+	manager.RegisterProvider("stream", &streamingProvider{name: "stream", count: 1000})
+
+	sink := &recordingSink{failAfter: 2}
+	progress, err := manager.StreamAll(t.Context(), sink, StreamOptions{BatchSize: 10})
+	if err == nil {
+		t.Fatal("Expected the sink error to be reported")
+	}
+	if progress[0].Persisted != 20 || progress[0].Discovered >= 1000 {
+		t.Errorf("Expected the scan to stop after the failed batch, got %+v", progress[0])
+	}
+}
+
+func TestManager_StreamAllSelectedProviders(t *testing.T) {
+	manager := NewManager(&config.ProvidersConfig{})
+	manager.RegisterProvider("stream", &streamingProvider{name: "stream", count: 5})
+	manager.RegisterProvider("list", &listProvider{stream: streamingProvider{name: "list", count: 7}})
+
+	sink := &recordingSink{}
+	progress, err := manager.StreamAll(t.Context(), sink, StreamOptions{Providers: []string{"list"}})
+	if err != nil {
+		t.Fatalf("StreamAll failed: %v", err)
+	}
+	if len(progress) != 1 || progress[0].Provider != "list" || sink.total() != 7 {
+		t.Errorf("Expected only the list provider to be scanned, got %+v", progress)
+	}
+
+	if _, err := manager.StreamAll(t.Context(), sink, StreamOptions{Providers: []string{"missing"}}); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("Expected ErrProviderNotFound, got %v", err)
+	}
+	if sink.total() != 7 {
+		t.Error("Expected nothing to be scanned when a provider is unknown")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/lib/pq"
+
 	"github.com/LederWorks/siros/backend/internal/models"
 )
 
@@ -42,6 +44,45 @@ func (r *blockchainRepository) CreateRecord(ctx context.Context, record *models.
 	return nil
 }
 
+// CreateRecords inserts change records in a single transaction
+func (r *blockchainRepository) CreateRecords(ctx context.Context, records []models.ChangeRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertRecords(ctx, tx, records)
+	})
+}
+
+// insertRecords inserts change records within tx
+func insertRecords(ctx context.Context, tx *sql.Tx, records []models.ChangeRecord) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO change_records (id, resource_id, operation, changes, timestamp, actor, previous_hash, data_hash, signature)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare change record insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range records {
+		record := &records[i]
+		changesJSON, err := json.Marshal(record.Changes)
+		if err != nil {
+			return fmt.Errorf("failed to marshal changes: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx,
+			record.ID, record.ResourceID, record.Operation, changesJSON,
+			record.Timestamp, record.Actor, record.PreviousHash, record.DataHash, record.Signature,
+		); err != nil {
+			return fmt.Errorf("failed to insert change record for %s: %w", record.ResourceID, err)
+		}
+	}
+
+	return nil
+}
+
 func (r *blockchainRepository) GetRecordsByResourceID(ctx context.Context, resourceID string) ([]models.ChangeRecord, error) {
 	query := `
 		SELECT id, resource_id, operation, changes, timestamp, actor, previous_hash, data_hash, signature
@@ -56,6 +97,60 @@ func (r *blockchainRepository) GetRecordsByResourceID(ctx context.Context, resou
 	}
 	defer rows.Close()
 
+	return scanRecords(rows)
+}
+
+// ListRecords returns a page of change records matching the query, newest
+// first
+func (r *blockchainRepository) ListRecords(ctx context.Context, query *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error) {
+	keys, err := newKeyset(models.ChangeOrder, []sortKey{{sql: "timestamp", desc: true}}, "id", query.Cursor, query.Limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, filter := range []struct{ column, value string }{
+		{"resource_id", query.ResourceID},
+		{"actor", query.Actor},
+		{"operation", query.Operation},
+	} {
+		if filter.value != "" {
+			args = append(args, filter.value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+		}
+	}
+	if query.Since != nil {
+		args = append(args, *query.Since)
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+
+	sqlQuery, pageArgs := keys.query(`
+		SELECT id, resource_id, operation, changes, timestamp, actor, previous_hash, data_hash, signature
+		FROM change_records
+	`, conditions, args)
+	rows, err := r.db.QueryContext(ctx, sqlQuery, pageArgs...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query change records: %w", err)
+	}
+	defer rows.Close()
+
+	records, err := scanRecords(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, info := trimPage(keys, records, 0, func(record *models.ChangeRecord) ([]string, string) {
+		return []string{timeKey(record.Timestamp)}, record.ID
+	})
+
+	if err := countRows(ctx, r.db, info, query.Total, "change_records", conditions, args); err != nil {
+		return nil, nil, err
+	}
+	return records, info, nil
+}
+
+// scanRecords reads change records from rows
+func scanRecords(rows *sql.Rows) ([]models.ChangeRecord, error) {
 	var records []models.ChangeRecord
 	for rows.Next() {
 		var record models.ChangeRecord
@@ -78,7 +173,7 @@ func (r *blockchainRepository) GetRecordsByResourceID(ctx context.Context, resou
 		records = append(records, record)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating change records: %w", err)
 	}
 
@@ -106,7 +201,7 @@ func (r *blockchainRepository) GetLatestRecord(ctx context.Context, resourceID s
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("no change records found for resource: %s", resourceID)
+			return nil, fmt.Errorf("change records %w for resource: %s", ErrNotFound, resourceID)
 		}
 		return nil, fmt.Errorf("failed to scan latest change record: %w", err)
 	}
@@ -119,3 +214,52 @@ func (r *blockchainRepository) GetLatestRecord(ctx context.Context, resourceID s
 
 	return &record, nil
 }
+
+// GetLatestRecords returns the latest change record of each resource that
+// has one, keyed by resource ID
+func (r *blockchainRepository) GetLatestRecords(ctx context.Context, resourceIDs []string) (map[string]models.ChangeRecord, error) {
+	latest := make(map[string]models.ChangeRecord)
+	if len(resourceIDs) == 0 {
+		return latest, nil
+	}
+
+	query := `
+		SELECT DISTINCT ON (resource_id)
+		       id, resource_id, operation, changes, timestamp, actor, previous_hash, data_hash, signature
+		FROM change_records
+		WHERE resource_id = ANY($1)
+		ORDER BY resource_id, timestamp DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(resourceIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest change records: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record models.ChangeRecord
+		var changesJSON []byte
+
+		if err := rows.Scan(
+			&record.ID, &record.ResourceID, &record.Operation, &changesJSON,
+			&record.Timestamp, &record.Actor, &record.PreviousHash, &record.DataHash, &record.Signature,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan change record: %w", err)
+		}
+
+		if len(changesJSON) > 0 {
+			if err := json.Unmarshal(changesJSON, &record.Changes); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal changes: %w", err)
+			}
+		}
+
+		latest[record.ResourceID] = record
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating change records: %w", err)
+	}
+
+	return latest, nil
+}
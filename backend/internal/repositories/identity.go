@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// identityRepository implements IdentityRepository
+type identityRepository struct {
+	db *sql.DB
+}
+
+// NewIdentityRepository creates a new identity repository
+func NewIdentityRepository(db *sql.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) FindAliases(ctx context.Context, aliases []string) ([]models.ResourceAlias, error) {
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT provider, account, region, alias, resource_id, source, created_at
+		FROM resource_aliases
+		WHERE alias = ANY($1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(aliases))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var found []models.ResourceAlias
+	for rows.Next() {
+		var alias models.ResourceAlias
+		if err := rows.Scan(&alias.Provider, &alias.Account, &alias.Region, &alias.Alias, &alias.ResourceID, &alias.Source, &alias.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		found = append(found, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating aliases: %w", err)
+	}
+
+	return found, nil
+}
+
+// AddAliases inserts aliases in a single transaction. Aliases that already
+// exist keep the resource they name.
+func (r *identityRepository) AddAliases(ctx context.Context, aliases []models.ResourceAlias) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return insertAliases(ctx, tx, aliases)
+	})
+}
+
+// insertAliases inserts aliases within tx, keeping existing ones unchanged
+func insertAliases(ctx context.Context, tx *sql.Tx, aliases []models.ResourceAlias) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO resource_aliases (provider, account, region, alias, resource_id, source, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (provider, account, region, alias) DO NOTHING
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare alias insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range aliases {
+		alias := &aliases[i]
+		if _, err := stmt.ExecContext(ctx,
+			alias.Provider, alias.Account, alias.Region, alias.Alias, alias.ResourceID, alias.Source, alias.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to insert alias %s: %w", alias.Alias, err)
+		}
+	}
+
+	return nil
+}
+
+// Merge replaces the resource fromID by into in a single transaction. into
+// is upserted, children and aliases of fromID are moved to it, fromID is
+// deleted and kept as an alias of into. The hashed change records of fromID
+// are never rewritten: they stay under fromID, which the alias resolves.
+func (r *identityRepository) Merge(ctx context.Context, fromID string, into *models.Resource) error {
+	dataJSON, err := json.Marshal(into.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+	metadataJSON, err := json.Marshal(into.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	parentID := into.ParentID
+	if parentID != nil && *parentID == fromID {
+		parentID = nil
+	}
+
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`
+			INSERT INTO resources (id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (id) DO UPDATE
+			SET type = EXCLUDED.type, provider = EXCLUDED.provider, name = EXCLUDED.name,
+			    data = EXCLUDED.data, metadata = EXCLUDED.metadata,
+			    vector = COALESCE(EXCLUDED.vector, resources.vector),
+			    parent_id = EXCLUDED.parent_id,
+			    created_at = LEAST(EXCLUDED.created_at, resources.created_at),
+			    modified_at = EXCLUDED.modified_at
+		`, []interface{}{
+			into.ID, into.Type, into.Provider, into.Name, dataJSON, metadataJSON,
+			pq.Array(into.Vector), parentID, into.CreatedAt, into.ModifiedAt,
+		}},
+		{`UPDATE resources SET parent_id = $2 WHERE parent_id = $1`, []interface{}{fromID, into.ID}},
+		{`UPDATE resource_aliases SET resource_id = $2 WHERE resource_id = $1`, []interface{}{fromID, into.ID}},
+		{`DELETE FROM resources WHERE id = $1`, []interface{}{fromID}},
+		{`
+			INSERT INTO resource_aliases (provider, account, region, alias, resource_id, source, created_at)
+			VALUES ($1, '', '', $2, $3, 'merge', NOW())
+			ON CONFLICT (provider, account, region, alias) DO UPDATE SET resource_id = EXCLUDED.resource_id
+		`, []interface{}{into.Provider, fromID, into.ID}},
+	}
+	for _, step := range steps {
+		if _, err := tx.ExecContext(ctx, step.query, step.args...); err != nil {
+			return fmt.Errorf("failed to merge resource %s into %s: %w", fromID, into.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit merge: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// importRepository implements ImportRepository
+type importRepository struct {
+	db *sql.DB
+}
+
+// NewImportRepository creates a new import repository
+func NewImportRepository(db *sql.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+// WriteBatch upserts resources, adds their aliases and inserts their change
+// records in a single transaction, so that a batch is stored with its
+// aliases and history or not at all
+func (r *importRepository) WriteBatch(ctx context.Context, resources []models.Resource, aliases []models.ResourceAlias, records []models.ChangeRecord) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if len(resources) > 0 {
+			if err := upsertResources(ctx, tx, resources); err != nil {
+				return err
+			}
+		}
+		if len(aliases) > 0 {
+			if err := insertAliases(ctx, tx, aliases); err != nil {
+				return err
+			}
+		}
+		if len(records) > 0 {
+			return insertRecords(ctx, tx, records)
+		}
+		return nil
+	})
+}
+
+// inTx runs fn in a new transaction, committing when it succeeds
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ func Migrate(db *sql.DB) error {
 
 		// Drop old tables if they exist (development only)
 		// TODO: Remove this in production and use proper migrations
+		`DROP TABLE IF EXISTS resource_aliases CASCADE`,
 		`DROP TABLE IF EXISTS change_records CASCADE`,
 		`DROP TABLE IF EXISTS resources CASCADE`,
 		`DROP TABLE IF EXISTS schemas CASCADE`,
@@ -32,6 +33,30 @@ func Migrate(db *sql.DB) error {
 			modified_at TIMESTAMP WITH TIME ZONE NOT NULL
 		)`,
 
+		// Create resource_aliases table mapping natural identifiers to Siros IDs
+		`CREATE TABLE IF NOT EXISTS resource_aliases (
+			provider VARCHAR(50) NOT NULL,
+			account VARCHAR(255) NOT NULL DEFAULT '',
+			region VARCHAR(100) NOT NULL DEFAULT '',
+			alias VARCHAR(1024) NOT NULL,
+			resource_id VARCHAR(255) NOT NULL REFERENCES resources(id) ON DELETE CASCADE,
+			source VARCHAR(50) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (provider, account, region, alias)
+		)`,
+
+		// Create saved_searches table holding named filter expressions
+		`CREATE TABLE IF NOT EXISTS saved_searches (
+			id VARCHAR(255) PRIMARY KEY,
+			name VARCHAR(255) NOT NULL UNIQUE,
+			description TEXT,
+			filter TEXT NOT NULL,
+			created_by VARCHAR(255) NOT NULL,
+			modified_by VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			modified_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		)`,
+
 		// Create schemas table
 		`CREATE TABLE IF NOT EXISTS schemas (
 			name VARCHAR(255) NOT NULL,
@@ -39,9 +64,14 @@ func Migrate(db *sql.DB) error {
 			type VARCHAR(100) NOT NULL,
 			version VARCHAR(50) NOT NULL,
 			schema JSONB NOT NULL,
+			mode VARCHAR(20) NOT NULL DEFAULT 'enforce',
+			compatibility VARCHAR(20),
+			transform JSONB,
 			description TEXT,
+			is_custom BOOLEAN NOT NULL DEFAULT FALSE,
 			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-			PRIMARY KEY (name, provider)
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (name, provider, version)
 		)`,
 
 		// Create change_records table for blockchain
@@ -57,6 +87,25 @@ func Migrate(db *sql.DB) error {
 			signature VARCHAR(255)
 		)`,
 
+		// Create change_proposals table holding changes awaiting approval
+		`CREATE TABLE IF NOT EXISTS change_proposals (
+			id VARCHAR(255) PRIMARY KEY,
+			operation VARCHAR(20) NOT NULL,
+			resource_id VARCHAR(255) NOT NULL DEFAULT '',
+			request JSONB NOT NULL,
+			diff JSONB NOT NULL,
+			warnings TEXT[],
+			reason TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			proposed_by VARCHAR(255) NOT NULL,
+			reviewed_by VARCHAR(255),
+			review_note TEXT,
+			error TEXT,
+			base_modified_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			reviewed_at TIMESTAMP WITH TIME ZONE
+		)`,
+
 		// Create terraform_keys table
 		`CREATE TABLE IF NOT EXISTS terraform_keys (
 			key VARCHAR(255) PRIMARY KEY,
@@ -76,16 +125,27 @@ func Migrate(db *sql.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_resources_metadata ON resources USING GIN(metadata)`,
 		`CREATE INDEX IF NOT EXISTS idx_resources_vector ON resources USING ivfflat (vector vector_cosine_ops) WITH (lists = 100)`,
 
+		`CREATE INDEX IF NOT EXISTS idx_resource_aliases_alias ON resource_aliases(alias)`,
+		`CREATE INDEX IF NOT EXISTS idx_resource_aliases_resource ON resource_aliases(resource_id)`,
+
 		`CREATE INDEX IF NOT EXISTS idx_change_records_resource ON change_records(resource_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_change_records_timestamp ON change_records(timestamp)`,
 		`CREATE INDEX IF NOT EXISTS idx_change_records_operation ON change_records(operation)`,
 
+		`CREATE INDEX IF NOT EXISTS idx_change_proposals_status ON change_proposals(status, created_at)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_schemas_type ON schemas(provider, type)`,
+
 		`CREATE INDEX IF NOT EXISTS idx_terraform_keys_path ON terraform_keys(path)`,
 		`CREATE INDEX IF NOT EXISTS idx_terraform_keys_created ON terraform_keys(created_at)`,
 	}
 
 	for _, query := range queries {
-		log.Printf("Executing migration: %s", query[:50]+"...")
+		summary := query
+		if len(summary) > 50 {
+			summary = summary[:50] + "..."
+		}
+		log.Printf("Executing migration: %s", summary)
 		if _, err := db.Exec(query); err != nil {
 			log.Printf("Migration query failed: %s", query)
 			return fmt.Errorf("failed to execute migration: %w", err)
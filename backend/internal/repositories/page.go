@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// keyset pages through rows ordered by one or more sort keys with a unique
+// column breaking ties, so that each page starts where the previous one
+// ended rather than skipping an offset
+type keyset struct {
+	order  string    // ordering cursors are issued for, as in "created_at:desc"
+	keys   []sortKey // sort keys, in order
+	tie    string    // unique column breaking ties, in the first key's direction
+	cursor *models.Cursor
+	limit  int
+}
+
+// sortKey is a vetted SQL expression ordered by a keyset
+type sortKey struct {
+	sql  string
+	desc bool
+}
+
+// newKeyset decodes the cursor of a page request for order
+func newKeyset(order string, keys []sortKey, tie string, cursor string, limit int) (*keyset, error) {
+	k := &keyset{order: order, keys: keys, tie: tie, limit: models.PageSize(limit)}
+	if cursor != "" {
+		decoded, err := models.DecodeCursor(cursor, order)
+		if err != nil {
+			return nil, err
+		}
+		if len(decoded.Values) != len(keys) {
+			return nil, fmt.Errorf("%w: expected %d sort values", models.ErrInvalidCursor, len(keys))
+		}
+		k.cursor = decoded
+	}
+	return k, nil
+}
+
+// backward reports whether rows are read toward the start of the listing
+func (k *keyset) backward() bool {
+	return k.cursor != nil && k.cursor.Before
+}
+
+// columns returns the sort keys followed by the tie-breaker, each with
+// whether it is read in ascending order
+func (k *keyset) columns() ([]string, []bool) {
+	sqls := make([]string, 0, len(k.keys)+1)
+	ascending := make([]bool, 0, len(k.keys)+1)
+	for _, key := range k.keys {
+		sqls = append(sqls, key.sql)
+		ascending = append(ascending, key.desc == k.backward())
+	}
+	return append(sqls, k.tie), append(ascending, k.keys[0].desc == k.backward())
+}
+
+// query completes a SELECT of the rows matching conditions with the
+// keyset condition, ORDER BY and LIMIT clauses. One row more than a page is
+// fetched to tell whether another page follows.
+func (k *keyset) query(selectFrom string, conditions []string, args []interface{}) (string, []interface{}) {
+	columns, ascending := k.columns()
+	args = slices.Clone(args)
+	if k.cursor != nil {
+		placeholders := make([]string, len(columns))
+		for i, value := range append(slices.Clone(k.cursor.Values), k.cursor.ID) {
+			args = append(args, value)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(slices.Clone(conditions), after(columns, ascending, placeholders))
+	}
+
+	query := selectFrom
+	if len(conditions) > 0 {
+		// #nosec G202 -- conditions only reference placeholders
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	order := make([]string, len(columns))
+	for i, column := range columns {
+		dir := "DESC"
+		if ascending[i] {
+			dir = "ASC"
+		}
+		order[i] = column + " " + dir
+	}
+	args = append(args, k.limit+1)
+	return query + fmt.Sprintf(" ORDER BY %s LIMIT $%d", strings.Join(order, ", "), len(args)), args
+}
+
+// after returns the condition selecting rows that come after the cursor
+// values in placeholders. Keys sorted in one direction compare as a row,
+// which indexes serve; mixed directions expand to comparisons key by key.
+func after(columns []string, ascending []bool, placeholders []string) string {
+	op := func(asc bool) string {
+		if asc {
+			return ">"
+		}
+		return "<"
+	}
+	if !slices.Contains(ascending, !ascending[0]) {
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op(ascending[0]), strings.Join(placeholders, ", "))
+	}
+
+	last := len(columns) - 1
+	condition := fmt.Sprintf("%s %s %s", columns[last], op(ascending[last]), placeholders[last])
+	for i := last - 1; i >= 0; i-- {
+		condition = fmt.Sprintf("(%s %s %s OR (%s = %s AND %s))",
+			columns[i], op(ascending[i]), placeholders[i], columns[i], placeholders[i], condition)
+	}
+	return condition
+}
+
+// trimPage trims the fetched rows to a page in listing order and returns the
+// cursors around it. key returns the sort values and tie of a row; offset is
+// the position of the first row when paging by offset instead.
+func trimPage[T any](k *keyset, rows []T, offset int, key func(*T) ([]string, string)) ([]T, *models.PageInfo) {
+	more := len(rows) > k.limit
+	if more {
+		rows = rows[:k.limit]
+	}
+	if k.backward() {
+		slices.Reverse(rows)
+	}
+
+	info := &models.PageInfo{}
+	if len(rows) == 0 {
+		return rows, info
+	}
+	cursor := func(row *T, before bool) string {
+		values, id := key(row)
+		return models.Cursor{Order: k.order, Values: values, ID: id, Before: before}.Encode()
+	}
+	if more || k.backward() {
+		info.NextCursor = cursor(&rows[len(rows)-1], false)
+	}
+	if (k.backward() && more) || (!k.backward() && (k.cursor != nil || offset > 0)) {
+		info.PrevCursor = cursor(&rows[0], true)
+	}
+	return rows, info
+}
+
+// timeKey formats a timestamp sort key so that it round-trips through a
+// cursor without losing precision
+func timeKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// countRows sets the total of info to the number of rows of from matching
+// conditions: counted exactly, or estimated by the query planner, which
+// avoids scanning large tables
+func countRows(ctx context.Context, db *sql.DB, info *models.PageInfo, mode, from string, conditions []string, args []interface{}) error {
+	if mode == "" {
+		return nil
+	}
+
+	query := "SELECT 1 FROM " + from
+	if len(conditions) > 0 {
+		// #nosec G202 -- conditions only reference placeholders
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if mode == models.TotalEstimate {
+		var plan []byte
+		if err := db.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+query, args...).Scan(&plan); err != nil {
+			return fmt.Errorf("failed to estimate total: %w", err)
+		}
+		var explained []struct {
+			Plan struct {
+				Rows float64 `json:"Plan Rows"`
+			} `json:"Plan"`
+		}
+		if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+			return fmt.Errorf("failed to estimate total: unexpected plan %s", plan)
+		}
+		total := int64(explained[0].Plan.Rows)
+		info.Total, info.TotalEstimated = &total, true
+		return nil
+	}
+
+	var total int64
+	// #nosec G202 -- query only references placeholders
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+query+") AS matching", args...).Scan(&total); err != nil {
+		return fmt.Errorf("failed to count total: %w", err)
+	}
+	info.Total = &total
+	return nil
+}
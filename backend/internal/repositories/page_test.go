@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+func TestKeyset_Query(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []sortKey
+		cursor *models.Cursor
+		want   string
+		args   []interface{}
+	}{
+		{
+			name: "first page",
+			keys: []sortKey{{sql: "provider"}, {sql: "modified_at", desc: true}},
+			want: "SELECT * FROM resources WHERE type = $1 ORDER BY provider ASC, modified_at DESC, id ASC LIMIT $2",
+			args: []interface{}{"vm", 11},
+		},
+		{
+			name:   "one direction",
+			keys:   []sortKey{{sql: "created_at", desc: true}},
+			cursor: &models.Cursor{Values: []string{"t"}, ID: "r1"},
+			want:   "SELECT * FROM resources WHERE type = $1 AND (created_at, id) < ($2, $3) ORDER BY created_at DESC, id DESC LIMIT $4",
+			args:   []interface{}{"vm", "t", "r1", 11},
+		},
+		{
+			name:   "mixed directions",
+			keys:   []sortKey{{sql: "provider"}, {sql: "modified_at", desc: true}},
+			cursor: &models.Cursor{Values: []string{"aws", "t"}, ID: "r1"},
+			want: "SELECT * FROM resources WHERE type = $1 AND (provider > $2 OR (provider = $2 AND (modified_at < $3 OR (modified_at = $3 AND id > $4)))) " +
+				"ORDER BY provider ASC, modified_at DESC, id ASC LIMIT $5",
+			args: []interface{}{"vm", "aws", "t", "r1", 11},
+		},
+		{
+			name:   "backward",
+			keys:   []sortKey{{sql: "provider"}, {sql: "modified_at", desc: true}},
+			cursor: &models.Cursor{Values: []string{"aws", "t"}, ID: "r1", Before: true},
+			want: "SELECT * FROM resources WHERE type = $1 AND (provider < $2 OR (provider = $2 AND (modified_at > $3 OR (modified_at = $3 AND id < $4)))) " +
+				"ORDER BY provider DESC, modified_at ASC, id DESC LIMIT $5",
+			args: []interface{}{"vm", "aws", "t", "r1", 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := ""
+			if tt.cursor != nil {
+				tt.cursor.Order = "test"
+				cursor = tt.cursor.Encode()
+			}
+			k, err := newKeyset("test", tt.keys, "id", cursor, 10)
+			if err != nil {
+				t.Fatalf("newKeyset() error = %v", err)
+			}
+			query, args := k.query("SELECT * FROM resources", []string{"type = $1"}, []interface{}{"vm"})
+			if query != tt.want {
+				t.Errorf("query() = %s\nwant %s", query, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("query() args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestKeyset_CursorMismatch(t *testing.T) {
+	cursor := models.Cursor{Order: "test", Values: []string{"aws"}, ID: "r1"}.Encode()
+	_, err := newKeyset("test", []sortKey{{sql: "provider"}, {sql: "name"}}, "id", cursor, 10)
+	if !errors.Is(err, models.ErrInvalidCursor) {
+		t.Errorf("newKeyset() error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	key := func(id *string) ([]string, string) { return []string{*id}, *id }
+
+	k, _ := newKeyset("test", []sortKey{{sql: "id"}}, "id", "", 2)
+	page, info := trimPage(k, []string{"a", "b", "c"}, 0, key)
+	if !reflect.DeepEqual(page, []string{"a", "b"}) || info.NextCursor == "" || info.PrevCursor != "" {
+		t.Fatalf("first page = %v, %+v", page, info)
+	}
+
+	back := models.Cursor{Order: "test", Values: []string{"c"}, ID: "c", Before: true}.Encode()
+	k, _ = newKeyset("test", []sortKey{{sql: "id"}}, "id", back, 2)
+	page, info = trimPage(k, []string{"b", "a"}, 0, key)
+	if !reflect.DeepEqual(page, []string{"a", "b"}) || info.NextCursor == "" || info.PrevCursor != "" {
+		t.Errorf("page before c = %v, %+v", page, info)
+	}
+}
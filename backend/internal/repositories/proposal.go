@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// proposalColumns are the columns scanProposal reads, in order
+const proposalColumns = `id, operation, resource_id, request, diff, warnings, reason, status,
+	proposed_by, reviewed_by, review_note, error, base_modified_at, created_at, reviewed_at`
+
+// proposalRepository implements ProposalRepository
+type proposalRepository struct {
+	db *sql.DB
+}
+
+// NewProposalRepository creates a new change proposal repository
+func NewProposalRepository(db *sql.DB) ProposalRepository {
+	return &proposalRepository{db: db}
+}
+
+func (r *proposalRepository) Create(ctx context.Context, proposal *models.ChangeProposal) error {
+	requestJSON, err := json.Marshal(proposal.Request())
+	if err != nil {
+		return fmt.Errorf("failed to marshal proposal request: %w", err)
+	}
+	diffJSON, err := json.Marshal(proposal.Diff)
+	if err != nil {
+		return fmt.Errorf("failed to marshal proposal diff: %w", err)
+	}
+
+	query := `
+		INSERT INTO change_proposals (id, operation, resource_id, request, diff, warnings, reason, status,
+			proposed_by, base_modified_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		proposal.ID, proposal.Operation, proposal.ResourceID, requestJSON, diffJSON, pq.Array(proposal.Warnings),
+		proposal.Reason, proposal.Status, proposal.ProposedBy, proposal.BaseModifiedAt, proposal.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert change proposal: %w", err)
+	}
+	return nil
+}
+
+func (r *proposalRepository) GetByID(ctx context.Context, id string) (*models.ChangeProposal, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+proposalColumns+` FROM change_proposals WHERE id = $1`, id)
+	proposal, err := scanProposal(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("change proposal %w: %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// UpdateStatus writes the review and outcome of a proposal whose status is
+// still from, so that a proposal is only ever applied once
+func (r *proposalRepository) UpdateStatus(ctx context.Context, proposal *models.ChangeProposal, from string) error {
+	query := `
+		UPDATE change_proposals
+		SET status = $2, resource_id = $3, reviewed_by = $4, review_note = $5, error = $6, reviewed_at = $7
+		WHERE id = $1 AND status = $8
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		proposal.ID, proposal.Status, proposal.ResourceID, proposal.ReviewedBy,
+		proposal.ReviewNote, proposal.Error, proposal.ReviewedAt, from,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update change proposal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s change proposal %w: %s", from, ErrNotFound, proposal.ID)
+	}
+
+	return nil
+}
+
+// List returns the proposals with a status, or all when it is empty,
+// newest first
+func (r *proposalRepository) List(ctx context.Context, status string) ([]models.ChangeProposal, error) {
+	query := `SELECT ` + proposalColumns + ` FROM change_proposals
+		WHERE $1 = '' OR status = $1 ORDER BY created_at DESC, id`
+
+	rows, err := r.db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change proposals: %w", err)
+	}
+	defer rows.Close()
+
+	var proposals []models.ChangeProposal
+	for rows.Next() {
+		proposal, err := scanProposal(rows)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating change proposals: %w", err)
+	}
+
+	return proposals, nil
+}
+
+// scanProposal scans a row of proposalColumns. sql.ErrNoRows is returned
+// unwrapped so callers can report the proposal they looked for.
+func scanProposal(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.ChangeProposal, error) {
+	var proposal models.ChangeProposal
+	var requestJSON, diffJSON []byte
+	var reviewedBy, reviewNote, errorText sql.NullString
+	err := row.Scan(
+		&proposal.ID, &proposal.Operation, &proposal.ResourceID, &requestJSON, &diffJSON,
+		pq.Array(&proposal.Warnings), &proposal.Reason, &proposal.Status, &proposal.ProposedBy,
+		&reviewedBy, &reviewNote, &errorText, &proposal.BaseModifiedAt, &proposal.CreatedAt, &proposal.ReviewedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan change proposal: %w", err)
+	}
+	proposal.ReviewedBy, proposal.ReviewNote, proposal.Error = reviewedBy.String, reviewNote.String, errorText.String
+
+	if proposal.Operation == "CREATE" {
+		err = json.Unmarshal(requestJSON, &proposal.Create)
+	} else {
+		err = json.Unmarshal(requestJSON, &proposal.Update)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal proposal request: %w", err)
+	}
+	if err := json.Unmarshal(diffJSON, &proposal.Diff); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal proposal diff: %w", err)
+	}
+
+	return &proposal, nil
+}
@@ -3,45 +3,95 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log"
 
 	"github.com/LederWorks/siros/backend/internal/models"
 )
 
+// ErrNotFound is wrapped by errors for rows that do not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrAlreadyExists is wrapped by errors for rows whose unique key is taken
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrAmbiguous is wrapped by errors for lookups that match several rows
+var ErrAmbiguous = errors.New("ambiguous")
+
 // Repositories holds all repository instances
 type Repositories struct {
 	Resource   ResourceRepository
 	Schema     SchemaRepository
 	Blockchain BlockchainRepository
+	Identity   IdentityRepository
+	Search     SavedSearchRepository
+	Proposal   ProposalRepository
+	Import     ImportRepository
 }
 
 // ResourceRepository defines the interface for resource data access
 type ResourceRepository interface {
 	Create(ctx context.Context, resource *models.Resource) error
 	GetByID(ctx context.Context, id string) (*models.Resource, error)
+	GetByIDs(ctx context.Context, ids []string) ([]models.Resource, error)
 	Update(ctx context.Context, resource *models.Resource) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error)
-	Search(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error)
+	List(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
+	Stream(ctx context.Context, query *models.SearchQuery, fn func(*models.Resource) error) error
+	Search(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
 	GetByParentID(ctx context.Context, parentID string) ([]models.Resource, error)
 	VectorSearch(ctx context.Context, vector []float32, threshold float32, limit int) ([]models.Resource, error)
+	UpsertBatch(ctx context.Context, resources []models.Resource) error
 }
 
 // SchemaRepository defines the interface for schema data access
 type SchemaRepository interface {
 	Create(ctx context.Context, schema *models.Schema) error
-	GetByID(ctx context.Context, id string) (*models.Schema, error)
+	GetByName(ctx context.Context, name, provider string) (*models.Schema, error)
 	Update(ctx context.Context, schema *models.Schema) error
-	Delete(ctx context.Context, id string) error
-	List(ctx context.Context) ([]models.Schema, error)
-	GetByName(ctx context.Context, name string) (*models.Schema, error)
+	Delete(ctx context.Context, name, provider string) error
+	List(ctx context.Context, query *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error)
+	GetForType(ctx context.Context, provider, resourceType, version string) (*models.Schema, error)
+	ListVersions(ctx context.Context, name, provider string) ([]models.Schema, error)
 }
 
 // BlockchainRepository defines the interface for blockchain audit data access
 type BlockchainRepository interface {
 	CreateRecord(ctx context.Context, record *models.ChangeRecord) error
+	CreateRecords(ctx context.Context, records []models.ChangeRecord) error
 	GetRecordsByResourceID(ctx context.Context, resourceID string) ([]models.ChangeRecord, error)
+	ListRecords(ctx context.Context, query *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error)
 	GetLatestRecord(ctx context.Context, resourceID string) (*models.ChangeRecord, error)
+	GetLatestRecords(ctx context.Context, resourceIDs []string) (map[string]models.ChangeRecord, error)
+}
+
+// IdentityRepository defines the interface for resource alias data access
+type IdentityRepository interface {
+	FindAliases(ctx context.Context, aliases []string) ([]models.ResourceAlias, error)
+	AddAliases(ctx context.Context, aliases []models.ResourceAlias) error
+	Merge(ctx context.Context, fromID string, into *models.Resource) error
+}
+
+// ImportRepository defines the interface for writing bulk import batches
+type ImportRepository interface {
+	WriteBatch(ctx context.Context, resources []models.Resource, aliases []models.ResourceAlias, records []models.ChangeRecord) error
+}
+
+// SavedSearchRepository defines the interface for saved search data access
+type SavedSearchRepository interface {
+	Create(ctx context.Context, search *models.SavedSearch) error
+	GetByID(ctx context.Context, id string) (*models.SavedSearch, error)
+	Update(ctx context.Context, search *models.SavedSearch) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]models.SavedSearch, error)
+}
+
+// ProposalRepository defines the interface for change proposal data access
+type ProposalRepository interface {
+	Create(ctx context.Context, proposal *models.ChangeProposal) error
+	GetByID(ctx context.Context, id string) (*models.ChangeProposal, error)
+	UpdateStatus(ctx context.Context, proposal *models.ChangeProposal, from string) error
+	List(ctx context.Context, status string) ([]models.ChangeProposal, error)
 }
 
 // NewRepositories creates a new Repositories instance with all repositories
@@ -50,5 +100,9 @@ func NewRepositories(db *sql.DB, _ *log.Logger) *Repositories {
 		Resource:   NewResourceRepository(db),
 		Schema:     NewSchemaRepository(db),
 		Blockchain: NewBlockchainRepository(db),
+		Identity:   NewIdentityRepository(db),
+		Search:     NewSavedSearchRepository(db),
+		Proposal:   NewProposalRepository(db),
+		Import:     NewImportRepository(db),
 	}
 }
@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 
+	"github.com/LederWorks/siros/backend/internal/filter"
 	"github.com/LederWorks/siros/backend/internal/models"
 )
 
@@ -52,30 +54,59 @@ func (r *resourceRepository) Create(ctx context.Context, resource *models.Resour
 	return nil
 }
 
+// GetByID returns a resource by its Siros ID or, failing that, by one of its
+// aliases such as its native ID or ARN. An alias of several resources, such
+// as a native ID reused across accounts, fails with ErrAmbiguous.
 func (r *resourceRepository) GetByID(ctx context.Context, id string) (*models.Resource, error) {
+	return r.getOne(ctx, "id = $1", "alias = $1", id)
+}
+
+// getOne returns the resource matching a condition on its ID, preferring it
+// over the resource matching a condition on its aliases. Aliases are unique
+// per provider and account only, so an alias matching several resources
+// fails rather than picking one of them.
+func (r *resourceRepository) getOne(ctx context.Context, condition, aliasCondition, id string) (*models.Resource, error) {
+	// #nosec G202 -- conditions are constants supplied by GetByID
 	query := `
-		SELECT id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at
-		FROM resources WHERE id = $1
+		SELECT id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at,
+		       ` + condition + ` AS exact
+		FROM resources
+		WHERE ` + condition + ` OR id IN (SELECT resource_id FROM resource_aliases WHERE ` + aliasCondition + `)
+		ORDER BY exact DESC
+		LIMIT 2
 	`
 
-	row := r.db.QueryRowContext(ctx, query, id)
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to get resource: %w", err)
+		}
+		return nil, fmt.Errorf("resource %w: %s", ErrNotFound, id)
+	}
 
 	var resource models.Resource
 	var dataJSON, metadataJSON []byte
 	var vector pq.Float32Array
+	var exact bool
 
-	err := row.Scan(
+	if err := rows.Scan(
 		&resource.ID, &resource.Type, &resource.Provider, &resource.Name,
 		&dataJSON, &metadataJSON, &vector, &resource.ParentID,
-		&resource.CreatedAt, &resource.ModifiedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("resource not found: %s", id)
-		}
+		&resource.CreatedAt, &resource.ModifiedAt, &exact,
+	); err != nil {
 		return nil, fmt.Errorf("failed to scan resource: %w", err)
 	}
+	if !exact && rows.Next() {
+		return nil, fmt.Errorf("resource %w: %s is an alias of several resources", ErrAmbiguous, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
 
 	// Unmarshal JSON fields
 	if len(dataJSON) > 0 {
@@ -130,7 +161,7 @@ func (r *resourceRepository) Update(ctx context.Context, resource *models.Resour
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("resource not found: %s", resource.ID)
+		return fmt.Errorf("resource %w: %s", ErrNotFound, resource.ID)
 	}
 
 	return nil
@@ -150,111 +181,283 @@ func (r *resourceRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("resource not found: %s", id)
+		return fmt.Errorf("resource %w: %s", ErrNotFound, id)
 	}
 
 	return nil
 }
 
-func (r *resourceRepository) List(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error) {
-	// Build the SQL query with filters
-	sqlQuery := `
-		SELECT id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at
-		FROM resources
-	`
+// UpsertBatch inserts or updates resources in a single transaction. The
+// original created_at of existing rows is kept. Parents that are not stored
+// yet are left unset rather than violating the foreign key; they are linked
+// once the parent exists and the child is upserted again.
+func (r *resourceRepository) UpsertBatch(ctx context.Context, resources []models.Resource) error {
+	if len(resources) == 0 {
+		return nil
+	}
 
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return upsertResources(ctx, tx, resources)
+	})
+}
 
-	// Add filters
-	if query.Provider != "" {
-		conditions = append(conditions, fmt.Sprintf("provider = $%d", argIndex))
-		args = append(args, query.Provider)
-		argIndex++
+// upsertResources upserts resources within tx
+func upsertResources(ctx context.Context, tx *sql.Tx, resources []models.Resource) error {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO resources (id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7,
+		        (SELECT id FROM resources WHERE id = $8), $9, $10)
+		ON CONFLICT (id) DO UPDATE
+		SET type = EXCLUDED.type, provider = EXCLUDED.provider, name = EXCLUDED.name,
+		    data = EXCLUDED.data, metadata = EXCLUDED.metadata,
+		    vector = COALESCE(EXCLUDED.vector, resources.vector),
+		    parent_id = COALESCE(EXCLUDED.parent_id, resources.parent_id),
+		    modified_at = EXCLUDED.modified_at
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare upsert: %w", err)
 	}
+	defer stmt.Close()
 
-	if query.Type != "" {
-		conditions = append(conditions, fmt.Sprintf("type = $%d", argIndex))
-		args = append(args, query.Type)
-		argIndex++
-	}
+	for i := range resources {
+		resource := &resources[i]
 
-	if len(query.Filters) > 0 {
-		for key, value := range query.Filters {
-			// Use JSONB path queries for filtering by metadata/data fields
-			if key == "region" || key == "environment" || key == "cost_center" {
-				conditions = append(conditions, fmt.Sprintf("metadata->>%d = $%d", argIndex, argIndex+1))
-				args = append(args, key, value)
-				argIndex += 2
-			}
+		dataJSON, err := json.Marshal(resource.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal data for %s: %w", resource.ID, err)
+		}
+		metadataJSON, err := json.Marshal(resource.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata for %s: %w", resource.ID, err)
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			resource.ID, resource.Type, resource.Provider, resource.Name,
+			dataJSON, metadataJSON, pq.Array(resource.Vector), resource.ParentID,
+			resource.CreatedAt, resource.ModifiedAt,
+		); err != nil {
+			return fmt.Errorf("failed to upsert resource %s: %w", resource.ID, err)
 		}
 	}
 
-	// Add WHERE clause if there are conditions
-	if len(conditions) > 0 {
-		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	return nil
+}
+
+// List returns a page of resources matching the query's filters. Pages are
+// read by keyset from the query's cursor, or by offset without one.
+func (r *resourceRepository) List(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	conditions, args, err := listConditions(query)
+	if err != nil {
+		return nil, nil, err
 	}
+	return r.page(ctx, query, conditions, args)
+}
 
-	// Add ORDER BY
-	sqlQuery += fmt.Sprintf(" ORDER BY %s %s", query.SortBy, strings.ToUpper(query.SortOrder))
+// page reads the page of resources matching conditions that query selects.
+// The sort keys are selected along with each resource so that cursors carry
+// the values the database ordered by.
+func (r *resourceRepository) page(ctx context.Context, query *models.SearchQuery, conditions []string, args []interface{}) ([]models.Resource, *models.PageInfo, error) {
+	sort, err := query.Sort()
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make([]sortKey, len(sort))
+	selected := make([]string, len(sort))
+	for i, key := range sort {
+		keys[i] = sortKey{sql: key.SQL(), desc: key.Desc}
+		selected[i] = key.SQL()
+	}
+	keyset, err := newKeyset(query.Order(), keys, "id", query.Cursor, query.Limit)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// Add LIMIT and OFFSET
-	sqlQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, query.Limit, query.Offset)
+	// #nosec G202 -- sort expressions are vetted by filter.ParseSort
+	sqlQuery, pageArgs := keyset.query(`
+		SELECT id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at, `+strings.Join(selected, ", ")+`
+		FROM resources
+	`, conditions, args)
+	if query.Offset > 0 && query.Cursor == "" {
+		pageArgs = append(pageArgs, query.Offset)
+		sqlQuery += fmt.Sprintf(" OFFSET $%d", len(pageArgs))
+	}
 
-	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	rows, err := r.db.QueryContext(ctx, sqlQuery, pageArgs...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query resources: %w", err)
+		return nil, nil, fmt.Errorf("failed to query resources: %w", err)
 	}
 	defer rows.Close()
 
-	return r.scanResources(rows)
-}
+	var sorted []sortedResource
+	for rows.Next() {
+		values := make([]interface{}, len(sort))
+		for i, key := range sort {
+			if key.Time() {
+				values[i] = new(time.Time)
+			} else {
+				values[i] = new(string)
+			}
+		}
+		resource, err := scanResource(rows, values...)
+		if err != nil {
+			return nil, nil, err
+		}
+		item := sortedResource{resource: resource, keys: make([]string, len(values))}
+		for i, value := range values {
+			if t, ok := value.(*time.Time); ok {
+				item.keys[i] = timeKey(*t)
+			} else {
+				item.keys[i] = *value.(*string)
+			}
+		}
+		sorted = append(sorted, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating resources: %w", err)
+	}
 
-func (r *resourceRepository) Search(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error) {
-	// For semantic search, we'll use vector similarity when available
-	// For now, implement text search on name and data fields
-	sqlQuery := `
-		SELECT id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at
-		FROM resources
-		WHERE (name ILIKE $1 OR data::text ILIKE $1)
-	`
+	sorted, info := trimPage(keyset, sorted, query.Offset, func(item *sortedResource) ([]string, string) {
+		return item.keys, item.resource.ID
+	})
+	resources := make([]models.Resource, len(sorted))
+	for i := range sorted {
+		resources[i] = sorted[i].resource
+	}
 
-	var args []interface{}
-	searchPattern := "%" + query.Query + "%"
-	args = append(args, searchPattern)
-	argIndex := 2
+	if err := countRows(ctx, r.db, info, query.Total, "resources", conditions, args); err != nil {
+		return nil, nil, err
+	}
+	return resources, info, nil
+}
+
+// sortedResource is a resource read with the values of its sort keys
+type sortedResource struct {
+	resource models.Resource
+	keys     []string
+}
 
-	// Add additional filters
+// listConditions returns the WHERE conditions and arguments for the
+// provider, type and filter expression of a query
+func listConditions(query *models.SearchQuery) ([]string, []interface{}, error) {
 	var conditions []string
+	var args []interface{}
 
 	if query.Provider != "" {
-		conditions = append(conditions, fmt.Sprintf("provider = $%d", argIndex))
 		args = append(args, query.Provider)
-		argIndex++
+		conditions = append(conditions, fmt.Sprintf("provider = $%d", len(args)))
 	}
 
 	if query.Type != "" {
-		conditions = append(conditions, fmt.Sprintf("type = $%d", argIndex))
 		args = append(args, query.Type)
-		argIndex++
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+
+	expr, err := query.Expr()
+	if err != nil {
+		return nil, nil, err
+	}
+	if expr != nil {
+		condition, filterArgs, err := filter.Compile(expr, args, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		conditions, args = append(conditions, condition), filterArgs
 	}
 
+	return conditions, args, nil
+}
+
+// exportFetchSize is the number of rows fetched from the export cursor at a
+// time
+const exportFetchSize = 1000
+
+// Stream calls fn for every resource matching the query's filters and text,
+// in ID order, reading them through a server-side cursor so that memory use
+// does not grow with the result. Limit, offset and sorting are ignored.
+func (r *resourceRepository) Stream(ctx context.Context, query *models.SearchQuery, fn func(*models.Resource) error) error {
+	conditions, args, err := listConditions(query)
+	if err != nil {
+		return err
+	}
+	if query.Query != "" {
+		args = append(args, "%"+query.Query+"%")
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR data::text ILIKE $%d)", len(args), len(args)))
+	}
+
+	declare := `
+		DECLARE resource_export NO SCROLL CURSOR FOR
+		SELECT id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at
+		FROM resources
+	`
 	if len(conditions) > 0 {
-		sqlQuery += " AND " + strings.Join(conditions, " AND ")
+		// #nosec G202 -- conditions only reference placeholders
+		declare += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	declare += " ORDER BY id"
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM resource_export", exportFetchSize)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("failed to fetch resources: %w", err)
+		}
+		resources, err := r.scanResources(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		for i := range resources {
+			if err := fn(&resources[i]); err != nil {
+				return err
+			}
+		}
+		if len(resources) < exportFetchSize {
+			return tx.Commit()
+		}
+	}
+}
+
+// Search returns a page of resources whose name or data contains the query
+// text and that match its filters
+func (r *resourceRepository) Search(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	// For semantic search, we'll use vector similarity when available
+	// For now, implement text search on name and data fields
+	conditions, args, err := listConditions(query)
+	if err != nil {
+		return nil, nil, err
 	}
+	args = append(args, "%"+query.Query+"%")
+	conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR data::text ILIKE $%d)", len(args), len(args)))
 
-	// Add ORDER BY
-	sqlQuery += fmt.Sprintf(" ORDER BY %s %s", query.SortBy, strings.ToUpper(query.SortOrder))
+	return r.page(ctx, query, conditions, args)
+}
 
-	// Add LIMIT and OFFSET
-	sqlQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, query.Limit, query.Offset)
+// GetByIDs returns the stored resources among ids; IDs that do not exist are
+// left out
+func (r *resourceRepository) GetByIDs(ctx context.Context, ids []string) ([]models.Resource, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, type, provider, name, data, metadata, vector, parent_id, created_at, modified_at
+		FROM resources WHERE id = ANY($1)
+	`
 
-	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
 	if err != nil {
-		return nil, fmt.Errorf("failed to search resources: %w", err)
+		return nil, fmt.Errorf("failed to query resources by ID: %w", err)
 	}
 	defer rows.Close()
 
@@ -337,33 +540,10 @@ func (r *resourceRepository) scanResources(rows *sql.Rows) ([]models.Resource, e
 	var resources []models.Resource
 
 	for rows.Next() {
-		var resource models.Resource
-		var dataJSON, metadataJSON []byte
-		var vector pq.Float32Array
-
-		err := rows.Scan(
-			&resource.ID, &resource.Type, &resource.Provider, &resource.Name,
-			&dataJSON, &metadataJSON, &vector, &resource.ParentID,
-			&resource.CreatedAt, &resource.ModifiedAt,
-		)
+		resource, err := scanResource(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan resource: %w", err)
-		}
-
-		// Unmarshal JSON fields
-		if len(dataJSON) > 0 {
-			if err := json.Unmarshal(dataJSON, &resource.Data); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal data: %w", err)
-			}
+			return nil, err
 		}
-
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &resource.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		}
-
-		resource.Vector = []float32(vector)
 		resources = append(resources, resource)
 	}
 
@@ -373,3 +553,36 @@ func (r *resourceRepository) scanResources(rows *sql.Rows) ([]models.Resource, e
 
 	return resources, nil
 }
+
+// scanResource scans the resource columns of the current row, followed by
+// any extra columns into extra
+func scanResource(rows *sql.Rows, extra ...interface{}) (models.Resource, error) {
+	var resource models.Resource
+	var dataJSON, metadataJSON []byte
+	var vector pq.Float32Array
+
+	dest := append([]interface{}{
+		&resource.ID, &resource.Type, &resource.Provider, &resource.Name,
+		&dataJSON, &metadataJSON, &vector, &resource.ParentID,
+		&resource.CreatedAt, &resource.ModifiedAt,
+	}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return resource, fmt.Errorf("failed to scan resource: %w", err)
+	}
+
+	// Unmarshal JSON fields
+	if len(dataJSON) > 0 {
+		if err := json.Unmarshal(dataJSON, &resource.Data); err != nil {
+			return resource, fmt.Errorf("failed to unmarshal data: %w", err)
+		}
+	}
+
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &resource.Metadata); err != nil {
+			return resource, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	resource.Vector = []float32(vector)
+	return resource, nil
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+func TestResourceRepository_GetByIDAlias(t *testing.T) {
+	db := testDB(t)
+	repo := NewResourceRepository(db)
+	ctx := context.Background()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, id := range []string{"prod-vm", "dev-vm", "shared-vm"} {
+		resource := &models.Resource{ID: id, Type: "vm", Provider: "aws", Name: id, CreatedAt: created, ModifiedAt: created}
+		if err := repo.Create(ctx, resource); err != nil {
+			t.Fatalf("Create(%s) error = %v", id, err)
+		}
+	}
+	// The same native ID in two accounts, and an alias that is also an ID
+	for _, alias := range [][3]string{
+		{"111111111111", "i-0abc", "prod-vm"},
+		{"222222222222", "i-0abc", "dev-vm"},
+		{"111111111111", "i-0def", "prod-vm"},
+		{"111111111111", "shared-vm", "prod-vm"},
+	} {
+		if _, err := db.ExecContext(ctx, `
+			INSERT INTO resource_aliases (provider, account, alias, resource_id, source)
+			VALUES ('aws', $1, $2, $3, 'scan')
+		`, alias[0], alias[1], alias[2]); err != nil {
+			t.Fatalf("failed to insert alias %s: %v", alias[1], err)
+		}
+	}
+
+	if resource, err := repo.GetByID(ctx, "i-0def"); err != nil || resource.ID != "prod-vm" {
+		t.Errorf("GetByID(i-0def) = %+v, %v; want prod-vm", resource, err)
+	}
+	if resource, err := repo.GetByID(ctx, "shared-vm"); err != nil || resource.ID != "shared-vm" {
+		t.Errorf("GetByID(shared-vm) = %+v, %v; want the resource with that ID", resource, err)
+	}
+	if _, err := repo.GetByID(ctx, "i-0abc"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("GetByID(i-0abc) error = %v, want ErrAmbiguous", err)
+	}
+	if _, err := repo.GetByID(ctx, "i-0xyz"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID(i-0xyz) error = %v, want ErrNotFound", err)
+	}
+}
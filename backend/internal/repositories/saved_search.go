@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// uniqueViolation is the Postgres error code for a duplicate unique key
+const uniqueViolation = "23505"
+
+// savedSearchRepository implements SavedSearchRepository
+type savedSearchRepository struct {
+	db *sql.DB
+}
+
+// NewSavedSearchRepository creates a new saved search repository
+func NewSavedSearchRepository(db *sql.DB) SavedSearchRepository {
+	return &savedSearchRepository{db: db}
+}
+
+func (r *savedSearchRepository) Create(ctx context.Context, search *models.SavedSearch) error {
+	query := `
+		INSERT INTO saved_searches (id, name, description, filter, created_by, modified_by, created_at, modified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		search.ID, search.Name, search.Description, search.Filter,
+		search.CreatedBy, search.ModifiedBy, search.CreatedAt, search.ModifiedAt,
+	)
+	if err != nil {
+		return savedSearchError("insert", search.Name, err)
+	}
+	return nil
+}
+
+// GetByID returns the saved search with the given ID or name
+func (r *savedSearchRepository) GetByID(ctx context.Context, id string) (*models.SavedSearch, error) {
+	query := `
+		SELECT id, name, description, filter, created_by, modified_by, created_at, modified_at
+		FROM saved_searches WHERE id = $1 OR name = $1
+	`
+
+	var search models.SavedSearch
+	var description sql.NullString
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&search.ID, &search.Name, &description, &search.Filter,
+		&search.CreatedBy, &search.ModifiedBy, &search.CreatedAt, &search.ModifiedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("saved search %w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to scan saved search: %w", err)
+	}
+	search.Description = description.String
+
+	return &search, nil
+}
+
+func (r *savedSearchRepository) Update(ctx context.Context, search *models.SavedSearch) error {
+	query := `
+		UPDATE saved_searches
+		SET name = $2, description = $3, filter = $4, modified_by = $5, modified_at = $6
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		search.ID, search.Name, search.Description, search.Filter, search.ModifiedBy, search.ModifiedAt,
+	)
+	if err != nil {
+		return savedSearchError("update", search.Name, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("saved search %w: %s", ErrNotFound, search.ID)
+	}
+
+	return nil
+}
+
+func (r *savedSearchRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("saved search %w: %s", ErrNotFound, id)
+	}
+
+	return nil
+}
+
+// List returns all saved searches ordered by name
+func (r *savedSearchRepository) List(ctx context.Context) ([]models.SavedSearch, error) {
+	query := `
+		SELECT id, name, description, filter, created_by, modified_by, created_at, modified_at
+		FROM saved_searches ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []models.SavedSearch
+	for rows.Next() {
+		var search models.SavedSearch
+		var description sql.NullString
+		if err := rows.Scan(
+			&search.ID, &search.Name, &description, &search.Filter,
+			&search.CreatedBy, &search.ModifiedBy, &search.CreatedAt, &search.ModifiedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		search.Description = description.String
+		searches = append(searches, search)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating saved searches: %w", err)
+	}
+
+	return searches, nil
+}
+
+// savedSearchError wraps a failed write, reporting a taken name as
+// ErrAlreadyExists
+func savedSearchError(op, name string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("saved search %w: %s", ErrAlreadyExists, name)
+	}
+	return fmt.Errorf("failed to %s saved search: %w", op, err)
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+
+	"github.com/lib/pq"
 
 	"github.com/LederWorks/siros/backend/internal/models"
 )
@@ -14,81 +18,126 @@ type schemaRepository struct {
 	db *sql.DB
 }
 
+// schemaColumns are the columns scanSchema reads
+const schemaColumns = `name, provider, type, version, schema, mode, compatibility, transform, description, is_custom, created_at, updated_at`
+
 // NewSchemaRepository creates a new schema repository
 func NewSchemaRepository(db *sql.DB) SchemaRepository {
 	return &schemaRepository{db: db}
 }
 
+// Create stores a new version of a schema
 func (r *schemaRepository) Create(ctx context.Context, schema *models.Schema) error {
-	schemaJSON, err := json.Marshal(schema.Schema)
+	schemaJSON, transformJSON, err := marshalSchema(schema)
 	if err != nil {
-		return fmt.Errorf("failed to marshal schema: %w", err)
+		return err
 	}
 
 	query := `
-		INSERT INTO schemas (name, provider, type, version, schema, description, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO schemas (name, provider, type, version, schema, mode, compatibility, transform, description, is_custom, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
 		schema.Name, schema.Provider, schema.Type, schema.Version,
-		schemaJSON, schema.Description, schema.CreatedAt,
+		schemaJSON, schema.Mode, schema.Compatibility, transformJSON, schema.Description,
+		schema.IsCustom, schema.CreatedAt, schema.UpdatedAt,
 	)
 
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("schema %w: %s version %s", ErrAlreadyExists, schema.Name, schema.Version)
+		}
 		return fmt.Errorf("failed to insert schema: %w", err)
 	}
 
 	return nil
 }
 
-func (r *schemaRepository) GetByID(ctx context.Context, id string) (*models.Schema, error) {
-	query := `
-		SELECT name, provider, type, version, schema, description, created_at
-		FROM schemas WHERE name = $1
-	`
-
-	row := r.db.QueryRowContext(ctx, query, id)
+// GetByName returns the latest version of a provider's schema
+func (r *schemaRepository) GetByName(ctx context.Context, name, provider string) (*models.Schema, error) {
+	versions, err := r.versions(ctx, `name = $1 AND provider = $2`, name, provider)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("schema %w: %s/%s", ErrNotFound, provider, name)
+	}
+	return &versions[len(versions)-1], nil
+}
 
-	var schema models.Schema
-	var schemaJSON []byte
+// GetForType returns a version of the schema registered for a provider's
+// resource type, the latest when version is empty
+func (r *schemaRepository) GetForType(ctx context.Context, provider, resourceType, version string) (*models.Schema, error) {
+	versions, err := r.versions(ctx, `provider = $1 AND type = $2`, provider, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if version == "" || models.CompareSchemaVersions(versions[i].Version, version) == 0 {
+			return &versions[i], nil
+		}
+	}
+	if version != "" {
+		return nil, fmt.Errorf("schema %w: %s/%s version %s", ErrNotFound, provider, resourceType, version)
+	}
+	return nil, fmt.Errorf("schema %w: %s/%s", ErrNotFound, provider, resourceType)
+}
 
-	err := row.Scan(
-		&schema.Name, &schema.Provider, &schema.Type, &schema.Version,
-		&schemaJSON, &schema.Description, &schema.CreatedAt,
-	)
+// ListVersions returns every version of the named schema, oldest first.
+// An empty provider matches any.
+func (r *schemaRepository) ListVersions(ctx context.Context, name, provider string) ([]models.Schema, error) {
+	if provider == "" {
+		return r.versions(ctx, `name = $1`, name)
+	}
+	return r.versions(ctx, `name = $1 AND provider = $2`, name, provider)
+}
 
+// versions returns the schemas matching a condition ordered by version.
+// Versions are compared numerically, which SQL cannot do on their text.
+func (r *schemaRepository) versions(ctx context.Context, condition string, args ...interface{}) ([]models.Schema, error) {
+	// #nosec G202 -- condition is one of this file's constant conditions
+	rows, err := r.db.QueryContext(ctx, `SELECT `+schemaColumns+` FROM schemas WHERE `+condition, args...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("schema not found: %s", id)
-		}
-		return nil, fmt.Errorf("failed to scan schema: %w", err)
+		return nil, fmt.Errorf("failed to query schemas: %w", err)
 	}
+	defer rows.Close()
 
-	if len(schemaJSON) > 0 {
-		if err := json.Unmarshal(schemaJSON, &schema.Schema); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal schema: %w", err)
+	var schemas []models.Schema
+	for rows.Next() {
+		schema, err := scanSchema(rows)
+		if err != nil {
+			return nil, err
 		}
+		schemas = append(schemas, *schema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schemas: %w", err)
 	}
 
-	return &schema, nil
+	slices.SortStableFunc(schemas, func(a, b models.Schema) int {
+		return models.CompareSchemaVersions(a.Version, b.Version)
+	})
+	return schemas, nil
 }
 
+// Update replaces one version of a schema
 func (r *schemaRepository) Update(ctx context.Context, schema *models.Schema) error {
-	schemaJSON, err := json.Marshal(schema.Schema)
+	schemaJSON, transformJSON, err := marshalSchema(schema)
 	if err != nil {
-		return fmt.Errorf("failed to marshal schema: %w", err)
+		return err
 	}
 
 	query := `
 		UPDATE schemas
-		SET provider = $2, type = $3, version = $4, schema = $5, description = $6
-		WHERE name = $1
+		SET type = $4, schema = $5, mode = $6, compatibility = $7, transform = $8, description = $9, updated_at = $10
+		WHERE name = $1 AND provider = $2 AND version = $3
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
-		schema.Name, schema.Provider, schema.Type, schema.Version,
-		schemaJSON, schema.Description,
+		schema.Name, schema.Provider, schema.Version, schema.Type,
+		schemaJSON, schema.Mode, schema.Compatibility, transformJSON, schema.Description, schema.UpdatedAt,
 	)
 
 	if err != nil {
@@ -101,16 +150,17 @@ func (r *schemaRepository) Update(ctx context.Context, schema *models.Schema) er
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("schema not found: %s", schema.Name)
+		return fmt.Errorf("schema %w: %s/%s version %s", ErrNotFound, schema.Provider, schema.Name, schema.Version)
 	}
 
 	return nil
 }
 
-func (r *schemaRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM schemas WHERE name = $1`
+// Delete removes every version of a provider's schema
+func (r *schemaRepository) Delete(ctx context.Context, name, provider string) error {
+	query := `DELETE FROM schemas WHERE name = $1 AND provider = $2`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, name, provider)
 	if err != nil {
 		return fmt.Errorf("failed to delete schema: %w", err)
 	}
@@ -121,54 +171,116 @@ func (r *schemaRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("schema not found: %s", id)
+		return fmt.Errorf("schema %w: %s/%s", ErrNotFound, provider, name)
 	}
 
 	return nil
 }
 
-func (r *schemaRepository) List(ctx context.Context) ([]models.Schema, error) {
-	query := `
-		SELECT name, provider, type, version, schema, description, created_at
-		FROM schemas
-		ORDER BY created_at DESC
-	`
+// List returns a page of schema versions matching the query's provider,
+// type and custom filters, ordered by name
+func (r *schemaRepository) List(ctx context.Context, query *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error) {
+	keys, err := newKeyset(models.SchemaOrder, []sortKey{{sql: "name"}}, schemaTie, query.Cursor, query.Limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conditions []string
+	var args []interface{}
+	if query.Provider != "" {
+		args = append(args, query.Provider)
+		conditions = append(conditions, fmt.Sprintf("provider = $%d", len(args)))
+	}
+	if query.Type != "" {
+		args = append(args, query.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if query.Custom != nil {
+		args = append(args, *query.Custom)
+		conditions = append(conditions, fmt.Sprintf("is_custom = $%d", len(args)))
+	}
 
-	rows, err := r.db.QueryContext(ctx, query)
+	sqlQuery, pageArgs := keys.query(`SELECT `+schemaColumns+` FROM schemas`, conditions, args)
+	rows, err := r.db.QueryContext(ctx, sqlQuery, pageArgs...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query schemas: %w", err)
+		return nil, nil, fmt.Errorf("failed to query schemas: %w", err)
 	}
 	defer rows.Close()
 
 	var schemas []models.Schema
 	for rows.Next() {
-		var schema models.Schema
-		var schemaJSON []byte
-
-		err := rows.Scan(
-			&schema.Name, &schema.Provider, &schema.Type, &schema.Version,
-			&schemaJSON, &schema.Description, &schema.CreatedAt,
-		)
+		schema, err := scanSchema(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan schema: %w", err)
+			return nil, nil, err
 		}
-
-		if len(schemaJSON) > 0 {
-			if err := json.Unmarshal(schemaJSON, &schema.Schema); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal schema: %w", err)
-			}
-		}
-
-		schemas = append(schemas, schema)
+		schemas = append(schemas, *schema)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating schemas: %w", err)
+		return nil, nil, fmt.Errorf("error iterating schemas: %w", err)
 	}
 
-	return schemas, nil
+	schemas, info := trimPage(keys, schemas, 0, func(schema *models.Schema) ([]string, string) {
+		return []string{schema.Name}, schema.Provider + " " + schema.Version
+	})
+	if err := countRows(ctx, r.db, info, query.Total, "schemas", conditions, args); err != nil {
+		return nil, nil, err
+	}
+	return schemas, info, nil
+}
+
+// schemaTie orders the versions of a schema name in listings
+const schemaTie = `provider || ' ' || version`
+
+// marshalSchema encodes a schema's definition and transform for storage
+func marshalSchema(schema *models.Schema) ([]byte, []byte, error) {
+	schemaJSON, err := json.Marshal(schema.Schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	var transformJSON []byte
+	if schema.Transform != nil {
+		if transformJSON, err = json.Marshal(schema.Transform); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal schema transform: %w", err)
+		}
+	}
+	return schemaJSON, transformJSON, nil
 }
 
-func (r *schemaRepository) GetByName(ctx context.Context, name string) (*models.Schema, error) {
-	return r.GetByID(ctx, name)
+// scanSchema scans a row of schemaColumns. sql.ErrNoRows is returned
+// unwrapped so callers can report the schema they looked for.
+func scanSchema(row interface {
+	Scan(dest ...interface{}) error
+}) (*models.Schema, error) {
+	var schema models.Schema
+	var schemaJSON, transformJSON []byte
+	var compatibility, description sql.NullString
+	var updatedAt sql.NullTime
+
+	err := row.Scan(
+		&schema.Name, &schema.Provider, &schema.Type, &schema.Version,
+		&schemaJSON, &schema.Mode, &compatibility, &transformJSON, &description,
+		&schema.IsCustom, &schema.CreatedAt, &updatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan schema: %w", err)
+	}
+	schema.Compatibility, schema.Description = compatibility.String, description.String
+	schema.UpdatedAt = updatedAt.Time
+
+	if len(schemaJSON) > 0 {
+		if err := json.Unmarshal(schemaJSON, &schema.Schema); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal schema: %w", err)
+		}
+	}
+	if len(transformJSON) > 0 {
+		if err := json.Unmarshal(transformJSON, &schema.Transform); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal schema transform: %w", err)
+		}
+	}
+
+	return &schema, nil
 }
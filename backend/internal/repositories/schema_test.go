@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// testDB connects to the PostgreSQL database named by
+// SIROS_TEST_DATABASE_URL and migrates it, skipping the test when the
+// variable is unset. Migrating drops every Siros table, so point it at a
+// disposable database with the vector extension available.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("SIROS_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("SIROS_TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+func testSchema(provider, version string, custom bool) *models.Schema {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &models.Schema{
+		Name: "disk", Provider: provider, Type: "disk", Version: version,
+		Schema:    map[string]interface{}{"type": "object"},
+		IsCustom:  custom,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func TestSchemaRepository(t *testing.T) {
+	repo := NewSchemaRepository(testDB(t))
+	ctx := context.Background()
+
+	for _, schema := range []*models.Schema{
+		testSchema("custom", "1.2", true),
+		testSchema("custom", "1.10", true),
+		testSchema("aws", "1", false),
+	} {
+		if err := repo.Create(ctx, schema); err != nil {
+			t.Fatalf("Create(%s %s) error = %v", schema.Provider, schema.Version, err)
+		}
+	}
+	if err := repo.Create(ctx, testSchema("custom", "1.2", true)); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Create() duplicate version error = %v, want ErrAlreadyExists", err)
+	}
+
+	latest, err := repo.GetByName(ctx, "disk", "custom")
+	if err != nil || latest.Version != "1.10" || !latest.IsCustom {
+		t.Fatalf("GetByName(custom) = %+v, %v; want custom version 1.10", latest, err)
+	}
+	aws, err := repo.GetByName(ctx, "disk", "aws")
+	if err != nil || aws.IsCustom {
+		t.Fatalf("GetByName(aws) = %+v, %v; want the predefined schema", aws, err)
+	}
+	if _, err := repo.GetByName(ctx, "disk", "gcp"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByName(gcp) error = %v, want ErrNotFound", err)
+	}
+
+	versions, err := repo.ListVersions(ctx, "disk", "")
+	if err != nil || len(versions) != 3 {
+		t.Fatalf("ListVersions() = %d versions, %v; want 3", len(versions), err)
+	}
+	if versions[1].Version != "1.2" || versions[2].Version != "1.10" {
+		t.Errorf("ListVersions() order = %s, %s, %s; want numeric order", versions[0].Version, versions[1].Version, versions[2].Version)
+	}
+
+	update := testSchema("custom", "1.2", true)
+	update.Description = "Disks"
+	update.UpdatedAt = update.CreatedAt.Add(time.Hour)
+	if err := repo.Update(ctx, update); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err := repo.GetForType(ctx, "custom", "disk", "1.2")
+	if err != nil || got.Description != "Disks" || !got.UpdatedAt.Equal(update.UpdatedAt) {
+		t.Errorf("GetForType() after update = %+v, %v", got, err)
+	}
+	if err := repo.Update(ctx, testSchema("gcp", "1", true)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update() of a missing schema error = %v, want ErrNotFound", err)
+	}
+
+	custom := true
+	page, _, err := repo.List(ctx, &models.SchemaQuery{Custom: &custom, Limit: 10})
+	if err != nil || len(page) != 2 {
+		t.Errorf("List(custom) = %d schemas, %v; want 2", len(page), err)
+	}
+	page, _, err = repo.List(ctx, &models.SchemaQuery{Provider: "aws", Limit: 10})
+	if err != nil || len(page) != 1 || page[0].IsCustom {
+		t.Errorf("List(aws) = %+v, %v; want the predefined schema", page, err)
+	}
+
+	if err := repo.Delete(ctx, "disk", "custom"); err != nil {
+		t.Fatalf("Delete(custom) error = %v", err)
+	}
+	if _, err := repo.GetByName(ctx, "disk", "aws"); err != nil {
+		t.Errorf("Delete(custom) removed the aws schema: %v", err)
+	}
+	if err := repo.Delete(ctx, "disk", "custom"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a deleted schema error = %v, want ErrNotFound", err)
+	}
+}
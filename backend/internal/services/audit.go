@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// AuditService reads the change ledger
+type AuditService interface {
+	ListChanges(ctx context.Context, query *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error)
+}
+
+// AuditLedger pages through change records
+type AuditLedger interface {
+	ListRecords(ctx context.Context, query *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error)
+}
+
+// auditService implements AuditService
+type auditService struct {
+	ledger AuditLedger
+	logger *log.Logger
+}
+
+// NewAuditService creates a change ledger service
+func NewAuditService(ledger AuditLedger, logger *log.Logger) AuditService {
+	return &auditService{
+		ledger: ledger,
+		logger: logger,
+	}
+}
+
+// ListChanges returns a page of change records matching the query, newest
+// first
+func (s *auditService) ListChanges(ctx context.Context, query *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error) {
+	if err := query.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
+	}
+	query.SetDefaults()
+
+	records, page, err := s.ledger.ListRecords(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+	return records, page, nil
+}
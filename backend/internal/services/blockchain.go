@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// ChangeLedger stores the hash-chained change records of resources
+type ChangeLedger interface {
+	CreateRecord(ctx context.Context, record *models.ChangeRecord) error
+	GetRecordsByResourceID(ctx context.Context, resourceID string) ([]models.ChangeRecord, error)
+	GetLatestRecord(ctx context.Context, resourceID string) (*models.ChangeRecord, error)
+}
+
+// blockchainService implements BlockchainService on the change ledger
+type blockchainService struct {
+	ledger ChangeLedger
+	chain  *ChangeChain
+	logger *log.Logger
+}
+
+// NewBlockchainService creates a service that records and verifies the
+// change history of resources
+func NewBlockchainService(ledger ChangeLedger, logger *log.Logger) BlockchainService {
+	return &blockchainService{
+		ledger: ledger,
+		chain:  NewChangeChain(),
+		logger: logger,
+	}
+}
+
+// RecordChange appends a change record chained to the resource's latest
+func (s *blockchainService) RecordChange(ctx context.Context, resourceID, operation, actor string, changes map[string]interface{}) error {
+	_, err := s.chain.Append(ctx, s.ledger, resourceID, operation, actor, time.Now(), changes)
+	return err
+}
+
+// GetAuditTrail returns the change records of a resource, newest first
+func (s *blockchainService) GetAuditTrail(ctx context.Context, resourceID string) ([]models.ChangeRecord, error) {
+	if resourceID == "" {
+		return nil, fmt.Errorf("resource ID is required")
+	}
+	records, err := s.ledger.GetRecordsByResourceID(ctx, resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit trail: %w", err)
+	}
+	return records, nil
+}
+
+// VerifyIntegrity reports whether every record of the resource still hashes
+// to its stored hash and the records form a single chain from the first.
+// The chain is followed by hash, as records made within the same second
+// have no reliable order.
+func (s *blockchainService) VerifyIntegrity(ctx context.Context, resourceID string) (bool, error) {
+	records, err := s.GetAuditTrail(ctx, resourceID)
+	if err != nil {
+		return false, err
+	}
+	next := make(map[string]*models.ChangeRecord, len(records))
+	for i := range records {
+		record := &records[i]
+		if record.ComputeHash() != record.DataHash {
+			s.logger.Printf("Change record %s of resource %s does not match its hash", record.ID, resourceID)
+			return false, nil
+		}
+		if _, forked := next[record.PreviousHash]; forked {
+			s.logger.Printf("Change record %s of resource %s forks the chain", record.ID, resourceID)
+			return false, nil
+		}
+		next[record.PreviousHash] = record
+	}
+
+	linked, hash := 0, ""
+	for record := next[hash]; record != nil; record = next[hash] {
+		linked++
+		hash = record.DataHash
+	}
+	if linked != len(records) {
+		s.logger.Printf("Change records of resource %s are not chained", resourceID)
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// ChainStore stores change records appended one at a time
+type ChainStore interface {
+	CreateRecord(ctx context.Context, record *models.ChangeRecord) error
+	GetLatestRecord(ctx context.Context, resourceID string) (*models.ChangeRecord, error)
+}
+
+// ChangeChain builds the hash-chained records of the change ledger. Every
+// writer of the ledger builds its records here, so that they are
+// identified, normalized, validated and hashed alike.
+type ChangeChain struct {
+	ids IDGenerator
+}
+
+// NewChangeChain creates a change chain
+func NewChangeChain() *ChangeChain {
+	return &ChangeChain{ids: NewHashIDGenerator("change")}
+}
+
+// Next builds the record of a change chained to previousHash, the hash of
+// the resource's latest record or "" for its first. A zero time records
+// the change as made now.
+func (c *ChangeChain) Next(previousHash, resourceID, operation, actor string, at time.Time, changes map[string]interface{}) (models.ChangeRecord, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	record := models.ChangeRecord{
+		ID:           c.ids.Generate(),
+		ResourceID:   resourceID,
+		Operation:    strings.ToUpper(operation),
+		Changes:      changes,
+		Timestamp:    at.UTC(),
+		Actor:        actor,
+		PreviousHash: previousHash,
+	}
+	if err := record.Validate(); err != nil {
+		return models.ChangeRecord{}, fmt.Errorf("invalid change record: %w", err)
+	}
+	record.DataHash = record.ComputeHash()
+	return record, nil
+}
+
+// Append stores the record of a change chained to the resource's latest record
+func (c *ChangeChain) Append(ctx context.Context, store ChainStore, resourceID, operation, actor string, at time.Time, changes map[string]interface{}) (*models.ChangeRecord, error) {
+	previousHash := ""
+	latest, err := store.GetLatestRecord(ctx, resourceID)
+	switch {
+	case err == nil:
+		previousHash = latest.DataHash
+	case !errors.Is(err, repositories.ErrNotFound):
+		return nil, fmt.Errorf("failed to record change: %w", err)
+	}
+
+	record, err := c.Next(previousHash, resourceID, operation, actor, at, changes)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.CreateRecord(ctx, &record); err != nil {
+		return nil, fmt.Errorf("failed to record change: %w", err)
+	}
+	return &record, nil
+}
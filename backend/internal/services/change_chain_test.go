@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangeChain(t *testing.T) {
+	ctx := context.Background()
+	ledger := &fakeChangeLedger{}
+	chain := NewChangeChain()
+
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	first, err := chain.Append(ctx, ledger, "r1", "create", "alice", at, map[string]interface{}{"source": "test"})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if first.Operation != "CREATE" || first.Timestamp.Location() != time.UTC || !first.Timestamp.Equal(at) {
+		t.Errorf("Expected a normalized operation and UTC timestamp, got %s at %v", first.Operation, first.Timestamp)
+	}
+	if !strings.HasPrefix(first.ID, "change-") || first.PreviousHash != "" || first.DataHash != first.ComputeHash() {
+		t.Errorf("Unexpected first record: %+v", first)
+	}
+
+	second, err := chain.Append(ctx, ledger, "r1", "update", "alice", time.Time{}, nil)
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if second.PreviousHash != first.DataHash || second.Timestamp.IsZero() {
+		t.Errorf("Expected the second record chained to the first and made now, got %+v", second)
+	}
+
+	if _, err := chain.Append(ctx, ledger, "r1", "update", "", at, nil); err == nil {
+		t.Error("Expected a record without an actor to be rejected")
+	}
+	if len(ledger.records) != 2 {
+		t.Errorf("Expected rejected records not to be stored, got %d records", len(ledger.records))
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/LederWorks/siros/backend/internal/export"
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// ExportService streams the inventory in bulk
+type ExportService interface {
+	Export(ctx context.Context, w io.Writer, query *models.SearchQuery, format string, columns []string) error
+}
+
+// ExportStore streams stored resources
+type ExportStore interface {
+	Stream(ctx context.Context, query *models.SearchQuery, fn func(*models.Resource) error) error
+}
+
+// exportService implements ExportService
+type exportService struct {
+	store  ExportStore
+	logger *log.Logger
+}
+
+// NewExportService creates a bulk export service
+func NewExportService(store ExportStore, logger *log.Logger) ExportService {
+	return &exportService{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Export writes every resource matching the query's filters to w in format.
+// Nothing is written when the format is unknown or the query is invalid.
+func (s *exportService) Export(ctx context.Context, w io.Writer, query *models.SearchQuery, format string, columns []string) error {
+	if err := query.Validate(); err != nil {
+		return fmt.Errorf("query validation failed: %w", err)
+	}
+
+	writer, err := export.NewWriter(w, format, columns)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	if err := s.store.Stream(ctx, query, func(resource *models.Resource) error {
+		count++
+		return writer.Write(resource)
+	}); err != nil {
+		return fmt.Errorf("failed to export resources: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to export resources: %w", err)
+	}
+
+	s.logger.Printf("Exported %d resources as %s", count, format)
+	return nil
+}
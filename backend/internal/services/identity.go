@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// IdentityService reconciles stored resources with their natural keys
+type IdentityService interface {
+	Deduplicate(ctx context.Context, actor string) (*models.DeduplicationReport, error)
+}
+
+// IdentityResources reads stored resources
+type IdentityResources interface {
+	GetByID(ctx context.Context, id string) (*models.Resource, error)
+	Stream(ctx context.Context, query *models.SearchQuery, fn func(*models.Resource) error) error
+}
+
+// IdentityStore moves a resource to another ID
+type IdentityStore interface {
+	Merge(ctx context.Context, fromID string, into *models.Resource) error
+}
+
+// IdentityLedger records the moves made by deduplication
+type IdentityLedger interface {
+	CreateRecord(ctx context.Context, record *models.ChangeRecord) error
+	GetLatestRecord(ctx context.Context, resourceID string) (*models.ChangeRecord, error)
+}
+
+// identityService implements IdentityService
+type identityService struct {
+	resources IdentityResources
+	store     IdentityStore
+	ledger    IdentityLedger
+	identity  *identity.Resolver
+	chain     *ChangeChain
+	logger    *log.Logger
+}
+
+// NewIdentityService creates a service that deduplicates stored resources
+func NewIdentityService(resources IdentityResources, store IdentityStore, ledger IdentityLedger, resolver *identity.Resolver, logger *log.Logger) IdentityService {
+	return &identityService{
+		resources: resources,
+		store:     store,
+		ledger:    ledger,
+		identity:  resolver,
+		chain:     NewChangeChain(),
+		logger:    logger,
+	}
+}
+
+// Deduplicate moves every resource stored under a native or random ID that
+// has a natural key to its derived Siros ID. When a resource is already
+// stored there the two are merged, keeping the more recently modified data.
+// The old ID remains an alias of the new one.
+func (s *identityService) Deduplicate(ctx context.Context, actor string) (*models.DeduplicationReport, error) {
+	report := &models.DeduplicationReport{}
+	var pending []models.Resource
+	if err := s.resources.Stream(ctx, &models.SearchQuery{}, func(resource *models.Resource) error {
+		report.Scanned++
+		if !identity.IsDerived(resource.ID) && identity.ForStored(resource).Valid() {
+			pending = append(pending, *resource)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+
+	// Parents moved earlier in the run are referenced by their new ID
+	moved := make(map[string]string, len(pending))
+	for i := range pending {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		resource := &pending[i]
+		if resource.ParentID != nil && moved[*resource.ParentID] != "" {
+			parentID := moved[*resource.ParentID]
+			resource.ParentID = &parentID
+		}
+
+		into, merged, err := s.move(ctx, resource, actor)
+		if err != nil {
+			s.logger.Printf("Failed to deduplicate resource %s: %v", resource.ID, err)
+			report.Failed++
+			continue
+		}
+		moved[resource.ID] = into
+		if merged {
+			report.Merged++
+		} else {
+			report.Renamed++
+		}
+	}
+
+	s.logger.Printf("Deduplicated %d resources for %s: %d renamed, %d merged, %d failed",
+		report.Scanned, actor, report.Renamed, report.Merged, report.Failed)
+	return report, nil
+}
+
+// move stores resource under the ID its natural key resolves to and returns
+// that ID and whether another resource was already stored there
+func (s *identityService) move(ctx context.Context, resource *models.Resource, actor string) (string, bool, error) {
+	ref := identity.ForStored(resource).Normalize()
+	ids, err := s.identity.Lookup(ctx, []identity.Ref{ref})
+	if err != nil {
+		return "", false, err
+	}
+	target := ref.ID()
+	if identity.IsDerived(ids[0]) {
+		target = ids[0]
+	}
+
+	into := *resource
+	merged := false
+	existing, err := s.resources.GetByID(ctx, target)
+	switch {
+	case err == nil:
+		merged = true
+		other := existing
+		if existing.ModifiedAt.After(resource.ModifiedAt) {
+			into, other = *existing, resource
+		}
+		if other.CreatedAt.Before(into.CreatedAt) {
+			into.CreatedAt = other.CreatedAt
+			into.Metadata.CreatedBy = other.Metadata.CreatedBy
+		}
+	case !errors.Is(err, repositories.ErrNotFound):
+		return "", false, err
+	}
+	into.ID = target
+	if into.Metadata.NativeID == "" {
+		into.Metadata.NativeID = ref.NativeID
+	}
+	if into.Metadata.Account == "" {
+		into.Metadata.Account = ref.Account
+	}
+
+	if err := s.store.Merge(ctx, resource.ID, &into); err != nil {
+		return "", false, err
+	}
+	if err := s.identity.Register(ctx, []string{target}, []identity.Ref{ref}, "dedup"); err != nil {
+		return "", false, err
+	}
+	return target, merged, s.record(ctx, target, resource.ID, actor)
+}
+
+// record appends a MERGE record to the target's chain. The records of
+// mergedFrom stay in their own chain; the MERGE record names it and hashes
+// in its head, so both chains verify and the merge is traceable.
+func (s *identityService) record(ctx context.Context, resourceID, mergedFrom, actor string) error {
+	changes := map[string]interface{}{
+		"source":      "identity",
+		"merged_from": mergedFrom,
+	}
+	head, err := s.ledger.GetLatestRecord(ctx, mergedFrom)
+	switch {
+	case err == nil:
+		changes["merged_head"] = head.DataHash
+	case !errors.Is(err, repositories.ErrNotFound):
+		return fmt.Errorf("failed to record merge: %w", err)
+	}
+
+	_, err = s.chain.Append(ctx, s.ledger, resourceID, "MERGE", actor, time.Now(), changes)
+	return err
+}
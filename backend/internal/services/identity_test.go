@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// fakeIdentityStore is the resource store, identity store and ledger in one
+type fakeIdentityStore struct {
+	resources map[string]models.Resource
+	aliases   fakeAliasStore
+	records   []models.ChangeRecord
+}
+
+func (s *fakeIdentityStore) GetByID(_ context.Context, id string) (*models.Resource, error) {
+	if resource, ok := s.resources[id]; ok {
+		return &resource, nil
+	}
+	return nil, fmt.Errorf("resource %w: %s", repositories.ErrNotFound, id)
+}
+
+func (s *fakeIdentityStore) Stream(_ context.Context, _ *models.SearchQuery, fn func(*models.Resource) error) error {
+	ids := make([]string, 0, len(s.resources))
+	for id := range s.resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		resource := s.resources[id]
+		if err := fn(&resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fakeIdentityStore) Merge(_ context.Context, fromID string, into *models.Resource) error {
+	delete(s.resources, fromID)
+	s.resources[into.ID] = *into
+	return nil
+}
+
+func (s *fakeIdentityStore) CreateRecord(_ context.Context, record *models.ChangeRecord) error {
+	s.records = append(s.records, *record)
+	return nil
+}
+
+func (s *fakeIdentityStore) GetLatestRecord(_ context.Context, resourceID string) (*models.ChangeRecord, error) {
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if s.records[i].ResourceID == resourceID {
+			return &s.records[i], nil
+		}
+	}
+	return nil, fmt.Errorf("change records %w for resource: %s", repositories.ErrNotFound, resourceID)
+}
+
+func (s *fakeIdentityStore) GetRecordsByResourceID(_ context.Context, resourceID string) ([]models.ChangeRecord, error) {
+	var records []models.ChangeRecord
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if s.records[i].ResourceID == resourceID {
+			records = append(records, s.records[i])
+		}
+	}
+	return records, nil
+}
+
+func TestIdentityService_Deduplicate(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+	scanned := identity.Ref{Provider: "aws", Region: "eu-west-1", NativeID: "i-0abc"}
+
+	store := &fakeIdentityStore{resources: map[string]models.Resource{
+		// Stored under its native ID by an earlier scan, and again under
+		// its derived ID by a later one
+		"i-0abc": {
+			ID: "i-0abc", Provider: "aws", Name: "web-1", CreatedAt: older, ModifiedAt: older,
+			Metadata: models.ResourceMetadata{Region: "eu-west-1", CreatedBy: "scanner"},
+		},
+		scanned.ID(): {
+			ID: scanned.ID(), Provider: "aws", Name: "web-1-renamed", CreatedAt: newer, ModifiedAt: newer,
+			Metadata: models.ResourceMetadata{Region: "eu-west-1", NativeID: "i-0abc", CreatedBy: "import"},
+		},
+		"cmdb-7":                           {ID: "cmdb-7", Provider: "custom", Name: "db", ModifiedAt: older},
+		"0123456789abcdef0123456789abcdef": {ID: "0123456789abcdef0123456789abcdef", Provider: "custom", Name: "manual"},
+	}}
+	service := NewIdentityService(store, store, store, identity.NewResolver(&store.aliases), log.New(io.Discard, "", 0))
+
+	report, err := service.Deduplicate(t.Context(), "admin")
+	if err != nil {
+		t.Fatalf("Deduplicate failed: %v", err)
+	}
+	if report.Scanned != 4 || report.Merged != 1 || report.Renamed != 1 || report.Failed != 0 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	merged := store.resources[scanned.ID()]
+	if merged.Name != "web-1-renamed" || !merged.CreatedAt.Equal(older) || merged.Metadata.CreatedBy != "scanner" {
+		t.Errorf("Expected the newer data with the original creation, got %+v", merged)
+	}
+	if _, ok := store.resources["i-0abc"]; ok {
+		t.Error("Expected the duplicate to be removed")
+	}
+	renamed := identity.Ref{Provider: "custom", NativeID: "cmdb-7"}.ID()
+	if store.resources[renamed].Name != "db" || store.resources[renamed].Metadata.NativeID != "cmdb-7" {
+		t.Errorf("Expected cmdb-7 to move to %s, got %v", renamed, store.resources)
+	}
+	if _, ok := store.resources["0123456789abcdef0123456789abcdef"]; !ok {
+		t.Error("Expected a resource without a natural key to be kept")
+	}
+
+	ids, err := identity.NewResolver(&store.aliases).Lookup(t.Context(), []identity.Ref{{Provider: "custom", NativeID: "cmdb-7"}})
+	if err != nil || ids[0] != renamed {
+		t.Errorf("Expected the old ID to resolve to %s, got %v %v", renamed, ids, err)
+	}
+	if len(store.records) != 2 || store.records[1].Changes["merged_from"] != "i-0abc" || store.records[1].Actor != "admin" {
+		t.Errorf("Unexpected change records: %+v", store.records)
+	}
+}
+
+func TestIdentityService_DeduplicateKeepsChains(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scanned := identity.Ref{Provider: "aws", Region: "eu-west-1", NativeID: "i-0abc"}
+	store := &fakeIdentityStore{resources: map[string]models.Resource{
+		"i-0abc": {
+			ID: "i-0abc", Provider: "aws", Name: "web-1", CreatedAt: at, ModifiedAt: at,
+			Metadata: models.ResourceMetadata{Region: "eu-west-1"},
+		},
+		scanned.ID(): {
+			ID: scanned.ID(), Provider: "aws", Name: "web-1", CreatedAt: at, ModifiedAt: at,
+			Metadata: models.ResourceMetadata{Region: "eu-west-1", NativeID: "i-0abc"},
+		},
+	}}
+	ledger := NewBlockchainService(store, log.New(io.Discard, "", 0))
+	for _, id := range []string{"i-0abc", "i-0abc", scanned.ID()} {
+		if err := ledger.RecordChange(t.Context(), id, "UPDATE", "scanner", map[string]interface{}{"name": "web-1"}); err != nil {
+			t.Fatalf("RecordChange failed: %v", err)
+		}
+	}
+	head := store.records[1].DataHash
+
+	service := NewIdentityService(store, store, store, identity.NewResolver(&store.aliases), log.New(io.Discard, "", 0))
+	if _, err := service.Deduplicate(t.Context(), "admin"); err != nil {
+		t.Fatalf("Deduplicate failed: %v", err)
+	}
+
+	for _, id := range []string{"i-0abc", scanned.ID()} {
+		if ok, err := ledger.VerifyIntegrity(t.Context(), id); err != nil || !ok {
+			t.Errorf("Expected the chain of %s to verify, got %v %v", id, ok, err)
+		}
+	}
+	if old, _ := store.GetRecordsByResourceID(t.Context(), "i-0abc"); len(old) != 2 {
+		t.Errorf("Expected the merged records to stay under their ID, got %+v", old)
+	}
+	latest, err := store.GetLatestRecord(t.Context(), scanned.ID())
+	if err != nil || latest.Operation != "MERGE" || latest.Changes["merged_from"] != "i-0abc" || latest.Changes["merged_head"] != head {
+		t.Errorf("Expected a MERGE record chained to the merged head, got %+v %v", latest, err)
+	}
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// Bulk import formats
+const (
+	ImportFormatJSONL = "jsonl"
+	ImportFormatCSV   = "csv"
+)
+
+// DefaultImportBatchSize is the number of rows written per transaction
+const DefaultImportBatchSize = 500
+
+// maxImportLine bounds the length of a single JSON Lines row
+const maxImportLine = 4 << 20
+
+// ImportService imports resources in bulk
+type ImportService interface {
+	Import(ctx context.Context, r io.Reader, format, actor string) (*models.ImportReport, error)
+}
+
+// ImportStore is the resource storage a bulk import upserts into
+type ImportStore interface {
+	GetByIDs(ctx context.Context, ids []string) ([]models.Resource, error)
+}
+
+// ImportLedger holds the change records a bulk import chains its changes to
+type ImportLedger interface {
+	GetLatestRecords(ctx context.Context, resourceIDs []string) (map[string]models.ChangeRecord, error)
+}
+
+// ImportWriter stores a batch's resources, aliases and change records in
+// one transaction
+type ImportWriter interface {
+	WriteBatch(ctx context.Context, resources []models.Resource, aliases []models.ResourceAlias, records []models.ChangeRecord) error
+}
+
+// importService implements ImportService
+type importService struct {
+	store     ImportStore
+	ledger    ImportLedger
+	writer    ImportWriter
+	identity  *identity.Resolver
+	validator *SchemaValidator
+	chain     *ChangeChain
+	batchSize int
+	logger    *log.Logger
+}
+
+// NewImportService creates a bulk import service that writes
+// DefaultImportBatchSize rows per transaction. Rows are stored under the
+// Siros IDs the resolver assigns their natural keys, and their data is
+// validated like that of resources created through the API.
+func NewImportService(store ImportStore, ledger ImportLedger, writer ImportWriter, resolver *identity.Resolver, validator *SchemaValidator, logger *log.Logger) ImportService {
+	return &importService{
+		store:     store,
+		ledger:    ledger,
+		writer:    writer,
+		identity:  resolver,
+		validator: validator,
+		chain:     NewChangeChain(),
+		batchSize: DefaultImportBatchSize,
+		logger:    logger,
+	}
+}
+
+// importRow is a decoded row, or the reason it could not be decoded
+type importRow struct {
+	record models.ImportRecord
+	err    error
+}
+
+// importDecoder reads rows from an import stream until io.EOF
+type importDecoder interface {
+	Next() (importRow, error)
+}
+
+// Import upserts every row read from r in batches and records one change per
+// imported row. Rows that fail are reported and skipped; only an unreadable
+// stream or a cancelled context stops the import, in which case the report
+// covers the rows handled so far.
+func (s *importService) Import(ctx context.Context, r io.Reader, format, actor string) (*models.ImportReport, error) {
+	if strings.TrimSpace(actor) == "" {
+		return nil, errors.New("actor is required")
+	}
+
+	var dec importDecoder
+	switch format {
+	case ImportFormatJSONL:
+		dec = newJSONLDecoder(r)
+	case ImportFormatCSV:
+		csvDec, err := newCSVDecoder(r)
+		if err != nil {
+			return nil, err
+		}
+		dec = csvDec
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+
+	report := &models.ImportReport{Results: []models.ImportResult{}}
+	batch := make([]importRow, 0, s.batchSize)
+	validator := s.validator.Batch()
+	flush := func() error {
+		results, err := s.importBatch(ctx, batch, validator, actor)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.logger.Printf("Failed to import batch of %d rows: %v", len(batch), err)
+		}
+		for _, result := range results {
+			report.Add(result)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		row, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read import stream: %w", err)
+		}
+
+		batch = append(batch, row)
+		if len(batch) == s.batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return report, err
+		}
+	}
+
+	s.logger.Printf("Imported %d rows for %s: %d created, %d updated, %d failed",
+		report.Total, actor, report.Created, report.Updated, report.Failed)
+	return report, nil
+}
+
+// importBatch validates a batch of rows, upserts them, registers their
+// aliases and records their changes in one transaction. When the batch
+// cannot be written every row that was not already rejected is reported as
+// failed, and the error is returned.
+func (s *importService) importBatch(ctx context.Context, rows []importRow, validator *SchemaValidator, actor string) ([]models.ImportResult, error) {
+	results := make([]models.ImportResult, len(rows))
+	resources := make([]models.Resource, 0, len(rows))
+	indexes := make([]int, 0, len(rows))
+	ids := make([]string, 0, len(rows))
+
+	fail := func(err error) ([]models.ImportResult, error) {
+		for _, i := range indexes {
+			if results[i].Status != models.ImportStatusFailed {
+				results[i].Status, results[i].Error = models.ImportStatusFailed, err.Error()
+			}
+		}
+		return results, err
+	}
+
+	// Resolve the Siros IDs of the rows and of their parents, which are
+	// named by native ID in the row's account and region
+	refs := make([]identity.Ref, len(rows), 2*len(rows))
+	parents := make(map[int]int)
+	for i := range rows {
+		results[i] = models.ImportResult{Line: rows[i].record.Line, ID: rows[i].record.Key()}
+		if rows[i].err != nil {
+			continue
+		}
+		refs[i] = importRef(&rows[i].record)
+		if parentID := rows[i].record.ParentID; parentID != nil && *parentID != "" && !identity.IsDerived(*parentID) {
+			parent := refs[i]
+			parent.NativeID, parent.ARN = *parentID, ""
+			parents[i] = len(refs)
+			refs = append(refs, parent)
+		}
+	}
+	resolved, err := s.identity.Resolve(ctx, refs)
+	if err != nil {
+		for i := range rows {
+			if rows[i].err == nil {
+				indexes = append(indexes, i)
+			}
+		}
+		return fail(err)
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.err != nil {
+			results[i].Status, results[i].Error = models.ImportStatusFailed, row.err.Error()
+			continue
+		}
+		resource, err := row.record.ToResource(resolved[i], actor)
+		if err != nil {
+			results[i].Status, results[i].Error = models.ImportStatusFailed, err.Error()
+			continue
+		}
+		if j, ok := parents[i]; ok {
+			resource.ParentID = &resolved[j]
+		}
+		results[i].ID = resource.ID
+		resources = append(resources, *resource)
+		indexes = append(indexes, i)
+		ids = append(ids, resource.ID)
+	}
+	if len(resources) == 0 {
+		return results, nil
+	}
+
+	stored, err := s.store.GetByIDs(ctx, ids)
+	if err != nil {
+		return fail(err)
+	}
+	existing := make(map[string]models.Resource, len(stored))
+	for i := range stored {
+		existing[stored[i].ID] = stored[i]
+	}
+
+	// Match rows to stored resources, whose pinned schema version rows
+	// without one keep. A key seen earlier in the batch counts as stored, so
+	// repeated rows update rather than create.
+	upserts := resources[:0]
+	accepted := indexes[:0]
+	for j := range resources {
+		resource, i := resources[j], indexes[j]
+		status := models.ImportStatusCreated
+		if current, ok := existing[resource.ID]; ok {
+			if !strings.EqualFold(current.Provider, resource.Provider) {
+				results[i].Status = models.ImportStatusFailed
+				results[i].Error = fmt.Sprintf("%s is already stored as a %s resource", resource.ID, current.Provider)
+				continue
+			}
+			resource.CreatedAt = current.CreatedAt
+			resource.Metadata.CreatedBy = current.Metadata.CreatedBy
+			if resource.Metadata.SchemaVersion == "" {
+				resource.Metadata.SchemaVersion = current.Metadata.SchemaVersion
+			}
+			status = models.ImportStatusUpdated
+		}
+		if err := validator.Check(ctx, &resource); err != nil {
+			results[i].Status, results[i].Error = models.ImportStatusFailed, err.Error()
+			continue
+		}
+		results[i].Status, results[i].Warnings = status, resource.Warnings
+		existing[resource.ID] = resource
+		upserts = append(upserts, resource)
+		accepted = append(accepted, i)
+	}
+	indexes = accepted
+	if len(upserts) == 0 {
+		return results, nil
+	}
+
+	upserted := make([]string, len(upserts))
+	upsertedRefs := make([]identity.Ref, len(upserts))
+	for j := range upserts {
+		upserted[j], upsertedRefs[j] = upserts[j].ID, refs[indexes[j]]
+	}
+
+	latest, err := s.ledger.GetLatestRecords(ctx, ids)
+	if err != nil {
+		return fail(fmt.Errorf("failed to record changes: %w", err))
+	}
+	now := time.Now().UTC()
+	records := make([]models.ChangeRecord, 0, len(upserts))
+	for j := range upserts {
+		i := indexes[j]
+		operation := "UPDATE"
+		if results[i].Status == models.ImportStatusCreated {
+			operation = "CREATE"
+		}
+		record, err := s.chain.Next(latest[upserts[j].ID].DataHash, upserts[j].ID, operation, actor, now, map[string]interface{}{
+			"source": "import",
+			"line":   results[i].Line,
+		})
+		if err != nil {
+			return fail(err)
+		}
+		latest[record.ResourceID] = record
+		records = append(records, record)
+	}
+
+	if err := s.writer.WriteBatch(ctx, upserts, identity.Aliases(upserted, upsertedRefs, "import"), records); err != nil {
+		return fail(err)
+	}
+
+	return results, nil
+}
+
+// importRef returns the natural identifiers of an import row
+func importRef(record *models.ImportRecord) identity.Ref {
+	account := record.Account
+	if account == "" {
+		account = record.Metadata.Account
+	}
+	return identity.Ref{
+		Provider: record.Provider,
+		Account:  account,
+		Region:   record.Metadata.Region,
+		NativeID: record.NativeID,
+		ARN:      record.ARN,
+	}.Normalize()
+}
+
+// jsonlDecoder reads one JSON object per line, skipping blank lines
+type jsonlDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLDecoder(r io.Reader) *jsonlDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	return &jsonlDecoder{scanner: scanner}
+}
+
+func (d *jsonlDecoder) Next() (importRow, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		row := importRow{}
+		if err := json.Unmarshal([]byte(line), &row.record); err != nil {
+			row.err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		row.record.Line = d.line
+		return row, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return importRow{}, fmt.Errorf("line %d: %w", d.line+1, err)
+	}
+	return importRow{}, io.EOF
+}
+
+// csvDecoder reads rows of a CSV file with a header. The columns provider,
+// account, native_id, arn, type, name, parent_id, region, environment and cost_center
+// map to the matching fields, tags.<key> columns to tags, and any other
+// column, with an optional data. prefix, to the resource data.
+type csvDecoder struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	return &csvDecoder{reader: reader, header: header}, nil
+}
+
+func (d *csvDecoder) Next() (importRow, error) {
+	fields, err := d.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		row := importRow{err: parseErr.Err}
+		row.record.Line = parseErr.StartLine
+		return row, nil
+	}
+	if err != nil {
+		return importRow{}, err
+	}
+
+	line, _ := d.reader.FieldPos(0)
+	row := importRow{record: models.ImportRecord{Line: line}}
+	record := &row.record
+	for i, value := range fields {
+		column := d.header[i]
+		if value == "" {
+			continue
+		}
+		switch column {
+		case "provider":
+			record.Provider = value
+		case "account":
+			record.Account = value
+		case "native_id":
+			record.NativeID = value
+		case "arn":
+			record.ARN = value
+		case "type":
+			record.Type = value
+		case "name":
+			record.Name = value
+		case "parent_id":
+			record.ParentID = &fields[i]
+		case "region":
+			record.Metadata.Region = value
+		case "environment":
+			record.Metadata.Environment = value
+		case "cost_center":
+			record.Metadata.CostCenter = value
+		default:
+			if key, ok := strings.CutPrefix(column, "tags."); ok {
+				if record.Metadata.Tags == nil {
+					record.Metadata.Tags = make(map[string]string)
+				}
+				record.Metadata.Tags[key] = value
+				continue
+			}
+			if record.Data == nil {
+				record.Data = make(map[string]interface{})
+			}
+			record.Data[strings.TrimPrefix(column, "data.")] = value
+		}
+	}
+	return row, nil
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// fakeImportStore keeps resources, aliases and change records in memory.
+// A batch is written whole or, when fail is set, not at all.
+type fakeImportStore struct {
+	resources map[string]models.Resource
+	aliases   fakeAliasStore
+	records   []models.ChangeRecord
+	batches   int
+	fail      error
+}
+
+func newFakeImportStore() *fakeImportStore {
+	return &fakeImportStore{resources: make(map[string]models.Resource)}
+}
+
+func (s *fakeImportStore) GetByIDs(_ context.Context, ids []string) ([]models.Resource, error) {
+	var found []models.Resource
+	for _, id := range ids {
+		if resource, ok := s.resources[id]; ok {
+			found = append(found, resource)
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeImportStore) WriteBatch(ctx context.Context, resources []models.Resource, aliases []models.ResourceAlias, records []models.ChangeRecord) error {
+	if s.fail != nil {
+		return s.fail
+	}
+	s.batches++
+	for i := range resources {
+		s.resources[resources[i].ID] = resources[i]
+	}
+	s.records = append(s.records, records...)
+	return s.aliases.AddAliases(ctx, aliases)
+}
+
+func (s *fakeImportStore) UpsertBatch(_ context.Context, resources []models.Resource) error {
+	s.batches++
+	for i := range resources {
+		s.resources[resources[i].ID] = resources[i]
+	}
+	return nil
+}
+
+func (s *fakeImportStore) CreateRecords(_ context.Context, records []models.ChangeRecord) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *fakeImportStore) GetLatestRecords(_ context.Context, ids []string) (map[string]models.ChangeRecord, error) {
+	latest := make(map[string]models.ChangeRecord)
+	for _, record := range s.records {
+		for _, id := range ids {
+			if record.ResourceID == id {
+				latest[id] = record
+			}
+		}
+	}
+	return latest, nil
+}
+
+func newTestImportService(store *fakeImportStore, batchSize int) *importService {
+	service := NewImportService(store, store, store, identity.NewResolver(&store.aliases), nil, log.New(io.Discard, "", 0)).(*importService)
+	service.batchSize = batchSize
+	return service
+}
+
+func TestImportService_JSONL(t *testing.T) {
+	store := newFakeImportStore()
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.resources["i-0abc"] = models.Resource{
+		ID: "i-0abc", Provider: "aws", CreatedAt: created,
+		Metadata: models.ResourceMetadata{CreatedBy: "scanner"},
+	}
+	store.resources["vm-1"] = models.Resource{ID: "vm-1", Provider: "azure"}
+	// Both were stored under their native IDs before IDs were derived
+	store.aliases.aliases = []models.ResourceAlias{
+		{Provider: "aws", Alias: "i-0abc", ResourceID: "i-0abc"},
+		{Provider: "azure", Alias: "vm-1", ResourceID: "vm-1"},
+	}
+
+	body := strings.Join([]string{
+		`{"provider":"aws","native_id":"i-0abc","type":"ec2.instance","name":"web-1","data":{"state":"running"}}`,
+		``,
+		`{"provider":"aws","arn":"arn:aws:s3:::logs","type":"s3.bucket","name":"logs","metadata":{"tags":{"team":"ops"}}}`,
+		`{"provider":"aws","type":"ec2.instance","name":"no-key"}`,
+		`{not json`,
+		`{"provider":"aws","native_id":"vm-1","type":"ec2.instance","name":"same-name"}`,
+		`{"provider":"aws","native_id":"i-0abc","type":"ec2.instance","name":"web-1-renamed"}`,
+	}, "\n")
+
+	report, err := newTestImportService(store, 2).Import(t.Context(), strings.NewReader(body), ImportFormatJSONL, "migration")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	if report.Total != 6 || report.Created != 2 || report.Updated != 2 || report.Failed != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	wantLines := []int{1, 3, 4, 5, 6, 7}
+	wantStatus := []string{"updated", "created", "failed", "failed", "created", "updated"}
+	for i, result := range report.Results {
+		if result.Line != wantLines[i] || result.Status != wantStatus[i] {
+			t.Errorf("Result %d: expected line %d %s, got %+v", i, wantLines[i], wantStatus[i], result)
+		}
+	}
+
+	instance := store.resources["i-0abc"]
+	if instance.Name != "web-1-renamed" || !instance.CreatedAt.Equal(created) || instance.Metadata.CreatedBy != "scanner" {
+		t.Errorf("Expected the instance to be updated in place, got %+v", instance)
+	}
+	if instance.Metadata.ModifiedBy != "migration" {
+		t.Errorf("Expected the import actor as modifier, got %q", instance.Metadata.ModifiedBy)
+	}
+	bucketID := identity.Ref{Provider: "aws", ARN: "arn:aws:s3:::logs"}.ID()
+	if report.Results[1].ID != bucketID {
+		t.Errorf("Expected the bucket to be reported under %s, got %+v", bucketID, report.Results[1])
+	}
+	if bucket := store.resources[bucketID]; bucket.Metadata.Tags["team"] != "ops" || bucket.Data["arn"] != "arn:aws:s3:::logs" {
+		t.Errorf("Unexpected bucket: %+v", bucket)
+	}
+	if store.resources["vm-1"].Provider != "azure" {
+		t.Error("Expected a row for another provider's resource not to overwrite it")
+	}
+	if len(store.aliases.aliases) != 4 || store.aliases.aliases[2].ResourceID != bucketID || store.aliases.aliases[2].Source != "import" {
+		t.Errorf("Expected the imported keys to be registered as aliases, got %+v", store.aliases.aliases)
+	}
+}
+
+func TestImportService_RecordsChanges(t *testing.T) {
+	store := newFakeImportStore()
+	body := `{"provider":"custom","native_id":"cmdb-1","type":"server","name":"a"}
+{"provider":"custom","native_id":"cmdb-1","type":"server","name":"b"}`
+
+	if _, err := newTestImportService(store, 500).Import(t.Context(), strings.NewReader(body), ImportFormatJSONL, "migration"); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	if len(store.records) != 2 {
+		t.Fatalf("Expected one change record per row, got %d", len(store.records))
+	}
+	first, second := store.records[0], store.records[1]
+	if first.Operation != "CREATE" || second.Operation != "UPDATE" {
+		t.Errorf("Expected CREATE then UPDATE, got %s then %s", first.Operation, second.Operation)
+	}
+	if second.PreviousHash != first.DataHash || second.DataHash != second.ComputeHash() {
+		t.Errorf("Expected the records to be chained, got %+v", store.records)
+	}
+	if first.Actor != "migration" || first.Changes["line"] != 1 {
+		t.Errorf("Unexpected change record: %+v", first)
+	}
+}
+
+func TestImportService_BatchFailure(t *testing.T) {
+	store := newFakeImportStore()
+	store.fail = errors.New("connection reset")
+	body := `{"provider":"custom","native_id":"cmdb-1","type":"server","name":"a"}`
+
+	report, err := newTestImportService(store, 500).Import(t.Context(), strings.NewReader(body), ImportFormatJSONL, "migration")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if report.Failed != 1 || report.Results[0].Status != models.ImportStatusFailed {
+		t.Errorf("Expected the row to be reported as failed, got %+v", report)
+	}
+	if len(store.resources) != 0 || len(store.aliases.aliases) != 0 || len(store.records) != 0 {
+		t.Errorf("Expected nothing of the failed batch to be stored, got %d resources, %d aliases, %d records",
+			len(store.resources), len(store.aliases.aliases), len(store.records))
+	}
+}
+
+func TestImportService_CSV(t *testing.T) {
+	store := newFakeImportStore()
+	body := `provider,native_id,type,name,region,tags.owner,data.rack,serial
+custom,srv-1,server,db-1,eu-west-1,alice,r12,SN1
+custom,srv-2,server
+custom,srv-3,server,db-3,,,,
+`
+
+	report, err := newTestImportService(store, 500).Import(t.Context(), strings.NewReader(body), ImportFormatCSV, "migration")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if report.Created != 2 || report.Failed != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if failed := report.Results[1]; failed.Line != 3 || failed.Status != "failed" {
+		t.Errorf("Expected line 3 to fail on its field count, got %+v", failed)
+	}
+
+	server := store.resources[identity.Ref{Provider: "custom", Region: "eu-west-1", NativeID: "srv-1"}.ID()]
+	if server.Metadata.Region != "eu-west-1" || server.Metadata.Tags["owner"] != "alice" {
+		t.Errorf("Unexpected metadata: %+v", server.Metadata)
+	}
+	if server.Data["rack"] != "r12" || server.Data["serial"] != "SN1" {
+		t.Errorf("Unexpected data: %+v", server.Data)
+	}
+}
+
+// countingSchemaLookup counts the lookups made through it
+type countingSchemaLookup struct {
+	SchemaLookup
+	calls int
+}
+
+func (l *countingSchemaLookup) GetForType(ctx context.Context, provider, resourceType, version string) (*models.Schema, error) {
+	l.calls++
+	return l.SchemaLookup.GetForType(ctx, provider, resourceType, version)
+}
+
+func TestImportService_ValidatesSchemas(t *testing.T) {
+	definition := map[string]interface{}{
+		"type":       "object",
+		"required":   []interface{}{"size"},
+		"properties": map[string]interface{}{"size": map[string]interface{}{"enum": []interface{}{"small", "large"}}},
+	}
+	schemas := &countingSchemaLookup{SchemaLookup: fakeSchemaLookup{
+		"aws/ec2.instance": {Name: "ec2", Version: "1", Schema: definition},
+		"aws/s3.bucket":    {Name: "s3", Version: "1", Mode: models.SchemaModeWarn, Schema: definition},
+	}}
+	store := newFakeImportStore()
+	service := newTestImportService(store, 500)
+	service.validator = NewSchemaValidator(schemas, log.New(io.Discard, "", 0))
+
+	body := strings.Join([]string{
+		`{"provider":"aws","native_id":"i-1","type":"ec2.instance","name":"web-1","data":{"size":"small"}}`,
+		`{"provider":"aws","native_id":"i-2","type":"ec2.instance","name":"web-2","data":{"size":"huge"}}`,
+		`{"provider":"aws","native_id":"logs","type":"s3.bucket","name":"logs"}`,
+		`{"provider":"aws","native_id":"i-3","type":"ec2.instance","name":"web-3","data":{"size":"large"}}`,
+	}, "\n")
+	report, err := service.Import(t.Context(), strings.NewReader(body), ImportFormatJSONL, "migration")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	// Enforced schemas reject rows; warn mode stores them with warnings
+	if report.Created != 3 || report.Failed != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if failed := report.Results[1]; failed.Status != "failed" || !strings.Contains(failed.Error, "schema validation failed") {
+		t.Errorf("Expected line 2 to fail validation, got %+v", failed)
+	}
+	if warned := report.Results[2]; warned.Status != "created" || len(warned.Warnings) != 1 {
+		t.Errorf("Expected line 3 to be created with a warning, got %+v", warned)
+	}
+	stored := store.resources[identity.Ref{Provider: "aws", NativeID: "i-1"}.ID()]
+	if stored.Metadata.SchemaVersion != "1" {
+		t.Errorf("Expected the row to be pinned to its schema version, got %q", stored.Metadata.SchemaVersion)
+	}
+	if schemas.calls != 2 {
+		t.Errorf("Expected each schema to be looked up once, got %d lookups", schemas.calls)
+	}
+}
+
+func TestImportService_UnsupportedFormat(t *testing.T) {
+	report, err := newTestImportService(newFakeImportStore(), 500).Import(t.Context(), strings.NewReader(""), "xml", "migration")
+	if err == nil || report != nil {
+		t.Errorf("Expected an error without a report, got %v, %v", report, err)
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// ErrMCPInvalidPrompt is wrapped by errors for prompt requests naming an
+// unknown prompt or passing invalid arguments
+var ErrMCPInvalidPrompt = errors.New("invalid MCP prompt request")
+
+const (
+	// defaultPromptTokenBudget is the default estimate of the tokens a
+	// prompt's embedded context may take
+	defaultPromptTokenBudget = 8000
+	// maxPromptTokenBudget caps the token_budget argument
+	maxPromptTokenBudget = 100000
+	// promptResourceLimit caps the resources a prompt selects
+	promptResourceLimit = 50
+	// promptChangeLimit is the number of recent changes embedded per
+	// resource
+	promptChangeLimit = 10
+	// promptTrailLimit is the number of resources of a selection whose
+	// recent changes are embedded
+	promptTrailLimit = 5
+)
+
+// mcpPrompt is an entry of the prompt registry. Its instruction is given
+// the scope of the resources the prompt's context was assembled from.
+type mcpPrompt struct {
+	name        string
+	title       string
+	description string
+	arguments   []string
+	instruction func(scope string) string
+}
+
+// mcpPromptArguments describes the arguments prompts take; all are
+// optional
+var mcpPromptArguments = map[string]string{
+	"resource_id":  "ID of a resource to focus on; its relationships and recent changes are included",
+	"provider":     "Cloud provider to select resources from",
+	"environment":  "Environment to select resources from",
+	"type":         "Resource type to select",
+	"token_budget": fmt.Sprintf("Approximate number of tokens the embedded context may take; defaults to %d", defaultPromptTokenBudget),
+}
+
+var mcpPrompts = []mcpPrompt{
+	{
+		name:        "resource_summary",
+		title:       "Summary",
+		description: "Summarize a resource or a selection of resources with their relationships and recent changes",
+		arguments:   []string{"resource_id", "provider", "environment", "type", "token_budget"},
+		instruction: func(scope string) string {
+			return fmt.Sprintf("Summarize %s: what the resources are, how they relate to each other and what changed recently. "+
+				"Base every statement on the embedded documents and cite resources by ID.", scope)
+		},
+	},
+	{
+		name:        "security_analysis",
+		title:       "Security analysis",
+		description: "Analyze the security posture of a resource or a selection of resources",
+		arguments:   []string{"resource_id", "provider", "environment", "type", "token_budget"},
+		instruction: func(scope string) string {
+			return fmt.Sprintf("Analyze the security posture of %s. Look at the IAM metadata, network exposure, encryption and "+
+				"public access settings in the resource data, and at recent changes made by unexpected actors. "+
+				"Rank the findings by severity and name the IDs of the resources they concern.", scope)
+		},
+	},
+	{
+		name:        "cost_optimization",
+		title:       "Cost optimization",
+		description: "Recommend cost optimizations for a resource or a selection of resources",
+		arguments:   []string{"resource_id", "provider", "environment", "type", "token_budget"},
+		instruction: func(scope string) string {
+			return fmt.Sprintf("Recommend cost optimizations for %s: idle or oversized resources, duplicates, and resources "+
+				"without a cost center or environment. Estimate the impact where the data allows and name the IDs "+
+				"of the resources concerned.", scope)
+		},
+	},
+}
+
+// ListPrompts describes the prompts of the registry
+func (s *mcpService) ListPrompts(_ context.Context) ([]MCPPrompt, error) {
+	prompts := make([]MCPPrompt, len(mcpPrompts))
+	for i, prompt := range mcpPrompts {
+		arguments := make([]map[string]interface{}, len(prompt.arguments))
+		for j, name := range prompt.arguments {
+			arguments[j] = map[string]interface{}{
+				"name":        name,
+				"description": mcpPromptArguments[name],
+				"required":    false,
+			}
+		}
+		prompts[i] = MCPPrompt{
+			"name":        prompt.name,
+			"description": prompt.description,
+			"arguments":   arguments,
+		}
+	}
+	return prompts, nil
+}
+
+// GetPrompt assembles a prompt: an instruction followed by the documents it
+// is grounded in, embedded as resources. With a resource_id, the resource,
+// its relationships, its recent changes and its related resources are
+// embedded, in that order; otherwise the most recently modified resources
+// of the selection and the recent changes of the first few. Documents that
+// would exceed the token budget are left out.
+func (s *mcpService) GetPrompt(ctx context.Context, name string, arguments map[string]interface{}) (*MCPPromptResult, error) {
+	s.logger.Printf("Getting MCP prompt: %s", name)
+	var prompt *mcpPrompt
+	for i := range mcpPrompts {
+		if mcpPrompts[i].name == name {
+			prompt = &mcpPrompts[i]
+		}
+	}
+	if prompt == nil {
+		return nil, fmt.Errorf("%w: unknown prompt %s", ErrMCPInvalidPrompt, name)
+	}
+	args, err := promptArguments(prompt, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	budget := defaultPromptTokenBudget
+	if value := args["token_budget"]; value != "" {
+		budget, err = strconv.Atoi(value)
+		if err != nil || budget < 1 || budget > maxPromptTokenBudget {
+			return nil, fmt.Errorf("%w: token_budget must be a number from 1 to %d", ErrMCPInvalidPrompt, maxPromptTokenBudget)
+		}
+	}
+	docs := &promptContext{budget: budget}
+
+	var scope string
+	if id := args["resource_id"]; id != "" {
+		scope, err = s.focusContext(ctx, docs, id)
+	} else {
+		scope, err = s.selectionContext(ctx, docs, args)
+	}
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrMCPInvalidPrompt, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	instruction := prompt.instruction(scope)
+	if len(docs.messages) == 0 && docs.omitted == 0 {
+		instruction += " No resources match the selection; say so and suggest how to broaden it."
+	}
+	if docs.omitted > 0 {
+		instruction += fmt.Sprintf(" %d more documents were left out to stay within the budget of %d tokens; "+
+			"use the tools to read them if they matter.", docs.omitted, budget)
+	}
+	messages := []map[string]interface{}{textMessage(instruction)}
+	messages = append(messages, docs.messages...)
+	return &MCPPromptResult{
+		"description": fmt.Sprintf("%s of %s", prompt.title, scope),
+		"messages":    messages,
+	}, nil
+}
+
+// promptArguments checks a prompt's arguments, which MCP passes as strings
+func promptArguments(prompt *mcpPrompt, arguments map[string]interface{}) (map[string]string, error) {
+	args := map[string]string{}
+	names := make([]string, 0, len(arguments))
+	for name := range arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.Contains(prompt.arguments, name) {
+			return nil, fmt.Errorf("%w: unknown argument %s", ErrMCPInvalidPrompt, name)
+		}
+		value, ok := arguments[name].(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: argument %s must be a string", ErrMCPInvalidPrompt, name)
+		}
+		args[name] = strings.TrimSpace(value)
+	}
+	return args, nil
+}
+
+// focusContext embeds a resource, its relationships, its recent changes and
+// its related resources
+func (s *mcpService) focusContext(ctx context.Context, docs *promptContext, id string) (string, error) {
+	resource, err := s.resources.GetResource(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	docs.embed(mcpURI("resources", resource.ID), promptResource(resource))
+
+	relationships, err := s.relationships(ctx, resource)
+	if err != nil {
+		return "", err
+	}
+	docs.embed(mcpURI("relationships", resource.ID), map[string]interface{}{
+		"resource_id":   resource.ID,
+		"relationships": relationships,
+	})
+	if err := s.embedChanges(ctx, docs, resource.ID); err != nil {
+		return "", err
+	}
+
+	embedded := map[string]bool{resource.ID: true}
+	for _, relationship := range relationships {
+		if embedded[relationship.TargetID] {
+			continue
+		}
+		embedded[relationship.TargetID] = true
+		related, err := s.resources.GetResource(ctx, relationship.TargetID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		docs.embed(mcpURI("resources", related.ID), promptResource(related))
+	}
+	return fmt.Sprintf("resource %s (%s %s %s)", resource.ID, resource.Provider, resource.Type, resource.Name), nil
+}
+
+// selectionContext embeds the most recently modified resources matching the
+// provider, environment and type arguments and the recent changes of the
+// first few
+func (s *mcpService) selectionContext(ctx context.Context, docs *promptContext, args map[string]string) (string, error) {
+	query := models.SearchQuery{
+		Provider: args["provider"],
+		Type:     args["type"],
+		SortBy:   "-modified_at",
+		Limit:    promptResourceLimit,
+	}
+	if environment := args["environment"]; environment != "" {
+		query.Filters = map[string]string{"environment": environment}
+	}
+	resources, page, err := s.resources.ListResources(ctx, &query)
+	if err != nil {
+		return "", err
+	}
+	if page != nil && page.NextCursor != "" {
+		docs.omitted++
+	}
+	for i := range resources {
+		docs.embed(mcpURI("resources", resources[i].ID), promptResource(&resources[i]))
+	}
+	for i := 0; i < len(resources) && i < promptTrailLimit; i++ {
+		if err := s.embedChanges(ctx, docs, resources[i].ID); err != nil {
+			return "", err
+		}
+	}
+
+	scope := "the"
+	for _, key := range []string{"provider", "type"} {
+		if args[key] != "" {
+			scope += " " + args[key]
+		}
+	}
+	scope += " resources"
+	if args["environment"] != "" {
+		scope += " in the " + args["environment"] + " environment"
+	}
+	return scope, nil
+}
+
+// embedChanges embeds the recent changes of a resource
+func (s *mcpService) embedChanges(ctx context.Context, docs *promptContext, id string) error {
+	if s.blockchain == nil {
+		return nil
+	}
+	records, err := s.blockchain.GetAuditTrail(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	total := len(records)
+	if len(records) > promptChangeLimit {
+		records = records[:promptChangeLimit]
+	}
+	docs.embed(mcpURI("audit", id), map[string]interface{}{
+		"resource_id": id,
+		"changes":     records,
+		"total":       total,
+	})
+	return nil
+}
+
+// promptContext collects the documents embedded in a prompt within a token
+// budget, estimated at four bytes of JSON per token
+type promptContext struct {
+	budget   int
+	used     int
+	omitted  int
+	messages []map[string]interface{}
+}
+
+// embed adds a document unless it would exceed the budget
+func (c *promptContext) embed(uri string, document interface{}) {
+	text, err := json.Marshal(document)
+	if err != nil {
+		c.omitted++
+		return
+	}
+	tokens := (len(text) + 3) / 4
+	if c.used+tokens > c.budget {
+		c.omitted++
+		return
+	}
+	c.used += tokens
+	c.messages = append(c.messages, map[string]interface{}{
+		"role": "user",
+		"content": map[string]interface{}{
+			"type": "resource",
+			"resource": map[string]interface{}{
+				"uri":      uri,
+				"mimeType": "application/json",
+				"text":     string(text),
+			},
+		},
+	})
+}
+
+func textMessage(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"role":    "user",
+		"content": map[string]interface{}{"type": "text", "text": text},
+	}
+}
+
+// promptResource returns a copy of a resource without its embedding
+// vector, which means nothing to a model
+func promptResource(resource *models.Resource) *models.Resource {
+	stripped := *resource
+	stripped.Vector = nil
+	return &stripped
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// promptURIs returns the URIs of the resources embedded in a prompt and
+// the text of its instruction
+func promptURIs(t *testing.T, result *MCPPromptResult) ([]string, string) {
+	t.Helper()
+	messages := (*result)["messages"].([]map[string]interface{})
+	instruction := messages[0]["content"].(map[string]interface{})["text"].(string)
+	var uris []string
+	for _, message := range messages[1:] {
+		content := message["content"].(map[string]interface{})
+		if message["role"] != "user" || content["type"] != "resource" {
+			t.Fatalf("message %v is not an embedded resource", message)
+		}
+		resource := content["resource"].(map[string]interface{})
+		if resource["mimeType"] != "application/json" || !strings.HasPrefix(resource["text"].(string), "{") {
+			t.Errorf("embedded resource %v is not JSON", resource)
+		}
+		uris = append(uris, resource["uri"].(string))
+	}
+	return uris, instruction
+}
+
+func TestMCPService_ListPrompts(t *testing.T) {
+	s, _ := newTestMCPService(t)
+	prompts, err := s.ListPrompts(context.Background())
+	if err != nil {
+		t.Fatalf("ListPrompts: %v", err)
+	}
+	var names []string
+	for _, prompt := range prompts {
+		names = append(names, prompt["name"].(string))
+		if len(prompt["arguments"].([]map[string]interface{})) == 0 {
+			t.Errorf("prompt %s takes no arguments", prompt["name"])
+		}
+	}
+	if strings.Join(names, " ") != "resource_summary security_analysis cost_optimization" {
+		t.Errorf("prompts = %v", names)
+	}
+}
+
+func TestMCPService_GetPrompt(t *testing.T) {
+	s, _ := newTestMCPService(t)
+	ctx := context.Background()
+
+	// A focused prompt embeds the resource, its relationships, its recent
+	// changes and its related resources
+	result, err := s.GetPrompt(ctx, "security_analysis", map[string]interface{}{"resource_id": "r2"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	uris, instruction := promptURIs(t, result)
+	want := "siros://resources/r2 siros://relationships/r2 siros://audit/r2 siros://resources/r1 siros://resources/r3"
+	if got := strings.Join(uris, " "); got != want {
+		t.Errorf("embedded %s, want %s", got, want)
+	}
+	if !strings.Contains(instruction, "security posture of resource r2") || strings.Contains(instruction, "left out") {
+		t.Errorf("instruction = %q", instruction)
+	}
+	if (*result)["description"] != "Security analysis of resource r2 (aws ec2 )" {
+		t.Errorf("description = %q", (*result)["description"])
+	}
+
+	// A selection embeds its resources and the changes of the first ones
+	result, err = s.GetPrompt(ctx, "cost_optimization", map[string]interface{}{"provider": "aws", "environment": "prod"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	uris, instruction = promptURIs(t, result)
+	if got := strings.Join(uris, " "); got != "siros://resources/r1 siros://resources/r2 siros://audit/r2" {
+		t.Errorf("embedded %s", got)
+	}
+	if !strings.Contains(instruction, "for the aws resources in the prod environment") {
+		t.Errorf("instruction = %q", instruction)
+	}
+
+	// The budget leaves out what does not fit
+	result, err = s.GetPrompt(ctx, "resource_summary", map[string]interface{}{"resource_id": "r2", "token_budget": "60"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	uris, instruction = promptURIs(t, result)
+	if len(uris) == 0 || len(uris) >= 5 || !strings.Contains(instruction, "were left out to stay within the budget of 60 tokens") {
+		t.Errorf("with a budget of 60 embedded %v: %q", uris, instruction)
+	}
+
+	result, err = s.GetPrompt(ctx, "resource_summary", map[string]interface{}{"provider": "gcp"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	if uris, instruction = promptURIs(t, result); len(uris) != 0 || !strings.Contains(instruction, "No resources match") {
+		t.Errorf("empty selection embedded %v: %q", uris, instruction)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		arguments map[string]interface{}
+	}{
+		{"haiku", nil},
+		{"resource_summary", map[string]interface{}{"resource_id": "missing"}},
+		{"resource_summary", map[string]interface{}{"region": "eu"}},
+		{"resource_summary", map[string]interface{}{"token_budget": "lots"}},
+		{"resource_summary", map[string]interface{}{"token_budget": 100}},
+	} {
+		if _, err := s.GetPrompt(ctx, tc.name, tc.arguments); !errors.Is(err, ErrMCPInvalidPrompt) {
+			t.Errorf("GetPrompt(%s, %v) error = %v, want ErrMCPInvalidPrompt", tc.name, tc.arguments, err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// mcpActorKey is the context key of the MCP client tools are called for
+type mcpActorKey struct{}
+
+// WithMCPActor returns a context naming the MCP client on whose behalf
+// tools are called; change proposals record it as their proposer
+func WithMCPActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, mcpActorKey{}, actor)
+}
+
+// mcpActor returns the MCP client a tool call is made for
+func mcpActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(mcpActorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "mcp"
+}
+
+// proposalTools are the tools that propose changes. Nothing they propose is
+// applied until a person approves it over the proposals API.
+func (s *mcpService) proposalTools() []*mcpTool {
+	stringMap := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+			"description":          description,
+		}
+	}
+	reason := stringProperty("Why the change should be made, for the person reviewing it")
+
+	return []*mcpTool{
+		{
+			name:        "propose_tags",
+			description: "Propose setting or removing tags of a resource. The change is dry-run and stored as a proposal that a person must approve before it is applied.",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id":  stringProperty("ID of the resource"),
+				"set": stringMap("Tags to set, by key"),
+				"remove": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Keys of the tags to remove",
+				},
+				"reason": reason,
+			}, "id", "reason"),
+			run: s.proposeTags,
+		},
+		{
+			name:        "propose_reparent",
+			description: "Propose moving a resource under another parent. The change is dry-run and stored as a proposal that a person must approve before it is applied.",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id":        stringProperty("ID of the resource"),
+				"parent_id": stringProperty("ID of the new parent"),
+				"reason":    reason,
+			}, "id", "parent_id", "reason"),
+			run: s.proposeReparent,
+		},
+		{
+			name:        "propose_create_resource",
+			description: "Propose creating a resource, such as a custom resource tracked outside the cloud providers. The resource is validated against its type's schema and stored as a proposal that a person must approve before it is created.",
+			inputSchema: objectSchema(map[string]interface{}{
+				"provider": map[string]interface{}{
+					"type":        "string",
+					"enum":        []interface{}{"aws", "azure", "gcp", "oci", "kubernetes", "custom"},
+					"description": "Provider of the resource; custom for resources tracked outside the cloud providers",
+				},
+				"type": stringProperty("Resource type"),
+				"name": stringProperty("Resource name"),
+				"data": map[string]interface{}{
+					"type":        "object",
+					"description": "Resource data, checked against the schema registered for the type",
+				},
+				"tags":        stringMap("Tags, by key"),
+				"environment": stringProperty("Environment of the resource"),
+				"region":      stringProperty("Region of the resource"),
+				"parent_id":   stringProperty("ID of the parent resource"),
+				"reason":      reason,
+			}, "provider", "type", "name", "reason"),
+			run: s.proposeCreateResource,
+		},
+		{
+			name:        "get_proposal",
+			description: "Get a change proposal, to learn whether it was approved, applied or rejected",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id": stringProperty("ID of the proposal"),
+			}, "id"),
+			run: s.getProposal,
+		},
+	}
+}
+
+// proposeTags runs the propose_tags tool
+func (s *mcpService) proposeTags(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	if s.proposals == nil {
+		return nil, errors.New("change proposals are not available")
+	}
+	resource, err := s.resources.GetResource(ctx, args.str("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := resource.Metadata
+	metadata.Tags = make(map[string]string, len(resource.Metadata.Tags))
+	for key, value := range resource.Metadata.Tags {
+		metadata.Tags[key] = value
+	}
+	if remove, ok := args["remove"].([]interface{}); ok {
+		for _, key := range remove {
+			delete(metadata.Tags, key.(string))
+		}
+	}
+	if set, ok := args["set"].(map[string]interface{}); ok {
+		for key, value := range set {
+			metadata.Tags[key] = value.(string)
+		}
+	}
+
+	proposal, err := s.proposals.ProposeUpdate(ctx, resource.ID, models.UpdateResourceRequest{Metadata: &metadata}, args.str("reason"), mcpActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return proposalContent(proposal), nil
+}
+
+// proposeReparent runs the propose_reparent tool
+func (s *mcpService) proposeReparent(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	if s.proposals == nil {
+		return nil, errors.New("change proposals are not available")
+	}
+	parentID := args.str("parent_id")
+	proposal, err := s.proposals.ProposeUpdate(ctx, args.str("id"), models.UpdateResourceRequest{ParentID: &parentID}, args.str("reason"), mcpActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return proposalContent(proposal), nil
+}
+
+// proposeCreateResource runs the propose_create_resource tool
+func (s *mcpService) proposeCreateResource(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	if s.proposals == nil {
+		return nil, errors.New("change proposals are not available")
+	}
+	req := models.CreateResourceRequest{
+		Provider: args.str("provider"),
+		Type:     args.str("type"),
+		Name:     args.str("name"),
+		Data:     map[string]interface{}{},
+		Metadata: models.ResourceMetadata{
+			Environment: args.str("environment"),
+			Region:      args.str("region"),
+		},
+	}
+	if data, ok := args["data"].(map[string]interface{}); ok {
+		req.Data = data
+	}
+	if tags, ok := args["tags"].(map[string]interface{}); ok {
+		req.Metadata.Tags = make(map[string]string, len(tags))
+		for key, value := range tags {
+			req.Metadata.Tags[key] = value.(string)
+		}
+	}
+	if parentID := args.str("parent_id"); parentID != "" {
+		req.ParentID = &parentID
+	}
+
+	proposal, err := s.proposals.ProposeCreate(ctx, req, args.str("reason"), mcpActor(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return proposalContent(proposal), nil
+}
+
+// getProposal runs the get_proposal tool
+func (s *mcpService) getProposal(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	if s.proposals == nil {
+		return nil, errors.New("change proposals are not available")
+	}
+	proposal, err := s.proposals.GetProposal(ctx, args.str("id"))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"proposal": proposal}, nil
+}
+
+// proposalContent is the result of a tool that made a proposal
+func proposalContent(proposal *models.ChangeProposal) map[string]interface{} {
+	return map[string]interface{}{
+		"proposal": proposal,
+		"message": fmt.Sprintf("Proposal %s is pending; nothing changes until a person approves it. "+
+			"Use get_proposal to follow it.", proposal.ID),
+	}
+}
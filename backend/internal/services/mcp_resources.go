@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// ErrMCPResourceNotFound is wrapped by errors for URIs that name no MCP
+// resource
+var ErrMCPResourceNotFound = errors.New("MCP resource not found")
+
+const (
+	// mcpResourceScheme prefixes the URIs of MCP resources
+	mcpResourceScheme = "siros://"
+	// mcpResourcePageSize is the number of resources resources/list returns
+	// per page
+	mcpResourcePageSize = 100
+)
+
+// mcpResourceTemplates are the URI templates of the MCP resources. Path
+// segments are percent-encoded; IDs may also hold raw slashes.
+var mcpResourceTemplates = []MCPResourceTemplate{
+	{
+		"uriTemplate": "siros://resources/{id}",
+		"name":        "resource",
+		"description": "A cloud resource",
+		"mimeType":    "application/json",
+	},
+	{
+		"uriTemplate": "siros://relationships/{id}",
+		"name":        "relationships",
+		"description": "The parent, children and discovered relationships of a resource",
+		"mimeType":    "application/json",
+	},
+	{
+		"uriTemplate": "siros://providers/{provider}/types/{type}",
+		"name":        "resources-by-type",
+		"description": "The resources of a type at a provider, up to the maximum page size",
+		"mimeType":    "application/json",
+	},
+	{
+		"uriTemplate": "siros://audit/{resource_id}",
+		"name":        "audit-trail",
+		"description": "The change history of a resource, newest first",
+		"mimeType":    "application/json",
+	},
+	{
+		"uriTemplate": "siros://schemas/{provider}/{name}",
+		"name":        "schema",
+		"description": "The latest version of a provider's schema",
+		"mimeType":    "application/json",
+	},
+}
+
+// mcpURI returns the URI of an MCP resource of a kind named by an ID, such
+// as a stored resource or its audit trail
+func mcpURI(kind, id string) string {
+	return mcpResourceScheme + kind + "/" + url.PathEscape(id)
+}
+
+// ListResources lists the stored resources as MCP resources, a page at a
+// time
+func (s *mcpService) ListResources(ctx context.Context, cursor string) ([]MCPResource, string, error) {
+	query := models.SearchQuery{Cursor: cursor, Limit: mcpResourcePageSize}
+	resources, page, err := s.resources.ListResources(ctx, &query)
+	if err != nil {
+		return nil, "", err
+	}
+	listed := make([]MCPResource, len(resources))
+	for i := range resources {
+		listed[i] = MCPResource{
+			"uri":         mcpURI("resources", resources[i].ID),
+			"name":        resources[i].Name,
+			"description": fmt.Sprintf("%s %s", resources[i].Provider, resources[i].Type),
+			"mimeType":    "application/json",
+		}
+	}
+	next := ""
+	if page != nil {
+		next = page.NextCursor
+	}
+	return listed, next, nil
+}
+
+// ListResourceTemplates lists the URI templates MCP resources are read by
+func (s *mcpService) ListResourceTemplates(_ context.Context) ([]MCPResourceTemplate, error) {
+	return mcpResourceTemplates, nil
+}
+
+// ReadResource reads the MCP resource a URI names, as JSON
+func (s *mcpService) ReadResource(ctx context.Context, uri string) (*MCPResourceContent, error) {
+	content, err := s.readResource(ctx, uri)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrMCPResourceNotFound, uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+	text, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", uri, err)
+	}
+	return &MCPResourceContent{
+		"uri":      uri,
+		"mimeType": "application/json",
+		"text":     string(text),
+	}, nil
+}
+
+func (s *mcpService) readResource(ctx context.Context, uri string) (interface{}, error) {
+	path, ok := strings.CutPrefix(uri, mcpResourceScheme)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMCPResourceNotFound, uri)
+	}
+	kind, rest, _ := strings.Cut(path, "/")
+	segments := strings.Split(rest, "/")
+	for i := range segments {
+		segment, err := url.PathUnescape(segments[i])
+		if err != nil || segment == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMCPResourceNotFound, uri)
+		}
+		segments[i] = segment
+	}
+
+	switch {
+	case kind == "resources":
+		return s.resources.GetResource(ctx, strings.Join(segments, "/"))
+
+	case kind == "relationships":
+		resource, err := s.resources.GetResource(ctx, strings.Join(segments, "/"))
+		if err != nil {
+			return nil, err
+		}
+		relationships, err := s.relationships(ctx, resource)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"resource_id": resource.ID, "relationships": relationships}, nil
+
+	case kind == "providers" && len(segments) == 3 && segments[1] == "types":
+		query := models.SearchQuery{Provider: segments[0], Type: segments[2], Limit: models.MaxPageSize}
+		resources, page, err := s.resources.ListResources(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"provider":  segments[0],
+			"type":      segments[2],
+			"resources": nonNilSlice(resources),
+			"truncated": page != nil && page.NextCursor != "",
+		}, nil
+
+	case kind == "audit" && s.blockchain != nil:
+		id := strings.Join(segments, "/")
+		records, err := s.blockchain.GetAuditTrail(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"resource_id": id, "changes": nonNilSlice(records)}, nil
+
+	case kind == "schemas" && len(segments) == 2 && s.schemas != nil:
+		return s.schemas.GetSchema(ctx, segments[1], segments[0])
+	}
+	return nil, fmt.Errorf("%w: %s", ErrMCPResourceNotFound, uri)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// fakeMCPSchemas serves a single aws schema
+type fakeMCPSchemas struct {
+	SchemaService
+}
+
+func (f *fakeMCPSchemas) GetSchema(_ context.Context, name, provider string) (*models.Schema, error) {
+	if provider != "aws" || name != "aws.ec2.instance" {
+		return nil, fmt.Errorf("schema %w: %s/%s", repositories.ErrNotFound, provider, name)
+	}
+	return &models.Schema{Name: name, Provider: provider, Version: "2"}, nil
+}
+
+func TestMCPService_ReadResource(t *testing.T) {
+	s, _ := newTestMCPService(t)
+	s.schemas = &fakeMCPSchemas{}
+	s.resources.(*fakeMCPResources).resources[3].ID = "arn:aws:ec2:us-east-1:1:instance/i-1"
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"siros://resources/r2", `{"id":"r2","type":"ec2"}`},
+		{mcpURI("resources", "arn:aws:ec2:us-east-1:1:instance/i-1"), `{"id":"arn:aws:ec2:us-east-1:1:instance/i-1"}`},
+		{"siros://resources/arn:aws:ec2:us-east-1:1:instance/i-1", `{"id":"arn:aws:ec2:us-east-1:1:instance/i-1"}`},
+		{"siros://relationships/r2", `{"resource_id":"r2","relationships":[{"type":"parent","target_id":"r1"},{"type":"environment"}]}`},
+		{"siros://providers/azure/types/vm", `{"provider":"azure","type":"vm","resources":[{"id":"arn:aws:ec2:us-east-1:1:instance/i-1"}],"truncated":false}`},
+		{"siros://audit/r2", `{"resource_id":"r2","changes":[{"operation":"UPDATE"},{},{}]}`},
+		{"siros://schemas/aws/aws.ec2.instance", `{"name":"aws.ec2.instance","version":"2"}`},
+	}
+	for _, tt := range tests {
+		content, err := s.ReadResource(context.Background(), tt.uri)
+		if err != nil {
+			t.Errorf("ReadResource(%s): %v", tt.uri, err)
+			continue
+		}
+		if (*content)["uri"] != tt.uri || (*content)["mimeType"] != "application/json" {
+			t.Errorf("ReadResource(%s) = %v", tt.uri, *content)
+		}
+		var got, want interface{}
+		_ = json.Unmarshal([]byte((*content)["text"].(string)), &got)
+		_ = json.Unmarshal([]byte(tt.want), &want)
+		if !jsonContains(got, want) {
+			t.Errorf("ReadResource(%s) = %s, want %s", tt.uri, (*content)["text"], tt.want)
+		}
+	}
+
+	for _, uri := range []string{
+		"siros://resources/missing",
+		"siros://resources/",
+		"siros://schemas/aws/nope",
+		"siros://providers/aws",
+		"siros://things/1",
+		"https://example.com/resources/r1",
+		"siros://resources/%zz",
+	} {
+		if _, err := s.ReadResource(context.Background(), uri); !errors.Is(err, ErrMCPResourceNotFound) {
+			t.Errorf("ReadResource(%s) error = %v, want ErrMCPResourceNotFound", uri, err)
+		}
+	}
+}
+
+func TestMCPService_ListResources(t *testing.T) {
+	s, _ := newTestMCPService(t)
+	var uris []string
+	cursor := ""
+	for {
+		resources, next, err := s.ListResources(context.Background(), cursor)
+		if err != nil {
+			t.Fatalf("ListResources: %v", err)
+		}
+		for _, resource := range resources {
+			uris = append(uris, resource["uri"].(string))
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if fmt.Sprint(uris) != "[siros://resources/r1 siros://resources/r2 siros://resources/r3 siros://resources/r4]" {
+		t.Errorf("listed %v", uris)
+	}
+	if _, _, err := s.ListResources(context.Background(), "bogus"); !errors.Is(err, models.ErrInvalidCursor) {
+		t.Errorf("ListResources with a bogus cursor = %v, want ErrInvalidCursor", err)
+	}
+}
+
+// jsonContains reports whether got holds everything in want: objects may
+// have more keys and arrays more elements
+func jsonContains(got, want interface{}) bool {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		obj, ok := got.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range want {
+			if v, ok := obj[key]; !ok || !jsonContains(v, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		arr, ok := got.([]interface{})
+		if !ok || len(arr) < len(want) {
+			return false
+		}
+		for i := range want {
+			if !jsonContains(arr[i], want[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return got == want
+}
@@ -0,0 +1,563 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+const (
+	// coverageScanLimit caps the resources analyze_coverage reads
+	coverageScanLimit = 10000
+	// coverageSampleSize is how many unmanaged resources are listed
+	coverageSampleSize = 20
+)
+
+// mcpTool is an entry of the tool registry. Arguments are checked against
+// the compiled input schema before run is called; run returns the
+// structured content of the result.
+type mcpTool struct {
+	name        string
+	description string
+	inputSchema map[string]interface{}
+	schema      *jsonschema.Schema
+	run         func(ctx context.Context, args toolArguments) (map[string]interface{}, error)
+}
+
+// toolArguments are the arguments of a tool call, decoded from JSON
+type toolArguments map[string]interface{}
+
+func (a toolArguments) str(key string) string {
+	value, _ := a[key].(string)
+	return value
+}
+
+func (a toolArguments) integer(key string, fallback int) int {
+	if value, ok := a[key].(float64); ok {
+		return int(value)
+	}
+	return fallback
+}
+
+func (a toolArguments) boolean(key string) bool {
+	value, _ := a[key].(bool)
+	return value
+}
+
+// objectSchema builds the input schema of a tool
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func integerProperty(description string, maximum int) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maximum, "description": description}
+}
+
+// registerTools builds the tool registry. A tool whose input schema does not
+// compile is left out.
+func (s *mcpService) registerTools() {
+	tools := []*mcpTool{
+		{
+			name:        "list_resources",
+			description: "List cloud resources matching a filter expression or saved search",
+			inputSchema: objectSchema(map[string]interface{}{
+				"filter":       stringProperty(`Filter expression, e.g. provider:aws AND tags.env IN (prod,stage) AND data.instance_type ~ "m5.*" AND modified_at > -7d`),
+				"saved_search": stringProperty("ID or name of a saved search to run; filter narrows it further"),
+				"provider":     stringProperty("Cloud provider filter"),
+				"type":         stringProperty("Resource type filter"),
+				"limit":        integerProperty("Maximum number of resources to return", models.MaxPageSize),
+				"cursor":       stringProperty("Cursor of the page to return"),
+			}),
+			run: s.listResources,
+		},
+		{
+			name:        "search_resources",
+			description: "Search cloud resources by free text or meaning",
+			inputSchema: objectSchema(map[string]interface{}{
+				"query": stringProperty("Text to search for"),
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"enum":        []interface{}{"text", "semantic"},
+					"description": "Match the words of the query, or its meaning; defaults to text",
+				},
+				"provider":    stringProperty("Cloud provider filter"),
+				"type":        stringProperty("Resource type filter"),
+				"environment": stringProperty("Environment filter"),
+			}, "query"),
+			run: s.searchResources,
+		},
+		{
+			name:        "get_resource",
+			description: "Get a cloud resource by ID, optionally with its children",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id": stringProperty("ID of the resource"),
+				"include_children": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also return the resources whose parent it is",
+				},
+			}, "id"),
+			run: s.getResource,
+		},
+		{
+			name:        "get_relationships",
+			description: "List the resources a resource is related to: its parent, its children and the resources of its provider in the same environment",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id": stringProperty("ID of the resource"),
+			}, "id"),
+			run: s.getRelationships,
+		},
+		{
+			name:        "find_similar",
+			description: "Find the resources most similar to a resource, scored from 0 to 1 by vector similarity or, without a vector, by shared attributes and tags",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id":    stringProperty("ID of the resource"),
+				"limit": integerProperty("Maximum number of resources to return; defaults to 10", 100),
+			}, "id"),
+			run: s.findSimilar,
+		},
+		{
+			name:        "get_audit_trail",
+			description: "Get the change history of a resource from the tamper-evident ledger, newest first",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id":    stringProperty("ID of the resource"),
+				"limit": integerProperty("Maximum number of changes to return", models.MaxPageSize),
+				"verify": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also verify the hash chain of the history",
+				},
+			}, "id"),
+			run: s.getAuditTrail,
+		},
+		{
+			name:        "diff_resource_versions",
+			description: "Summarize what changed in a resource between two entries of its change history",
+			inputSchema: objectSchema(map[string]interface{}{
+				"id":   stringProperty("ID of the resource"),
+				"from": stringProperty("ID of the change to diff from, exclusive; defaults to the one before to"),
+				"to":   stringProperty("ID of the change to diff to, inclusive; defaults to the latest"),
+			}, "id"),
+			run: s.diffResourceVersions,
+		},
+		{
+			name:        "analyze_coverage",
+			description: "Measure how many resources are managed by Terraform, per provider and type",
+			inputSchema: objectSchema(map[string]interface{}{
+				"filter":   stringProperty("Filter expression selecting the resources to analyze"),
+				"provider": stringProperty("Cloud provider filter"),
+				"type":     stringProperty("Resource type filter"),
+			}),
+			run: s.analyzeCoverage,
+		},
+	}
+	tools = append(tools, s.proposalTools()...)
+
+	s.tools = make(map[string]*mcpTool, len(tools))
+	for _, tool := range tools {
+		schema, err := jsonschema.Compile(tool.inputSchema)
+		if err != nil {
+			s.logger.Printf("Leaving out MCP tool %s: %v", tool.name, err)
+			continue
+		}
+		tool.schema = schema
+		s.tools[tool.name] = tool
+		s.toolNames = append(s.toolNames, tool.name)
+	}
+}
+
+// ListTools describes the tools of the registry
+func (s *mcpService) ListTools(_ context.Context) ([]MCPTool, error) {
+	tools := make([]MCPTool, 0, len(s.toolNames))
+	for _, name := range s.toolNames {
+		tool := s.tools[name]
+		tools = append(tools, MCPTool{
+			"name":        tool.name,
+			"description": tool.description,
+			"inputSchema": tool.inputSchema,
+		})
+	}
+	return tools, nil
+}
+
+// CallTool runs a tool of the registry. Unknown tools, invalid arguments
+// and failures are reported as tool errors so the caller can correct them.
+func (s *mcpService) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*MCPToolResult, error) {
+	s.logger.Printf("Calling MCP tool: %s", name)
+	tool, ok := s.tools[name]
+	if !ok {
+		return toolResult(fmt.Sprintf("unknown tool: %s", name), true), nil
+	}
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	if errs := tool.schema.Validate(arguments); len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return toolResult("invalid arguments: "+strings.Join(messages, "; "), true), nil
+	}
+
+	content, err := tool.run(ctx, arguments)
+	if err != nil {
+		s.logger.Printf("MCP tool %s failed: %v", name, err)
+		return toolResult(err.Error(), true), nil
+	}
+	text, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s result: %w", name, err)
+	}
+	result := toolResult(string(text), false)
+	(*result)["structuredContent"] = content
+	return result, nil
+}
+
+// toolResult wraps text as the result of a tool call
+func toolResult(text string, isError bool) *MCPToolResult {
+	return &MCPToolResult{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// listResources runs the list_resources tool
+func (s *mcpService) listResources(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	query := models.SearchQuery{
+		Filter:   args.str("filter"),
+		Provider: args.str("provider"),
+		Type:     args.str("type"),
+		Cursor:   args.str("cursor"),
+		Limit:    args.integer("limit", 0),
+	}
+
+	var resources []models.Resource
+	var page *models.PageInfo
+	var err error
+	if saved := args.str("saved_search"); saved != "" {
+		if s.savedSearches == nil {
+			return nil, errors.New("saved searches are not available")
+		}
+		resources, page, err = s.savedSearches.RunSearch(ctx, saved, &query)
+	} else {
+		resources, page, err = s.resources.ListResources(ctx, &query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"resources": nonNilSlice(resources), "page": page}, nil
+}
+
+// searchResources runs the search_resources tool
+func (s *mcpService) searchResources(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	filters := SearchFilters{}
+	for _, key := range []string{"provider", "type", "environment"} {
+		if value := args.str(key); value != "" {
+			filters[key] = value
+		}
+	}
+
+	search := s.search.TextSearch
+	if args.str("mode") == "semantic" {
+		search = s.search.SemanticSearch
+	}
+	results, err := search(ctx, args.str("query"), filters)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"results": nonNilSlice(results)}, nil
+}
+
+// getResource runs the get_resource tool
+func (s *mcpService) getResource(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	resource, err := s.resources.GetResource(ctx, args.str("id"))
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]interface{}{"resource": resource}
+	if args.boolean("include_children") {
+		children, err := s.resources.GetResourcesByParent(ctx, resource.ID)
+		if err != nil {
+			return nil, err
+		}
+		content["children"] = nonNilSlice(children)
+	}
+	return content, nil
+}
+
+// getRelationships runs the get_relationships tool
+func (s *mcpService) getRelationships(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	resource, err := s.resources.GetResource(ctx, args.str("id"))
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := s.relationships(ctx, resource)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"resource_id": resource.ID, "relationships": relationships}, nil
+}
+
+// relationships lists a resource's relationships. Containment comes from
+// the stored parent links; the rest is discovered by the search service.
+func (s *mcpService) relationships(ctx context.Context, resource *models.Resource) ([]ResourceRelationship, error) {
+	var relationships []ResourceRelationship
+	if resource.ParentID != nil {
+		relationships = append(relationships, ResourceRelationship{
+			ID:         resource.ID + ":parent",
+			SourceID:   resource.ID,
+			TargetID:   *resource.ParentID,
+			Type:       "parent",
+			Direction:  "outbound",
+			Confidence: 1,
+		})
+	}
+	children, err := s.resources.GetResourcesByParent(ctx, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		relationships = append(relationships, ResourceRelationship{
+			ID:         resource.ID + ":child:" + children[i].ID,
+			SourceID:   resource.ID,
+			TargetID:   children[i].ID,
+			Type:       "child",
+			Direction:  "outbound",
+			Confidence: 1,
+		})
+	}
+	discovered, err := s.search.DiscoverRelationships(ctx, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	return nonNilSlice(append(relationships, discovered...)), nil
+}
+
+// findSimilar runs the find_similar tool
+func (s *mcpService) findSimilar(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	id := args.str("id")
+	results, err := s.search.SimilaritySearch(ctx, id, args.integer("limit", 10))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"resource_id": id, "results": nonNilSlice(results)}, nil
+}
+
+// getAuditTrail runs the get_audit_trail tool
+func (s *mcpService) getAuditTrail(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	if s.blockchain == nil {
+		return nil, errors.New("the change ledger is not available")
+	}
+	id := args.str("id")
+	records, err := s.blockchain.GetAuditTrail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]interface{}{"resource_id": id, "total": len(records)}
+	if limit := args.integer("limit", 0); limit > 0 && limit < len(records) {
+		records = records[:limit]
+	}
+	content["changes"] = nonNilSlice(records)
+	if args.boolean("verify") {
+		verified, err := s.blockchain.VerifyIntegrity(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		content["verified"] = verified
+	}
+	return content, nil
+}
+
+// diffResourceVersions runs the diff_resource_versions tool. Change records
+// hold what each change did rather than snapshots, so the diff merges the
+// records after from up to to: a field recorded as {"old", "new"} keeps
+// its first old and last new value, anything else its last value.
+func (s *mcpService) diffResourceVersions(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	if s.blockchain == nil {
+		return nil, errors.New("the change ledger is not available")
+	}
+	id := args.str("id")
+	records, err := s.blockchain.GetAuditTrail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("resource %s has no recorded changes", id)
+	}
+
+	index := func(changeID string) int {
+		for i := range records {
+			if records[i].ID == changeID {
+				return i
+			}
+		}
+		return -1
+	}
+	// Records are newest first: to sits at a lower index than from
+	to := 0
+	if changeID := args.str("to"); changeID != "" {
+		if to = index(changeID); to < 0 {
+			return nil, fmt.Errorf("change %s is not part of the history of %s", changeID, id)
+		}
+	}
+	from := to + 1
+	if changeID := args.str("from"); changeID != "" {
+		if from = index(changeID); from < 0 {
+			return nil, fmt.Errorf("change %s is not part of the history of %s", changeID, id)
+		}
+		if from <= to {
+			return nil, fmt.Errorf("change %s is not older than change %s", changeID, records[to].ID)
+		}
+	}
+	if from > len(records) {
+		from = len(records)
+	}
+
+	fields := map[string]interface{}{}
+	applied := make([]models.ChangeRecord, 0, from-to)
+	for i := from - 1; i >= to; i-- {
+		applied = append(applied, records[i])
+		for field, value := range records[i].Changes {
+			change, ok := value.(map[string]interface{})
+			_, hasOld := change["old"]
+			_, hasNew := change["new"]
+			if !ok || !hasOld || !hasNew {
+				fields[field] = value
+				continue
+			}
+			if previous, ok := fields[field].(map[string]interface{}); ok {
+				if _, merged := previous["old"]; merged {
+					fields[field] = map[string]interface{}{"old": previous["old"], "new": change["new"]}
+					continue
+				}
+			}
+			fields[field] = map[string]interface{}{"old": change["old"], "new": change["new"]}
+		}
+	}
+
+	content := map[string]interface{}{
+		"resource_id": id,
+		"to":          records[to].ID,
+		"changes":     applied,
+		"fields":      fields,
+	}
+	if from < len(records) {
+		content["from"] = records[from].ID
+	}
+	return content, nil
+}
+
+// coverageTally counts the Terraform-managed resources of a group
+type coverageTally struct {
+	Total    int     `json:"total"`
+	Managed  int     `json:"managed"`
+	Coverage float64 `json:"coverage_percentage"`
+}
+
+func (t *coverageTally) add(managed bool) {
+	t.Total++
+	if managed {
+		t.Managed++
+	}
+	t.Coverage = float64(t.Managed*1000/t.Total) / 10
+}
+
+// analyzeCoverage runs the analyze_coverage tool, counting the resources
+// managed by Terraform among those selected, up to coverageScanLimit
+func (s *mcpService) analyzeCoverage(ctx context.Context, args toolArguments) (map[string]interface{}, error) {
+	overall := &coverageTally{}
+	byProvider := map[string]*coverageTally{}
+	byType := map[string]*coverageTally{}
+	group := func(groups map[string]*coverageTally, key string) *coverageTally {
+		if groups[key] == nil {
+			groups[key] = &coverageTally{}
+		}
+		return groups[key]
+	}
+	var unmanaged []map[string]interface{}
+
+	query := models.SearchQuery{
+		Filter:   args.str("filter"),
+		Provider: args.str("provider"),
+		Type:     args.str("type"),
+		Limit:    models.MaxPageSize,
+	}
+	truncated := false
+	for {
+		resources, page, err := s.resources.ListResources(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+		for i := range resources {
+			resource := &resources[i]
+			managed := terraformManaged(resource)
+			overall.add(managed)
+			group(byProvider, resource.Provider).add(managed)
+			group(byType, resource.Type).add(managed)
+			if !managed && len(unmanaged) < coverageSampleSize {
+				unmanaged = append(unmanaged, map[string]interface{}{
+					"id":       resource.ID,
+					"name":     resource.Name,
+					"type":     resource.Type,
+					"provider": resource.Provider,
+				})
+			}
+		}
+		if page == nil || page.NextCursor == "" {
+			break
+		}
+		if overall.Total >= coverageScanLimit {
+			truncated = true
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	return map[string]interface{}{
+		"total":               overall.Total,
+		"managed":             overall.Managed,
+		"unmanaged":           overall.Total - overall.Managed,
+		"coverage_percentage": overall.Coverage,
+		"by_provider":         byProvider,
+		"by_type":             byType,
+		"unmanaged_sample":    nonNilSlice(unmanaged),
+		"truncated":           truncated,
+	}, nil
+}
+
+// terraformManaged reports whether a resource came from Terraform state or
+// is tagged as managed by it
+func terraformManaged(resource *models.Resource) bool {
+	if tfType, ok := resource.Metadata.Custom["terraform_type"].(string); ok && tfType != "" {
+		return true
+	}
+	for key, value := range resource.Metadata.Tags {
+		if strings.EqualFold(key, "managed_by") && strings.EqualFold(value, "terraform") {
+			return true
+		}
+	}
+	return false
+}
+
+// nonNilSlice keeps empty lists from encoding as null
+func nonNilSlice[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
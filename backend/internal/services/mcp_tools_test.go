@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// fakeMCPResources serves resources from memory, at most pageSize per page
+// when it is set. Its cursors count the resources before the page.
+type fakeMCPResources struct {
+	ResourceService
+	resources []models.Resource
+	pageSize  int
+}
+
+func (r *fakeMCPResources) GetResource(_ context.Context, id string) (*models.Resource, error) {
+	for i := range r.resources {
+		if r.resources[i].ID == id {
+			return &r.resources[i], nil
+		}
+	}
+	return nil, fmt.Errorf("resource %w: %s", repositories.ErrNotFound, id)
+}
+
+func (r *fakeMCPResources) ListResources(_ context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	if err := query.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
+	}
+	var matching []models.Resource
+	for _, resource := range r.resources {
+		if (query.Provider == "" || resource.Provider == query.Provider) &&
+			(query.Type == "" || resource.Type == query.Type) &&
+			(query.Filters["environment"] == "" || resource.Metadata.Environment == query.Filters["environment"]) {
+			matching = append(matching, resource)
+		}
+	}
+	start := 0
+	if query.Cursor != "" {
+		cursor, _ := models.DecodeCursor(query.Cursor, query.Order())
+		start = len(cursor.Values)
+	}
+	start = min(start, len(matching))
+	size := models.PageSize(query.Limit)
+	if r.pageSize > 0 {
+		size = min(size, r.pageSize)
+	}
+	end := min(start+size, len(matching))
+	page := &models.PageInfo{}
+	if end < len(matching) {
+		page.NextCursor = models.Cursor{Order: query.Order(), Values: make([]string, end)}.Encode()
+	}
+	return matching[start:end], page, nil
+}
+
+func (r *fakeMCPResources) CreateResource(_ context.Context, req *models.CreateResourceRequest) (*models.Resource, error) {
+	resource := req.ToResource()
+	resource.ID = "created-" + req.Name
+	r.resources = append(r.resources, *resource)
+	return resource, nil
+}
+
+func (r *fakeMCPResources) UpdateResource(ctx context.Context, id string, req models.UpdateResourceRequest, modifiedBy string) (*models.Resource, error) {
+	resource, err := r.GetResource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req.ApplyTo(resource, modifiedBy)
+	return resource, nil
+}
+
+func (r *fakeMCPResources) GetResourcesByParent(_ context.Context, parentID string) ([]models.Resource, error) {
+	var children []models.Resource
+	for i := range r.resources {
+		if r.resources[i].ParentID != nil && *r.resources[i].ParentID == parentID {
+			children = append(children, r.resources[i])
+		}
+	}
+	return children, nil
+}
+
+// fakeMCPSearch records the searches it runs
+type fakeMCPSearch struct {
+	SearchService
+	mode    string
+	filters SearchFilters
+}
+
+func (s *fakeMCPSearch) TextSearch(_ context.Context, query string, filters SearchFilters) ([]SearchResult, error) {
+	s.mode, s.filters = "text", filters
+	return []SearchResult{{"id": "r1", "query": query}}, nil
+}
+
+func (s *fakeMCPSearch) SemanticSearch(_ context.Context, query string, filters SearchFilters) ([]SearchResult, error) {
+	s.mode, s.filters = "semantic", filters
+	return []SearchResult{{"id": "r1", "query": query}}, nil
+}
+
+func (s *fakeMCPSearch) SimilaritySearch(_ context.Context, resourceID string, limit int) ([]SearchResult, error) {
+	return []SearchResult{{"id": "r3", "similar_to": resourceID, "limit": limit}}, nil
+}
+
+func (s *fakeMCPSearch) DiscoverRelationships(_ context.Context, resourceID string) ([]ResourceRelationship, error) {
+	return []ResourceRelationship{{SourceID: resourceID, TargetID: "r3", Type: "environment"}}, nil
+}
+
+func newTestMCPService(t *testing.T) (*mcpService, *fakeMCPSearch) {
+	t.Helper()
+	parent := "r1"
+	resources := &fakeMCPResources{resources: []models.Resource{
+		{ID: "r1", Provider: "aws", Type: "vpc", Metadata: models.ResourceMetadata{Environment: "prod", Tags: map[string]string{"Managed_By": "Terraform"}}},
+		{ID: "r2", Provider: "aws", Type: "ec2", ParentID: &parent, Metadata: models.ResourceMetadata{Environment: "prod", Custom: map[string]interface{}{"terraform_type": "aws_instance"}}},
+		{ID: "r3", Provider: "aws", Type: "ec2"},
+		{ID: "r4", Provider: "azure", Type: "vm"},
+	}}
+	search := &fakeMCPSearch{}
+
+	ctx := context.Background()
+	logger := log.New(io.Discard, "", 0)
+	blockchain := NewBlockchainService(&fakeChangeLedger{}, logger)
+	for _, change := range []map[string]interface{}{
+		{"name": map[string]interface{}{"old": "a", "new": "b"}},
+		{"name": map[string]interface{}{"old": "b", "new": "c"}, "size": "large"},
+		{"size": "small"},
+	} {
+		if err := blockchain.RecordChange(ctx, "r2", "UPDATE", "alice", change); err != nil {
+			t.Fatalf("RecordChange: %v", err)
+		}
+	}
+	return NewMCPService(resources, search, blockchain, nil, nil, nil, logger).(*mcpService), search
+}
+
+// callTool calls a tool and returns its structured content, checking that
+// the text content carries the same data
+func callTool(t *testing.T, s *mcpService, name string, arguments map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	result, err := s.CallTool(context.Background(), name, arguments)
+	if err != nil {
+		t.Fatalf("CallTool(%s): %v", name, err)
+	}
+	text := (*result)["content"].([]map[string]interface{})[0]["text"].(string)
+	if (*result)["isError"] == true {
+		t.Fatalf("CallTool(%s) failed: %s", name, text)
+	}
+
+	var fromText, structured map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &fromText); err != nil {
+		t.Fatalf("CallTool(%s) text is not JSON: %v", name, err)
+	}
+	encoded, _ := json.Marshal((*result)["structuredContent"])
+	if err := json.Unmarshal(encoded, &structured); err != nil {
+		t.Fatalf("CallTool(%s) structured content: %v", name, err)
+	}
+	if string(encoded) != strings.TrimSpace(text) {
+		t.Errorf("CallTool(%s) text %s differs from structured content %s", name, text, encoded)
+	}
+	return structured
+}
+
+func TestMCPService_ListTools(t *testing.T) {
+	s, _ := newTestMCPService(t)
+	tools, err := s.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool["name"].(string))
+		if _, ok := tool["inputSchema"].(map[string]interface{}); !ok {
+			t.Errorf("tool %s has no input schema", tool["name"])
+		}
+	}
+	want := "list_resources search_resources get_resource get_relationships find_similar get_audit_trail diff_resource_versions analyze_coverage " +
+		"propose_tags propose_reparent propose_create_resource get_proposal"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("tools = %s, want %s", got, want)
+	}
+}
+
+func TestMCPService_CallToolErrors(t *testing.T) {
+	s, _ := newTestMCPService(t)
+	for _, tc := range []struct {
+		name      string
+		tool      string
+		arguments map[string]interface{}
+		message   string
+	}{
+		{"unknown tool", "drop_tables", nil, "unknown tool"},
+		{"missing argument", "get_resource", nil, "invalid arguments"},
+		{"wrong type", "find_similar", map[string]interface{}{"id": "r1", "limit": "ten"}, "invalid arguments"},
+		{"unknown argument", "get_resource", map[string]interface{}{"id": "r1", "verbose": true}, "invalid arguments"},
+		{"invalid enum", "search_resources", map[string]interface{}{"query": "vpc", "mode": "fuzzy"}, "invalid arguments"},
+		{"not found", "get_resource", map[string]interface{}{"id": "missing"}, "not found"},
+		{"invalid filter", "list_resources", map[string]interface{}{"filter": "provider:"}, "query validation failed"},
+		{"saved searches unavailable", "list_resources", map[string]interface{}{"saved_search": "prod"}, "not available"},
+		{"unknown change", "diff_resource_versions", map[string]interface{}{"id": "r2", "to": "nope"}, "not part of the history"},
+		{"proposals unavailable", "propose_reparent", map[string]interface{}{"id": "r3", "parent_id": "r1", "reason": "tidy"}, "not available"},
+		{"missing reason", "propose_tags", map[string]interface{}{"id": "r1"}, "invalid arguments"},
+		{"non-string tag", "propose_tags", map[string]interface{}{"id": "r1", "set": map[string]interface{}{"env": 1}, "reason": "tidy"}, "invalid arguments"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := s.CallTool(context.Background(), tc.tool, tc.arguments)
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			text := (*result)["content"].([]map[string]interface{})[0]["text"].(string)
+			if (*result)["isError"] != true || !strings.Contains(text, tc.message) {
+				t.Errorf("result = %v, want an error containing %q", *result, tc.message)
+			}
+		})
+	}
+}
+
+func TestMCPService_CallTool(t *testing.T) {
+	s, search := newTestMCPService(t)
+
+	listed := callTool(t, s, "list_resources", map[string]interface{}{"provider": "azure", "limit": float64(5)})
+	if resources := listed["resources"].([]interface{}); len(resources) != 1 || resources[0].(map[string]interface{})["id"] != "r4" {
+		t.Errorf("list_resources = %v, want r4", listed)
+	}
+
+	callTool(t, s, "search_resources", map[string]interface{}{"query": "vpc", "mode": "semantic", "provider": "aws"})
+	if search.mode != "semantic" || search.filters["provider"] != "aws" || len(search.filters) != 1 {
+		t.Errorf("search ran %s with %v, want semantic with the provider filter", search.mode, search.filters)
+	}
+
+	got := callTool(t, s, "get_resource", map[string]interface{}{"id": "r1", "include_children": true})
+	if got["resource"].(map[string]interface{})["id"] != "r1" || len(got["children"].([]interface{})) != 1 {
+		t.Errorf("get_resource = %v, want r1 with child r2", got)
+	}
+
+	related := callTool(t, s, "get_relationships", map[string]interface{}{"id": "r2"})
+	var types []string
+	for _, rel := range related["relationships"].([]interface{}) {
+		types = append(types, rel.(map[string]interface{})["type"].(string))
+	}
+	if strings.Join(types, ",") != "parent,environment" {
+		t.Errorf("relationship types = %v, want parent,environment", types)
+	}
+
+	similar := callTool(t, s, "find_similar", map[string]interface{}{"id": "r2"})
+	if result := similar["results"].([]interface{})[0].(map[string]interface{}); result["limit"] != float64(10) {
+		t.Errorf("find_similar = %v, want the default limit of 10", similar)
+	}
+
+	trail := callTool(t, s, "get_audit_trail", map[string]interface{}{"id": "r2", "limit": float64(2), "verify": true})
+	if trail["total"] != float64(3) || len(trail["changes"].([]interface{})) != 2 || trail["verified"] != true {
+		t.Errorf("get_audit_trail = %v, want 2 of 3 verified changes", trail)
+	}
+
+	// By default the diff covers the latest change alone
+	changes := trail["changes"].([]interface{})
+	latest := changes[0].(map[string]interface{})["id"].(string)
+	diff := callTool(t, s, "diff_resource_versions", map[string]interface{}{"id": "r2"})
+	if diff["to"] != latest || len(diff["changes"].([]interface{})) != 1 {
+		t.Errorf("diff_resource_versions = %v, want the latest change alone", diff)
+	}
+	first := changes[1].(map[string]interface{})["id"].(string)
+	diff = callTool(t, s, "diff_resource_versions", map[string]interface{}{"id": "r2", "from": first})
+	if diff["from"] != first || len(diff["changes"].([]interface{})) != 1 {
+		t.Errorf("diff_resource_versions = %v, want the change after %s", diff, first)
+	}
+	trail = callTool(t, s, "get_audit_trail", map[string]interface{}{"id": "r2"})
+	oldest := trail["changes"].([]interface{})[2].(map[string]interface{})["id"].(string)
+	diff = callTool(t, s, "diff_resource_versions", map[string]interface{}{"id": "r2", "from": oldest})
+	fields, _ := json.Marshal(diff["fields"])
+	if string(fields) != `{"name":{"new":"c","old":"b"},"size":"small"}` {
+		t.Errorf("fields after the oldest change = %s", fields)
+	}
+	whole := callTool(t, s, "diff_resource_versions", map[string]interface{}{"id": "r2", "to": latest, "from": oldest})
+	if len(whole["changes"].([]interface{})) != 2 {
+		t.Errorf("diff_resource_versions = %v, want 2 changes", whole)
+	}
+
+	// Coverage reads every page
+	s.resources.(*fakeMCPResources).pageSize = 1
+	coverage := callTool(t, s, "analyze_coverage", nil)
+	if coverage["total"] != float64(4) || coverage["managed"] != float64(2) || coverage["coverage_percentage"] != float64(50) {
+		t.Errorf("analyze_coverage = %v, want 2 of 4 managed", coverage)
+	}
+	aws := coverage["by_provider"].(map[string]interface{})["aws"].(map[string]interface{})
+	if aws["total"] != float64(3) || aws["coverage_percentage"] != 66.6 {
+		t.Errorf("aws coverage = %v, want 2 of 3", aws)
+	}
+	if sample := coverage["unmanaged_sample"].([]interface{}); len(sample) != 2 {
+		t.Errorf("unmanaged sample = %v, want r3 and r4", sample)
+	}
+}
+
+func TestMCPService_ProposalTools(t *testing.T) {
+	s, _ := newTestMCPService(t)
+	resources := s.resources.(*fakeMCPResources)
+	for i := range resources.resources {
+		resources.resources[i].Name = resources.resources[i].ID
+		resources.resources[i].Metadata.CreatedBy, resources.resources[i].Metadata.ModifiedBy = "bob", "bob"
+	}
+	s.proposals = NewProposalService(&fakeProposalStore{}, resources, s.blockchain, nil, log.New(io.Discard, "", 0))
+
+	tagged := callTool(t, s, "propose_tags", map[string]interface{}{
+		"id":     "r1",
+		"set":    map[string]interface{}{"owner": "network"},
+		"remove": []interface{}{"Managed_By"},
+		"reason": "Record the owner",
+	})
+	proposal := tagged["proposal"].(map[string]interface{})
+	if proposal["status"] != "pending" || proposal["proposed_by"] != "mcp" {
+		t.Errorf("propose_tags = %v", tagged)
+	}
+	if diff := proposal["diff"].(map[string]interface{}); len(diff) != 2 || diff["metadata.tags.owner"] == nil || diff["metadata.tags.Managed_By"] == nil {
+		t.Errorf("propose_tags diff = %v", diff)
+	}
+	if tags := resources.resources[0].Metadata.Tags; tags["owner"] != "" || tags["Managed_By"] != "Terraform" {
+		t.Errorf("propose_tags changed the tags to %v", tags)
+	}
+
+	// Proposals name the MCP client they were made for
+	result, err := s.CallTool(WithMCPActor(context.Background(), "mcp:assistant"), "propose_create_resource", map[string]interface{}{
+		"provider":    "custom",
+		"type":        "firewall",
+		"name":        "edge",
+		"tags":        map[string]interface{}{"site": "hq"},
+		"environment": "prod",
+		"parent_id":   "r1",
+		"reason":      "Track the on-premises firewall",
+	})
+	if err != nil || (*result)["isError"] == true {
+		t.Fatalf("propose_create_resource = %v, %v", result, err)
+	}
+	created := (*result)["structuredContent"].(map[string]interface{})["proposal"].(*models.ChangeProposal)
+	if created.ProposedBy != "mcp:assistant" || created.Create.Metadata.Tags["site"] != "hq" || *created.Create.ParentID != "r1" {
+		t.Errorf("propose_create_resource proposal = %+v", created)
+	}
+
+	got := callTool(t, s, "get_proposal", map[string]interface{}{"id": created.ID})
+	if got["proposal"].(map[string]interface{})["id"] != created.ID {
+		t.Errorf("get_proposal = %v", got)
+	}
+
+	reparented, err := s.CallTool(context.Background(), "propose_reparent", map[string]interface{}{"id": "r1", "parent_id": "r2", "reason": "Nest"})
+	if err != nil || (*reparented)["isError"] != true {
+		t.Errorf("proposing r1 under its own child = %v, %v; want a tool error", reparented, err)
+	}
+}
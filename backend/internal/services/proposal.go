@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+var (
+	// ErrProposalNotPending is wrapped by errors for reviews of proposals
+	// that were already reviewed
+	ErrProposalNotPending = errors.New("change proposal is not pending")
+	// ErrProposalSelfApproval is wrapped by errors for approvals by the
+	// actor who made the proposal
+	ErrProposalSelfApproval = errors.New("change proposal cannot be approved by its proposer")
+	// ErrProposalStale is wrapped by errors for approvals of updates to
+	// resources that changed after the dry run
+	ErrProposalStale = errors.New("resource changed after the proposal")
+)
+
+// maxParentDepth bounds the walk up a proposed parent's ancestors
+const maxParentDepth = 100
+
+// ProposalService records changes proposed by AI assistants and applies
+// them once a person approves them
+type ProposalService interface {
+	ProposeCreate(ctx context.Context, req models.CreateResourceRequest, reason, actor string) (*models.ChangeProposal, error)
+	ProposeUpdate(ctx context.Context, id string, req models.UpdateResourceRequest, reason, actor string) (*models.ChangeProposal, error)
+	GetProposal(ctx context.Context, id string) (*models.ChangeProposal, error)
+	ListProposals(ctx context.Context, status string) ([]models.ChangeProposal, error)
+	ApproveProposal(ctx context.Context, id, approver, note string) (*models.ChangeProposal, error)
+	RejectProposal(ctx context.Context, id, reviewer, note string) (*models.ChangeProposal, error)
+}
+
+// ProposalStore persists change proposals
+type ProposalStore interface {
+	Create(ctx context.Context, proposal *models.ChangeProposal) error
+	GetByID(ctx context.Context, id string) (*models.ChangeProposal, error)
+	UpdateStatus(ctx context.Context, proposal *models.ChangeProposal, from string) error
+	List(ctx context.Context, status string) ([]models.ChangeProposal, error)
+}
+
+// proposalService implements ProposalService
+type proposalService struct {
+	store      ProposalStore
+	resources  ResourceService
+	blockchain BlockchainService
+	validator  *SchemaValidator
+	ids        IDGenerator
+	logger     *log.Logger
+}
+
+// NewProposalService creates a change proposal service. Approved changes
+// are applied through the resource service and recorded in the ledger.
+func NewProposalService(store ProposalStore, resources ResourceService, blockchain BlockchainService, validator *SchemaValidator, logger *log.Logger) ProposalService {
+	return &proposalService{
+		store:      store,
+		resources:  resources,
+		blockchain: blockchain,
+		validator:  validator,
+		ids:        NewHashIDGenerator("proposal"),
+		logger:     logger,
+	}
+}
+
+// ProposeCreate records a proposal to create a resource, after checking it
+// the way creating it would
+func (s *proposalService) ProposeCreate(ctx context.Context, req models.CreateResourceRequest, reason, actor string) (*models.ChangeProposal, error) {
+	// The approver becomes the creator when the proposal is applied
+	req.Metadata.CreatedBy, req.Metadata.ModifiedBy = actor, actor
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if req.ParentID != nil {
+		if err := s.checkParent(ctx, "", *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	resource := req.ToResource()
+	// The ID is only derived when the resource is created
+	resource.ID = "proposed"
+	if err := resource.Validate(); err != nil {
+		return nil, fmt.Errorf("resource validation failed: %w", err)
+	}
+	if err := s.validator.Check(ctx, resource); err != nil {
+		return nil, err
+	}
+
+	proposal := &models.ChangeProposal{
+		Operation: "CREATE",
+		Create:    &req,
+		Diff:      resourceDiff(nil, resource),
+		Warnings:  resource.Warnings,
+	}
+	return s.propose(ctx, proposal, reason, actor)
+}
+
+// ProposeUpdate records a proposal to update a resource, with the diff of a
+// dry run of the update against the resource as it is now
+func (s *proposalService) ProposeUpdate(ctx context.Context, id string, req models.UpdateResourceRequest, reason, actor string) (*models.ChangeProposal, error) {
+	current, err := s.resources.GetResource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if err := s.checkParent(ctx, current.ID, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	updated, err := cloneResource(current)
+	if err != nil {
+		return nil, err
+	}
+	req.ApplyTo(updated, actor)
+	if err := updated.Validate(); err != nil {
+		return nil, fmt.Errorf("updated resource validation failed: %w", err)
+	}
+	if err := s.validator.Check(ctx, updated); err != nil {
+		return nil, err
+	}
+
+	diff := resourceDiff(current, updated)
+	if len(diff) == 0 {
+		return nil, fmt.Errorf("validation failed: the change leaves resource %s as it is", current.ID)
+	}
+	base := current.ModifiedAt
+	proposal := &models.ChangeProposal{
+		Operation:      "UPDATE",
+		ResourceID:     current.ID,
+		Update:         &req,
+		Diff:           diff,
+		Warnings:       updated.Warnings,
+		BaseModifiedAt: &base,
+	}
+	return s.propose(ctx, proposal, reason, actor)
+}
+
+func (s *proposalService) propose(ctx context.Context, proposal *models.ChangeProposal, reason, actor string) (*models.ChangeProposal, error) {
+	proposal.ID = s.ids.Generate()
+	proposal.Reason = strings.TrimSpace(reason)
+	proposal.Status = models.ProposalStatusPending
+	proposal.ProposedBy = actor
+	proposal.CreatedAt = time.Now().UTC()
+	if err := proposal.Validate(); err != nil {
+		return nil, fmt.Errorf("proposal validation failed: %w", err)
+	}
+
+	if err := s.store.Create(ctx, proposal); err != nil {
+		return nil, fmt.Errorf("failed to create change proposal: %w", err)
+	}
+	s.logger.Printf("Change proposal %s (%s %s) made by %s", proposal.ID, proposal.Operation, proposal.ResourceID, actor)
+	return proposal, nil
+}
+
+// checkParent checks that a proposed parent exists and is not the resource
+// itself or one of its descendants
+func (s *proposalService) checkParent(ctx context.Context, id, parentID string) error {
+	if strings.TrimSpace(parentID) == "" {
+		return errors.New("validation failed: parent_id must name a resource")
+	}
+	ancestor := parentID
+	for depth := 0; depth < maxParentDepth; depth++ {
+		if ancestor == id {
+			return fmt.Errorf("validation failed: %s cannot be the parent of %s, which would be its own ancestor", parentID, id)
+		}
+		resource, err := s.resources.GetResource(ctx, ancestor)
+		if err != nil {
+			return fmt.Errorf("parent %s: %w", ancestor, err)
+		}
+		if !resource.HasParent() {
+			return nil
+		}
+		ancestor = *resource.ParentID
+	}
+	return fmt.Errorf("validation failed: the ancestors of %s are nested deeper than %d", parentID, maxParentDepth)
+}
+
+func (s *proposalService) GetProposal(ctx context.Context, id string) (*models.ChangeProposal, error) {
+	proposal, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change proposal: %w", err)
+	}
+	return proposal, nil
+}
+
+func (s *proposalService) ListProposals(ctx context.Context, status string) ([]models.ChangeProposal, error) {
+	proposals, err := s.store.List(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change proposals: %w", err)
+	}
+	return proposals, nil
+}
+
+// ApproveProposal applies a pending proposal on behalf of the approver and
+// records the change in the ledger with both the approver and the proposer.
+// An update is not applied when the resource changed after the dry run; a
+// proposal that fails to apply is marked failed. Once the change is applied
+// the approval succeeds: a failure to record it in the ledger is kept in the
+// applied proposal's error rather than returned.
+func (s *proposalService) ApproveProposal(ctx context.Context, id, approver, note string) (*models.ChangeProposal, error) {
+	proposal, err := s.pending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if approver == proposal.ProposedBy {
+		return nil, fmt.Errorf("%w: %s", ErrProposalSelfApproval, approver)
+	}
+
+	// Claim the proposal so that concurrent approvals apply it only once
+	now := time.Now().UTC()
+	proposal.Status = models.ProposalStatusApproved
+	proposal.ReviewedBy, proposal.ReviewNote, proposal.ReviewedAt = approver, note, &now
+	if err := s.review(ctx, proposal, models.ProposalStatusPending); err != nil {
+		return nil, err
+	}
+
+	if applyErr := s.apply(ctx, proposal, approver); applyErr != nil {
+		proposal.Status, proposal.Error = models.ProposalStatusFailed, applyErr.Error()
+		if err := s.review(ctx, proposal, models.ProposalStatusApproved); err != nil {
+			return nil, err
+		}
+		return proposal, fmt.Errorf("failed to apply change proposal %s: %w", proposal.ID, applyErr)
+	}
+
+	proposal.Status = models.ProposalStatusApplied
+	if err := s.record(ctx, proposal, approver); err != nil {
+		s.logger.Printf("Change proposal %s was applied but not recorded: %v", proposal.ID, err)
+		proposal.Error = fmt.Sprintf("applied but not recorded in the change ledger: %v", err)
+	}
+	if err := s.review(ctx, proposal, models.ProposalStatusApproved); err != nil {
+		s.logger.Printf("Change proposal %s was applied but its status was not saved: %v", proposal.ID, err)
+	}
+
+	s.logger.Printf("Change proposal %s approved by %s and applied to %s", proposal.ID, approver, proposal.ResourceID)
+	return proposal, nil
+}
+
+// record appends the change an approved proposal applied to the ledger
+func (s *proposalService) record(ctx context.Context, proposal *models.ChangeProposal, approver string) error {
+	changes := make(map[string]interface{}, len(proposal.Diff)+4)
+	for field, change := range proposal.Diff {
+		changes[field] = change
+	}
+	changes["source"] = "proposal"
+	changes["proposal_id"] = proposal.ID
+	changes["proposed_by"] = proposal.ProposedBy
+	changes["approved_by"] = approver
+	return s.blockchain.RecordChange(ctx, proposal.ResourceID, proposal.Operation, approver, changes)
+}
+
+// apply makes the change a proposal describes
+func (s *proposalService) apply(ctx context.Context, proposal *models.ChangeProposal, approver string) error {
+	if proposal.Operation == "CREATE" {
+		req := *proposal.Create
+		req.Metadata.CreatedBy, req.Metadata.ModifiedBy = approver, approver
+		resource, err := s.resources.CreateResource(ctx, &req)
+		if err != nil {
+			return err
+		}
+		proposal.ResourceID = resource.ID
+		return nil
+	}
+
+	current, err := s.resources.GetResource(ctx, proposal.ResourceID)
+	if err != nil {
+		return err
+	}
+	if proposal.BaseModifiedAt != nil && !current.ModifiedAt.Equal(*proposal.BaseModifiedAt) {
+		return fmt.Errorf("%w: %s was modified at %s; propose the change again", ErrProposalStale,
+			current.ID, current.ModifiedAt.Format(time.RFC3339))
+	}
+	_, err = s.resources.UpdateResource(ctx, proposal.ResourceID, *proposal.Update, approver)
+	return err
+}
+
+// RejectProposal closes a pending proposal without applying it
+func (s *proposalService) RejectProposal(ctx context.Context, id, reviewer, note string) (*models.ChangeProposal, error) {
+	proposal, err := s.pending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	proposal.Status = models.ProposalStatusRejected
+	proposal.ReviewedBy, proposal.ReviewNote, proposal.ReviewedAt = reviewer, note, &now
+	if err := s.review(ctx, proposal, models.ProposalStatusPending); err != nil {
+		return nil, err
+	}
+	s.logger.Printf("Change proposal %s rejected by %s", proposal.ID, reviewer)
+	return proposal, nil
+}
+
+// pending returns a proposal that has not been reviewed yet
+func (s *proposalService) pending(ctx context.Context, id string) (*models.ChangeProposal, error) {
+	proposal, err := s.GetProposal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.Status != models.ProposalStatusPending {
+		return nil, fmt.Errorf("%w: %s is %s", ErrProposalNotPending, proposal.ID, proposal.Status)
+	}
+	return proposal, nil
+}
+
+// review stores a proposal's new status, provided it still has status from
+func (s *proposalService) review(ctx context.Context, proposal *models.ChangeProposal, from string) error {
+	err := s.store.UpdateStatus(ctx, proposal, from)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return fmt.Errorf("%w: %s was reviewed concurrently", ErrProposalNotPending, proposal.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update change proposal: %w", err)
+	}
+	return nil
+}
+
+// cloneResource returns a deep copy of a resource
+func cloneResource(resource *models.Resource) (*models.Resource, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy resource: %w", err)
+	}
+	var clone models.Resource
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy resource: %w", err)
+	}
+	return &clone, nil
+}
+
+// resourceDiff returns the fields that differ between two versions of a
+// resource as {"old", "new"} pairs keyed by their dotted path, such as
+// metadata.tags.env. A nil before diffs against nothing. Who modified the
+// resource, and when, is left out.
+func resourceDiff(before, after *models.Resource) map[string]interface{} {
+	oldFields, newFields := map[string]interface{}{}, map[string]interface{}{}
+	if before != nil {
+		flattenFields(oldFields, "", diffView(before))
+	}
+	flattenFields(newFields, "", diffView(after))
+
+	paths := make([]string, 0, len(oldFields)+len(newFields))
+	for path := range oldFields {
+		paths = append(paths, path)
+	}
+	for path := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	diff := map[string]interface{}{}
+	for _, path := range paths {
+		if !reflect.DeepEqual(oldFields[path], newFields[path]) {
+			diff[path] = map[string]interface{}{"old": oldFields[path], "new": newFields[path]}
+		}
+	}
+	return diff
+}
+
+// diffView returns the fields of a resource a change can set, decoded from
+// JSON so that both versions compare alike
+func diffView(resource *models.Resource) interface{} {
+	metadata := resource.Metadata
+	metadata.CreatedBy, metadata.ModifiedBy = "", ""
+	data, _ := json.Marshal(map[string]interface{}{
+		"name":      resource.Name,
+		"type":      resource.Type,
+		"provider":  resource.Provider,
+		"parent_id": resource.ParentID,
+		"data":      resource.Data,
+		"metadata":  metadata,
+	})
+	var view interface{}
+	_ = json.Unmarshal(data, &view)
+	return view
+}
+
+// flattenFields adds the leaves of a decoded JSON object to fields by their
+// dotted path; arrays are leaves
+func flattenFields(fields map[string]interface{}, prefix string, value interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		if value != nil && value != "" {
+			fields[prefix] = value
+		}
+		return
+	}
+	for key, child := range object {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		flattenFields(fields, path, child)
+	}
+}
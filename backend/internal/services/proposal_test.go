@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// fakeProposalStore keeps proposals in memory
+type fakeProposalStore struct {
+	proposals map[string]models.ChangeProposal
+}
+
+func (s *fakeProposalStore) Create(_ context.Context, proposal *models.ChangeProposal) error {
+	if s.proposals == nil {
+		s.proposals = map[string]models.ChangeProposal{}
+	}
+	s.proposals[proposal.ID] = *proposal
+	return nil
+}
+
+func (s *fakeProposalStore) GetByID(_ context.Context, id string) (*models.ChangeProposal, error) {
+	proposal, ok := s.proposals[id]
+	if !ok {
+		return nil, fmt.Errorf("change proposal %w: %s", repositories.ErrNotFound, id)
+	}
+	return &proposal, nil
+}
+
+func (s *fakeProposalStore) UpdateStatus(_ context.Context, proposal *models.ChangeProposal, from string) error {
+	if stored, ok := s.proposals[proposal.ID]; !ok || stored.Status != from {
+		return fmt.Errorf("%s change proposal %w: %s", from, repositories.ErrNotFound, proposal.ID)
+	}
+	s.proposals[proposal.ID] = *proposal
+	return nil
+}
+
+func (s *fakeProposalStore) List(_ context.Context, status string) ([]models.ChangeProposal, error) {
+	var proposals []models.ChangeProposal
+	for _, proposal := range s.proposals {
+		if status == "" || proposal.Status == status {
+			proposals = append(proposals, proposal)
+		}
+	}
+	return proposals, nil
+}
+
+// newTestProposalService serves proposals for a vpc r1 holding an instance
+// r2 tagged env=dev
+func newTestProposalService(t *testing.T) (ProposalService, *fakeMCPResources, *fakeChangeLedger) {
+	t.Helper()
+	parent := "r1"
+	metadata := models.ResourceMetadata{CreatedBy: "bob", ModifiedBy: "bob"}
+	tagged := metadata
+	tagged.Tags = map[string]string{"env": "dev"}
+	resources := &fakeMCPResources{resources: []models.Resource{
+		{ID: "r1", Provider: "aws", Type: "vpc", Name: "main", Metadata: metadata},
+		{ID: "r2", Provider: "aws", Type: "ec2", Name: "web", ParentID: &parent, Metadata: tagged},
+		{ID: "r3", Provider: "aws", Type: "vpc", Name: "spare", Metadata: metadata},
+	}}
+	logger := log.New(io.Discard, "", 0)
+	ledger := &fakeChangeLedger{}
+	service := NewProposalService(&fakeProposalStore{}, resources, NewBlockchainService(ledger, logger), nil, logger)
+	return service, resources, ledger
+}
+
+func TestProposalService_ApproveUpdate(t *testing.T) {
+	ctx := context.Background()
+	service, resources, ledger := newTestProposalService(t)
+
+	metadata := resources.resources[1].Metadata
+	metadata.Tags = map[string]string{"env": "prod", "team": "web"}
+	proposal, err := service.ProposeUpdate(ctx, "r2", models.UpdateResourceRequest{Metadata: &metadata}, "Tag for the web team", "mcp:assistant")
+	if err != nil {
+		t.Fatalf("ProposeUpdate: %v", err)
+	}
+	want := map[string]interface{}{
+		"metadata.tags.env":  map[string]interface{}{"old": "dev", "new": "prod"},
+		"metadata.tags.team": map[string]interface{}{"old": nil, "new": "web"},
+	}
+	if fmt.Sprint(proposal.Diff) != fmt.Sprint(want) || proposal.Status != models.ProposalStatusPending {
+		t.Errorf("proposal = %+v, want a pending proposal with diff %v", proposal, want)
+	}
+	if resources.resources[1].Metadata.Tags["env"] != "dev" || len(ledger.records) != 0 {
+		t.Fatalf("the proposal changed the resource before it was approved")
+	}
+
+	if _, err := service.ApproveProposal(ctx, proposal.ID, "mcp:assistant", ""); !errors.Is(err, ErrProposalSelfApproval) {
+		t.Errorf("self approval error = %v, want ErrProposalSelfApproval", err)
+	}
+
+	approved, err := service.ApproveProposal(ctx, proposal.ID, "alice", "looks right")
+	if err != nil {
+		t.Fatalf("ApproveProposal: %v", err)
+	}
+	if approved.Status != models.ProposalStatusApplied || approved.ReviewedBy != "alice" || approved.ReviewedAt == nil {
+		t.Errorf("approved proposal = %+v", approved)
+	}
+	if tags := resources.resources[1].Metadata.Tags; tags["env"] != "prod" || tags["team"] != "web" {
+		t.Errorf("tags after approval = %v", tags)
+	}
+	if by := resources.resources[1].Metadata.ModifiedBy; by != "alice" {
+		t.Errorf("resource modified by %s, want alice", by)
+	}
+
+	if len(ledger.records) != 1 {
+		t.Fatalf("ledger holds %d records, want 1", len(ledger.records))
+	}
+	record := ledger.records[0]
+	if record.ResourceID != "r2" || record.Operation != "UPDATE" || record.Actor != "alice" ||
+		record.Changes["proposed_by"] != "mcp:assistant" || record.Changes["approved_by"] != "alice" ||
+		record.Changes["proposal_id"] != proposal.ID || record.Changes["metadata.tags.team"] == nil {
+		t.Errorf("ledger record = %+v", record)
+	}
+
+	if _, err := service.ApproveProposal(ctx, proposal.ID, "carol", ""); !errors.Is(err, ErrProposalNotPending) {
+		t.Errorf("second approval error = %v, want ErrProposalNotPending", err)
+	}
+	if _, err := service.RejectProposal(ctx, proposal.ID, "carol", ""); !errors.Is(err, ErrProposalNotPending) {
+		t.Errorf("rejection after approval error = %v, want ErrProposalNotPending", err)
+	}
+}
+
+func TestProposalService_ApproveCreate(t *testing.T) {
+	ctx := context.Background()
+	service, resources, ledger := newTestProposalService(t)
+
+	parent := "r1"
+	req := models.CreateResourceRequest{
+		Provider: "custom",
+		Type:     "firewall",
+		Name:     "edge",
+		Data:     map[string]interface{}{"rules": 3},
+		ParentID: &parent,
+	}
+	proposal, err := service.ProposeCreate(ctx, req, "Track the on-premises firewall", "mcp:assistant")
+	if err != nil {
+		t.Fatalf("ProposeCreate: %v", err)
+	}
+	if diff, ok := proposal.Diff["data.rules"].(map[string]interface{}); !ok || diff["old"] != nil || diff["new"] != float64(3) {
+		t.Errorf("diff = %v", proposal.Diff)
+	}
+	if len(resources.resources) != 3 {
+		t.Fatalf("the proposal created the resource before it was approved")
+	}
+
+	approved, err := service.ApproveProposal(ctx, proposal.ID, "alice", "")
+	if err != nil {
+		t.Fatalf("ApproveProposal: %v", err)
+	}
+	created, err := resources.GetResource(ctx, approved.ResourceID)
+	if err != nil {
+		t.Fatalf("created resource: %v", err)
+	}
+	if created.Metadata.CreatedBy != "alice" || created.Provider != "custom" || *created.ParentID != "r1" {
+		t.Errorf("created resource = %+v", created)
+	}
+	if len(ledger.records) != 1 || ledger.records[0].Operation != "CREATE" || ledger.records[0].ResourceID != created.ID ||
+		ledger.records[0].Changes["proposed_by"] != "mcp:assistant" {
+		t.Errorf("ledger records = %+v", ledger.records)
+	}
+}
+
+func TestProposalService_Stale(t *testing.T) {
+	ctx := context.Background()
+	service, resources, ledger := newTestProposalService(t)
+
+	parent := "r3"
+	proposal, err := service.ProposeUpdate(ctx, "r2", models.UpdateResourceRequest{ParentID: &parent}, "Move to the spare VPC", "mcp:assistant")
+	if err != nil {
+		t.Fatalf("ProposeUpdate: %v", err)
+	}
+	name := "web-2"
+	if _, err := resources.UpdateResource(ctx, "r2", models.UpdateResourceRequest{Name: &name}, "bob"); err != nil {
+		t.Fatalf("UpdateResource: %v", err)
+	}
+
+	failed, err := service.ApproveProposal(ctx, proposal.ID, "alice", "")
+	if !errors.Is(err, ErrProposalStale) || failed == nil || failed.Status != models.ProposalStatusFailed || failed.Error == "" {
+		t.Errorf("approving a stale proposal = %+v, %v; want it failed with ErrProposalStale", failed, err)
+	}
+	if *resources.resources[1].ParentID != "r1" || len(ledger.records) != 0 {
+		t.Errorf("a stale proposal was applied")
+	}
+}
+
+func TestProposalService_ApproveUnrecorded(t *testing.T) {
+	ctx := context.Background()
+	service, resources, ledger := newTestProposalService(t)
+
+	name := "web-2"
+	proposal, err := service.ProposeUpdate(ctx, "r2", models.UpdateResourceRequest{Name: &name}, "Rename", "mcp:assistant")
+	if err != nil {
+		t.Fatalf("ProposeUpdate: %v", err)
+	}
+	ledger.fail = errors.New("ledger unavailable")
+
+	// The change is applied, so the approval succeeds and reports the ledger failure
+	approved, err := service.ApproveProposal(ctx, proposal.ID, "alice", "")
+	if err != nil {
+		t.Fatalf("ApproveProposal: %v", err)
+	}
+	if approved.Status != models.ProposalStatusApplied || approved.Error == "" || resources.resources[1].Name != "web-2" {
+		t.Errorf("approved proposal = %+v, want it applied with the ledger error", approved)
+	}
+	if stored, _ := service.GetProposal(ctx, proposal.ID); stored.Status != models.ProposalStatusApplied || stored.Error != approved.Error {
+		t.Errorf("stored proposal = %+v, want it applied with the ledger error", stored)
+	}
+}
+
+func TestProposalService_Reject(t *testing.T) {
+	ctx := context.Background()
+	service, resources, _ := newTestProposalService(t)
+
+	name := "api"
+	proposal, err := service.ProposeUpdate(ctx, "r2", models.UpdateResourceRequest{Name: &name}, "Rename", "mcp:assistant")
+	if err != nil {
+		t.Fatalf("ProposeUpdate: %v", err)
+	}
+	rejected, err := service.RejectProposal(ctx, proposal.ID, "alice", "keep the name")
+	if err != nil {
+		t.Fatalf("RejectProposal: %v", err)
+	}
+	if rejected.Status != models.ProposalStatusRejected || rejected.ReviewNote != "keep the name" || resources.resources[1].Name != "web" {
+		t.Errorf("rejected proposal = %+v, resource name %s", rejected, resources.resources[1].Name)
+	}
+
+	pending, err := service.ListProposals(ctx, models.ProposalStatusPending)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("pending proposals = %v, %v", pending, err)
+	}
+	if _, err := service.ApproveProposal(ctx, proposal.ID, "bob", ""); !errors.Is(err, ErrProposalNotPending) {
+		t.Errorf("approval after rejection error = %v, want ErrProposalNotPending", err)
+	}
+}
+
+func TestProposalService_ProposeErrors(t *testing.T) {
+	ctx := context.Background()
+	service, _, _ := newTestProposalService(t)
+
+	parentOf := func(id string) models.UpdateResourceRequest {
+		return models.UpdateResourceRequest{ParentID: &id}
+	}
+	name := "web"
+	for _, tc := range []struct {
+		name string
+		id   string
+		req  models.UpdateResourceRequest
+	}{
+		{"missing resource", "missing", parentOf("r1")},
+		{"missing parent", "r2", parentOf("missing")},
+		{"own parent", "r1", parentOf("r1")},
+		{"descendant parent", "r1", parentOf("r2")},
+		{"no change", "r2", models.UpdateResourceRequest{Name: &name}},
+	} {
+		if _, err := service.ProposeUpdate(ctx, tc.id, tc.req, "because", "mcp:assistant"); err == nil {
+			t.Errorf("%s: ProposeUpdate succeeded", tc.name)
+		}
+	}
+
+	if _, err := service.ProposeUpdate(ctx, "r2", parentOf("r3"), " ", "mcp:assistant"); err == nil {
+		t.Errorf("ProposeUpdate without a reason succeeded")
+	}
+	if _, err := service.ProposeCreate(ctx, models.CreateResourceRequest{Provider: "mainframe", Type: "lpar", Name: "a", Data: map[string]interface{}{}}, "because", "mcp:assistant"); err == nil {
+		t.Errorf("ProposeCreate with an unsupported provider succeeded")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/terraform"
 )
 
 // ResourceService defines the interface for resource business logic
@@ -14,8 +15,8 @@ type ResourceService interface {
 	GetResource(ctx context.Context, id string) (*models.Resource, error)
 	UpdateResource(ctx context.Context, id string, req models.UpdateResourceRequest, modifiedBy string) (*models.Resource, error)
 	DeleteResource(ctx context.Context, id string, deletedBy string) error
-	ListResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error)
-	SearchResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error)
+	ListResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
+	SearchResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
 	GetResourcesByParent(ctx context.Context, parentID string) ([]models.Resource, error)
 }
 
@@ -37,9 +38,14 @@ type BlockchainService interface {
 type SchemaService interface {
 	CreateSchema(ctx context.Context, schema *models.Schema) error
 	GetSchema(ctx context.Context, name, provider string) (*models.Schema, error)
-	ListSchemas(ctx context.Context, provider string) ([]models.Schema, error)
+	ListSchemas(ctx context.Context, query *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error)
 	UpdateSchema(ctx context.Context, name, provider string, schema *models.Schema) error
 	DeleteSchema(ctx context.Context, name, provider string) error
+	ValidateData(ctx context.Context, name, provider string, data map[string]interface{}) (*models.SchemaValidationResult, error)
+	ListVersions(ctx context.Context, name, provider string) ([]models.Schema, error)
+	CompareVersions(ctx context.Context, name, provider, from, to string) (*models.SchemaCompatibility, error)
+	MigrateResources(ctx context.Context, name, provider string, req *models.SchemaMigrationRequest, actor string) (*models.SchemaMigrationReport, error)
+	ImportTerraformSchemas(ctx context.Context, schemas *terraform.ProviderSchemas, dryRun bool) (*models.SchemaCatalogReport, error)
 }
 
 // TerraformService defines the interface for Terraform operations
@@ -56,10 +62,11 @@ type ResourceRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Resource, error)
 	Update(ctx context.Context, resource *models.Resource) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error)
-	Search(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error)
+	List(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
+	Search(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
 	GetByParentID(ctx context.Context, parentID string) ([]models.Resource, error)
 	VectorSearch(ctx context.Context, vector []float32, threshold float32, limit int) ([]models.Resource, error)
+	UpsertBatch(ctx context.Context, resources []models.Resource) error
 }
 
 // BlockchainRepository defines the interface for blockchain data access
@@ -67,13 +74,14 @@ type BlockchainRepository interface {
 	CreateRecord(ctx context.Context, record *models.ChangeRecord) error
 	GetRecordsByResourceID(ctx context.Context, resourceID string) ([]models.ChangeRecord, error)
 	GetLatestRecord(ctx context.Context, resourceID string) (*models.ChangeRecord, error)
+	ListRecords(ctx context.Context, query *models.ChangeQuery) ([]models.ChangeRecord, *models.PageInfo, error)
 }
 
 // SchemaRepository defines the interface for schema data access
 type SchemaRepository interface {
 	Create(ctx context.Context, schema *models.Schema) error
 	GetByNameAndProvider(ctx context.Context, name, provider string) (*models.Schema, error)
-	List(ctx context.Context, provider string) ([]models.Schema, error)
+	List(ctx context.Context, query *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error)
 	Update(ctx context.Context, schema *models.Schema) error
 	Delete(ctx context.Context, name, provider string) error
 }
@@ -266,34 +274,34 @@ func (s *resourceService) DeleteResource(ctx context.Context, id, deletedBy stri
 	return nil
 }
 
-func (s *resourceService) ListResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error) {
+func (s *resourceService) ListResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
 	// Validate and set defaults
 	if err := query.Validate(); err != nil {
-		return nil, fmt.Errorf("query validation failed: %w", err)
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
 	}
 	query.SetDefaults()
 
-	resources, err := s.resourceRepo.List(ctx, query)
+	resources, page, err := s.resourceRepo.List(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list resources: %w", err)
+		return nil, nil, fmt.Errorf("failed to list resources: %w", err)
 	}
 
-	return resources, nil
+	return resources, page, nil
 }
 
-func (s *resourceService) SearchResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error) {
+func (s *resourceService) SearchResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
 	// Validate and set defaults
 	if err := query.Validate(); err != nil {
-		return nil, fmt.Errorf("query validation failed: %w", err)
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
 	}
 	query.SetDefaults()
 
-	resources, err := s.resourceRepo.Search(ctx, query)
+	resources, page, err := s.resourceRepo.Search(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search resources: %w", err)
+		return nil, nil, fmt.Errorf("failed to search resources: %w", err)
 	}
 
-	return resources, nil
+	return resources, page, nil
 }
 
 func (s *resourceService) GetResourcesByParent(ctx context.Context, parentID string) ([]models.Resource, error) {
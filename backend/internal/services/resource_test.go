@@ -51,15 +51,15 @@ func (m *mockResourceRepository) Delete(_ context.Context, id string) error {
 	return nil
 }
 
-func (m *mockResourceRepository) List(_ context.Context, _ *models.SearchQuery) ([]models.Resource, error) {
+func (m *mockResourceRepository) List(_ context.Context, _ *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
 	var result []models.Resource
 	for _, resource := range m.resources {
 		result = append(result, *resource)
 	}
-	return result, nil
+	return result, &models.PageInfo{}, nil
 }
 
-func (m *mockResourceRepository) Search(_ context.Context, _ *models.SearchQuery) ([]models.Resource, error) {
+func (m *mockResourceRepository) Search(_ context.Context, _ *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
 	return m.List(context.Background(), &models.SearchQuery{})
 }
 
@@ -74,7 +74,21 @@ func (m *mockResourceRepository) GetByParentID(_ context.Context, parentID strin
 }
 
 func (m *mockResourceRepository) VectorSearch(_ context.Context, _ []float32, _ float32, _ int) ([]models.Resource, error) {
-	return []models.Resource{}, nil
+	result := []models.Resource{}
+	for _, resource := range m.resources {
+		if len(resource.Vector) > 0 {
+			result = append(result, *resource)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockResourceRepository) UpsertBatch(_ context.Context, resources []models.Resource) error {
+	for i := range resources {
+		resource := resources[i]
+		m.resources[resource.ID] = &resource
+	}
+	return nil
 }
 
 type mockVectorService struct{}
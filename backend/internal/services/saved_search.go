@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// SavedSearchService manages named filter expressions and runs them
+type SavedSearchService interface {
+	CreateSearch(ctx context.Context, search *models.SavedSearch, actor string) error
+	GetSearch(ctx context.Context, id string) (*models.SavedSearch, error)
+	UpdateSearch(ctx context.Context, id string, search *models.SavedSearch, actor string) error
+	DeleteSearch(ctx context.Context, id string) error
+	ListSearches(ctx context.Context) ([]models.SavedSearch, error)
+	RunSearch(ctx context.Context, id string, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
+}
+
+// SavedSearchStore persists saved searches
+type SavedSearchStore interface {
+	Create(ctx context.Context, search *models.SavedSearch) error
+	GetByID(ctx context.Context, id string) (*models.SavedSearch, error)
+	Update(ctx context.Context, search *models.SavedSearch) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]models.SavedSearch, error)
+}
+
+// SavedSearchResources lists the resources a saved search matches
+type SavedSearchResources interface {
+	List(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error)
+}
+
+// savedSearchService implements SavedSearchService
+type savedSearchService struct {
+	store     SavedSearchStore
+	resources SavedSearchResources
+	ids       IDGenerator
+	logger    *log.Logger
+}
+
+// NewSavedSearchService creates a saved search service
+func NewSavedSearchService(store SavedSearchStore, resources SavedSearchResources, logger *log.Logger) SavedSearchService {
+	return &savedSearchService{
+		store:     store,
+		resources: resources,
+		ids:       NewHashIDGenerator("search"),
+		logger:    logger,
+	}
+}
+
+func (s *savedSearchService) CreateSearch(ctx context.Context, search *models.SavedSearch, actor string) error {
+	if err := search.Validate(); err != nil {
+		return fmt.Errorf("saved search validation failed: %w", err)
+	}
+
+	now := time.Now()
+	search.ID = s.ids.Generate()
+	search.CreatedBy, search.ModifiedBy = actor, actor
+	search.CreatedAt, search.ModifiedAt = now, now
+
+	if err := s.store.Create(ctx, search); err != nil {
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+	s.logger.Printf("Saved search %s (%s) created by %s", search.Name, search.ID, actor)
+	return nil
+}
+
+func (s *savedSearchService) GetSearch(ctx context.Context, id string) (*models.SavedSearch, error) {
+	search, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return search, nil
+}
+
+func (s *savedSearchService) UpdateSearch(ctx context.Context, id string, search *models.SavedSearch, actor string) error {
+	if err := search.Validate(); err != nil {
+		return fmt.Errorf("saved search validation failed: %w", err)
+	}
+
+	existing, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get saved search: %w", err)
+	}
+	search.ID = existing.ID
+	search.CreatedBy, search.CreatedAt = existing.CreatedBy, existing.CreatedAt
+	search.ModifiedBy, search.ModifiedAt = actor, time.Now()
+
+	if err := s.store.Update(ctx, search); err != nil {
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+	return nil
+}
+
+func (s *savedSearchService) DeleteSearch(ctx context.Context, id string) error {
+	existing, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get saved search: %w", err)
+	}
+	if err := s.store.Delete(ctx, existing.ID); err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	return nil
+}
+
+func (s *savedSearchService) ListSearches(ctx context.Context) ([]models.SavedSearch, error) {
+	searches, err := s.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// RunSearch returns a page of the resources matching a saved search and the
+// query's own filters, which narrow it further
+func (s *savedSearchService) RunSearch(ctx context.Context, id string, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	search, err := s.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+
+	query.Filter = joinFilters(search.Filter, query.Filter)
+	if err := query.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
+	}
+	query.SetDefaults()
+
+	resources, page, err := s.resources.List(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to run saved search: %w", err)
+	}
+	return resources, page, nil
+}
+
+// joinFilters joins filter expressions with AND, skipping empty ones
+func joinFilters(filters ...string) string {
+	var parts []string
+	for _, f := range filters {
+		if strings.TrimSpace(f) != "" {
+			parts = append(parts, "("+f+")")
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
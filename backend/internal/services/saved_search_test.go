@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// fakeSavedSearchStore keeps saved searches in memory
+type fakeSavedSearchStore struct {
+	searches map[string]models.SavedSearch
+}
+
+func (s *fakeSavedSearchStore) Create(_ context.Context, search *models.SavedSearch) error {
+	for _, existing := range s.searches {
+		if existing.Name == search.Name {
+			return fmt.Errorf("saved search %w: %s", repositories.ErrAlreadyExists, search.Name)
+		}
+	}
+	s.searches[search.ID] = *search
+	return nil
+}
+
+func (s *fakeSavedSearchStore) GetByID(_ context.Context, id string) (*models.SavedSearch, error) {
+	for _, search := range s.searches {
+		if search.ID == id || search.Name == id {
+			return &search, nil
+		}
+	}
+	return nil, fmt.Errorf("saved search %w: %s", repositories.ErrNotFound, id)
+}
+
+func (s *fakeSavedSearchStore) Update(_ context.Context, search *models.SavedSearch) error {
+	s.searches[search.ID] = *search
+	return nil
+}
+
+func (s *fakeSavedSearchStore) Delete(_ context.Context, id string) error {
+	delete(s.searches, id)
+	return nil
+}
+
+func (s *fakeSavedSearchStore) List(_ context.Context) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	for _, search := range s.searches {
+		searches = append(searches, search)
+	}
+	return searches, nil
+}
+
+// fakeSavedSearchResources records the last query it was asked to list
+type fakeSavedSearchResources struct {
+	query *models.SearchQuery
+}
+
+func (r *fakeSavedSearchResources) List(_ context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
+	r.query = query
+	return []models.Resource{{ID: "r1"}}, &models.PageInfo{}, nil
+}
+
+func TestSavedSearchService(t *testing.T) {
+	store := &fakeSavedSearchStore{searches: map[string]models.SavedSearch{}}
+	resources := &fakeSavedSearchResources{}
+	service := NewSavedSearchService(store, resources, log.New(io.Discard, "", 0))
+	ctx := context.Background()
+
+	search := &models.SavedSearch{Name: "prod-compute", Filter: `tags.env:prod AND type:ec2`}
+	if err := service.CreateSearch(ctx, search, "alice"); err != nil {
+		t.Fatalf("CreateSearch() error = %v", err)
+	}
+	if !strings.HasPrefix(search.ID, "search-") || search.CreatedBy != "alice" {
+		t.Errorf("Unexpected saved search %+v", search)
+	}
+
+	err := service.CreateSearch(ctx, &models.SavedSearch{Name: "prod-compute", Filter: `type:ec2`}, "bob")
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateSearch() of a taken name error = %v", err)
+	}
+	err = service.CreateSearch(ctx, &models.SavedSearch{Name: "broken", Filter: `type:`}, "bob")
+	if err == nil || !strings.Contains(err.Error(), "validation") {
+		t.Errorf("CreateSearch() of an invalid filter error = %v", err)
+	}
+
+	query := &models.SearchQuery{Filter: `region:eu-west-1`}
+	if _, _, err := service.RunSearch(ctx, "prod-compute", query); err != nil {
+		t.Fatalf("RunSearch() error = %v", err)
+	}
+	if want := `(tags.env:prod AND type:ec2) AND (region:eu-west-1)`; resources.query.Filter != want {
+		t.Errorf("RunSearch() filter = %s, want %s", resources.query.Filter, want)
+	}
+
+	update := &models.SavedSearch{Name: "prod-compute", Filter: `tags.env:prod`}
+	if err := service.UpdateSearch(ctx, search.ID, update, "bob"); err != nil {
+		t.Fatalf("UpdateSearch() error = %v", err)
+	}
+	if update.CreatedBy != "alice" || update.ModifiedBy != "bob" {
+		t.Errorf("UpdateSearch() attribution = %s/%s", update.CreatedBy, update.ModifiedBy)
+	}
+
+	if err := service.DeleteSearch(ctx, "prod-compute"); err != nil {
+		t.Fatalf("DeleteSearch() error = %v", err)
+	}
+	if _, err := service.GetSearch(ctx, search.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetSearch() after delete error = %v", err)
+	}
+}
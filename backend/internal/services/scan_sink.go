@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/providers"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// scanActor is recorded as the creator and modifier of scanned resources
+const scanActor = "scanner"
+
+// resourceSink persists scanned provider resources through batched upserts
+type resourceSink struct {
+	resourceRepo ResourceRepository
+	identity     *identity.Resolver
+}
+
+// NewResourceSink returns a sink that upserts the batches produced by
+// providers.Manager.StreamAll into the resource repository. Resources are
+// stored under the Siros IDs the resolver assigns their native IDs.
+func NewResourceSink(resourceRepo ResourceRepository, resolver *identity.Resolver) providers.ResourceSink {
+	return &resourceSink{resourceRepo: resourceRepo, identity: resolver}
+}
+
+func (s *resourceSink) UpsertResources(ctx context.Context, resources []types.Resource) error {
+	// Parents are resolved together with the batch. They are in the same
+	// account and region as their children, unless the child names the
+	// provider and account of a parent elsewhere, as Kubernetes clusters
+	// do for their EKS, GKE or AKS cluster.
+	refs := make([]identity.Ref, len(resources), 2*len(resources))
+	parents := make(map[int]int)
+	for i := range resources {
+		refs[i] = identity.ForResource(&resources[i]).Normalize()
+		if parentID := resources[i].ParentID; parentID != nil && *parentID != "" {
+			parents[i] = len(refs)
+			refs = append(refs, parentRef(refs[i], &resources[i]))
+		}
+	}
+	ids, err := s.identity.Resolve(ctx, refs)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]models.Resource, len(resources))
+	for i := range resources {
+		batch[i] = scannedResource(&resources[i])
+		batch[i].ID = ids[i]
+		if j, ok := parents[i]; ok {
+			batch[i].ParentID = &ids[j]
+		}
+	}
+	if err := s.resourceRepo.UpsertBatch(ctx, batch); err != nil {
+		return err
+	}
+	return s.identity.Register(ctx, ids[:len(resources)], refs[:len(resources)], "scan")
+}
+
+// parentRef returns the natural identifiers of a resource's parent, whose
+// native ID is the resource's parent ID
+func parentRef(child identity.Ref, resource *types.Resource) identity.Ref {
+	parent := identity.Ref{Provider: child.Provider, Account: child.Account, Region: child.Region, NativeID: *resource.ParentID}
+	if provider, _ := resource.Metadata[types.MetadataParentProvider].(string); provider != "" && provider != child.Provider {
+		account, _ := resource.Metadata[types.MetadataParentAccount].(string)
+		parent.Provider, parent.Account = provider, account
+	}
+	return parent.Normalize()
+}
+
+// scannedResource converts a provider resource to the stored model. Region,
+// tags, account and native ID become metadata; the provider's details, state
+// and ARN become data.
+func scannedResource(resource *types.Resource) models.Resource {
+	data := make(map[string]interface{}, len(resource.Metadata)+2)
+	for key, value := range resource.Metadata {
+		data[key] = value
+	}
+	if resource.State != "" {
+		data["state"] = string(resource.State)
+	}
+	if resource.ARN != "" {
+		data["arn"] = resource.ARN
+	}
+	if len(resource.Links) > 0 {
+		data["links"] = resource.Links
+	}
+
+	modifiedAt := time.Now().UTC()
+	if resource.LastScannedAt != nil {
+		modifiedAt = resource.LastScannedAt.UTC()
+	}
+	createdAt := resource.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = modifiedAt
+	}
+
+	return models.Resource{
+		ID:       resource.ID,
+		Type:     resource.Type,
+		Provider: resource.Provider,
+		Name:     resource.Name,
+		Data:     data,
+		Metadata: models.ResourceMetadata{
+			CreatedBy:  scanActor,
+			ModifiedBy: scanActor,
+			Tags:       resource.Tags,
+			Region:     resource.Region,
+			NativeID:   resource.ID,
+			Account:    identity.ForResource(resource).Normalize().Account,
+		},
+		Vector:     resource.Vector,
+		ParentID:   resource.ParentID,
+		CreatedAt:  createdAt,
+		ModifiedAt: modifiedAt,
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// fakeAliasStore keeps aliases in memory
+type fakeAliasStore struct {
+	aliases []models.ResourceAlias
+}
+
+func (s *fakeAliasStore) FindAliases(_ context.Context, values []string) ([]models.ResourceAlias, error) {
+	var found []models.ResourceAlias
+	for _, alias := range s.aliases {
+		for _, value := range values {
+			if alias.Alias == value {
+				found = append(found, alias)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// AddAliases keeps existing aliases unchanged, like the repository
+func (s *fakeAliasStore) AddAliases(_ context.Context, aliases []models.ResourceAlias) error {
+	for _, alias := range aliases {
+		found := false
+		for _, stored := range s.aliases {
+			found = found || (stored.Provider == alias.Provider && stored.Account == alias.Account && stored.Region == alias.Region && stored.Alias == alias.Alias)
+		}
+		if !found {
+			s.aliases = append(s.aliases, alias)
+		}
+	}
+	return nil
+}
+
+func TestResourceSink_UpsertResources(t *testing.T) {
+	repo := newMockResourceRepository()
+	aliases := &fakeAliasStore{}
+	sink := NewResourceSink(repo, identity.NewResolver(aliases))
+
+	scannedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	parentID := "vpc-1"
+	scanned := types.Resource{
+		ID:            "i-1",
+		Type:          "aws.ec2.instance",
+		Provider:      "aws",
+		Name:          "web",
+		Region:        "eu-west-1",
+		ARN:           "arn:aws:ec2:eu-west-1:123:instance/i-1",
+		Tags:          map[string]string{"env": "prod"},
+		Metadata:      map[string]interface{}{"instance_type": "t3.micro"},
+		State:         types.ResourceStateActive,
+		ParentID:      &parentID,
+		LastScannedAt: &scannedAt,
+	}
+	if err := sink.UpsertResources(t.Context(), []types.Resource{scanned}); err != nil {
+		t.Fatalf("UpsertResources failed: %v", err)
+	}
+
+	id := identity.ForResource(&scanned).ID()
+	stored := repo.resources[id]
+	if stored == nil {
+		t.Fatalf("Expected the resource to be stored under %s", id)
+	}
+	if stored.Metadata.Region != "eu-west-1" || stored.Metadata.Tags["env"] != "prod" || stored.Metadata.NativeID != "i-1" {
+		t.Errorf("Expected region, tags and native ID in metadata, got %+v", stored.Metadata)
+	}
+	if stored.Data["state"] != "active" || stored.Data["arn"] == nil || stored.Data["instance_type"] != "t3.micro" {
+		t.Errorf("Unexpected data: %+v", stored.Data)
+	}
+	parentRef := identity.Ref{Provider: "aws", Account: "123", Region: "eu-west-1", NativeID: parentID}
+	if !stored.ModifiedAt.Equal(scannedAt) || stored.ParentID == nil || *stored.ParentID != parentRef.ID() {
+		t.Errorf("Unexpected resource: %+v", stored)
+	}
+	if len(aliases.aliases) != 2 || aliases.aliases[0].ResourceID != id || aliases.aliases[0].Source != "scan" {
+		t.Errorf("Expected the native ID and ARN as aliases, got %+v", aliases.aliases)
+	}
+
+	// A resource already known under another ID keeps it
+	aliases.aliases = []models.ResourceAlias{{Provider: "aws", Alias: "i-1", ResourceID: "legacy"}}
+	if err := sink.UpsertResources(t.Context(), []types.Resource{scanned}); err != nil {
+		t.Fatalf("UpsertResources failed: %v", err)
+	}
+	if repo.resources["legacy"] == nil {
+		t.Error("Expected the aliased resource to be updated")
+	}
+}
+
+func TestResourceSink_CrossProviderParents(t *testing.T) {
+	repo := newMockResourceRepository()
+	sink := NewResourceSink(repo, identity.NewResolver(&fakeAliasStore{}))
+
+	gkeID := "//container.googleapis.com/projects/web-prod/locations/us-central1/clusters/prod"
+	clouds := []types.Resource{
+		{ID: "prod", Type: "eks.cluster", Provider: "aws", Region: "eu-west-1", ARN: "arn:aws:eks:eu-west-1:111111111111:cluster/prod",
+			Metadata: map[string]interface{}{"account_id": "111111111111"}},
+		{ID: gkeID, Type: "container.cluster", Provider: "gcp", Region: "us-central1"},
+	}
+	if err := sink.UpsertResources(t.Context(), clouds); err != nil {
+		t.Fatalf("UpsertResources failed: %v", err)
+	}
+
+	eks, gke := "prod", gkeID
+	clusters := []types.Resource{
+		{ID: "k8s:eks", Type: "kubernetes.cluster", Provider: "kubernetes", Region: "eu-west-1", ParentID: &eks,
+			Metadata: map[string]interface{}{types.MetadataParentProvider: "aws", types.MetadataParentAccount: "111111111111"}},
+		{ID: "k8s:gke", Type: "kubernetes.cluster", Provider: "kubernetes", Region: "us-central1", ParentID: &gke,
+			Metadata: map[string]interface{}{types.MetadataParentProvider: "gcp", types.MetadataParentAccount: "web-prod"}},
+	}
+	if err := sink.UpsertResources(t.Context(), clusters); err != nil {
+		t.Fatalf("UpsertResources failed: %v", err)
+	}
+
+	for i := range clusters {
+		stored := repo.resources[identity.ForResource(&clusters[i]).ID()]
+		want := identity.ForResource(&clouds[i]).ID()
+		if stored == nil || stored.ParentID == nil || *stored.ParentID != want {
+			t.Errorf("Expected %s parented to %s, got %+v", clusters[i].ID, want, stored)
+		}
+	}
+}
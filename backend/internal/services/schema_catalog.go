@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+	"github.com/LederWorks/siros/backend/internal/terraform"
+)
+
+// ImportTerraformSchemas registers the predefined schemas generated from
+// Terraform provider schemas. A schema is versioned by its provider's
+// selected version when known, and by the next major version otherwise; a
+// version identical to the latest is left alone. Types that already have a
+// schema under another name are skipped, and a dry run reports without
+// writing.
+func (s *schemaService) ImportTerraformSchemas(ctx context.Context, schemas *terraform.ProviderSchemas, dryRun bool) (*models.SchemaCatalogReport, error) {
+	if err := schemas.Validate(); err != nil {
+		return nil, fmt.Errorf("schema validation failed: %w", err)
+	}
+	catalog, err := schemas.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("schema validation failed: %w", err)
+	}
+
+	report := &models.SchemaCatalogReport{DryRun: dryRun, Schemas: []models.SchemaCatalogEntry{}}
+	for i := range catalog {
+		entry, err := s.importCatalogEntry(ctx, &catalog[i], dryRun)
+		if err != nil {
+			return nil, err
+		}
+
+		switch entry.Status {
+		case models.SchemaCatalogCreated:
+			report.Created++
+		case models.SchemaCatalogUpdated:
+			report.Updated++
+		case models.SchemaCatalogUnchanged:
+			report.Unchanged++
+			continue
+		case models.SchemaCatalogSkipped:
+			report.Skipped++
+		case models.SchemaCatalogFailed:
+			report.Failed++
+		}
+		report.Schemas = append(report.Schemas, *entry)
+	}
+
+	s.logger.Printf("Imported Terraform schemas: %d created, %d updated, %d unchanged, %d skipped, %d failed (dry run: %t)",
+		report.Created, report.Updated, report.Unchanged, report.Skipped, report.Failed, dryRun)
+	return report, nil
+}
+
+// importCatalogEntry registers one catalog schema, returning an error only
+// when the schemas cannot be read
+func (s *schemaService) importCatalogEntry(ctx context.Context, item *terraform.CatalogEntry, dryRun bool) (*models.SchemaCatalogEntry, error) {
+	schema := item.Schema
+	entry := &models.SchemaCatalogEntry{
+		Name:           schema.Name,
+		Provider:       schema.Provider,
+		Type:           schema.Type,
+		TerraformTypes: item.TerraformTypes,
+	}
+	fail := func(err error) (*models.SchemaCatalogEntry, error) {
+		entry.Status, entry.Error = models.SchemaCatalogFailed, err.Error()
+		return entry, nil
+	}
+
+	existing, err := s.schemaRepo.GetForType(ctx, schema.Provider, schema.Type, "")
+	switch {
+	case err == nil && existing.Name != schema.Name:
+		entry.Status = models.SchemaCatalogSkipped
+		entry.Error = fmt.Sprintf("%s resources are described by schema %s", schema.Type, existing.Name)
+		return entry, nil
+	case err != nil && !errors.Is(err, repositories.ErrNotFound):
+		return nil, err
+	}
+
+	versions, err := s.schemaRepo.ListVersions(ctx, schema.Name, schema.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		schema.Version = "1"
+		if _, err := models.ParseSchemaVersion(item.Version); err == nil {
+			schema.Version = item.Version
+		}
+		entry.Status, entry.Version = models.SchemaCatalogCreated, schema.Version
+		if dryRun {
+			return entry, nil
+		}
+		if err := s.CreateSchema(ctx, &schema); err != nil {
+			return fail(err)
+		}
+		return entry, nil
+	}
+
+	latest := versions[len(versions)-1]
+	entry.PreviousVersion = latest.Version
+	if err := checkCustom(&latest, &schema); err != nil {
+		return fail(err)
+	}
+	if sameJSON(latest.Schema, schema.Schema) {
+		entry.Status, entry.Version = models.SchemaCatalogUnchanged, latest.Version
+		return entry, nil
+	}
+
+	schema.Version = nextCatalogVersion(latest.Version, item.Version)
+	entry.Version = schema.Version
+	if models.CompareSchemaVersions(schema.Version, latest.Version) <= 0 {
+		return fail(fmt.Errorf("provider version %s is not greater than the latest version %s", schema.Version, latest.Version))
+	}
+	compatibility, changes, err := jsonschema.Compare(latest.Schema, schema.Schema)
+	if err != nil {
+		return fail(fmt.Errorf("failed to compare schema versions: %w", err))
+	}
+	entry.Status = models.SchemaCatalogUpdated
+	entry.Compatibility, entry.Changes = string(compatibility), changes
+	if dryRun {
+		return entry, nil
+	}
+	if err := s.CreateSchema(ctx, &schema); err != nil {
+		return fail(err)
+	}
+	return entry, nil
+}
+
+// nextCatalogVersion is the provider's version, or without a valid one the
+// major version after latest
+func nextCatalogVersion(latest, providerVersion string) string {
+	if _, err := models.ParseSchemaVersion(providerVersion); err == nil {
+		return providerVersion
+	}
+	parts, err := models.ParseSchemaVersion(latest)
+	if err != nil {
+		return "1"
+	}
+	return strconv.Itoa(parts[0] + 1)
+}
+
+// sameJSON reports whether two documents encode to the same JSON
+func sameJSON(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/terraform"
+)
+
+// awsProviderSchemas returns provider schemas with an aws_instance of the
+// given attributes and an aws_s3_bucket
+func awsProviderSchemas(version string, attributes map[string]terraform.Attribute) *terraform.ProviderSchemas {
+	const source = "registry.terraform.io/hashicorp/aws"
+	schemas := &terraform.ProviderSchemas{
+		FormatVersion: "1.0",
+		Schemas: map[string]terraform.ProviderSchema{source: {ResourceSchemas: map[string]terraform.ResourceSchema{
+			"aws_instance":  {Block: terraform.Block{Attributes: attributes}},
+			"aws_s3_bucket": {Block: terraform.Block{Attributes: map[string]terraform.Attribute{"bucket": {Type: []byte(`"string"`), Optional: true}}}},
+		}}},
+	}
+	if version != "" {
+		schemas.Selections = map[string]string{source: version}
+	}
+	return schemas
+}
+
+func TestSchemaService_ImportTerraformSchemas(t *testing.T) {
+	repo := &fakeSchemaRepo{}
+	service := newTestSchemaService(repo, newFakeImportStore())
+	ctx := context.Background()
+
+	bucket := diskSchema("1", nil)
+	bucket.Name, bucket.Provider, bucket.Type, bucket.IsCustom = "buckets", "aws", "s3.bucket", true
+	repo.versions = append(repo.versions, *bucket)
+
+	ami := map[string]terraform.Attribute{"ami": {Type: []byte(`"string"`), Optional: true}}
+	report, err := service.ImportTerraformSchemas(ctx, awsProviderSchemas("5.31.0", ami), false)
+	if err != nil {
+		t.Fatalf("ImportTerraformSchemas() error = %v", err)
+	}
+	if report.Created != 1 || report.Skipped != 1 || len(report.Schemas) != 2 {
+		t.Fatalf("ImportTerraformSchemas() = %+v, want 1 created and the custom bucket type skipped", report)
+	}
+	instance, err := service.GetSchema(ctx, "aws_instance", "aws")
+	if err != nil || instance.Version != "5.31.0" || instance.Type != "ec2.instance" || instance.IsCustom {
+		t.Fatalf("GetSchema(aws_instance) = %+v, %v; want predefined version 5.31.0", instance, err)
+	}
+
+	report, err = service.ImportTerraformSchemas(ctx, awsProviderSchemas("5.31.0", ami), false)
+	if err != nil || report.Unchanged != 1 || report.Skipped != 1 || len(report.Schemas) != 1 {
+		t.Fatalf("ImportTerraformSchemas() again = %+v, %v; want the instance unchanged", report, err)
+	}
+
+	required := map[string]terraform.Attribute{"ami": {Type: []byte(`"string"`), Required: true}}
+	report, err = service.ImportTerraformSchemas(ctx, awsProviderSchemas("5.32.0", required), true)
+	if err != nil || report.Updated != 1 || len(repo.versions) != 2 {
+		t.Fatalf("ImportTerraformSchemas() dry run = %+v, %v; want an update and nothing written", report, err)
+	}
+	entry := report.Schemas[0]
+	if entry.Version != "5.32.0" || entry.PreviousVersion != "5.31.0" || entry.Compatibility != "forward" || len(entry.Changes) == 0 {
+		t.Errorf("ImportTerraformSchemas() dry run entry = %+v, want a forward update with changes", entry)
+	}
+
+	if _, err := service.ImportTerraformSchemas(ctx, awsProviderSchemas("", required), false); err != nil {
+		t.Fatalf("ImportTerraformSchemas() without a provider version error = %v", err)
+	}
+	if instance, _ := service.GetSchema(ctx, "aws_instance", "aws"); instance.Version != "6" {
+		t.Errorf("GetSchema(aws_instance) version = %s, want the next major version 6", instance.Version)
+	}
+
+	report, err = service.ImportTerraformSchemas(ctx, awsProviderSchemas("5.33.0", ami), false)
+	if err != nil || report.Failed != 1 || report.Schemas[0].Status != models.SchemaCatalogFailed {
+		t.Errorf("ImportTerraformSchemas() with an older provider version = %+v, %v; want a failure", report, err)
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// schemaMigrationBatchSize is the number of resources upgraded per
+// transaction
+const schemaMigrationBatchSize = 500
+
+// SchemaMigrationStore is the resource storage a schema migration reads and
+// upgrades
+type SchemaMigrationStore interface {
+	Stream(ctx context.Context, query *models.SearchQuery, fn func(*models.Resource) error) error
+	UpsertBatch(ctx context.Context, resources []models.Resource) error
+}
+
+// SchemaMigrationLedger records the changes made by a schema migration
+type SchemaMigrationLedger interface {
+	CreateRecords(ctx context.Context, records []models.ChangeRecord) error
+	GetLatestRecords(ctx context.Context, resourceIDs []string) (map[string]models.ChangeRecord, error)
+}
+
+// migratedResource is an upgraded resource waiting to be written
+type migratedResource struct {
+	resource models.Resource
+	from     string
+	applied  []string
+}
+
+// MigrateResources upgrades the resources of a schema's type to a version,
+// applying the transform of every version after the one each resource is
+// pinned to. Resources without a pin predate versioning and are upgraded
+// from the first version. The upgraded data must satisfy the target version
+// unless its mode is warn or off, and every upgraded resource is recorded
+// in the change ledger.
+func (s *schemaService) MigrateResources(ctx context.Context, name, provider string, req *models.SchemaMigrationRequest, actor string) (*models.SchemaMigrationReport, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("migration validation failed: %w", err)
+	}
+	if s.resources == nil || s.ledger == nil {
+		return nil, errors.New("schema migrations are not configured")
+	}
+
+	versions, err := s.ListVersions(ctx, name, provider)
+	if err != nil {
+		return nil, err
+	}
+	target := len(versions) - 1
+	if req.To != "" {
+		if target = schemaVersionIndex(versions, req.To); target < 0 {
+			return nil, fmt.Errorf("schema %w: %s version %s", repositories.ErrNotFound, name, req.To)
+		}
+	}
+	if req.From != "" && schemaVersionIndex(versions, req.From) < 0 {
+		return nil, fmt.Errorf("schema %w: %s version %s", repositories.ErrNotFound, name, req.From)
+	}
+	to := &versions[target]
+
+	report := &models.SchemaMigrationReport{
+		Schema:   to.Name,
+		Provider: to.Provider,
+		Type:     to.Type,
+		To:       to.Version,
+		DryRun:   req.DryRun,
+	}
+	var batch []migratedResource
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.writeMigrated(ctx, batch, to, actor)
+		batch = batch[:0]
+		return err
+	}
+
+	query := &models.SearchQuery{Provider: to.Provider, Type: to.Type}
+	err = s.resources.Stream(ctx, query, func(resource *models.Resource) error {
+		report.Scanned++
+
+		from := resource.Metadata.SchemaVersion
+		current := 0
+		if from != "" {
+			current = schemaVersionIndex(versions, from)
+		}
+		switch {
+		case current < 0:
+			report.Failed++
+			report.Failures = append(report.Failures, models.SchemaMigrationFailure{
+				ResourceID: resource.ID, From: from, Error: "pinned to an unknown schema version",
+			})
+			return nil
+		case current > target, current == target && from != "",
+			req.From != "" && models.CompareSchemaVersions(versions[current].Version, req.From) != 0:
+			report.Skipped++
+			return nil
+		}
+
+		data, applied, err := upgradeData(resource.Data, versions[current+1:target+1])
+		if err == nil && to.ValidationMode() == models.SchemaModeEnforce && s.validator != nil {
+			var violations []error
+			checked, verr := s.validator.Validate(to, data)
+			for i := range checked {
+				violations = append(violations, checked[i])
+			}
+			if verr != nil {
+				err = verr
+			} else if len(violations) > 0 {
+				err = fmt.Errorf("upgraded data violates version %s: %w", to.Version, errors.Join(violations...))
+			}
+		}
+		if err != nil {
+			report.Failed++
+			report.Failures = append(report.Failures, models.SchemaMigrationFailure{
+				ResourceID: resource.ID, From: from, Error: err.Error(),
+			})
+			return nil
+		}
+
+		report.Migrated++
+		if req.DryRun {
+			return nil
+		}
+		upgraded := *resource
+		upgraded.Data = data
+		upgraded.Metadata.SchemaVersion = to.Version
+		upgraded.UpdateModified(actor)
+		batch = append(batch, migratedResource{resource: upgraded, from: from, applied: applied})
+		if len(batch) >= schemaMigrationBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return report, fmt.Errorf("schema migration stopped: %w", err)
+	}
+
+	s.logger.Printf("Migrated %s resources of %s to version %s for %s: %d migrated, %d skipped, %d failed (dry run %t)",
+		to.Type, to.Name, to.Version, actor, report.Migrated, report.Skipped, report.Failed, req.DryRun)
+	return report, nil
+}
+
+// upgradeData applies the transforms of versions, in order, to a copy of
+// data
+func upgradeData(data map[string]interface{}, versions []models.Schema) (map[string]interface{}, []string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to copy data: %w", err)
+	}
+	upgraded := map[string]interface{}{}
+	if err := json.Unmarshal(encoded, &upgraded); err != nil || upgraded == nil {
+		upgraded = map[string]interface{}{}
+	}
+
+	var applied []string
+	for i := range versions {
+		changes, err := versions[i].Transform.Apply(upgraded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transform to version %s failed: %w", versions[i].Version, err)
+		}
+		applied = append(applied, changes...)
+	}
+	return upgraded, applied, nil
+}
+
+// writeMigrated stores a batch of upgraded resources and records a change
+// for each
+func (s *schemaService) writeMigrated(ctx context.Context, batch []migratedResource, to *models.Schema, actor string) error {
+	resources := make([]models.Resource, len(batch))
+	ids := make([]string, len(batch))
+	for i := range batch {
+		resources[i], ids[i] = batch[i].resource, batch[i].resource.ID
+	}
+	if err := s.resources.UpsertBatch(ctx, resources); err != nil {
+		return err
+	}
+
+	latest, err := s.ledger.GetLatestRecords(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to record changes: %w", err)
+	}
+	now := time.Now().UTC()
+	records := make([]models.ChangeRecord, 0, len(batch))
+	for i := range batch {
+		changes := map[string]interface{}{
+			"source": "schema_migration",
+			"schema": to.Name,
+			"from":   batch[i].from,
+			"to":     to.Version,
+		}
+		if len(batch[i].applied) > 0 {
+			changes["transform"] = batch[i].applied
+		}
+		record, err := s.chain.Next(latest[ids[i]].DataHash, ids[i], "UPDATE", actor, now, changes)
+		if err != nil {
+			return err
+		}
+		latest[record.ResourceID] = record
+		records = append(records, record)
+	}
+	if err := s.ledger.CreateRecords(ctx, records); err != nil {
+		return fmt.Errorf("failed to record changes: %w", err)
+	}
+	return nil
+}
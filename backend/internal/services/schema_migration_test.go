@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log"
+	"slices"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// fakeMigrationStore streams the resources of a fakeImportStore in ID order
+type fakeMigrationStore struct {
+	*fakeImportStore
+}
+
+func (s fakeMigrationStore) Stream(_ context.Context, query *models.SearchQuery, fn func(*models.Resource) error) error {
+	ids := make([]string, 0, len(s.resources))
+	for id := range s.resources {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		resource := s.resources[id]
+		if resource.Provider != query.Provider || resource.Type != query.Type {
+			continue
+		}
+		if err := fn(&resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestSchemaService(repo *fakeSchemaRepo, store *fakeImportStore) *schemaService {
+	logger := log.New(io.Discard, "", 0)
+	validator := NewSchemaValidator(repo, logger)
+	return NewSchemaService(repo, fakeMigrationStore{store}, store, validator, logger).(*schemaService)
+}
+
+func TestSchemaService_MigrateResources(t *testing.T) {
+	repo := &fakeSchemaRepo{}
+	store := newFakeImportStore()
+	service := newTestSchemaService(repo, store)
+	ctx := context.Background()
+
+	repo.versions = []models.Schema{
+		*diskSchema("1", map[string]interface{}{"size": map[string]interface{}{"type": "number"}}),
+		*diskSchema("2", map[string]interface{}{"size_gb": map[string]interface{}{"type": "number"}}, "size_gb"),
+		*diskSchema("3", map[string]interface{}{"size_gb": map[string]interface{}{"type": "number"}, "tier": map[string]interface{}{"type": "string"}}, "size_gb", "tier"),
+	}
+	repo.versions[1].Transform = &models.SchemaTransform{Renames: map[string]string{"/size": "/size_gb"}}
+	repo.versions[2].Transform = &models.SchemaTransform{Defaults: map[string]interface{}{"/tier": "standard"}}
+
+	pinned := func(id, version string, data map[string]interface{}) models.Resource {
+		resource := models.Resource{ID: id, Provider: "custom", Type: "disk", Name: id, Data: data}
+		resource.Metadata.SchemaVersion = version
+		return resource
+	}
+	store.resources["d1"] = pinned("d1", "", map[string]interface{}{"size": 10.0})
+	store.resources["d2"] = pinned("d2", "2", map[string]interface{}{"size_gb": 20.0})
+	store.resources["d3"] = pinned("d3", "3", map[string]interface{}{"size_gb": 30.0, "tier": "fast"})
+	store.resources["d4"] = pinned("d4", "1", map[string]interface{}{"other": true})
+	store.resources["d5"] = pinned("d5", "9", map[string]interface{}{})
+	store.resources["x1"] = models.Resource{ID: "x1", Provider: "custom", Type: "volume"}
+
+	dryRun, err := service.MigrateResources(ctx, "disk", "", &models.SchemaMigrationRequest{DryRun: true}, "tester")
+	if err != nil {
+		t.Fatalf("MigrateResources() dry run error = %v", err)
+	}
+	if dryRun.Migrated != 2 || len(store.records) != 0 || store.resources["d1"].Metadata.SchemaVersion != "" {
+		t.Fatalf("MigrateResources() dry run = %+v, records %d; want 2 migrated and nothing written", dryRun, len(store.records))
+	}
+
+	report, err := service.MigrateResources(ctx, "disk", "", &models.SchemaMigrationRequest{}, "tester")
+	if err != nil {
+		t.Fatalf("MigrateResources() error = %v", err)
+	}
+	if report.To != "3" || report.Scanned != 5 || report.Migrated != 2 || report.Skipped != 1 || report.Failed != 2 {
+		t.Errorf("MigrateResources() = %+v, want 5 scanned, 2 migrated, 1 skipped, 2 failed", report)
+	}
+
+	d1 := store.resources["d1"]
+	if d1.Metadata.SchemaVersion != "3" || d1.Data["size_gb"] != 10.0 || d1.Data["tier"] != "standard" || d1.Data["size"] != nil {
+		t.Errorf("migrated d1 = %+v, want version 3 with size_gb and tier", d1)
+	}
+	if d2 := store.resources["d2"]; d2.Metadata.SchemaVersion != "3" || d2.Data["tier"] != "standard" {
+		t.Errorf("migrated d2 = %+v, want version 3 with tier", d2)
+	}
+	if d4 := store.resources["d4"]; d4.Metadata.SchemaVersion != "1" {
+		t.Errorf("d4 failed validation but was migrated to %s", d4.Metadata.SchemaVersion)
+	}
+
+	if len(store.records) != 2 {
+		t.Fatalf("MigrateResources() recorded %d changes, want 2", len(store.records))
+	}
+	for _, record := range store.records {
+		if record.Operation != "UPDATE" || record.Changes["source"] != "schema_migration" || record.Changes["to"] != "3" || record.DataHash == "" {
+			t.Errorf("MigrateResources() recorded %+v", record)
+		}
+	}
+
+	if _, err := service.MigrateResources(ctx, "disk", "", &models.SchemaMigrationRequest{From: "2", To: "1"}, "tester"); err == nil {
+		t.Error("MigrateResources() accepted a downgrade")
+	}
+	if _, err := service.MigrateResources(ctx, "disk", "", &models.SchemaMigrationRequest{To: "4"}, "tester"); err == nil {
+		t.Error("MigrateResources() accepted an unknown target version")
+	}
+}
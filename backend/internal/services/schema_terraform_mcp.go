@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
 	"github.com/LederWorks/siros/backend/internal/models"
 	"github.com/LederWorks/siros/backend/internal/repositories"
 )
@@ -11,43 +14,258 @@ import (
 // schemaService implements SchemaService
 type schemaService struct {
 	schemaRepo repositories.SchemaRepository
+	resources  SchemaMigrationStore
+	ledger     SchemaMigrationLedger
+	validator  *SchemaValidator
+	chain      *ChangeChain
 	logger     *log.Logger
 }
 
-// NewSchemaService creates a new schema service
-func NewSchemaService(schemaRepo repositories.SchemaRepository, logger *log.Logger) SchemaService {
+// NewSchemaService creates a new schema service. Migrations upgrade the
+// resources in store, recording each in the ledger.
+func NewSchemaService(schemaRepo repositories.SchemaRepository, store SchemaMigrationStore, ledger SchemaMigrationLedger, validator *SchemaValidator, logger *log.Logger) SchemaService {
 	return &schemaService{
 		schemaRepo: schemaRepo,
+		resources:  store,
+		ledger:     ledger,
+		validator:  validator,
+		chain:      NewChangeChain(),
 		logger:     logger,
 	}
 }
 
+// CreateSchema registers a new version of a schema, which must be greater
+// than the latest, and classifies its compatibility with the latest. Every
+// version of a schema is custom or every one predefined.
 func (s *schemaService) CreateSchema(ctx context.Context, schema *models.Schema) error {
 	if err := schema.Validate(); err != nil {
+		return fmt.Errorf("schema validation failed: %w", err)
+	}
+
+	versions, err := s.schemaRepo.ListVersions(ctx, schema.Name, schema.Provider)
+	if err != nil {
+		return err
+	}
+	if len(versions) > 0 {
+		latest := versions[len(versions)-1]
+		if err := checkCustom(&latest, schema); err != nil {
+			return err
+		}
+		if models.CompareSchemaVersions(schema.Version, latest.Version) <= 0 {
+			return fmt.Errorf("schema validation failed: version %s of %s must be greater than the latest version %s",
+				schema.Version, schema.Name, latest.Version)
+		}
+	}
+	if err := s.classify(schema, versions); err != nil {
 		return err
 	}
+	if schema.CreatedAt.IsZero() {
+		schema.CreatedAt = time.Now().UTC()
+	}
+	schema.UpdatedAt = schema.CreatedAt
 
 	return s.schemaRepo.Create(ctx, schema)
 }
 
-func (s *schemaService) GetSchema(ctx context.Context, name, _ string) (*models.Schema, error) {
-	return s.schemaRepo.GetByName(ctx, name)
+// classify sets a schema version's compatibility with the version before
+// it. The first version has no compatibility and nothing to transform.
+func (s *schemaService) classify(schema *models.Schema, versions []models.Schema) error {
+	var previous *models.Schema
+	for i := range versions {
+		if models.CompareSchemaVersions(versions[i].Version, schema.Version) < 0 {
+			previous = &versions[i]
+		}
+	}
+
+	schema.Compatibility = ""
+	if previous == nil {
+		if schema.Transform != nil {
+			return fmt.Errorf("schema validation failed: version %s of %s has no previous version to transform", schema.Version, schema.Name)
+		}
+		return nil
+	}
+	if previous.Type != schema.Type {
+		return fmt.Errorf("schema validation failed: %s describes %s resources, not %s", schema.Name, previous.Type, schema.Type)
+	}
+
+	compatibility, _, err := jsonschema.Compare(previous.Schema, schema.Schema)
+	if err != nil {
+		return fmt.Errorf("failed to compare schema versions: %w", err)
+	}
+	schema.Compatibility = string(compatibility)
+	return nil
+}
+
+// GetSchema returns the latest version of a schema. Without a provider the
+// name must be registered for a single provider.
+func (s *schemaService) GetSchema(ctx context.Context, name, provider string) (*models.Schema, error) {
+	if provider != "" {
+		return s.schemaRepo.GetByName(ctx, name, provider)
+	}
+
+	versions, err := s.ListVersions(ctx, name, provider)
+	if err != nil {
+		return nil, err
+	}
+	return &versions[len(versions)-1], nil
 }
 
-func (s *schemaService) ListSchemas(ctx context.Context, _ string) ([]models.Schema, error) {
-	return s.schemaRepo.List(ctx)
+func (s *schemaService) ListSchemas(ctx context.Context, query *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error) {
+	if err := query.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
+	}
+	query.SetDefaults()
+
+	return s.schemaRepo.List(ctx, query)
 }
 
-func (s *schemaService) UpdateSchema(ctx context.Context, _, _ string, schema *models.Schema) error {
+// UpdateSchema replaces a version of a provider's schema, the latest unless
+// the schema names one, and reclassifies it. The provider may be left to
+// the schema, and the schema's name is taken from name.
+func (s *schemaService) UpdateSchema(ctx context.Context, name, provider string, schema *models.Schema) error {
+	if provider == "" {
+		provider = schema.Provider
+	}
+	versions, err := s.ListVersions(ctx, name, provider)
+	if err != nil {
+		return err
+	}
+
+	schema.Name, schema.Provider = name, versions[0].Provider
+	if schema.Version == "" {
+		schema.Version = versions[len(versions)-1].Version
+	}
+	i := schemaVersionIndex(versions, schema.Version)
+	if i < 0 {
+		return fmt.Errorf("schema %w: %s version %s", repositories.ErrNotFound, name, schema.Version)
+	}
+	if err := checkCustom(&versions[i], schema); err != nil {
+		return err
+	}
+	schema.Version = versions[i].Version
+
 	if err := schema.Validate(); err != nil {
+		return fmt.Errorf("schema validation failed: %w", err)
+	}
+	if err := s.classify(schema, versions); err != nil {
 		return err
 	}
+	schema.CreatedAt = versions[i].CreatedAt
+	schema.UpdatedAt = time.Now().UTC()
 
 	return s.schemaRepo.Update(ctx, schema)
 }
 
-func (s *schemaService) DeleteSchema(ctx context.Context, name, _ string) error {
-	return s.schemaRepo.Delete(ctx, name)
+// DeleteSchema removes every version of a custom schema
+func (s *schemaService) DeleteSchema(ctx context.Context, name, provider string) error {
+	versions, err := s.ListVersions(ctx, name, provider)
+	if err != nil {
+		return err
+	}
+	if !versions[0].IsCustom {
+		return fmt.Errorf("cannot delete %s: %w", name, models.ErrPredefinedSchema)
+	}
+
+	return s.schemaRepo.Delete(ctx, name, versions[0].Provider)
+}
+
+// checkCustom rejects changes that would turn a custom schema into a
+// predefined one or the reverse
+func checkCustom(existing, schema *models.Schema) error {
+	switch {
+	case existing.IsCustom == schema.IsCustom:
+		return nil
+	case !existing.IsCustom:
+		return fmt.Errorf("cannot change %s: %w", schema.Name, models.ErrPredefinedSchema)
+	default:
+		return fmt.Errorf("schema %w: %s is a custom schema", repositories.ErrAlreadyExists, schema.Name)
+	}
+}
+
+// ListVersions returns every version of a schema, oldest first
+func (s *schemaService) ListVersions(ctx context.Context, name, provider string) ([]models.Schema, error) {
+	versions, err := s.schemaRepo.ListVersions(ctx, name, provider)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("schema %w: %s", repositories.ErrNotFound, name)
+	}
+	for _, version := range versions[1:] {
+		if version.Provider != versions[0].Provider {
+			return nil, fmt.Errorf("query validation failed: schema %s exists for several providers; name one", name)
+		}
+	}
+	return versions, nil
+}
+
+// CompareVersions classifies version to of a schema against version from.
+// To defaults to the latest version and from to the version before to.
+func (s *schemaService) CompareVersions(ctx context.Context, name, provider, from, to string) (*models.SchemaCompatibility, error) {
+	versions, err := s.ListVersions(ctx, name, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	target := len(versions) - 1
+	if to != "" {
+		if target = schemaVersionIndex(versions, to); target < 0 {
+			return nil, fmt.Errorf("schema %w: %s version %s", repositories.ErrNotFound, name, to)
+		}
+	}
+	base := target - 1
+	if from != "" {
+		if base = schemaVersionIndex(versions, from); base < 0 {
+			return nil, fmt.Errorf("schema %w: %s version %s", repositories.ErrNotFound, name, from)
+		}
+	}
+	if base < 0 {
+		return nil, fmt.Errorf("query validation failed: version %s is the first version of %s", versions[target].Version, name)
+	}
+
+	compatibility, changes, err := jsonschema.Compare(versions[base].Schema, versions[target].Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare schema versions: %w", err)
+	}
+	return &models.SchemaCompatibility{
+		Schema:        versions[target].Name,
+		Provider:      versions[target].Provider,
+		From:          versions[base].Version,
+		To:            versions[target].Version,
+		Compatibility: string(compatibility),
+		Changes:       append([]jsonschema.Change{}, changes...),
+	}, nil
+}
+
+// schemaVersionIndex returns the index of a version among versions, or -1
+func schemaVersionIndex(versions []models.Schema, version string) int {
+	for i := range versions {
+		if models.CompareSchemaVersions(versions[i].Version, version) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateData validates data against a schema whatever its mode, reporting
+// every violation
+func (s *schemaService) ValidateData(ctx context.Context, name, provider string, data map[string]interface{}) (*models.SchemaValidationResult, error) {
+	schema, err := s.GetSchema(ctx, name, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	violations, err := s.validator.Validate(schema, data)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SchemaValidationResult{
+		Schema:  schema.Name,
+		Version: schema.Version,
+		Mode:    schema.ValidationMode(),
+		Valid:   len(violations) == 0,
+		Errors:  append([]jsonschema.Error{}, violations...),
+	}, nil
 }
 
 // terraformService implements TerraformService
@@ -95,85 +313,49 @@ func (s *terraformService) DeleteKey(_ context.Context, key string) error {
 
 // mcpService implements MCPService
 type mcpService struct {
-	resourceRepo repositories.ResourceRepository
-	logger       *log.Logger
+	resources     ResourceService
+	search        SearchService
+	blockchain    BlockchainService
+	schemas       SchemaService
+	savedSearches SavedSearchService
+	proposals     ProposalService
+	tools         map[string]*mcpTool
+	toolNames     []string
+	logger        *log.Logger
 }
 
-// NewMCPService creates a new MCP service
-func NewMCPService(resourceRepo repositories.ResourceRepository, logger *log.Logger) MCPService {
-	return &mcpService{
-		resourceRepo: resourceRepo,
-		logger:       logger,
+// NewMCPService creates a new MCP service whose tools and resources are
+// served by the resource, search, blockchain and schema services. Changes
+// are only proposed through the proposal service, never applied.
+func NewMCPService(resources ResourceService, search SearchService, blockchain BlockchainService, schemas SchemaService, savedSearches SavedSearchService, proposals ProposalService, logger *log.Logger) MCPService {
+	s := &mcpService{
+		resources:     resources,
+		search:        search,
+		blockchain:    blockchain,
+		schemas:       schemas,
+		savedSearches: savedSearches,
+		proposals:     proposals,
+		logger:        logger,
 	}
+	s.registerTools()
+	return s
 }
 
+// Initialize describes the server and the capabilities it offers; the
+// protocol version is negotiated by the transport
 func (s *mcpService) Initialize(_ context.Context, _ MCPInitRequest) (*MCPInitResponse, error) {
 	s.logger.Printf("Initializing MCP session")
 	response := MCPInitResponse{
-		"server_name":      "siros",
-		"server_version":   "1.0.0",
-		"protocol_version": "2024-11-05",
+		"serverInfo": map[string]interface{}{
+			"name":    "siros",
+			"version": "1.0.0",
+		},
 		"capabilities": map[string]interface{}{
-			"resources": map[string]interface{}{
-				"subscribe":    true,
-				"list_changed": true,
-			},
-			"tools":   map[string]interface{}{},
-			"prompts": map[string]interface{}{},
+			"resources": map[string]interface{}{"subscribe": true},
+			"tools":     map[string]interface{}{},
+			"prompts":   map[string]interface{}{},
 		},
+		"instructions": "Siros tracks cloud resources across providers. Use the tools to list, search and inspect them, follow their relationships and read their change history. Changes can only be proposed; a person approves them before they are applied. Resources under siros:// can be read and subscribed to.",
 	}
 	return &response, nil
 }
-
-func (s *mcpService) ListResources(_ context.Context) ([]MCPResource, error) {
-	s.logger.Printf("Listing MCP resources")
-	// TODO: Implement actual resource listing
-	return []MCPResource{}, nil
-}
-
-func (s *mcpService) ReadResource(_ context.Context, uri string) (*MCPResourceContent, error) {
-	s.logger.Printf("Reading MCP resource: %s", uri)
-	// TODO: Implement actual resource reading
-	content := MCPResourceContent{
-		"uri":      uri,
-		"mimeType": "application/json",
-		"text":     "{}",
-	}
-	return &content, nil
-}
-
-func (s *mcpService) ListTools(_ context.Context) ([]MCPTool, error) {
-	s.logger.Printf("Listing MCP tools")
-	// TODO: Implement actual tool listing
-	return []MCPTool{}, nil
-}
-
-func (s *mcpService) CallTool(_ context.Context, name string, _ map[string]interface{}) (*MCPToolResult, error) {
-	s.logger.Printf("Calling MCP tool: %s", name)
-	// TODO: Implement actual tool calling
-	result := MCPToolResult{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": "Tool not implemented",
-			},
-		},
-	}
-	return &result, nil
-}
-
-func (s *mcpService) ListPrompts(_ context.Context) ([]MCPPrompt, error) {
-	s.logger.Printf("Listing MCP prompts")
-	// TODO: Implement actual prompt listing
-	return []MCPPrompt{}, nil
-}
-
-func (s *mcpService) GetPrompt(_ context.Context, name string, _ map[string]interface{}) (*MCPPromptResult, error) {
-	s.logger.Printf("Getting MCP prompt: %s", name)
-	// TODO: Implement actual prompt retrieval
-	result := MCPPromptResult{
-		"description": "Prompt not implemented",
-		"messages":    []map[string]interface{}{},
-	}
-	return &result, nil
-}
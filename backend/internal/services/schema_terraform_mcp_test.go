@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// fakeSchemaRepo keeps the versions of schemas in memory, oldest first
+type fakeSchemaRepo struct {
+	versions []models.Schema
+}
+
+func (r *fakeSchemaRepo) Create(_ context.Context, schema *models.Schema) error {
+	r.versions = append(r.versions, *schema)
+	return nil
+}
+
+func (r *fakeSchemaRepo) Update(_ context.Context, schema *models.Schema) error {
+	for i := range r.versions {
+		if r.versions[i].Name == schema.Name && r.versions[i].Version == schema.Version {
+			r.versions[i] = *schema
+			return nil
+		}
+	}
+	return repositories.ErrNotFound
+}
+
+func (r *fakeSchemaRepo) Delete(_ context.Context, name, provider string) error {
+	n := len(r.versions)
+	r.versions = slices.DeleteFunc(r.versions, func(schema models.Schema) bool {
+		return schema.Name == name && schema.Provider == provider
+	})
+	if len(r.versions) == n {
+		return repositories.ErrNotFound
+	}
+	return nil
+}
+
+func (r *fakeSchemaRepo) List(_ context.Context, _ *models.SchemaQuery) ([]models.Schema, *models.PageInfo, error) {
+	return r.versions, &models.PageInfo{}, nil
+}
+
+func (r *fakeSchemaRepo) GetByName(_ context.Context, name, provider string) (*models.Schema, error) {
+	for i := len(r.versions) - 1; i >= 0; i-- {
+		if r.versions[i].Name == name && r.versions[i].Provider == provider {
+			return &r.versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("schema %w: %s/%s", repositories.ErrNotFound, provider, name)
+}
+
+func (r *fakeSchemaRepo) GetForType(_ context.Context, provider, resourceType, version string) (*models.Schema, error) {
+	for i := len(r.versions) - 1; i >= 0; i-- {
+		schema := r.versions[i]
+		if schema.Provider == provider && schema.Type == resourceType && (version == "" || version == schema.Version) {
+			return &schema, nil
+		}
+	}
+	return nil, fmt.Errorf("schema %w: %s/%s", repositories.ErrNotFound, provider, resourceType)
+}
+
+func (r *fakeSchemaRepo) ListVersions(_ context.Context, name, provider string) ([]models.Schema, error) {
+	var versions []models.Schema
+	for _, schema := range r.versions {
+		if schema.Name == name && (provider == "" || schema.Provider == provider) {
+			versions = append(versions, schema)
+		}
+	}
+	return versions, nil
+}
+
+func diskSchema(version string, properties map[string]interface{}, required ...interface{}) *models.Schema {
+	schema := &models.Schema{
+		Name: "disk", Provider: "custom", Type: "disk", Version: version,
+		Schema: map[string]interface{}{"type": "object", "properties": properties},
+	}
+	if len(required) > 0 {
+		schema.Schema["required"] = required
+	}
+	return schema
+}
+
+func TestSchemaService_CreateSchemaVersions(t *testing.T) {
+	repo := &fakeSchemaRepo{}
+	service := newTestSchemaService(repo, newFakeImportStore())
+	ctx := context.Background()
+	size := map[string]interface{}{"type": "number"}
+
+	if err := service.CreateSchema(ctx, diskSchema("1", map[string]interface{}{"size": size})); err != nil {
+		t.Fatalf("CreateSchema() error = %v", err)
+	}
+	if err := service.CreateSchema(ctx, diskSchema("1.0", map[string]interface{}{"size": size})); err == nil {
+		t.Error("CreateSchema() accepted a version that is not greater than the latest")
+	}
+
+	second := diskSchema("2", map[string]interface{}{"size": size, "tier": map[string]interface{}{"type": "string"}}, "tier")
+	if err := service.CreateSchema(ctx, second); err != nil {
+		t.Fatalf("CreateSchema() error = %v", err)
+	}
+	if second.Compatibility != "forward" {
+		t.Errorf("CreateSchema() compatibility = %q, want forward", second.Compatibility)
+	}
+
+	result, err := service.CompareVersions(ctx, "disk", "", "", "")
+	if err != nil {
+		t.Fatalf("CompareVersions() error = %v", err)
+	}
+	if result.From != "1" || result.To != "2" || len(result.Changes) != 2 {
+		t.Errorf("CompareVersions() = %+v, want 1 to 2 with 2 changes", result)
+	}
+}
+
+func TestSchemaService_Providers(t *testing.T) {
+	repo := &fakeSchemaRepo{}
+	service := newTestSchemaService(repo, newFakeImportStore())
+	ctx := context.Background()
+	size := map[string]interface{}{"size": map[string]interface{}{"type": "number"}}
+
+	custom := diskSchema("1", size)
+	custom.IsCustom = true
+	predefined := diskSchema("1", size)
+	predefined.Provider = "aws"
+	for _, schema := range []*models.Schema{custom, predefined} {
+		if err := service.CreateSchema(ctx, schema); err != nil {
+			t.Fatalf("CreateSchema(%s) error = %v", schema.Provider, err)
+		}
+	}
+
+	if _, err := service.GetSchema(ctx, "disk", ""); err == nil || !strings.Contains(err.Error(), "validation") {
+		t.Errorf("GetSchema() without a provider error = %v, want a validation error", err)
+	}
+	schema, err := service.GetSchema(ctx, "disk", "aws")
+	if err != nil || schema.Provider != "aws" || schema.IsCustom {
+		t.Fatalf("GetSchema(aws) = %+v, %v", schema, err)
+	}
+
+	// A custom version of a predefined schema is refused, and the reverse
+	// conflicts
+	next := diskSchema("2", size)
+	next.Provider, next.IsCustom = "aws", true
+	if err := service.CreateSchema(ctx, next); !errors.Is(err, models.ErrPredefinedSchema) {
+		t.Errorf("CreateSchema() custom version of a predefined schema error = %v", err)
+	}
+	next.Provider, next.IsCustom = "custom", false
+	if err := service.CreateSchema(ctx, next); !errors.Is(err, repositories.ErrAlreadyExists) {
+		t.Errorf("CreateSchema() predefined version of a custom schema error = %v", err)
+	}
+
+	update := &models.Schema{Type: "disk", Schema: size, Description: "Disks", IsCustom: true}
+	if err := service.UpdateSchema(ctx, "disk", "aws", update); !errors.Is(err, models.ErrPredefinedSchema) {
+		t.Errorf("UpdateSchema(aws) error = %v, want ErrPredefinedSchema", err)
+	}
+	if err := service.UpdateSchema(ctx, "disk", "custom", update); err != nil {
+		t.Fatalf("UpdateSchema(custom) error = %v", err)
+	}
+	if update.Version != "1" || update.Provider != "custom" || update.UpdatedAt.IsZero() {
+		t.Errorf("UpdateSchema(custom) = %+v, want version 1 of the custom schema", update)
+	}
+
+	if err := service.DeleteSchema(ctx, "disk", "aws"); !errors.Is(err, models.ErrPredefinedSchema) {
+		t.Errorf("DeleteSchema(aws) error = %v, want ErrPredefinedSchema", err)
+	}
+	if err := service.DeleteSchema(ctx, "disk", "custom"); err != nil {
+		t.Fatalf("DeleteSchema(custom) error = %v", err)
+	}
+	if schema, err := service.GetSchema(ctx, "disk", ""); err != nil || schema.Provider != "aws" {
+		t.Errorf("GetSchema() after delete = %+v, %v; want the aws schema", schema, err)
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// SchemaLookup finds a version of the schema registered for a provider's
+// resource type, the latest when version is empty
+type SchemaLookup interface {
+	GetForType(ctx context.Context, provider, resourceType, version string) (*models.Schema, error)
+}
+
+// SchemaValidator validates resource data against the schema registered
+// for the resource's provider and type. Schemas are compiled once per
+// definition.
+type SchemaValidator struct {
+	schemas SchemaLookup
+	logger  *log.Logger
+
+	mu       sync.Mutex
+	compiled map[[sha256.Size]byte]*jsonschema.Schema
+}
+
+// NewSchemaValidator creates a schema validator
+func NewSchemaValidator(schemas SchemaLookup, logger *log.Logger) *SchemaValidator {
+	return &SchemaValidator{
+		schemas:  schemas,
+		logger:   logger,
+		compiled: map[[sha256.Size]byte]*jsonschema.Schema{},
+	}
+}
+
+// Batch returns a validator that looks each schema version up once, for
+// checking the many rows of a bulk import. It is not safe for concurrent use.
+func (v *SchemaValidator) Batch() *SchemaValidator {
+	if v == nil || v.schemas == nil {
+		return v
+	}
+	return NewSchemaValidator(&schemaCache{lookup: v.schemas, found: map[string]schemaLookup{}}, v.logger)
+}
+
+// schemaLookup is the outcome of one schema lookup
+type schemaLookup struct {
+	schema *models.Schema
+	err    error
+}
+
+// schemaCache remembers the schemas, and missing schemas, it looked up
+type schemaCache struct {
+	lookup SchemaLookup
+	found  map[string]schemaLookup
+}
+
+func (c *schemaCache) GetForType(ctx context.Context, provider, resourceType, version string) (*models.Schema, error) {
+	key := provider + "\x00" + resourceType + "\x00" + version
+	result, ok := c.found[key]
+	if !ok {
+		result.schema, result.err = c.lookup.GetForType(ctx, provider, resourceType, version)
+		if result.err != nil && !errors.Is(result.err, repositories.ErrNotFound) {
+			return nil, result.err
+		}
+		c.found[key] = result
+	}
+	return result.schema, result.err
+}
+
+// Check validates a resource's data against the schema version it is pinned
+// to, pinning it to the latest version when it is not. Under the enforce
+// mode violations are returned as a *models.SchemaValidationError; under
+// warn they are added to the resource's warnings. Resources without a
+// registered schema, and any resource when the validator is nil, pass.
+func (v *SchemaValidator) Check(ctx context.Context, resource *models.Resource) error {
+	if v == nil || v.schemas == nil {
+		return nil
+	}
+
+	version := resource.Metadata.SchemaVersion
+	schema, err := v.schemas.GetForType(ctx, resource.Provider, resource.Type, version)
+	if errors.Is(err, repositories.ErrNotFound) {
+		if version != "" {
+			return fmt.Errorf("resource validation failed: %s/%s has no schema version %s", resource.Provider, resource.Type, version)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get schema for %s/%s: %w", resource.Provider, resource.Type, err)
+	}
+	resource.Metadata.SchemaVersion = schema.Version
+
+	mode := schema.ValidationMode()
+	if mode == models.SchemaModeOff {
+		return nil
+	}
+
+	violations, err := v.Validate(schema, resource.Data)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	if mode == models.SchemaModeWarn {
+		for _, violation := range violations {
+			resource.Warnings = append(resource.Warnings, violation.Error())
+		}
+		v.logger.Printf("Resource %s does not match schema %s version %s: %d violations",
+			resource.ID, schema.Name, schema.Version, len(violations))
+		return nil
+	}
+	return &models.SchemaValidationError{Schema: schema.Name, Version: schema.Version, Violations: violations}
+}
+
+// Validate validates data against a schema, returning its violations.
+// Missing data validates as an empty object.
+func (v *SchemaValidator) Validate(schema *models.Schema, data map[string]interface{}) ([]jsonschema.Error, error) {
+	compiled, err := v.compile(schema)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return compiled.Validate(data), nil
+}
+
+// compile returns the compiled form of a schema's definition, keyed by its
+// content so that updated definitions are recompiled
+func (v *SchemaValidator) compile(schema *models.Schema) (*jsonschema.Schema, error) {
+	definition, err := json.Marshal(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema %s: %w", schema.Name, err)
+	}
+	key := sha256.Sum256(definition)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if compiled, ok := v.compiled[key]; ok {
+		return compiled, nil
+	}
+	compiled, err := jsonschema.Compile(schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s is invalid: %w", schema.Name, err)
+	}
+	v.compiled[key] = compiled
+	return compiled, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/repositories"
+)
+
+// fakeSchemaLookup serves one schema per provider and type
+type fakeSchemaLookup map[string]*models.Schema
+
+func (l fakeSchemaLookup) GetForType(_ context.Context, provider, resourceType, version string) (*models.Schema, error) {
+	if schema, ok := l[provider+"/"+resourceType]; ok && (version == "" || version == schema.Version) {
+		return schema, nil
+	}
+	return nil, fmt.Errorf("schema %w: %s/%s", repositories.ErrNotFound, provider, resourceType)
+}
+
+func TestSchemaValidator_Check(t *testing.T) {
+	definition := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"size"},
+		"properties": map[string]interface{}{
+			"size": map[string]interface{}{"enum": []interface{}{"small", "large"}},
+		},
+	}
+	schemas := fakeSchemaLookup{}
+	validator := NewSchemaValidator(schemas, log.New(io.Discard, "", 0))
+
+	tests := []struct {
+		name         string
+		mode         string
+		data         map[string]interface{}
+		wantError    bool
+		wantWarnings int
+	}{
+		{name: "valid", mode: models.SchemaModeEnforce, data: map[string]interface{}{"size": "small"}},
+		{name: "enforce", mode: "", data: map[string]interface{}{"size": "huge"}, wantError: true},
+		{name: "missing data", mode: models.SchemaModeEnforce, wantError: true},
+		{name: "warn", mode: models.SchemaModeWarn, data: map[string]interface{}{"size": "huge"}, wantWarnings: 1},
+		{name: "off", mode: models.SchemaModeOff, data: map[string]interface{}{"size": "huge"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemas["custom/disk"] = &models.Schema{Name: "disk", Provider: "custom", Type: "disk", Version: "1", Mode: tt.mode, Schema: definition}
+			resource := &models.Resource{ID: "r1", Provider: "custom", Type: "disk", Data: tt.data}
+
+			err := validator.Check(context.Background(), resource)
+			var schemaErr *models.SchemaValidationError
+			if tt.wantError != errors.As(err, &schemaErr) {
+				t.Fatalf("Check() error = %v, wantError %v", err, tt.wantError)
+			}
+			if len(resource.Warnings) != tt.wantWarnings {
+				t.Errorf("Check() warnings = %v, want %d", resource.Warnings, tt.wantWarnings)
+			}
+			if resource.Metadata.SchemaVersion != "1" {
+				t.Errorf("Check() pinned version %q, want 1", resource.Metadata.SchemaVersion)
+			}
+		})
+	}
+
+	if err := validator.Check(context.Background(), &models.Resource{Provider: "aws", Type: "ec2"}); err != nil {
+		t.Errorf("Check() without a schema error = %v", err)
+	}
+	pinned := &models.Resource{Provider: "custom", Type: "disk", Data: map[string]interface{}{"size": "small"}}
+	pinned.Metadata.SchemaVersion = "2"
+	if err := validator.Check(context.Background(), pinned); err == nil {
+		t.Error("Check() accepted a resource pinned to an unknown version")
+	}
+	var nilValidator *SchemaValidator
+	if err := nilValidator.Check(context.Background(), &models.Resource{}); err != nil {
+		t.Errorf("nil Check() error = %v", err)
+	}
+}
@@ -2,22 +2,42 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"sort"
+	"time"
 
+	"github.com/LederWorks/siros/backend/internal/identity"
 	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/providers"
 )
 
+// ErrScanUnavailable is returned by ScanProviders when no providers are configured
+var ErrScanUnavailable = errors.New("provider scanning is not available")
+
+// ProviderScanner streams provider scans into a sink, as providers.Manager does
+type ProviderScanner interface {
+	StreamAll(ctx context.Context, sink providers.ResourceSink, opts providers.StreamOptions) ([]providers.ScanProgress, error)
+}
+
 // searchService implements SearchService
 type searchService struct {
 	resourceRepo ResourceRepository
+	scanner      ProviderScanner
+	sink         providers.ResourceSink
 	logger       *log.Logger
 }
 
-// NewSearchService creates a new search service
-func NewSearchService(resourceRepo ResourceRepository, logger *log.Logger) SearchService {
+// NewSearchService creates a new search service. Scanned resources are
+// stored under the Siros IDs the resolver assigns; a nil scanner disables
+// provider scanning.
+func NewSearchService(resourceRepo ResourceRepository, scanner ProviderScanner, resolver *identity.Resolver, logger *log.Logger) SearchService {
 	return &searchService{
 		resourceRepo: resourceRepo,
+		scanner:      scanner,
+		sink:         NewResourceSink(resourceRepo, resolver),
 		logger:       logger,
 	}
 }
@@ -49,7 +69,7 @@ func (s *searchService) SemanticSearch(ctx context.Context, query string, filter
 		searchQuery.Filters["environment"] = environment
 	}
 
-	resources, err := s.resourceRepo.Search(ctx, &searchQuery)
+	resources, _, err := s.resourceRepo.Search(ctx, &searchQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform search: %w", err)
 	}
@@ -99,7 +119,7 @@ func (s *searchService) TextSearch(ctx context.Context, query string, filters Se
 		searchQuery.Filters["environment"] = environment
 	}
 
-	resources, err := s.resourceRepo.Search(ctx, &searchQuery)
+	resources, _, err := s.resourceRepo.Search(ctx, &searchQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform text search: %w", err)
 	}
@@ -125,159 +145,238 @@ func (s *searchService) TextSearch(ctx context.Context, query string, filters Se
 	return results, nil
 }
 
+// similarityCandidates is the number of resources of the same type that
+// SimilaritySearch scores when the source resource has no vector
+const similarityCandidates = 100
+
+// SimilaritySearch returns the resources most similar to a resource, best
+// first. Resources with a vector are compared by the cosine similarity of
+// their vectors; others by the attributes and tags they share with the
+// resources of their type.
 func (s *searchService) SimilaritySearch(ctx context.Context, resourceID string, limit int) ([]SearchResult, error) {
 	s.logger.Printf("Performing similarity search for resource: %s", resourceID)
 
-	// Get the source resource
 	resource, err := s.resourceRepo.GetByID(ctx, resourceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get source resource: %w", err)
 	}
 
-	// TODO: Implement actual vector similarity search
-	// For now, return resources of the same type
-	searchQuery := models.SearchQuery{
-		Type:   resource.Type,
-		Limit:  limit,
-		Offset: 0,
+	var candidates []models.Resource
+	matchType := "vector"
+	if len(resource.Vector) > 0 {
+		candidates, err = s.resourceRepo.VectorSearch(ctx, resource.Vector, 0, limit+1)
+	} else {
+		matchType = "attributes"
+		candidates, _, err = s.resourceRepo.List(ctx, &models.SearchQuery{Type: resource.Type, Limit: similarityCandidates})
 	}
-
-	resources, err := s.resourceRepo.List(ctx, &searchQuery)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find similar resources: %w", err)
 	}
 
-	// Filter out the source resource and convert to search results
-	results := make([]SearchResult, 0, len(resources))
-	for i := range resources {
-		res := &resources[i] // Pointer iteration to avoid 256-byte copy
-		if res.ID != resourceID {
-			results = append(results, SearchResult{
-				"id":          res.ID,
-				"type":        res.Type,
-				"provider":    res.Provider,
-				"name":        res.Name,
-				"data":        res.Data,
-				"metadata":    res.Metadata,
-				"score":       0.7, // TODO: Calculate actual similarity score
-				"match_type":  "similarity",
-				"created_at":  res.CreatedAt,
-				"modified_at": res.ModifiedAt,
-			})
+	results := make([]SearchResult, 0, len(candidates))
+	for i := range candidates {
+		res := &candidates[i] // Pointer iteration to avoid 256-byte copy
+		if res.ID == resource.ID {
+			continue
+		}
+		score := attributeSimilarity(resource, res)
+		if matchType == "vector" {
+			score = cosineSimilarity(resource.Vector, res.Vector)
 		}
+		results = append(results, SearchResult{
+			"id":          res.ID,
+			"type":        res.Type,
+			"provider":    res.Provider,
+			"name":        res.Name,
+			"data":        res.Data,
+			"metadata":    res.Metadata,
+			"score":       score,
+			"match_type":  matchType,
+			"created_at":  res.CreatedAt,
+			"modified_at": res.ModifiedAt,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i]["score"].(float64) > results[j]["score"].(float64)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
 	}
-
 	return results, nil
 }
 
-func (s *searchService) ScanProviders(_ context.Context, providers []string) (*ProviderScanResult, error) {
-	s.logger.Printf("Scanning providers: %v", providers)
+// cosineSimilarity returns the cosine of the angle between two vectors, or
+// 0 when they differ in length or either is zero
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
 
-	// TODO: Implement actual provider scanning
-	// This would integrate with AWS/Azure/GCP/OCI APIs to discover resources
+// attributeSimilarity returns the share of two resources' provider,
+// account, region, environment and tags that they have in common.
+// Attributes neither resource sets are not compared.
+func attributeSimilarity(a, b *models.Resource) float64 {
+	matched, compared := 0, 0
+	compare := func(x, y string) {
+		if x == "" && y == "" {
+			return
+		}
+		compared++
+		if x == y {
+			matched++
+		}
+	}
+	compare(a.Provider, b.Provider)
+	compare(a.Metadata.Account, b.Metadata.Account)
+	compare(a.Metadata.Region, b.Metadata.Region)
+	compare(a.Metadata.Environment, b.Metadata.Environment)
+	for key, value := range a.Metadata.Tags {
+		compare(value, b.Metadata.Tags[key])
+	}
+	for key, value := range b.Metadata.Tags {
+		if _, ok := a.Metadata.Tags[key]; !ok {
+			compare("", value)
+		}
+	}
+	if compared == 0 {
+		return 0
+	}
+	return float64(matched) / float64(compared)
+}
 
-	result := ProviderScanResult{
-		"scan_id":      "scan-" + generateID(),
-		"status":       "completed",
-		"providers":    providers,
-		"started_at":   getCurrentTimestamp(),
-		"completed_at": getCurrentTimestamp(),
-		"results": map[string]interface{}{
-			"total_discovered":  0,
-			"new_resources":     0,
-			"updated_resources": 0,
-			"errors":            []string{},
+// ScanProviders streams a scan of the named providers, or of every
+// registered provider when none are named, into the resource repository.
+// Batches persisted before a provider failed are kept and counted; the
+// failure is listed in the result and returned.
+func (s *searchService) ScanProviders(ctx context.Context, names []string) (*ProviderScanResult, error) {
+	if s.scanner == nil {
+		return nil, ErrScanUnavailable
+	}
+	s.logger.Printf("Scanning providers: %v", names)
+
+	startedAt := time.Now().UTC()
+	durations := make(map[string]time.Duration)
+	progress, scanErr := s.scanner.StreamAll(ctx, s.sink, providers.StreamOptions{
+		Providers: names,
+		Progress: func(p providers.ScanProgress) {
+			if p.Done {
+				durations[p.Provider] = time.Since(p.StartedAt)
+			}
 		},
+	})
+	if progress == nil && scanErr != nil {
+		return nil, scanErr
 	}
 
-	for _, provider := range providers {
-		// TODO: Implement actual provider-specific scanning
-		s.logger.Printf("Scanning provider: %s", provider)
-
-		// Placeholder implementation
-		providerResult := map[string]interface{}{
-			"provider":         provider,
-			"discovered":       0,
-			"new":              0,
-			"updated":          0,
-			"errors":           []string{},
-			"scan_duration_ms": 100,
+	var discovered, persisted int
+	errs := []string{}
+	scanned := make([]string, 0, len(progress))
+	results := make(map[string]interface{}, len(progress))
+	for _, p := range progress {
+		discovered += p.Discovered
+		persisted += p.Persisted
+		scanned = append(scanned, p.Provider)
+
+		providerErrors := []string{}
+		if p.Err != nil {
+			providerErrors = append(providerErrors, p.Err.Error())
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Provider, p.Err))
 		}
-
-		if results, ok := result["results"].(map[string]interface{}); ok {
-			results[provider] = providerResult
+		results[p.Provider] = map[string]interface{}{
+			"provider":         p.Provider,
+			"discovered":       p.Discovered,
+			"persisted":        p.Persisted,
+			"batches":          p.Batches,
+			"errors":           providerErrors,
+			"scan_duration_ms": durations[p.Provider].Milliseconds(),
 		}
 	}
+	sort.Strings(scanned)
+
+	status := "completed"
+	switch {
+	case scanErr != nil:
+		status = "failed"
+	case len(errs) > 0:
+		status = "partial"
+	}
 
-	return &result, nil
+	result := ProviderScanResult{
+		"scan_id":      NewHashIDGenerator("scan").Generate(),
+		"status":       status,
+		"providers":    scanned,
+		"started_at":   startedAt.Format(time.RFC3339),
+		"completed_at": time.Now().UTC().Format(time.RFC3339),
+		"results": map[string]interface{}{
+			"total_discovered": discovered,
+			"total_persisted":  persisted,
+			"errors":           errs,
+			"providers":        results,
+		},
+	}
+	s.logger.Printf("Provider scan %s: %d discovered, %d persisted, %d errors", status, discovered, persisted, len(errs))
+	return &result, scanErr
 }
 
+// DiscoverRelationships relates a resource to the other resources of its
+// provider in the same environment. Resources without an environment have
+// no discovered relationships.
 func (s *searchService) DiscoverRelationships(ctx context.Context, resourceID string) ([]ResourceRelationship, error) {
 	s.logger.Printf("Discovering relationships for resource: %s", resourceID)
 
-	// Get the source resource
 	resource, err := s.resourceRepo.GetByID(ctx, resourceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get source resource: %w", err)
 	}
 
-	// TODO: Implement actual relationship discovery using:
-	// - Network topology analysis
-	// - Security group references
-	// - VPC/subnet relationships
-	// - Load balancer targets
-	// - Database connections
-	// - Cross-cloud relationships (Oracle@Azure, VPN tunnels)
-
+	// TODO: Discover relationships from network topology, security group
+	// references, load balancer targets and cross-cloud links
 	relationships := []ResourceRelationship{}
+	if resource.Metadata.Environment == "" {
+		return relationships, nil
+	}
 
-	// Placeholder: Find resources in the same environment/region
 	searchQuery := models.SearchQuery{
 		Provider: resource.Provider,
 		Limit:    20,
-		Offset:   0,
 		Filters: map[string]string{
 			"environment": resource.Metadata.Environment,
 		},
 	}
-
-	relatedResources, err := s.resourceRepo.List(ctx, &searchQuery)
+	relatedResources, _, err := s.resourceRepo.List(ctx, &searchQuery)
 	if err != nil {
-		s.logger.Printf("Warning: Failed to find related resources: %v", err)
-		return relationships, nil
+		return nil, fmt.Errorf("failed to find related resources: %w", err)
 	}
 
-	// Create relationships with resources in the same environment
 	for i := range relatedResources {
 		related := &relatedResources[i] // Pointer iteration to avoid 256-byte copy
-		if related.ID != resourceID {
-			relationship := ResourceRelationship{
-				ID:         generateID(),
-				SourceID:   resourceID,
-				TargetID:   related.ID,
-				Type:       "environment",
-				Direction:  "bidirectional",
-				Confidence: 0.6,
-				Properties: map[string]interface{}{
-					"environment":   resource.Metadata.Environment,
-					"region":        resource.Metadata.Region,
-					"discovered_by": "environment_analysis",
-				},
-			}
-			relationships = append(relationships, relationship)
+		if related.ID == resource.ID {
+			continue
 		}
+		relationships = append(relationships, ResourceRelationship{
+			ID:         resource.ID + ":environment:" + related.ID,
+			SourceID:   resource.ID,
+			TargetID:   related.ID,
+			Type:       "environment",
+			Direction:  "bidirectional",
+			Confidence: 0.6,
+			Properties: map[string]interface{}{
+				"environment":   resource.Metadata.Environment,
+				"region":        related.Metadata.Region,
+				"discovered_by": "environment_analysis",
+			},
+		})
 	}
-
 	return relationships, nil
 }
-
-// Helper functions
-func generateID() string {
-	// TODO: Use proper ID generation
-	return "placeholder-id"
-}
-
-func getCurrentTimestamp() string {
-	// TODO: Use proper timestamp formatting
-	return "2024-01-01T00:00:00Z"
-}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/config"
+	"github.com/LederWorks/siros/backend/internal/identity"
+	"github.com/LederWorks/siros/backend/internal/models"
+	"github.com/LederWorks/siros/backend/internal/providers"
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// fakeScanProvider returns fixed resources and an optional error from Scan
+type fakeScanProvider struct {
+	name      string
+	resources []types.Resource
+	err       error
+}
+
+func (p *fakeScanProvider) Name() string { return p.name }
+
+func (p *fakeScanProvider) Scan(_ context.Context) ([]types.Resource, error) {
+	return p.resources, p.err
+}
+
+func (p *fakeScanProvider) GetResource(_ string) (*types.Resource, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *fakeScanProvider) Validate() error { return nil }
+
+func TestSearchService_ScanProviders(t *testing.T) {
+	manager := providers.NewManager(&config.ProvidersConfig{})
+	manager.RegisterProvider("aws", &fakeScanProvider{name: "aws", resources: []types.Resource{
+		{ID: "i-1", Type: "ec2.instance", Provider: "aws", Region: "eu-west-1", Metadata: map[string]interface{}{"account_id": "111"}},
+		{ID: "i-2", Type: "ec2.instance", Provider: "aws", Region: "eu-west-1", Metadata: map[string]interface{}{"account_id": "111"}},
+	}})
+	manager.RegisterProvider("gcp", &fakeScanProvider{name: "gcp", err: errors.New("credentials expired")})
+
+	repo := newMockResourceRepository()
+	service := NewSearchService(repo, manager, identity.NewResolver(&fakeAliasStore{}), log.New(io.Discard, "", 0))
+
+	result, err := service.ScanProviders(t.Context(), []string{"aws"})
+	if err != nil {
+		t.Fatalf("ScanProviders failed: %v", err)
+	}
+	totals := (*result)["results"].(map[string]interface{})
+	if (*result)["status"] != "completed" || totals["total_discovered"] != 2 || totals["total_persisted"] != 2 {
+		t.Errorf("Unexpected scan result: %+v", *result)
+	}
+	if len(repo.resources) != 2 {
+		t.Errorf("Expected 2 scanned resources to be stored, got %d", len(repo.resources))
+	}
+
+	result, err = service.ScanProviders(t.Context(), nil)
+	if err == nil {
+		t.Fatal("Expected the failed provider to be reported")
+	}
+	if result == nil || (*result)["status"] != "failed" {
+		t.Fatalf("Expected a failed scan result, got %+v", result)
+	}
+	totals = (*result)["results"].(map[string]interface{})
+	if errs := totals["errors"].([]string); len(errs) != 1 || totals["total_persisted"] != 2 {
+		t.Errorf("Expected the gcp failure alongside the aws counts, got %+v", totals)
+	}
+
+	if _, err := service.ScanProviders(t.Context(), []string{"azure"}); !errors.Is(err, providers.ErrProviderNotFound) {
+		t.Errorf("Expected ErrProviderNotFound for an unregistered provider, got %v", err)
+	}
+
+	unconfigured := NewSearchService(repo, nil, identity.NewResolver(&fakeAliasStore{}), log.New(io.Discard, "", 0))
+	if _, err := unconfigured.ScanProviders(t.Context(), nil); !errors.Is(err, ErrScanUnavailable) {
+		t.Errorf("Expected ErrScanUnavailable without a scanner, got %v", err)
+	}
+}
+
+func TestSearchService_SimilaritySearch(t *testing.T) {
+	repo := newMockResourceRepository()
+	for _, resource := range []*models.Resource{
+		{ID: "web-1", Type: "vm", Provider: "aws", Metadata: models.ResourceMetadata{Region: "eu-west-1", Environment: "prod", Tags: map[string]string{"team": "web"}}},
+		{ID: "web-2", Type: "vm", Provider: "aws", Metadata: models.ResourceMetadata{Region: "eu-west-1", Environment: "prod", Tags: map[string]string{"team": "web"}}},
+		{ID: "batch-1", Type: "vm", Provider: "aws", Metadata: models.ResourceMetadata{Region: "us-east-1", Environment: "prod", Tags: map[string]string{"team": "data"}}},
+		{ID: "db-1", Type: "db", Provider: "aws", Vector: []float32{1, 0}},
+		{ID: "db-2", Type: "db", Provider: "aws", Vector: []float32{1, 1}},
+		{ID: "db-3", Type: "db", Provider: "aws", Vector: []float32{0, 1}},
+	} {
+		repo.resources[resource.ID] = resource
+	}
+	service := NewSearchService(repo, nil, identity.NewResolver(&fakeAliasStore{}), log.New(io.Discard, "", 0))
+
+	results, err := service.SimilaritySearch(t.Context(), "web-1", 10)
+	if err != nil {
+		t.Fatalf("SimilaritySearch failed: %v", err)
+	}
+	// The mock ignores the type filter, so the databases score too
+	if len(results) != 5 || results[0]["id"] != "web-2" || results[0]["score"] != 1.0 || results[0]["match_type"] != "attributes" {
+		t.Fatalf("Expected web-2 first with a full attribute match, got %v", results)
+	}
+	if results[1]["id"] != "batch-1" || results[1]["score"] != 0.5 {
+		t.Errorf("Expected batch-1 second sharing half its attributes, got %v", results[1])
+	}
+
+	results, err = service.SimilaritySearch(t.Context(), "db-1", 1)
+	if err != nil {
+		t.Fatalf("SimilaritySearch failed: %v", err)
+	}
+	if len(results) != 1 || results[0]["id"] != "db-2" || results[0]["match_type"] != "vector" {
+		t.Fatalf("Expected only db-2 by vector, got %v", results)
+	}
+	if score := results[0]["score"].(float64); score < 0.707 || score > 0.708 {
+		t.Errorf("Expected the cosine similarity of db-2, got %v", score)
+	}
+}
+
+func TestSearchService_DiscoverRelationships(t *testing.T) {
+	repo := newMockResourceRepository()
+	repo.resources["web-1"] = &models.Resource{ID: "web-1", Provider: "aws", Metadata: models.ResourceMetadata{Environment: "prod"}}
+	repo.resources["web-2"] = &models.Resource{ID: "web-2", Provider: "aws", Metadata: models.ResourceMetadata{Environment: "prod", Region: "eu-west-1"}}
+	repo.resources["scratch"] = &models.Resource{ID: "scratch", Provider: "aws"}
+	service := NewSearchService(repo, nil, identity.NewResolver(&fakeAliasStore{}), log.New(io.Discard, "", 0))
+
+	relationships, err := service.DiscoverRelationships(t.Context(), "web-1")
+	if err != nil {
+		t.Fatalf("DiscoverRelationships failed: %v", err)
+	}
+	ids := map[string]bool{}
+	for _, relationship := range relationships {
+		ids[relationship.ID] = true
+	}
+	if !ids["web-1:environment:web-2"] {
+		t.Errorf("Expected a relationship identified by its ends, got %+v", relationships)
+	}
+
+	relationships, err = service.DiscoverRelationships(t.Context(), "scratch")
+	if err != nil || len(relationships) != 0 {
+		t.Errorf("Expected no relationships without an environment, got %+v %v", relationships, err)
+	}
+}
@@ -4,16 +4,24 @@ import (
 	"context"
 	"log"
 
+	"github.com/LederWorks/siros/backend/internal/identity"
 	"github.com/LederWorks/siros/backend/internal/repositories"
 )
 
 // Services holds all service instances
 type Services struct {
-	Resource  ResourceService
-	Search    SearchService
-	Schema    SchemaService
-	Terraform TerraformService
-	MCP       MCPService
+	Resource    ResourceService
+	Import      ImportService
+	Export      ExportService
+	Identity    IdentityService
+	Audit       AuditService
+	Blockchain  BlockchainService
+	Search      SearchService
+	SavedSearch SavedSearchService
+	Proposal    ProposalService
+	Schema      SchemaService
+	Terraform   TerraformService
+	MCP         MCPService
 }
 
 // SearchService defines the interface for search operations
@@ -28,7 +36,8 @@ type SearchService interface {
 // MCPService defines the interface for Model Context Protocol operations
 type MCPService interface {
 	Initialize(ctx context.Context, req MCPInitRequest) (*MCPInitResponse, error)
-	ListResources(ctx context.Context) ([]MCPResource, error)
+	ListResources(ctx context.Context, cursor string) ([]MCPResource, string, error)
+	ListResourceTemplates(ctx context.Context) ([]MCPResourceTemplate, error)
 	ReadResource(ctx context.Context, uri string) (*MCPResourceContent, error)
 	ListTools(ctx context.Context) ([]MCPTool, error)
 	CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*MCPToolResult, error)
@@ -54,20 +63,38 @@ type ResourceRelationship struct {
 type MCPInitRequest map[string]interface{}
 type MCPInitResponse map[string]interface{}
 type MCPResource map[string]interface{}
+type MCPResourceTemplate map[string]interface{}
 type MCPResourceContent map[string]interface{}
 type MCPTool map[string]interface{}
 type MCPToolResult map[string]interface{}
 type MCPPrompt map[string]interface{}
 type MCPPromptResult map[string]interface{}
 
-// NewServices creates a new Services instance with all services
-func NewServices(repos *repositories.Repositories, logger *log.Logger) *Services {
+// NewServices creates a new Services instance with all services. Provider
+// scans run through scanner, which may be nil when no providers are configured.
+func NewServices(repos *repositories.Repositories, scanner ProviderScanner, logger *log.Logger) *Services {
+	resolver := identity.NewResolver(repos.Identity)
+	savedSearches := NewSavedSearchService(repos.Search, repos.Resource, logger)
+	validator := NewSchemaValidator(repos.Schema, logger)
+	resources := NewSimpleResourceService(repos.Resource, resolver, validator, logger)
+	search := NewSearchService(repos.Resource, scanner, resolver, logger)
+	blockchain := NewBlockchainService(repos.Blockchain, logger)
+	schemas := NewSchemaService(repos.Schema, repos.Resource, repos.Blockchain, validator, logger)
+	proposals := NewProposalService(repos.Proposal, resources, blockchain, validator, logger)
+
 	// Create simplified services for now
 	return &Services{
-		Resource:  NewSimpleResourceService(repos.Resource, logger),
-		Search:    NewSearchService(repos.Resource, logger),
-		Schema:    NewSchemaService(repos.Schema, logger),
-		Terraform: NewTerraformService(repos.Resource, logger),
-		MCP:       NewMCPService(repos.Resource, logger),
+		Resource:    resources,
+		Import:      NewImportService(repos.Resource, repos.Blockchain, repos.Import, resolver, validator, logger),
+		Export:      NewExportService(repos.Resource, logger),
+		Identity:    NewIdentityService(repos.Resource, repos.Identity, repos.Blockchain, resolver, logger),
+		Audit:       NewAuditService(repos.Blockchain, logger),
+		Blockchain:  blockchain,
+		Search:      search,
+		SavedSearch: savedSearches,
+		Proposal:    proposals,
+		Schema:      schemas,
+		Terraform:   NewTerraformService(repos.Resource, logger),
+		MCP:         NewMCPService(resources, search, blockchain, schemas, savedSearches, proposals, logger),
 	}
 }
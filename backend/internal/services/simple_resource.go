@@ -3,10 +3,12 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/LederWorks/siros/backend/internal/identity"
 	"github.com/LederWorks/siros/backend/internal/models"
 	"github.com/LederWorks/siros/backend/internal/repositories"
 )
@@ -14,13 +16,19 @@ import (
 // simpleResourceService is a simplified implementation of ResourceService
 type simpleResourceService struct {
 	resourceRepo repositories.ResourceRepository
+	identity     *identity.Resolver
+	validator    *SchemaValidator
 	logger       *log.Logger
 }
 
-// NewSimpleResourceService creates a simplified resource service
-func NewSimpleResourceService(resourceRepo repositories.ResourceRepository, logger *log.Logger) ResourceService {
+// NewSimpleResourceService creates a simplified resource service. Resources
+// created with a native ID or ARN are stored under their derived Siros ID,
+// and their data is validated against the schema registered for their type.
+func NewSimpleResourceService(resourceRepo repositories.ResourceRepository, resolver *identity.Resolver, validator *SchemaValidator, logger *log.Logger) ResourceService {
 	return &simpleResourceService{
 		resourceRepo: resourceRepo,
+		identity:     resolver,
+		validator:    validator,
 		logger:       logger,
 	}
 }
@@ -44,23 +52,64 @@ func (s *simpleResourceService) CreateResource(ctx context.Context, req *models.
 	// Convert request to resource model
 	resource := req.ToResource()
 
-	// Generate unique ID
-	resource.ID = s.generateID()
+	// Derive the ID from the natural key when there is one, so that scans
+	// and imports of the same resource find it
+	ref := identity.ForStored(resource)
+	if ref.Valid() {
+		id, err := s.existingID(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			return nil, fmt.Errorf("resource already exists: %s", id)
+		}
+		resource.ID = ref.ID()
+	} else {
+		resource.ID = s.generateID()
+	}
 
 	// Validate the resource
 	if err := resource.Validate(); err != nil {
 		return nil, fmt.Errorf("resource validation failed: %w", err)
 	}
+	if err := s.validator.Check(ctx, resource); err != nil {
+		return nil, err
+	}
 
 	// Store the resource
 	if err := s.resourceRepo.Create(ctx, resource); err != nil {
 		return nil, fmt.Errorf("failed to store resource: %w", err)
 	}
+	if ref.Valid() {
+		if err := s.identity.Register(ctx, []string{resource.ID}, []identity.Ref{ref}, "api"); err != nil {
+			return nil, err
+		}
+	}
 
 	s.logger.Printf("Created resource: %s", resource.ID)
 	return resource, nil
 }
 
+// existingID returns the ID of the stored resource ref names, or ""
+func (s *simpleResourceService) existingID(ctx context.Context, ref identity.Ref) (string, error) {
+	ids, err := s.identity.Lookup(ctx, []identity.Ref{ref})
+	if err != nil {
+		return "", err
+	}
+	if ids[0] != "" {
+		return ids[0], nil
+	}
+	_, err = s.resourceRepo.GetByID(ctx, ref.ID())
+	switch {
+	case err == nil:
+		return ref.ID(), nil
+	case errors.Is(err, repositories.ErrNotFound):
+		return "", nil
+	default:
+		return "", fmt.Errorf("failed to get resource: %w", err)
+	}
+}
+
 func (s *simpleResourceService) GetResource(ctx context.Context, id string) (*models.Resource, error) {
 	if id == "" {
 		return nil, fmt.Errorf("resource ID is required")
@@ -94,13 +143,20 @@ func (s *simpleResourceService) UpdateResource(ctx context.Context, id string, r
 		return nil, fmt.Errorf("failed to get resource: %w", err)
 	}
 
-	// Apply updates
+	// Apply updates, keeping the schema version pin unless it is replaced
+	pinned := resource.Metadata.SchemaVersion
 	req.ApplyTo(resource, modifiedBy)
+	if resource.Metadata.SchemaVersion == "" {
+		resource.Metadata.SchemaVersion = pinned
+	}
 
 	// Validate updated resource
 	if err := resource.Validate(); err != nil {
 		return nil, fmt.Errorf("updated resource validation failed: %w", err)
 	}
+	if err := s.validator.Check(ctx, resource); err != nil {
+		return nil, err
+	}
 
 	// Update the resource
 	if err := s.resourceRepo.Update(ctx, resource); err != nil {
@@ -129,34 +185,34 @@ func (s *simpleResourceService) DeleteResource(ctx context.Context, id, deletedB
 	return nil
 }
 
-func (s *simpleResourceService) ListResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error) {
+func (s *simpleResourceService) ListResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
 	// Validate and set defaults
 	if err := query.Validate(); err != nil {
-		return nil, fmt.Errorf("query validation failed: %w", err)
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
 	}
 	query.SetDefaults()
 
-	resources, err := s.resourceRepo.List(ctx, query)
+	resources, page, err := s.resourceRepo.List(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list resources: %w", err)
+		return nil, nil, fmt.Errorf("failed to list resources: %w", err)
 	}
 
-	return resources, nil
+	return resources, page, nil
 }
 
-func (s *simpleResourceService) SearchResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, error) {
+func (s *simpleResourceService) SearchResources(ctx context.Context, query *models.SearchQuery) ([]models.Resource, *models.PageInfo, error) {
 	// Validate and set defaults
 	if err := query.Validate(); err != nil {
-		return nil, fmt.Errorf("query validation failed: %w", err)
+		return nil, nil, fmt.Errorf("query validation failed: %w", err)
 	}
 	query.SetDefaults()
 
-	resources, err := s.resourceRepo.Search(ctx, query)
+	resources, page, err := s.resourceRepo.Search(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search resources: %w", err)
+		return nil, nil, fmt.Errorf("failed to search resources: %w", err)
 	}
 
-	return resources, nil
+	return resources, page, nil
 }
 
 func (s *simpleResourceService) GetResourcesByParent(ctx context.Context, parentID string) ([]models.Resource, error) {
@@ -6,19 +6,21 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/LederWorks/siros/backend/internal/storage"
+	"github.com/LederWorks/siros/backend/internal/providers"
 	"github.com/LederWorks/siros/backend/pkg/types"
 )
 
 // StateImporter handles Terraform state imports
 type StateImporter struct {
-	storage *storage.Storage
+	sink providers.ResourceSink
 }
 
-// NewStateImporter creates a new Terraform state importer
-func NewStateImporter(storage *storage.Storage) *StateImporter {
+// NewStateImporter creates a new Terraform state importer. Resources are
+// written through sink, so they resolve to the same Siros IDs as scanned
+// ones.
+func NewStateImporter(sink providers.ResourceSink) *StateImporter {
 	return &StateImporter{
-		storage: storage,
+		sink: sink,
 	}
 }
 
@@ -40,10 +42,9 @@ func (si *StateImporter) ImportState(ctx context.Context, state *types.Terraform
 	}
 
 	// Store resources in database
-	for i := range resources {
-		resource := &resources[i] // Pointer iteration to avoid 264-byte copy
-		if err := si.storage.CreateResource(ctx, resource); err != nil {
-			log.Printf("Failed to store resource %s: %v", resource.ID, err)
+	if len(resources) > 0 {
+		if err := si.sink.UpsertResources(ctx, resources); err != nil {
+			return nil, fmt.Errorf("failed to store resources: %w", err)
 		}
 	}
 
@@ -122,6 +123,15 @@ func mapTerraformType(tfType string) string {
 		"aws_vpc":                 "ec2.vpc",
 		"aws_subnet":              "ec2.subnet",
 		"aws_security_group":      "ec2.security_group",
+		"aws_iam_role":            "iam.role",
+		"aws_iam_policy":          "iam.policy",
+		"aws_eks_cluster":         "eks.cluster",
+		"aws_lb":                  "elbv2.load_balancer",
+		"aws_alb":                 "elbv2.load_balancer",
+		"aws_dynamodb_table":      "dynamodb.table",
+		"aws_sqs_queue":           "sqs.queue",
+		"aws_sns_topic":           "sns.topic",
+		"aws_kms_key":             "kms.key",
 		"azurerm_virtual_machine": "azure.virtualmachine",
 		"azurerm_storage_account": "azure.storageaccount",
 		"google_compute_instance": "gcp.compute.instance",
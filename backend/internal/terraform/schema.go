@@ -0,0 +1,403 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
+	"github.com/LederWorks/siros/backend/internal/models"
+)
+
+// ProviderSchemas is the output of `terraform providers schema -json`.
+// Selections optionally carries the provider versions in use by source
+// address, as printed under the same key by `terraform version -json`.
+type ProviderSchemas struct {
+	FormatVersion string                    `json:"format_version"`
+	Schemas       map[string]ProviderSchema `json:"provider_schemas"`
+	Selections    map[string]string         `json:"provider_selections,omitempty"`
+}
+
+// ProviderSchema holds the schemas of one provider's resource types
+type ProviderSchema struct {
+	ResourceSchemas map[string]ResourceSchema `json:"resource_schemas"`
+}
+
+// ResourceSchema is the schema of a resource type
+type ResourceSchema struct {
+	Version int64 `json:"version"`
+	Block   Block `json:"block"`
+}
+
+// Block is a configuration block with attributes and nested blocks
+type Block struct {
+	Attributes  map[string]Attribute   `json:"attributes,omitempty"`
+	BlockTypes  map[string]NestedBlock `json:"block_types,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Deprecated  bool                   `json:"deprecated,omitempty"`
+}
+
+// Attribute is a block attribute, with either a type or nested attributes
+type Attribute struct {
+	Type        json.RawMessage   `json:"type,omitempty"`
+	NestedType  *NestedAttributes `json:"nested_type,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Required    bool              `json:"required,omitempty"`
+	Optional    bool              `json:"optional,omitempty"`
+	Computed    bool              `json:"computed,omitempty"`
+	Sensitive   bool              `json:"sensitive,omitempty"`
+	Deprecated  bool              `json:"deprecated,omitempty"`
+}
+
+// NestedAttributes are the attributes of a nested attribute type
+type NestedAttributes struct {
+	Attributes  map[string]Attribute `json:"attributes"`
+	NestingMode string               `json:"nesting_mode"`
+}
+
+// NestedBlock is a block type nested within a block
+type NestedBlock struct {
+	NestingMode string `json:"nesting_mode"`
+	Block       Block  `json:"block"`
+	MinItems    int    `json:"min_items,omitempty"`
+	MaxItems    int    `json:"max_items,omitempty"`
+}
+
+// ParseProviderSchemas parses the output of `terraform providers schema
+// -json`
+func ParseProviderSchemas(data []byte) (*ProviderSchemas, error) {
+	var schemas ProviderSchemas
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("failed to parse Terraform provider schemas: %w", err)
+	}
+	if err := schemas.Validate(); err != nil {
+		return nil, err
+	}
+	return &schemas, nil
+}
+
+// Validate checks that the schemas are in a format version this package
+// reads
+func (ps *ProviderSchemas) Validate() error {
+	if major, _, _ := strings.Cut(ps.FormatVersion, "."); major != "1" {
+		return fmt.Errorf("unsupported Terraform provider schema format version %q", ps.FormatVersion)
+	}
+	return nil
+}
+
+// CatalogEntry is the Siros schema generated for a Siros resource type.
+// Terraform types mapped to the same Siros type share one schema.
+type CatalogEntry struct {
+	// Source is the provider's source address, such as
+	// registry.terraform.io/hashicorp/aws
+	Source string
+	// Version is the provider version selected for the source, if known
+	Version        string
+	TerraformTypes []string
+	Schema         models.Schema
+}
+
+// Catalog converts every resource type of the provider schemas to a
+// predefined Siros schema for the resource type mapTerraformType links it
+// to. The Terraform attributes are described under data.attributes, where
+// state imports store them; the schemas are in warn mode, as scanned
+// resources of the same types carry other data. Entries are ordered by
+// provider and type, and Version is left for the caller to assign.
+func (ps *ProviderSchemas) Catalog() ([]CatalogEntry, error) {
+	type key struct{ provider, resourceType string }
+	entries := map[key]*CatalogEntry{}
+	definitions := map[key][]interface{}{}
+
+	for _, source := range sortedKeys(ps.Schemas) {
+		provider := extractProvider(source)
+		resourceSchemas := ps.Schemas[source].ResourceSchemas
+		for _, tfType := range sortedKeys(resourceSchemas) {
+			resourceSchema := resourceSchemas[tfType]
+			attributes, err := resourceSchema.Block.jsonSchema()
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert %s: %w", tfType, err)
+			}
+
+			k := key{provider, mapTerraformType(tfType)}
+			entry, ok := entries[k]
+			if !ok {
+				entry = &CatalogEntry{
+					Source:  source,
+					Version: ps.Selections[source],
+					Schema: models.Schema{
+						Name:        tfType,
+						Provider:    provider,
+						Type:        k.resourceType,
+						Mode:        models.SchemaModeWarn,
+						Description: firstLine(resourceSchema.Block.Description),
+					},
+				}
+				entries[k] = entry
+			}
+			entry.TerraformTypes = append(entry.TerraformTypes, tfType)
+			if !containsDefinition(definitions[k], attributes) {
+				definitions[k] = append(definitions[k], attributes)
+			}
+		}
+	}
+
+	catalog := make([]CatalogEntry, 0, len(entries))
+	for k, entry := range entries {
+		var attributes interface{} = definitions[k][0]
+		if len(definitions[k]) > 1 {
+			attributes = map[string]interface{}{"anyOf": definitions[k]}
+		}
+		entry.Schema.Schema = map[string]interface{}{
+			"$schema": jsonschema.Draft,
+			"title":   strings.Join(entry.TerraformTypes, ", "),
+			"type":    "object",
+			"properties": map[string]interface{}{
+				"attributes":     attributes,
+				"terraform_type": map[string]interface{}{"enum": stringsToValues(entry.TerraformTypes)},
+			},
+		}
+		catalog = append(catalog, *entry)
+	}
+	sort.Slice(catalog, func(i, j int) bool {
+		a, b := catalog[i].Schema, catalog[j].Schema
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Type < b.Type
+	})
+	return catalog, nil
+}
+
+// jsonSchema converts a block to the JSON Schema of its values in state.
+// Attributes that are not required may be null there.
+func (b *Block) jsonSchema() (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	var required []interface{}
+
+	for _, name := range sortedKeys(b.Attributes) {
+		attribute := b.Attributes[name]
+		schema, err := attribute.jsonSchema()
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		if attribute.Required {
+			required = append(required, name)
+		} else {
+			schema = nullable(schema)
+		}
+		properties[name] = schema
+	}
+
+	for _, name := range sortedKeys(b.BlockTypes) {
+		nested := b.BlockTypes[name]
+		schema, err := nested.jsonSchema()
+		if err != nil {
+			return nil, fmt.Errorf("block %s: %w", name, err)
+		}
+		if nested.MinItems > 0 {
+			required = append(required, name)
+		}
+		properties[name] = schema
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	annotate(schema, b.Description, b.Deprecated)
+	return schema, nil
+}
+
+func (a *Attribute) jsonSchema() (map[string]interface{}, error) {
+	var schema map[string]interface{}
+	var err error
+	switch {
+	case a.NestedType != nil:
+		schema, err = a.NestedType.jsonSchema()
+	case len(a.Type) > 0:
+		var t interface{}
+		if err = json.Unmarshal(a.Type, &t); err == nil {
+			schema, err = typeSchema(t)
+		}
+	default:
+		err = fmt.Errorf("has neither a type nor nested attributes")
+	}
+	if err != nil {
+		return nil, err
+	}
+	annotate(schema, a.Description, a.Deprecated)
+	if a.Sensitive {
+		schema["writeOnly"] = true
+	}
+	return schema, nil
+}
+
+func (n *NestedAttributes) jsonSchema() (map[string]interface{}, error) {
+	block := Block{Attributes: n.Attributes}
+	object, err := block.jsonSchema()
+	if err != nil {
+		return nil, err
+	}
+	return nest(n.NestingMode, object, 0, 0)
+}
+
+func (n *NestedBlock) jsonSchema() (map[string]interface{}, error) {
+	object, err := n.Block.jsonSchema()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := nest(n.NestingMode, object, n.MinItems, n.MaxItems)
+	if err != nil {
+		return nil, err
+	}
+	if n.NestingMode == "single" && n.MinItems == 0 {
+		schema = nullable(schema)
+	}
+	return schema, nil
+}
+
+// nest wraps the schema of a nested object for its nesting mode
+func nest(mode string, object map[string]interface{}, minItems, maxItems int) (map[string]interface{}, error) {
+	var schema map[string]interface{}
+	switch mode {
+	case "single", "group":
+		return object, nil
+	case "list", "set":
+		schema = map[string]interface{}{"type": "array", "items": object}
+		if mode == "set" {
+			schema["uniqueItems"] = true
+		}
+		if minItems > 0 {
+			schema["minItems"] = minItems
+		}
+		if maxItems > 0 {
+			schema["maxItems"] = maxItems
+		}
+	case "map":
+		schema = map[string]interface{}{"type": "object", "additionalProperties": object}
+	default:
+		return nil, fmt.Errorf("unknown nesting mode %q", mode)
+	}
+	return schema, nil
+}
+
+// typeSchema converts a Terraform type in its JSON form, such as "string"
+// or ["list", "number"], to JSON Schema
+func typeSchema(t interface{}) (map[string]interface{}, error) {
+	switch t := t.(type) {
+	case string:
+		switch t {
+		case "string", "number":
+			return map[string]interface{}{"type": t}, nil
+		case "bool":
+			return map[string]interface{}{"type": "boolean"}, nil
+		case "dynamic":
+			return map[string]interface{}{}, nil
+		}
+	case []interface{}:
+		if len(t) < 2 {
+			break
+		}
+		kind, _ := t[0].(string)
+		switch kind {
+		case "list", "set", "map":
+			element, err := typeSchema(t[1])
+			if err != nil {
+				return nil, err
+			}
+			if kind == "map" {
+				return map[string]interface{}{"type": "object", "additionalProperties": nullable(element)}, nil
+			}
+			schema := map[string]interface{}{"type": "array", "items": element}
+			if kind == "set" {
+				schema["uniqueItems"] = true
+			}
+			return schema, nil
+		case "object":
+			attributes, ok := t[1].(map[string]interface{})
+			if !ok {
+				break
+			}
+			properties := map[string]interface{}{}
+			for _, name := range sortedKeys(attributes) {
+				schema, err := typeSchema(attributes[name])
+				if err != nil {
+					return nil, fmt.Errorf("object attribute %s: %w", name, err)
+				}
+				properties[name] = nullable(schema)
+			}
+			return map[string]interface{}{"type": "object", "properties": properties}, nil
+		case "tuple":
+			elements, ok := t[1].([]interface{})
+			if !ok {
+				break
+			}
+			items := make([]interface{}, len(elements))
+			for i, element := range elements {
+				schema, err := typeSchema(element)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = schema
+			}
+			return map[string]interface{}{
+				"type": "array", "prefixItems": items,
+				"minItems": len(items), "maxItems": len(items),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %v", t)
+}
+
+// nullable lets a schema also accept null
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	switch t := schema["type"].(type) {
+	case string:
+		schema["type"] = []interface{}{t, "null"}
+	case []interface{}:
+		schema["type"] = append(t, "null")
+	}
+	return schema
+}
+
+func annotate(schema map[string]interface{}, description string, deprecated bool) {
+	if description != "" {
+		schema["description"] = description
+	}
+	if deprecated {
+		schema["deprecated"] = true
+	}
+}
+
+func containsDefinition(definitions []interface{}, definition interface{}) bool {
+	encoded, _ := json.Marshal(definition)
+	for _, d := range definitions {
+		if other, _ := json.Marshal(d); string(other) == string(encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func stringsToValues(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
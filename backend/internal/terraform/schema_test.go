@@ -0,0 +1,120 @@
+package terraform
+
+import (
+	"testing"
+
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
+)
+
+const testProviderSchemas = `{
+  "format_version": "1.0",
+  "provider_schemas": {
+    "registry.terraform.io/hashicorp/aws": {
+      "resource_schemas": {
+        "aws_instance": {
+          "version": 1,
+          "block": {
+            "description": "Provides an EC2 instance resource.\nMore text.",
+            "attributes": {
+              "ami": {"type": "string", "required": true},
+              "tags": {"type": ["map", "string"], "optional": true},
+              "cpu_count": {"type": "number", "computed": true},
+              "password": {"type": "string", "optional": true, "sensitive": true}
+            },
+            "block_types": {
+              "ebs_block_device": {
+                "nesting_mode": "set",
+                "block": {"attributes": {"volume_size": {"type": "number", "optional": true}}}
+              },
+              "credit_specification": {
+                "nesting_mode": "list",
+                "max_items": 1,
+                "block": {"attributes": {"cpu_credits": {"type": "string", "optional": true}}}
+              }
+            }
+          }
+        },
+        "aws_lb": {"version": 0, "block": {"attributes": {"name": {"type": "string", "optional": true}}}},
+        "aws_alb": {"version": 0, "block": {"attributes": {"name": {"type": "string", "optional": true}}}}
+      }
+    }
+  },
+  "provider_selections": {"registry.terraform.io/hashicorp/aws": "5.31.0"}
+}`
+
+func TestProviderSchemas_Catalog(t *testing.T) {
+	schemas, err := ParseProviderSchemas([]byte(testProviderSchemas))
+	if err != nil {
+		t.Fatalf("ParseProviderSchemas() error = %v", err)
+	}
+	catalog, err := schemas.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog() error = %v", err)
+	}
+	if len(catalog) != 2 {
+		t.Fatalf("Catalog() = %d entries, want 2", len(catalog))
+	}
+
+	instance, lb := catalog[0], catalog[1]
+	if instance.Schema.Name != "aws_instance" || instance.Schema.Type != "ec2.instance" || instance.Schema.Provider != "aws" ||
+		instance.Version != "5.31.0" || instance.Schema.Description != "Provides an EC2 instance resource." {
+		t.Errorf("Catalog()[0] = %+v", instance)
+	}
+	if lb.Schema.Name != "aws_alb" || lb.Schema.Type != "elbv2.load_balancer" || len(lb.TerraformTypes) != 2 {
+		t.Errorf("Catalog()[1] = %+v, want aws_alb and aws_lb sharing a schema", lb)
+	}
+	if instance.Schema.IsCustom || instance.Schema.Mode != "warn" {
+		t.Errorf("Catalog() schema is custom %t in mode %q, want a predefined warn schema", instance.Schema.IsCustom, instance.Schema.Mode)
+	}
+
+	compiled, err := jsonschema.Compile(instance.Schema.Schema)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	tests := []struct {
+		name  string
+		data  map[string]interface{}
+		valid bool
+	}{
+		{name: "full", valid: true, data: map[string]interface{}{
+			"terraform_type": "aws_instance",
+			"attributes": map[string]interface{}{
+				"ami": "ami-1", "tags": map[string]interface{}{"Name": "web"}, "cpu_count": 2.0, "password": nil,
+				"ebs_block_device":     []interface{}{map[string]interface{}{"volume_size": 8.0}},
+				"credit_specification": []interface{}{},
+			},
+		}},
+		{name: "scanned data without attributes", valid: true, data: map[string]interface{}{"instance_type": "t3.micro"}},
+		{name: "missing required attribute", data: map[string]interface{}{"attributes": map[string]interface{}{"cpu_count": 2.0}}},
+		{name: "wrong attribute type", data: map[string]interface{}{"attributes": map[string]interface{}{"ami": 1.0}}},
+		{name: "too many blocks", data: map[string]interface{}{"attributes": map[string]interface{}{
+			"ami": "ami-1", "credit_specification": []interface{}{map[string]interface{}{}, map[string]interface{}{}},
+		}}},
+		{name: "other terraform type", data: map[string]interface{}{"terraform_type": "aws_lb"}},
+	}
+	for _, tt := range tests {
+		if violations := compiled.Validate(tt.data); (len(violations) == 0) != tt.valid {
+			t.Errorf("%s: violations = %v, want valid %t", tt.name, violations, tt.valid)
+		}
+	}
+}
+
+func TestTypeSchema(t *testing.T) {
+	valid := []string{`"bool"`, `"dynamic"`, `["set", "string"]`, `["object", {"a": "number"}]`, `["tuple", ["string", "bool"]]`}
+	for _, raw := range valid {
+		attribute := Attribute{Type: []byte(raw)}
+		if _, err := attribute.jsonSchema(); err != nil {
+			t.Errorf("jsonSchema(%s) error = %v", raw, err)
+		}
+	}
+	for _, raw := range []string{`"int"`, `["list"]`, `["object", "a"]`} {
+		attribute := Attribute{Type: []byte(raw)}
+		if _, err := attribute.jsonSchema(); err == nil {
+			t.Errorf("jsonSchema(%s) accepted an unsupported type", raw)
+		}
+	}
+
+	if _, err := ParseProviderSchemas([]byte(`{"format_version": "2.0"}`)); err == nil {
+		t.Error("ParseProviderSchemas() accepted format version 2.0")
+	}
+}
@@ -2,9 +2,12 @@ package views
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/LederWorks/siros/backend/internal/jsonschema"
 	"github.com/LederWorks/siros/backend/internal/models"
 )
 
@@ -15,18 +18,42 @@ type APIResponse struct {
 	Meta  *Meta       `json:"meta,omitempty"`
 }
 
-// APIError represents error information in API responses
+// APIError represents error information in API responses. Violations lists
+// the schema violations of rejected resource data.
 type APIError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Code       string             `json:"code"`
+	Message    string             `json:"message"`
+	Details    string             `json:"details,omitempty"`
+	Violations []jsonschema.Error `json:"violations,omitempty"`
 }
 
-// Meta contains metadata about the response
+// Meta contains metadata about the response. For paginated lists, Count is
+// the size of the page, the cursors select the pages around it, and Total,
+// when requested, counts every matching item.
 type Meta struct {
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
-	Count     *int      `json:"count,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+	Version        string    `json:"version"`
+	Count          *int      `json:"count,omitempty"`
+	NextCursor     string    `json:"next_cursor,omitempty"`
+	PrevCursor     string    `json:"prev_cursor,omitempty"`
+	Total          *int64    `json:"total,omitempty"`
+	TotalEstimated bool      `json:"total_estimated,omitempty"`
+}
+
+// listMeta returns the metadata of a page of count items
+func listMeta(count int, page *models.PageInfo) *Meta {
+	meta := &Meta{
+		Timestamp: time.Now(),
+		Version:   "1.0",
+		Count:     &count,
+	}
+	if page != nil {
+		meta.NextCursor = page.NextCursor
+		meta.PrevCursor = page.PrevCursor
+		meta.Total = page.Total
+		meta.TotalEstimated = page.TotalEstimated
+	}
+	return meta
 }
 
 // WriteJSONResponse writes a standardized JSON response
@@ -52,16 +79,14 @@ func WriteResourceResponse(w http.ResponseWriter, status int, resource *models.R
 	WriteJSONResponse(w, status, response)
 }
 
-// WriteResourceListResponse writes a list of resources response
-func WriteResourceListResponse(w http.ResponseWriter, status int, resources []models.Resource) {
-	count := len(resources)
+// WriteResourceListResponse writes a page of resources response
+func WriteResourceListResponse(w http.ResponseWriter, status int, resources []models.Resource, page *models.PageInfo) {
+	if resources == nil {
+		resources = []models.Resource{}
+	}
 	response := APIResponse{
 		Data: resources,
-		Meta: &Meta{
-			Timestamp: time.Now(),
-			Version:   "1.0",
-			Count:     &count,
-		},
+		Meta: listMeta(len(resources), page),
 	}
 	WriteJSONResponse(w, status, response)
 }
@@ -78,46 +103,165 @@ func WriteSchemaResponse(w http.ResponseWriter, status int, schema *models.Schem
 	WriteJSONResponse(w, status, response)
 }
 
-// WriteSchemaListResponse writes a list of schemas response
-func WriteSchemaListResponse(w http.ResponseWriter, status int, schemas []models.Schema) {
-	count := len(schemas)
+// WriteSchemaListResponse writes a page of schemas response
+func WriteSchemaListResponse(w http.ResponseWriter, status int, schemas []models.Schema, page *models.PageInfo) {
+	if schemas == nil {
+		schemas = []models.Schema{}
+	}
 	response := APIResponse{
 		Data: schemas,
+		Meta: listMeta(len(schemas), page),
+	}
+	WriteJSONResponse(w, status, response)
+}
+
+// WriteSearchResponse writes a page of search results response
+func WriteSearchResponse(w http.ResponseWriter, status int, results []models.Resource, page *models.PageInfo) {
+	if results == nil {
+		results = []models.Resource{}
+	}
+	response := APIResponse{
+		Data: results,
+		Meta: listMeta(len(results), page),
+	}
+	WriteJSONResponse(w, status, response)
+}
+
+// WriteSavedSearchResponse writes a saved search response
+func WriteSavedSearchResponse(w http.ResponseWriter, status int, search *models.SavedSearch) {
+	response := APIResponse{
+		Data: search,
 		Meta: &Meta{
 			Timestamp: time.Now(),
 			Version:   "1.0",
-			Count:     &count,
 		},
 	}
 	WriteJSONResponse(w, status, response)
 }
 
-// WriteSearchResponse writes a search result response
-func WriteSearchResponse(w http.ResponseWriter, status int, results []models.Resource) {
-	count := len(results)
+// WriteSavedSearchListResponse writes a list of saved searches response
+func WriteSavedSearchListResponse(w http.ResponseWriter, status int, searches []models.SavedSearch) {
+	if searches == nil {
+		searches = []models.SavedSearch{}
+	}
 	response := APIResponse{
-		Data: results,
+		Data: searches,
+		Meta: listMeta(len(searches), nil),
+	}
+	WriteJSONResponse(w, status, response)
+}
+
+// WriteProposalResponse writes a change proposal response
+func WriteProposalResponse(w http.ResponseWriter, status int, proposal *models.ChangeProposal) {
+	response := APIResponse{
+		Data: proposal,
+		Meta: &Meta{
+			Timestamp: time.Now(),
+			Version:   "1.0",
+		},
+	}
+	WriteJSONResponse(w, status, response)
+}
+
+// WriteProposalListResponse writes a list of change proposals response
+func WriteProposalListResponse(w http.ResponseWriter, status int, proposals []models.ChangeProposal) {
+	if proposals == nil {
+		proposals = []models.ChangeProposal{}
+	}
+	response := APIResponse{
+		Data: proposals,
+		Meta: listMeta(len(proposals), nil),
+	}
+	WriteJSONResponse(w, status, response)
+}
+
+// WriteChangeListResponse writes a page of change records response
+func WriteChangeListResponse(w http.ResponseWriter, status int, records []models.ChangeRecord, page *models.PageInfo) {
+	if records == nil {
+		records = []models.ChangeRecord{}
+	}
+	response := APIResponse{
+		Data: records,
+		Meta: listMeta(len(records), page),
+	}
+	WriteJSONResponse(w, status, response)
+}
+
+// WriteImportResponse writes the report of a bulk import. An import that
+// stopped early is reported with the rows handled before it stopped.
+func WriteImportResponse(w http.ResponseWriter, report *models.ImportReport, err error) {
+	status := http.StatusOK
+	response := APIResponse{
+		Data: report,
 		Meta: &Meta{
 			Timestamp: time.Now(),
 			Version:   "1.0",
-			Count:     &count,
+			Count:     &report.Total,
 		},
 	}
+	if err != nil {
+		status = http.StatusInternalServerError
+		response.Error = &APIError{
+			Code:    generateErrorCode(status),
+			Message: "Bulk import stopped",
+			Details: err.Error(),
+		}
+	}
 	WriteJSONResponse(w, status, response)
 }
 
+// WriteScanResponse writes the result of a provider scan. A scan in which a
+// provider failed is reported with what was persisted before the failure.
+func WriteScanResponse(w http.ResponseWriter, result map[string]interface{}, err error) {
+	status := http.StatusOK
+	response := APIResponse{
+		Data: result,
+		Meta: &Meta{
+			Timestamp: time.Now(),
+			Version:   "1.0",
+		},
+	}
+	if err != nil {
+		status = http.StatusBadGateway
+		response.Error = &APIError{
+			Code:    generateErrorCode(status),
+			Message: "Provider scan failed",
+			Details: err.Error(),
+		}
+	}
+	WriteJSONResponse(w, status, response)
+}
+
+// WriteDeduplicationResponse writes the report of a deduplication run
+func WriteDeduplicationResponse(w http.ResponseWriter, report *models.DeduplicationReport) {
+	response := APIResponse{
+		Data: report,
+		Meta: &Meta{
+			Timestamp: time.Now(),
+			Version:   "1.0",
+		},
+	}
+	WriteJSONResponse(w, http.StatusOK, response)
+}
+
 // WriteError writes a standardized error response
 func WriteError(w http.ResponseWriter, status int, message string, err error) {
 	var details string
+	var violations []jsonschema.Error
 	if err != nil {
 		details = err.Error()
+		var schemaErr *models.SchemaValidationError
+		if errors.As(err, &schemaErr) {
+			violations = schemaErr.Violations
+		}
 	}
 
 	response := APIResponse{
 		Error: &APIError{
-			Code:    generateErrorCode(status),
-			Message: message,
-			Details: details,
+			Code:       generateErrorCode(status),
+			Message:    message,
+			Details:    details,
+			Violations: violations,
 		},
 		Meta: &Meta{
 			Timestamp: time.Now(),
@@ -178,6 +322,6 @@ func generateErrorCode(status int) string {
 	case http.StatusInternalServerError:
 		return "E500"
 	default:
-		return "E" + string(rune(status))
+		return "E" + strconv.Itoa(status)
 	}
 }
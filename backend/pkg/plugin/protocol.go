@@ -0,0 +1,102 @@
+// Package plugin defines the contract between Siros and out-of-process
+// provider plugins, and the helpers a plugin binary uses to serve it.
+//
+// A plugin is an executable named siros-provider-<name> placed in the
+// configured plugins directory. Siros starts it and exchanges newline-delimited
+// JSON-RPC 2.0 messages over the plugin's stdin and stdout; anything the plugin
+// writes to stderr is passed through to the Siros log. The first call is always
+// describe, which negotiates the protocol version and hands the plugin its
+// configuration. Scan results are streamed to Siros as scan/resources
+// notifications before the scan call returns.
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// ProtocolVersion is the version of the plugin contract implemented by this package
+const ProtocolVersion = 1
+
+// JSON-RPC methods. Calls are sent by Siros to the plugin; notifications
+// flow in the direction noted.
+const (
+	MethodDescribe    = "describe"
+	MethodValidate    = "validate"
+	MethodScan        = "scan"
+	MethodGetResource = "get_resource"
+
+	// MethodScanResources is a plugin-to-host notification carrying a batch of scan results
+	MethodScanResources = "scan/resources"
+	// MethodCancel is a host-to-plugin notification cancelling an in-flight call
+	MethodCancel = "$/cancel"
+)
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeCancelled      = -32800
+	CodeNotFound       = -32001
+)
+
+// Message is a JSON-RPC 2.0 request, response or notification
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+// DescribeParams are sent with the describe call
+type DescribeParams struct {
+	ProtocolVersion int                    `json:"protocol_version"`
+	Config          map[string]interface{} `json:"config,omitempty"`
+}
+
+// Description identifies a plugin and the resources it provides
+type Description struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	ResourceTypes   []string `json:"resource_types,omitempty"`
+}
+
+// GetResourceParams are sent with the get_resource call
+type GetResourceParams struct {
+	ID string `json:"id"`
+}
+
+// ScanResult is the result of the scan call, sent after every batch
+type ScanResult struct {
+	Total int `json:"total"`
+}
+
+// ScanResources is the payload of a scan/resources notification
+type ScanResources struct {
+	RequestID int64            `json:"request_id"`
+	Resources []types.Resource `json:"resources"`
+}
+
+// CancelParams is the payload of a $/cancel notification
+type CancelParams struct {
+	ID int64 `json:"id"`
+}
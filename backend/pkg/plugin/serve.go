@@ -0,0 +1,209 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// ErrNotFound is returned by Provider.GetResource for unknown IDs
+var ErrNotFound = errors.New("resource not found")
+
+// Provider is implemented by plugin binaries
+type Provider interface {
+	// Describe configures the plugin and returns its description. It is
+	// called once per process before any other method.
+	Describe(ctx context.Context, config map[string]interface{}) (*Description, error)
+	// Validate checks the plugin's configuration and credentials
+	Validate(ctx context.Context) error
+	// Scan discovers resources, passing them to emit in batches as they are found
+	Scan(ctx context.Context, emit func(resources ...types.Resource) error) error
+	// GetResource returns a single resource, or ErrNotFound
+	GetResource(ctx context.Context, id string) (*types.Resource, error)
+}
+
+// Serve serves p over stdin and stdout until stdin is closed. Plugins must not
+// write anything else to stdout.
+func Serve(p Provider) error {
+	return ServeStreams(context.Background(), p, os.Stdin, os.Stdout)
+}
+
+// ServeStreams serves p over the given streams until in is exhausted or ctx is
+// cancelled. Calls are handled concurrently.
+func ServeStreams(ctx context.Context, p Provider, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &server{
+		provider: p,
+		enc:      json.NewEncoder(out),
+		inflight: make(map[int64]context.CancelFunc),
+	}
+	defer s.wg.Wait()
+
+	dec := json.NewDecoder(in)
+	for {
+		var msg Message
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			_ = s.send(Message{Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			return fmt.Errorf("failed to decode message: %w", err)
+		}
+
+		if msg.Method == MethodCancel {
+			var params CancelParams
+			if err := json.Unmarshal(msg.Params, &params); err == nil {
+				s.cancel(params.ID)
+			}
+			continue
+		}
+		if msg.ID == nil {
+			// Unknown notifications are ignored
+			continue
+		}
+
+		callCtx, callCancel := context.WithCancel(ctx)
+		s.track(*msg.ID, callCancel)
+		s.wg.Add(1)
+		go func(msg Message) {
+			defer s.wg.Done()
+			defer s.cancel(*msg.ID)
+			s.handle(callCtx, msg)
+		}(msg)
+	}
+}
+
+// server dispatches calls to a Provider and serializes writes
+type server struct {
+	provider Provider
+	wg       sync.WaitGroup
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu       sync.Mutex
+	inflight map[int64]context.CancelFunc
+}
+
+func (s *server) track(id int64, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight[id] = cancel
+}
+
+func (s *server) cancel(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.inflight[id]; ok {
+		cancel()
+		delete(s.inflight, id)
+	}
+}
+
+func (s *server) send(msg Message) error {
+	msg.JSONRPC = "2.0"
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.enc.Encode(msg)
+}
+
+// handle runs a single call and sends its response
+func (s *server) handle(ctx context.Context, msg Message) {
+	result, err := s.dispatch(ctx, msg)
+
+	response := Message{ID: msg.ID}
+	if err != nil {
+		response.Error = toError(ctx, err)
+	} else if response.Result, err = json.Marshal(result); err != nil {
+		response.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	_ = s.send(response)
+}
+
+func (s *server) dispatch(ctx context.Context, msg Message) (interface{}, error) {
+	switch msg.Method {
+	case MethodDescribe:
+		var params DescribeParams
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.ProtocolVersion != ProtocolVersion {
+			return nil, &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("unsupported protocol version %d, plugin speaks %d", params.ProtocolVersion, ProtocolVersion)}
+		}
+		desc, err := s.provider.Describe(ctx, params.Config)
+		if err != nil {
+			return nil, err
+		}
+		if desc.ProtocolVersion == 0 {
+			desc.ProtocolVersion = ProtocolVersion
+		}
+		return desc, nil
+
+	case MethodValidate:
+		return struct{}{}, s.provider.Validate(ctx)
+
+	case MethodScan:
+		total := 0
+		err := s.provider.Scan(ctx, func(resources ...types.Resource) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if len(resources) == 0 {
+				return nil
+			}
+			params, err := json.Marshal(ScanResources{RequestID: *msg.ID, Resources: resources})
+			if err != nil {
+				return err
+			}
+			total += len(resources)
+			return s.send(Message{Method: MethodScanResources, Params: params})
+		})
+		if err != nil {
+			return nil, err
+		}
+		return ScanResult{Total: total}, nil
+
+	case MethodGetResource:
+		var params GetResourceParams
+		if err := decodeParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.provider.GetResource(ctx, params.ID)
+
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+}
+
+func decodeParams(raw json.RawMessage, out interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// toError maps a provider error to a JSON-RPC error
+func toError(ctx context.Context, err error) *Error {
+	var rpcErr *Error
+	switch {
+	case errors.As(err, &rpcErr):
+		return rpcErr
+	case errors.Is(err, ErrNotFound):
+		return &Error{Code: CodeNotFound, Message: err.Error()}
+	case ctx.Err() != nil:
+		return &Error{Code: CodeCancelled, Message: ctx.Err().Error()}
+	default:
+		return &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+}
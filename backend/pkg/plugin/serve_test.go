@@ -0,0 +1,192 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/LederWorks/siros/backend/pkg/types"
+)
+
+// testProvider emits two batches on scan and blocks on "slow" lookups until cancelled
+type testProvider struct {
+	config map[string]interface{}
+}
+
+func (p *testProvider) Describe(_ context.Context, config map[string]interface{}) (*Description, error) {
+	p.config = config
+	return &Description{Name: "cmdb", Version: "1.2.0", ResourceTypes: []string{"cmdb.server"}}, nil
+}
+
+func (p *testProvider) Validate(context.Context) error {
+	if p.config["endpoint"] == nil {
+		return fmt.Errorf("endpoint is required")
+	}
+	return nil
+}
+
+func (p *testProvider) Scan(_ context.Context, emit func(...types.Resource) error) error {
+	if err := emit(types.Resource{ID: "srv-1", Type: "cmdb.server"}, types.Resource{ID: "srv-2", Type: "cmdb.server"}); err != nil {
+		return err
+	}
+	return emit(types.Resource{ID: "srv-3", Type: "cmdb.server"})
+}
+
+func (p *testProvider) GetResource(ctx context.Context, id string) (*types.Resource, error) {
+	switch id {
+	case "srv-1":
+		return &types.Resource{ID: id, Type: "cmdb.server"}, nil
+	case "slow":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	default:
+		return nil, ErrNotFound
+	}
+}
+
+// testClient drives ServeStreams over in-memory pipes
+type testClient struct {
+	t   *testing.T
+	enc *json.Encoder
+	dec *json.Decoder
+	id  int64
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ServeStreams(context.Background(), &testProvider{}, inR, outW)
+		_ = outW.Close()
+	}()
+	t.Cleanup(func() {
+		_ = inW.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("ServeStreams failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("ServeStreams did not return after stdin was closed")
+		}
+	})
+	return &testClient{t: t, enc: json.NewEncoder(inW), dec: json.NewDecoder(outR)}
+}
+
+func (c *testClient) send(method string, params interface{}) int64 {
+	c.t.Helper()
+	c.id++
+	id := c.id
+	raw, _ := json.Marshal(params)
+	if err := c.enc.Encode(Message{JSONRPC: "2.0", ID: &id, Method: method, Params: raw}); err != nil {
+		c.t.Fatalf("failed to send: %v", err)
+	}
+	return id
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	raw, _ := json.Marshal(params)
+	if err := c.enc.Encode(Message{JSONRPC: "2.0", Method: method, Params: raw}); err != nil {
+		c.t.Fatalf("failed to send: %v", err)
+	}
+}
+
+func (c *testClient) receive() Message {
+	c.t.Helper()
+	var msg Message
+	if err := c.dec.Decode(&msg); err != nil {
+		c.t.Fatalf("failed to receive: %v", err)
+	}
+	return msg
+}
+
+func TestServe_Describe(t *testing.T) {
+	client := newTestClient(t)
+
+	id := client.send(MethodDescribe, DescribeParams{ProtocolVersion: ProtocolVersion, Config: map[string]interface{}{"endpoint": "https://cmdb"}})
+	msg := client.receive()
+	if msg.ID == nil || *msg.ID != id || msg.Error != nil {
+		t.Fatalf("Unexpected response: %+v", msg)
+	}
+	var desc Description
+	if err := json.Unmarshal(msg.Result, &desc); err != nil {
+		t.Fatalf("invalid description: %v", err)
+	}
+	if desc.Name != "cmdb" || desc.ProtocolVersion != ProtocolVersion {
+		t.Errorf("Unexpected description: %+v", desc)
+	}
+
+	client.send(MethodValidate, nil)
+	if msg := client.receive(); msg.Error != nil {
+		t.Errorf("Expected configuration to be passed to the plugin, got %v", msg.Error)
+	}
+}
+
+func TestServe_RejectsProtocolVersion(t *testing.T) {
+	client := newTestClient(t)
+
+	client.send(MethodDescribe, DescribeParams{ProtocolVersion: ProtocolVersion + 1})
+	msg := client.receive()
+	if msg.Error == nil || msg.Error.Code != CodeInvalidRequest {
+		t.Errorf("Expected an unsupported version error, got %+v", msg)
+	}
+}
+
+func TestServe_ScanStreamsBatches(t *testing.T) {
+	client := newTestClient(t)
+
+	id := client.send(MethodScan, nil)
+	var streamed int
+	for {
+		msg := client.receive()
+		if msg.Method == MethodScanResources {
+			var batch ScanResources
+			if err := json.Unmarshal(msg.Params, &batch); err != nil || batch.RequestID != id {
+				t.Fatalf("Unexpected batch: %s", msg.Params)
+			}
+			streamed += len(batch.Resources)
+			continue
+		}
+
+		var result ScanResult
+		if err := json.Unmarshal(msg.Result, &result); err != nil {
+			t.Fatalf("invalid scan result: %v", err)
+		}
+		if result.Total != 3 || streamed != 3 {
+			t.Errorf("Expected 3 streamed resources, got %d streamed and total %d", streamed, result.Total)
+		}
+		return
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	client := newTestClient(t)
+
+	client.send(MethodGetResource, GetResourceParams{ID: "missing"})
+	if msg := client.receive(); msg.Error == nil || msg.Error.Code != CodeNotFound {
+		t.Errorf("Expected not found error, got %+v", msg)
+	}
+
+	client.send("delete_everything", nil)
+	if msg := client.receive(); msg.Error == nil || msg.Error.Code != CodeMethodNotFound {
+		t.Errorf("Expected method not found error, got %+v", msg)
+	}
+}
+
+func TestServe_Cancel(t *testing.T) {
+	client := newTestClient(t)
+
+	slow := client.send(MethodGetResource, GetResourceParams{ID: "slow"})
+	client.notify(MethodCancel, CancelParams{ID: slow})
+
+	msg := client.receive()
+	if msg.ID == nil || *msg.ID != slow || msg.Error == nil || msg.Error.Code != CodeCancelled {
+		t.Errorf("Expected the slow call to be cancelled, got %+v", msg)
+	}
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"time"
 )
 
@@ -24,6 +25,14 @@ type Resource struct {
 	LastScannedAt *time.Time             `json:"last_scanned_at" db:"last_scanned_at"`
 }
 
+// Metadata keys naming the provider and account of a resource's parent when
+// it is at another provider than the resource, as for a Kubernetes cluster
+// running on EKS, GKE or AKS
+const (
+	MetadataParentProvider = "parent_provider"
+	MetadataParentAccount  = "parent_account"
+)
+
 // ResourceState represents the current state of a resource
 type ResourceState string
 
@@ -90,11 +99,19 @@ type SearchResult struct {
 // Provider represents a cloud service provider
 type Provider interface {
 	Name() string
-	Scan(ctx interface{}) ([]Resource, error)
+	Scan(ctx context.Context) ([]Resource, error)
 	GetResource(id string) (*Resource, error)
 	Validate() error
 }
 
+// StreamingProvider is a Provider that can send resources as they are
+// discovered instead of returning them all at once. ScanStream blocks while
+// out is full, so a slow consumer throttles the scan. It must not close out.
+type StreamingProvider interface {
+	Provider
+	ScanStream(ctx context.Context, out chan<- Resource) error
+}
+
 // APIResponse represents a standard API response
 type APIResponse struct {
 	Success bool        `json:"success"`
@@ -121,6 +121,62 @@ export async function deleteResource(id: string): Promise<void> {
   }
 }
 
+export interface ChangeProposal {
+  id: string
+  operation: 'CREATE' | 'UPDATE'
+  resource_id?: string
+  diff: Record<string, { old: any; new: any }>
+  warnings?: string[]
+  reason: string
+  status: 'pending' | 'approved' | 'applied' | 'failed' | 'rejected'
+  proposed_by: string
+  reviewed_by?: string
+  review_note?: string
+  error?: string
+  created_at: string
+  reviewed_at?: string
+}
+
+export async function fetchProposals(status?: ChangeProposal['status']): Promise<ChangeProposal[]> {
+  const params = new URLSearchParams()
+  if (status) {
+    params.append('status', status)
+  }
+
+  const response = await fetch(`${API_BASE}/proposals?${params}`)
+  if (!response.ok) {
+    throw new Error('Failed to fetch change proposals')
+  }
+
+  const result = await response.json()
+  return result.data || []
+}
+
+// reviewProposal approves or rejects a change proposal on behalf of user,
+// who must not be the proposal's proposer. The user is sent in the server's
+// trusted reviewer header, X-User by default.
+export async function reviewProposal(
+  id: string,
+  decision: 'approve' | 'reject',
+  user: string,
+  note?: string,
+): Promise<ChangeProposal> {
+  const response = await fetch(`${API_BASE}/proposals/${encodeURIComponent(id)}/${decision}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      'X-User': user,
+    },
+    body: JSON.stringify({ note }),
+  })
+
+  const result = await response.json()
+  if (!response.ok) {
+    throw new Error(result.error?.details || result.error?.message || `Failed to ${decision} change proposal`)
+  }
+  return result.data
+}
+
 export async function healthCheck(): Promise<any> {
   const response = await fetch(`${API_BASE}/health`)
   if (!response.ok) {
@@ -255,38 +255,29 @@ function Test-MCPEndpoints {
 
     $results = @()
 
-    # Initialize MCP
+    # Initialize an MCP session on the JSON-RPC endpoint. Later requests
+    # need the Mcp-Session-Id header it returns; the Go tests script a
+    # whole session.
     $mcpInit = @{
-        protocolVersion = "2024-11-05"
-        capabilities    = @{
-            roots    = @{
-                listChanged = $true
+        jsonrpc = "2.0"
+        id      = 1
+        method  = "initialize"
+        params  = @{
+            protocolVersion = "2025-06-18"
+            capabilities    = @{
+                roots    = @{
+                    listChanged = $true
+                }
+                sampling = @{}
+            }
+            clientInfo      = @{
+                name    = "siros-api-test"
+                version = "1.0.0"
             }
-            sampling = @{}
-        }
-        clientInfo      = @{
-            name    = "siros-api-test"
-            version = "1.0.0"
         }
     } | ConvertTo-Json -Depth 10
 
-    $results += Test-ApiEndpoint -Method "POST" -Url "$BaseUrl/api/v1/mcp/initialize" -Description "MCP Initialize" -Body $mcpInit
-
-    # List MCP resources
-    $mcpListResources = @{
-        method = "resources/list"
-        params = @{}
-    } | ConvertTo-Json -Depth 10
-
-    $results += Test-ApiEndpoint -Method "POST" -Url "$BaseUrl/api/v1/mcp/resources/list" -Description "MCP List Resources" -Body $mcpListResources
-
-    # List MCP tools
-    $mcpListTools = @{
-        method = "tools/list"
-        params = @{}
-    } | ConvertTo-Json -Depth 10
-
-    $results += Test-ApiEndpoint -Method "POST" -Url "$BaseUrl/api/v1/mcp/tools/list" -Description "MCP List Tools" -Body $mcpListTools
+    $results += Test-ApiEndpoint -Method "POST" -Url "$BaseUrl/api/v1/mcp" -Description "MCP Initialize" -Body $mcpInit
 
     return $results
 }
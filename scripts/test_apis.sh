@@ -271,47 +271,32 @@ test_mcp_endpoints() {
 
     local results=0
 
-    # Initialize MCP
+    # Initialize an MCP session on the JSON-RPC endpoint. Later requests
+    # need the Mcp-Session-Id header it returns; the Go tests script a
+    # whole session.
     local mcp_init=$(cat <<EOF
 {
-    "protocolVersion": "2024-11-05",
-    "capabilities": {
-        "roots": {
-            "listChanged": true
+    "jsonrpc": "2.0",
+    "id": 1,
+    "method": "initialize",
+    "params": {
+        "protocolVersion": "2025-06-18",
+        "capabilities": {
+            "roots": {
+                "listChanged": true
+            },
+            "sampling": {}
         },
-        "sampling": {}
-    },
-    "clientInfo": {
-        "name": "siros-api-test",
-        "version": "1.0.0"
+        "clientInfo": {
+            "name": "siros-api-test",
+            "version": "1.0.0"
+        }
     }
 }
 EOF
 )
 
-    test_endpoint "POST" "$BASE_URL/api/v1/mcp/initialize" "MCP Initialize" "$mcp_init" || ((results++))
-
-    # List MCP resources
-    local mcp_list_resources=$(cat <<EOF
-{
-    "method": "resources/list",
-    "params": {}
-}
-EOF
-)
-
-    test_endpoint "POST" "$BASE_URL/api/v1/mcp/resources/list" "MCP List Resources" "$mcp_list_resources" || ((results++))
-
-    # List MCP tools
-    local mcp_list_tools=$(cat <<EOF
-{
-    "method": "tools/list",
-    "params": {}
-}
-EOF
-)
-
-    test_endpoint "POST" "$BASE_URL/api/v1/mcp/tools/list" "MCP List Tools" "$mcp_list_tools" || ((results++))
+    test_endpoint "POST" "$BASE_URL/api/v1/mcp" "MCP Initialize" "$mcp_init" || ((results++))
 
     return $results
 }